@@ -0,0 +1,328 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/config"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/web"
+)
+
+// Exit codes of `onwatch check`. They are part of its documented interface;
+// scripts branch on them, so never renumber.
+const (
+	checkExitOK          = 0 // every matched quota is at or under --max
+	checkExitOver        = 1 // at least one matched quota is over --max
+	checkExitUsage       = 2 // bad flags, or no quota matched the filters
+	checkExitUnavailable = 3 // neither the instance nor the database could be read
+)
+
+// defaultCheckMax is the used-percent threshold when --max is not given.
+const defaultCheckMax = 90.0
+
+// exitCodeError makes run() exit with a specific status. A nil err exits
+// silently, for results already reported on stdout.
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error { return e.err }
+
+// checkCLIOptions holds the flags accepted by `onwatch check`.
+type checkCLIOptions struct {
+	Providers []string
+	Quotas    []string
+	Max       float64
+	JSON      bool
+	Help      bool
+}
+
+// checkResult is the --json output of `onwatch check`. Fields are only ever
+// added, never renamed or removed.
+type checkResult struct {
+	Status    string       `json:"status"` // "ok", "over" or "no_match"
+	Source    string       `json:"source"` // "instance" or "database"
+	Max       float64      `json:"max"`
+	CheckedAt time.Time    `json:"checked_at"`
+	Quotas    []checkQuota `json:"quotas"`
+}
+
+// checkQuota is one quota compared against --max.
+type checkQuota struct {
+	ID               string  `json:"id"`
+	Provider         string  `json:"provider"`
+	Quota            string  `json:"quota"`
+	Label            string  `json:"label"`
+	UsedPercent      float64 `json:"used_percent"`
+	RemainingPercent float64 `json:"remaining_percent"`
+	ResetAt          string  `json:"reset_at,omitempty"`
+	Over             bool    `json:"over"`
+}
+
+// parseCheckArgs reads the check flags from the command-line arguments. Of the
+// global flags only --db and --port are accepted, since they are the ones
+// config.Load applies to a check; anything else is a usage error rather than
+// being silently ignored by a script's quota gate.
+func parseCheckArgs(args []string) (checkCLIOptions, error) {
+	opts := checkCLIOptions{Max: defaultCheckMax}
+	sawCommand := false
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		switch name {
+		case "--json":
+			opts.JSON = true
+		case "--help", "-h":
+			opts.Help = true
+		case "--provider", "--quota", "--max", "--db", "--port":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return opts, fmt.Errorf("%s needs a value", name)
+				}
+				i++
+				value = args[i]
+			}
+			switch name {
+			case "--provider":
+				opts.Providers = append(opts.Providers, splitCheckList(value)...)
+			case "--quota":
+				opts.Quotas = append(opts.Quotas, splitCheckList(value)...)
+			case "--max":
+				v, err := strconv.ParseFloat(value, 64)
+				if err != nil || v < 0 || v > 100 {
+					return opts, fmt.Errorf("--max must be a percentage between 0 and 100, got %q", value)
+				}
+				opts.Max = v
+			case "--port":
+				if v, err := strconv.Atoi(value); err != nil || v <= 0 {
+					return opts, fmt.Errorf("--port must be a positive number, got %q", value)
+				}
+			case "--db":
+				if value == "" {
+					return opts, fmt.Errorf("--db needs a value")
+				}
+			}
+		case "check":
+			if sawCommand {
+				return opts, fmt.Errorf("unexpected argument %q", args[i])
+			}
+			sawCommand = true
+		default:
+			if strings.HasPrefix(name, "-") {
+				return opts, fmt.Errorf("unknown flag %q (see 'onwatch check --help')", name)
+			}
+			return opts, fmt.Errorf("unexpected argument %q", args[i])
+		}
+	}
+	return opts, nil
+}
+
+func splitCheckList(value string) []string {
+	var out []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// runCheckCommand handles `onwatch check`. It reads quota usage from the
+// running instance, or from the database when --db is given or the instance
+// cannot be reached, and exits non-zero when a quota is over --max.
+func runCheckCommand() error {
+	opts, err := parseCheckArgs(os.Args[1:])
+	if err != nil {
+		return &exitCodeError{code: checkExitUsage, err: err}
+	}
+	if opts.Help {
+		printCheckHelp()
+		return nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return &exitCodeError{code: checkExitUsage, err: fmt.Errorf("failed to load config: %w", err)}
+	}
+
+	query := url.Values{}
+	if len(opts.Providers) > 0 {
+		query.Set("provider", strings.Join(opts.Providers, ","))
+	}
+	var report *web.HeadroomReport
+	source := "database"
+	if !cfg.DBPathExplicit {
+		endpoint := fmt.Sprintf("http://localhost:%d%s/api/headroom", cfg.Port, cfg.BasePath)
+		if report, _, err = fetchHeadroom(endpoint, query); err == nil {
+			source = "instance"
+		}
+	}
+	if report == nil {
+		if report, err = readStoredHeadroom(cfg.DBPath, opts.Providers); err != nil {
+			return &exitCodeError{code: checkExitUnavailable, err: err}
+		}
+	}
+
+	result := evaluateCheck(report, opts, source)
+	if opts.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	} else if err := printCheckResult(os.Stdout, result); err != nil {
+		return err
+	}
+
+	switch result.Status {
+	case "over":
+		return &exitCodeError{code: checkExitOver}
+	case "no_match":
+		return &exitCodeError{code: checkExitUsage}
+	}
+	return nil
+}
+
+// readStoredHeadroom opens the database read path used when no instance
+// answers. A missing file is reported rather than created.
+func readStoredHeadroom(dbPath string, providers []string) (*web.HeadroomReport, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("onwatch is not running and no database exists at %s", dbPath)
+		}
+		return nil, fmt.Errorf("failed to access database: %w", err)
+	}
+	s, err := store.New(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer s.Close()
+	return web.BuildStoredHeadroom(s, web.HeadroomFilter{Providers: providers}, time.Now().UTC())
+}
+
+// evaluateCheck compares every quota matching the filters against opts.Max.
+// A provider matches by name ("codex") or entry ID ("codex:2"); a quota by
+// key ("five_hour") or label ("5-Hour Limit"), case-insensitively.
+func evaluateCheck(report *web.HeadroomReport, opts checkCLIOptions, source string) checkResult {
+	result := checkResult{Status: "ok", Source: source, Max: opts.Max, CheckedAt: report.GeneratedAt, Quotas: []checkQuota{}}
+	for _, e := range report.Providers {
+		if len(opts.Providers) > 0 && !checkListHas(opts.Providers, e.Provider, e.ID) {
+			continue
+		}
+		for _, q := range e.Quotas {
+			if len(opts.Quotas) > 0 && !checkListHas(opts.Quotas, q.Key, q.Label) {
+				continue
+			}
+			over := q.UsedPercent > opts.Max
+			result.Quotas = append(result.Quotas, checkQuota{
+				ID:               e.ID,
+				Provider:         e.Provider,
+				Quota:            q.Key,
+				Label:            q.Label,
+				UsedPercent:      q.UsedPercent,
+				RemainingPercent: q.RemainingPercent,
+				ResetAt:          q.ResetAt,
+				Over:             over,
+			})
+			if over {
+				result.Status = "over"
+			}
+		}
+	}
+	if len(result.Quotas) == 0 {
+		result.Status = "no_match"
+	}
+	return result
+}
+
+func checkListHas(list []string, values ...string) bool {
+	for _, want := range list {
+		for _, v := range values {
+			if strings.EqualFold(want, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// printCheckResult prints one line per checked quota and a summary line.
+func printCheckResult(w io.Writer, result checkResult) error {
+	if result.Status == "no_match" {
+		_, err := fmt.Fprintln(w, "No quotas match the given --provider/--quota.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tID\tQUOTA\tUSED\tMAX")
+	over := 0
+	for _, q := range result.Quotas {
+		status := "ok"
+		if q.Over {
+			status = "OVER"
+			over++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.1f%%\t%.0f%%\n", status, q.ID, q.Label, q.UsedPercent, result.Max)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if over > 0 {
+		_, err := fmt.Fprintf(w, "%d of %d quotas over %.0f%% (source: %s)\n", over, len(result.Quotas), result.Max, result.Source)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "All %d quotas within %.0f%% (source: %s)\n", len(result.Quotas), result.Max, result.Source)
+	return err
+}
+
+// printCheckHelp prints help for the check command, including its exit codes.
+func printCheckHelp() {
+	fmt.Println("Quota Check")
+	fmt.Println()
+	fmt.Println("Usage: onwatch check [--provider NAME] [--quota NAME] [--max PERCENT] [--json] [--db PATH] [--port PORT]")
+	fmt.Println()
+	fmt.Println("Exits non-zero when a quota is used beyond --max, so scripts and hooks")
+	fmt.Println("can skip expensive runs when limits are nearly exhausted.")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --provider NAME  Provider name (anthropic) or account ID (codex:2); comma-separated")
+	fmt.Println("  --quota NAME     Quota key (five_hour) or label (\"5-Hour Limit\"); comma-separated")
+	fmt.Println("  --max PERCENT    Highest allowed used percentage (default: 90)")
+	fmt.Println("  --json           Print the result as JSON")
+	fmt.Println("  --db PATH        Read this database directly instead of the running instance")
+	fmt.Println("  --port PORT      Port of the running instance (default: from config)")
+	fmt.Println()
+	fmt.Println("Without --db the running instance is asked first; if it cannot be reached the")
+	fmt.Println("default database is read instead. Database reads use each quota's latest")
+	fmt.Println("sample from the last 24 hours and carry no reset times.")
+	fmt.Println()
+	fmt.Println("Exit codes:")
+	fmt.Println("  0  Every matched quota is at or under --max")
+	fmt.Println("  1  At least one matched quota is over --max")
+	fmt.Println("  2  Invalid flags, or no quota matched --provider/--quota")
+	fmt.Println("  3  Neither the running instance nor the database could be read")
+	fmt.Println()
+	fmt.Println("JSON output:")
+	fmt.Println("  {\"status\": \"ok|over|no_match\", \"source\": \"instance|database\", \"max\": 85,")
+	fmt.Println("   \"checked_at\": \"...\", \"quotas\": [{\"id\", \"provider\", \"quota\", \"label\",")
+	fmt.Println("   \"used_percent\", \"remaining_percent\", \"reset_at\", \"over\"}]}")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  onwatch check --provider anthropic --quota five_hour --max 85")
+	fmt.Println("  onwatch check --provider codex --max 95 --json")
+	fmt.Println("  onwatch check --max 80 || echo \"limits nearly exhausted, skipping\"")
+}
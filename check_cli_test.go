@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/onllm-dev/onwatch/v2/internal/web"
+)
+
+func TestParseCheckArgs(t *testing.T) {
+	opts, err := parseCheckArgs([]string{"check", "--provider", "anthropic", "--quota=five_hour,Weekly", "--max", "85", "--json"})
+	if err != nil {
+		t.Fatalf("parseCheckArgs: %v", err)
+	}
+	if !opts.JSON || opts.Max != 85 {
+		t.Errorf("JSON/Max = %v/%v, want true/85", opts.JSON, opts.Max)
+	}
+	if strings.Join(opts.Providers, ",") != "anthropic" || strings.Join(opts.Quotas, ",") != "five_hour,weekly" {
+		t.Errorf("providers %v, quotas %v", opts.Providers, opts.Quotas)
+	}
+
+	if opts, _ := parseCheckArgs([]string{"check"}); opts.Max != defaultCheckMax {
+		t.Errorf("default Max = %v, want %v", opts.Max, defaultCheckMax)
+	}
+	// The global --db and --port flags are accepted and left to config.Load.
+	if _, err := parseCheckArgs([]string{"--db", "/tmp/onwatch.db", "check", "--port=9300"}); err != nil {
+		t.Errorf("global flags: %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"check", "--max", "120"},
+		{"check", "--max=abc"},
+		{"check", "--quota"},
+		{"check", "--port", "web"},
+		// A typo must fail the gate instead of checking every quota.
+		{"check", "--provder", "anthropic"},
+		{"check", "--interval", "60"},
+		{"check", "--debug"},
+		{"check", "anthropic"},
+		{"check", "check"},
+	} {
+		if _, err := parseCheckArgs(args); err == nil {
+			t.Errorf("parseCheckArgs(%v) should fail", args)
+		}
+	}
+}
+
+func TestRunCheckCommand_UnknownFlagIsUsageError(t *testing.T) {
+	origArgs := os.Args
+	t.Cleanup(func() { os.Args = origArgs })
+	os.Args = []string{"onwatch", "check", "--provder", "anthropic"}
+
+	err := runCheckCommand()
+	var exitErr *exitCodeError
+	if !errors.As(err, &exitErr) || exitErr.code != checkExitUsage {
+		t.Fatalf("runCheckCommand() = %v, want exit code %d", err, checkExitUsage)
+	}
+	if !strings.Contains(err.Error(), `unknown flag "--provder"`) {
+		t.Errorf("error = %q, want it to name the unknown flag", err)
+	}
+}
+
+func TestEvaluateCheck(t *testing.T) {
+	report := &web.HeadroomReport{Providers: []web.HeadroomEntry{
+		{ID: "anthropic", Provider: "anthropic", Quotas: []web.HeadroomQuota{
+			{Key: "five_hour", Label: "5-Hour Limit", UsedPercent: 88, RemainingPercent: 12},
+			{Key: "seven_day", Label: "Weekly All-Model", UsedPercent: 40, RemainingPercent: 60},
+		}},
+		{ID: "codex:2", Provider: "codex", Quotas: []web.HeadroomQuota{
+			{Key: "five_hour", Label: "5-Hour Limit", UsedPercent: 30, RemainingPercent: 70},
+		}},
+	}}
+
+	for _, tc := range []struct {
+		name      string
+		providers []string
+		quotas    []string
+		max       float64
+		status    string
+		count     int
+	}{
+		{"over on five_hour", []string{"anthropic"}, []string{"five_hour"}, 85, "over", 1},
+		{"at the threshold is ok", []string{"anthropic"}, []string{"5-hour limit"}, 88, "ok", 1},
+		{"account ID", []string{"codex:2"}, nil, 85, "ok", 1},
+		{"all quotas", nil, nil, 85, "over", 3},
+		{"unknown quota", []string{"anthropic"}, []string{"monthly"}, 85, "no_match", 0},
+	} {
+		result := evaluateCheck(report, checkCLIOptions{Providers: tc.providers, Quotas: tc.quotas, Max: tc.max}, "instance")
+		if result.Status != tc.status || len(result.Quotas) != tc.count {
+			t.Errorf("%s: status %s with %d quotas, want %s with %d", tc.name, result.Status, len(result.Quotas), tc.status, tc.count)
+		}
+	}
+
+	var buf bytes.Buffer
+	result := evaluateCheck(report, checkCLIOptions{Providers: []string{"anthropic"}, Max: 85}, "database")
+	if err := printCheckResult(&buf, result); err != nil {
+		t.Fatalf("printCheckResult: %v", err)
+	}
+	for _, want := range []string{"OVER", "88.0%", "1 of 2 quotas over 85% (source: database)"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestReadStoredHeadroom_MissingDatabase(t *testing.T) {
+	_, err := readStoredHeadroom(t.TempDir()+"/missing.db", nil)
+	if err == nil || !strings.Contains(err.Error(), "no database exists") {
+		t.Errorf("err = %v, want missing database error", err)
+	}
+}
@@ -16,9 +16,9 @@ import (
 	"time"
 
 	"github.com/onllm-dev/onwatch/v2/internal/api"
-	apiintegrations "github.com/onllm-dev/onwatch/v2/internal/api_integrations"
 	"github.com/onllm-dev/onwatch/v2/internal/metrics"
 	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
 )
 
 type check struct {
@@ -285,7 +285,7 @@ func seedAll(s *store.Store, now, future time.Time) error {
 	cost2 := 0.03
 	lat1 := 320
 	lat2 := 210
-	events := []*apiintegrations.UsageEvent{
+	events := []*usageevent.Event{
 		{
 			Timestamp:    now,
 			Integration:  "claude-api",
@@ -37,13 +37,20 @@ def main() -> None:
     # --- onWatch block to copy --------------------------------------------
     # Add this block after your real API call returns.
     # Map the provider response usage fields into the normalised onWatch event.
+    # Anthropic's input_tokens excludes cached tokens, so add them back.
+    usage = response.usage
+    cache_read = getattr(usage, "cache_read_input_tokens", None) or 0
+    cache_write = getattr(usage, "cache_creation_input_tokens", None) or 0
+    prompt_tokens = usage.input_tokens + cache_read + cache_write
     output_path = append_usage_event(
         integration="notes-organiser",
         provider="anthropic",
         model=response.model,
-        prompt_tokens=response.usage.input_tokens,
-        completion_tokens=response.usage.output_tokens,
-        total_tokens=response.usage.input_tokens + response.usage.output_tokens,
+        prompt_tokens=prompt_tokens,
+        completion_tokens=usage.output_tokens,
+        total_tokens=prompt_tokens + usage.output_tokens,
+        cache_read_tokens=cache_read,
+        cache_write_tokens=cache_write,
         request_id=getattr(response, "id", None),
         metadata={"example": True},
     )
@@ -34,6 +34,10 @@ def append_usage_event(
     prompt_tokens: int,
     completion_tokens: int,
     total_tokens: int | None = None,
+    cache_read_tokens: int | None = None,
+    cache_write_tokens: int | None = None,
+    reasoning_tokens: int | None = None,
+    audio_tokens: int | None = None,
     account: str | None = None,
     request_id: str | None = None,
     cost_usd: float | None = None,
@@ -50,7 +54,9 @@ def append_usage_event(
     - prompt_tokens
     - completion_tokens
 
-    Optional fields are only written when present.
+    Optional fields are only written when present. Cache read and write
+    tokens are part of prompt_tokens, reasoning tokens part of
+    completion_tokens.
     """
     event = {
         "ts": datetime.now(timezone.utc).replace(microsecond=0).isoformat().replace("+00:00", "Z"),
@@ -62,6 +68,14 @@ def append_usage_event(
     }
     if total_tokens is not None:
         event["total_tokens"] = int(total_tokens)
+    for key, value in (
+        ("cache_read_tokens", cache_read_tokens),
+        ("cache_write_tokens", cache_write_tokens),
+        ("reasoning_tokens", reasoning_tokens),
+        ("audio_tokens", audio_tokens),
+    ):
+        if value is not None:
+            event[key] = int(value)
     if account:
         event["account"] = account
     if request_id:
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/config"
+	"github.com/onllm-dev/onwatch/v2/internal/web"
+)
+
+// headroomCLIOptions holds the flags accepted by `onwatch headroom`.
+type headroomCLIOptions struct {
+	JSON  bool
+	Best  bool
+	Query url.Values
+}
+
+// parseHeadroomArgs reads the headroom flags from the arguments after the
+// subcommand. Filters are passed through to /api/headroom.
+func parseHeadroomArgs(args []string) headroomCLIOptions {
+	opts := headroomCLIOptions{Query: url.Values{}}
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		switch name {
+		case "--json":
+			opts.JSON = true
+		case "--best":
+			opts.Best = true
+		case "--provider", "--category", "--min-remaining":
+			if !hasValue && i+1 < len(args) {
+				i++
+				value = args[i]
+			}
+			if value != "" {
+				opts.Query.Set(strings.ReplaceAll(strings.TrimPrefix(name, "--"), "-", "_"), value)
+			}
+		}
+	}
+	return opts
+}
+
+// runHeadroomCommand handles `onwatch headroom`. It asks the running instance
+// for the headroom ranking over localhost and prints it.
+func runHeadroomCommand() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	opts := parseHeadroomArgs(os.Args[1:])
+	endpoint := fmt.Sprintf("http://localhost:%d%s/api/headroom", cfg.Port, cfg.BasePath)
+	report, raw, err := fetchHeadroom(endpoint, opts.Query)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case opts.JSON:
+		_, err = os.Stdout.Write(raw)
+		return err
+	case opts.Best:
+		if report.Best == "" {
+			return fmt.Errorf("no provider has headroom left")
+		}
+		fmt.Println(report.Best)
+		return nil
+	default:
+		return printHeadroomTable(os.Stdout, report, time.Now())
+	}
+}
+
+func fetchHeadroom(endpoint string, query url.Values) (*web.HeadroomReport, []byte, error) {
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(endpoint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not reach onwatch (is it running? try 'onwatch status'): %w", err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read headroom response: %w", err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, nil, fmt.Errorf("headroom request needs a login: /api/headroom is only open to localhost when onwatch runs without a base path")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("headroom request returned %s", resp.Status)
+	}
+	var report web.HeadroomReport
+	if err := json.Unmarshal(raw, &report); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse headroom response: %w", err)
+	}
+	return &report, raw, nil
+}
+
+// printHeadroomTable prints one row per provider, best first.
+func printHeadroomTable(w io.Writer, report *web.HeadroomReport, now time.Time) error {
+	if len(report.Providers) == 0 {
+		_, err := fmt.Fprintln(w, "No providers match.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RANK\tID\tPROVIDER\tLEFT\tBINDING QUOTA\tRESETS IN\tFORECAST")
+	for _, e := range report.Providers {
+		forecast := "lasts until reset"
+		switch {
+		case !e.Available:
+			forecast = "exhausted"
+		case e.ExhaustsBeforeReset:
+			forecast = "runs out before reset"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%.0f%%\t%s\t%s\t%s\n",
+			e.Rank, e.ID, e.Label, e.RemainingPercent, e.BindingQuota, headroomResetIn(e.ResetAt, now), forecast)
+	}
+	return tw.Flush()
+}
+
+func headroomResetIn(resetAt string, now time.Time) string {
+	t, err := time.Parse(time.RFC3339, resetAt)
+	if err != nil || !t.After(now) {
+		return "-"
+	}
+	d := t.Sub(now).Round(time.Minute)
+	if d >= 24*time.Hour {
+		return fmt.Sprintf("%dd %dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+	return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+}
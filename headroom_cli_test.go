@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseHeadroomArgs(t *testing.T) {
+	opts := parseHeadroomArgs([]string{"headroom", "--category", "coding", "--provider=anthropic,codex", "--min-remaining", "20", "--best"})
+	if !opts.Best || opts.JSON {
+		t.Errorf("Best/JSON = %v/%v, want true/false", opts.Best, opts.JSON)
+	}
+	if got := opts.Query.Encode(); got != "category=coding&min_remaining=20&provider=anthropic%2Ccodex" {
+		t.Errorf("query = %s", got)
+	}
+}
+
+func TestFetchHeadroomAndPrint(t *testing.T) {
+	now := time.Date(2026, 4, 6, 12, 0, 0, 0, time.UTC)
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		w.Write([]byte(`{"best":"codex:1","providers":[
+			{"rank":1,"id":"codex:1","label":"Codex","available":true,"remaining_percent":82,"binding_quota":"5-Hour Limit","reset_at":"2026-04-06T15:30:00Z"},
+			{"rank":2,"id":"anthropic","label":"Anthropic","available":true,"remaining_percent":40,"binding_quota":"Weekly","reset_at":"2026-04-09T12:00:00Z","exhausts_before_reset":true}
+		]}`))
+	}))
+	defer srv.Close()
+
+	opts := parseHeadroomArgs([]string{"--category", "coding"})
+	report, _, err := fetchHeadroom(srv.URL+"/api/headroom", opts.Query)
+	if err != nil {
+		t.Fatalf("fetchHeadroom: %v", err)
+	}
+	if gotQuery != "category=coding" || report.Best != "codex:1" || len(report.Providers) != 2 {
+		t.Fatalf("query %q, report %+v", gotQuery, report)
+	}
+
+	var buf bytes.Buffer
+	if err := printHeadroomTable(&buf, report, now); err != nil {
+		t.Fatalf("printHeadroomTable: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"codex:1", "82%", "3h 30m", "lasts until reset", "3d 0h", "runs out before reset"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -697,6 +697,7 @@ processResponse:
 				Provider:    "anthropic",
 				QuotaKey:    q.Name,
 				Utilization: q.Utilization,
+				Pacing:      tracker.QuotaPacing(q.Name, q.Utilization, q.ResetsAt, now),
 			})
 		}
 	}
@@ -8,12 +8,12 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"time"
 
 	apiintegrations "github.com/onllm-dev/onwatch/v2/internal/api_integrations"
 	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
 )
 
 const (
@@ -24,18 +24,43 @@ const (
 	apiIntegrationPruneIntervalDefault                 = time.Hour
 )
 
+// errAPIIntegrationSourceUnreadable marks failures to read or decompress a
+// source, as opposed to failures to store its events.
+var errAPIIntegrationSourceUnreadable = errors.New("source unreadable")
+
 // APIIntegrationsIngestAgent tails normalized JSONL API integration usage files and stores the events.
+// Gzip and zstd archives are ingested once, and a rotated file is read to its
+// end before the file that replaced it.
 type APIIntegrationsIngestAgent struct {
 	store          *store.Store
 	dir            string
+	sources        []string
 	interval       time.Duration
 	retention      time.Duration
 	pruneInterval  time.Duration
 	lastPrune      time.Time
 	scanPathCursor int
+	doneArchives   map[string]apiIntegrationArchiveStamp
 	logger         *slog.Logger
 }
 
+// apiIntegrationArchiveStamp identifies the version of an archive that was ingested.
+type apiIntegrationArchiveStamp struct {
+	size    int64
+	modTime time.Time
+	inode   uint64
+}
+
+func newAPIIntegrationArchiveStamp(info os.FileInfo) apiIntegrationArchiveStamp {
+	return apiIntegrationArchiveStamp{size: info.Size(), modTime: info.ModTime().UTC(), inode: fileInode(info)}
+}
+
+// invalidLineAlerts counts the invalid-line alerts of one file in one scan.
+type invalidLineAlerts struct {
+	created    int
+	suppressed int
+}
+
 // NewAPIIntegrationsIngestAgent creates a new API integrations file ingester.
 func NewAPIIntegrationsIngestAgent(store *store.Store, dir string, retention time.Duration, logger *slog.Logger) *APIIntegrationsIngestAgent {
 	if logger == nil {
@@ -47,10 +72,25 @@ func NewAPIIntegrationsIngestAgent(store *store.Store, dir string, retention tim
 		interval:      apiIntegrationIngestIntervalDefault,
 		retention:     retention,
 		pruneInterval: apiIntegrationPruneIntervalDefault,
+		doneArchives:  make(map[string]apiIntegrationArchiveStamp),
 		logger:        logger,
 	}
 }
 
+// SetSources sets the glob patterns of the files to ingest, where "**"
+// matches any number of directories. No patterns means
+// apiintegrations.DefaultSources of the agent's directory.
+func (a *APIIntegrationsIngestAgent) SetSources(patterns []string) {
+	a.sources = patterns
+}
+
+func (a *APIIntegrationsIngestAgent) sourcePatterns() []string {
+	if len(a.sources) > 0 {
+		return a.sources
+	}
+	return apiintegrations.DefaultSources(a.dir)
+}
+
 // SetInterval overrides the scan interval. Used in tests.
 func (a *APIIntegrationsIngestAgent) SetInterval(interval time.Duration) {
 	if interval > 0 {
@@ -60,7 +100,7 @@ func (a *APIIntegrationsIngestAgent) SetInterval(interval time.Duration) {
 
 // Run starts the periodic ingestion loop until context cancellation.
 func (a *APIIntegrationsIngestAgent) Run(ctx context.Context) error {
-	a.logger.Info("API integrations ingester started", "dir", a.dir, "interval", a.interval)
+	a.logger.Info("API integrations ingester started", "dir", a.dir, "sources", a.sourcePatterns(), "interval", a.interval)
 	defer a.logger.Info("API integrations ingester stopped")
 
 	if err := os.MkdirAll(a.dir, 0o700); err != nil {
@@ -83,13 +123,19 @@ func (a *APIIntegrationsIngestAgent) Run(ctx context.Context) error {
 }
 
 func (a *APIIntegrationsIngestAgent) scan() {
-	pattern := filepath.Join(a.dir, "*.jsonl")
-	paths, err := filepath.Glob(pattern)
+	matches, err := apiintegrations.ExpandSources(a.sourcePatterns())
 	if err != nil {
-		a.logger.Error("API integrations ingester glob failed", "dir", a.dir, "error", err)
+		a.logger.Error("API integrations ingester glob failed", "sources", a.sourcePatterns(), "error", err)
 		return
 	}
-	sort.Strings(paths)
+	// Archives that were already ingested do not count towards the scan cap.
+	paths := matches[:0]
+	for _, path := range matches {
+		if apiintegrations.IsArchiveSource(path) && a.archiveIngested(path) {
+			continue
+		}
+		paths = append(paths, path)
+	}
 	if len(paths) > apiIntegrationIngestMaxFilesPerScan {
 		start := a.scanPathCursor % len(paths)
 		selected := make([]string, 0, apiIntegrationIngestMaxFilesPerScan)
@@ -98,7 +144,7 @@ func (a *APIIntegrationsIngestAgent) scan() {
 		}
 		a.logger.Warn(
 			"API integrations ingester skipped files beyond scan cap",
-			"dir", a.dir,
+			"sources", a.sourcePatterns(),
 			"total_files", len(paths),
 			"processed_files", apiIntegrationIngestMaxFilesPerScan,
 			"skipped_files", len(paths)-apiIntegrationIngestMaxFilesPerScan,
@@ -110,7 +156,11 @@ func (a *APIIntegrationsIngestAgent) scan() {
 	}
 
 	for _, path := range paths {
-		if err := a.scanFile(path); err != nil {
+		scanFile := a.scanFile
+		if apiintegrations.IsArchiveSource(path) {
+			scanFile = a.scanArchive
+		}
+		if err := scanFile(path); err != nil {
 			a.logger.Error("API integrations ingester scan failed", "path", path, "error", err)
 		}
 	}
@@ -132,13 +182,22 @@ func (a *APIIntegrationsIngestAgent) scanFile(path string) error {
 		return nil
 	}
 
+	inode := fileInode(info)
 	state, err := a.store.GetAPIIntegrationIngestState(path)
 	if err != nil {
 		return err
 	}
+	if state == nil {
+		if state, err = a.renamedFileState(path, info); err != nil {
+			return err
+		}
+	}
 	if state == nil {
 		state = &apiintegrations.IngestState{SourcePath: path}
 	}
+	if state.LogicalPath == "" {
+		state.LogicalPath = apiintegrations.LogicalSourcePath(path)
+	}
 	if state.PartialLineOversized {
 		a.logger.Warn(
 			"API integrations ingester discarded oversized persisted partial line",
@@ -149,10 +208,24 @@ func (a *APIIntegrationsIngestAgent) scanFile(path string) error {
 		state.PartialLine = ""
 	}
 
-	if info.Size() < state.Offset {
+	var alerts invalidLineAlerts
+	defer a.logSuppressedAlerts(path, &alerts)
+
+	switch {
+	case state.Inode != 0 && inode != 0 && inode != state.Inode:
+		// The file was renamed away and replaced: finish the old one first.
+		if err := a.drainRotatedFile(path, state, &alerts); err != nil {
+			return err
+		}
+		state.Offset = 0
+		state.PartialLine = ""
+		state.Rotations++
+		state.LastRotatedAt = time.Now().UTC()
+	case info.Size() < state.Offset:
 		state.Offset = 0
 		state.PartialLine = ""
 	}
+	state.Inode = inode
 
 	file, err := os.Open(path)
 	if err != nil {
@@ -169,16 +242,225 @@ func (a *APIIntegrationsIngestAgent) scanFile(path string) error {
 		return fmt.Errorf("read file: %w", err)
 	}
 
-	if len(data) == 0 {
-		state.FileSize = info.Size()
-		state.FileModTime = info.ModTime().UTC()
-		return a.store.UpsertAPIIntegrationIngestState(state)
-	}
-
 	state.Offset += int64(len(data))
 	state.FileSize = info.Size()
 	state.FileModTime = info.ModTime().UTC()
 
+	if err := a.ingestData(path, state, data, &alerts); err != nil {
+		return err
+	}
+	return a.store.UpsertAPIIntegrationIngestState(state)
+}
+
+// drainRotatedFile reads the rest of the file path pointed to at the last
+// scan, found in the same directory by its inode, so lines written before the
+// rotation are not lost. Its events keep the logical path of the live file,
+// so an archive of it made later adds no duplicates.
+func (a *APIIntegrationsIngestAgent) drainRotatedFile(path string, state *apiintegrations.IngestState, alerts *invalidLineAlerts) error {
+	rotated := findFileByInode(filepath.Dir(path), state.Inode)
+	if rotated == "" {
+		if pending := state.FileSize - state.Offset; pending > 0 || state.PartialLine != "" {
+			msg := fmt.Sprintf("%s was rotated and the old file was not found next to it; up to %d bytes may be missing unless a matching archive is ingested", filepath.Base(path), pending)
+			a.logger.Warn("API integrations ingester lost track of rotated file", "path", path, "pending_bytes", pending)
+			metadata := fmt.Sprintf(`{"source_path":%q,"pending_bytes":%d}`, path, pending)
+			if _, err := a.store.CreateSystemAlert("api_integrations", "ingest_rotation", "API integrations rotated file not found", msg, "warning", metadata); err != nil {
+				a.logger.Warn("Failed to create API integrations rotation alert", "path", path, "error", err)
+			}
+		}
+		return nil
+	}
+
+	file, err := os.Open(rotated)
+	if err != nil {
+		return fmt.Errorf("open rotated file: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Seek(state.Offset, io.SeekStart); err != nil {
+		return fmt.Errorf("seek rotated file: %w", err)
+	}
+	a.logger.Info("API integrations ingester following rotated file", "path", path, "rotated_path", rotated, "offset", state.Offset)
+	if err := a.ingestReader(path, state, file, alerts); err != nil {
+		return fmt.Errorf("rotated file %s: %w", rotated, err)
+	}
+
+	// A rotated name that is itself a source, such as lumberjack's
+	// notes-<time>.jsonl, is now read to its end; record that so it is not
+	// ingested again from its start under its own path.
+	if apiintegrations.IsArchiveSource(rotated) || !apiintegrations.MatchesSources(a.sourcePatterns(), rotated) {
+		return nil
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat rotated file: %w", err)
+	}
+	return a.store.UpsertAPIIntegrationIngestState(&apiintegrations.IngestState{
+		SourcePath:  rotated,
+		LogicalPath: state.LogicalPath,
+		Inode:       state.Inode,
+		Offset:      info.Size(),
+		FileSize:    info.Size(),
+		FileModTime: info.ModTime().UTC(),
+	})
+}
+
+// renamedFileState returns the cursor for a newly found path that is a
+// tailed file renamed to a name the sources also match, so it continues
+// where the file was left under its old name and keeps its logical path.
+// It returns nil when path is a new file.
+func (a *APIIntegrationsIngestAgent) renamedFileState(path string, info os.FileInfo) (*apiintegrations.IngestState, error) {
+	inode := fileInode(info)
+	if inode == 0 {
+		return nil, nil
+	}
+	states, err := a.store.QueryAPIIntegrationIngestStatesByInode(inode)
+	if err != nil {
+		return nil, err
+	}
+	for _, prev := range states {
+		if filepath.Dir(prev.SourcePath) != filepath.Dir(path) || apiintegrations.IsArchiveSource(prev.SourcePath) {
+			continue
+		}
+		// As on rotation, the old path must have been replaced by a new
+		// file; an inode reused after a deletion must not skip lines.
+		if old, err := os.Stat(prev.SourcePath); err != nil || fileInode(old) == inode || info.Size() < prev.Offset {
+			continue
+		}
+		a.logger.Info("API integrations ingester following renamed file", "path", path, "previous_path", prev.SourcePath, "offset", prev.Offset)
+		return &apiintegrations.IngestState{
+			SourcePath:  path,
+			LogicalPath: prev.LogicalPath,
+			Inode:       inode,
+			Offset:      prev.Offset,
+			PartialLine: prev.PartialLine,
+		}, nil
+	}
+	return nil, nil
+}
+
+// findFileByInode returns the regular file in dir with the given inode, or "".
+func findFileByInode(dir string, inode uint64) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err == nil && fileInode(info) == inode {
+			return filepath.Join(dir, entry.Name())
+		}
+	}
+	return ""
+}
+
+// scanArchive ingests a gzip or zstd archive once. An archive that changes
+// later is read again; events already stored are skipped as duplicates.
+func (a *APIIntegrationsIngestAgent) scanArchive(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("stat file: %w", err)
+	}
+	stamp := newAPIIntegrationArchiveStamp(info)
+
+	state := &apiintegrations.IngestState{
+		SourcePath:  path,
+		LogicalPath: apiintegrations.LogicalSourcePath(path),
+		Inode:       stamp.inode,
+	}
+	// An archive of a renamed source, such as lumberjack's
+	// notes-<time>.jsonl.gz, belongs to the file that source was read as.
+	if uncompressed, err := a.store.GetAPIIntegrationIngestState(strings.TrimSuffix(strings.TrimSuffix(path, ".gz"), ".zst")); err != nil {
+		return err
+	} else if uncompressed != nil && uncompressed.LogicalPath != "" {
+		state.LogicalPath = uncompressed.LogicalPath
+	}
+	var alerts invalidLineAlerts
+	defer a.logSuppressedAlerts(path, &alerts)
+
+	r, err := apiintegrations.OpenArchiveSource(path)
+	if err == nil {
+		err = a.ingestReader(path, state, r, &alerts)
+		r.Close()
+	} else {
+		err = fmt.Errorf("%w: %v", errAPIIntegrationSourceUnreadable, err)
+	}
+	if err != nil {
+		if !errors.Is(err, errAPIIntegrationSourceUnreadable) {
+			return err
+		}
+		// A corrupt archive stays corrupt; report it once and keep what was read.
+		a.logger.Warn("API integrations ingester could not read archive", "path", path, "error", err)
+		metadata := fmt.Sprintf(`{"source_path":%q}`, path)
+		if _, alertErr := a.store.CreateSystemAlert("api_integrations", "ingest_error", "API integrations archive could not be read", fmt.Sprintf("%s: %v", filepath.Base(path), err), "warning", metadata); alertErr != nil {
+			a.logger.Warn("Failed to create API integrations archive alert", "path", path, "error", alertErr)
+		}
+	}
+
+	state.Offset = stamp.size
+	state.FileSize = stamp.size
+	state.FileModTime = stamp.modTime
+	state.PartialLine = ""
+	if err := a.store.UpsertAPIIntegrationIngestState(state); err != nil {
+		return err
+	}
+	a.doneArchives[path] = stamp
+	return nil
+}
+
+// archiveIngested reports whether the current version of an archive was
+// already ingested, by this agent or a previous run.
+func (a *APIIntegrationsIngestAgent) archiveIngested(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return true
+	}
+	stamp := newAPIIntegrationArchiveStamp(info)
+	if done, ok := a.doneArchives[path]; ok {
+		return done == stamp
+	}
+	state, err := a.store.GetAPIIntegrationIngestState(path)
+	if err != nil || state == nil {
+		return false
+	}
+	if state.FileSize == stamp.size && state.Offset == stamp.size && state.FileModTime.Equal(stamp.modTime) && state.Inode == stamp.inode {
+		a.doneArchives[path] = stamp
+		return true
+	}
+	return false
+}
+
+// ingestReader ingests everything left in r. The source is complete, so a
+// last line without a trailing newline is ingested too.
+func (a *APIIntegrationsIngestAgent) ingestReader(path string, state *apiintegrations.IngestState, r io.Reader, alerts *invalidLineAlerts) error {
+	buf := make([]byte, apiIntegrationIngestMaxReadBytes)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			if ingestErr := a.ingestData(path, state, buf[:n], alerts); ingestErr != nil {
+				return ingestErr
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", errAPIIntegrationSourceUnreadable, err)
+		}
+	}
+	return a.ingestData(path, state, []byte("\n"), alerts)
+}
+
+// ingestData stores the events of the complete lines in state.PartialLine
+// followed by data, keeping any trailing partial line in state.
+func (a *APIIntegrationsIngestAgent) ingestData(path string, state *apiintegrations.IngestState, data []byte, alerts *invalidLineAlerts) error {
+	if len(data) == 0 {
+		return nil
+	}
 	combined := state.PartialLine + string(data)
 	lines, remainder := splitCompleteLines(combined)
 	state.PartialLine = remainder
@@ -192,21 +474,22 @@ func (a *APIIntegrationsIngestAgent) scanFile(path string) error {
 		state.PartialLine = ""
 	}
 
-	invalidAlertsCreated := 0
-	invalidAlertsSuppressed := 0
-
+	sourcePath := state.LogicalPath
+	if sourcePath == "" {
+		sourcePath = path
+	}
 	for _, line := range lines {
 		trimmed := strings.TrimSpace(line)
 		if trimmed == "" {
 			continue
 		}
-		event, err := apiintegrations.ParseUsageEventLine([]byte(trimmed), path)
+		event, err := usageevent.ParseLine([]byte(trimmed), sourcePath)
 		if err != nil {
-			if invalidAlertsCreated < apiIntegrationIngestMaxInvalidAlertsPerFilePerScan {
+			if alerts.created < apiIntegrationIngestMaxInvalidAlertsPerFilePerScan {
 				a.recordInvalidLine(path, trimmed, err)
-				invalidAlertsCreated++
+				alerts.created++
 			} else {
-				invalidAlertsSuppressed++
+				alerts.suppressed++
 			}
 			continue
 		}
@@ -217,17 +500,19 @@ func (a *APIIntegrationsIngestAgent) scanFile(path string) error {
 			return err
 		}
 	}
-	if invalidAlertsSuppressed > 0 {
+	return nil
+}
+
+func (a *APIIntegrationsIngestAgent) logSuppressedAlerts(path string, alerts *invalidLineAlerts) {
+	if alerts.suppressed > 0 {
 		a.logger.Warn(
 			"API integrations ingester suppressed invalid line alerts",
 			"path", path,
 			"alert_limit", apiIntegrationIngestMaxInvalidAlertsPerFilePerScan,
-			"alerts_created", invalidAlertsCreated,
-			"alerts_suppressed", invalidAlertsSuppressed,
+			"alerts_created", alerts.created,
+			"alerts_suppressed", alerts.suppressed,
 		)
 	}
-
-	return a.store.UpsertAPIIntegrationIngestState(state)
 }
 
 func splitCompleteLines(data string) ([]string, string) {
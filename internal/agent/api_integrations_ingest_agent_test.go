@@ -2,6 +2,7 @@ package agent
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
 	"log/slog"
@@ -11,8 +12,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
 	apiintegrations "github.com/onllm-dev/onwatch/v2/internal/api_integrations"
 	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
 )
 
 func newBufferedJSONLogger() (*slog.Logger, *bytes.Buffer) {
@@ -491,9 +494,9 @@ func TestAPIIntegrationsIngestAgent_Scan_PrunesExpiredDatabaseRows(t *testing.T)
 	defer st.Close()
 
 	oldEvent := `{"ts":"2025-12-01T12:00:00Z","integration":"notes","provider":"openai","model":"gpt-4.1-mini","prompt_tokens":2,"completion_tokens":1}`
-	parsedOld, err := apiintegrations.ParseUsageEventLine([]byte(oldEvent), "/tmp/api-integrations/notes.jsonl")
+	parsedOld, err := usageevent.ParseLine([]byte(oldEvent), "/tmp/api-integrations/notes.jsonl")
 	if err != nil {
-		t.Fatalf("ParseUsageEventLine(old): %v", err)
+		t.Fatalf("ParseLine(old): %v", err)
 	}
 	if _, err := st.InsertAPIIntegrationUsageEvent(parsedOld); err != nil {
 		t.Fatalf("InsertAPIIntegrationUsageEvent(old): %v", err)
@@ -526,3 +529,228 @@ func TestAPIIntegrationsIngestAgent_Scan_PrunesExpiredDatabaseRows(t *testing.T)
 		t.Fatalf("expected retained new event, got %+v", events[0])
 	}
 }
+
+func TestAPIIntegrationsIngestAgent_Scan_FollowsRotatedFile(t *testing.T) {
+	t.Parallel()
+	st, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer st.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.jsonl")
+	eventLine := func(minute int) string {
+		return fmt.Sprintf(`{"ts":"2026-04-03T12:%02d:00Z","integration":"notes","provider":"openai","model":"gpt-4.1-mini","prompt_tokens":3,"completion_tokens":2}`+"\n", minute)
+	}
+	if err := os.WriteFile(path, []byte(eventLine(0)), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	ag := NewAPIIntegrationsIngestAgent(st, dir, 0, slog.Default())
+	ag.scan()
+
+	// Lines written just before logrotate renames the file are read from the
+	// rotated file, then the new file is read from its start.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := f.WriteString(eventLine(1) + strings.TrimSuffix(eventLine(2), "\n")); err != nil {
+		t.Fatalf("WriteString: %v", err)
+	}
+	_ = f.Close()
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(eventLine(3)), 0o600); err != nil {
+		t.Fatalf("WriteFile(new): %v", err)
+	}
+	ag.scan()
+
+	events, err := st.QueryAPIIntegrationUsageRange(time.Date(2026, 4, 3, 11, 0, 0, 0, time.UTC), time.Date(2026, 4, 3, 13, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationUsageRange: %v", err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("len(events)=%d want 4", len(events))
+	}
+	for _, event := range events {
+		if event.SourcePath != path {
+			t.Fatalf("event source=%q want %q", event.SourcePath, path)
+		}
+	}
+	state, err := st.GetAPIIntegrationIngestState(path)
+	if err != nil || state == nil {
+		t.Fatalf("GetAPIIntegrationIngestState: %+v, %v", state, err)
+	}
+	if state.Rotations != 1 || state.LastRotatedAt.IsZero() || state.Offset != int64(len(eventLine(3))) {
+		t.Fatalf("state=%+v", state)
+	}
+
+	// Compressing the rotated file adds no duplicates.
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	rotated, err := os.ReadFile(path + ".1")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	_, _ = zw.Write(rotated)
+	_ = zw.Close()
+	if err := os.WriteFile(path+".1.gz", gz.Bytes(), 0o600); err != nil {
+		t.Fatalf("WriteFile(gz): %v", err)
+	}
+	if err := os.Remove(path + ".1"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	ag.scan()
+	events, err = st.QueryAPIIntegrationUsageRange(time.Date(2026, 4, 3, 11, 0, 0, 0, time.UTC), time.Date(2026, 4, 3, 13, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationUsageRange(2): %v", err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("len(events)=%d want 4 after archiving", len(events))
+	}
+}
+
+func TestAPIIntegrationsIngestAgent_Scan_RotatedNameMatchingSources(t *testing.T) {
+	t.Parallel()
+	// lumberjack's notes-<time>.jsonl sorts before notes.jsonl, so it is
+	// found before the rotation is; notes_<date>.jsonl sorts after it.
+	for _, rotatedName := range []string{"notes-2026-04-03T12-05-00.000.jsonl", "notes_20260403.jsonl"} {
+		rotatedName := rotatedName
+		t.Run(rotatedName, func(t *testing.T) {
+			t.Parallel()
+			st, err := store.New(":memory:")
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			defer st.Close()
+
+			dir := t.TempDir()
+			path := filepath.Join(dir, "notes.jsonl")
+			rotated := filepath.Join(dir, rotatedName)
+			eventLine := func(minute int) string {
+				return fmt.Sprintf(`{"ts":"2026-04-03T12:%02d:00Z","integration":"notes","provider":"openai","model":"gpt-4.1-mini","prompt_tokens":3,"completion_tokens":2}`+"\n", minute)
+			}
+			if err := os.WriteFile(path, []byte(eventLine(0)+eventLine(1)), 0o600); err != nil {
+				t.Fatalf("WriteFile: %v", err)
+			}
+			ag := NewAPIIntegrationsIngestAgent(st, dir, 0, slog.Default())
+			ag.scan()
+
+			if err := os.Rename(path, rotated); err != nil {
+				t.Fatalf("Rename: %v", err)
+			}
+			if err := os.WriteFile(path, []byte(eventLine(2)), 0o600); err != nil {
+				t.Fatalf("WriteFile(new): %v", err)
+			}
+			ag.scan()
+			ag.scan()
+
+			// Compressing the rotated file adds no duplicates either.
+			var gz bytes.Buffer
+			zw := gzip.NewWriter(&gz)
+			data, err := os.ReadFile(rotated)
+			if err != nil {
+				t.Fatalf("ReadFile: %v", err)
+			}
+			_, _ = zw.Write(data)
+			_ = zw.Close()
+			if err := os.WriteFile(rotated+".gz", gz.Bytes(), 0o600); err != nil {
+				t.Fatalf("WriteFile(gz): %v", err)
+			}
+			if err := os.Remove(rotated); err != nil {
+				t.Fatalf("Remove: %v", err)
+			}
+			ag.scan()
+
+			events, err := st.QueryAPIIntegrationUsageRange(time.Date(2026, 4, 3, 11, 0, 0, 0, time.UTC), time.Date(2026, 4, 3, 13, 0, 0, 0, time.UTC))
+			if err != nil {
+				t.Fatalf("QueryAPIIntegrationUsageRange: %v", err)
+			}
+			if len(events) != 3 {
+				t.Fatalf("len(events)=%d want 3", len(events))
+			}
+			for _, event := range events {
+				if event.SourcePath != path {
+					t.Fatalf("event source=%q want %q", event.SourcePath, path)
+				}
+			}
+		})
+	}
+}
+
+func TestAPIIntegrationsIngestAgent_Scan_ArchivesAndNestedSources(t *testing.T) {
+	t.Parallel()
+	st, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer st.Close()
+
+	dir := t.TempDir()
+	other := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "team", "2026-04-03"), 0o700); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	line := func(integration string, minute int) string {
+		return fmt.Sprintf(`{"ts":"2026-04-03T12:%02d:00Z","integration":%q,"provider":"openai","model":"gpt-4.1-mini","prompt_tokens":3,"completion_tokens":2}`+"\n", minute, integration)
+	}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte(line("gzip", 0) + line("gzip", 1) + strings.TrimSuffix(line("gzip", 2), "\n")))
+	_ = zw.Close()
+	if err := os.WriteFile(filepath.Join(dir, "team", "2026-04-03", "10.jsonl.gz"), gz.Bytes(), 0o600); err != nil {
+		t.Fatalf("WriteFile(gz): %v", err)
+	}
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("zstd.NewWriter: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(other, "batch.jsonl.zst"), enc.EncodeAll([]byte(line("zstd", 3)), nil), 0o600); err != nil {
+		t.Fatalf("WriteFile(zst): %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "team", "live.jsonl"), []byte(line("live", 4)), 0o600); err != nil {
+		t.Fatalf("WriteFile(live): %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(other, "ignored.jsonl"), []byte(line("ignored", 5)), 0o600); err != nil {
+		t.Fatalf("WriteFile(ignored): %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.jsonl.gz"), []byte("not gzip"), 0o600); err != nil {
+		t.Fatalf("WriteFile(broken): %v", err)
+	}
+
+	ag := NewAPIIntegrationsIngestAgent(st, dir, 0, slog.Default())
+	ag.SetSources(append(apiintegrations.DefaultSources(dir), filepath.Join(other, "*.zst")))
+	ag.scan()
+	ag.scan()
+
+	summary, err := st.QueryAPIIntegrationUsageSummary()
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationUsageSummary: %v", err)
+	}
+	requests := make(map[string]int)
+	for _, row := range summary {
+		requests[row.IntegrationName] += row.RequestCount
+	}
+	if requests["gzip"] != 3 || requests["zstd"] != 1 || requests["live"] != 1 || requests["ignored"] != 0 {
+		t.Fatalf("requests=%v", requests)
+	}
+
+	archive := filepath.Join(dir, "team", "2026-04-03", "10.jsonl.gz")
+	state, err := st.GetAPIIntegrationIngestState(archive)
+	if err != nil || state == nil {
+		t.Fatalf("GetAPIIntegrationIngestState: %+v, %v", state, err)
+	}
+	if state.LogicalPath != strings.TrimSuffix(archive, ".gz") || state.Offset != state.FileSize {
+		t.Fatalf("archive state=%+v", state)
+	}
+	alerts, err := st.GetActiveSystemAlertsByProvider("api_integrations", 10)
+	if err != nil {
+		t.Fatalf("GetActiveSystemAlertsByProvider: %v", err)
+	}
+	if len(alerts) != 1 || !strings.Contains(alerts[0].Message, "broken.jsonl.gz") {
+		t.Fatalf("alerts=%+v want one for the broken archive", alerts)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package agent
+
+import "os"
+
+// fileInode returns 0 on Windows, where os.FileInfo carries no file index;
+// rotated sources are then only detected when the file shrinks.
+func fileInode(info os.FileInfo) uint64 {
+	return 0
+}
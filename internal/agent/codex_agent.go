@@ -502,6 +502,7 @@ func (a *CodexAgent) poll(ctx context.Context) {
 				AccountID:   fmt.Sprintf("%d", a.accountID),
 				Utilization: q.Utilization,
 				Limit:       100,
+				Pacing:      tracker.QuotaPacing(q.Name, q.Utilization, q.ResetsAt, now),
 			})
 		}
 	}
@@ -108,6 +108,15 @@ func (a *DeepSeekAgent) poll(ctx context.Context) {
 		}
 	}
 
+	// Warn before the balance runs out
+	if a.notifier != nil && a.tracker != nil {
+		if runway, err := a.tracker.Runway(snapshot.Currency, now); err != nil {
+			a.logger.Error("DeepSeek runway forecast failed", "error", err)
+		} else {
+			a.notifier.CheckRunway("deepseek", "balance", runway)
+		}
+	}
+
 	// Report to session manager for usage-based session detection
 	// Inverting for balance: smaller balance means usage
 	if a.sm != nil {
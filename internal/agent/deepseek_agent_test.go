@@ -0,0 +1,108 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/api"
+	"github.com/onllm-dev/onwatch/v2/internal/notify"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/tracker"
+)
+
+// TestDeepSeekAgent_RunwayAlertRearmsAfterTopUp drives a poll through the
+// tracker's runway forecast into the notification engine: a low balance
+// alerts once, and a top-up seen by the agent re-arms the alert.
+func TestDeepSeekAgent_RunwayAlertRearmsAfterTopUp(t *testing.T) {
+	var balance atomic.Value
+	balance.Store("35.00")
+	var messages atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/user/balance":
+			fmt.Fprintf(w, `{"is_available":true,"balance_infos":[{"currency":"USD","total_balance":%q,"granted_balance":"0","topped_up_balance":%q}]}`,
+				balance.Load(), balance.Load())
+		case strings.HasSuffix(r.URL.Path, "/getUpdates"):
+			// Long poll until the bot stops; the body must be read for the
+			// server to notice the client going away.
+			io.Copy(io.Discard, r.Body)
+			<-r.Context().Done()
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			messages.Add(1)
+			w.Write([]byte(`{"ok":true,"result":{}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	str, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer str.Close()
+
+	// A week of spending 10/day leaves 40 an hour ago.
+	now := time.Now().UTC()
+	for day := 6; day >= 0; day-- {
+		snap := &api.DeepSeekSnapshot{
+			CapturedAt:   now.Add(-time.Hour - time.Duration(day)*24*time.Hour),
+			IsAvailable:  true,
+			Currency:     "USD",
+			TotalBalance: 40 + float64(day)*10,
+		}
+		if _, err := str.InsertDeepSeekSnapshot(snap); err != nil {
+			t.Fatalf("InsertDeepSeekSnapshot: %v", err)
+		}
+	}
+	if err := str.SetSetting("notifications", `{"warning_threshold":80,"critical_threshold":95,"notify_runway":true,"runway_days":5,"channels":{"telegram":true}}`); err != nil {
+		t.Fatalf("SetSetting notifications: %v", err)
+	}
+	if err := str.SetSetting("telegram", fmt.Sprintf(`{"bot_token":"123:abc","chat_ids":"42","api_url":%q}`, server.URL)); err != nil {
+		t.Fatalf("SetSetting telegram: %v", err)
+	}
+
+	logger := slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))
+	notifier := notify.New(str, logger)
+	if err := notifier.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if err := notifier.ConfigureTelegram(); err != nil {
+		t.Fatalf("ConfigureTelegram: %v", err)
+	}
+	defer notifier.Close()
+
+	client := api.NewDeepSeekClient("test-key", logger, api.WithDeepSeekBaseURL(server.URL))
+	agent := NewDeepSeekAgent(client, str, tracker.NewDeepSeekTracker(str, logger), time.Minute, logger, nil)
+	agent.SetNotifier(notifier)
+	ctx := context.Background()
+
+	agent.poll(ctx)
+	agent.poll(ctx)
+	if got := messages.Load(); got != 1 {
+		t.Fatalf("expected 1 runway alert for a low balance, got %d", got)
+	}
+
+	// The top-up lifts the runway above 5 days and ends the alert cycle.
+	balance.Store("100.00")
+	agent.poll(ctx)
+	if sentAt, _, _ := str.GetLastNotification("deepseek", "balance", "runway_low"); !sentAt.IsZero() {
+		t.Fatal("expected the top-up to clear the runway_low notification log")
+	}
+
+	// Spending it down again alerts again.
+	balance.Store("20.00")
+	agent.poll(ctx)
+	if got := messages.Load(); got != 2 {
+		t.Errorf("expected a new runway alert after the top-up, got %d alerts", got)
+	}
+}
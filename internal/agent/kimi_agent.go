@@ -108,6 +108,7 @@ func (a *KimiAgent) poll(ctx context.Context) {
 			Provider:    "kimi",
 			QuotaKey:    q.Name,
 			Utilization: q.Utilization,
+			Pacing:      tracker.QuotaPacing(q.Name, q.Utilization, q.ResetsAt, time.Now()),
 		})
 	}
 
@@ -103,6 +103,15 @@ func (a *MoonshotAgent) poll(ctx context.Context) {
 		}
 	}
 
+	// Warn before the balance runs out
+	if a.notifier != nil && a.tracker != nil {
+		if runway, err := a.tracker.Runway(now); err != nil {
+			a.logger.Error("Moonshot runway forecast failed", "error", err)
+		} else {
+			a.notifier.CheckRunway("moonshot", "balance", runway)
+		}
+	}
+
 	// Report to session manager for usage-based session detection
 	// Inverting for balance: smaller balance means usage
 	if a.sm != nil {
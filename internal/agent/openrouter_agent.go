@@ -122,6 +122,13 @@ func (a *OpenRouterAgent) poll(ctx context.Context) {
 				Limit:       *snapshot.Limit,
 			})
 		}
+		if a.tracker != nil {
+			if runway, err := a.tracker.Runway(now); err != nil {
+				a.logger.Error("OpenRouter runway forecast failed", "error", err)
+			} else {
+				a.notifier.CheckRunway("openrouter", "credits", runway)
+			}
+		}
 	}
 
 	// Report to session manager for usage-based session detection
@@ -26,6 +26,22 @@ const (
 	CursorFormatCount   CursorQuotaFormat = "count"
 )
 
+var cursorDisplayNames = map[string]string{
+	"total_usage": "Total Usage",
+	"auto_usage":  "Auto + Composer",
+	"api_usage":   "API Usage",
+	"credits":     "Credits",
+	"on_demand":   "On-Demand",
+}
+
+// CursorDisplayName returns a UI label for a cursor quota key.
+func CursorDisplayName(name string) string {
+	if dn, ok := cursorDisplayNames[name]; ok {
+		return dn
+	}
+	return name
+}
+
 type CursorQuota struct {
 	Name        string
 	Used        float64
@@ -0,0 +1,53 @@
+package apiintegrations
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxDimensions is the number of metadata keys that can be promoted to
+// indexed dimensions.
+const MaxDimensions = 8
+
+// BuiltinDimensions are the event fields usage can always be grouped by.
+var BuiltinDimensions = []string{"integration", "provider", "account", "model"}
+
+var dimensionKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// IsBuiltinDimension reports whether name is one of BuiltinDimensions.
+func IsBuiltinDimension(name string) bool {
+	for _, builtin := range BuiltinDimensions {
+		if name == builtin {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseDimensions validates a list of metadata keys to promote to dimensions.
+// Keys are trimmed, duplicates dropped and order kept. A key names a
+// top-level field of an event's metadata object and may not shadow a
+// built-in dimension.
+func ParseDimensions(keys []string) ([]string, error) {
+	parsed := make([]string, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key == "" || seen[key] {
+			continue
+		}
+		if !dimensionKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("dimension %q must be 1-64 letters, digits, '_', '.' or '-'", key)
+		}
+		if IsBuiltinDimension(strings.ToLower(key)) {
+			return nil, fmt.Errorf("dimension %q is built in", key)
+		}
+		seen[key] = true
+		parsed = append(parsed, key)
+	}
+	if len(parsed) > MaxDimensions {
+		return nil, fmt.Errorf("at most %d dimensions can be promoted", MaxDimensions)
+	}
+	return parsed, nil
+}
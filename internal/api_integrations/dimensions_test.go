@@ -0,0 +1,27 @@
+package apiintegrations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDimensions(t *testing.T) {
+	got, err := ParseDimensions([]string{" feature ", "team.name", "", "feature", "cost-center"})
+	if err != nil {
+		t.Fatalf("ParseDimensions: %v", err)
+	}
+	if want := []string{"feature", "team.name", "cost-center"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseDimensions=%v want %v", got, want)
+	}
+
+	for _, keys := range [][]string{
+		{"Model"},
+		{"a b"},
+		{`a"b`},
+		{"a1", "a2", "a3", "a4", "a5", "a6", "a7", "a8", "a9"},
+	} {
+		if _, err := ParseDimensions(keys); err == nil {
+			t.Errorf("ParseDimensions(%q) succeeded, want error", keys)
+		}
+	}
+}
@@ -0,0 +1,454 @@
+package apiintegrations
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
+)
+
+// OTLPSourcePath is the source path recorded for events received over OTLP.
+const OTLPSourcePath = "otlp"
+
+// OTLPTokenUsageMetric is the GenAI semantic-convention histogram of tokens
+// used per request, split by the gen_ai.token.type attribute.
+const OTLPTokenUsageMetric = "gen_ai.client.token.usage"
+
+// maxOTLPErrorMessages caps the per-item errors joined into a partial-success
+// message.
+const maxOTLPErrorMessages = 5
+
+// OTLPMapping names the attributes that carry the API integration fields the
+// GenAI semantic conventions do not define. Attributes are looked up on the
+// span or data point first, then its scope, then its resource.
+type OTLPMapping struct {
+	IntegrationAttr string // integration name; "service.name" when empty
+	AccountAttr     string // optional; events fall back to the "default" account
+	CostAttr        string // optional USD cost per span
+}
+
+// OTLPResult is the outcome of converting one OTLP export request.
+type OTLPResult struct {
+	Events   []*usageevent.Event
+	Rejected int      // spans or data points with GenAI usage that failed validation
+	Errors   []string // the first few rejection reasons
+}
+
+// ErrorMessage joins the rejection reasons for an OTLP partial-success
+// response.
+func (r *OTLPResult) ErrorMessage() string {
+	return strings.Join(r.Errors, "; ")
+}
+
+func (r *OTLPResult) reject(err error) {
+	r.Rejected++
+	if len(r.Errors) < maxOTLPErrorMessages {
+		r.Errors = append(r.Errors, err.Error())
+	}
+}
+
+// DecodeOTLPTraces decodes an OTLP/HTTP trace export body. TracesData shares
+// its wire format with ExportTraceServiceRequest, so both encodings decode
+// into it directly.
+func DecodeOTLPTraces(body []byte, isJSON bool) (*tracepb.TracesData, error) {
+	data := &tracepb.TracesData{}
+	if !isJSON {
+		if err := proto.Unmarshal(body, data); err != nil {
+			return nil, fmt.Errorf("decode OTLP traces: %w", err)
+		}
+		return data, nil
+	}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, data); err != nil {
+		return nil, fmt.Errorf("decode OTLP traces: %w", err)
+	}
+	for _, rs := range data.GetResourceSpans() {
+		for _, ss := range rs.GetScopeSpans() {
+			for _, span := range ss.GetSpans() {
+				span.TraceId = otlpJSONID(span.TraceId)
+				span.SpanId = otlpJSONID(span.SpanId)
+			}
+		}
+	}
+	return data, nil
+}
+
+// DecodeOTLPMetrics decodes an OTLP/HTTP metrics export body.
+func DecodeOTLPMetrics(body []byte, isJSON bool) (*metricspb.MetricsData, error) {
+	data := &metricspb.MetricsData{}
+	var err error
+	if isJSON {
+		err = (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, data)
+	} else {
+		err = proto.Unmarshal(body, data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode OTLP metrics: %w", err)
+	}
+	return data, nil
+}
+
+// otlpJSONID recovers an ID from OTLP/JSON. The spec encodes trace and span
+// IDs as hex, but protojson reads bytes fields as base64; re-encoding the
+// decoded bytes gives back the hex text.
+func otlpJSONID(b []byte) []byte {
+	if len(b) == 0 {
+		return b
+	}
+	id, err := hex.DecodeString(base64.StdEncoding.EncodeToString(b))
+	if err != nil {
+		return b
+	}
+	return id
+}
+
+// EventsFromOTLPTraces maps GenAI spans to usage events. Spans without
+// gen_ai.usage.* token attributes are not LLM calls and are skipped, unless
+// they are failed calls to a GenAI model: those, like any span with an error
+// status or error.type, are recorded with status "error".
+func EventsFromOTLPTraces(data *tracepb.TracesData, mapping OTLPMapping) *OTLPResult {
+	result := &OTLPResult{}
+	for _, rs := range data.GetResourceSpans() {
+		for _, ss := range rs.GetScopeSpans() {
+			for _, span := range ss.GetSpans() {
+				attrs := otlpAttrs{span.GetAttributes(), ss.GetScope().GetAttributes(), rs.GetResource().GetAttributes()}
+				input, hasInput := attrs.int("gen_ai.usage.input_tokens", "gen_ai.usage.prompt_tokens")
+				output, hasOutput := attrs.int("gen_ai.usage.output_tokens", "gen_ai.usage.completion_tokens")
+				errorType := attrs.str("error.type")
+				failed := span.GetStatus().GetCode() == tracepb.Status_STATUS_CODE_ERROR || errorType != ""
+				if !hasInput && !hasOutput && (!failed || attrs.str("gen_ai.request.model", "gen_ai.response.model") == "") {
+					continue
+				}
+
+				wire := attrs.eventFields(mapping)
+				wire["ts"] = otlpTime(span.GetEndTimeUnixNano())
+				cacheRead, hasCacheRead := attrs.int("gen_ai.usage.cache_read.input_tokens", "gen_ai.usage.cache_read_input_tokens")
+				cacheWrite, hasCacheWrite := attrs.int("gen_ai.usage.cache_creation.input_tokens", "gen_ai.usage.cache_creation_input_tokens")
+				if hasCacheRead {
+					wire["cache_read_tokens"] = cacheRead
+				}
+				if hasCacheWrite {
+					wire["cache_write_tokens"] = cacheWrite
+				}
+				// Input tokens should include cached ones, but some Anthropic
+				// instrumentations report them separately.
+				if cacheRead+cacheWrite > input {
+					input += cacheRead + cacheWrite
+				}
+				if reasoning, ok := attrs.int("gen_ai.usage.reasoning.output_tokens", "gen_ai.usage.reasoning_tokens"); ok {
+					wire["reasoning_tokens"] = reasoning
+				}
+				wire["prompt_tokens"] = input
+				wire["completion_tokens"] = output
+				if id := attrs.str("gen_ai.response.id"); id != "" {
+					wire["request_id"] = id
+				} else if len(span.GetSpanId()) > 0 {
+					wire["request_id"] = hex.EncodeToString(span.GetSpanId())
+				}
+				if end, start := span.GetEndTimeUnixNano(), span.GetStartTimeUnixNano(); start > 0 && end >= start {
+					wire["latency_ms"] = int((end - start) / uint64(time.Millisecond))
+				}
+				if mapping.CostAttr != "" {
+					if cost, ok := attrs.float(mapping.CostAttr); ok {
+						wire["cost_usd"] = cost
+					}
+				}
+				if failed {
+					wire["status"] = usageevent.StatusError
+					if len(errorType) > usageevent.MaxErrorTypeLen {
+						errorType = errorType[:usageevent.MaxErrorTypeLen]
+					}
+					if errorType != "" {
+						wire["error_type"] = errorType
+					}
+				}
+
+				event, err := usageevent.FromFields(wire, OTLPSourcePath)
+				if err != nil {
+					result.reject(fmt.Errorf("span %q: %w", span.GetName(), err))
+					continue
+				}
+				result.Events = append(result.Events, event)
+			}
+		}
+	}
+	return result
+}
+
+// OTLPMetricsConverter maps gen_ai.client.token.usage metrics to usage events,
+// one per data point and series with input and output tokens combined.
+// Cumulative series are turned into deltas against the previous export, so
+// it keeps the last value of each series between requests.
+type OTLPMetricsConverter struct {
+	mu      sync.Mutex
+	started time.Time
+	last    map[string]otlpCumulative
+}
+
+type otlpCumulative struct {
+	start uint64
+	value float64
+}
+
+// NewOTLPMetricsConverter creates a converter. Cumulative series that began
+// before now are used as a baseline on first sight, since their earlier
+// usage cannot be told apart from usage already recorded.
+func NewOTLPMetricsConverter(now time.Time) *OTLPMetricsConverter {
+	return &OTLPMetricsConverter{started: now, last: make(map[string]otlpCumulative)}
+}
+
+// Convert maps the token usage metrics in data to usage events. Other
+// metrics are ignored.
+func (c *OTLPMetricsConverter) Convert(data *metricspb.MetricsData, mapping OTLPMapping) *OTLPResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := &OTLPResult{}
+	type pending struct {
+		wire     map[string]interface{}
+		input    int
+		output   int
+		hasUsage bool
+	}
+	var order []string
+	groups := make(map[string]*pending)
+
+	for _, rm := range data.GetResourceMetrics() {
+		for _, sm := range rm.GetScopeMetrics() {
+			for _, metric := range sm.GetMetrics() {
+				if metric.GetName() != OTLPTokenUsageMetric {
+					continue
+				}
+				for _, point := range otlpTokenPoints(metric) {
+					attrs := otlpAttrs{point.attrs, sm.GetScope().GetAttributes(), rm.GetResource().GetAttributes()}
+					tokenType := attrs.str("gen_ai.token.type")
+					if tokenType != "input" && tokenType != "output" {
+						continue
+					}
+					value := point.value
+					if point.cumulative {
+						var ok bool
+						value, ok = c.delta(attrs.seriesKey(), point)
+						if !ok {
+							continue
+						}
+					}
+
+					wire := attrs.eventFields(mapping)
+					wire["ts"] = otlpTime(point.time)
+					key := fmt.Sprintf("%v|%v|%v|%v|%v", wire["ts"], wire["integration"], wire["account"], wire["provider"], wire["model"])
+					group := groups[key]
+					if group == nil {
+						group = &pending{wire: wire}
+						groups[key] = group
+						order = append(order, key)
+					}
+					if tokenType == "input" {
+						group.input += int(value)
+					} else {
+						group.output += int(value)
+					}
+					group.hasUsage = group.hasUsage || value > 0
+				}
+			}
+		}
+	}
+
+	for _, key := range order {
+		group := groups[key]
+		if !group.hasUsage {
+			continue
+		}
+		group.wire["prompt_tokens"] = group.input
+		group.wire["completion_tokens"] = group.output
+		event, err := usageevent.FromFields(group.wire, OTLPSourcePath)
+		if err != nil {
+			result.reject(fmt.Errorf("%s: %w", OTLPTokenUsageMetric, err))
+			continue
+		}
+		result.Events = append(result.Events, event)
+	}
+	return result
+}
+
+// delta returns the usage a cumulative point adds to its series. A series
+// seen for the first time counts in full only if it started after the
+// converter; a new start time means the counter restarted.
+func (c *OTLPMetricsConverter) delta(key string, point otlpTokenPoint) (float64, bool) {
+	prev, seen := c.last[key]
+	c.last[key] = otlpCumulative{start: point.start, value: point.value}
+	switch {
+	case !seen:
+		if point.start == 0 || time.Unix(0, int64(point.start)).Before(c.started) {
+			return 0, false
+		}
+		return point.value, true
+	case point.start != prev.start || point.value < prev.value:
+		return point.value, true
+	default:
+		return point.value - prev.value, true
+	}
+}
+
+type otlpTokenPoint struct {
+	attrs      []*commonpb.KeyValue
+	start      uint64
+	time       uint64
+	value      float64
+	cumulative bool
+}
+
+// otlpTokenPoints flattens the histogram (or sum) data points of a token
+// usage metric into token totals.
+func otlpTokenPoints(metric *metricspb.Metric) []otlpTokenPoint {
+	var points []otlpTokenPoint
+	if hist := metric.GetHistogram(); hist != nil {
+		cumulative := hist.GetAggregationTemporality() == metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+		for _, dp := range hist.GetDataPoints() {
+			points = append(points, otlpTokenPoint{dp.GetAttributes(), dp.GetStartTimeUnixNano(), dp.GetTimeUnixNano(), dp.GetSum(), cumulative})
+		}
+	}
+	if sum := metric.GetSum(); sum != nil {
+		cumulative := sum.GetAggregationTemporality() == metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+		for _, dp := range sum.GetDataPoints() {
+			value := dp.GetAsDouble()
+			if _, ok := dp.GetValue().(*metricspb.NumberDataPoint_AsInt); ok {
+				value = float64(dp.GetAsInt())
+			}
+			points = append(points, otlpTokenPoint{dp.GetAttributes(), dp.GetStartTimeUnixNano(), dp.GetTimeUnixNano(), value, cumulative})
+		}
+	}
+	return points
+}
+
+// otlpProviders maps gen_ai.provider.name / gen_ai.system values to onWatch
+// providers. Other values pass through as custom providers.
+var otlpProviders = map[string]string{
+	"openai":          "openai",
+	"azure.ai.openai": "openai",
+	"anthropic":       "anthropic",
+	"mistral_ai":      "mistral",
+	"mistral":         "mistral",
+	"gcp.gemini":      "gemini",
+	"gcp.vertex_ai":   "gemini",
+	"gcp.gen_ai":      "gemini",
+	"gemini":          "gemini",
+	"vertex_ai":       "gemini",
+	"openrouter":      "openrouter",
+	"aws.bedrock":     "bedrock",
+	"x_ai":            "xai",
+}
+
+// otlpAttrs is an attribute lookup chain, most specific first.
+type otlpAttrs [][]*commonpb.KeyValue
+
+func (a otlpAttrs) value(key string) *commonpb.AnyValue {
+	for _, set := range a {
+		for _, kv := range set {
+			if kv.GetKey() == key {
+				return kv.GetValue()
+			}
+		}
+	}
+	return nil
+}
+
+func (a otlpAttrs) str(keys ...string) string {
+	for _, key := range keys {
+		v := a.value(key)
+		if v == nil {
+			continue
+		}
+		switch x := v.GetValue().(type) {
+		case *commonpb.AnyValue_StringValue:
+			return x.StringValue
+		case *commonpb.AnyValue_IntValue:
+			return strconv.FormatInt(x.IntValue, 10)
+		case *commonpb.AnyValue_DoubleValue:
+			return strconv.FormatFloat(x.DoubleValue, 'f', -1, 64)
+		case *commonpb.AnyValue_BoolValue:
+			return strconv.FormatBool(x.BoolValue)
+		}
+	}
+	return ""
+}
+
+func (a otlpAttrs) float(keys ...string) (float64, bool) {
+	for _, key := range keys {
+		v := a.value(key)
+		if v == nil {
+			continue
+		}
+		switch x := v.GetValue().(type) {
+		case *commonpb.AnyValue_IntValue:
+			return float64(x.IntValue), true
+		case *commonpb.AnyValue_DoubleValue:
+			return x.DoubleValue, true
+		case *commonpb.AnyValue_StringValue:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(x.StringValue), 64); err == nil {
+				return f, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func (a otlpAttrs) int(keys ...string) (int, bool) {
+	f, ok := a.float(keys...)
+	return int(f), ok
+}
+
+// eventFields returns the usage event fields shared by spans and metrics.
+func (a otlpAttrs) eventFields(mapping OTLPMapping) map[string]interface{} {
+	integrationAttr := mapping.IntegrationAttr
+	if integrationAttr == "" {
+		integrationAttr = "service.name"
+	}
+	system := strings.ToLower(a.str("gen_ai.provider.name", "gen_ai.system"))
+	provider, known := otlpProviders[system]
+	if !known {
+		provider = system
+	}
+	wire := map[string]interface{}{
+		"integration": a.str(integrationAttr),
+		"provider":    provider,
+		"model":       a.str("gen_ai.response.model", "gen_ai.request.model"),
+	}
+	if mapping.AccountAttr != "" {
+		wire["account"] = a.str(mapping.AccountAttr)
+	}
+	if op := a.str("gen_ai.operation.name"); op != "" {
+		wire["metadata"] = map[string]string{"operation": op}
+	}
+	return wire
+}
+
+// seriesKey identifies a metric series by all of its attributes.
+func (a otlpAttrs) seriesKey() string {
+	var parts []string
+	for i, set := range a {
+		for _, kv := range set {
+			parts = append(parts, fmt.Sprintf("%d:%s=%s", i, kv.GetKey(), otlpAttrs{{kv}}.str(kv.GetKey())))
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "\x00")
+}
+
+func otlpTime(unixNano uint64) string {
+	if unixNano == 0 {
+		return time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	return time.Unix(0, int64(unixNano)).UTC().Format(time.RFC3339Nano)
+}
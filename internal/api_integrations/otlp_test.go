@@ -0,0 +1,184 @@
+package apiintegrations
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
+)
+
+const otlpTraceJSON = `{"resourceSpans":[{
+	"resource":{"attributes":[
+		{"key":"service.name","value":{"stringValue":"support-bot"}},
+		{"key":"tenant.id","value":{"stringValue":"acme"}}]},
+	"scopeSpans":[{"spans":[
+		{"traceId":"5b8efff798038103d269b633813fc60c","spanId":"eee19b7ec3c1b174","name":"chat claude",
+		 "startTimeUnixNano":"1775217600000000000","endTimeUnixNano":"1775217601250000000",
+		 "attributes":[
+			{"key":"gen_ai.system","value":{"stringValue":"anthropic"}},
+			{"key":"gen_ai.operation.name","value":{"stringValue":"chat"}},
+			{"key":"gen_ai.request.model","value":{"stringValue":"claude-3-7-sonnet"}},
+			{"key":"gen_ai.usage.input_tokens","value":{"intValue":"120"}},
+			{"key":"gen_ai.usage.output_tokens","value":{"intValue":"30"}},
+			{"key":"llm.cost_usd","value":{"doubleValue":0.0021}}]},
+		{"spanId":"aaaaaaaaaaaaaaaa","name":"GET /health","attributes":[]},
+		{"spanId":"bbbbbbbbbbbbbbbb","name":"chat bedrock","endTimeUnixNano":"1775217602000000000",
+		 "attributes":[
+			{"key":"gen_ai.system","value":{"stringValue":"aws.bedrock"}},
+			{"key":"gen_ai.request.model","value":{"stringValue":"titan"}},
+			{"key":"gen_ai.usage.input_tokens","value":{"intValue":"5"}}]},
+		{"spanId":"cccccccccccccccc","name":"chat other","endTimeUnixNano":"1775217603000000000",
+		 "attributes":[
+			{"key":"gen_ai.system","value":{"stringValue":"acme llm"}},
+			{"key":"gen_ai.request.model","value":{"stringValue":"m"}},
+			{"key":"gen_ai.usage.input_tokens","value":{"intValue":"5"}}]}
+	]}]}]}`
+
+func TestEventsFromOTLPTraces_JSON(t *testing.T) {
+	data, err := DecodeOTLPTraces([]byte(otlpTraceJSON), true)
+	if err != nil {
+		t.Fatalf("DecodeOTLPTraces: %v", err)
+	}
+	result := EventsFromOTLPTraces(data, OTLPMapping{AccountAttr: "tenant.id", CostAttr: "llm.cost_usd"})
+
+	if len(result.Events) != 2 {
+		t.Fatalf("events=%d want 2 (non-GenAI span skipped, invalid provider rejected)", len(result.Events))
+	}
+	if result.Rejected != 1 || !strings.Contains(result.ErrorMessage(), `invalid provider "acme llm"`) {
+		t.Fatalf("rejected=%d errors=%q", result.Rejected, result.ErrorMessage())
+	}
+	if result.Events[1].Provider != "bedrock" {
+		t.Fatalf("provider=%q want aws.bedrock mapped to bedrock", result.Events[1].Provider)
+	}
+
+	event := result.Events[0]
+	if event.Integration != "support-bot" || event.Account != "acme" || event.Provider != "anthropic" || event.Model != "claude-3-7-sonnet" {
+		t.Fatalf("event=%+v", event)
+	}
+	if event.PromptTokens != 120 || event.CompletionTokens != 30 || event.TotalTokens != 150 {
+		t.Fatalf("tokens=%d/%d/%d", event.PromptTokens, event.CompletionTokens, event.TotalTokens)
+	}
+	if event.RequestID != "eee19b7ec3c1b174" {
+		t.Fatalf("request_id=%q want the hex span id", event.RequestID)
+	}
+	if event.LatencyMS == nil || *event.LatencyMS != 1250 {
+		t.Fatalf("latency=%v want 1250", event.LatencyMS)
+	}
+	if event.CostUSD == nil || *event.CostUSD != 0.0021 {
+		t.Fatalf("cost=%v want 0.0021", event.CostUSD)
+	}
+	if !event.Timestamp.Equal(time.Unix(0, 1775217601250000000)) {
+		t.Fatalf("ts=%v", event.Timestamp)
+	}
+	if event.SourcePath != OTLPSourcePath || event.MetadataJSON != `{"operation":"chat"}` {
+		t.Fatalf("source=%q metadata=%q", event.SourcePath, event.MetadataJSON)
+	}
+}
+
+func TestEventsFromOTLPTraces_ErrorSpans(t *testing.T) {
+	data, err := DecodeOTLPTraces([]byte(`{"resourceSpans":[{
+		"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"support-bot"}}]},
+		"scopeSpans":[{"spans":[
+			{"spanId":"dddddddddddddddd","name":"chat gpt-4.1","startTimeUnixNano":"1775217600000000000","endTimeUnixNano":"1775217630000000000",
+			 "status":{"code":2,"message":"deadline"},
+			 "attributes":[
+				{"key":"gen_ai.system","value":{"stringValue":"openai"}},
+				{"key":"gen_ai.request.model","value":{"stringValue":"gpt-4.1"}},
+				{"key":"error.type","value":{"stringValue":"timeout"}}]},
+			{"spanId":"eeeeeeeeeeeeeeee","name":"GET /broken","status":{"code":2}},
+			{"spanId":"ffffffffffffffff","name":"chat o3","endTimeUnixNano":"1775217640000000000",
+			 "status":{"code":2},
+			 "attributes":[
+				{"key":"gen_ai.system","value":{"stringValue":"openai"}},
+				{"key":"gen_ai.request.model","value":{"stringValue":"o3"}},
+				{"key":"gen_ai.usage.input_tokens","value":{"intValue":"40"}}]}
+		]}]}]}`), true)
+	if err != nil {
+		t.Fatalf("DecodeOTLPTraces: %v", err)
+	}
+	result := EventsFromOTLPTraces(data, OTLPMapping{})
+	if len(result.Events) != 2 || result.Rejected != 0 {
+		t.Fatalf("events=%d rejected=%d want 2 and 0 (non-GenAI error span skipped)", len(result.Events), result.Rejected)
+	}
+	timeout := result.Events[0]
+	if timeout.Status != usageevent.StatusError || timeout.ErrorType != "timeout" || timeout.PromptTokens != 0 || timeout.LatencyMS == nil || *timeout.LatencyMS != 30000 {
+		t.Fatalf("event=%+v", timeout)
+	}
+	if failed := result.Events[1]; failed.Status != usageevent.StatusError || failed.ErrorType != "" || failed.PromptTokens != 40 {
+		t.Fatalf("event=%+v", failed)
+	}
+}
+
+func otlpTokenUsage(temporality metricspb.AggregationTemporality, start, at time.Time, input, output float64) *metricspb.MetricsData {
+	point := func(tokenType string, sum float64) *metricspb.HistogramDataPoint {
+		return &metricspb.HistogramDataPoint{
+			StartTimeUnixNano: uint64(start.UnixNano()),
+			TimeUnixNano:      uint64(at.UnixNano()),
+			Count:             1,
+			Sum:               &sum,
+			Attributes: []*commonpb.KeyValue{
+				{Key: "gen_ai.token.type", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: tokenType}}},
+				{Key: "gen_ai.system", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "openai"}}},
+				{Key: "gen_ai.request.model", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "gpt-4.1"}}},
+			},
+		}
+	}
+	return &metricspb.MetricsData{ResourceMetrics: []*metricspb.ResourceMetrics{{
+		Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{
+			{Key: "service.name", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "worker"}}},
+		}},
+		ScopeMetrics: []*metricspb.ScopeMetrics{{Metrics: []*metricspb.Metric{
+			{Name: "http.server.duration"},
+			{Name: OTLPTokenUsageMetric, Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+				AggregationTemporality: temporality,
+				DataPoints:             []*metricspb.HistogramDataPoint{point("input", input), point("output", output)},
+			}}},
+		}}},
+	}}}
+}
+
+func TestOTLPMetricsConverter_Delta(t *testing.T) {
+	now := time.Date(2026, 4, 3, 12, 0, 0, 0, time.UTC)
+	c := NewOTLPMetricsConverter(now)
+
+	result := c.Convert(otlpTokenUsage(metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA, now, now.Add(time.Minute), 40, 10), OTLPMapping{})
+	if len(result.Events) != 1 || result.Rejected != 0 {
+		t.Fatalf("events=%d rejected=%d want 1 combined event", len(result.Events), result.Rejected)
+	}
+	event := result.Events[0]
+	if event.Integration != "worker" || event.Provider != "openai" || event.Model != "gpt-4.1" || event.PromptTokens != 40 || event.CompletionTokens != 10 {
+		t.Fatalf("event=%+v", event)
+	}
+}
+
+func TestOTLPMetricsConverter_CumulativeDiffsAgainstPreviousExport(t *testing.T) {
+	now := time.Date(2026, 4, 3, 12, 0, 0, 0, time.UTC)
+	cumulative := metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+
+	// A series that started before the receiver is only a baseline.
+	c := NewOTLPMetricsConverter(now)
+	early := now.Add(-time.Hour)
+	if result := c.Convert(otlpTokenUsage(cumulative, early, now.Add(time.Minute), 500, 100), OTLPMapping{}); len(result.Events) != 0 {
+		t.Fatalf("baseline events=%d want 0", len(result.Events))
+	}
+	result := c.Convert(otlpTokenUsage(cumulative, early, now.Add(2*time.Minute), 530, 104), OTLPMapping{})
+	if len(result.Events) != 1 || result.Events[0].PromptTokens != 30 || result.Events[0].CompletionTokens != 4 {
+		t.Fatalf("events=%+v want one 30/4 delta", result.Events)
+	}
+	// Re-sending the same cumulative value adds nothing.
+	if result := c.Convert(otlpTokenUsage(cumulative, early, now.Add(3*time.Minute), 530, 104), OTLPMapping{}); len(result.Events) != 0 {
+		t.Fatalf("unchanged events=%d want 0", len(result.Events))
+	}
+
+	// A restarted counter counts in full.
+	restart := now.Add(4 * time.Minute)
+	result = c.Convert(otlpTokenUsage(cumulative, restart, now.Add(5*time.Minute), 8, 2), OTLPMapping{})
+	if len(result.Events) != 1 || result.Events[0].PromptTokens != 8 || result.Events[0].CompletionTokens != 2 {
+		t.Fatalf("events=%+v want one 8/2 event after restart", result.Events)
+	}
+}
@@ -0,0 +1,243 @@
+package apiintegrations
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
+)
+
+// pricingDateLayout is the layout of ModelPrice.EffectiveFrom.
+const pricingDateLayout = "2006-01-02"
+
+//go:embed pricing.json
+var builtinPricingJSON []byte
+
+// ModelPrice is the list price of one model from a date on, in USD per
+// million tokens. Cache rates default to the input rate when unset.
+type ModelPrice struct {
+	Provider             string   `json:"provider"`
+	Model                string   `json:"model"`
+	EffectiveFrom        string   `json:"effective_from"`
+	InputUSDPerMTok      float64  `json:"input_usd_per_mtok"`
+	OutputUSDPerMTok     float64  `json:"output_usd_per_mtok"`
+	CacheReadUSDPerMTok  *float64 `json:"cache_read_usd_per_mtok,omitempty"`
+	CacheWriteUSDPerMTok *float64 `json:"cache_write_usd_per_mtok,omitempty"`
+
+	from time.Time
+}
+
+// Cost returns the cost of event's tokens at these rates.
+func (p ModelPrice) Cost(event *usageevent.Event) float64 {
+	cacheRead := derefInt(event.CacheReadTokens)
+	cacheWrite := derefInt(event.CacheWriteTokens)
+	readRate, writeRate := p.InputUSDPerMTok, p.InputUSDPerMTok
+	if p.CacheReadUSDPerMTok != nil {
+		readRate = *p.CacheReadUSDPerMTok
+	}
+	if p.CacheWriteUSDPerMTok != nil {
+		writeRate = *p.CacheWriteUSDPerMTok
+	}
+	uncached := event.PromptTokens - cacheRead - cacheWrite
+	return (float64(uncached)*p.InputUSDPerMTok +
+		float64(cacheRead)*readRate +
+		float64(cacheWrite)*writeRate +
+		float64(event.CompletionTokens)*p.OutputUSDPerMTok) / 1e6
+}
+
+// PricingCatalog is a versioned table of model prices.
+type PricingCatalog struct {
+	Version string       `json:"version"`
+	Prices  []ModelPrice `json:"prices"`
+
+	// byModel holds the prices of each provider and model, oldest first.
+	byModel map[string][]ModelPrice
+}
+
+// ParsePricingCatalog parses and validates a catalog in the format of the
+// built-in pricing.json.
+func ParsePricingCatalog(data []byte) (*PricingCatalog, error) {
+	var c PricingCatalog
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid pricing catalog: %w", err)
+	}
+	seen := make(map[string]bool, len(c.Prices))
+	for i := range c.Prices {
+		p := &c.Prices[i]
+		provider, err := usageevent.NormalizeProvider(p.Provider)
+		if err != nil {
+			return nil, fmt.Errorf("price %d: %w", i+1, err)
+		}
+		p.Provider = provider
+		p.Model = strings.ToLower(strings.TrimSpace(p.Model))
+		if p.Model == "" {
+			return nil, fmt.Errorf("price %d: model is required", i+1)
+		}
+		if p.from, err = time.Parse(pricingDateLayout, p.EffectiveFrom); err != nil {
+			return nil, fmt.Errorf("price %d: effective_from must be YYYY-MM-DD", i+1)
+		}
+		for _, rate := range []*float64{&p.InputUSDPerMTok, &p.OutputUSDPerMTok, p.CacheReadUSDPerMTok, p.CacheWriteUSDPerMTok} {
+			if rate != nil && (*rate < 0 || math.IsNaN(*rate) || math.IsInf(*rate, 0)) {
+				return nil, fmt.Errorf("price %d: rates must be non-negative", i+1)
+			}
+		}
+		key := pricingKey(p.Provider, p.Model) + "@" + p.EffectiveFrom
+		if seen[key] {
+			return nil, fmt.Errorf("price %d: duplicate price for %s/%s from %s", i+1, p.Provider, p.Model, p.EffectiveFrom)
+		}
+		seen[key] = true
+	}
+	c.index()
+	return &c, nil
+}
+
+// BuiltinPricingCatalog returns the catalog shipped with onWatch.
+func BuiltinPricingCatalog() *PricingCatalog {
+	c, err := ParsePricingCatalog(builtinPricingJSON)
+	if err != nil {
+		panic("apiintegrations: invalid built-in pricing catalog: " + err.Error())
+	}
+	return c
+}
+
+// LoadPricingCatalog returns the built-in catalog overridden by the catalog
+// file at path, if any. A file price replaces the built-in price for the same
+// provider, model and effective date; other file prices are added.
+func LoadPricingCatalog(path string) (*PricingCatalog, error) {
+	builtin := BuiltinPricingCatalog()
+	if path == "" {
+		return builtin, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing file: %w", err)
+	}
+	local, err := ParsePricingCatalog(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	localVersion := local.Version
+	if localVersion == "" {
+		localVersion = "local"
+	}
+	merged := &PricingCatalog{Version: builtin.Version + "+" + localVersion}
+	replaced := make(map[string]bool, len(local.Prices))
+	for _, p := range local.Prices {
+		replaced[pricingKey(p.Provider, p.Model)+"@"+p.EffectiveFrom] = true
+	}
+	for _, p := range builtin.Prices {
+		if !replaced[pricingKey(p.Provider, p.Model)+"@"+p.EffectiveFrom] {
+			merged.Prices = append(merged.Prices, p)
+		}
+	}
+	merged.Prices = append(merged.Prices, local.Prices...)
+	merged.index()
+	return merged, nil
+}
+
+func (c *PricingCatalog) index() {
+	c.byModel = make(map[string][]ModelPrice)
+	for _, p := range c.Prices {
+		key := pricingKey(p.Provider, p.Model)
+		c.byModel[key] = append(c.byModel[key], p)
+	}
+	for _, prices := range c.byModel {
+		sort.Slice(prices, func(i, j int) bool { return prices[i].from.Before(prices[j].from) })
+	}
+}
+
+func pricingKey(provider, model string) string {
+	return provider + "/" + model
+}
+
+// modelVersionSuffix matches the dated snapshot suffix of model names such as
+// claude-sonnet-4-20250514, gpt-4.1-2025-04-14 and claude-3-7-sonnet@20250219.
+var modelVersionSuffix = regexp.MustCompile(`[-@](\d{8}|\d{4}-\d{2}-\d{2})$`)
+
+// Lookup returns the price of a model in effect at the given time. Dated
+// snapshots and -latest aliases fall back to the base model's price, and
+// routed models such as anthropic/claude-sonnet-4 to the named provider's.
+// Calls made before a model's first listed price use that price.
+func (c *PricingCatalog) Lookup(provider, model string, at time.Time) (ModelPrice, bool) {
+	if c == nil {
+		return ModelPrice{}, false
+	}
+	model = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(model)), "models/")
+	for _, candidate := range []string{
+		model,
+		modelVersionSuffix.ReplaceAllString(model, ""),
+		strings.TrimSuffix(model, "-latest"),
+	} {
+		prices := c.byModel[pricingKey(provider, candidate)]
+		if len(prices) == 0 {
+			continue
+		}
+		price := prices[0]
+		for _, p := range prices[1:] {
+			if p.from.After(at) {
+				break
+			}
+			price = p
+		}
+		return price, true
+	}
+	if vendor, name, ok := strings.Cut(model, "/"); ok {
+		if vendor, err := usageevent.NormalizeProvider(vendor); err == nil && vendor != provider {
+			return c.Lookup(vendor, name, at)
+		}
+	}
+	return ModelPrice{}, false
+}
+
+// CostEstimator estimates the cost of events that do not report one, from
+// the pricing catalog or else from the provider's configured rates.
+type CostEstimator struct {
+	catalog   *PricingCatalog
+	providers *ProviderRegistry
+}
+
+// NewCostEstimator returns an estimator using catalog and providers; either
+// may be nil.
+func NewCostEstimator(catalog *PricingCatalog, providers *ProviderRegistry) *CostEstimator {
+	return &CostEstimator{catalog: catalog, providers: providers}
+}
+
+// EstimateCost returns the estimated cost of event in USD. ok is false when
+// neither the catalog nor the provider has rates for it.
+func (e *CostEstimator) EstimateCost(event *usageevent.Event) (cost float64, ok bool) {
+	if e == nil || event == nil {
+		return 0, false
+	}
+	if price, ok := e.catalog.Lookup(event.Provider, event.Model, event.Timestamp); ok {
+		return price.Cost(event), true
+	}
+	if e.providers == nil {
+		return 0, false
+	}
+	provider, _ := e.providers.Lookup(event.Provider)
+	if provider.InputUSDPerMTok == nil && provider.OutputUSDPerMTok == nil {
+		return 0, false
+	}
+	var price ModelPrice
+	if provider.InputUSDPerMTok != nil {
+		price.InputUSDPerMTok = *provider.InputUSDPerMTok
+	}
+	if provider.OutputUSDPerMTok != nil {
+		price.OutputUSDPerMTok = *provider.OutputUSDPerMTok
+	}
+	return price.Cost(event), true
+}
+
+func derefInt(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
@@ -0,0 +1,121 @@
+package apiintegrations
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
+)
+
+func TestBuiltinPricingCatalog_Lookup(t *testing.T) {
+	c := BuiltinPricingCatalog()
+	if c.Version == "" || len(c.Prices) == 0 {
+		t.Fatalf("catalog=%+v", c)
+	}
+
+	at := time.Date(2026, 4, 3, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		provider, model string
+		at              time.Time
+		wantModel       string
+		wantInput       float64
+	}{
+		{"anthropic", "claude-sonnet-4-20250514", at, "claude-sonnet-4", 3},
+		{"anthropic", "claude-3-7-sonnet-latest", at, "claude-3-7-sonnet", 3},
+		{"openai", "GPT-4.1-mini-2025-04-14", at, "gpt-4.1-mini", 0.4},
+		{"gemini", "models/gemini-2.5-flash", at, "gemini-2.5-flash", 0.3},
+		{"openrouter", "anthropic/claude-opus-4-1", at, "claude-opus-4-1", 15},
+		{"openai", "o3", time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC), "o3", 10},
+		{"openai", "o3", at, "o3", 2},
+		{"openai", "o3", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "o3", 10},
+	}
+	for _, tt := range tests {
+		price, ok := c.Lookup(tt.provider, tt.model, tt.at)
+		if !ok || price.Model != tt.wantModel || price.InputUSDPerMTok != tt.wantInput {
+			t.Errorf("Lookup(%s, %s, %s) = %+v, %v", tt.provider, tt.model, tt.at.Format("2006-01-02"), price, ok)
+		}
+	}
+	for _, miss := range [][2]string{{"openai", "gpt-4.1-turbo"}, {"vllm", "qwen3"}, {"anthropic", "gpt-4.1"}} {
+		if _, ok := c.Lookup(miss[0], miss[1], at); ok {
+			t.Errorf("Lookup(%s, %s) found a price", miss[0], miss[1])
+		}
+	}
+}
+
+func TestModelPrice_CostWithCache(t *testing.T) {
+	cacheRead, cacheWrite := 0.3, 3.75
+	price := ModelPrice{InputUSDPerMTok: 3, OutputUSDPerMTok: 15, CacheReadUSDPerMTok: &cacheRead, CacheWriteUSDPerMTok: &cacheWrite}
+	read, write := 600_000, 200_000
+	event := &usageevent.Event{PromptTokens: 1_000_000, CompletionTokens: 100_000, CacheReadTokens: &read, CacheWriteTokens: &write}
+	// 200k uncached at $3, 600k reads at $0.30, 200k writes at $3.75, 100k output at $15.
+	if got, want := price.Cost(event), 0.6+0.18+0.75+1.5; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("Cost=%v want %v", got, want)
+	}
+	price.CacheWriteUSDPerMTok = nil
+	if got, want := price.Cost(event), 0.6+0.18+0.6+1.5; math.Abs(got-want) > 1e-9 {
+		t.Fatalf("Cost without write rate=%v want %v", got, want)
+	}
+}
+
+func TestLoadPricingCatalog_Override(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pricing.json")
+	if err := os.WriteFile(path, []byte(`{"version":"team-1","prices":[
+		{"provider":"openai","model":"gpt-4.1","effective_from":"2025-04-14","input_usd_per_mtok":1.5,"output_usd_per_mtok":6},
+		{"provider":"lab-vllm","model":"qwen3","effective_from":"2025-01-01","input_usd_per_mtok":0.05,"output_usd_per_mtok":0.1}
+	]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadPricingCatalog(path)
+	if err != nil {
+		t.Fatalf("LoadPricingCatalog: %v", err)
+	}
+	if c.Version != BuiltinPricingCatalog().Version+"+team-1" {
+		t.Fatalf("version=%q", c.Version)
+	}
+	at := time.Date(2026, 4, 3, 0, 0, 0, 0, time.UTC)
+	if p, _ := c.Lookup("openai", "gpt-4.1", at); p.InputUSDPerMTok != 1.5 || p.CacheReadUSDPerMTok != nil {
+		t.Fatalf("gpt-4.1=%+v want the override", p)
+	}
+	if _, ok := c.Lookup("lab-vllm", "qwen3", at); !ok {
+		t.Fatal("custom model price missing")
+	}
+	if p, _ := c.Lookup("openai", "gpt-4.1-mini", at); p.InputUSDPerMTok != 0.4 {
+		t.Fatalf("gpt-4.1-mini=%+v want the built-in price", p)
+	}
+
+	if _, err := LoadPricingCatalog(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+	for _, raw := range []string{
+		`{"prices":[{"provider":"openai","model":"x","effective_from":"2025/01/01","input_usd_per_mtok":1,"output_usd_per_mtok":1}]}`,
+		`{"prices":[{"provider":"openai","model":"","effective_from":"2025-01-01","input_usd_per_mtok":1,"output_usd_per_mtok":1}]}`,
+		`{"prices":[{"provider":"openai","model":"x","effective_from":"2025-01-01","input_usd_per_mtok":-1,"output_usd_per_mtok":1}]}`,
+		`{"prices":[{"provider":"open ai","model":"x","effective_from":"2025-01-01","input_usd_per_mtok":1,"output_usd_per_mtok":1}]}`,
+		`{"prices":[{"provider":"openai","model":"x","effective_from":"2025-01-01"},{"provider":"openai","model":"X","effective_from":"2025-01-01"}]}`,
+	} {
+		if _, err := ParsePricingCatalog([]byte(raw)); err == nil {
+			t.Errorf("ParsePricingCatalog(%s) succeeded, want error", raw)
+		}
+	}
+}
+
+func TestCostEstimator_FallsBackToProviderRates(t *testing.T) {
+	in, out := 1.0, 2.0
+	estimator := NewCostEstimator(BuiltinPricingCatalog(), NewProviderRegistry([]Provider{{ID: "lab-vllm", DisplayName: "Lab", InputUSDPerMTok: &in, OutputUSDPerMTok: &out}}))
+	ts := time.Date(2026, 4, 3, 0, 0, 0, 0, time.UTC)
+
+	cost, ok := estimator.EstimateCost(&usageevent.Event{Timestamp: ts, Provider: "openai", Model: "gpt-4.1", PromptTokens: 1_000_000, CompletionTokens: 1_000_000})
+	if !ok || math.Abs(cost-10) > 1e-9 {
+		t.Fatalf("catalog cost=%v ok=%v want 10", cost, ok)
+	}
+	cost, ok = estimator.EstimateCost(&usageevent.Event{Timestamp: ts, Provider: "lab-vllm", Model: "qwen3", PromptTokens: 500_000, CompletionTokens: 250_000})
+	if !ok || math.Abs(cost-1) > 1e-9 {
+		t.Fatalf("provider rate cost=%v ok=%v want 1", cost, ok)
+	}
+	if _, ok := estimator.EstimateCost(&usageevent.Event{Timestamp: ts, Provider: "ollama", Model: "llama3", PromptTokens: 1}); ok {
+		t.Fatal("estimated a cost without rates")
+	}
+}
@@ -0,0 +1,81 @@
+package apiintegrations
+
+import "sort"
+
+// Provider describes an LLM API provider usage events can be attributed to.
+// Rates are optional list prices in USD per million tokens.
+type Provider struct {
+	ID               string   `json:"id"`
+	DisplayName      string   `json:"display_name"`
+	InputUSDPerMTok  *float64 `json:"input_usd_per_mtok,omitempty"`
+	OutputUSDPerMTok *float64 `json:"output_usd_per_mtok,omitempty"`
+}
+
+// builtinProviders are known without configuration. Events may name any other
+// provider; those are treated as custom.
+var builtinProviders = []Provider{
+	{ID: "anthropic", DisplayName: "Anthropic"},
+	{ID: "openai", DisplayName: "OpenAI"},
+	{ID: "mistral", DisplayName: "Mistral"},
+	{ID: "openrouter", DisplayName: "OpenRouter"},
+	{ID: "gemini", DisplayName: "Gemini"},
+	{ID: "deepseek", DisplayName: "DeepSeek"},
+	{ID: "groq", DisplayName: "Groq"},
+	{ID: "xai", DisplayName: "xAI"},
+	{ID: "together", DisplayName: "Together AI"},
+	{ID: "fireworks", DisplayName: "Fireworks AI"},
+	{ID: "bedrock", DisplayName: "Amazon Bedrock"},
+	{ID: "azure-openai", DisplayName: "Azure OpenAI"},
+	{ID: "cohere", DisplayName: "Cohere"},
+	{ID: "perplexity", DisplayName: "Perplexity"},
+	{ID: "moonshot", DisplayName: "Moonshot"},
+	{ID: "vllm", DisplayName: "vLLM"},
+	{ID: "ollama", DisplayName: "Ollama"},
+}
+
+// BuiltinProviders returns the providers known without configuration.
+func BuiltinProviders() []Provider {
+	return append([]Provider(nil), builtinProviders...)
+}
+
+// ProviderRegistry resolves provider ids to display names and rates: the
+// built-in providers, plus configured entries that add providers or override
+// built-in ones.
+type ProviderRegistry struct {
+	byID map[string]Provider
+}
+
+// NewProviderRegistry creates a registry from the built-in providers and
+// configured, which are expected to be validated already.
+func NewProviderRegistry(configured []Provider) *ProviderRegistry {
+	r := &ProviderRegistry{byID: make(map[string]Provider, len(builtinProviders)+len(configured))}
+	for _, p := range builtinProviders {
+		r.byID[p.ID] = p
+	}
+	for _, p := range configured {
+		if base, ok := r.byID[p.ID]; ok && p.DisplayName == "" {
+			p.DisplayName = base.DisplayName
+		}
+		r.byID[p.ID] = p
+	}
+	return r
+}
+
+// Lookup returns the provider with the given id. Unknown ids are custom
+// providers: ok is false and the returned provider is named after its id.
+func (r *ProviderRegistry) Lookup(id string) (Provider, bool) {
+	if p, ok := r.byID[id]; ok {
+		return p, true
+	}
+	return Provider{ID: id, DisplayName: id}, false
+}
+
+// Providers returns the registered providers sorted by id.
+func (r *ProviderRegistry) Providers() []Provider {
+	out := make([]Provider, 0, len(r.byID))
+	for _, p := range r.byID {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
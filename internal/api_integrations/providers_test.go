@@ -0,0 +1,25 @@
+package apiintegrations
+
+import "testing"
+
+func TestProviderRegistry(t *testing.T) {
+	rate := 0.27
+	r := NewProviderRegistry([]Provider{
+		{ID: "deepseek", InputUSDPerMTok: &rate},
+		{ID: "local-vllm", DisplayName: "Lab vLLM"},
+	})
+
+	p, ok := r.Lookup("deepseek")
+	if !ok || p.DisplayName != "DeepSeek" || p.InputUSDPerMTok == nil || *p.InputUSDPerMTok != rate {
+		t.Fatalf("deepseek=%+v ok=%v want built-in name with configured rate", p, ok)
+	}
+	if p, ok := r.Lookup("local-vllm"); !ok || p.DisplayName != "Lab vLLM" {
+		t.Fatalf("local-vllm=%+v ok=%v", p, ok)
+	}
+	if p, ok := r.Lookup("acme"); ok || p.DisplayName != "acme" {
+		t.Fatalf("acme=%+v ok=%v want custom", p, ok)
+	}
+	if got := len(r.Providers()); got != len(builtinProviders)+1 {
+		t.Fatalf("Providers()=%d entries want %d", got, len(builtinProviders)+1)
+	}
+}
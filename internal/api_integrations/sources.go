@@ -0,0 +1,196 @@
+package apiintegrations
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultSources returns the source patterns used when none are configured:
+// JSONL files anywhere under dir plus their gzip and zstd archives, including
+// logrotate names such as notes.jsonl.1.gz.
+func DefaultSources(dir string) []string {
+	return []string{
+		filepath.Join(dir, "**", "*.jsonl"),
+		filepath.Join(dir, "**", "*.jsonl*.gz"),
+		filepath.Join(dir, "**", "*.jsonl*.zst"),
+	}
+}
+
+// ExpandSources returns the regular files matching any of patterns, sorted
+// and without duplicates. Patterns use filepath.Match syntax per path
+// segment, and a "**" segment matches any number of directories.
+func ExpandSources(patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := globSource(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("source %q: %w", pattern, err)
+		}
+		for _, path := range matches {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func globSource(pattern string) ([]string, error) {
+	segments := strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/")
+	// The walk starts at the longest prefix without wildcards.
+	static := 0
+	for static < len(segments) && !strings.ContainsAny(segments[static], `*?[\`) {
+		static++
+	}
+	if static == len(segments) {
+		if info, err := os.Stat(pattern); err == nil && info.Mode().IsRegular() {
+			return []string{filepath.Clean(pattern)}, nil
+		}
+		return nil, nil
+	}
+	for _, segment := range segments[static:] {
+		if _, err := filepath.Match(segment, ""); err != nil {
+			return nil, err
+		}
+	}
+	root := filepath.FromSlash(strings.Join(segments[:static], "/"))
+	if static == 1 && segments[0] == "" {
+		root = string(filepath.Separator)
+	} else if root == "" {
+		root = "."
+	}
+	rest := segments[static:]
+	recursive := false
+	for _, segment := range rest {
+		recursive = recursive || segment == "**"
+	}
+
+	var matches []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		rel, relErr := filepath.Rel(root, path)
+		if relErr != nil || rel == "." {
+			return nil
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if d.IsDir() {
+			if !recursive && len(parts) >= len(rest) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && matchSourceSegments(rest, parts) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	return matches, err
+}
+
+func matchSourceSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSourceSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	ok, _ := filepath.Match(pattern[0], parts[0])
+	return ok && matchSourceSegments(pattern[1:], parts[1:])
+}
+
+// MatchesSources reports whether path matches any of patterns, as
+// ExpandSources would find it.
+func MatchesSources(patterns []string, path string) bool {
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+	for _, pattern := range patterns {
+		if matchSourceSegments(strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/"), parts) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsArchiveSource reports whether path is a gzip or zstd archive, which is
+// read once instead of tailed.
+func IsArchiveSource(path string) bool {
+	return strings.HasSuffix(path, ".gz") || strings.HasSuffix(path, ".zst")
+}
+
+// rotatedSourceSuffix matches the compression and rotation suffixes that
+// follow ".jsonl" in rotated sources: notes.jsonl.1, notes.jsonl-20260403.gz.
+var rotatedSourceSuffix = regexp.MustCompile(`\.jsonl(?:[.-][0-9]+)?(?:\.gz|\.zst)?$`)
+
+// LogicalSourcePath returns the source path events read from path are
+// attributed to: the live file a rotated copy or archive was made from.
+// Events keep the same fingerprint whichever copy they are read from.
+func LogicalSourcePath(path string) string {
+	if loc := rotatedSourceSuffix.FindStringIndex(path); loc != nil {
+		return path[:loc[0]] + ".jsonl"
+	}
+	return path
+}
+
+// OpenArchiveSource opens a gzip or zstd archive for reading its
+// decompressed contents.
+func OpenArchiveSource(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	var r io.ReadCloser
+	switch {
+	case strings.HasSuffix(path, ".gz"):
+		r, err = gzip.NewReader(file)
+	case strings.HasSuffix(path, ".zst"):
+		var dec *zstd.Decoder
+		if dec, err = zstd.NewReader(file); err == nil {
+			r = dec.IOReadCloser()
+		}
+	default:
+		err = fmt.Errorf("not an archive: %s", path)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return archiveReader{ReadCloser: r, file: file}, nil
+}
+
+type archiveReader struct {
+	io.ReadCloser
+	file *os.File
+}
+
+func (r archiveReader) Close() error {
+	err := r.ReadCloser.Close()
+	if fileErr := r.file.Close(); err == nil {
+		err = fileErr
+	}
+	return err
+}
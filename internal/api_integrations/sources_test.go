@@ -0,0 +1,76 @@
+package apiintegrations
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandSources(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"a.jsonl",
+		"a.jsonl.1",
+		"a.jsonl.2.gz",
+		"notes.txt",
+		"sub/b.jsonl",
+		"sub/deeper/c.jsonl.zst",
+		"sub/deeper/d.jsonl",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rel := func(paths []string) []string {
+		out := make([]string, len(paths))
+		for i, path := range paths {
+			r, _ := filepath.Rel(dir, path)
+			out[i] = filepath.ToSlash(r)
+		}
+		return out
+	}
+
+	tests := []struct {
+		patterns []string
+		want     []string
+	}{
+		{DefaultSources(dir), []string{"a.jsonl", "a.jsonl.2.gz", "sub/b.jsonl", "sub/deeper/c.jsonl.zst", "sub/deeper/d.jsonl"}},
+		{[]string{filepath.Join(dir, "*.jsonl")}, []string{"a.jsonl"}},
+		{[]string{filepath.Join(dir, "*", "*.jsonl"), filepath.Join(dir, "sub", "b.jsonl")}, []string{"sub/b.jsonl"}},
+		{[]string{filepath.Join(dir, "sub", "**", "*.jsonl")}, []string{"sub/b.jsonl", "sub/deeper/d.jsonl"}},
+		{[]string{filepath.Join(dir, "missing", "**", "*.jsonl")}, []string{}},
+	}
+	for _, tt := range tests {
+		got, err := ExpandSources(tt.patterns)
+		if err != nil {
+			t.Fatalf("ExpandSources(%v): %v", tt.patterns, err)
+		}
+		if r := rel(got); !reflect.DeepEqual(r, tt.want) {
+			t.Errorf("ExpandSources(%v)=%v want %v", tt.patterns, r, tt.want)
+		}
+	}
+	if _, err := ExpandSources([]string{filepath.Join(dir, "[")}); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
+
+func TestLogicalSourcePath(t *testing.T) {
+	for path, want := range map[string]string{
+		"/logs/notes.jsonl":               "/logs/notes.jsonl",
+		"/logs/notes.jsonl.1":             "/logs/notes.jsonl",
+		"/logs/notes.jsonl.3.gz":          "/logs/notes.jsonl",
+		"/logs/notes.jsonl-20260403.zst":  "/logs/notes.jsonl",
+		"/logs/2026-04-03T10.jsonl.gz":    "/logs/2026-04-03T10.jsonl",
+		"/logs/notes.log.gz":              "/logs/notes.log.gz",
+		"/logs/notes.jsonl.backup.jsonl1": "/logs/notes.jsonl.backup.jsonl1",
+	} {
+		if got := LogicalSourcePath(path); got != want {
+			t.Errorf("LogicalSourcePath(%q)=%q want %q", path, got, want)
+		}
+	}
+}
@@ -91,6 +91,15 @@ type Config struct {
 	APIIntegrationsEnabled   bool          // ONWATCH_API_INTEGRATIONS_ENABLED (default: true)
 	APIIntegrationsDir       string        // ONWATCH_API_INTEGRATIONS_DIR (default: ~/.onwatch/api-integrations or /data/api-integrations)
 	APIIntegrationsRetention time.Duration // ONWATCH_API_INTEGRATIONS_RETENTION (example: 720h, 0 disables pruning)
+	APIIntegrationsSources   []string      // ONWATCH_API_INTEGRATIONS_SOURCES (comma-separated globs, ** matches subdirectories; default: JSONL files and archives under APIIntegrationsDir)
+
+	// OTLP attribute mapping for API Integrations received over OpenTelemetry
+	APIIntegrationsOTLPIntegrationAttr string // ONWATCH_API_INTEGRATIONS_OTLP_INTEGRATION_ATTR (default: service.name)
+	APIIntegrationsOTLPAccountAttr     string // ONWATCH_API_INTEGRATIONS_OTLP_ACCOUNT_ATTR (optional)
+	APIIntegrationsOTLPCostAttr        string // ONWATCH_API_INTEGRATIONS_OTLP_COST_ATTR (optional, USD per span)
+
+	// Model price overrides for API Integrations cost estimates
+	APIIntegrationsPricingFile string // ONWATCH_API_INTEGRATIONS_PRICING_FILE (optional JSON catalog)
 
 	// Shared configuration
 	PollInterval       time.Duration // ONWATCH_POLL_INTERVAL (seconds → Duration)
@@ -425,6 +434,18 @@ func loadFromEnvAndFlags(flags *flagValues) (*Config, error) {
 			cfg.APIIntegrationsRetention = v
 		}
 	}
+	cfg.APIIntegrationsOTLPIntegrationAttr = strings.TrimSpace(os.Getenv("ONWATCH_API_INTEGRATIONS_OTLP_INTEGRATION_ATTR"))
+	if cfg.APIIntegrationsOTLPIntegrationAttr == "" {
+		cfg.APIIntegrationsOTLPIntegrationAttr = "service.name"
+	}
+	cfg.APIIntegrationsOTLPAccountAttr = strings.TrimSpace(os.Getenv("ONWATCH_API_INTEGRATIONS_OTLP_ACCOUNT_ATTR"))
+	cfg.APIIntegrationsOTLPCostAttr = strings.TrimSpace(os.Getenv("ONWATCH_API_INTEGRATIONS_OTLP_COST_ATTR"))
+	cfg.APIIntegrationsPricingFile = strings.TrimSpace(os.Getenv("ONWATCH_API_INTEGRATIONS_PRICING_FILE"))
+	for _, source := range strings.Split(os.Getenv("ONWATCH_API_INTEGRATIONS_SOURCES"), ",") {
+		if source = strings.TrimSpace(source); source != "" {
+			cfg.APIIntegrationsSources = append(cfg.APIIntegrationsSources, source)
+		}
+	}
 
 	// Poll Interval (seconds) - ONWATCH_* first, SYNTRACK_* fallback
 	if flags.interval > 0 {
@@ -781,6 +802,11 @@ func (c *Config) String() string {
 	fmt.Fprintf(&sb, "  APIIntegrationsEnabled: %v,\n", c.APIIntegrationsEnabled)
 	fmt.Fprintf(&sb, "  APIIntegrationsDir: %s,\n", c.APIIntegrationsDir)
 	fmt.Fprintf(&sb, "  APIIntegrationsRetention: %v,\n", c.APIIntegrationsRetention)
+	fmt.Fprintf(&sb, "  APIIntegrationsSources: %v,\n", c.APIIntegrationsSources)
+	fmt.Fprintf(&sb, "  APIIntegrationsOTLPIntegrationAttr: %s,\n", c.APIIntegrationsOTLPIntegrationAttr)
+	fmt.Fprintf(&sb, "  APIIntegrationsOTLPAccountAttr: %s,\n", c.APIIntegrationsOTLPAccountAttr)
+	fmt.Fprintf(&sb, "  APIIntegrationsOTLPCostAttr: %s,\n", c.APIIntegrationsOTLPCostAttr)
+	fmt.Fprintf(&sb, "  APIIntegrationsPricingFile: %s,\n", c.APIIntegrationsPricingFile)
 
 	// Redact Cursor token
 	cursorDisplay := redactAPIKey(c.CursorToken, "")
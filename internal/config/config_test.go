@@ -206,6 +206,21 @@ func TestConfig_APIIntegrationsRetention_LoadsFromEnv(t *testing.T) {
 	}
 }
 
+func TestConfig_APIIntegrationsSources_LoadsFromEnv(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("ONWATCH_API_INTEGRATIONS_SOURCES", " /var/log/app/**/*.jsonl, ,/srv/batch/*.jsonl.gz ")
+	defer os.Clearenv()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	want := []string{"/var/log/app/**/*.jsonl", "/srv/batch/*.jsonl.gz"}
+	if len(cfg.APIIntegrationsSources) != len(want) || cfg.APIIntegrationsSources[0] != want[0] || cfg.APIIntegrationsSources[1] != want[1] {
+		t.Errorf("APIIntegrationsSources = %v, want %v", cfg.APIIntegrationsSources, want)
+	}
+}
+
 func TestConfig_APIIntegrationsRetention_Disabled(t *testing.T) {
 	os.Clearenv()
 	os.Setenv("ONWATCH_API_INTEGRATIONS_RETENTION", "0")
@@ -220,6 +235,32 @@ func TestConfig_APIIntegrationsRetention_Disabled(t *testing.T) {
 	}
 }
 
+func TestConfig_APIIntegrationsOTLPMapping(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.APIIntegrationsOTLPIntegrationAttr != "service.name" || cfg.APIIntegrationsOTLPAccountAttr != "" || cfg.APIIntegrationsOTLPCostAttr != "" {
+		t.Errorf("default OTLP mapping = %q/%q/%q, want service.name with no account or cost attribute",
+			cfg.APIIntegrationsOTLPIntegrationAttr, cfg.APIIntegrationsOTLPAccountAttr, cfg.APIIntegrationsOTLPCostAttr)
+	}
+
+	os.Setenv("ONWATCH_API_INTEGRATIONS_OTLP_INTEGRATION_ATTR", "app.feature")
+	os.Setenv("ONWATCH_API_INTEGRATIONS_OTLP_ACCOUNT_ATTR", "tenant.id")
+	os.Setenv("ONWATCH_API_INTEGRATIONS_OTLP_COST_ATTR", "llm.cost_usd")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.APIIntegrationsOTLPIntegrationAttr != "app.feature" || cfg.APIIntegrationsOTLPAccountAttr != "tenant.id" || cfg.APIIntegrationsOTLPCostAttr != "llm.cost_usd" {
+		t.Errorf("OTLP mapping = %q/%q/%q, want app.feature/tenant.id/llm.cost_usd",
+			cfg.APIIntegrationsOTLPIntegrationAttr, cfg.APIIntegrationsOTLPAccountAttr, cfg.APIIntegrationsOTLPCostAttr)
+	}
+}
+
 func TestConfig_OnlySyntheticProvider(t *testing.T) {
 	os.Setenv("SYNTHETIC_API_KEY", "syn_test_key")
 	defer os.Clearenv()
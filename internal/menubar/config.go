@@ -139,6 +139,31 @@ type QuotaMeter struct {
 	Source          string    `json:"source,omitempty"`     // "statusline" or "api"
 	AgeSeconds      int64     `json:"age_seconds,omitempty"`
 	IsStale         bool      `json:"is_stale,omitempty"`
+
+	// Pacing is set for weekly and monthly windows only.
+	Pacing *QuotaPacing `json:"pacing,omitempty"`
+
+	// Runway is set for prepaid balances with enough history to forecast.
+	Runway *QuotaRunway `json:"runway,omitempty"`
+}
+
+// QuotaRunway forecasts when a prepaid balance reaches its floor at the
+// trailing spend rate.
+type QuotaRunway struct {
+	DaysLeft   float64 `json:"days_left"`
+	DepletesAt string  `json:"depletes_at,omitempty"` // empty while nothing is being spent
+	DailySpend float64 `json:"daily_spend"`
+	Low        bool    `json:"low,omitempty"`
+}
+
+// QuotaPacing compares a long window's usage against the even-spread budget
+// line to reset.
+type QuotaPacing struct {
+	Status         string  `json:"status"` // "ahead", "on_pace" or "behind"
+	ExpectedPct    float64 `json:"expected_percent"`
+	DeltaPct       float64 `json:"delta_percent"`
+	DailyAllowance float64 `json:"daily_allowance"` // percent per day to last until reset
+	DaysLeft       float64 `json:"days_left"`
 }
 
 // TrendSeries groups sparkline points for a provider-level detailed view.
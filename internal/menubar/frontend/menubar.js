@@ -68,6 +68,8 @@
     const percent = Math.max(0, Math.min(100, Number(quota.percent || 0)));
     const dashOffset = length - (length * percent / 100);
     const ageTag = quota.source ? `<div class="meter-age">${escapeHTML(quotaAgeLabel(quota))}</div>` : '';
+    const paceTag = quota.pacing ? `<div class="meter-pace pace-${escapeHTML(quota.pacing.status)}">${escapeHTML(quotaPaceLabel(quota.pacing))}</div>` : '';
+    const runwayTag = quota.runway ? `<div class="meter-pace${quota.runway.low ? ' pace-ahead' : ''}">${escapeHTML(quotaRunwayLabel(quota.runway))}</div>` : '';
     return `
       <div class="quota-meter status-${severityClass(quota.status)}">
         <div class="meter-shell">
@@ -78,11 +80,36 @@
           <div class="meter-value">${escapeHTML(quota.display_value || `${percent.toFixed(0)}%`)}</div>
         </div>
         <div class="meter-label">${escapeHTML(quota.label)}</div>
+        ${paceTag}
+        ${runwayTag}
         ${ageTag}
       </div>
     `;
   }
 
+  function quotaPaceLabel(pacing) {
+    const delta = Math.abs(Number(pacing.delta_percent || 0)).toFixed(0);
+    const allowance = `${Number(pacing.daily_allowance || 0).toFixed(1)}%/day`;
+    if (pacing.status === 'ahead') {
+      return `${delta}% ahead · ${allowance}`;
+    }
+    if (pacing.status === 'behind') {
+      return `${delta}% behind · ${allowance}`;
+    }
+    return `On pace · ${allowance}`;
+  }
+
+  function quotaRunwayLabel(runway) {
+    if (!runway.depletes_at) {
+      return 'No recent spend';
+    }
+    const days = Number(runway.days_left || 0);
+    if (days <= 0) {
+      return 'At floor';
+    }
+    return days < 1 ? `${Math.round(days * 24)}h left` : `${days.toFixed(1)}d left`;
+  }
+
   function trendMarkup(series) {
     const points = Array.isArray(series.points) ? series.points : [];
     if (!points.length) {
@@ -16,6 +16,10 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// apiIntegrationReliabilityWindow is the trailing window the API integration
+// error and latency gauges cover.
+const apiIntegrationReliabilityWindow = 15 * time.Minute
+
 // defaultAccountID is emitted for single-account providers so the account_id
 // label is always non-empty (PromQL-friendly).
 const defaultAccountID = "default"
@@ -48,6 +52,13 @@ type Metrics struct {
 	apiIntegrationRequests *prometheus.GaugeVec
 	apiIntegrationSpendUSD *prometheus.GaugeVec
 
+	// API integration reliability over the trailing
+	// apiIntegrationReliabilityWindow, per integration, provider and model.
+	apiIntegrationRecentRequests *prometheus.GaugeVec
+	apiIntegrationRecentErrors   *prometheus.GaugeVec
+	apiIntegrationErrorRatio     *prometheus.GaugeVec
+	apiIntegrationLatency        *prometheus.GaugeVec
+
 	// accountInfo is a join-metric (value always 1) mapping numeric account_id
 	// to human-readable account_name for Grafana etc.
 	accountInfo *prometheus.GaugeVec
@@ -140,6 +151,34 @@ func New() *Metrics {
 			},
 			[]string{"integration"},
 		),
+		apiIntegrationRecentRequests: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "onwatch_api_integration_recent_requests",
+				Help: "API integration calls captured in the last 15 minutes.",
+			},
+			[]string{"integration", "provider", "model"},
+		),
+		apiIntegrationRecentErrors: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "onwatch_api_integration_recent_errors",
+				Help: "API integration calls that failed in the last 15 minutes.",
+			},
+			[]string{"integration", "provider", "model"},
+		),
+		apiIntegrationErrorRatio: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "onwatch_api_integration_error_ratio",
+				Help: "Share (0-1) of API integration calls that failed in the last 15 minutes.",
+			},
+			[]string{"integration", "provider", "model"},
+		),
+		apiIntegrationLatency: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "onwatch_api_integration_latency_seconds",
+				Help: "Latency percentiles (quantile 0.5, 0.95, 0.99) of successful API integration calls in the last 15 minutes. Absent when no call reported a latency.",
+			},
+			[]string{"integration", "provider", "model", "quantile"},
+		),
 		accountInfo: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "onwatch_account_info",
@@ -161,6 +200,10 @@ func New() *Metrics {
 		m.cyclesFailedTotal,
 		m.apiIntegrationRequests,
 		m.apiIntegrationSpendUSD,
+		m.apiIntegrationRecentRequests,
+		m.apiIntegrationRecentErrors,
+		m.apiIntegrationErrorRatio,
+		m.apiIntegrationLatency,
 		m.accountInfo,
 	)
 
@@ -246,6 +289,10 @@ func (m *Metrics) Scrape(s *store.Store, pollInterval time.Duration) {
 	m.agentLastCycleAge.Reset()
 	m.apiIntegrationRequests.Reset()
 	m.apiIntegrationSpendUSD.Reset()
+	m.apiIntegrationRecentRequests.Reset()
+	m.apiIntegrationRecentErrors.Reset()
+	m.apiIntegrationErrorRatio.Reset()
+	m.apiIntegrationLatency.Reset()
 	m.accountInfo.Reset()
 
 	m.scrapeAnthropic(s, staleThreshold)
@@ -259,6 +306,7 @@ func (m *Metrics) Scrape(s *store.Store, pollInterval time.Duration) {
 	m.scrapeMoonshot(s, staleThreshold)
 	m.scrapeDeepSeek(s, staleThreshold)
 	m.scrapeAPIIntegrations(s, staleThreshold)
+	m.scrapeAPIIntegrationReliability(s)
 }
 
 func (m *Metrics) scrapeAPIIntegrations(s *store.Store, staleThreshold time.Duration) {
@@ -311,6 +359,27 @@ func (m *Metrics) scrapeAPIIntegrations(s *store.Store, staleThreshold time.Dura
 	}
 }
 
+func (m *Metrics) scrapeAPIIntegrationReliability(s *store.Store) {
+	now := time.Now().UTC()
+	rows, err := s.QueryAPIIntegrationReliability(now.Add(-apiIntegrationReliabilityWindow), now, 0)
+	if err != nil {
+		m.scrapeErrorsTotal.WithLabelValues("api_integrations", "query_failed").Inc()
+		return
+	}
+	for _, row := range rows {
+		labels := []string{row.IntegrationName, row.Provider, row.Model}
+		m.apiIntegrationRecentRequests.WithLabelValues(labels...).Set(float64(row.RequestCount))
+		m.apiIntegrationRecentErrors.WithLabelValues(labels...).Set(float64(row.ErrorCount))
+		m.apiIntegrationErrorRatio.WithLabelValues(labels...).Set(row.ErrorRate())
+		if row.LatencyCount == 0 {
+			continue
+		}
+		for quantile, ms := range map[string]int{"0.5": row.LatencyP50MS, "0.95": row.LatencyP95MS, "0.99": row.LatencyP99MS} {
+			m.apiIntegrationLatency.WithLabelValues(append(labels, quantile)...).Set(float64(ms) / 1000)
+		}
+	}
+}
+
 func (m *Metrics) scrapeAnthropic(s *store.Store, staleThreshold time.Duration) {
 	method := "anthropic"
 
@@ -6,6 +6,7 @@ import (
 
 	"github.com/onllm-dev/onwatch/v2/internal/api"
 	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
 	dto "github.com/prometheus/client_model/go"
 )
 
@@ -260,6 +261,54 @@ func TestMetrics_ScrapeResetsStaleSeriesBetweenScrapes(t *testing.T) {
 	}
 }
 
+func TestMetrics_ScrapeExportsAPIIntegrationReliability(t *testing.T) {
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	ts := time.Now().UTC().Add(-5 * time.Minute).Format(time.RFC3339)
+	for i, line := range []string{
+		`{"ts":"` + ts + `","integration":"notes","provider":"openai","model":"gpt-4.1","request_id":"a","prompt_tokens":1,"completion_tokens":1,"latency_ms":400}`,
+		`{"ts":"` + ts + `","integration":"notes","provider":"openai","model":"gpt-4.1","request_id":"b","prompt_tokens":1,"completion_tokens":1,"latency_ms":1200}`,
+		`{"ts":"` + ts + `","integration":"notes","provider":"openai","model":"gpt-4.1","request_id":"c","prompt_tokens":0,"completion_tokens":0,"status":"error","error_type":"rate_limit"}`,
+		`{"ts":"` + ts + `","integration":"notes","provider":"openai","model":"gpt-4.1","request_id":"d","prompt_tokens":0,"completion_tokens":0,"status":"error","error_type":"server_error"}`,
+		// Older than the window.
+		`{"ts":"` + time.Now().UTC().Add(-time.Hour).Format(time.RFC3339) + `","integration":"notes","provider":"anthropic","model":"claude-sonnet-4","prompt_tokens":1,"completion_tokens":1,"latency_ms":900}`,
+	} {
+		event, err := usageevent.ParseLine([]byte(line), "/tmp/api-integrations/notes.jsonl")
+		if err != nil {
+			t.Fatalf("ParseLine %d: %v", i, err)
+		}
+		if _, err := s.InsertAPIIntegrationUsageEvent(event); err != nil {
+			t.Fatalf("InsertAPIIntegrationUsageEvent %d: %v", i, err)
+		}
+	}
+
+	m := New()
+	m.Scrape(s, time.Minute)
+	families, err := m.Gather().Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	labels := map[string]string{"integration": "notes", "provider": "openai", "model": "gpt-4.1"}
+	assertGaugeValue(t, families, "onwatch_api_integration_recent_requests", labels, 4)
+	assertGaugeValue(t, families, "onwatch_api_integration_recent_errors", labels, 2)
+	assertGaugeValue(t, families, "onwatch_api_integration_error_ratio", labels, 0.5)
+	for quantile, want := range map[string]float64{"0.5": 0.4, "0.95": 1.2, "0.99": 1.2} {
+		assertGaugeValue(t, families, "onwatch_api_integration_latency_seconds", map[string]string{
+			"integration": "notes", "provider": "openai", "model": "gpt-4.1", "quantile": quantile,
+		}, want)
+	}
+	if hasGaugeMetric(families, "onwatch_api_integration_recent_requests", map[string]string{
+		"integration": "notes", "provider": "anthropic", "model": "claude-sonnet-4",
+	}) {
+		t.Fatal("calls outside the reliability window should not be exported")
+	}
+}
+
 func assertGaugeValue(t *testing.T, families []*dto.MetricFamily, name string, labels map[string]string, want float64) {
 	t.Helper()
 	metric := findMetric(t, families, name, labels)
@@ -0,0 +1,332 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/tracker"
+)
+
+const (
+	// anomalyCheckInterval is how often the detector re-evaluates quotas.
+	anomalyCheckInterval = 5 * time.Minute
+	// anomalyLearningWindow is how much snapshot history baselines learn from.
+	anomalyLearningWindow = 14 * 24 * time.Hour
+	// anomalyHistoryLimit caps the snapshots read per quota; two weeks of
+	// one-minute polling fits comfortably.
+	anomalyHistoryLimit = 25000
+	// anomalyBaselineRefresh is how long a learned baseline is reused before
+	// the learning window is read again. Baselines move slowly, so only the
+	// recent window is read on every check.
+	anomalyBaselineRefresh = time.Hour
+	// anomalyRecentWindow is the history read to measure last-hour
+	// consumption, including the polling gap before the hour starts.
+	anomalyRecentWindow = 3 * time.Hour
+	// anomalyRecentLimit caps the snapshots read for the recent window.
+	anomalyRecentLimit = 1000
+	// anomalyCycleLimit is the number of completed reset cycles used for the
+	// fallback baseline.
+	anomalyCycleLimit = 10
+	// anomalyCooldown suppresses repeat alerts for the same quota.
+	anomalyCooldown = 6 * time.Hour
+	// AnomalyAlertType is the system_alerts type raised by the detector.
+	AnomalyAlertType = "consumption_anomaly"
+)
+
+// anomalyBaseline is a learned baseline cached per quota.
+type anomalyBaseline struct {
+	baseline  *tracker.ConsumptionBaseline
+	learnedAt time.Time
+}
+
+// anomalyDetector periodically compares each quota's consumption in the last
+// hour with its learned baseline.
+type anomalyDetector struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// observeForAnomalies records the latest status of a quota reported by an
+// agent so the detector evaluates it on its next pass.
+func (e *NotificationEngine) observeForAnomalies(status QuotaStatus) {
+	key := normalizeNotificationProvider(status.Provider) + ":" + notificationQuotaKey(status)
+	e.anomalyMu.Lock()
+	defer e.anomalyMu.Unlock()
+	if e.anomalyQuotas == nil {
+		e.anomalyQuotas = make(map[string]QuotaStatus)
+	}
+	e.anomalyQuotas[key] = status
+}
+
+// StartAnomalyDetection begins checking observed quotas for consumption
+// anomalies in the background. Calling it again while running is a no-op.
+func (e *NotificationEngine) StartAnomalyDetection() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.anomaly != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	det := &anomalyDetector{cancel: cancel, done: make(chan struct{})}
+	e.anomaly = det
+	go func() {
+		defer close(det.done)
+		ticker := time.NewTicker(anomalyCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.detectAnomalies(time.Now())
+			}
+		}
+	}()
+}
+
+// stopAnomalyDetection halts the detector and waits for it to exit.
+func (e *NotificationEngine) stopAnomalyDetection() {
+	e.mu.Lock()
+	det := e.anomaly
+	e.anomaly = nil
+	e.mu.Unlock()
+	if det == nil {
+		return
+	}
+	det.cancel()
+	<-det.done
+}
+
+// detectAnomalies evaluates every observed quota and raises an alert for each
+// one consuming faster than its baseline allows, at most once per cooldown.
+func (e *NotificationEngine) detectAnomalies(now time.Time) {
+	e.mu.RLock()
+	cfg := e.cfg
+	e.mu.RUnlock()
+
+	e.anomalyMu.Lock()
+	keys := make([]string, 0, len(e.anomalyQuotas))
+	statuses := make(map[string]QuotaStatus, len(e.anomalyQuotas))
+	for key, status := range e.anomalyQuotas {
+		if last, ok := e.anomalyAlerted[key]; ok && now.Sub(last) < anomalyCooldown {
+			continue
+		}
+		keys = append(keys, key)
+		statuses[key] = status
+	}
+	e.anomalyMu.Unlock()
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		status := statuses[key]
+		anomaly, err := e.evaluateAnomaly(key, status, now, cfg.AnomalyFactor)
+		if err != nil {
+			e.logger.Error("failed to evaluate consumption anomaly", "error", err,
+				"provider", status.Provider, "quota", status.QuotaKey)
+			continue
+		}
+		if anomaly == nil {
+			continue
+		}
+		e.raiseAnomaly(status, anomaly, cfg)
+		e.anomalyMu.Lock()
+		if e.anomalyAlerted == nil {
+			e.anomalyAlerted = make(map[string]time.Time)
+		}
+		e.anomalyAlerted[key] = now
+		e.anomalyMu.Unlock()
+	}
+}
+
+// evaluateAnomaly compares the quota's consumption during the last hour with
+// its baseline.
+func (e *NotificationEngine) evaluateAnomaly(key string, status QuotaStatus, now time.Time, factor float64) (*tracker.ConsumptionAnomaly, error) {
+	baseline, err := e.anomalyBaseline(key, status, now)
+	if err != nil {
+		return nil, err
+	}
+	if baseline == nil {
+		return nil, nil
+	}
+	points, err := quotaHistoryLimit(e.store, status, now.Add(-anomalyRecentWindow), now, anomalyRecentLimit)
+	if err != nil {
+		return nil, err
+	}
+	return tracker.DetectConsumptionAnomaly(tracker.RecentConsumption(usageSamples(points), now), baseline, factor), nil
+}
+
+// anomalyBaseline returns the quota's baseline learned from history older
+// than the last hour, re-learning it at most once per anomalyBaselineRefresh.
+func (e *NotificationEngine) anomalyBaseline(key string, status QuotaStatus, now time.Time) (*tracker.ConsumptionBaseline, error) {
+	e.anomalyMu.Lock()
+	cached, ok := e.anomalyBaselines[key]
+	e.anomalyMu.Unlock()
+	if ok && now.Sub(cached.learnedAt) < anomalyBaselineRefresh {
+		return cached.baseline, nil
+	}
+
+	learnUntil := now.Add(-time.Hour)
+	points, err := quotaHistoryLimit(e.store, status, now.Add(-anomalyLearningWindow), learnUntil, anomalyHistoryLimit)
+	if err != nil {
+		return nil, err
+	}
+	samples := usageSamples(points)
+	for len(samples) > 0 && samples[len(samples)-1].At.After(learnUntil) {
+		samples = samples[:len(samples)-1]
+	}
+	cycleRates, err := quotaCycleRates(e.store, status, anomalyCycleLimit)
+	if err != nil {
+		return nil, err
+	}
+	baseline := tracker.LearnConsumptionBaseline(samples, cycleRates)
+
+	e.anomalyMu.Lock()
+	if e.anomalyBaselines == nil {
+		e.anomalyBaselines = make(map[string]anomalyBaseline)
+	}
+	e.anomalyBaselines[key] = anomalyBaseline{baseline: baseline, learnedAt: now}
+	e.anomalyMu.Unlock()
+	return baseline, nil
+}
+
+func usageSamples(points []usagePoint) []tracker.UsageSample {
+	samples := make([]tracker.UsageSample, len(points))
+	for i, p := range points {
+		samples[i] = tracker.UsageSample{At: p.At, Percent: p.Percent}
+	}
+	return samples
+}
+
+// raiseAnomaly records an in-dashboard system alert and, when anomaly
+// notifications are enabled, notifies every enabled channel.
+func (e *NotificationEngine) raiseAnomaly(status QuotaStatus, anomaly *tracker.ConsumptionAnomaly, cfg NotificationConfig) {
+	provider := normalizeNotificationProvider(status.Provider)
+	title := fmt.Sprintf("%s %s is consuming %.0fx faster than usual",
+		titleCase(provider), status.QuotaKey, anomaly.Ratio)
+	message := fmt.Sprintf("%.1f%% of the quota was used in the last hour, against a typical %.1f%% per hour learned from %s. Check for a runaway agent or loop.",
+		anomaly.CurrentRate, anomaly.BaselineRate, anomalySourceLabel(anomaly.Source))
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"quota_key":     status.QuotaKey,
+		"account_id":    status.AccountID,
+		"current_rate":  anomaly.CurrentRate,
+		"baseline_rate": anomaly.BaselineRate,
+		"ratio":         anomaly.Ratio,
+	})
+	if _, err := e.store.CreateSystemAlert(provider, AnomalyAlertType, title, message, "warning", string(metadata)); err != nil {
+		e.logger.Error("failed to create anomaly system alert", "error", err)
+	}
+	e.logger.Warn("consumption anomaly detected", "provider", provider, "quota", status.QuotaKey,
+		"current_rate", anomaly.CurrentRate, "baseline_rate", anomaly.BaselineRate)
+
+	if !cfg.Types.Anomaly {
+		return
+	}
+	e.mu.RLock()
+	mailer, pushSender := e.mailer, e.pushSender
+	e.mu.RUnlock()
+	status.Anomaly = anomaly
+	e.sendNotification(mailer, pushSender, cfg.Channels, status, "anomaly")
+}
+
+func anomalySourceLabel(source string) string {
+	if source == tracker.BaselineFromCycles {
+		return "past reset cycles"
+	}
+	return "recent snapshots"
+}
+
+// quotaCycleRates returns the average consumption rate, in percentage points
+// per hour, of the quota's most recent completed reset cycles. Providers whose
+// cycles are not tracked in comparable units return no rates.
+func quotaCycleRates(s *store.Store, status QuotaStatus, limit int) ([]float64, error) {
+	accountID := int64(1)
+	if id, err := strconv.ParseInt(status.AccountID, 10, 64); err == nil && id > 0 {
+		accountID = id
+	}
+
+	var rates []float64
+	add := func(start time.Time, end *time.Time, delta float64) {
+		if end != nil {
+			rates = append(rates, tracker.CycleRate(delta, start, *end))
+		}
+	}
+
+	switch normalizeNotificationProvider(status.Provider) {
+	case "synthetic", "legacy":
+		if status.Limit <= 0 {
+			return nil, nil
+		}
+		cycles, err := s.QueryCycleHistory(status.QuotaKey, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cycles {
+			add(c.CycleStart, c.CycleEnd, c.TotalDelta/status.Limit*100)
+		}
+	case "anthropic":
+		cycles, err := s.QueryAnthropicCycleHistory(status.QuotaKey, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cycles {
+			add(c.CycleStart, c.CycleEnd, c.TotalDelta)
+		}
+	case "codex":
+		cycles, err := s.QueryCodexCycleHistory(accountID, status.QuotaKey, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cycles {
+			add(c.CycleStart, c.CycleEnd, c.TotalDelta)
+		}
+	case "copilot":
+		if status.Limit <= 0 {
+			return nil, nil
+		}
+		cycles, err := s.QueryCopilotCycleHistory(status.QuotaKey, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cycles {
+			add(c.CycleStart, c.CycleEnd, float64(c.TotalDelta)/status.Limit*100)
+		}
+	case "gemini":
+		cycles, err := s.QueryGeminiCycleHistory(status.QuotaKey, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cycles {
+			add(c.CycleStart, c.CycleEnd, c.TotalDelta*100) // tracked as a fraction
+		}
+	case "cursor":
+		cycles, err := s.QueryCursorCycleHistory(status.QuotaKey, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cycles {
+			add(c.CycleStart, c.CycleEnd, c.TotalDelta)
+		}
+	case "grok":
+		cycles, err := s.QueryGrokCyclesForQuota(accountID, status.QuotaKey, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cycles {
+			add(c.CycleStart, c.CycleEnd, c.TotalDelta)
+		}
+	case "kimi":
+		cycles, err := s.QueryKimiCyclesForQuota(accountID, status.QuotaKey, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cycles {
+			add(c.CycleStart, c.CycleEnd, c.TotalDelta)
+		}
+	}
+	return rates, nil
+}
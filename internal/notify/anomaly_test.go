@@ -0,0 +1,210 @@
+package notify
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/api"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/tracker"
+)
+
+// seedAnthropicBurst records twelve hours of steady use at 2 points per hour
+// followed by 50 points in the last hour before now.
+func seedAnthropicBurst(t *testing.T, s *store.Store, now time.Time) {
+	t.Helper()
+	insert := func(at time.Time, util float64) {
+		snap := &api.AnthropicSnapshot{
+			CapturedAt: at,
+			Quotas:     []api.AnthropicQuota{{Name: "seven_day", Utilization: util}},
+		}
+		if _, err := s.InsertAnthropicSnapshot(snap); err != nil {
+			t.Fatalf("InsertAnthropicSnapshot: %v", err)
+		}
+	}
+	start := now.Add(-13 * time.Hour)
+	util := 0.0
+	for at := start; !at.After(now.Add(-time.Hour)); at = at.Add(15 * time.Minute) {
+		insert(at, util)
+		util += 0.5
+	}
+	insert(now.Add(-40*time.Minute), util+30)
+	insert(now.Add(-10*time.Minute), util+50)
+}
+
+func anomalyAlerts(t *testing.T, s *store.Store) []store.SystemAlert {
+	t.Helper()
+	alerts, err := s.GetActiveSystemAlerts()
+	if err != nil {
+		t.Fatalf("GetActiveSystemAlerts: %v", err)
+	}
+	var out []store.SystemAlert
+	for _, a := range alerts {
+		if a.AlertType == AnomalyAlertType {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+func TestDetectAnomalies_RaisesSystemAlertOncePerCooldown(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	defer s.Close()
+	now := time.Now().UTC()
+	seedAnthropicBurst(t, s, now)
+
+	engine := newTestEngine(t, s)
+	// No channels are configured: Check still registers the quota.
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "seven_day", Utilization: 56, Limit: 100})
+	engine.detectAnomalies(now)
+
+	alerts := anomalyAlerts(t, s)
+	if len(alerts) != 1 {
+		t.Fatalf("expected 1 anomaly alert, got %d", len(alerts))
+	}
+	a := alerts[0]
+	if a.Provider != "anthropic" || a.Severity != "warning" || !strings.Contains(a.Title, "25x faster than usual") {
+		t.Errorf("alert = %+v", a)
+	}
+	var meta map[string]interface{}
+	if err := json.Unmarshal([]byte(a.Metadata), &meta); err != nil || meta["quota_key"] != "seven_day" {
+		t.Errorf("metadata = %q (%v)", a.Metadata, err)
+	}
+
+	engine.detectAnomalies(now.Add(anomalyCheckInterval))
+	if n := len(anomalyAlerts(t, s)); n != 1 {
+		t.Errorf("expected cooldown to suppress a repeat alert, got %d alerts", n)
+	}
+}
+
+func TestDetectAnomalies_RespectsFactor(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	defer s.Close()
+	now := time.Now().UTC()
+	seedAnthropicBurst(t, s, now)
+
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		AnomalyFactor:     30,
+	})
+	engine := newTestEngine(t, s)
+	if err := engine.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "seven_day", Utilization: 56, Limit: 100})
+	engine.detectAnomalies(now)
+
+	if n := len(anomalyAlerts(t, s)); n != 0 {
+		t.Errorf("25x the baseline should not trip a 30x factor, got %d alerts", n)
+	}
+}
+
+func TestDetectAnomalies_SendsNotificationWhenEnabled(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	defer s.Close()
+	now := time.Now().UTC()
+	seedAnthropicBurst(t, s, now)
+
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyAnomaly:     true,
+	})
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "seven_day", Utilization: 56, Limit: 100})
+	engine.detectAnomalies(now)
+
+	if mailCount.Load() != 1 {
+		t.Errorf("expected 1 anomaly email, got %d", mailCount.Load())
+	}
+	if sentAt, _, _ := s.GetLastNotification("anthropic", "seven_day", "anomaly"); sentAt.IsZero() {
+		t.Error("expected anomaly notification to be logged")
+	}
+
+	status := QuotaStatus{
+		Provider: "anthropic",
+		QuotaKey: "seven_day",
+		Anomaly:  &tracker.ConsumptionAnomaly{CurrentRate: 50, BaselineRate: 2, Ratio: 25},
+	}
+	if subject := engine.buildSubject(status, "anomaly"); subject != "[ANOMALY] Anthropic quota seven_day is consuming 25x faster than usual" {
+		t.Errorf("subject = %q", subject)
+	}
+	if body := engine.buildBody(status, "anomaly"); !strings.Contains(body, "Last hour: 50.0% (typical 2.0%/hour)") {
+		t.Errorf("body missing rates:\n%s", body)
+	}
+}
+
+func TestEvaluateAnomaly_ReusesBaselineUntilRefresh(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	defer s.Close()
+	engine := newTestEngine(t, s)
+
+	now := time.Now().UTC()
+	status := QuotaStatus{Provider: "anthropic", QuotaKey: "seven_day", Utilization: 56, Limit: 100}
+	key := "anthropic:seven_day"
+
+	// Without history there is no baseline, and that result is cached.
+	if anomaly, err := engine.evaluateAnomaly(key, status, now, 0); err != nil || anomaly != nil {
+		t.Fatalf("evaluateAnomaly with no history = %+v, %v", anomaly, err)
+	}
+
+	later := now.Add(anomalyBaselineRefresh)
+	seedAnthropicBurst(t, s, later)
+
+	// Checks within the refresh interval reuse the cached baseline instead
+	// of re-reading the learning window.
+	if anomaly, err := engine.evaluateAnomaly(key, status, now.Add(anomalyCheckInterval), 0); err != nil || anomaly != nil {
+		t.Fatalf("evaluateAnomaly before refresh = %+v, %v; want the cached empty baseline", anomaly, err)
+	}
+
+	anomaly, err := engine.evaluateAnomaly(key, status, later, 0)
+	if err != nil {
+		t.Fatalf("evaluateAnomaly: %v", err)
+	}
+	if anomaly == nil || anomaly.BaselineRate != 2 {
+		t.Fatalf("anomaly after refresh = %+v, want one against a 2%%/hour baseline", anomaly)
+	}
+	engine.anomalyMu.Lock()
+	learnedAt := engine.anomalyBaselines[key].learnedAt
+	engine.anomalyMu.Unlock()
+	if !learnedAt.Equal(later) {
+		t.Errorf("baseline learned at %v, want %v", learnedAt, later)
+	}
+}
+
+func TestQuotaCycleRates_ScalesUnits(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	defer s.Close()
+
+	start := time.Now().UTC().Add(-48 * time.Hour)
+	for i := 0; i < 2; i++ {
+		cs := start.Add(time.Duration(i) * 10 * time.Hour)
+		if _, err := s.CreateCopilotCycle("premium_interactions", cs, nil); err != nil {
+			t.Fatalf("CreateCopilotCycle: %v", err)
+		}
+		if err := s.CloseCopilotCycle("premium_interactions", cs.Add(10*time.Hour), 60, 30); err != nil {
+			t.Fatalf("CloseCopilotCycle: %v", err)
+		}
+	}
+
+	rates, err := quotaCycleRates(s, QuotaStatus{Provider: "copilot", QuotaKey: "premium_interactions", Limit: 300}, anomalyCycleLimit)
+	if err != nil {
+		t.Fatalf("quotaCycleRates: %v", err)
+	}
+	// 30 of 300 requests over 10 hours is 1 point per hour.
+	if len(rates) != 2 || rates[0] != 1 || rates[1] != 1 {
+		t.Errorf("rates = %v, want [1 1]", rates)
+	}
+}
@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"context"
+	"time"
+)
+
+// budgetCheckInterval is how often monthly budgets are compared with spend.
+const budgetCheckInterval = 10 * time.Minute
+
+// BudgetStatus is one monthly spend budget and the spend counted against it.
+type BudgetStatus struct {
+	Key       string  // "total", "provider:deepseek", "model:gpt-4o", ...
+	Label     string  // human-readable scope, e.g. "Model gpt-4o"
+	Month     string  // YYYY-MM the spend covers
+	Budget    float64 // USD per month
+	Spent     float64 // USD spent so far this month
+	Projected float64 // USD by the end of the month at the current pace
+	Warning   float64 // percent of Budget that raises a warning
+	Critical  float64 // percent of Budget that raises a critical alert
+}
+
+// Percent returns the share of the budget spent so far.
+func (b BudgetStatus) Percent() float64 {
+	if b.Budget <= 0 {
+		return 0
+	}
+	return b.Spent / b.Budget * 100
+}
+
+// BudgetProvider reports every configured budget for the month containing now.
+type BudgetProvider func(now time.Time) ([]BudgetStatus, error)
+
+// budgetChecker periodically evaluates budgets from a BudgetProvider.
+type budgetChecker struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// SetBudgetProvider sets the source of budget statuses for budget alerts.
+func (e *NotificationEngine) SetBudgetProvider(provider BudgetProvider) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.budgetProvider = provider
+}
+
+// StartBudgetChecks begins checking monthly budgets in the background.
+// Calling it again while running is a no-op.
+func (e *NotificationEngine) StartBudgetChecks() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.budgets != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	checker := &budgetChecker{cancel: cancel, done: make(chan struct{})}
+	e.budgets = checker
+	go func() {
+		defer close(checker.done)
+		ticker := time.NewTicker(budgetCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.checkBudgets(time.Now())
+			}
+		}
+	}()
+}
+
+// stopBudgetChecks halts the budget checker and waits for it to exit.
+func (e *NotificationEngine) stopBudgetChecks() {
+	e.mu.Lock()
+	checker := e.budgets
+	e.budgets = nil
+	e.mu.Unlock()
+	if checker == nil {
+		return
+	}
+	checker.cancel()
+	<-checker.done
+}
+
+// checkBudgets sends a warning or critical notification for each budget past
+// its threshold. The month is part of the notification key, so each level
+// fires at most once per budget per month.
+func (e *NotificationEngine) checkBudgets(now time.Time) {
+	e.mu.RLock()
+	cfg := e.cfg
+	provider := e.budgetProvider
+	mailer, pushSender, desktop, telegram := e.mailer, e.pushSender, e.desktop, e.telegram
+	e.mu.RUnlock()
+
+	if provider == nil || (mailer == nil && pushSender == nil && desktop == nil && telegram == nil) {
+		return
+	}
+	budgets, err := provider(now)
+	if err != nil {
+		e.logger.Error("failed to evaluate budgets", "error", err)
+		return
+	}
+	for _, b := range budgets {
+		b := b
+		status := QuotaStatus{
+			Provider:    "budget",
+			QuotaKey:    b.Key + "@" + b.Month,
+			Utilization: b.Percent(),
+			Limit:       b.Budget,
+			Budget:      &b,
+		}
+		switch {
+		case status.Utilization >= b.Critical && cfg.Types.Critical:
+			e.sendNotification(mailer, pushSender, cfg.Channels, status, "budget_critical")
+		case status.Utilization >= b.Warning && cfg.Types.Warning:
+			e.sendNotification(mailer, pushSender, cfg.Channels, status, "budget_warning")
+		}
+	}
+}
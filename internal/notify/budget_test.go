@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckBudgets_SendsOncePerLevelPerMonth(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	defer s.Close()
+
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyWarning:     true,
+		NotifyCritical:    true,
+	})
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	status := BudgetStatus{Key: "model:gpt-4o", Label: "Model gpt-4o", Month: "2026-03", Budget: 100, Spent: 85, Projected: 140, Warning: 80, Critical: 100}
+	engine.SetBudgetProvider(func(time.Time) ([]BudgetStatus, error) {
+		return []BudgetStatus{status, {Key: "total", Label: "All spend", Month: "2026-03", Budget: 500, Spent: 10, Warning: 80, Critical: 100}}, nil
+	})
+
+	engine.checkBudgets(time.Now())
+	engine.checkBudgets(time.Now())
+	if mailCount.Load() != 1 {
+		t.Fatalf("expected 1 warning email, got %d", mailCount.Load())
+	}
+	if sentAt, _, _ := s.GetLastNotification("budget", "model:gpt-4o@2026-03", "budget_warning"); sentAt.IsZero() {
+		t.Error("expected budget warning to be logged")
+	}
+
+	status.Spent = 120
+	engine.checkBudgets(time.Now())
+	if mailCount.Load() != 2 {
+		t.Errorf("expected a critical email once over budget, got %d emails", mailCount.Load())
+	}
+
+	// A new month starts with a clean notification log.
+	status.Month = "2026-04"
+	engine.checkBudgets(time.Now())
+	if mailCount.Load() != 3 {
+		t.Errorf("expected the critical alert again in a new month, got %d emails", mailCount.Load())
+	}
+
+	engine.SetBudgetProvider(func(time.Time) ([]BudgetStatus, error) { return nil, errors.New("boom") })
+	engine.checkBudgets(time.Now())
+
+	qs := QuotaStatus{Provider: "budget", QuotaKey: "model:gpt-4o@2026-04", Utilization: 120, Budget: &status}
+	if subject := engine.buildSubject(qs, "budget_critical"); subject != "[CRITICAL] Model gpt-4o budget at 120% ($120.00 of $100.00)" {
+		t.Errorf("subject = %q", subject)
+	}
+	if body := engine.buildBody(qs, "budget_critical"); !strings.Contains(body, "Projected by month end: $140.00") {
+		t.Errorf("body missing projection:\n%s", body)
+	}
+}
@@ -0,0 +1,275 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/pkg/browser"
+
+	"github.com/onllm-dev/onwatch/v2/internal/menubar"
+)
+
+// Desktop Notifications Specification bus names and members.
+const (
+	desktopBusName    = "org.freedesktop.Notifications"
+	desktopObjectPath = dbus.ObjectPath("/org/freedesktop/Notifications")
+	desktopInterface  = "org.freedesktop.Notifications"
+
+	desktopActionDefault = "default"
+	desktopActionOpen    = "open"
+	desktopActionSnooze  = "snooze"
+
+	// DefaultDesktopSnooze is how long a quota's desktop alerts stay muted
+	// after "Snooze" is clicked.
+	DefaultDesktopSnooze = time.Hour
+)
+
+// Urgency levels from the Desktop Notifications Specification.
+const (
+	DesktopUrgencyLow      byte = 0
+	DesktopUrgencyNormal   byte = 1
+	DesktopUrgencyCritical byte = 2
+)
+
+// DesktopConfig holds settings for the D-Bus desktop notification channel.
+type DesktopConfig struct {
+	BusAddress   string                                           // empty uses the session bus
+	DashboardURL string                                           // target of the "Open dashboard" action
+	SnoozeFor    time.Duration                                    // defaults to DefaultDesktopSnooze
+	OnSnooze     func(provider, quotaKey string, d time.Duration) // called when "Snooze" is clicked
+}
+
+// DesktopNotification is a single alert shown via org.freedesktop.Notifications.
+type DesktopNotification struct {
+	Summary  string
+	Body     string
+	Urgency  byte
+	Provider string // snooze target; empty disables the snooze action
+	QuotaKey string
+}
+
+// desktopImage matches the (iiibiiay) "image-data" hint signature.
+type desktopImage struct {
+	Width         int32
+	Height        int32
+	RowStride     int32
+	HasAlpha      bool
+	BitsPerSample int32
+	Channels      int32
+	Data          []byte
+}
+
+type desktopTarget struct {
+	provider string
+	quotaKey string
+}
+
+// DesktopNotifier sends alerts to the Linux desktop over D-Bus and handles
+// the "Open dashboard" and "Snooze" actions the user clicks on them.
+type DesktopNotifier struct {
+	conn         *dbus.Conn
+	obj          dbus.BusObject
+	logger       *slog.Logger
+	dashboardURL string
+	snoozeFor    time.Duration
+	onSnooze     func(provider, quotaKey string, d time.Duration)
+	openURL      func(url string) error
+	icon         *desktopImage
+	signals      chan *dbus.Signal
+
+	mu      sync.Mutex
+	pending map[uint32]desktopTarget
+}
+
+// NewDesktopNotifier connects to the notification server on the session bus
+// and subscribes to action signals.
+func NewDesktopNotifier(cfg DesktopConfig, logger *slog.Logger) (*DesktopNotifier, error) {
+	var conn *dbus.Conn
+	var err error
+	if cfg.BusAddress != "" {
+		conn, err = dbus.Connect(cfg.BusAddress)
+	} else {
+		conn, err = dbus.ConnectSessionBus()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("notify.NewDesktopNotifier: connect session bus: %w", err)
+	}
+
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(desktopObjectPath),
+		dbus.WithMatchInterface(desktopInterface),
+	); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("notify.NewDesktopNotifier: subscribe to signals: %w", err)
+	}
+
+	snoozeFor := cfg.SnoozeFor
+	if snoozeFor <= 0 {
+		snoozeFor = DefaultDesktopSnooze
+	}
+
+	icon, err := desktopIconFromPNG(menubar.IconTemplate2x)
+	if err != nil {
+		logger.Debug("desktop notification icon unavailable", "error", err)
+	}
+
+	d := &DesktopNotifier{
+		conn:         conn,
+		obj:          conn.Object(desktopBusName, desktopObjectPath),
+		logger:       logger,
+		dashboardURL: cfg.DashboardURL,
+		snoozeFor:    snoozeFor,
+		onSnooze:     cfg.OnSnooze,
+		openURL:      browser.OpenURL,
+		icon:         icon,
+		signals:      make(chan *dbus.Signal, 16),
+		pending:      make(map[uint32]desktopTarget),
+	}
+	conn.Signal(d.signals)
+	go d.listen()
+
+	return d, nil
+}
+
+// Send shows a notification and returns once the server has accepted it.
+func (d *DesktopNotifier) Send(n DesktopNotification) error {
+	var actions []string
+	if d.dashboardURL != "" {
+		actions = append(actions, desktopActionDefault, "Open dashboard", desktopActionOpen, "Open dashboard")
+	}
+	if n.Provider != "" && n.QuotaKey != "" {
+		actions = append(actions, desktopActionSnooze, fmt.Sprintf("Snooze %s", formatSnooze(d.snoozeFor)))
+	}
+
+	hints := map[string]dbus.Variant{
+		"urgency":       dbus.MakeVariant(n.Urgency),
+		"desktop-entry": dbus.MakeVariant("onwatch"),
+	}
+	if d.icon != nil {
+		hints["image-data"] = dbus.MakeVariant(*d.icon)
+	}
+
+	var id uint32
+	call := d.obj.Call(desktopInterface+".Notify", 0,
+		"onWatch", // app_name
+		uint32(0), // replaces_id
+		"",        // app_icon (sent as image-data instead)
+		n.Summary, // summary
+		n.Body,    // body
+		actions,   // actions
+		hints,     // hints
+		int32(-1), // expire_timeout: server default
+	)
+	if err := call.Store(&id); err != nil {
+		return fmt.Errorf("notify.DesktopNotifier.Send: %w", err)
+	}
+
+	if n.Provider != "" && n.QuotaKey != "" {
+		d.mu.Lock()
+		d.pending[id] = desktopTarget{provider: n.Provider, quotaKey: n.QuotaKey}
+		d.mu.Unlock()
+	}
+	return nil
+}
+
+// Close stops listening for actions and disconnects from the bus.
+func (d *DesktopNotifier) Close() error {
+	return d.conn.Close()
+}
+
+// listen dispatches ActionInvoked and NotificationClosed signals until the
+// connection is closed.
+func (d *DesktopNotifier) listen() {
+	for sig := range d.signals {
+		if sig.Path != desktopObjectPath || len(sig.Body) == 0 {
+			continue
+		}
+		id, ok := sig.Body[0].(uint32)
+		if !ok {
+			continue
+		}
+		switch sig.Name {
+		case desktopInterface + ".ActionInvoked":
+			if len(sig.Body) < 2 {
+				continue
+			}
+			if action, ok := sig.Body[1].(string); ok {
+				d.handleAction(id, action)
+			}
+		case desktopInterface + ".NotificationClosed":
+			d.mu.Lock()
+			delete(d.pending, id)
+			d.mu.Unlock()
+		}
+	}
+}
+
+func (d *DesktopNotifier) handleAction(id uint32, action string) {
+	switch action {
+	case desktopActionDefault, desktopActionOpen:
+		if d.dashboardURL == "" {
+			return
+		}
+		if err := d.openURL(d.dashboardURL); err != nil {
+			d.logger.Warn("failed to open dashboard from desktop notification", "error", err)
+		}
+	case desktopActionSnooze:
+		d.mu.Lock()
+		target, ok := d.pending[id]
+		delete(d.pending, id)
+		d.mu.Unlock()
+		if !ok || d.onSnooze == nil {
+			return
+		}
+		d.onSnooze(target.provider, target.quotaKey, d.snoozeFor)
+		d.logger.Info("snoozed desktop quota notifications",
+			"provider", target.provider, "quota", target.quotaKey, "for", d.snoozeFor)
+	}
+}
+
+// desktopIconFromPNG converts an embedded PNG into the raw RGBA layout
+// expected by the "image-data" hint.
+func desktopIconFromPNG(data []byte) (*desktopImage, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	rgba := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return &desktopImage{
+		Width:         int32(b.Dx()),
+		Height:        int32(b.Dy()),
+		RowStride:     int32(rgba.Stride),
+		HasAlpha:      true,
+		BitsPerSample: 8,
+		Channels:      4,
+		Data:          rgba.Pix,
+	}, nil
+}
+
+// desktopUrgency maps an alert type to a notification urgency level.
+func desktopUrgency(notifType string) byte {
+	switch notifType {
+	case "critical", "anomaly", "budget_critical":
+		return DesktopUrgencyCritical
+	case "reset":
+		return DesktopUrgencyLow
+	default:
+		return DesktopUrgencyNormal
+	}
+}
+
+func formatSnooze(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	}
+	return fmt.Sprintf("%dm", int(d/time.Minute))
+}
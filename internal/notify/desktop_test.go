@@ -0,0 +1,474 @@
+package notify
+
+import (
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/onllm-dev/onwatch/v2/internal/menubar"
+)
+
+// fakeNotifyCall records the arguments of one org.freedesktop.Notifications.Notify call.
+type fakeNotifyCall struct {
+	AppName string
+	Summary string
+	Body    string
+	Actions []string
+	Hints   map[string]dbus.Variant
+}
+
+// fakeNotificationServer implements the Notify method of the Desktop
+// Notifications Specification on a private test bus.
+type fakeNotificationServer struct {
+	conn *dbus.Conn
+
+	mu     sync.Mutex
+	calls  []fakeNotifyCall
+	nextID uint32
+}
+
+func (f *fakeNotificationServer) Notify(appName string, replacesID uint32, appIcon, summary, body string,
+	actions []string, hints map[string]dbus.Variant, timeout int32) (uint32, *dbus.Error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	f.calls = append(f.calls, fakeNotifyCall{
+		AppName: appName,
+		Summary: summary,
+		Body:    body,
+		Actions: actions,
+		Hints:   hints,
+	})
+	return f.nextID, nil
+}
+
+func (f *fakeNotificationServer) Calls() []fakeNotifyCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeNotifyCall(nil), f.calls...)
+}
+
+// invoke emits ActionInvoked as if the user clicked an action button.
+func (f *fakeNotificationServer) invoke(t *testing.T, id uint32, action string) {
+	t.Helper()
+	if err := f.conn.Emit(desktopObjectPath, desktopInterface+".ActionInvoked", id, action); err != nil {
+		t.Fatalf("emit ActionInvoked: %v", err)
+	}
+}
+
+// startTestSessionBus launches a private dbus-daemon and returns its address.
+func startTestSessionBus(t *testing.T) string {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not installed")
+	}
+
+	dir, err := os.MkdirTemp("", "onwatch-dbus")
+	if err != nil {
+		t.Fatalf("create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "bus")
+	addr := "unix:path=" + socket
+
+	cmd := exec.Command(daemon, "--session", "--nofork", "--nopidfile", "--address="+addr)
+	if err := cmd.Start(); err != nil {
+		t.Skipf("start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	// The socket file appears before the daemon listens on it, so wait
+	// until a connection is accepted.
+	for i := 0; i < 100; i++ {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			conn.Close()
+			return addr
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("dbus-daemon did not accept connections")
+	return ""
+}
+
+// startFakeNotificationServer claims org.freedesktop.Notifications on the bus.
+func startFakeNotificationServer(t *testing.T, addr string) *fakeNotificationServer {
+	t.Helper()
+	conn, err := dbus.Connect(addr)
+	if err != nil {
+		t.Fatalf("connect fake server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	srv := &fakeNotificationServer{conn: conn}
+	if err := conn.Export(srv, desktopObjectPath, desktopInterface); err != nil {
+		t.Fatalf("export fake server: %v", err)
+	}
+	reply, err := conn.RequestName(desktopBusName, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("request name: reply=%v err=%v", reply, err)
+	}
+	return srv
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("condition not met before timeout")
+}
+
+func TestDesktopNotifier_Send(t *testing.T) {
+	addr := startTestSessionBus(t)
+	srv := startFakeNotificationServer(t, addr)
+
+	d, err := NewDesktopNotifier(DesktopConfig{BusAddress: addr, DashboardURL: "http://localhost:9211/"}, slog.Default())
+	if err != nil {
+		t.Fatalf("NewDesktopNotifier: %v", err)
+	}
+	defer d.Close()
+
+	err = d.Send(DesktopNotification{
+		Summary:  "[CRITICAL] Anthropic quota five_hour at 96.0%",
+		Body:     "Utilization: 96.0%",
+		Urgency:  DesktopUrgencyCritical,
+		Provider: "anthropic",
+		QuotaKey: "five_hour",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	calls := srv.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 Notify call, got %d", len(calls))
+	}
+	c := calls[0]
+	if c.AppName != "onWatch" {
+		t.Errorf("app_name = %q, want onWatch", c.AppName)
+	}
+	if !strings.Contains(c.Summary, "CRITICAL") {
+		t.Errorf("summary = %q", c.Summary)
+	}
+	if got := c.Hints["urgency"].Value(); got != DesktopUrgencyCritical {
+		t.Errorf("urgency hint = %v, want %d", got, DesktopUrgencyCritical)
+	}
+	if _, ok := c.Hints["image-data"]; !ok {
+		t.Error("expected image-data hint with the menubar icon")
+	}
+	actions := strings.Join(c.Actions, ",")
+	for _, key := range []string{desktopActionDefault, desktopActionOpen, desktopActionSnooze} {
+		if !strings.Contains(actions, key) {
+			t.Errorf("actions %v missing %q", c.Actions, key)
+		}
+	}
+}
+
+func TestDesktopNotifier_Send_NoTargetNoSnooze(t *testing.T) {
+	addr := startTestSessionBus(t)
+	srv := startFakeNotificationServer(t, addr)
+
+	d, err := NewDesktopNotifier(DesktopConfig{BusAddress: addr}, slog.Default())
+	if err != nil {
+		t.Fatalf("NewDesktopNotifier: %v", err)
+	}
+	defer d.Close()
+
+	if err := d.Send(DesktopNotification{Summary: "test", Urgency: DesktopUrgencyNormal}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	calls := srv.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 Notify call, got %d", len(calls))
+	}
+	if len(calls[0].Actions) != 0 {
+		t.Errorf("expected no actions without dashboard URL or snooze target, got %v", calls[0].Actions)
+	}
+}
+
+func TestDesktopNotifier_Send_NoServer(t *testing.T) {
+	addr := startTestSessionBus(t)
+
+	d, err := NewDesktopNotifier(DesktopConfig{BusAddress: addr}, slog.Default())
+	if err != nil {
+		t.Fatalf("NewDesktopNotifier: %v", err)
+	}
+	defer d.Close()
+
+	if err := d.Send(DesktopNotification{Summary: "test"}); err == nil {
+		t.Fatal("expected error when no notification server owns the bus name")
+	}
+}
+
+func TestDesktopNotifier_Actions(t *testing.T) {
+	addr := startTestSessionBus(t)
+	srv := startFakeNotificationServer(t, addr)
+
+	var mu sync.Mutex
+	var snoozedProvider, snoozedQuota string
+	var snoozedFor time.Duration
+	d, err := NewDesktopNotifier(DesktopConfig{
+		BusAddress:   addr,
+		DashboardURL: "http://localhost:9211/",
+		SnoozeFor:    30 * time.Minute,
+		OnSnooze: func(provider, quotaKey string, dur time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			snoozedProvider, snoozedQuota, snoozedFor = provider, quotaKey, dur
+		},
+	}, slog.Default())
+	if err != nil {
+		t.Fatalf("NewDesktopNotifier: %v", err)
+	}
+	defer d.Close()
+
+	opened := make(chan string, 1)
+	d.openURL = func(url string) error {
+		opened <- url
+		return nil
+	}
+
+	if err := d.Send(DesktopNotification{Summary: "s", Provider: "codex", QuotaKey: "weekly"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	srv.invoke(t, 1, desktopActionOpen)
+	select {
+	case url := <-opened:
+		if url != "http://localhost:9211/" {
+			t.Errorf("opened %q", url)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("open action did not open the dashboard")
+	}
+
+	srv.invoke(t, 1, desktopActionSnooze)
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return snoozedProvider != ""
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	if snoozedProvider != "codex" || snoozedQuota != "weekly" || snoozedFor != 30*time.Minute {
+		t.Errorf("snooze = %s/%s for %v", snoozedProvider, snoozedQuota, snoozedFor)
+	}
+}
+
+func TestNotificationEngine_Check_DesktopChannel(t *testing.T) {
+	addr := startTestSessionBus(t)
+	srv := startFakeNotificationServer(t, addr)
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", addr)
+
+	s := newTestStore(t)
+	defer s.Close()
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyWarning:     true,
+		NotifyCritical:    true,
+		NotifyReset:       true,
+		Channels:          &NotificationChannels{Desktop: true},
+	})
+
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	if err := engine.ConfigureDesktop("http://localhost:9211/"); err != nil {
+		t.Fatalf("ConfigureDesktop: %v", err)
+	}
+	defer engine.desktop.Close()
+
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 85})
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 97})
+
+	calls := srv.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 desktop notifications, got %d", len(calls))
+	}
+	if got := calls[0].Hints["urgency"].Value(); got != DesktopUrgencyNormal {
+		t.Errorf("warning urgency = %v, want %d", got, DesktopUrgencyNormal)
+	}
+	if got := calls[1].Hints["urgency"].Value(); got != DesktopUrgencyCritical {
+		t.Errorf("critical urgency = %v, want %d", got, DesktopUrgencyCritical)
+	}
+
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", ResetOccurred: true})
+	calls = srv.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("expected reset notification, got %d calls", len(calls))
+	}
+	if got := calls[2].Hints["urgency"].Value(); got != DesktopUrgencyLow {
+		t.Errorf("reset urgency = %v, want %d", got, DesktopUrgencyLow)
+	}
+}
+
+func TestNotificationEngine_Check_DesktopSnooze(t *testing.T) {
+	addr := startTestSessionBus(t)
+	srv := startFakeNotificationServer(t, addr)
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", addr)
+	api := startFakeTelegramAPI(t)
+
+	s := newTestStore(t)
+	defer s.Close()
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyWarning:     true,
+		NotifyCritical:    true,
+		Channels:          &NotificationChannels{Desktop: true, Telegram: true},
+	})
+	storeTelegramConfig(t, s, telegramSettingsJSON{BotToken: testTelegramToken, ChatIDs: "111", APIURL: api.srv.URL})
+
+	engine := newTestEngine(t, s)
+	defer engine.Close()
+	engine.Reload()
+	if err := engine.ConfigureDesktop(""); err != nil {
+		t.Fatalf("ConfigureDesktop: %v", err)
+	}
+	if err := engine.ConfigureTelegram(); err != nil {
+		t.Fatalf("ConfigureTelegram: %v", err)
+	}
+
+	engine.Check(QuotaStatus{Provider: "codex", QuotaKey: "weekly", Utilization: 85})
+	srv.invoke(t, 1, desktopActionSnooze)
+	waitFor(t, func() bool { return engine.isDesktopSnoozed("codex", "weekly") })
+
+	// Critical would normally show on the desktop, but the quota is snoozed
+	// there. Other channels still get it.
+	engine.Check(QuotaStatus{Provider: "codex", QuotaKey: "weekly", Utilization: 97})
+	if got := len(srv.Calls()); got != 1 {
+		t.Fatalf("expected snoozed quota to stay quiet on the desktop, got %d calls", got)
+	}
+	if got := len(api.Sent()); got != 2 {
+		t.Fatalf("expected snooze to leave telegram alerts alone, got %d messages", got)
+	}
+
+	// Other quotas are unaffected.
+	engine.Check(QuotaStatus{Provider: "codex", QuotaKey: "five_hour", Utilization: 97})
+	if got := len(srv.Calls()); got != 2 {
+		t.Fatalf("expected unrelated quota to notify, got %d calls", got)
+	}
+
+	// A reset clears the snooze.
+	engine.Check(QuotaStatus{Provider: "codex", QuotaKey: "weekly", ResetOccurred: true})
+	if engine.isDesktopSnoozed("codex", "weekly") {
+		t.Error("expected reset to clear snooze")
+	}
+}
+
+func TestNotificationEngine_ConfigureDesktop_NoSessionBus(t *testing.T) {
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "")
+
+	s := newTestStore(t)
+	defer s.Close()
+	engine := newTestEngine(t, s)
+
+	if err := engine.ConfigureDesktop("http://localhost:9211/"); err != nil {
+		t.Fatalf("ConfigureDesktop: %v", err)
+	}
+	if engine.desktop != nil {
+		t.Error("expected desktop channel to stay disabled without a session bus")
+	}
+	if err := engine.SendTestDesktop(); err == nil {
+		t.Error("expected SendTestDesktop to fail without a session bus")
+	}
+}
+
+func TestNotificationEngine_SendAuthErrorNotification_Desktop(t *testing.T) {
+	addr := startTestSessionBus(t)
+	srv := startFakeNotificationServer(t, addr)
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", addr)
+
+	s := newTestStore(t)
+	defer s.Close()
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyAuthError:   true,
+		Channels:          &NotificationChannels{Desktop: true},
+	})
+
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	if err := engine.ConfigureDesktop(""); err != nil {
+		t.Fatalf("ConfigureDesktop: %v", err)
+	}
+	defer engine.desktop.Close()
+
+	sent := engine.SendAuthErrorNotification(AuthErrorAlert{
+		Provider: "anthropic",
+		Title:    "Token expired",
+		Message:  "Re-run claude login",
+	})
+	if !sent {
+		t.Fatal("expected auth error to be delivered via desktop")
+	}
+	calls := srv.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 desktop notification, got %d", len(calls))
+	}
+	if got := calls[0].Hints["urgency"].Value(); got != DesktopUrgencyCritical {
+		t.Errorf("urgency = %v, want %d for non-recoverable auth error", got, DesktopUrgencyCritical)
+	}
+}
+
+func TestDesktopUrgency(t *testing.T) {
+	t.Parallel()
+	tests := map[string]byte{
+		"warning":  DesktopUrgencyNormal,
+		"critical": DesktopUrgencyCritical,
+		"reset":    DesktopUrgencyLow,
+	}
+	for notifType, want := range tests {
+		if got := desktopUrgency(notifType); got != want {
+			t.Errorf("desktopUrgency(%q) = %d, want %d", notifType, got, want)
+		}
+	}
+}
+
+func TestDesktopIconFromPNG(t *testing.T) {
+	t.Parallel()
+	icon, err := desktopIconFromPNG(menubar.IconTemplate2x)
+	if err != nil {
+		t.Fatalf("desktopIconFromPNG: %v", err)
+	}
+	if icon.Width <= 0 || icon.Height <= 0 {
+		t.Fatalf("invalid icon size %dx%d", icon.Width, icon.Height)
+	}
+	if int(icon.RowStride*icon.Height) != len(icon.Data) {
+		t.Errorf("data length %d does not match stride %d x height %d", len(icon.Data), icon.RowStride, icon.Height)
+	}
+	if sig := dbus.SignatureOf(*icon).String(); sig != "(iiibiiay)" {
+		t.Errorf("image-data signature = %s, want (iiibiiay)", sig)
+	}
+
+	if _, err := desktopIconFromPNG([]byte("not a png")); err == nil {
+		t.Error("expected error for invalid PNG")
+	}
+}
+
+func TestFormatSnooze(t *testing.T) {
+	t.Parallel()
+	if got := formatSnooze(time.Hour); got != "1h" {
+		t.Errorf("formatSnooze(1h) = %q", got)
+	}
+	if got := formatSnooze(30 * time.Minute); got != "30m" {
+		t.Errorf("formatSnooze(30m) = %q", got)
+	}
+}
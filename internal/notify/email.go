@@ -0,0 +1,394 @@
+package notify
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"image/color"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/menubar"
+)
+
+// EmailTemplateFile is the file name in the data directory that overrides
+// the built-in HTML email template.
+const EmailTemplateFile = "email_template.html"
+
+//go:embed templates/email.html
+var defaultEmailTemplate string
+
+// sparklineContentID identifies the inline sparkline image in the HTML part.
+const sparklineContentID = "sparkline@onwatch"
+
+// emailMessage is an email with a plaintext body and an optional HTML
+// alternative that may reference inline images by Content-ID.
+type emailMessage struct {
+	To      []string // overrides the configured recipients when set
+	Subject string
+	Text    string
+	HTML    string
+	Images  []inlineImage
+}
+
+// inlineImage is an image attached to the HTML part as multipart/related.
+type inlineImage struct {
+	ContentID   string
+	ContentType string
+	Filename    string
+	Data        []byte
+}
+
+// emailTemplateData is the data passed to the HTML email template.
+type emailTemplateData struct {
+	Subject      string
+	Provider     string
+	QuotaKey     string
+	QuotaLabel   string
+	AlertType    string
+	Utilization  float64
+	Limit        float64
+	Color        string
+	Summary      string // replaces the utilization line for alerts that have none
+	Quotas       []emailQuotaBar
+	Sparkline    template.URL // cid: URL of the 24h chart; empty without history
+	ResetIn      string
+	ResetAt      string
+	DashboardURL string
+	SentAt       string
+}
+
+// emailQuotaBar is one utilization bar in the HTML email.
+type emailQuotaBar struct {
+	Label   string
+	Value   string
+	Percent float64
+	Width   int // Percent clamped to 0-100 for the bar width
+	Color   string
+	ResetIn string
+	Alerted bool
+}
+
+// Status colors shared with the dashboard stylesheet.
+var emailStatusColors = map[string]string{
+	"healthy":  "#10B981",
+	"warning":  "#F59E0B",
+	"danger":   "#EF4444",
+	"critical": "#DC2626",
+	"reset":    "#0D9488",
+}
+
+// SetDataDir sets the directory searched for EmailTemplateFile.
+func (e *NotificationEngine) SetDataDir(dir string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.dataDir = dir
+}
+
+// SetDashboardURL sets the dashboard link included in HTML emails.
+func (e *NotificationEngine) SetDashboardURL(url string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.dashboardURL = url
+}
+
+// buildEmail renders the HTML alternative for a quota alert. If the template
+// fails to render the email is sent as plaintext only.
+func (e *NotificationEngine) buildEmail(status QuotaStatus, notifType, subject, text string) emailMessage {
+	email := emailMessage{Subject: subject, Text: text}
+
+	e.mu.RLock()
+	dataDir := e.dataDir
+	e.mu.RUnlock()
+
+	tmpl, err := loadEmailTemplate(dataDir)
+	if err != nil {
+		e.logger.Warn("custom email template unusable, using built-in template", "error", err)
+		tmpl = template.Must(template.New("email").Parse(defaultEmailTemplate))
+	}
+
+	data, image := e.emailTemplateData(status, notifType, subject)
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		e.logger.Warn("failed to render HTML email, sending plaintext", "error", err)
+		return email
+	}
+	email.HTML = buf.String()
+	if image != nil && data.Sparkline != "" {
+		email.Images = append(email.Images, *image)
+	}
+	return email
+}
+
+// loadEmailTemplate parses the template override in dataDir, or the built-in
+// template when no override exists.
+func loadEmailTemplate(dataDir string) (*template.Template, error) {
+	src := defaultEmailTemplate
+	if dataDir != "" {
+		path := filepath.Join(dataDir, EmailTemplateFile)
+		content, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			src = string(content)
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+	}
+	tmpl, err := template.New("email").Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("parse email template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// emailTemplateData collects the quota bars, reset countdown and 24h
+// sparkline for an alert. Missing data is left empty rather than failing.
+func (e *NotificationEngine) emailTemplateData(status QuotaStatus, notifType, subject string) (emailTemplateData, *inlineImage) {
+	e.mu.RLock()
+	dashboardURL := e.dashboardURL
+	cfg := e.cfg
+	e.mu.RUnlock()
+
+	now := time.Now()
+	data := emailTemplateData{
+		Subject:      subject,
+		Provider:     titleCase(status.Provider),
+		QuotaKey:     status.QuotaKey,
+		QuotaLabel:   status.QuotaKey,
+		AlertType:    notifType,
+		Utilization:  status.Utilization,
+		Limit:        status.Limit,
+		Color:        emailAlertColor(notifType, status.Utilization, cfg),
+		DashboardURL: dashboardURL,
+		SentAt:       now.UTC().Format(time.RFC3339),
+	}
+	if status.Budget != nil {
+		data.Provider = "Budget"
+		data.QuotaLabel = status.Budget.Label
+		return data, nil
+	}
+	if r := status.Reliability; r != nil {
+		data.Provider = "API Integrations"
+		data.QuotaLabel = r.Integration + " " + r.Provider + "/" + r.Model
+		data.Summary = fmt.Sprintf("%s over the last %s.", titleCase(r.summary()), formatSnooze(r.Window))
+		return data, nil
+	}
+	if r := status.Runway; r != nil {
+		data.Summary = fmt.Sprintf("The balance of %.2f lasts about %.1f more days at %.2f/day.", r.Balance, r.DaysLeft, r.DailySpend)
+		if r.DaysLeft <= 0 {
+			data.Summary = fmt.Sprintf("The balance of %.2f has reached its floor of %.2f.", r.Balance, r.Floor)
+		}
+	}
+
+	if snapshot, err := e.currentSnapshot(); err == nil && snapshot != nil {
+		if card := findProviderCard(snapshot.Providers, status); card != nil {
+			data.Provider = card.Label
+			for _, q := range card.Quotas {
+				bar := emailQuotaBar{
+					Label:   q.Label,
+					Value:   q.DisplayValue,
+					Percent: q.Percent,
+					Width:   int(min(100, max(0, q.Percent))),
+					Color:   emailStatusColor(q.Status),
+					ResetIn: q.TimeUntilReset,
+					Alerted: q.Key == status.QuotaKey,
+				}
+				if bar.Value == "" {
+					bar.Value = fmt.Sprintf("%.1f%%", q.Percent)
+				}
+				if bar.Alerted {
+					data.QuotaLabel = q.Label
+					data.ResetIn = q.TimeUntilReset
+					if at, err := time.Parse(time.RFC3339, q.ResetAt); err == nil {
+						data.ResetAt = at.UTC().Format("Jan 2, 15:04 MST")
+					}
+				}
+				data.Quotas = append(data.Quotas, bar)
+			}
+		}
+	}
+
+	start := now.Add(-24 * time.Hour)
+	points, err := quotaHistory(e.store, status, start, now)
+	if err != nil {
+		e.logger.Warn("failed to load quota history for email", "error", err, "provider", status.Provider)
+		return data, nil
+	}
+	png, err := renderSparklinePNG(points, start, now, parseHexColor(data.Color))
+	if err != nil || png == nil {
+		return data, nil
+	}
+	data.Sparkline = template.URL("cid:" + sparklineContentID)
+	return data, &inlineImage{
+		ContentID:   sparklineContentID,
+		ContentType: "image/png",
+		Filename:    "sparkline.png",
+		Data:        png,
+	}
+}
+
+// findProviderCard returns the menubar card for the alerted provider account.
+func findProviderCard(cards []menubar.ProviderCard, status QuotaStatus) *menubar.ProviderCard {
+	provider := normalizeNotificationProvider(status.Provider)
+	accountID := status.AccountID
+	if accountID == "" {
+		accountID = "1"
+	}
+	var fallback *menubar.ProviderCard
+	for i := range cards {
+		card := &cards[i]
+		switch {
+		case card.ID == provider+":"+accountID, card.ID == provider:
+			return card
+		case fallback == nil && card.BaseProvider == provider:
+			fallback = card
+		}
+	}
+	return fallback
+}
+
+func emailAlertColor(notifType string, utilization float64, cfg NotificationConfig) string {
+	switch {
+	case notifType == "reset":
+		return emailStatusColors["reset"]
+	case notifType == "critical" || notifType == "anomaly" || notifType == "budget_critical" || utilization >= cfg.Critical:
+		return emailStatusColors["critical"]
+	case notifType == "warning" || notifType == "pacing" || notifType == "budget_warning" || notifType == "runway_low" || notifType == "degraded" || utilization >= cfg.Warning:
+		return emailStatusColors["warning"]
+	default:
+		return emailStatusColors["healthy"]
+	}
+}
+
+func emailStatusColor(status string) string {
+	if c, ok := emailStatusColors[status]; ok {
+		return c
+	}
+	return emailStatusColors["healthy"]
+}
+
+// parseHexColor parses "#RRGGBB", returning opaque black on malformed input.
+func parseHexColor(s string) color.RGBA {
+	c := color.RGBA{A: 0xFF}
+	if len(s) == 7 && s[0] == '#' {
+		fmt.Sscanf(s[1:], "%02x%02x%02x", &c.R, &c.G, &c.B)
+	}
+	return c
+}
+
+// buildMultipartMessage constructs a multipart/alternative message with the
+// plaintext body first and the HTML part (with related inline images) last,
+// so clients that cannot render HTML show the text.
+func (m *SMTPMailer) buildMultipartMessage(to []string, email emailMessage) (string, error) {
+	var body bytes.Buffer
+	alt := multipart.NewWriter(&body)
+
+	textPart, err := alt.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := writeQuotedPrintable(textPart, email.Text); err != nil {
+		return "", err
+	}
+
+	htmlHeader := textproto.MIMEHeader{
+		"Content-Type":              {"text/html; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	}
+	if len(email.Images) == 0 {
+		htmlPart, err := alt.CreatePart(htmlHeader)
+		if err != nil {
+			return "", err
+		}
+		if err := writeQuotedPrintable(htmlPart, email.HTML); err != nil {
+			return "", err
+		}
+	} else {
+		var relatedBody bytes.Buffer
+		related := multipart.NewWriter(&relatedBody)
+		htmlPart, err := related.CreatePart(htmlHeader)
+		if err != nil {
+			return "", err
+		}
+		if err := writeQuotedPrintable(htmlPart, email.HTML); err != nil {
+			return "", err
+		}
+		for _, img := range email.Images {
+			imgPart, err := related.CreatePart(textproto.MIMEHeader{
+				"Content-Type":              {img.ContentType},
+				"Content-Transfer-Encoding": {"base64"},
+				"Content-ID":                {"<" + img.ContentID + ">"},
+				"Content-Disposition":       {mime.FormatMediaType("inline", map[string]string{"filename": img.Filename})},
+			})
+			if err != nil {
+				return "", err
+			}
+			if err := writeBase64Lines(imgPart, img.Data); err != nil {
+				return "", err
+			}
+		}
+		if err := related.Close(); err != nil {
+			return "", err
+		}
+		relatedPart, err := alt.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {mime.FormatMediaType("multipart/related", map[string]string{
+				"boundary": related.Boundary(),
+				"type":     "text/html",
+			})},
+		})
+		if err != nil {
+			return "", err
+		}
+		if _, err := relatedPart.Write(relatedBody.Bytes()); err != nil {
+			return "", err
+		}
+	}
+	if err := alt.Close(); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("From: %s <%s>\r\n", m.config.FromName, m.config.FromAddr))
+	sb.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(to, ", ")))
+	sb.WriteString(fmt.Sprintf("Subject: %s\r\n", email.Subject))
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString(fmt.Sprintf("Content-Type: %s\r\n", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": alt.Boundary()})))
+	sb.WriteString("\r\n")
+	sb.Write(body.Bytes())
+	return sb.String(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, s string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(s)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writeBase64Lines writes base64 wrapped at 76 characters per RFC 2045.
+func writeBase64Lines(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+	return err
+}
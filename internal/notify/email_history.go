@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+// usagePoint is one utilization sample used to draw email sparklines.
+type usagePoint = store.QuotaSample
+
+// emailHistoryLimit caps the snapshots read per sparkline; one day of polling
+// at the shortest interval fits comfortably.
+const emailHistoryLimit = 2000
+
+// quotaHistory returns utilization samples for the quota named in status
+// between start and end, oldest first. Providers without per-quota history
+// return no points.
+func quotaHistory(s *store.Store, status QuotaStatus, start, end time.Time) ([]usagePoint, error) {
+	return quotaHistoryLimit(s, status, start, end, emailHistoryLimit)
+}
+
+// quotaHistoryLimit is quotaHistory reading at most limit snapshots (the most
+// recent ones in the range).
+func quotaHistoryLimit(s *store.Store, status QuotaStatus, start, end time.Time, limit int) ([]usagePoint, error) {
+	accountID := int64(1)
+	if id, err := strconv.ParseInt(status.AccountID, 10, 64); err == nil && id > 0 {
+		accountID = id
+	}
+
+	provider := normalizeNotificationProvider(status.Provider)
+	if provider == "legacy" {
+		provider = "synthetic"
+	}
+	series, _, err := s.QueryQuotaSeries(provider, accountID, start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range series {
+		if q.Name == status.QuotaKey {
+			return q.Samples, nil
+		}
+	}
+	return nil, nil
+}
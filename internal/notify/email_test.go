@@ -0,0 +1,451 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/color"
+	"image/png"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/api"
+	"github.com/onllm-dev/onwatch/v2/internal/menubar"
+)
+
+// capturingSMTPHandler accepts one message per DATA command and stores the
+// un-dot-stuffed message text.
+func capturingSMTPHandler(conn net.Conn, mu *sync.Mutex, messages *[]string) {
+	defer conn.Close()
+	fmt.Fprintf(conn, "220 mock ESMTP\r\n")
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		cmd := strings.ToUpper(strings.SplitN(scanner.Text(), " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			fmt.Fprintf(conn, "250-mock\r\n250 AUTH PLAIN\r\n")
+		case "AUTH":
+			fmt.Fprintf(conn, "235 OK\r\n")
+		case "MAIL", "RCPT":
+			fmt.Fprintf(conn, "250 OK\r\n")
+		case "DATA":
+			fmt.Fprintf(conn, "354 Go ahead\r\n")
+			var sb strings.Builder
+			for scanner.Scan() {
+				line := scanner.Text()
+				if line == "." {
+					break
+				}
+				sb.WriteString(strings.TrimPrefix(line, "."))
+				sb.WriteString("\r\n")
+			}
+			mu.Lock()
+			*messages = append(*messages, sb.String())
+			mu.Unlock()
+			fmt.Fprintf(conn, "250 OK\r\n")
+		case "QUIT":
+			fmt.Fprintf(conn, "221 Bye\r\n")
+			return
+		default:
+			fmt.Fprintf(conn, "500 Unknown\r\n")
+		}
+	}
+}
+
+// mimeParts walks a parsed message and returns leaf parts keyed by media type.
+func mimeParts(t *testing.T, raw string) (string, map[string][]*multipart.Part, map[string][]byte) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	topType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("ParseMediaType: %v", err)
+	}
+	parts := map[string][]*multipart.Part{}
+	bodies := map[string][]byte{}
+	var walk func(r io.Reader, boundary string)
+	walk = func(r io.Reader, boundary string) {
+		mr := multipart.NewReader(r, boundary)
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				t.Fatalf("NextPart: %v", err)
+			}
+			mediaType, ps, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+			if strings.HasPrefix(mediaType, "multipart/") {
+				walk(p, ps["boundary"])
+				continue
+			}
+			data, err := io.ReadAll(p)
+			if err != nil {
+				t.Fatalf("read part %s: %v", mediaType, err)
+			}
+			parts[mediaType] = append(parts[mediaType], p)
+			bodies[mediaType] = data
+		}
+	}
+	walk(msg.Body, params["boundary"])
+	return topType, parts, bodies
+}
+
+func TestSMTPMailer_SendEmail_Multipart(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	var messages []string
+	addr, ln := mockSMTPServer(t, func(conn net.Conn) {
+		capturingSMTPHandler(conn, &mu, &messages)
+	})
+	defer ln.Close()
+
+	host, port := splitHostPort(t, addr)
+	mailer := NewSMTPMailer(SMTPConfig{
+		Host: host, Port: port, Protocol: "none",
+		FromAddr: "alerts@onwatch.dev", FromName: "onWatch", ToAddrs: []string{"admin@example.com"},
+	}, slog.Default())
+
+	img := bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, 100)
+	err := mailer.sendEmail(emailMessage{
+		Subject: "[WARNING] test",
+		Text:    "Provider: anthropic\nUtilization: 85.0%",
+		HTML:    `<p>85%</p><img src="cid:` + sparklineContentID + `">`,
+		Images:  []inlineImage{{ContentID: sparklineContentID, ContentType: "image/png", Filename: "sparkline.png", Data: img}},
+	})
+	if err != nil {
+		t.Fatalf("sendEmail failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	topType, parts, bodies := mimeParts(t, messages[0])
+	if topType != "multipart/alternative" {
+		t.Fatalf("top-level type = %q, want multipart/alternative", topType)
+	}
+	if !strings.Contains(string(bodies["text/plain"]), "Utilization: 85.0%") {
+		t.Errorf("plaintext part = %q", bodies["text/plain"])
+	}
+	if !strings.Contains(string(bodies["text/html"]), "cid:"+sparklineContentID) {
+		t.Errorf("html part = %q", bodies["text/html"])
+	}
+	if len(parts["image/png"]) != 1 {
+		t.Fatalf("expected one inline image, got %d", len(parts["image/png"]))
+	}
+	if got := parts["image/png"][0].Header.Get("Content-ID"); got != "<"+sparklineContentID+">" {
+		t.Errorf("Content-ID = %q", got)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(bodies["image/png"]), "\r\n", ""))
+	if err != nil {
+		t.Fatalf("decode inline image: %v", err)
+	}
+	if !bytes.Equal(decoded, img) {
+		t.Error("inline image did not round-trip")
+	}
+}
+
+func TestSMTPMailer_SendEmail_NoHTMLSendsPlaintext(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	var messages []string
+	addr, ln := mockSMTPServer(t, func(conn net.Conn) {
+		capturingSMTPHandler(conn, &mu, &messages)
+	})
+	defer ln.Close()
+
+	host, port := splitHostPort(t, addr)
+	mailer := NewSMTPMailer(SMTPConfig{
+		Host: host, Port: port, Protocol: "none",
+		FromAddr: "alerts@onwatch.dev", ToAddrs: []string{"admin@example.com"},
+	}, slog.Default())
+	if err := mailer.sendEmail(emailMessage{Subject: "s", Text: "plain body"}); err != nil {
+		t.Fatalf("sendEmail failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(messages) != 1 || !strings.Contains(messages[0], "Content-Type: text/plain; charset=UTF-8") {
+		t.Fatalf("expected a text/plain message, got %v", messages)
+	}
+}
+
+func TestRenderSparklinePNG(t *testing.T) {
+	t.Parallel()
+	end := time.Now()
+	start := end.Add(-24 * time.Hour)
+	stroke := color.RGBA{R: 0xDC, G: 0x26, B: 0x26, A: 0xFF}
+
+	if out, err := renderSparklinePNG([]usagePoint{{At: start, Percent: 10}}, start, end, stroke); err != nil || out != nil {
+		t.Fatalf("single point: got %d bytes, err %v; want nil", len(out), err)
+	}
+
+	points := []usagePoint{
+		{At: start.Add(time.Hour), Percent: 10},
+		{At: start.Add(12 * time.Hour), Percent: 60},
+		{At: end, Percent: 95},
+	}
+	out, err := renderSparklinePNG(points, start, end, stroke)
+	if err != nil {
+		t.Fatalf("renderSparklinePNG: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != sparklineWidth || b.Dy() != sparklineHeight {
+		t.Fatalf("bounds = %v", b)
+	}
+	// The newest point (95%) is drawn in the stroke color near the top right.
+	if got := color.RGBAModel.Convert(img.At(sparklineWidth-1, sparklineY(95))).(color.RGBA); got != stroke {
+		t.Errorf("pixel at 95%% = %v, want stroke %v", got, stroke)
+	}
+	// Nothing is drawn before the first sample.
+	if _, _, _, a := img.At(0, sparklineY(10)).RGBA(); a != 0 {
+		t.Error("expected transparent pixel before the first sample")
+	}
+}
+
+func TestParseHexColor(t *testing.T) {
+	t.Parallel()
+	if got := parseHexColor("#0D9488"); got != (color.RGBA{R: 0x0D, G: 0x94, B: 0x88, A: 0xFF}) {
+		t.Errorf("parseHexColor = %v", got)
+	}
+	if got := parseHexColor("teal"); got != (color.RGBA{A: 0xFF}) {
+		t.Errorf("malformed color = %v, want black", got)
+	}
+}
+
+// seedAnthropicHistory stores hourly five_hour snapshots over the last day.
+func seedAnthropicHistory(t *testing.T, engine *NotificationEngine) {
+	t.Helper()
+	now := time.Now().UTC()
+	for i := 23; i >= 0; i-- {
+		_, err := engine.store.InsertAnthropicSnapshot(&api.AnthropicSnapshot{
+			CapturedAt: now.Add(-time.Duration(i) * time.Hour),
+			Quotas:     []api.AnthropicQuota{{Name: "five_hour", Utilization: float64(80 - i*2)}},
+		})
+		if err != nil {
+			t.Fatalf("InsertAnthropicSnapshot: %v", err)
+		}
+	}
+}
+
+func anthropicMenubarSnapshot() (*menubar.Snapshot, error) {
+	return &menubar.Snapshot{Providers: []menubar.ProviderCard{{
+		ID: "anthropic", BaseProvider: "anthropic", Label: "Anthropic",
+		Quotas: []menubar.QuotaMeter{
+			{Key: "five_hour", Label: "5-Hour Limit", DisplayValue: "85%", Percent: 85, Status: "warning",
+				ResetAt: time.Now().Add(2 * time.Hour).UTC().Format(time.RFC3339), TimeUntilReset: "2h 0m"},
+			{Key: "seven_day", Label: "Weekly All-Model", DisplayValue: "40%", Percent: 40, Status: "healthy"},
+		},
+	}}}, nil
+}
+
+func TestBuildEmail_RendersBarsSparklineAndLink(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	defer s.Close()
+	engine := newTestEngine(t, s)
+	engine.SetSnapshotProvider(anthropicMenubarSnapshot)
+	engine.SetDashboardURL("http://localhost:9211/")
+	seedAnthropicHistory(t, engine)
+
+	status := QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 85}
+	email := engine.buildEmail(status, "warning", "[WARNING] subject", "plain body")
+
+	if email.Text != "plain body" || email.Subject != "[WARNING] subject" {
+		t.Errorf("text fallback changed: %+v", email)
+	}
+	for _, want := range []string{
+		"Anthropic &middot; 5-Hour Limit",
+		"Weekly All-Model",
+		"Resets in <strong>2h 0m</strong>",
+		`href="http://localhost:9211/"`,
+		`src="cid:` + sparklineContentID + `"`,
+		"background:#F59E0B",
+	} {
+		if !strings.Contains(email.HTML, want) {
+			t.Errorf("HTML missing %q", want)
+		}
+	}
+	if len(email.Images) != 1 || email.Images[0].ContentType != "image/png" {
+		t.Fatalf("expected one PNG sparkline, got %+v", email.Images)
+	}
+	if _, err := png.Decode(bytes.NewReader(email.Images[0].Data)); err != nil {
+		t.Errorf("sparkline is not a valid PNG: %v", err)
+	}
+}
+
+func TestBuildEmail_NoHistoryOrSnapshot(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	defer s.Close()
+	engine := newTestEngine(t, s)
+
+	email := engine.buildEmail(QuotaStatus{Provider: "zai", QuotaKey: "tokens", Utilization: 96}, "critical", "subj", "body")
+	if email.HTML == "" {
+		t.Fatal("expected HTML even without history")
+	}
+	if len(email.Images) != 0 || strings.Contains(email.HTML, "cid:") {
+		t.Error("expected no sparkline without history")
+	}
+	if strings.Contains(email.HTML, "Open dashboard") {
+		t.Error("expected no dashboard link when URL is unset")
+	}
+	if !strings.Contains(email.HTML, "96.0%") {
+		t.Error("expected utilization in HTML")
+	}
+}
+
+func TestBuildEmail_TemplateOverride(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	defer s.Close()
+	engine := newTestEngine(t, s)
+	dir := t.TempDir()
+	engine.SetDataDir(dir)
+
+	override := `<p>{{.Provider}}/{{.QuotaKey}} {{printf "%.0f" .Utilization}}</p>`
+	if err := os.WriteFile(filepath.Join(dir, EmailTemplateFile), []byte(override), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	email := engine.buildEmail(QuotaStatus{Provider: "codex", QuotaKey: "five_hour", Utilization: 81}, "warning", "subj", "body")
+	if email.HTML != "<p>Codex/five_hour 81</p>" {
+		t.Errorf("override HTML = %q", email.HTML)
+	}
+
+	// A broken override falls back to the built-in template.
+	if err := os.WriteFile(filepath.Join(dir, EmailTemplateFile), []byte("{{.Provider"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	email = engine.buildEmail(QuotaStatus{Provider: "codex", QuotaKey: "five_hour", Utilization: 81}, "warning", "subj", "body")
+	if !strings.Contains(email.HTML, "Sent by onWatch") {
+		t.Errorf("expected built-in template after parse error, got %q", email.HTML)
+	}
+
+	// A template that fails at execution time sends plaintext only.
+	if err := os.WriteFile(filepath.Join(dir, EmailTemplateFile), []byte("{{.Missing}}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	email = engine.buildEmail(QuotaStatus{Provider: "codex", QuotaKey: "five_hour"}, "warning", "subj", "body")
+	if email.HTML != "" || email.Text != "body" {
+		t.Errorf("expected plaintext-only email, got %+v", email)
+	}
+}
+
+func TestFindProviderCard(t *testing.T) {
+	t.Parallel()
+	cards := []menubar.ProviderCard{
+		{ID: "codex:1", BaseProvider: "codex", Label: "Codex"},
+		{ID: "codex:2", BaseProvider: "codex", Label: "Codex (work)"},
+		{ID: "anthropic", BaseProvider: "anthropic", Label: "Anthropic"},
+	}
+	tests := []struct {
+		status QuotaStatus
+		want   string
+	}{
+		{QuotaStatus{Provider: "codex", AccountID: "2"}, "Codex (work)"},
+		{QuotaStatus{Provider: "codex"}, "Codex"},
+		{QuotaStatus{Provider: "codex", AccountID: "9"}, "Codex"},
+		{QuotaStatus{Provider: "Anthropic"}, "Anthropic"},
+	}
+	for _, tt := range tests {
+		card := findProviderCard(cards, tt.status)
+		if card == nil || card.Label != tt.want {
+			t.Errorf("findProviderCard(%+v) = %v, want %s", tt.status, card, tt.want)
+		}
+	}
+	if findProviderCard(cards, QuotaStatus{Provider: "gemini"}) != nil {
+		t.Error("expected no card for an untracked provider")
+	}
+}
+
+func TestQuotaHistory_Providers(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	defer s.Close()
+	now := time.Now().UTC()
+	start := now.Add(-24 * time.Hour)
+
+	limit := 10.0
+	for i, usage := range []float64{2, 5} {
+		at := now.Add(-time.Duration(2-i) * time.Hour)
+		if _, err := s.InsertSnapshot(&api.Snapshot{CapturedAt: at, Sub: api.QuotaInfo{Limit: 200, Requests: usage * 20}}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.InsertOpenRouterSnapshot(&api.OpenRouterSnapshot{CapturedAt: at, Usage: usage, Limit: &limit}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := quotaHistory(s, QuotaStatus{Provider: "synthetic", QuotaKey: "subscription"}, start, now)
+	if err != nil || len(got) != 2 || got[0].Percent != 20 || got[1].Percent != 50 {
+		t.Errorf("synthetic history = %+v, err %v", got, err)
+	}
+	got, err = quotaHistory(s, QuotaStatus{Provider: "openrouter", QuotaKey: "credits"}, start, now)
+	if err != nil || len(got) != 2 || got[1].Percent != 50 {
+		t.Errorf("openrouter history = %+v, err %v", got, err)
+	}
+	got, err = quotaHistory(s, QuotaStatus{Provider: "antigravity", QuotaKey: "x"}, start, now)
+	if err != nil || got != nil {
+		t.Errorf("unsupported provider history = %+v, err %v", got, err)
+	}
+}
+
+func TestSendNotification_EmailIsMultipart(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	defer s.Close()
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	engine.SetSnapshotProvider(anthropicMenubarSnapshot)
+	seedAnthropicHistory(t, engine)
+
+	var mu sync.Mutex
+	var messages []string
+	addr, ln := mockSMTPServer(t, func(conn net.Conn) {
+		capturingSMTPHandler(conn, &mu, &messages)
+	})
+	defer ln.Close()
+	host, port := splitHostPort(t, addr)
+	storeSMTPConfig(t, s, host, port)
+	if err := engine.ConfigureSMTP(); err != nil {
+		t.Fatalf("ConfigureSMTP: %v", err)
+	}
+
+	status := QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 85}
+	engine.sendNotification(engine.mailer, nil, NotificationChannels{Email: true}, status, "warning")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	_, parts, bodies := mimeParts(t, messages[0])
+	if !strings.Contains(string(bodies["text/plain"]), "Alert Type: warning") {
+		t.Errorf("plaintext fallback = %q", bodies["text/plain"])
+	}
+	if !strings.Contains(string(bodies["text/html"]), "5-Hour Limit") {
+		t.Error("HTML part missing quota label")
+	}
+	if len(parts["image/png"]) != 1 {
+		t.Errorf("expected inline sparkline, got %d images", len(parts["image/png"]))
+	}
+}
@@ -4,20 +4,40 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"os"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/onllm-dev/onwatch/v2/internal/menubar"
 	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/tracker"
 )
 
-// NotificationEngine evaluates quota statuses and sends alerts via email and push.
+// NotificationEngine evaluates quota statuses and sends alerts via email, push, desktop and Telegram.
 type NotificationEngine struct {
 	store               *store.Store
 	logger              *slog.Logger
 	mailer              *SMTPMailer
 	pushSender          *PushSender
+	desktop             *DesktopNotifier
+	telegram            *TelegramBot
+	snapshotProvider    menubar.SnapshotProvider   // feeds Telegram /status and /resets
+	dataDir             string                     // searched for the HTML email template override
+	dashboardURL        string                     // linked from HTML emails
+	reports             *reportScheduler           // nil until StartReports
+	reportMu            sync.Mutex                 // serializes report sends
+	anomaly             *anomalyDetector           // nil until StartAnomalyDetection
+	anomalyMu           sync.Mutex                 // guards anomalyQuotas, anomalyAlerted and anomalyBaselines
+	anomalyQuotas       map[string]QuotaStatus     // provider:quota -> latest status seen
+	anomalyAlerted      map[string]time.Time       // provider:quota -> last anomaly alert
+	anomalyBaselines    map[string]anomalyBaseline // provider:quota -> cached baseline
+	budgets             *budgetChecker             // nil until StartBudgetChecks
+	budgetProvider      BudgetProvider             // reports monthly budgets and spend
+	reliability         *reliabilityChecker        // nil until StartReliabilityChecks
+	reliabilityProvider ReliabilityProvider        // reports API integration error rates and latency
 	vapidPublicKey      string
+	desktopSnoozed      map[string]time.Time // provider:quota -> desktop alerts muted until
 	mu                  sync.RWMutex
 	cfg                 NotificationConfig
 	encryptionKey       string // current hex-encoded key for decrypting SMTP passwords
@@ -26,18 +46,23 @@ type NotificationEngine struct {
 
 // NotificationConfig holds threshold and delivery settings.
 type NotificationConfig struct {
-	Warning   float64                      // global warning threshold (default 80)
-	Critical  float64                      // global critical threshold (default 95)
-	Overrides map[string]ThresholdOverride // per provider+quota overrides (legacy key: quota only)
-	Cooldown  time.Duration                // minimum time between notifications
-	Types     NotificationTypes            // which notification types are enabled
-	Channels  NotificationChannels         // which delivery channels are enabled
+	Warning       float64                      // global warning threshold (default 80)
+	Critical      float64                      // global critical threshold (default 95)
+	Overrides     map[string]ThresholdOverride // per provider+quota overrides (legacy key: quota only)
+	Cooldown      time.Duration                // minimum time between notifications
+	Types         NotificationTypes            // which notification types are enabled
+	Channels      NotificationChannels         // which delivery channels are enabled
+	PaceThreshold float64                      // points ahead of the budget line that raise a pacing alert (default 10)
+	AnomalyFactor float64                      // multiple of the baseline hourly rate that counts as an anomaly (default 3)
+	RunwayDays    float64                      // days of prepaid balance left that raise a runway alert (default 7)
 }
 
 // NotificationChannels controls which delivery channels are active.
 type NotificationChannels struct {
-	Email bool `json:"email"`
-	Push  bool `json:"push"`
+	Email    bool `json:"email"`
+	Push     bool `json:"push"`
+	Desktop  bool `json:"desktop"`
+	Telegram bool `json:"telegram"`
 }
 
 // ThresholdOverride allows per-quota threshold customization.
@@ -56,6 +81,9 @@ type NotificationTypes struct {
 	Critical  bool `json:"critical"`
 	Reset     bool `json:"reset"`
 	AuthError bool `json:"auth_error"` // Auth failure notifications
+	Pacing    bool `json:"pacing"`     // Ahead of the budget line in a long window
+	Anomaly   bool `json:"anomaly"`    // Consumption well above the learned baseline
+	Runway    bool `json:"runway"`     // Prepaid balance projected to run out soon
 }
 
 // QuotaStatus represents the current state of a quota for notification evaluation.
@@ -66,20 +94,29 @@ type QuotaStatus struct {
 	Utilization   float64
 	Limit         float64
 	ResetOccurred bool
+	Pacing        *tracker.Pacing             // nil for windows shorter than a day
+	Anomaly       *tracker.ConsumptionAnomaly // set by the anomaly detector for "anomaly" notifications
+	Budget        *BudgetStatus               // set for "budget_warning" and "budget_critical" notifications
+	Reliability   *ReliabilityStatus          // set for "degraded" notifications
+	Runway        *tracker.Runway             // set for "runway_low" notifications
 }
 
 // New creates a new NotificationEngine with default configuration.
 func New(s *store.Store, logger *slog.Logger) *NotificationEngine {
 	return &NotificationEngine{
-		store:  s,
-		logger: logger,
+		store:          s,
+		logger:         logger,
+		desktopSnoozed: make(map[string]time.Time),
 		cfg: NotificationConfig{
-			Warning:   80,
-			Critical:  95,
-			Overrides: make(map[string]ThresholdOverride),
-			Cooldown:  30 * time.Minute,
-			Types:     NotificationTypes{Warning: true, Critical: true, Reset: false},
-			Channels:  NotificationChannels{Email: true, Push: true},
+			Warning:       80,
+			Critical:      95,
+			Overrides:     make(map[string]ThresholdOverride),
+			Cooldown:      30 * time.Minute,
+			Types:         NotificationTypes{Warning: true, Critical: true, Reset: false},
+			Channels:      NotificationChannels{Email: true, Push: true},
+			PaceThreshold: 10,
+			AnomalyFactor: tracker.DefaultAnomalyFactor,
+			RunwayDays:    tracker.DefaultRunwayDays,
 		},
 	}
 }
@@ -122,6 +159,12 @@ type notificationSettingsJSON struct {
 	NotifyCritical    bool                  `json:"notify_critical"`
 	NotifyReset       bool                  `json:"notify_reset"`
 	NotifyAuthError   bool                  `json:"notify_auth_error"`
+	NotifyPacing      bool                  `json:"notify_pacing"`
+	PaceThreshold     float64               `json:"pace_threshold"`
+	NotifyAnomaly     bool                  `json:"notify_anomaly"`
+	AnomalyFactor     float64               `json:"anomaly_factor"`
+	NotifyRunway      bool                  `json:"notify_runway"`
+	RunwayDays        float64               `json:"runway_days"`
 	CooldownMinutes   int                   `json:"cooldown_minutes"`
 	Channels          *NotificationChannels `json:"channels,omitempty"`
 	Overrides         []struct {
@@ -161,11 +204,23 @@ func (e *NotificationEngine) Reload() error {
 	if notif.CooldownMinutes > 0 {
 		e.cfg.Cooldown = time.Duration(notif.CooldownMinutes) * time.Minute
 	}
+	if notif.PaceThreshold > 0 {
+		e.cfg.PaceThreshold = notif.PaceThreshold
+	}
+	if notif.AnomalyFactor > 0 {
+		e.cfg.AnomalyFactor = notif.AnomalyFactor
+	}
+	if notif.RunwayDays > 0 {
+		e.cfg.RunwayDays = notif.RunwayDays
+	}
 	e.cfg.Types = NotificationTypes{
 		Warning:   notif.NotifyWarning,
 		Critical:  notif.NotifyCritical,
 		Reset:     notif.NotifyReset,
 		AuthError: notif.NotifyAuthError,
+		Pacing:    notif.NotifyPacing,
+		Anomaly:   notif.NotifyAnomaly,
+		Runway:    notif.NotifyRunway,
 	}
 
 	overrides := make(map[string]ThresholdOverride, len(notif.Overrides))
@@ -208,6 +263,15 @@ type smtpSettingsJSON struct {
 	FromAddress string `json:"from_address"`
 	FromName    string `json:"from_name"`
 	To          string `json:"to"`
+
+	// XOAUTH2 settings. Client secret and refresh token are stored via EncryptForStorage.
+	AuthMethod        string `json:"auth_method,omitempty"`
+	OAuthProvider     string `json:"oauth_provider,omitempty"`
+	OAuthTokenURL     string `json:"oauth_token_url,omitempty"`
+	OAuthTenant       string `json:"oauth_tenant,omitempty"`
+	OAuthClientID     string `json:"oauth_client_id,omitempty"`
+	OAuthClientSecret string `json:"oauth_client_secret,omitempty"`
+	OAuthRefreshToken string `json:"oauth_refresh_token,omitempty"`
 }
 
 // ConfigureSMTP initializes or updates the SMTP mailer from DB settings.
@@ -303,6 +367,27 @@ func (e *NotificationEngine) ConfigureSMTP() error {
 		ToAddrs:  toAddrs,
 	}
 
+	if s.AuthMethod == SMTPAuthOAuth2 {
+		clientSecret, err := decryptStoredSecret(s.OAuthClientSecret, key)
+		if err != nil {
+			return fmt.Errorf("notify.ConfigureSMTP: decrypt OAuth2 client secret: %w", err)
+		}
+		refreshToken, err := decryptStoredSecret(s.OAuthRefreshToken, key)
+		if err != nil {
+			return fmt.Errorf("notify.ConfigureSMTP: decrypt OAuth2 refresh token: %w", err)
+		}
+		cfg.AuthMethod = SMTPAuthOAuth2
+		cfg.OAuth2 = SMTPOAuth2Config{
+			Provider:     s.OAuthProvider,
+			TokenURL:     s.OAuthTokenURL,
+			TenantID:     s.OAuthTenant,
+			ClientID:     s.OAuthClientID,
+			ClientSecret: clientSecret,
+			RefreshToken: refreshToken,
+			OnRotate:     e.saveSMTPRefreshToken,
+		}
+	}
+
 	e.mu.Lock()
 	e.mailer = NewSMTPMailer(cfg, e.logger)
 	e.mu.Unlock()
@@ -310,6 +395,59 @@ func (e *NotificationEngine) ConfigureSMTP() error {
 	return nil
 }
 
+// saveSMTPRefreshToken persists a refresh token the OAuth2 provider rotated,
+// so the SMTP mailer keeps working after a restart. It leaves the settings
+// alone when the saved token is no longer the one that was rotated.
+func (e *NotificationEngine) saveSMTPRefreshToken(previous, rotated string) {
+	e.mu.RLock()
+	key := e.encryptionKey
+	e.mu.RUnlock()
+
+	smtpJSON, err := e.store.GetSetting("smtp")
+	if err != nil || smtpJSON == "" {
+		e.logger.Warn("failed to load SMTP settings to save rotated OAuth2 refresh token", "error", err)
+		return
+	}
+	var settings map[string]interface{}
+	if err := json.Unmarshal([]byte(smtpJSON), &settings); err != nil {
+		e.logger.Warn("failed to parse SMTP settings to save rotated OAuth2 refresh token", "error", err)
+		return
+	}
+	stored, _ := settings["oauth_refresh_token"].(string)
+	if current, err := decryptStoredSecret(stored, key); err != nil || current != previous {
+		return
+	}
+	value := rotated
+	if key != "" {
+		if value, err = EncryptForStorage(rotated, key); err != nil {
+			e.logger.Warn("failed to encrypt rotated OAuth2 refresh token", "error", err)
+			return
+		}
+	}
+	settings["oauth_refresh_token"] = value
+	updated, err := json.Marshal(settings)
+	if err != nil {
+		e.logger.Warn("failed to marshal SMTP settings with rotated OAuth2 refresh token", "error", err)
+		return
+	}
+	if err := e.store.SetSetting("smtp", string(updated)); err != nil {
+		e.logger.Warn("failed to save rotated OAuth2 refresh token", "error", err)
+		return
+	}
+	e.logger.Info("saved rotated SMTP OAuth2 refresh token")
+}
+
+// decryptStoredSecret decrypts an "enc:"-prefixed value; plaintext is returned as-is.
+func decryptStoredSecret(value, key string) (string, error) {
+	if !IsEncryptedValue(value) {
+		return value, nil
+	}
+	if key == "" {
+		return "", fmt.Errorf("encryption key not set")
+	}
+	return DecryptFromStorage(value, key)
+}
+
 // ConfigurePush initializes the push notification sender.
 // Loads or generates VAPID keys, stored in the settings table as "vapid_keys".
 func (e *NotificationEngine) ConfigurePush() error {
@@ -357,6 +495,190 @@ func (e *NotificationEngine) ConfigurePush() error {
 	return nil
 }
 
+// ConfigureDesktop connects the desktop notification channel to the session
+// bus named by DBUS_SESSION_BUS_ADDRESS. Without a session bus (headless
+// servers, macOS, Windows) the channel stays disabled and nil is returned.
+func (e *NotificationEngine) ConfigureDesktop(dashboardURL string) error {
+	addr := os.Getenv("DBUS_SESSION_BUS_ADDRESS")
+	if addr == "" {
+		return nil
+	}
+
+	desktop, err := NewDesktopNotifier(DesktopConfig{
+		BusAddress:   addr,
+		DashboardURL: dashboardURL,
+		OnSnooze:     e.SnoozeDesktop,
+	}, e.logger)
+	if err != nil {
+		return fmt.Errorf("notify.ConfigureDesktop: %w", err)
+	}
+
+	e.mu.Lock()
+	old := e.desktop
+	e.desktop = desktop
+	e.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// SnoozeDesktop mutes desktop alerts for provider+quotaKey for d. Email,
+// push and Telegram alerts are still sent. The snooze is cleared early if
+// the quota resets.
+func (e *NotificationEngine) SnoozeDesktop(provider, quotaKey string, d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.desktopSnoozed == nil {
+		e.desktopSnoozed = make(map[string]time.Time)
+	}
+	e.desktopSnoozed[notificationOverrideKey(provider, quotaKey)] = time.Now().Add(d)
+}
+
+// isDesktopSnoozed reports whether desktop alerts for provider+quotaKey are
+// currently muted.
+func (e *NotificationEngine) isDesktopSnoozed(provider, quotaKey string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	until, ok := e.desktopSnoozed[notificationOverrideKey(provider, quotaKey)]
+	return ok && time.Now().Before(until)
+}
+
+// clearDesktopSnooze removes any desktop snooze for provider+quotaKey.
+func (e *NotificationEngine) clearDesktopSnooze(provider, quotaKey string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.desktopSnoozed, notificationOverrideKey(provider, quotaKey))
+}
+
+// SendTestDesktop shows a test notification on the local desktop.
+func (e *NotificationEngine) SendTestDesktop() error {
+	e.mu.RLock()
+	desktop := e.desktop
+	e.mu.RUnlock()
+
+	if desktop == nil {
+		return fmt.Errorf("desktop notifications not available (no D-Bus session bus)")
+	}
+
+	return desktop.Send(DesktopNotification{
+		Summary: "[onWatch] Test Notification",
+		Body:    "Desktop notifications are working correctly.",
+		Urgency: DesktopUrgencyNormal,
+	})
+}
+
+// telegramSettingsJSON matches the JSON shape saved by the handler's UpdateSettings.
+type telegramSettingsJSON struct {
+	BotToken string `json:"bot_token"`
+	ChatIDs  string `json:"chat_ids"`
+	APIURL   string `json:"api_url"`
+}
+
+// SetSnapshotProvider sets the source of quota data for Telegram commands.
+// The web handler's BuildMenubarSnapshot is used so replies match the menubar.
+func (e *NotificationEngine) SetSnapshotProvider(provider menubar.SnapshotProvider) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.snapshotProvider = provider
+}
+
+// currentSnapshot builds a snapshot using the provider set at call time.
+func (e *NotificationEngine) currentSnapshot() (*menubar.Snapshot, error) {
+	e.mu.RLock()
+	provider := e.snapshotProvider
+	e.mu.RUnlock()
+	if provider == nil {
+		return nil, fmt.Errorf("snapshot provider not configured")
+	}
+	return provider()
+}
+
+// ConfigureTelegram initializes or updates the Telegram bot from DB settings
+// and starts polling for commands. The handler stores the config under key
+// "telegram" with the bot token encrypted via EncryptForStorage.
+func (e *NotificationEngine) ConfigureTelegram() error {
+	v, err := e.store.GetSetting("telegram")
+	if err != nil {
+		return fmt.Errorf("notify.ConfigureTelegram: %w", err)
+	}
+
+	var s telegramSettingsJSON
+	if v != "" {
+		if err := json.Unmarshal([]byte(v), &s); err != nil {
+			return fmt.Errorf("notify.ConfigureTelegram: invalid telegram JSON: %w", err)
+		}
+	}
+
+	chatIDs, err := ParseTelegramChatIDs(s.ChatIDs)
+	if err != nil {
+		return fmt.Errorf("notify.ConfigureTelegram: %w", err)
+	}
+
+	var bot *TelegramBot
+	if s.BotToken != "" && len(chatIDs) > 0 {
+		e.mu.RLock()
+		key := e.encryptionKey
+		e.mu.RUnlock()
+
+		token, err := decryptStoredSecret(s.BotToken, key)
+		if err != nil {
+			return fmt.Errorf("notify.ConfigureTelegram: decrypt bot token: %w", err)
+		}
+		bot = NewTelegramBot(TelegramConfig{
+			BotToken: token,
+			APIURL:   s.APIURL,
+			ChatIDs:  chatIDs,
+		}, e.currentSnapshot, e.logger)
+	}
+
+	e.mu.Lock()
+	old := e.telegram
+	e.telegram = bot
+	e.mu.Unlock()
+
+	if old != nil {
+		old.Stop()
+	}
+	if bot != nil {
+		bot.Start()
+	}
+	return nil
+}
+
+// SendTestTelegram sends a test message to every allowlisted Telegram chat.
+func (e *NotificationEngine) SendTestTelegram() error {
+	e.mu.RLock()
+	bot := e.telegram
+	e.mu.RUnlock()
+
+	if bot == nil {
+		return fmt.Errorf("telegram not configured")
+	}
+	return bot.Send("[onWatch] Test Message\n\nTelegram alerts are working. Send /status to see current quotas.")
+}
+
+// Close releases channel resources: stops the Telegram poller and
+// disconnects from the session bus.
+func (e *NotificationEngine) Close() {
+	e.mu.Lock()
+	bot, desktop := e.telegram, e.desktop
+	e.telegram, e.desktop = nil, nil
+	e.mu.Unlock()
+
+	if bot != nil {
+		bot.Stop()
+	}
+	if desktop != nil {
+		desktop.Close()
+	}
+	e.stopReports()
+	e.stopAnomalyDetection()
+	e.stopBudgetChecks()
+	e.stopReliabilityChecks()
+}
+
 // GetVAPIDPublicKey returns the VAPID public key for client-side push subscription.
 func (e *NotificationEngine) GetVAPIDPublicKey() string {
 	e.mu.RLock()
@@ -411,10 +733,15 @@ func (e *NotificationEngine) Check(status QuotaStatus) {
 	cfg := e.cfg
 	mailer := e.mailer
 	pushSender := e.pushSender
+	desktop := e.desktop
+	telegram := e.telegram
 	e.mu.RUnlock()
 
+	// The anomaly detector raises dashboard alerts even without channels
+	e.observeForAnomalies(status)
+
 	// Need at least one channel configured
-	if mailer == nil && pushSender == nil {
+	if mailer == nil && pushSender == nil && desktop == nil && telegram == nil {
 		return
 	}
 
@@ -431,6 +758,7 @@ func (e *NotificationEngine) Check(status QuotaStatus) {
 		if err := e.store.ClearNotificationLog(provider, quotaKey); err != nil {
 			e.logger.Error("failed to clear notification log on reset", "error", err)
 		}
+		e.clearDesktopSnooze(provider, quotaKey)
 		if cfg.Types.Reset && !(hasOverride && override.DisableReset) {
 			e.sendNotification(mailer, pushSender, cfg.Channels, status, "reset")
 		}
@@ -469,6 +797,11 @@ func (e *NotificationEngine) Check(status QuotaStatus) {
 		e.sendNotification(mailer, pushSender, cfg.Channels, status, "warning")
 		return
 	}
+
+	// Check pacing: an early heads-up while still below the warning threshold
+	if status.Pacing != nil && status.Pacing.DeltaPercent >= cfg.PaceThreshold && cfg.Types.Pacing {
+		e.sendNotification(mailer, pushSender, cfg.Channels, status, "pacing")
+	}
 }
 
 // SendTestEmail sends a test email to verify SMTP configuration.
@@ -528,7 +861,7 @@ func (e *NotificationEngine) sendNotification(mailer *SMTPMailer, pushSender *Pu
 
 	// Send via email if enabled and configured
 	if channels.Email && mailer != nil {
-		if err := mailer.Send(subject, body); err != nil {
+		if err := mailer.sendEmail(e.buildEmail(status, notifType, subject, body)); err != nil {
 			e.logger.Error("failed to send email notification", "error", err,
 				"quota", quotaKey, "type", notifType)
 		} else {
@@ -560,6 +893,43 @@ func (e *NotificationEngine) sendNotification(mailer *SMTPMailer, pushSender *Pu
 		}
 	}
 
+	// Send to the local desktop if enabled, a session bus is available and
+	// the quota is not snoozed there
+	e.mu.RLock()
+	desktop := e.desktop
+	e.mu.RUnlock()
+	if channels.Desktop && desktop != nil && !e.isDesktopSnoozed(provider, quotaKey) {
+		n := DesktopNotification{
+			Summary:  subject,
+			Body:     body,
+			Urgency:  desktopUrgency(notifType),
+			Provider: provider,
+			QuotaKey: quotaKey,
+		}
+		if notifType == "reset" {
+			n.Provider, n.QuotaKey = "", "" // nothing left to snooze
+		}
+		if err := desktop.Send(n); err != nil {
+			e.logger.Error("failed to send desktop notification", "error", err,
+				"quota", quotaKey, "type", notifType)
+		} else {
+			sent = true
+		}
+	}
+
+	// Send to Telegram chats if enabled and configured
+	e.mu.RLock()
+	telegram := e.telegram
+	e.mu.RUnlock()
+	if channels.Telegram && telegram != nil {
+		if err := telegram.Send(subject + "\n\n" + body); err != nil {
+			e.logger.Error("failed to send telegram notification", "error", err,
+				"quota", quotaKey, "type", notifType)
+		} else {
+			sent = true
+		}
+	}
+
 	// Log the notification only if at least one channel succeeded
 	if sent {
 		if err := e.store.UpsertNotificationLog(provider, quotaKey, notifType, status.Utilization); err != nil {
@@ -612,6 +982,46 @@ func (e *NotificationEngine) buildSubject(status QuotaStatus, notifType string)
 	case "reset":
 		return fmt.Sprintf("[RESET] %s quota %s has been reset",
 			titleCase(status.Provider), status.QuotaKey)
+	case "pacing":
+		if status.Pacing != nil {
+			return fmt.Sprintf("[PACING] %s quota %s is %.0f%% ahead of pace",
+				titleCase(status.Provider), status.QuotaKey, status.Pacing.DeltaPercent)
+		}
+		return fmt.Sprintf("[PACING] %s quota %s is ahead of pace",
+			titleCase(status.Provider), status.QuotaKey)
+	case "anomaly":
+		if status.Anomaly != nil {
+			return fmt.Sprintf("[ANOMALY] %s quota %s is consuming %.0fx faster than usual",
+				titleCase(status.Provider), status.QuotaKey, status.Anomaly.Ratio)
+		}
+		return fmt.Sprintf("[ANOMALY] %s quota %s is consuming faster than usual",
+			titleCase(status.Provider), status.QuotaKey)
+	case "runway_low":
+		if status.Runway != nil && status.Runway.DaysLeft <= 0 {
+			return fmt.Sprintf("[RUNWAY] %s %s has reached its floor",
+				titleCase(status.Provider), status.QuotaKey)
+		}
+		if status.Runway != nil {
+			return fmt.Sprintf("[RUNWAY] %s %s runs out in %.1f days",
+				titleCase(status.Provider), status.QuotaKey, status.Runway.DaysLeft)
+		}
+		return fmt.Sprintf("[RUNWAY] %s %s is running low",
+			titleCase(status.Provider), status.QuotaKey)
+	case "budget_critical", "budget_warning":
+		level := "WARNING"
+		if notifType == "budget_critical" {
+			level = "CRITICAL"
+		}
+		if status.Budget != nil {
+			return fmt.Sprintf("[%s] %s budget at %.0f%% ($%.2f of $%.2f)",
+				level, status.Budget.Label, status.Utilization, status.Budget.Spent, status.Budget.Budget)
+		}
+		return fmt.Sprintf("[%s] Budget %s at %.0f%%", level, status.QuotaKey, status.Utilization)
+	case "degraded":
+		if r := status.Reliability; r != nil {
+			return fmt.Sprintf("[DEGRADED] %s %s/%s: %s", r.Integration, r.Provider, r.Model, r.summary())
+		}
+		return fmt.Sprintf("[DEGRADED] API integration %s", status.QuotaKey)
 	default:
 		return fmt.Sprintf("[%s] %s quota %s", notifType, status.Provider, status.QuotaKey)
 	}
@@ -620,12 +1030,65 @@ func (e *NotificationEngine) buildSubject(status QuotaStatus, notifType string)
 // buildBody creates the email body text.
 func (e *NotificationEngine) buildBody(status QuotaStatus, notifType string) string {
 	var sb strings.Builder
+	if status.Budget != nil {
+		b := status.Budget
+		sb.WriteString(fmt.Sprintf("Budget: %s\n", b.Label))
+		sb.WriteString(fmt.Sprintf("Month: %s\n", b.Month))
+		sb.WriteString(fmt.Sprintf("Spent: $%.2f of $%.2f (%.1f%%)\n", b.Spent, b.Budget, status.Utilization))
+		sb.WriteString(fmt.Sprintf("Projected by month end: $%.2f\n", b.Projected))
+		sb.WriteString(fmt.Sprintf("Alert Type: %s\n", notifType))
+		sb.WriteString(fmt.Sprintf("Time: %s\n", time.Now().UTC().Format(time.RFC3339)))
+		sb.WriteString("\n-- Sent by onWatch")
+		return sb.String()
+	}
+	if r := status.Reliability; r != nil {
+		sb.WriteString(fmt.Sprintf("Integration: %s\n", r.Integration))
+		sb.WriteString(fmt.Sprintf("Provider: %s\n", r.Provider))
+		sb.WriteString(fmt.Sprintf("Model: %s\n", r.Model))
+		sb.WriteString(fmt.Sprintf("Errors: %d of %d calls (%.1f%%) in the last %s\n", r.Errors, r.Requests, r.ErrorRate(), formatSnooze(r.Window)))
+		if r.ErrorType != "" {
+			sb.WriteString(fmt.Sprintf("Most frequent error: %s\n", r.ErrorType))
+		}
+		if r.P95LatencyMS > 0 {
+			sb.WriteString(fmt.Sprintf("p95 latency: %s\n", formatLatency(r.P95LatencyMS)))
+		}
+		sb.WriteString(fmt.Sprintf("Alert Type: %s\n", notifType))
+		sb.WriteString(fmt.Sprintf("Time: %s\n", time.Now().UTC().Format(time.RFC3339)))
+		sb.WriteString("\n-- Sent by onWatch")
+		return sb.String()
+	}
+	if status.Runway != nil {
+		r := status.Runway
+		sb.WriteString(fmt.Sprintf("Provider: %s\n", status.Provider))
+		sb.WriteString(fmt.Sprintf("Balance: %.2f\n", r.Balance))
+		if r.Floor > 0 {
+			sb.WriteString(fmt.Sprintf("Floor: %.2f\n", r.Floor))
+		}
+		sb.WriteString(fmt.Sprintf("Spend rate: %.2f/day over the last %.1f days (top-ups excluded)\n",
+			r.DailySpend, r.Span.Hours()/24))
+		if r.DepletesAt != nil {
+			sb.WriteString(fmt.Sprintf("Runs out: %s (%.1f days)\n", r.DepletesAt.UTC().Format(time.RFC3339), r.DaysLeft))
+		}
+		sb.WriteString(fmt.Sprintf("Alert Type: %s\n", notifType))
+		sb.WriteString(fmt.Sprintf("Time: %s\n", time.Now().UTC().Format(time.RFC3339)))
+		sb.WriteString("\n-- Sent by onWatch")
+		return sb.String()
+	}
 	sb.WriteString(fmt.Sprintf("Provider: %s\n", status.Provider))
 	sb.WriteString(fmt.Sprintf("Quota: %s\n", status.QuotaKey))
 	sb.WriteString(fmt.Sprintf("Utilization: %.1f%%\n", status.Utilization))
 	if status.Limit > 0 {
 		sb.WriteString(fmt.Sprintf("Limit: %.0f\n", status.Limit))
 	}
+	if notifType == "pacing" && status.Pacing != nil {
+		sb.WriteString(fmt.Sprintf("Expected by now: %.1f%%\n", status.Pacing.ExpectedUtil))
+		sb.WriteString(fmt.Sprintf("Daily allowance: %.1f%%/day for %.1f days until reset\n",
+			status.Pacing.DailyAllowance, status.Pacing.DaysLeft))
+	}
+	if notifType == "anomaly" && status.Anomaly != nil {
+		sb.WriteString(fmt.Sprintf("Last hour: %.1f%% (typical %.1f%%/hour)\n",
+			status.Anomaly.CurrentRate, status.Anomaly.BaselineRate))
+	}
 	sb.WriteString(fmt.Sprintf("Alert Type: %s\n", notifType))
 	sb.WriteString(fmt.Sprintf("Time: %s\n", time.Now().UTC().Format(time.RFC3339)))
 	sb.WriteString("\n-- Sent by onWatch")
@@ -641,7 +1104,7 @@ type AuthErrorAlert struct {
 	IsRecovable bool   // If false, requires manual re-authentication
 }
 
-// SendAuthErrorNotification sends an auth error alert via email and/or push.
+// SendAuthErrorNotification sends an auth error alert via every enabled channel.
 // Also creates an in-dashboard system alert for when the user logs in.
 // Returns true if at least one notification was sent successfully.
 func (e *NotificationEngine) SendAuthErrorNotification(alert AuthErrorAlert) bool {
@@ -649,6 +1112,8 @@ func (e *NotificationEngine) SendAuthErrorNotification(alert AuthErrorAlert) boo
 	cfg := e.cfg
 	mailer := e.mailer
 	pushSender := e.pushSender
+	desktop := e.desktop
+	telegram := e.telegram
 	e.mu.RUnlock()
 
 	// Check if auth error notifications are enabled
@@ -694,7 +1159,33 @@ func (e *NotificationEngine) SendAuthErrorNotification(alert AuthErrorAlert) boo
 		}
 	}
 
-	// Create in-dashboard system alert (always, regardless of email/push success)
+	// Send to the local desktop if enabled and a session bus is available
+	if cfg.Channels.Desktop && desktop != nil {
+		urgency := DesktopUrgencyNormal
+		if !alert.IsRecovable {
+			urgency = DesktopUrgencyCritical
+		}
+		if err := desktop.Send(DesktopNotification{
+			Summary: subject,
+			Body:    alert.Message,
+			Urgency: urgency,
+		}); err != nil {
+			e.logger.Error("failed to send auth error desktop notification", "error", err, "provider", alert.Provider)
+		} else {
+			sent = true
+		}
+	}
+
+	// Send to Telegram chats if enabled and configured
+	if cfg.Channels.Telegram && telegram != nil {
+		if err := telegram.Send(subject + "\n\n" + body); err != nil {
+			e.logger.Error("failed to send auth error telegram message", "error", err, "provider", alert.Provider)
+		} else {
+			sent = true
+		}
+	}
+
+	// Create in-dashboard system alert (always, regardless of delivery success)
 	severity := "warning"
 	if !alert.IsRecovable {
 		severity = "error"
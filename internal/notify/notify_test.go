@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/tracker"
 )
 
 func newTestStore(t *testing.T) *store.Store {
@@ -114,6 +115,12 @@ func TestNotificationEngine_Reload_Defaults(t *testing.T) {
 	if cfg.Types.Reset {
 		t.Error("Default Types.Reset should be false")
 	}
+	if cfg.Types.Pacing {
+		t.Error("Default Types.Pacing should be false")
+	}
+	if cfg.PaceThreshold != 10 {
+		t.Errorf("Default PaceThreshold = %v, want 10", cfg.PaceThreshold)
+	}
 }
 
 func TestNotificationEngine_Reload_CustomValues(t *testing.T) {
@@ -569,6 +576,84 @@ func TestNotificationEngine_Check_ResetDisabled_NoNotification(t *testing.T) {
 	}
 }
 
+func TestNotificationEngine_Check_PacingAlert(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	defer s.Close()
+
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyWarning:     true,
+		NotifyCritical:    true,
+		NotifyPacing:      true,
+		PaceThreshold:     15,
+	})
+	engine := newTestEngine(t, s)
+	engine.Reload()
+
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	status := QuotaStatus{
+		Provider:    "anthropic",
+		QuotaKey:    "seven_day",
+		Utilization: 40,
+		Limit:       100,
+		Pacing:      &tracker.Pacing{ExpectedUtil: 30, DeltaPercent: 10, Status: tracker.PaceAhead},
+	}
+
+	// 10 points ahead is under the configured 15-point threshold
+	engine.Check(status)
+	if mailCount.Load() != 0 {
+		t.Fatalf("Expected 0 emails below pace threshold, got %d", mailCount.Load())
+	}
+
+	status.Utilization = 50
+	status.Pacing = &tracker.Pacing{ExpectedUtil: 30, DeltaPercent: 20, Status: tracker.PaceAhead, DailyAllowance: 10, DaysLeft: 5}
+	engine.Check(status)
+	engine.Check(status)
+	if mailCount.Load() != 1 {
+		t.Errorf("Expected 1 pacing email per cycle, got %d", mailCount.Load())
+	}
+	if sentAt, _, _ := s.GetLastNotification("anthropic", "seven_day", "pacing"); sentAt.IsZero() {
+		t.Error("Expected pacing notification to be logged")
+	}
+
+	subject := engine.buildSubject(status, "pacing")
+	if subject != "[PACING] Anthropic quota seven_day is 20% ahead of pace" {
+		t.Errorf("subject = %q", subject)
+	}
+	if body := engine.buildBody(status, "pacing"); !strings.Contains(body, "Daily allowance: 10.0%/day for 5.0 days until reset") {
+		t.Errorf("body missing daily allowance:\n%s", body)
+	}
+}
+
+func TestNotificationEngine_Check_PacingDisabled_NoNotification(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	defer s.Close()
+
+	// Default has Pacing=false
+	engine := newTestEngine(t, s)
+	engine.Reload()
+
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	engine.Check(QuotaStatus{
+		Provider:    "codex",
+		QuotaKey:    "seven_day",
+		Utilization: 60,
+		Limit:       100,
+		Pacing:      &tracker.Pacing{ExpectedUtil: 20, DeltaPercent: 40, Status: tracker.PaceAhead},
+	})
+
+	if mailCount.Load() != 0 {
+		t.Errorf("Expected 0 emails (pacing disabled), got %d", mailCount.Load())
+	}
+}
+
 func TestNotificationEngine_ConfigureSMTP_NoSettings(t *testing.T) {
 	t.Parallel()
 	s := newTestStore(t)
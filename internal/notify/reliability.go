@@ -0,0 +1,231 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// reliabilityCheckInterval is how often API integration error rates and
+	// latency are compared with their thresholds.
+	reliabilityCheckInterval = 5 * time.Minute
+	// reliabilityCooldown is the least time between two alerts for the same
+	// integration, provider and model, however often it recovers.
+	reliabilityCooldown = time.Hour
+	// ReliabilityAlertProvider is the provider of reliability system alerts
+	// and notifications.
+	ReliabilityAlertProvider = "api_integrations"
+	// ReliabilityAlertType is the system_alerts type raised for a degraded
+	// integration, provider and model.
+	ReliabilityAlertType = "api_integration_degraded"
+)
+
+// ReliabilityStatus is the error rate and p95 latency of the calls one
+// integration made to one provider and model over a recent window, with the
+// thresholds they are held to.
+type ReliabilityStatus struct {
+	Key          string // "integration/provider/model"
+	Integration  string
+	Provider     string
+	Model        string
+	Window       time.Duration
+	Requests     int
+	Errors       int
+	ErrorType    string // most frequent error type, if any
+	P95LatencyMS int    // of successful calls; 0 when none reported a latency
+	MaxErrorRate float64
+	MaxP95MS     int
+	MinRequests  int
+}
+
+// ErrorRate returns the share of requests that failed, in percent.
+func (r ReliabilityStatus) ErrorRate() float64 {
+	if r.Requests <= 0 {
+		return 0
+	}
+	return float64(r.Errors) / float64(r.Requests) * 100
+}
+
+// Evaluated reports whether the window had enough requests to judge.
+func (r ReliabilityStatus) Evaluated() bool {
+	return r.Requests > 0 && r.Requests >= r.MinRequests
+}
+
+// Degraded reports which thresholds the window crossed. A zero threshold is
+// disabled.
+func (r ReliabilityStatus) Degraded() (errorRate, latency bool) {
+	if !r.Evaluated() {
+		return false, false
+	}
+	errorRate = r.MaxErrorRate > 0 && r.ErrorRate() >= r.MaxErrorRate
+	latency = r.MaxP95MS > 0 && r.P95LatencyMS >= r.MaxP95MS
+	return errorRate, latency
+}
+
+// summary describes the crossed thresholds, e.g. "12% errors, p95 8.2s".
+func (r ReliabilityStatus) summary() string {
+	errorRate, latency := r.Degraded()
+	var parts []string
+	if errorRate {
+		parts = append(parts, fmt.Sprintf("%.0f%% errors", r.ErrorRate()))
+	}
+	if latency {
+		parts = append(parts, "p95 "+formatLatency(r.P95LatencyMS))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatLatency(ms int) string {
+	if ms < 1000 {
+		return fmt.Sprintf("%dms", ms)
+	}
+	return fmt.Sprintf("%.1fs", float64(ms)/1000)
+}
+
+// ReliabilityProvider reports the API integration reliability over the
+// window ending at now.
+type ReliabilityProvider func(now time.Time) ([]ReliabilityStatus, error)
+
+// reliabilityChecker periodically evaluates a ReliabilityProvider.
+type reliabilityChecker struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// SetReliabilityProvider sets the source of API integration reliability for
+// degradation alerts.
+func (e *NotificationEngine) SetReliabilityProvider(provider ReliabilityProvider) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.reliabilityProvider = provider
+}
+
+// StartReliabilityChecks begins checking API integration error rates and
+// latency in the background. Calling it again while running is a no-op.
+func (e *NotificationEngine) StartReliabilityChecks() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.reliability != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	checker := &reliabilityChecker{cancel: cancel, done: make(chan struct{})}
+	e.reliability = checker
+	go func() {
+		defer close(checker.done)
+		ticker := time.NewTicker(reliabilityCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.checkReliability(time.Now().UTC())
+			}
+		}
+	}()
+}
+
+// stopReliabilityChecks halts the reliability checker and waits for it to
+// exit.
+func (e *NotificationEngine) stopReliabilityChecks() {
+	e.mu.Lock()
+	checker := e.reliability
+	e.reliability = nil
+	e.mu.Unlock()
+	if checker == nil {
+		return
+	}
+	checker.cancel()
+	<-checker.done
+}
+
+// checkReliability raises a system alert, and a warning notification, when
+// an integration, provider and model crosses an error rate or p95 latency
+// threshold. It alerts once per degradation: the episode ends when a window
+// with enough requests is back under the thresholds, and at least
+// reliabilityCooldown after the alert.
+func (e *NotificationEngine) checkReliability(now time.Time) {
+	e.mu.RLock()
+	cfg := e.cfg
+	provider := e.reliabilityProvider
+	mailer, pushSender := e.mailer, e.pushSender
+	e.mu.RUnlock()
+
+	if provider == nil {
+		return
+	}
+	statuses, err := provider(now)
+	if err != nil {
+		e.logger.Error("failed to evaluate API integration reliability", "error", err)
+		return
+	}
+	for _, r := range statuses {
+		r := r
+		alertedAt, _, err := e.store.GetLastNotification(ReliabilityAlertProvider, r.Key, ReliabilityAlertType)
+		if err != nil {
+			e.logger.Error("failed to check notification log", "error", err)
+			return
+		}
+		errorRate, latency := r.Degraded()
+		if !errorRate && !latency {
+			if r.Evaluated() && !alertedAt.IsZero() && now.Sub(alertedAt) >= reliabilityCooldown {
+				if err := e.store.ClearNotificationLog(ReliabilityAlertProvider, r.Key); err != nil {
+					e.logger.Error("failed to clear notification log", "error", err)
+				}
+			}
+			continue
+		}
+		if !alertedAt.IsZero() {
+			continue
+		}
+
+		e.raiseReliabilityAlert(r)
+		if err := e.store.UpsertNotificationLog(ReliabilityAlertProvider, r.Key, ReliabilityAlertType, r.ErrorRate()); err != nil {
+			e.logger.Error("failed to log reliability alert", "error", err)
+		}
+		status := QuotaStatus{
+			Provider:    ReliabilityAlertProvider,
+			QuotaKey:    r.Key,
+			Utilization: r.ErrorRate(),
+			Reliability: &r,
+		}
+		if !cfg.Types.Warning {
+			continue
+		}
+		e.sendNotification(mailer, pushSender, cfg.Channels, status, "degraded")
+	}
+}
+
+// raiseReliabilityAlert records an in-dashboard system alert for a degraded
+// integration, provider and model.
+func (e *NotificationEngine) raiseReliabilityAlert(r ReliabilityStatus) {
+	title := fmt.Sprintf("%s calls to %s %s are degraded: %s", r.Integration, r.Provider, r.Model, r.summary())
+	message := fmt.Sprintf("%d of %d calls failed (%.1f%%) in the last %s", r.Errors, r.Requests, r.ErrorRate(), formatSnooze(r.Window))
+	if r.ErrorType != "" {
+		message += ", mostly " + r.ErrorType
+	}
+	if r.P95LatencyMS > 0 {
+		message += fmt.Sprintf("; p95 latency of successful calls was %s", formatLatency(r.P95LatencyMS))
+	}
+	message += "."
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"integration":    r.Integration,
+		"provider":       r.Provider,
+		"model":          r.Model,
+		"requests":       r.Requests,
+		"errors":         r.Errors,
+		"error_rate":     r.ErrorRate(),
+		"error_type":     r.ErrorType,
+		"p95_latency_ms": r.P95LatencyMS,
+		"window_seconds": int(r.Window / time.Second),
+	})
+	if _, err := e.store.CreateSystemAlert(ReliabilityAlertProvider, ReliabilityAlertType, title, message, "warning", string(metadata)); err != nil {
+		e.logger.Error("failed to create reliability system alert", "error", err)
+	}
+	e.logger.Warn("API integration degraded", "integration", r.Integration, "provider", r.Provider, "model", r.Model,
+		"error_rate", r.ErrorRate(), "p95_latency_ms", r.P95LatencyMS)
+}
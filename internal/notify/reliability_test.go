@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckReliability_AlertsOncePerDegradation(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	defer s.Close()
+
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyWarning:     true,
+		NotifyCritical:    true,
+	})
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	status := ReliabilityStatus{
+		Key: "notes/openai/gpt-4.1", Integration: "notes", Provider: "openai", Model: "gpt-4.1",
+		Window: 15 * time.Minute, Requests: 50, Errors: 10, ErrorType: "rate_limit", P95LatencyMS: 8200,
+		MaxErrorRate: 10, MaxP95MS: 10000, MinRequests: 20,
+	}
+	quiet := ReliabilityStatus{Key: "notes/openai/o3", Integration: "notes", Provider: "openai", Model: "o3",
+		Window: 15 * time.Minute, Requests: 5, Errors: 5, MaxErrorRate: 10, MinRequests: 20}
+	engine.SetReliabilityProvider(func(time.Time) ([]ReliabilityStatus, error) {
+		return []ReliabilityStatus{status, quiet}, nil
+	})
+
+	now := time.Now()
+	engine.checkReliability(now)
+	engine.checkReliability(now.Add(5 * time.Minute))
+	if mailCount.Load() != 1 {
+		t.Fatalf("expected 1 degradation email, got %d", mailCount.Load())
+	}
+	alerts, err := s.GetActiveSystemAlertsByProvider(ReliabilityAlertProvider, 10)
+	if err != nil {
+		t.Fatalf("GetActiveSystemAlertsByProvider: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].AlertType != ReliabilityAlertType || !strings.Contains(alerts[0].Title, "20% errors") {
+		t.Fatalf("alerts=%+v", alerts)
+	}
+
+	// Recovering within the cooldown does not start a new episode.
+	status.Errors = 0
+	engine.checkReliability(now.Add(10 * time.Minute))
+	status.Errors = 10
+	engine.checkReliability(now.Add(15 * time.Minute))
+	if mailCount.Load() != 1 {
+		t.Fatalf("expected no alert within the cooldown, got %d emails", mailCount.Load())
+	}
+
+	// Recovering after it does.
+	status.Errors = 0
+	engine.checkReliability(now.Add(2 * time.Hour))
+	status.P95LatencyMS = 12000
+	engine.checkReliability(now.Add(2*time.Hour + 5*time.Minute))
+	if mailCount.Load() != 2 {
+		t.Fatalf("expected a latency alert after recovery, got %d emails", mailCount.Load())
+	}
+
+	qs := QuotaStatus{Provider: ReliabilityAlertProvider, QuotaKey: status.Key, Reliability: &status}
+	if subject := engine.buildSubject(qs, "degraded"); subject != "[DEGRADED] notes openai/gpt-4.1: p95 12.0s" {
+		t.Errorf("subject = %q", subject)
+	}
+	if body := engine.buildBody(qs, "degraded"); !strings.Contains(body, "Errors: 0 of 50 calls (0.0%) in the last 15m") {
+		t.Errorf("body:\n%s", body)
+	}
+}
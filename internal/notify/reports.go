@@ -0,0 +1,292 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/report"
+)
+
+// Settings keys for scheduled reports. The handler owns "reports"; the
+// scheduler records delivery progress under "reports_state".
+const (
+	reportsSettingKey      = "reports"
+	reportsStateSettingKey = "reports_state"
+)
+
+// reportCheckInterval is how often the scheduler looks for due reports.
+const reportCheckInterval = 5 * time.Minute
+
+// ReportSchedule configures one recurring usage report email.
+type ReportSchedule struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Enabled    bool   `json:"enabled"`
+	Period     string `json:"period"`       // report.PeriodWeekly or report.PeriodMonthly
+	Weekday    int    `json:"weekday"`      // 0 = Sunday; weekly reports only
+	DayOfMonth int    `json:"day_of_month"` // 1-28; monthly reports only
+	Hour       int    `json:"hour"`         // local hour of day, 0-23
+	Recipients string `json:"recipients"`   // comma-separated addresses
+}
+
+// ReportSettings is the JSON shape stored under the "reports" setting key.
+type ReportSettings struct {
+	Schedules []ReportSchedule `json:"schedules"`
+}
+
+// Validate checks the schedule fields and recipient addresses.
+func (s ReportSchedule) Validate() error {
+	if strings.TrimSpace(s.ID) == "" {
+		return errors.New("report schedule id is required")
+	}
+	switch s.Period {
+	case report.PeriodWeekly:
+		if s.Weekday < 0 || s.Weekday > 6 {
+			return errors.New("weekday must be between 0 (Sunday) and 6 (Saturday)")
+		}
+	case report.PeriodMonthly:
+		if s.DayOfMonth < 1 || s.DayOfMonth > 28 {
+			return errors.New("day of month must be between 1 and 28")
+		}
+	default:
+		return fmt.Errorf("period must be %q or %q", report.PeriodWeekly, report.PeriodMonthly)
+	}
+	if s.Hour < 0 || s.Hour > 23 {
+		return errors.New("hour must be between 0 and 23")
+	}
+	if _, err := ParseReportRecipients(s.Recipients); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ParseReportRecipients splits a comma-separated recipient list and validates
+// each address. An empty list is allowed and means the SMTP recipients.
+func ParseReportRecipients(raw string) ([]string, error) {
+	var addrs []string
+	for _, addr := range strings.Split(raw, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		parsed, err := mail.ParseAddress(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient %q", addr)
+		}
+		addrs = append(addrs, parsed.Address)
+	}
+	return addrs, nil
+}
+
+// lastDue returns the most recent scheduled send time at or before now.
+func (s ReportSchedule) lastDue(now time.Time) time.Time {
+	loc := now.Location()
+	switch s.Period {
+	case report.PeriodMonthly:
+		due := time.Date(now.Year(), now.Month(), s.DayOfMonth, s.Hour, 0, 0, 0, loc)
+		if due.After(now) {
+			due = due.AddDate(0, -1, 0)
+		}
+		return due
+	default:
+		due := time.Date(now.Year(), now.Month(), now.Day(), s.Hour, 0, 0, 0, loc)
+		due = due.AddDate(0, 0, -((int(due.Weekday()) - s.Weekday + 7) % 7))
+		if due.After(now) {
+			due = due.AddDate(0, 0, -7)
+		}
+		return due
+	}
+}
+
+// reportScheduler periodically sends due report emails.
+type reportScheduler struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// StartReports begins checking report schedules in the background.
+// Calling it again while running is a no-op.
+func (e *NotificationEngine) StartReports() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.reports != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	sched := &reportScheduler{cancel: cancel, done: make(chan struct{})}
+	e.reports = sched
+	go func() {
+		defer close(sched.done)
+		ticker := time.NewTicker(reportCheckInterval)
+		defer ticker.Stop()
+		for {
+			e.sendDueReports(time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// stopReports halts the report scheduler and waits for it to exit.
+func (e *NotificationEngine) stopReports() {
+	e.mu.Lock()
+	sched := e.reports
+	e.reports = nil
+	e.mu.Unlock()
+	if sched == nil {
+		return
+	}
+	sched.cancel()
+	<-sched.done
+}
+
+// sendDueReports emails every enabled schedule whose send time has passed
+// since it was last delivered. A schedule seen for the first time starts
+// from now rather than back-filling past periods.
+func (e *NotificationEngine) sendDueReports(now time.Time) {
+	e.reportMu.Lock()
+	defer e.reportMu.Unlock()
+
+	settings, err := e.loadReportSettings()
+	if err != nil {
+		e.logger.Error("failed to load report schedules", "error", err)
+		return
+	}
+	state := e.loadReportState()
+	next := make(map[string]time.Time, len(settings.Schedules))
+	changed := false
+
+	for _, sched := range settings.Schedules {
+		last, seen := state[sched.ID]
+		if !sched.Enabled {
+			if seen {
+				next[sched.ID] = last
+			}
+			continue
+		}
+		due := sched.lastDue(now)
+		switch {
+		case !seen:
+			last = now
+			changed = true
+		case last.Before(due):
+			if err := e.sendReport(sched, due); err != nil {
+				e.logger.Error("failed to send scheduled report", "error", err, "schedule", sched.ID)
+			} else {
+				last = now
+				changed = true
+			}
+		}
+		next[sched.ID] = last
+	}
+
+	// Drop state for schedules that were removed.
+	if changed || len(next) != len(state) {
+		e.saveReportState(next)
+	}
+}
+
+// SendReportNow emails the report for the schedule with the given ID,
+// covering the period that ends now.
+func (e *NotificationEngine) SendReportNow(scheduleID string) error {
+	settings, err := e.loadReportSettings()
+	if err != nil {
+		return err
+	}
+	for _, sched := range settings.Schedules {
+		if sched.ID == scheduleID {
+			e.reportMu.Lock()
+			defer e.reportMu.Unlock()
+			return e.sendReport(sched, time.Now())
+		}
+	}
+	return fmt.Errorf("report schedule %q not found", scheduleID)
+}
+
+// sendReport builds the report for the period ending at end and emails it.
+func (e *NotificationEngine) sendReport(sched ReportSchedule, end time.Time) error {
+	e.mu.RLock()
+	mailer := e.mailer
+	e.mu.RUnlock()
+	if mailer == nil {
+		return fmt.Errorf("SMTP not configured")
+	}
+
+	start, err := report.PeriodStart(sched.Period, end)
+	if err != nil {
+		return err
+	}
+	r, err := report.Build(e.store, sched.Period, start, end)
+	if err != nil {
+		return err
+	}
+	var html bytes.Buffer
+	if err := r.RenderHTML(&html); err != nil {
+		return fmt.Errorf("render report: %w", err)
+	}
+	to, err := ParseReportRecipients(sched.Recipients)
+	if err != nil {
+		return err
+	}
+	return mailer.sendEmail(emailMessage{
+		To:      to,
+		Subject: r.Subject(),
+		Text:    r.Text(),
+		HTML:    html.String(),
+	})
+}
+
+func (e *NotificationEngine) loadReportSettings() (ReportSettings, error) {
+	var settings ReportSettings
+	raw, err := e.store.GetSetting(reportsSettingKey)
+	if err != nil {
+		return settings, fmt.Errorf("notify.loadReportSettings: %w", err)
+	}
+	if raw == "" {
+		return settings, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &settings); err != nil {
+		return settings, fmt.Errorf("notify.loadReportSettings: invalid reports JSON: %w", err)
+	}
+	return settings, nil
+}
+
+// loadReportState returns when each schedule was last delivered.
+func (e *NotificationEngine) loadReportState() map[string]time.Time {
+	state := make(map[string]time.Time)
+	raw, err := e.store.GetSetting(reportsStateSettingKey)
+	if err != nil || raw == "" {
+		return state
+	}
+	var stored map[string]string
+	if err := json.Unmarshal([]byte(raw), &stored); err != nil {
+		e.logger.Warn("ignoring invalid report state", "error", err)
+		return state
+	}
+	for id, at := range stored {
+		if t, err := time.Parse(time.RFC3339, at); err == nil {
+			state[id] = t
+		}
+	}
+	return state
+}
+
+func (e *NotificationEngine) saveReportState(state map[string]time.Time) {
+	stored := make(map[string]string, len(state))
+	for id, at := range state {
+		stored[id] = at.UTC().Format(time.RFC3339)
+	}
+	raw, _ := json.Marshal(stored)
+	if err := e.store.SetSetting(reportsStateSettingKey, string(raw)); err != nil {
+		e.logger.Error("failed to save report state", "error", err)
+	}
+}
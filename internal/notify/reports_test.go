@@ -0,0 +1,219 @@
+package notify
+
+import (
+	"encoding/json"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+func storeReportSchedules(t *testing.T, s *store.Store, schedules ...ReportSchedule) {
+	t.Helper()
+	raw, _ := json.Marshal(ReportSettings{Schedules: schedules})
+	if err := s.SetSetting(reportsSettingKey, string(raw)); err != nil {
+		t.Fatalf("SetSetting: %v", err)
+	}
+}
+
+// setupCapturingMailer configures the engine with a mock SMTP server and
+// returns the captured messages.
+func setupCapturingMailer(t *testing.T, s *store.Store, engine *NotificationEngine) func() []string {
+	t.Helper()
+	var mu sync.Mutex
+	var messages []string
+	addr, ln := mockSMTPServer(t, func(conn net.Conn) {
+		capturingSMTPHandler(conn, &mu, &messages)
+	})
+	t.Cleanup(func() { ln.Close() })
+	host, port := splitHostPort(t, addr)
+	storeSMTPConfig(t, s, host, port)
+	if err := engine.ConfigureSMTP(); err != nil {
+		t.Fatalf("ConfigureSMTP: %v", err)
+	}
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), messages...)
+	}
+}
+
+func TestReportSchedule_Validate(t *testing.T) {
+	t.Parallel()
+	valid := ReportSchedule{ID: "a", Period: "weekly", Weekday: 1, Hour: 8, Recipients: "ops@example.com, Team <team@example.com>"}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid schedule rejected: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*ReportSchedule)
+	}{
+		{"missing id", func(s *ReportSchedule) { s.ID = " " }},
+		{"unknown period", func(s *ReportSchedule) { s.Period = "daily" }},
+		{"weekday out of range", func(s *ReportSchedule) { s.Weekday = 7 }},
+		{"day of month out of range", func(s *ReportSchedule) { s.Period = "monthly"; s.DayOfMonth = 31 }},
+		{"hour out of range", func(s *ReportSchedule) { s.Hour = 24 }},
+		{"bad recipient", func(s *ReportSchedule) { s.Recipients = "ops@example.com, not-an-address" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sched := valid
+			tt.mutate(&sched)
+			if err := sched.Validate(); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestParseReportRecipients(t *testing.T) {
+	t.Parallel()
+	got, err := ParseReportRecipients(" ops@example.com ,, Team <team@example.com>")
+	if err != nil {
+		t.Fatalf("ParseReportRecipients: %v", err)
+	}
+	if strings.Join(got, ",") != "ops@example.com,team@example.com" {
+		t.Errorf("recipients = %v", got)
+	}
+	if got, err := ParseReportRecipients(""); err != nil || len(got) != 0 {
+		t.Errorf("empty recipients = %v, %v; want none", got, err)
+	}
+}
+
+func TestReportSchedule_LastDue(t *testing.T) {
+	t.Parallel()
+	// Wednesday, April 15 2026 10:30 UTC
+	now := time.Date(2026, 4, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		sched ReportSchedule
+		want  time.Time
+	}{
+		{"weekly earlier this week", ReportSchedule{Period: "weekly", Weekday: 1, Hour: 8}, time.Date(2026, 4, 13, 8, 0, 0, 0, time.UTC)},
+		{"weekly earlier today", ReportSchedule{Period: "weekly", Weekday: 3, Hour: 9}, time.Date(2026, 4, 15, 9, 0, 0, 0, time.UTC)},
+		{"weekly later today", ReportSchedule{Period: "weekly", Weekday: 3, Hour: 11}, time.Date(2026, 4, 8, 11, 0, 0, 0, time.UTC)},
+		{"weekly later this week", ReportSchedule{Period: "weekly", Weekday: 5, Hour: 8}, time.Date(2026, 4, 10, 8, 0, 0, 0, time.UTC)},
+		{"monthly this month", ReportSchedule{Period: "monthly", DayOfMonth: 1, Hour: 8}, time.Date(2026, 4, 1, 8, 0, 0, 0, time.UTC)},
+		{"monthly previous month", ReportSchedule{Period: "monthly", DayOfMonth: 20, Hour: 8}, time.Date(2026, 3, 20, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sched.lastDue(now); !got.Equal(tt.want) {
+				t.Errorf("lastDue = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendDueReports(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	defer s.Close()
+	engine := newTestEngine(t, s)
+	messages := setupCapturingMailer(t, s, engine)
+
+	storeReportSchedules(t, s,
+		ReportSchedule{ID: "weekly", Enabled: true, Period: "weekly", Weekday: 1, Hour: 8, Recipients: "team@example.com, ops@example.com"},
+		ReportSchedule{ID: "off", Enabled: false, Period: "monthly", DayOfMonth: 1, Hour: 8},
+	)
+
+	// First sight of a schedule only records the starting point.
+	monday := time.Date(2026, 4, 13, 9, 0, 0, 0, time.UTC)
+	engine.sendDueReports(monday)
+	if got := len(messages()); got != 0 {
+		t.Fatalf("expected no back-filled report, got %d", got)
+	}
+
+	// Still within the same period: nothing due.
+	engine.sendDueReports(monday.Add(48 * time.Hour))
+	if got := len(messages()); got != 0 {
+		t.Fatalf("expected no report before next Monday, got %d", got)
+	}
+
+	// The next Monday 08:00 has passed.
+	engine.sendDueReports(monday.Add(7 * 24 * time.Hour))
+	sent := messages()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 report, got %d", len(sent))
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(sent[0]))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	if to := msg.Header.Get("To"); to != "team@example.com, ops@example.com" {
+		t.Errorf("To = %q, want schedule recipients", to)
+	}
+	if subject := msg.Header.Get("Subject"); !strings.Contains(subject, "Weekly usage report") {
+		t.Errorf("Subject = %q", subject)
+	}
+	_, _, bodies := mimeParts(t, sent[0])
+	if !strings.Contains(string(bodies["text/html"]), "Quota cycles") {
+		t.Error("HTML part missing report body")
+	}
+
+	// Already delivered for this period.
+	engine.sendDueReports(monday.Add(7*24*time.Hour + time.Hour))
+	if got := len(messages()); got != 1 {
+		t.Errorf("expected report to be sent once, got %d", got)
+	}
+
+	state := engine.loadReportState()
+	if _, ok := state["off"]; ok {
+		t.Error("disabled schedule should not be tracked")
+	}
+}
+
+func TestSendDueReports_PrunesRemovedSchedules(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	defer s.Close()
+	engine := newTestEngine(t, s)
+
+	engine.saveReportState(map[string]time.Time{"gone": time.Now()})
+	storeReportSchedules(t, s)
+	engine.sendDueReports(time.Now())
+
+	if state := engine.loadReportState(); len(state) != 0 {
+		t.Errorf("state = %v, want removed schedules pruned", state)
+	}
+}
+
+func TestSendReportNow(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	defer s.Close()
+	engine := newTestEngine(t, s)
+
+	storeReportSchedules(t, s, ReportSchedule{ID: "monthly", Period: "monthly", DayOfMonth: 1, Hour: 8})
+	if err := engine.SendReportNow("monthly"); err == nil || !strings.Contains(err.Error(), "SMTP not configured") {
+		t.Fatalf("expected SMTP error without mailer, got %v", err)
+	}
+
+	messages := setupCapturingMailer(t, s, engine)
+	if err := engine.SendReportNow("missing"); err == nil {
+		t.Error("expected error for unknown schedule")
+	}
+	if err := engine.SendReportNow("monthly"); err != nil {
+		t.Fatalf("SendReportNow: %v", err)
+	}
+	sent := messages()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 report, got %d", len(sent))
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(sent[0]))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	if to := msg.Header.Get("To"); to != "admin@example.com" {
+		t.Errorf("To = %q, want SMTP recipients when the schedule has none", to)
+	}
+	if subject := msg.Header.Get("Subject"); !strings.Contains(subject, "Monthly usage report") {
+		t.Errorf("Subject = %q", subject)
+	}
+}
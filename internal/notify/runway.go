@@ -0,0 +1,45 @@
+package notify
+
+import "github.com/onllm-dev/onwatch/v2/internal/tracker"
+
+// CheckRunway sends a "runway_low" notification when a prepaid balance is
+// projected to reach its floor within the configured number of days. Like
+// threshold alerts it fires once per cycle. Balance agents never report a
+// quota reset, so the cycle ends here: a top-up in the latest sample, or the
+// runway recovering above the threshold, clears the notification log.
+func (e *NotificationEngine) CheckRunway(provider, quotaKey string, runway *tracker.Runway) {
+	e.mu.RLock()
+	cfg := e.cfg
+	mailer, pushSender, desktop, telegram := e.mailer, e.pushSender, e.desktop, e.telegram
+	e.mu.RUnlock()
+
+	if runway != nil && (runway.ToppedUpLast() || !runway.Low(cfg.RunwayDays)) {
+		e.clearRunwayLog(provider, quotaKey)
+	}
+	if !cfg.Types.Runway || !runway.Low(cfg.RunwayDays) {
+		return
+	}
+	if mailer == nil && pushSender == nil && desktop == nil && telegram == nil {
+		return
+	}
+	status := QuotaStatus{Provider: provider, QuotaKey: quotaKey, Runway: runway}
+	e.sendNotification(mailer, pushSender, cfg.Channels, status, "runway_low")
+}
+
+// clearRunwayLog ends the current runway_low cycle so the next low balance
+// alerts again.
+func (e *NotificationEngine) clearRunwayLog(provider, quotaKey string) {
+	status := QuotaStatus{Provider: provider, QuotaKey: quotaKey}
+	provider, quotaKey = normalizeNotificationProvider(status.Provider), notificationQuotaKey(status)
+	sentAt, _, err := e.store.GetLastNotification(provider, quotaKey, "runway_low")
+	if err != nil {
+		e.logger.Error("failed to check notification log", "error", err)
+		return
+	}
+	if sentAt.IsZero() {
+		return
+	}
+	if err := e.store.ClearNotificationLog(provider, quotaKey); err != nil {
+		e.logger.Error("failed to clear runway notification log", "error", err)
+	}
+}
@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/tracker"
+)
+
+func TestCheckRunway_SendsOncePerCycle(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	defer s.Close()
+
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyReset:       false,
+		NotifyRunway:      true,
+		RunwayDays:        5,
+	})
+	engine := newTestEngine(t, s)
+	engine.Reload()
+	mailCount, cleanup := setupSMTPAndMailer(t, s, engine)
+	defer cleanup()
+
+	depletes := time.Now().Add(4 * 24 * time.Hour)
+	low := &tracker.Runway{Balance: 40, DailySpend: 10, Span: 7 * 24 * time.Hour, DaysLeft: 4, DepletesAt: &depletes}
+	healthy := &tracker.Runway{Balance: 60, DailySpend: 10, Span: 7 * 24 * time.Hour, DaysLeft: 6, DepletesAt: &depletes}
+
+	engine.CheckRunway("deepseek", "balance", healthy)
+	engine.CheckRunway("deepseek", "balance", nil)
+	if mailCount.Load() != 0 {
+		t.Fatalf("expected no email above the 5 day threshold, got %d", mailCount.Load())
+	}
+	engine.CheckRunway("deepseek", "balance", low)
+	engine.CheckRunway("deepseek", "balance", low)
+	if mailCount.Load() != 1 {
+		t.Fatalf("expected 1 runway email, got %d", mailCount.Load())
+	}
+
+	// Recovering above the threshold ends the cycle so the next low balance
+	// alerts again.
+	engine.CheckRunway("deepseek", "balance", healthy)
+	if sentAt, _, _ := s.GetLastNotification("deepseek", "balance", "runway_low"); !sentAt.IsZero() {
+		t.Error("expected the runway_low log to be cleared once the runway recovers")
+	}
+	engine.CheckRunway("deepseek", "balance", low)
+	if mailCount.Load() != 2 {
+		t.Fatalf("expected a new runway email after recovering, got %d emails", mailCount.Load())
+	}
+
+	// So does a top-up, even one too small to lift the runway above it.
+	toppedUp := *low
+	toppedUp.LastChange = 5
+	engine.CheckRunway("deepseek", "balance", &toppedUp)
+	if mailCount.Load() != 3 {
+		t.Errorf("expected a new runway email after a top-up, got %d emails", mailCount.Load())
+	}
+
+	qs := QuotaStatus{Provider: "deepseek", QuotaKey: "balance", Runway: low}
+	if subject := engine.buildSubject(qs, "runway_low"); subject != "[RUNWAY] Deepseek balance runs out in 4.0 days" {
+		t.Errorf("subject = %q", subject)
+	}
+	if body := engine.buildBody(qs, "runway_low"); !strings.Contains(body, "Spend rate: 10.00/day over the last 7.0 days") {
+		t.Errorf("body missing spend rate:\n%s", body)
+	}
+}
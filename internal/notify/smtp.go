@@ -1,6 +1,7 @@
 package notify
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -13,20 +14,24 @@ import (
 
 // SMTPConfig holds SMTP connection settings.
 type SMTPConfig struct {
-	Host     string   // SMTP server hostname
-	Port     int      // SMTP server port (25, 465, 587)
-	Username string   // SMTP auth username
-	Password string   // SMTP auth password (plaintext or decrypted)
-	Protocol string   // "auto", "tls" (implicit TLS), "starttls" (explicit upgrade), "none" (plaintext)
-	FromAddr string   // Sender email address
-	FromName string   // Sender display name
-	ToAddrs  []string // Recipient email addresses
+	Host       string           // SMTP server hostname
+	Port       int              // SMTP server port (25, 465, 587)
+	Username   string           // SMTP auth username
+	Password   string           // SMTP auth password (plaintext or decrypted)
+	AuthMethod string           // SMTPAuthPassword (default) or SMTPAuthOAuth2
+	OAuth2     SMTPOAuth2Config // used when AuthMethod is SMTPAuthOAuth2
+	Protocol   string           // "auto", "tls" (implicit TLS), "starttls" (explicit upgrade), "none" (plaintext)
+	FromAddr   string           // Sender email address
+	FromName   string           // Sender display name
+	ToAddrs    []string         // Recipient email addresses
 }
 
 // SMTPMailer sends email notifications via SMTP.
 type SMTPMailer struct {
 	config SMTPConfig
 	logger *slog.Logger
+	// oauth caches XOAUTH2 access tokens; nil for password auth.
+	oauth *oauth2TokenSource
 	// tlsConfig is a test hook for injecting trusted roots.
 	tlsConfig *tls.Config
 }
@@ -36,12 +41,42 @@ func NewSMTPMailer(cfg SMTPConfig, logger *slog.Logger) *SMTPMailer {
 	if normalizedSMTPProtocol(cfg.Protocol) == "none" && logger != nil {
 		logger.Warn("SMTP using unencrypted connection. If authentication is enabled, credentials may be sent in plaintext.")
 	}
-	return &SMTPMailer{config: cfg, logger: logger}
+	m := &SMTPMailer{config: cfg, logger: logger}
+	if cfg.AuthMethod == SMTPAuthOAuth2 {
+		m.oauth = newOAuth2TokenSource(cfg.OAuth2)
+	}
+	return m
 }
 
 // Send sends an email with the given subject and plaintext body.
 func (m *SMTPMailer) Send(subject, body string) error {
-	msg := m.buildMessage(subject, body)
+	return m.deliver(subject, m.buildMessage(subject, body), m.config.ToAddrs)
+}
+
+// sendEmail sends a multipart email with an HTML part, falling back to a
+// plaintext-only message when the email has no HTML. The email's recipients
+// override the configured ones when set.
+func (m *SMTPMailer) sendEmail(email emailMessage) error {
+	to := email.To
+	if len(to) == 0 {
+		to = m.config.ToAddrs
+	}
+	if email.HTML == "" {
+		return m.deliver(email.Subject, m.buildMessageTo(to, email.Subject, email.Text), to)
+	}
+	msg, err := m.buildMultipartMessage(to, email)
+	if err != nil {
+		return fmt.Errorf("notify.Send: build message: %w", err)
+	}
+	return m.deliver(email.Subject, msg, to)
+}
+
+// deliver sends a fully built RFC 2822 message to the given recipients.
+func (m *SMTPMailer) deliver(subject, msg string, to []string) error {
+	// Refresh the OAuth2 access token before opening the SMTP session.
+	if _, err := m.accessToken(); err != nil {
+		return fmt.Errorf("notify.Send: auth: %w", err)
+	}
 
 	client, encrypted, err := m.connect()
 	if err != nil {
@@ -57,7 +92,7 @@ func (m *SMTPMailer) Send(subject, body string) error {
 		return fmt.Errorf("notify.Send: MAIL FROM: %w", err)
 	}
 
-	for _, addr := range m.config.ToAddrs {
+	for _, addr := range to {
 		if err := client.Rcpt(addr); err != nil {
 			return fmt.Errorf("notify.Send: RCPT TO %s: %w", addr, err)
 		}
@@ -77,7 +112,7 @@ func (m *SMTPMailer) Send(subject, body string) error {
 	}
 
 	client.Quit()
-	m.logger.Info("email sent", "subject", subject, "recipients", len(m.config.ToAddrs))
+	m.logger.Info("email sent", "subject", subject, "recipients", len(to))
 	return nil
 }
 
@@ -103,6 +138,10 @@ func (m *SMTPMailer) SendWithDiag(subject, body string) TestConnectionResult {
 
 	msg := m.buildMessage(subject, body)
 
+	if err := m.writeOAuthDiag(&diag); err != nil {
+		return TestConnectionResult{Diagnostics: diag.String(), Error: fmt.Errorf("notify.Send: auth: %w", err)}
+	}
+
 	client, encrypted, err := m.connect()
 	if err != nil {
 		diag.WriteString(fmt.Sprintf("Connection: FAILED - %v\n", err))
@@ -167,6 +206,10 @@ func (m *SMTPMailer) TestConnectionDiag() TestConnectionResult {
 	diag.WriteString(fmt.Sprintf("Host: %s:%d\n", m.config.Host, m.config.Port))
 	diag.WriteString(fmt.Sprintf("Protocol: %s\n", protocol))
 
+	if err := m.writeOAuthDiag(&diag); err != nil {
+		return TestConnectionResult{Diagnostics: diag.String(), Error: fmt.Errorf("notify.TestConnection: auth: %w", err)}
+	}
+
 	client, encrypted, err := m.connect()
 	if err != nil {
 		diag.WriteString(fmt.Sprintf("Connection: FAILED - %v\n", err))
@@ -370,7 +413,32 @@ func (m *SMTPMailer) clientTLSConfig() *tls.Config {
 	return &tls.Config{ServerName: m.config.Host}
 }
 
-// authenticate performs SMTP AUTH PLAIN.
+// accessToken returns a current XOAUTH2 access token, refreshing it if needed.
+// Returns "" for password auth.
+func (m *SMTPMailer) accessToken() (string, error) {
+	if m.oauth == nil {
+		return "", nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return m.oauth.Token(ctx)
+}
+
+// writeOAuthDiag refreshes the OAuth2 access token and records the outcome,
+// so token endpoint failures are reported separately from SMTP AUTH failures.
+func (m *SMTPMailer) writeOAuthDiag(diag *strings.Builder) error {
+	if m.oauth == nil {
+		return nil
+	}
+	if _, err := m.accessToken(); err != nil {
+		diag.WriteString(fmt.Sprintf("OAuth2 token: FAILED - %v\n", err))
+		return err
+	}
+	diag.WriteString(fmt.Sprintf("OAuth2 token: OK (expires in %s)\n", time.Until(m.oauth.Expiry()).Round(time.Minute)))
+	return nil
+}
+
+// authenticate performs SMTP AUTH PLAIN, or XOAUTH2 when OAuth2 is configured.
 func (m *SMTPMailer) authenticate(client *smtp.Client, encrypted bool) error {
 	if m.config.Username == "" {
 		return nil
@@ -389,6 +457,19 @@ func (m *SMTPMailer) authenticate(client *smtp.Client, encrypted bool) error {
 		}
 	}
 
+	if m.oauth != nil {
+		token, err := m.accessToken()
+		if err != nil {
+			return err
+		}
+		return client.Auth(&xoauth2Auth{
+			username:      m.config.Username,
+			accessToken:   token,
+			host:          m.config.Host,
+			allowInsecure: !encrypted && protocol == "none",
+		})
+	}
+
 	auth := smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
 	if !encrypted && protocol == "none" {
 		auth = newPlainAuth("", m.config.Username, m.config.Password, m.config.Host, true)
@@ -436,9 +517,14 @@ func isLocalSMTPHost(name string) bool {
 
 // buildMessage constructs an RFC 2822 email message.
 func (m *SMTPMailer) buildMessage(subject, body string) string {
+	return m.buildMessageTo(m.config.ToAddrs, subject, body)
+}
+
+// buildMessageTo constructs an RFC 2822 email message for the given recipients.
+func (m *SMTPMailer) buildMessageTo(to []string, subject, body string) string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("From: %s <%s>\r\n", m.config.FromName, m.config.FromAddr))
-	sb.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(m.config.ToAddrs, ", ")))
+	sb.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(to, ", ")))
 	sb.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
 	sb.WriteString("MIME-Version: 1.0\r\n")
 	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
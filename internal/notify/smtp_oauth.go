@@ -0,0 +1,248 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SMTP authentication methods.
+const (
+	SMTPAuthPassword = "password"
+	SMTPAuthOAuth2   = "oauth2"
+)
+
+// OAuth2 token endpoints and scopes for the built-in providers.
+const (
+	googleOAuth2TokenURL       = "https://oauth2.googleapis.com/token"
+	microsoftOAuth2TokenURLFmt = "https://login.microsoftonline.com/%s/oauth2/v2.0/token"
+	microsoftOAuth2SMTPScope   = "https://outlook.office.com/SMTP.Send offline_access"
+
+	// oauth2ExpiryMargin refreshes access tokens slightly before they expire
+	// so a token never lapses between refresh and AUTH.
+	oauth2ExpiryMargin = 2 * time.Minute
+)
+
+// SMTPOAuth2Config holds the client credentials and refresh token used to
+// obtain access tokens for SMTP AUTH XOAUTH2.
+type SMTPOAuth2Config struct {
+	Provider     string // "google", "microsoft", or "custom"
+	TokenURL     string // required for "custom"; overrides the provider default
+	TenantID     string // Microsoft only; defaults to "common"
+	ClientID     string
+	ClientSecret string // plaintext or decrypted
+	RefreshToken string // plaintext or decrypted
+
+	// OnRotate, if set, is called with the previous and new refresh token
+	// when the provider rotates it, so the new one can be saved.
+	OnRotate func(previous, rotated string)
+}
+
+// tokenEndpoint returns the token URL and scope to request for the provider.
+func (c SMTPOAuth2Config) tokenEndpoint() (string, string, error) {
+	switch strings.ToLower(strings.TrimSpace(c.Provider)) {
+	case "google":
+		if c.TokenURL != "" {
+			return c.TokenURL, "", nil
+		}
+		return googleOAuth2TokenURL, "", nil
+	case "microsoft":
+		tenant := strings.TrimSpace(c.TenantID)
+		if tenant == "" {
+			tenant = "common"
+		}
+		if c.TokenURL != "" {
+			return c.TokenURL, microsoftOAuth2SMTPScope, nil
+		}
+		return fmt.Sprintf(microsoftOAuth2TokenURLFmt, url.PathEscape(tenant)), microsoftOAuth2SMTPScope, nil
+	case "", "custom":
+		if c.TokenURL == "" {
+			return "", "", errors.New("token URL is required for a custom OAuth2 provider")
+		}
+		return c.TokenURL, "", nil
+	default:
+		return "", "", fmt.Errorf("unknown OAuth2 provider %q", c.Provider)
+	}
+}
+
+// oauth2TokenSource exchanges the refresh token for access tokens and caches
+// them until shortly before they expire.
+type oauth2TokenSource struct {
+	config SMTPOAuth2Config
+	client *http.Client
+	now    func() time.Time
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+func newOAuth2TokenSource(cfg SMTPOAuth2Config) *oauth2TokenSource {
+	return &oauth2TokenSource{
+		config: cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+		now:    time.Now,
+	}
+}
+
+// oauth2TokenResponse is the token endpoint response (RFC 6749 section 5).
+type oauth2TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Token returns a valid access token, refreshing it first if it is missing
+// or about to expire.
+func (s *oauth2TokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.accessToken != "" && s.now().Add(oauth2ExpiryMargin).Before(s.expiry) {
+		return s.accessToken, nil
+	}
+
+	tok, err := s.refresh(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.accessToken = tok.AccessToken
+	if tok.ExpiresIn > 0 {
+		s.expiry = s.now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	} else {
+		s.expiry = s.now().Add(time.Hour)
+	}
+	// Some providers rotate refresh tokens; keep using the newest one.
+	if tok.RefreshToken != "" && tok.RefreshToken != s.config.RefreshToken {
+		previous := s.config.RefreshToken
+		s.config.RefreshToken = tok.RefreshToken
+		if s.config.OnRotate != nil {
+			s.config.OnRotate(previous, tok.RefreshToken)
+		}
+	}
+	return s.accessToken, nil
+}
+
+// Expiry returns when the cached access token expires.
+func (s *oauth2TokenSource) Expiry() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.expiry
+}
+
+func (s *oauth2TokenSource) refresh(ctx context.Context) (*oauth2TokenResponse, error) {
+	if s.config.ClientID == "" || s.config.RefreshToken == "" {
+		return nil, errors.New("oauth2: client ID and refresh token are required")
+	}
+	tokenURL, scope, err := s.config.tokenEndpoint()
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: %w", err)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", s.config.RefreshToken)
+	form.Set("client_id", s.config.ClientID)
+	if s.config.ClientSecret != "" {
+		form.Set("client_secret", s.config.ClientSecret)
+	}
+	if scope != "" {
+		form.Set("scope", scope)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: token endpoint unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: read response: %w", err)
+	}
+
+	var tok oauth2TokenResponse
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return nil, fmt.Errorf("oauth2: token endpoint returned HTTP %d with invalid JSON", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || tok.Error != "" {
+		return nil, oauth2RefreshError(resp.StatusCode, tok.Error, tok.ErrorDescription)
+	}
+	if tok.AccessToken == "" {
+		return nil, errors.New("oauth2: token endpoint returned no access token")
+	}
+	return &tok, nil
+}
+
+// oauth2RefreshError turns a token endpoint error into an actionable message.
+func oauth2RefreshError(status int, code, description string) error {
+	msg := fmt.Sprintf("oauth2: token refresh failed (HTTP %d)", status)
+	if code != "" {
+		msg += ": " + code
+	}
+	if description != "" {
+		msg += " - " + description
+	}
+	switch code {
+	case "invalid_grant":
+		msg += " (the refresh token is expired or revoked; generate a new one)"
+	case "invalid_client", "unauthorized_client":
+		msg += " (check the OAuth2 client ID and client secret)"
+	}
+	return errors.New(msg)
+}
+
+// xoauth2Auth implements smtp.Auth for the XOAUTH2 mechanism used by
+// Gmail and Microsoft 365.
+type xoauth2Auth struct {
+	username, accessToken string
+	host                  string
+	allowInsecure         bool
+}
+
+func (a *xoauth2Auth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	if !server.TLS && !a.allowInsecure && !isLocalSMTPHost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	resp := []byte("user=" + a.username + "\x01auth=Bearer " + a.accessToken + "\x01\x01")
+	return "XOAUTH2", resp, nil
+}
+
+// Next handles the server's error challenge. On rejection the server sends a
+// JSON status before failing the exchange; surface it instead of the bare 535.
+func (a *xoauth2Auth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	var challenge struct {
+		Status string `json:"status"`
+		Scope  string `json:"scope"`
+	}
+	if json.Unmarshal(fromServer, &challenge) == nil && challenge.Status != "" {
+		if challenge.Scope != "" {
+			return nil, fmt.Errorf("XOAUTH2 rejected: status %s, required scope %s", challenge.Status, challenge.Scope)
+		}
+		return nil, fmt.Errorf("XOAUTH2 rejected: status %s", challenge.Status)
+	}
+	return nil, fmt.Errorf("XOAUTH2 rejected: %s", strings.TrimSpace(string(fromServer)))
+}
@@ -0,0 +1,478 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeTokenEndpoint issues access tokens for the test refresh token (or the
+// token it rotated to).
+type fakeTokenEndpoint struct {
+	srv       *httptest.Server
+	refreshes atomic.Int32
+	expiresIn int64
+	rotateTo  string
+
+	mu       sync.Mutex
+	lastForm url.Values
+}
+
+func startFakeTokenEndpoint(t *testing.T) *fakeTokenEndpoint {
+	t.Helper()
+	f := &fakeTokenEndpoint{expiresIn: 3600}
+	f.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		f.mu.Lock()
+		f.lastForm = r.PostForm
+		f.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.PostForm.Get("client_id") != "client-id" || r.PostForm.Get("client_secret") != "client-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, `{"error":"invalid_client","error_description":"Unauthorized client"}`)
+			return
+		}
+		refresh := r.PostForm.Get("refresh_token")
+		if r.PostForm.Get("grant_type") != "refresh_token" || (refresh != "refresh-token" && (f.rotateTo == "" || refresh != f.rotateTo)) {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"error":"invalid_grant","error_description":"Token has been expired or revoked."}`)
+			return
+		}
+		n := f.refreshes.Add(1)
+		resp := map[string]interface{}{
+			"access_token": fmt.Sprintf("access-%d", n),
+			"expires_in":   f.expiresIn,
+			"token_type":   "Bearer",
+		}
+		if f.rotateTo != "" {
+			resp["refresh_token"] = f.rotateTo
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeTokenEndpoint) LastForm() url.Values {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lastForm
+}
+
+func testOAuth2Config(tokenURL string) SMTPOAuth2Config {
+	return SMTPOAuth2Config{
+		Provider:     "custom",
+		TokenURL:     tokenURL,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RefreshToken: "refresh-token",
+	}
+}
+
+// xoauth2SMTPHandler accepts AUTH XOAUTH2 only for the expected access token
+// and records the decoded initial response.
+func xoauth2SMTPHandler(conn net.Conn, wantToken string, gotAuth *atomic.Value, mailCount *atomic.Int32) {
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "220 mock.smtp.test ESMTP\r\n")
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := scanner.Text()
+		parts := strings.Fields(line)
+		switch strings.ToUpper(parts[0]) {
+		case "EHLO", "HELO":
+			fmt.Fprintf(conn, "250-mock.smtp.test\r\n")
+			fmt.Fprintf(conn, "250 AUTH XOAUTH2\r\n")
+		case "AUTH":
+			decoded, _ := base64.StdEncoding.DecodeString(parts[len(parts)-1])
+			gotAuth.Store(string(decoded))
+			if strings.Contains(string(decoded), "auth=Bearer "+wantToken+"\x01") {
+				fmt.Fprintf(conn, "235 2.7.0 Accepted\r\n")
+				continue
+			}
+			challenge := base64.StdEncoding.EncodeToString([]byte(`{"status":"401","schemes":"bearer","scope":"https://mail.google.com/"}`))
+			fmt.Fprintf(conn, "334 %s\r\n", challenge)
+			if scanner.Scan() {
+				fmt.Fprintf(conn, "535 5.7.8 Username and Password not accepted\r\n")
+			}
+		case "MAIL", "RCPT", "RSET":
+			fmt.Fprintf(conn, "250 OK\r\n")
+		case "DATA":
+			fmt.Fprintf(conn, "354 Start mail input\r\n")
+			for scanner.Scan() {
+				if scanner.Text() == "." {
+					break
+				}
+			}
+			mailCount.Add(1)
+			fmt.Fprintf(conn, "250 OK\r\n")
+		case "QUIT":
+			fmt.Fprintf(conn, "221 Bye\r\n")
+			return
+		default:
+			fmt.Fprintf(conn, "500 Unknown command\r\n")
+		}
+	}
+}
+
+func newOAuthTestMailer(t *testing.T, addr, tokenURL string) *SMTPMailer {
+	t.Helper()
+	host, port := splitHostPort(t, addr)
+	return NewSMTPMailer(SMTPConfig{
+		Host:       host,
+		Port:       port,
+		Username:   "alerts@example.com",
+		AuthMethod: SMTPAuthOAuth2,
+		OAuth2:     testOAuth2Config(tokenURL),
+		Protocol:   "none",
+		FromAddr:   "alerts@example.com",
+		FromName:   "onWatch",
+		ToAddrs:    []string{"admin@example.com"},
+	}, slog.Default())
+}
+
+func TestOAuth2TokenSource_CachesUntilNearExpiry(t *testing.T) {
+	t.Parallel()
+	endpoint := startFakeTokenEndpoint(t)
+	src := newOAuth2TokenSource(testOAuth2Config(endpoint.srv.URL))
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	src.now = func() time.Time { return now }
+
+	tok, err := src.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if tok != "access-1" {
+		t.Fatalf("token = %q, want access-1", tok)
+	}
+
+	now = now.Add(30 * time.Minute)
+	if tok, _ := src.Token(context.Background()); tok != "access-1" {
+		t.Errorf("token refreshed too early: %q", tok)
+	}
+
+	// Within the expiry margin the token is refreshed ahead of time.
+	now = now.Add(29 * time.Minute)
+	if tok, _ := src.Token(context.Background()); tok != "access-2" {
+		t.Errorf("token = %q, want refreshed access-2", tok)
+	}
+	if got := endpoint.refreshes.Load(); got != 2 {
+		t.Errorf("refreshes = %d, want 2", got)
+	}
+}
+
+func TestOAuth2TokenSource_UsesRotatedRefreshToken(t *testing.T) {
+	t.Parallel()
+	endpoint := startFakeTokenEndpoint(t)
+	endpoint.rotateTo = "rotated-refresh-token"
+	src := newOAuth2TokenSource(testOAuth2Config(endpoint.srv.URL))
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	src.now = func() time.Time { return now }
+
+	if _, err := src.Token(context.Background()); err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	now = now.Add(2 * time.Hour)
+	if _, err := src.Token(context.Background()); err != nil {
+		t.Fatalf("second Token: %v", err)
+	}
+	if got := endpoint.LastForm().Get("refresh_token"); got != "rotated-refresh-token" {
+		t.Errorf("second refresh used %q, want the rotated refresh token", got)
+	}
+}
+
+func TestOAuth2TokenSource_Errors(t *testing.T) {
+	t.Parallel()
+	endpoint := startFakeTokenEndpoint(t)
+
+	tests := []struct {
+		name    string
+		mutate  func(*SMTPOAuth2Config)
+		wantErr string
+	}{
+		{"revoked refresh token", func(c *SMTPOAuth2Config) { c.RefreshToken = "revoked" }, "invalid_grant - Token has been expired or revoked. (the refresh token is expired or revoked; generate a new one)"},
+		{"bad client", func(c *SMTPOAuth2Config) { c.ClientSecret = "wrong" }, "invalid_client - Unauthorized client (check the OAuth2 client ID and client secret)"},
+		{"missing refresh token", func(c *SMTPOAuth2Config) { c.RefreshToken = "" }, "client ID and refresh token are required"},
+		{"custom without token URL", func(c *SMTPOAuth2Config) { c.TokenURL = "" }, "token URL is required"},
+		{"unknown provider", func(c *SMTPOAuth2Config) { c.Provider = "yahoo" }, `unknown OAuth2 provider "yahoo"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testOAuth2Config(endpoint.srv.URL)
+			tt.mutate(&cfg)
+			_, err := newOAuth2TokenSource(cfg).Token(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Token() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSMTPOAuth2Config_TokenEndpoint(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		cfg       SMTPOAuth2Config
+		wantURL   string
+		wantScope string
+	}{
+		{SMTPOAuth2Config{Provider: "google"}, googleOAuth2TokenURL, ""},
+		{SMTPOAuth2Config{Provider: "microsoft"}, "https://login.microsoftonline.com/common/oauth2/v2.0/token", microsoftOAuth2SMTPScope},
+		{SMTPOAuth2Config{Provider: "microsoft", TenantID: "contoso.onmicrosoft.com"}, "https://login.microsoftonline.com/contoso.onmicrosoft.com/oauth2/v2.0/token", microsoftOAuth2SMTPScope},
+		{SMTPOAuth2Config{Provider: "custom", TokenURL: "https://idp.example.com/token"}, "https://idp.example.com/token", ""},
+	}
+	for _, tt := range tests {
+		gotURL, gotScope, err := tt.cfg.tokenEndpoint()
+		if err != nil {
+			t.Fatalf("tokenEndpoint(%+v): %v", tt.cfg, err)
+		}
+		if gotURL != tt.wantURL || gotScope != tt.wantScope {
+			t.Errorf("tokenEndpoint(%+v) = %q, %q; want %q, %q", tt.cfg, gotURL, gotScope, tt.wantURL, tt.wantScope)
+		}
+	}
+}
+
+func TestSMTPMailer_Send_XOAUTH2(t *testing.T) {
+	t.Parallel()
+	endpoint := startFakeTokenEndpoint(t)
+
+	var gotAuth atomic.Value
+	var mailCount atomic.Int32
+	addr, ln := mockSMTPServer(t, func(conn net.Conn) {
+		xoauth2SMTPHandler(conn, "access-1", &gotAuth, &mailCount)
+	})
+	defer ln.Close()
+
+	mailer := newOAuthTestMailer(t, addr, endpoint.srv.URL)
+	if err := mailer.Send("Test", "Body"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if err := mailer.Send("Test", "Body"); err != nil {
+		t.Fatalf("second Send: %v", err)
+	}
+
+	if mailCount.Load() != 2 {
+		t.Errorf("mail count = %d, want 2", mailCount.Load())
+	}
+	if got := endpoint.refreshes.Load(); got != 1 {
+		t.Errorf("refreshes = %d, want cached token reused", got)
+	}
+	want := "user=alerts@example.com\x01auth=Bearer access-1\x01\x01"
+	if got, _ := gotAuth.Load().(string); got != want {
+		t.Errorf("XOAUTH2 initial response = %q, want %q", got, want)
+	}
+}
+
+func TestSMTPMailer_Send_XOAUTH2Rejected(t *testing.T) {
+	t.Parallel()
+	endpoint := startFakeTokenEndpoint(t)
+
+	var gotAuth atomic.Value
+	var mailCount atomic.Int32
+	addr, ln := mockSMTPServer(t, func(conn net.Conn) {
+		xoauth2SMTPHandler(conn, "some-other-token", &gotAuth, &mailCount)
+	})
+	defer ln.Close()
+
+	err := newOAuthTestMailer(t, addr, endpoint.srv.URL).Send("Test", "Body")
+	if err == nil {
+		t.Fatal("expected XOAUTH2 rejection")
+	}
+	if !strings.Contains(err.Error(), "XOAUTH2 rejected: status 401, required scope https://mail.google.com/") {
+		t.Errorf("error should describe the server challenge, got %v", err)
+	}
+}
+
+func TestSMTPMailer_TestConnectionDiag_OAuthFailure(t *testing.T) {
+	t.Parallel()
+	endpoint := startFakeTokenEndpoint(t)
+
+	var connections atomic.Int32
+	addr, ln := mockSMTPServer(t, func(conn net.Conn) {
+		connections.Add(1)
+		conn.Close()
+	})
+	defer ln.Close()
+
+	mailer := newOAuthTestMailer(t, addr, endpoint.srv.URL)
+	mailer.oauth.config.RefreshToken = "revoked"
+
+	res := mailer.TestConnectionDiag()
+	if res.Error == nil {
+		t.Fatal("expected OAuth2 error")
+	}
+	if !strings.Contains(res.Diagnostics, "OAuth2 token: FAILED - oauth2: token refresh failed (HTTP 400): invalid_grant") {
+		t.Errorf("diagnostics should report the token refresh failure, got:\n%s", res.Diagnostics)
+	}
+	if connections.Load() != 0 {
+		t.Error("SMTP connection should not be opened when the token refresh fails")
+	}
+}
+
+func TestSMTPMailer_TestConnectionDiag_OAuthSuccess(t *testing.T) {
+	t.Parallel()
+	endpoint := startFakeTokenEndpoint(t)
+
+	var gotAuth atomic.Value
+	var mailCount atomic.Int32
+	addr, ln := mockSMTPServer(t, func(conn net.Conn) {
+		xoauth2SMTPHandler(conn, "access-1", &gotAuth, &mailCount)
+	})
+	defer ln.Close()
+
+	res := newOAuthTestMailer(t, addr, endpoint.srv.URL).TestConnectionDiag()
+	if res.Error != nil {
+		t.Fatalf("TestConnectionDiag: %v\n%s", res.Error, res.Diagnostics)
+	}
+	for _, want := range []string{"OAuth2 token: OK (expires in 1h0m0s)", "Auth: OK"} {
+		if !strings.Contains(res.Diagnostics, want) {
+			t.Errorf("diagnostics missing %q:\n%s", want, res.Diagnostics)
+		}
+	}
+}
+
+func TestNotificationEngine_ConfigureSMTP_OAuth2EncryptedSecrets(t *testing.T) {
+	t.Parallel()
+	endpoint := startFakeTokenEndpoint(t)
+
+	var gotAuth atomic.Value
+	var mailCount atomic.Int32
+	addr, ln := mockSMTPServer(t, func(conn net.Conn) {
+		xoauth2SMTPHandler(conn, "access-1", &gotAuth, &mailCount)
+	})
+	defer ln.Close()
+
+	s := newTestStore(t)
+	defer s.Close()
+	key, _ := GenerateEncryptionKey()
+	secret, _ := EncryptForStorage("client-secret", key)
+	refresh, _ := EncryptForStorage("refresh-token", key)
+	host, port := splitHostPort(t, addr)
+	smtpJSON, _ := json.Marshal(smtpSettingsJSON{
+		Host:              host,
+		Port:              port,
+		Protocol:          "none",
+		Username:          "alerts@example.com",
+		FromAddress:       "alerts@example.com",
+		To:                "admin@example.com",
+		AuthMethod:        SMTPAuthOAuth2,
+		OAuthProvider:     "custom",
+		OAuthTokenURL:     endpoint.srv.URL,
+		OAuthClientID:     "client-id",
+		OAuthClientSecret: secret,
+		OAuthRefreshToken: refresh,
+	})
+	s.SetSetting("smtp", string(smtpJSON))
+
+	engine := newTestEngine(t, s)
+	if err := engine.ConfigureSMTP(); err == nil {
+		t.Fatal("expected error decrypting secrets without an encryption key")
+	}
+
+	engine.SetEncryptionKey(key)
+	if err := engine.ConfigureSMTP(); err != nil {
+		t.Fatalf("ConfigureSMTP: %v", err)
+	}
+	if err := engine.SendTestEmail(); err != nil {
+		t.Fatalf("SendTestEmail: %v", err)
+	}
+	if mailCount.Load() != 1 {
+		t.Errorf("mail count = %d, want 1", mailCount.Load())
+	}
+	if got := endpoint.LastForm().Get("client_secret"); got != "client-secret" {
+		t.Errorf("token request client_secret = %q, want decrypted value", got)
+	}
+}
+
+func TestNotificationEngine_ConfigureSMTP_SavesRotatedRefreshToken(t *testing.T) {
+	t.Parallel()
+	endpoint := startFakeTokenEndpoint(t)
+	endpoint.rotateTo = "rotated-refresh-token"
+
+	var gotAuth atomic.Value
+	var mailCount atomic.Int32
+	addr, ln := mockSMTPServer(t, func(conn net.Conn) {
+		xoauth2SMTPHandler(conn, "access-1", &gotAuth, &mailCount)
+	})
+	defer ln.Close()
+
+	s := newTestStore(t)
+	defer s.Close()
+	key, _ := GenerateEncryptionKey()
+	secret, _ := EncryptForStorage("client-secret", key)
+	refresh, _ := EncryptForStorage("refresh-token", key)
+	host, port := splitHostPort(t, addr)
+	smtpJSON, _ := json.Marshal(smtpSettingsJSON{
+		Host:              host,
+		Port:              port,
+		Protocol:          "none",
+		Username:          "alerts@example.com",
+		FromAddress:       "alerts@example.com",
+		To:                "admin@example.com",
+		AuthMethod:        SMTPAuthOAuth2,
+		OAuthProvider:     "custom",
+		OAuthTokenURL:     endpoint.srv.URL,
+		OAuthClientID:     "client-id",
+		OAuthClientSecret: secret,
+		OAuthRefreshToken: refresh,
+	})
+	s.SetSetting("smtp", string(smtpJSON))
+
+	engine := newTestEngine(t, s)
+	engine.SetEncryptionKey(key)
+	if err := engine.ConfigureSMTP(); err != nil {
+		t.Fatalf("ConfigureSMTP: %v", err)
+	}
+	if err := engine.SendTestEmail(); err != nil {
+		t.Fatalf("SendTestEmail: %v", err)
+	}
+
+	raw, _ := s.GetSetting("smtp")
+	var saved smtpSettingsJSON
+	if err := json.Unmarshal([]byte(raw), &saved); err != nil {
+		t.Fatalf("unmarshal saved settings: %v", err)
+	}
+	if !IsEncryptedValue(saved.OAuthRefreshToken) {
+		t.Fatalf("saved refresh token is not encrypted: %q", saved.OAuthRefreshToken)
+	}
+	if got, _ := DecryptFromStorage(saved.OAuthRefreshToken, key); got != "rotated-refresh-token" {
+		t.Fatalf("saved refresh token = %q, want the rotated one", got)
+	}
+	if saved.OAuthClientSecret != secret || saved.Host != host {
+		t.Errorf("other SMTP settings changed: %+v", saved)
+	}
+
+	// After a restart the rotated token is used.
+	restarted := newTestEngine(t, s)
+	restarted.SetEncryptionKey(key)
+	if err := restarted.ConfigureSMTP(); err != nil {
+		t.Fatalf("ConfigureSMTP after restart: %v", err)
+	}
+	if _, err := restarted.mailer.oauth.Token(context.Background()); err != nil {
+		t.Fatalf("Token after restart: %v", err)
+	}
+	if got := endpoint.LastForm().Get("refresh_token"); got != "rotated-refresh-token" {
+		t.Errorf("refresh after restart used %q, want the rotated refresh token", got)
+	}
+
+	// A token saved by the user in the meantime is not overwritten.
+	restarted.saveSMTPRefreshToken("refresh-token", "stale-rotation")
+	raw, _ = s.GetSetting("smtp")
+	json.Unmarshal([]byte(raw), &saved)
+	if got, _ := DecryptFromStorage(saved.OAuthRefreshToken, key); got != "rotated-refresh-token" {
+		t.Errorf("refresh token = %q after a stale rotation, want it unchanged", got)
+	}
+}
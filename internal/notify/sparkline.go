@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"time"
+)
+
+// Sparkline dimensions in pixels. The image is rendered at twice the size it
+// is displayed at in the email so it stays sharp on high-DPI screens.
+const (
+	sparklineWidth     = 560
+	sparklineHeight    = 120
+	sparklineLineWidth = 4
+)
+
+// renderSparklinePNG draws utilization points (0-100%) spanning start..end as
+// a filled line chart. It returns nil when there are fewer than two points.
+func renderSparklinePNG(points []usagePoint, start, end time.Time, stroke color.RGBA) ([]byte, error) {
+	if len(points) < 2 || !end.After(start) {
+		return nil, nil
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, sparklineWidth, sparklineHeight))
+	fill := color.RGBA{R: stroke.R, G: stroke.G, B: stroke.B, A: 0x33}
+	grid := color.RGBA{R: 0xE5, G: 0xE7, B: 0xEB, A: 0xFF}
+
+	// Guide lines at 50% and 100%.
+	for _, pct := range []float64{50, 100} {
+		y := sparklineY(pct)
+		for x := 0; x < sparklineWidth; x += 8 {
+			for dx := 0; dx < 4 && x+dx < sparklineWidth; dx++ {
+				img.SetRGBA(x+dx, y, grid)
+			}
+		}
+	}
+
+	span := end.Sub(start).Seconds()
+	xOf := func(t time.Time) float64 {
+		return t.Sub(start).Seconds() / span * float64(sparklineWidth-1)
+	}
+
+	prevY := -1
+	for x := 0; x < sparklineWidth; x++ {
+		pct, ok := sparklineValueAt(points, float64(x), xOf)
+		if !ok {
+			prevY = -1
+			continue
+		}
+		y := sparklineY(pct)
+		for fy := y; fy < sparklineHeight; fy++ {
+			img.SetRGBA(x, fy, fill)
+		}
+		// Join to the previous column so steep changes stay continuous.
+		top, bottom := y, y
+		if prevY >= 0 {
+			top, bottom = min(y, prevY), max(y, prevY)
+		}
+		for ly := top - sparklineLineWidth/2; ly <= bottom+sparklineLineWidth/2; ly++ {
+			if ly >= 0 && ly < sparklineHeight {
+				img.SetRGBA(x, ly, stroke)
+			}
+		}
+		prevY = y
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sparklineValueAt linearly interpolates the utilization at pixel column x.
+// Columns outside the sampled range report false.
+func sparklineValueAt(points []usagePoint, x float64, xOf func(time.Time) float64) (float64, bool) {
+	for i := 1; i < len(points); i++ {
+		x0, x1 := xOf(points[i-1].At), xOf(points[i].At)
+		if x < x0 || x > x1 {
+			continue
+		}
+		if x1 == x0 {
+			return points[i].Percent, true
+		}
+		frac := (x - x0) / (x1 - x0)
+		return points[i-1].Percent + frac*(points[i].Percent-points[i-1].Percent), true
+	}
+	return 0, false
+}
+
+// sparklineY maps a utilization percentage to an image row, leaving padding
+// at the top and bottom for the stroke.
+func sparklineY(pct float64) int {
+	pct = math.Max(0, math.Min(100, pct))
+	pad := float64(sparklineLineWidth)
+	usable := float64(sparklineHeight-1) - 2*pad
+	return int(math.Round(pad + usable*(1-pct/100)))
+}
@@ -0,0 +1,435 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/onllm-dev/onwatch/v2/internal/menubar"
+)
+
+// DefaultTelegramAPIURL is the public Telegram Bot API endpoint.
+const DefaultTelegramAPIURL = "https://api.telegram.org"
+
+// telegramMaxMessageLen is the Bot API limit for a single sendMessage text.
+const telegramMaxMessageLen = 4096
+
+// TelegramConfig holds Bot API credentials and the chat allowlist.
+type TelegramConfig struct {
+	BotToken string
+	APIURL   string  // Bot API base URL; defaults to DefaultTelegramAPIURL
+	ChatIDs  []int64 // alerts go to every chat; commands are only answered for these
+}
+
+// TelegramBot delivers alerts to Telegram chats and answers status commands
+// via long polling of getUpdates.
+type TelegramBot struct {
+	config      TelegramConfig
+	client      *http.Client
+	logger      *slog.Logger
+	snapshot    menubar.SnapshotProvider
+	pollTimeout time.Duration
+	retryDelay  time.Duration
+	allowed     map[int64]bool
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// telegramResponse is the common envelope of every Bot API response.
+type telegramResponse struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+type telegramUpdate struct {
+	UpdateID int64            `json:"update_id"`
+	Message  *telegramMessage `json:"message"`
+}
+
+type telegramMessage struct {
+	MessageID int64 `json:"message_id"`
+	Chat      struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+	Text string `json:"text"`
+}
+
+// NewTelegramBot creates a bot. snapshot supplies data for /status and /resets.
+func NewTelegramBot(cfg TelegramConfig, snapshot menubar.SnapshotProvider, logger *slog.Logger) *TelegramBot {
+	if cfg.APIURL == "" {
+		cfg.APIURL = DefaultTelegramAPIURL
+	}
+	cfg.APIURL = strings.TrimRight(cfg.APIURL, "/")
+
+	allowed := make(map[int64]bool, len(cfg.ChatIDs))
+	for _, id := range cfg.ChatIDs {
+		allowed[id] = true
+	}
+
+	return &TelegramBot{
+		config:      cfg,
+		client:      &http.Client{Timeout: 60 * time.Second},
+		logger:      logger,
+		snapshot:    snapshot,
+		pollTimeout: 30 * time.Second,
+		retryDelay:  5 * time.Second,
+		allowed:     allowed,
+	}
+}
+
+// Send delivers a message to every allowlisted chat.
+// Returns an error only if no chat received it.
+func (b *TelegramBot) Send(text string) error {
+	if len(b.config.ChatIDs) == 0 {
+		return fmt.Errorf("notify.TelegramBot.Send: no chat IDs configured")
+	}
+	var lastErr error
+	sent := 0
+	for _, chatID := range b.config.ChatIDs {
+		if err := b.sendMessage(context.Background(), chatID, text); err != nil {
+			lastErr = err
+			b.logger.Error("failed to send telegram message", "chat_id", chatID, "error", err)
+			continue
+		}
+		sent++
+	}
+	if sent == 0 {
+		return lastErr
+	}
+	return nil
+}
+
+// Start begins polling for commands in the background. Calling Start on a
+// running bot is a no-op.
+func (b *TelegramBot) Start() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+	b.done = make(chan struct{})
+	go b.run(ctx, b.done)
+}
+
+// Stop halts command polling and waits for the poller to exit.
+func (b *TelegramBot) Stop() {
+	b.mu.Lock()
+	cancel, done := b.cancel, b.done
+	b.cancel, b.done = nil, nil
+	b.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+func (b *TelegramBot) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	b.logger.Info("Telegram bot polling started", "chats", len(b.config.ChatIDs))
+
+	var offset int64
+	for {
+		updates, err := b.getUpdates(ctx, offset)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			b.logger.Warn("telegram getUpdates failed", "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(b.retryDelay):
+			}
+			continue
+		}
+		for _, u := range updates {
+			if u.UpdateID >= offset {
+				offset = u.UpdateID + 1
+			}
+			b.handleUpdate(ctx, u)
+		}
+	}
+}
+
+// handleUpdate answers a command from an allowlisted chat. Messages from
+// other chats are dropped without a reply so the bot does not leak that it exists.
+func (b *TelegramBot) handleUpdate(ctx context.Context, u telegramUpdate) {
+	if u.Message == nil || u.Message.Text == "" {
+		return
+	}
+	chatID := u.Message.Chat.ID
+	if !b.allowed[chatID] {
+		b.logger.Warn("ignoring telegram message from chat not in allowlist", "chat_id", chatID)
+		return
+	}
+	reply := b.commandResponse(u.Message.Text)
+	if reply == "" {
+		return
+	}
+	if err := b.sendMessage(ctx, chatID, reply); err != nil {
+		b.logger.Error("failed to reply to telegram command", "chat_id", chatID, "error", err)
+	}
+}
+
+// commandResponse returns the reply text for a bot command, or "" to stay silent.
+func (b *TelegramBot) commandResponse(text string) string {
+	fields := strings.Fields(strings.TrimSpace(text))
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return ""
+	}
+	// Strip the "@BotName" suffix Telegram adds in group chats.
+	command := strings.ToLower(fields[0])
+	if i := strings.IndexByte(command, '@'); i > 0 {
+		command = command[:i]
+	}
+	args := fields[1:]
+
+	switch command {
+	case "/start", "/help":
+		return telegramHelpText()
+	case "/status", "/resets":
+		if b.snapshot == nil {
+			return "Status is not available yet."
+		}
+		snapshot, err := b.snapshot()
+		if err != nil {
+			b.logger.Error("failed to build snapshot for telegram", "error", err)
+			return "Failed to load quota status."
+		}
+		if command == "/resets" {
+			return formatTelegramResets(snapshot, time.Now())
+		}
+		filter := ""
+		if len(args) > 0 {
+			filter = args[0]
+		}
+		return formatTelegramStatus(snapshot, filter)
+	default:
+		return "Unknown command. " + telegramHelpText()
+	}
+}
+
+func telegramHelpText() string {
+	return "onWatch commands:\n" +
+		"/status - quota usage for all providers\n" +
+		"/status <provider> - e.g. /status codex\n" +
+		"/resets - upcoming quota resets"
+}
+
+// formatTelegramStatus renders provider cards, optionally filtered by provider.
+func formatTelegramStatus(snapshot *menubar.Snapshot, filter string) string {
+	cards := filterTelegramProviders(snapshot.Providers, filter)
+	if len(cards) == 0 {
+		if filter != "" {
+			return fmt.Sprintf("No provider matching %q.", filter)
+		}
+		return "No providers are being tracked yet."
+	}
+
+	var sb strings.Builder
+	if filter == "" {
+		sb.WriteString(fmt.Sprintf("onWatch: %s", snapshot.Aggregate.Label))
+		if snapshot.UpdatedAgo != "" {
+			sb.WriteString(fmt.Sprintf(" (updated %s)", snapshot.UpdatedAgo))
+		}
+		sb.WriteString("\n")
+	}
+	for _, card := range cards {
+		sb.WriteString(fmt.Sprintf("\n%s %s - %.0f%%\n", telegramStatusIcon(card.Status), card.Label, card.HighestPercent))
+		for _, q := range card.Quotas {
+			line := fmt.Sprintf("  • %s: %s", q.Label, q.DisplayValue)
+			if q.TimeUntilReset != "" {
+				line += fmt.Sprintf(" (resets in %s)", q.TimeUntilReset)
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+	return truncateTelegram(strings.TrimSpace(sb.String()))
+}
+
+// formatTelegramResets lists upcoming quota resets, soonest first.
+func formatTelegramResets(snapshot *menubar.Snapshot, now time.Time) string {
+	type reset struct {
+		at    time.Time
+		label string
+		quota menubar.QuotaMeter
+	}
+	var resets []reset
+	for _, card := range snapshot.Providers {
+		for _, q := range card.Quotas {
+			at, err := time.Parse(time.RFC3339, q.ResetAt)
+			if err != nil || at.Before(now) {
+				continue
+			}
+			resets = append(resets, reset{at: at, label: card.Label, quota: q})
+		}
+	}
+	if len(resets) == 0 {
+		return "No upcoming resets."
+	}
+	sort.Slice(resets, func(i, j int) bool { return resets[i].at.Before(resets[j].at) })
+
+	var sb strings.Builder
+	sb.WriteString("Upcoming resets:\n")
+	for _, r := range resets {
+		sb.WriteString(fmt.Sprintf("\n%s %s %s - in %s (%.0f%% used)",
+			telegramStatusIcon(r.quota.Status), r.label, r.quota.Label,
+			formatTelegramDuration(r.at.Sub(now)), r.quota.Percent))
+	}
+	return truncateTelegram(sb.String())
+}
+
+func filterTelegramProviders(cards []menubar.ProviderCard, filter string) []menubar.ProviderCard {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+	if filter == "" {
+		return cards
+	}
+	var out []menubar.ProviderCard
+	for _, card := range cards {
+		id := strings.ToLower(card.ID)
+		if strings.EqualFold(card.BaseProvider, filter) ||
+			id == filter ||
+			strings.HasPrefix(id, filter+":") ||
+			strings.EqualFold(card.Label, filter) {
+			out = append(out, card)
+		}
+	}
+	return out
+}
+
+func telegramStatusIcon(status string) string {
+	switch status {
+	case "critical":
+		return "🔴"
+	case "danger", "warning":
+		return "🟡"
+	default:
+		return "🟢"
+	}
+}
+
+func formatTelegramDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
+
+func truncateTelegram(text string) string {
+	if len(text) <= telegramMaxMessageLen {
+		return text
+	}
+	cut := telegramMaxMessageLen - len("\n…")
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "\n…"
+}
+
+func (b *TelegramBot) getUpdates(ctx context.Context, offset int64) ([]telegramUpdate, error) {
+	payload := map[string]interface{}{
+		"offset":          offset,
+		"timeout":         int(b.pollTimeout.Seconds()),
+		"allowed_updates": []string{"message"},
+	}
+	var updates []telegramUpdate
+	if err := b.call(ctx, "getUpdates", payload, &updates); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+func (b *TelegramBot) sendMessage(ctx context.Context, chatID int64, text string) error {
+	payload := map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     truncateTelegram(text),
+		"disable_web_page_preview": true,
+	}
+	return b.call(ctx, "sendMessage", payload, nil)
+}
+
+// call invokes a Bot API method. The bot token is never included in errors.
+func (b *TelegramBot) call(ctx context.Context, method string, payload interface{}, result interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("telegram %s: %w", method, err)
+	}
+	url := b.config.APIURL + "/bot" + b.config.BotToken + "/" + method
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("telegram %s: invalid request", method)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("telegram %s: request failed: %s", method, redactToken(err.Error(), b.config.BotToken))
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("telegram %s: read response: %w", method, err)
+	}
+	var envelope telegramResponse
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("telegram %s: HTTP %d: invalid response", method, resp.StatusCode)
+	}
+	if !envelope.OK {
+		return fmt.Errorf("telegram %s: HTTP %d: %s", method, resp.StatusCode, envelope.Description)
+	}
+	if result != nil {
+		if err := json.Unmarshal(envelope.Result, result); err != nil {
+			return fmt.Errorf("telegram %s: invalid result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+func redactToken(s, token string) string {
+	if token == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, token, "<redacted>")
+}
+
+// ParseTelegramChatIDs parses a comma-separated list of numeric chat IDs.
+func ParseTelegramChatIDs(s string) ([]int64, error) {
+	var ids []int64
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chat ID %q", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,404 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/menubar"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+const testTelegramToken = "123456:TEST-token"
+
+type telegramSentMessage struct {
+	ChatID int64  `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+// fakeTelegramAPI is a minimal Bot API server. getUpdates hands out queued
+// updates once, then blocks briefly like a long poll.
+type fakeTelegramAPI struct {
+	srv *httptest.Server
+
+	mu      sync.Mutex
+	updates []telegramUpdate
+	sent    []telegramSentMessage
+	failFor map[int64]bool
+}
+
+func startFakeTelegramAPI(t *testing.T) *fakeTelegramAPI {
+	t.Helper()
+	f := &fakeTelegramAPI{failFor: make(map[int64]bool)}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeTelegramAPI) handle(w http.ResponseWriter, r *http.Request) {
+	prefix := "/bot" + testTelegramToken + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		w.WriteHeader(http.StatusUnauthorized)
+		io.WriteString(w, `{"ok":false,"description":"Unauthorized"}`)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+
+	switch strings.TrimPrefix(r.URL.Path, prefix) {
+	case "getUpdates":
+		f.mu.Lock()
+		updates := f.updates
+		f.updates = nil
+		f.mu.Unlock()
+		if len(updates) == 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(20 * time.Millisecond):
+			}
+		}
+		result, _ := json.Marshal(updates)
+		if updates == nil {
+			result = []byte("[]")
+		}
+		w.Write([]byte(`{"ok":true,"result":` + string(result) + `}`))
+	case "sendMessage":
+		var msg telegramSentMessage
+		json.Unmarshal(body, &msg)
+		f.mu.Lock()
+		fail := f.failFor[msg.ChatID]
+		if !fail {
+			f.sent = append(f.sent, msg)
+		}
+		f.mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"ok":false,"description":"Bad Request: chat not found"}`)
+			return
+		}
+		io.WriteString(w, `{"ok":true,"result":{"message_id":1}}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, `{"ok":false,"description":"Not Found"}`)
+	}
+}
+
+func (f *fakeTelegramAPI) queueMessage(updateID, chatID int64, text string) {
+	u := telegramUpdate{UpdateID: updateID, Message: &telegramMessage{MessageID: updateID, Text: text}}
+	u.Message.Chat.ID = chatID
+	f.mu.Lock()
+	f.updates = append(f.updates, u)
+	f.mu.Unlock()
+}
+
+func (f *fakeTelegramAPI) Sent() []telegramSentMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]telegramSentMessage(nil), f.sent...)
+}
+
+func testTelegramSnapshot() *menubar.Snapshot {
+	return &menubar.Snapshot{
+		UpdatedAgo: "1m ago",
+		Aggregate:  menubar.Aggregate{Label: "1 warning"},
+		Providers: []menubar.ProviderCard{
+			{
+				ID: "anthropic", BaseProvider: "anthropic", Label: "Claude", Status: "warning", HighestPercent: 82,
+				Quotas: []menubar.QuotaMeter{
+					{Label: "5-Hour", DisplayValue: "82%", Percent: 82, Status: "warning", TimeUntilReset: "2h 10m"},
+				},
+			},
+			{
+				ID: "codex:work", BaseProvider: "codex", Label: "Codex (work)", Status: "healthy", HighestPercent: 12,
+				Quotas: []menubar.QuotaMeter{
+					{Label: "Weekly", DisplayValue: "12%", Percent: 12, Status: "healthy"},
+				},
+			},
+		},
+	}
+}
+
+func TestTelegramBot_Send(t *testing.T) {
+	api := startFakeTelegramAPI(t)
+	api.failFor[222] = true
+	bot := NewTelegramBot(TelegramConfig{BotToken: testTelegramToken, APIURL: api.srv.URL + "/", ChatIDs: []int64{111, 222}}, nil, slog.Default())
+
+	if err := bot.Send("hello"); err != nil {
+		t.Fatalf("Send returned error although one chat succeeded: %v", err)
+	}
+	sent := api.Sent()
+	if len(sent) != 1 || sent[0].ChatID != 111 || sent[0].Text != "hello" {
+		t.Fatalf("unexpected sent messages: %+v", sent)
+	}
+}
+
+func TestTelegramBot_Send_AllFail(t *testing.T) {
+	api := startFakeTelegramAPI(t)
+	api.failFor[111] = true
+	bot := NewTelegramBot(TelegramConfig{BotToken: testTelegramToken, APIURL: api.srv.URL, ChatIDs: []int64{111}}, nil, slog.Default())
+
+	err := bot.Send("hello")
+	if err == nil {
+		t.Fatal("expected error when no chat received the message")
+	}
+	if !strings.Contains(err.Error(), "chat not found") {
+		t.Errorf("error should carry the API description, got %v", err)
+	}
+}
+
+func TestTelegramBot_Send_NoChats(t *testing.T) {
+	bot := NewTelegramBot(TelegramConfig{BotToken: testTelegramToken}, nil, slog.Default())
+	if err := bot.Send("hello"); err == nil {
+		t.Fatal("expected error with no chat IDs")
+	}
+}
+
+func TestTelegramBot_ErrorsRedactToken(t *testing.T) {
+	bot := NewTelegramBot(TelegramConfig{BotToken: testTelegramToken, APIURL: "http://127.0.0.1:1", ChatIDs: []int64{1}}, nil, slog.Default())
+	err := bot.Send("hello")
+	if err == nil {
+		t.Fatal("expected connection error")
+	}
+	if strings.Contains(err.Error(), testTelegramToken) {
+		t.Errorf("error leaks bot token: %v", err)
+	}
+}
+
+func TestTelegramBot_PollingAnswersAllowlistedChats(t *testing.T) {
+	api := startFakeTelegramAPI(t)
+	bot := NewTelegramBot(TelegramConfig{BotToken: testTelegramToken, APIURL: api.srv.URL, ChatIDs: []int64{111}},
+		func() (*menubar.Snapshot, error) { return testTelegramSnapshot(), nil }, slog.Default())
+	bot.pollTimeout = 0
+	bot.retryDelay = 10 * time.Millisecond
+
+	api.queueMessage(1, 999, "/status")
+	api.queueMessage(2, 111, "/status@onwatch_bot codex")
+	bot.Start()
+	defer bot.Stop()
+
+	waitFor(t, func() bool { return len(api.Sent()) > 0 })
+	time.Sleep(50 * time.Millisecond)
+
+	sent := api.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("expected exactly one reply, got %+v", sent)
+	}
+	if sent[0].ChatID != 111 {
+		t.Errorf("reply sent to chat %d, want 111", sent[0].ChatID)
+	}
+	if !strings.Contains(sent[0].Text, "Codex (work)") || strings.Contains(sent[0].Text, "Claude") {
+		t.Errorf("/status codex should only list codex, got %q", sent[0].Text)
+	}
+}
+
+func TestTelegramBot_StartStop(t *testing.T) {
+	api := startFakeTelegramAPI(t)
+	bot := NewTelegramBot(TelegramConfig{BotToken: testTelegramToken, APIURL: api.srv.URL, ChatIDs: []int64{111}}, nil, slog.Default())
+	bot.pollTimeout = 0
+
+	bot.Start()
+	bot.Start() // no-op while running
+	done := make(chan struct{})
+	go func() {
+		bot.Stop()
+		bot.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not return")
+	}
+}
+
+func TestTelegramBot_CommandResponse(t *testing.T) {
+	bot := NewTelegramBot(TelegramConfig{BotToken: testTelegramToken},
+		func() (*menubar.Snapshot, error) { return testTelegramSnapshot(), nil }, slog.Default())
+
+	tests := []struct {
+		text     string
+		contains []string
+		empty    bool
+	}{
+		{text: "/help", contains: []string{"/status", "/resets"}},
+		{text: "/start", contains: []string{"onWatch commands"}},
+		{text: "/status", contains: []string{"onWatch: 1 warning (updated 1m ago)", "🟡 Claude - 82%", "5-Hour: 82% (resets in 2h 10m)", "🟢 Codex (work) - 12%"}},
+		{text: "/STATUS anthropic", contains: []string{"Claude"}},
+		{text: "/status gemini", contains: []string{`No provider matching "gemini".`}},
+		{text: "/unknown", contains: []string{"Unknown command."}},
+		{text: "hello", empty: true},
+	}
+	for _, tt := range tests {
+		got := bot.commandResponse(tt.text)
+		if tt.empty {
+			if got != "" {
+				t.Errorf("commandResponse(%q) = %q, want empty", tt.text, got)
+			}
+			continue
+		}
+		for _, want := range tt.contains {
+			if !strings.Contains(got, want) {
+				t.Errorf("commandResponse(%q) = %q, missing %q", tt.text, got, want)
+			}
+		}
+	}
+}
+
+func TestTelegramBot_CommandResponse_NoSnapshot(t *testing.T) {
+	bot := NewTelegramBot(TelegramConfig{BotToken: testTelegramToken}, nil, slog.Default())
+	if got := bot.commandResponse("/status"); got != "Status is not available yet." {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestFormatTelegramResets(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	snapshot := &menubar.Snapshot{
+		Providers: []menubar.ProviderCard{
+			{Label: "Claude", Quotas: []menubar.QuotaMeter{
+				{Label: "Weekly", Percent: 40, Status: "healthy", ResetAt: now.Add(50 * time.Hour).Format(time.RFC3339)},
+				{Label: "5-Hour", Percent: 91, Status: "critical", ResetAt: now.Add(90 * time.Minute).Format(time.RFC3339)},
+				{Label: "Past", ResetAt: now.Add(-time.Hour).Format(time.RFC3339)},
+				{Label: "None"},
+			}},
+		},
+	}
+
+	got := formatTelegramResets(snapshot, now)
+	want := "Upcoming resets:\n\n🔴 Claude 5-Hour - in 1h 30m (91% used)\n🟢 Claude Weekly - in 2d 2h (40% used)"
+	if got != want {
+		t.Errorf("formatTelegramResets =\n%q\nwant\n%q", got, want)
+	}
+
+	if got := formatTelegramResets(&menubar.Snapshot{}, now); got != "No upcoming resets." {
+		t.Errorf("empty snapshot = %q", got)
+	}
+}
+
+func TestTruncateTelegram(t *testing.T) {
+	short := "hello"
+	if got := truncateTelegram(short); got != short {
+		t.Errorf("short text changed: %q", got)
+	}
+	long := strings.Repeat("é", telegramMaxMessageLen)
+	got := truncateTelegram(long)
+	if len(got) > telegramMaxMessageLen {
+		t.Errorf("truncated length %d exceeds limit", len(got))
+	}
+	if !strings.HasSuffix(got, "\n…") {
+		t.Errorf("truncated text should end with ellipsis")
+	}
+	if !strings.HasPrefix(got, "éé") || strings.ContainsRune(got, '�') {
+		t.Errorf("truncation split a rune")
+	}
+}
+
+func TestParseTelegramChatIDs(t *testing.T) {
+	ids, err := ParseTelegramChatIDs(" 123, -1001234567890 ,,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != 123 || ids[1] != -1001234567890 {
+		t.Errorf("got %v", ids)
+	}
+	if _, err := ParseTelegramChatIDs("123, @channel"); err == nil {
+		t.Error("expected error for non-numeric chat ID")
+	}
+}
+
+// storeTelegramConfig saves Telegram settings under the "telegram" key,
+// matching the format that the handler's UpdateSettings uses.
+func storeTelegramConfig(t *testing.T, s *store.Store, cfg telegramSettingsJSON) {
+	t.Helper()
+	data, _ := json.Marshal(cfg)
+	if err := s.SetSetting("telegram", string(data)); err != nil {
+		t.Fatalf("SetSetting: %v", err)
+	}
+}
+
+func TestNotificationEngine_ConfigureTelegram_EncryptedToken(t *testing.T) {
+	api := startFakeTelegramAPI(t)
+	s := newTestStore(t)
+	defer s.Close()
+
+	key, _ := GenerateEncryptionKey()
+	encrypted, err := EncryptForStorage(testTelegramToken, key)
+	if err != nil {
+		t.Fatalf("EncryptForStorage: %v", err)
+	}
+	storeTelegramConfig(t, s, telegramSettingsJSON{BotToken: encrypted, ChatIDs: "111", APIURL: api.srv.URL})
+
+	engine := newTestEngine(t, s)
+	defer engine.Close()
+
+	if err := engine.ConfigureTelegram(); err == nil {
+		t.Fatal("expected error without encryption key")
+	}
+
+	engine.SetEncryptionKey(key)
+	if err := engine.ConfigureTelegram(); err != nil {
+		t.Fatalf("ConfigureTelegram: %v", err)
+	}
+	if err := engine.SendTestTelegram(); err != nil {
+		t.Fatalf("SendTestTelegram: %v", err)
+	}
+	sent := api.Sent()
+	if len(sent) != 1 || !strings.Contains(sent[0].Text, "Test Message") {
+		t.Fatalf("unexpected messages: %+v", sent)
+	}
+}
+
+func TestNotificationEngine_ConfigureTelegram_Disabled(t *testing.T) {
+	s := newTestStore(t)
+	defer s.Close()
+	engine := newTestEngine(t, s)
+
+	if err := engine.ConfigureTelegram(); err != nil {
+		t.Fatalf("ConfigureTelegram with no settings: %v", err)
+	}
+	if err := engine.SendTestTelegram(); err == nil {
+		t.Fatal("expected error when telegram is not configured")
+	}
+
+	storeTelegramConfig(t, s, telegramSettingsJSON{BotToken: testTelegramToken, ChatIDs: "abc"})
+	if err := engine.ConfigureTelegram(); err == nil {
+		t.Fatal("expected error for invalid chat IDs")
+	}
+}
+
+func TestNotificationEngine_Check_TelegramChannel(t *testing.T) {
+	api := startFakeTelegramAPI(t)
+	s := newTestStore(t)
+	defer s.Close()
+	storeNotificationConfig(t, s, notificationSettingsJSON{
+		WarningThreshold:  80,
+		CriticalThreshold: 95,
+		NotifyWarning:     true,
+		NotifyCritical:    true,
+		Channels:          &NotificationChannels{Telegram: true},
+	})
+	storeTelegramConfig(t, s, telegramSettingsJSON{BotToken: testTelegramToken, ChatIDs: "111", APIURL: api.srv.URL})
+
+	engine := newTestEngine(t, s)
+	defer engine.Close()
+	engine.Reload()
+	if err := engine.ConfigureTelegram(); err != nil {
+		t.Fatalf("ConfigureTelegram: %v", err)
+	}
+
+	engine.Check(QuotaStatus{Provider: "anthropic", QuotaKey: "five_hour", Utilization: 97})
+
+	sent := api.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 telegram alert, got %d", len(sent))
+	}
+	if !strings.Contains(sent[0].Text, "five_hour") {
+		t.Errorf("alert should mention the quota, got %q", sent[0].Text)
+	}
+}
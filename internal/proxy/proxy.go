@@ -0,0 +1,340 @@
+// Package proxy implements a local reverse proxy that forwards LLM API calls
+// to their upstreams and records the token usage in each response as API
+// integration usage events.
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
+)
+
+// SourcePath is the source path recorded for events captured by the proxy.
+const SourcePath = "proxy"
+
+// DefaultListenAddr is where `onwatch proxy` listens unless told otherwise.
+const DefaultListenAddr = "127.0.0.1:9212"
+
+// Request headers a client can set to tag its calls. They are removed before
+// the request is forwarded.
+const (
+	IntegrationHeader = "X-OnWatch-Integration"
+	AccountHeader     = "X-OnWatch-Account"
+)
+
+// Route forwards requests under /<Name>/ to Upstream. Usage is recorded
+// under Provider, with Name as the integration unless the client tags it.
+type Route struct {
+	Name     string
+	Provider string
+	Upstream *url.URL
+}
+
+// DefaultRoutes returns a route per supported provider, named after it.
+func DefaultRoutes() []Route {
+	upstreams := []struct{ provider, rawURL string }{
+		{"openai", "https://api.openai.com"},
+		{"anthropic", "https://api.anthropic.com"},
+		{"openrouter", "https://openrouter.ai/api"},
+		{"mistral", "https://api.mistral.ai"},
+		{"gemini", "https://generativelanguage.googleapis.com"},
+	}
+	routes := make([]Route, 0, len(upstreams))
+	for _, u := range upstreams {
+		target, _ := url.Parse(u.rawURL)
+		routes = append(routes, Route{Name: u.provider, Provider: u.provider, Upstream: target})
+	}
+	return routes
+}
+
+// ParseRoute parses a NAME=PROVIDER:URL route definition, e.g.
+// "team-a=openai:https://gateway.internal/openai".
+func ParseRoute(def string) (Route, error) {
+	name, rest, ok := strings.Cut(def, "=")
+	provider, rawURL, ok2 := strings.Cut(rest, ":")
+	name = strings.TrimSpace(name)
+	if !ok || !ok2 || name == "" || strings.Contains(name, "/") {
+		return Route{}, fmt.Errorf("route %q must look like NAME=PROVIDER:URL", def)
+	}
+	provider, err := usageevent.NormalizeProvider(provider)
+	if err != nil {
+		return Route{}, fmt.Errorf("route %q: %w", def, err)
+	}
+	target, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return Route{}, fmt.Errorf("route %q: upstream must be an http or https URL", def)
+	}
+	return Route{Name: name, Provider: provider, Upstream: target}, nil
+}
+
+// Recorder stores captured usage events.
+type Recorder interface {
+	InsertAPIIntegrationUsageEvent(event *usageevent.Event) (int64, error)
+}
+
+// Proxy is an http.Handler that forwards /<route>/... to the route's
+// upstream and records the usage in successful responses, including
+// streamed (SSE) ones. Error responses and failed upstream requests are
+// recorded as events with status "error" and no tokens.
+type Proxy struct {
+	routes     map[string]*Route
+	proxies    map[string]*httputil.ReverseProxy
+	keyAliases map[string]string
+	recorder   Recorder
+	logger     *slog.Logger
+}
+
+// New creates a Proxy. Later routes replace earlier ones with the same name.
+// keyAliases maps an API key suffix to the account name its calls are
+// recorded under, the longest matching suffix winning; other keys are
+// recorded as "key-" plus their last four characters.
+func New(routes []Route, keyAliases map[string]string, recorder Recorder, logger *slog.Logger) *Proxy {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	p := &Proxy{
+		routes:     make(map[string]*Route),
+		proxies:    make(map[string]*httputil.ReverseProxy),
+		keyAliases: keyAliases,
+		recorder:   recorder,
+		logger:     logger,
+	}
+	for i := range routes {
+		route := routes[i]
+		p.routes[route.Name] = &route
+		p.proxies[route.Name] = &httputil.ReverseProxy{
+			Rewrite: func(pr *httputil.ProxyRequest) {
+				pr.Out.URL.Path = strings.TrimPrefix(pr.Out.URL.Path, "/"+route.Name)
+				pr.Out.URL.RawPath = ""
+				pr.SetURL(route.Upstream)
+				// Let the transport negotiate gzip so responses arrive
+				// decoded and their usage can be read.
+				pr.Out.Header.Del("Accept-Encoding")
+				pr.Out.Header.Del(IntegrationHeader)
+				pr.Out.Header.Del(AccountHeader)
+			},
+			FlushInterval:  -1,
+			ModifyResponse: p.modifyResponse,
+			ErrorHandler:   p.errorHandler,
+		}
+	}
+	return p
+}
+
+// RouteNames returns the configured route names, sorted.
+func (p *Proxy) RouteNames() []string {
+	names := make([]string, 0, len(p.routes))
+	for name := range p.routes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type captureKey struct{}
+
+// capture is the per-request state shared with the response recorder.
+type capture struct {
+	route       *Route
+	integration string
+	account     string
+	path        string
+	model       string // requested model, used when the response names none
+	start       time.Time
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	route, ok := p.routes[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown route %q; configured routes: %s", name, strings.Join(p.RouteNames(), ", ")), http.StatusNotFound)
+		return
+	}
+
+	c := &capture{
+		route:       route,
+		integration: route.Name,
+		account:     p.accountFor(r),
+		path:        strings.TrimPrefix(r.URL.Path, "/"+route.Name),
+		start:       time.Now(),
+	}
+	if v := strings.TrimSpace(r.Header.Get(IntegrationHeader)); v != "" {
+		c.integration = v
+	}
+	if v := strings.TrimSpace(r.Header.Get(AccountHeader)); v != "" {
+		c.account = v
+	}
+	model, body, err := usageevent.PeekRequestModel(r.Body, c.path)
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	c.model, r.Body = model, body
+	p.proxies[name].ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), captureKey{}, c)))
+}
+
+// accountFor names the account a request is billed to from its API key:
+// OpenAI-style bearer tokens, Anthropic's x-api-key, or Gemini's
+// x-goog-api-key and key parameter.
+func (p *Proxy) accountFor(r *http.Request) string {
+	key := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	for _, alt := range []string{r.Header.Get("X-Api-Key"), r.Header.Get("X-Goog-Api-Key"), r.URL.Query().Get("key")} {
+		if key == "" {
+			key = strings.TrimSpace(alt)
+		}
+	}
+	if key == "" {
+		return "default"
+	}
+	alias, matched := "", ""
+	for suffix, name := range p.keyAliases {
+		if suffix != "" && strings.HasSuffix(key, suffix) && len(suffix) > len(matched) {
+			alias, matched = name, suffix
+		}
+	}
+	if alias != "" {
+		return alias
+	}
+	if len(key) <= 4 {
+		return "default"
+	}
+	return "key-" + key[len(key)-4:]
+}
+
+func (p *Proxy) modifyResponse(resp *http.Response) error {
+	c, ok := resp.Request.Context().Value(captureKey{}).(*capture)
+	if !ok {
+		return nil
+	}
+	if resp.StatusCode >= 400 {
+		p.recordFailure(c, usageevent.ErrorTypeForHTTPStatus(resp.StatusCode), resp)
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil
+	}
+	status, stream := resp.StatusCode, usageevent.IsEventStream(resp.Header)
+	rateLimits := usageevent.RateLimitHeaders(resp.Header)
+	usageevent.CaptureResponseUsage(resp, func(u *usageevent.ResponseUsage) {
+		if !u.Found {
+			return
+		}
+		metadata := map[string]interface{}{
+			"route":  c.route.Name,
+			"path":   c.path,
+			"status": status,
+			"stream": stream,
+		}
+		if len(rateLimits) > 0 {
+			metadata["ratelimit"] = rateLimits
+		}
+		p.record(c, u, metadata)
+	})
+	return nil
+}
+
+func (p *Proxy) errorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	p.logger.Warn("Proxy upstream request failed", "route", r.URL.Path, "error", err)
+	if c, ok := r.Context().Value(captureKey{}).(*capture); ok {
+		p.recordFailure(c, usageevent.ErrorTypeForError(err), nil)
+	}
+	http.Error(w, "upstream request failed", http.StatusBadGateway)
+}
+
+func (p *Proxy) record(c *capture, u *usageevent.ResponseUsage, metadata map[string]interface{}) {
+	model := u.Model
+	if model == "" {
+		model = c.model
+	}
+	if model == "" {
+		model = "unknown"
+	}
+	fields := map[string]interface{}{
+		"ts":                time.Now().UTC().Format(time.RFC3339Nano),
+		"integration":       c.integration,
+		"provider":          c.route.Provider,
+		"account":           c.account,
+		"model":             model,
+		"request_id":        u.ResponseID,
+		"prompt_tokens":     u.InputTokens,
+		"completion_tokens": u.OutputTokens,
+		"latency_ms":        time.Since(c.start).Milliseconds(),
+		"metadata":          metadata,
+	}
+	if u.CostUSD != nil {
+		fields["cost_usd"] = *u.CostUSD
+	}
+	for key, value := range map[string]*int{
+		"cache_read_tokens":  u.CacheReadTokens,
+		"cache_write_tokens": u.CacheWriteTokens,
+		"reasoning_tokens":   u.ReasoningTokens,
+		"audio_tokens":       u.AudioTokens,
+	} {
+		if value != nil {
+			fields[key] = *value
+		}
+	}
+	p.insert(c, fields)
+}
+
+// recordFailure records a call that failed with an error response, or with
+// no response when resp is nil. Its latency runs to the response headers.
+func (p *Proxy) recordFailure(c *capture, errorType string, resp *http.Response) {
+	model := c.model
+	if model == "" {
+		model = "unknown"
+	}
+	metadata := map[string]interface{}{
+		"route": c.route.Name,
+		"path":  c.path,
+	}
+	fields := map[string]interface{}{
+		"ts":                time.Now().UTC().Format(time.RFC3339Nano),
+		"integration":       c.integration,
+		"provider":          c.route.Provider,
+		"account":           c.account,
+		"model":             model,
+		"prompt_tokens":     0,
+		"completion_tokens": 0,
+		"latency_ms":        time.Since(c.start).Milliseconds(),
+		"status":            usageevent.StatusError,
+		"error_type":        errorType,
+		"metadata":          metadata,
+	}
+	if resp != nil {
+		metadata["status"] = resp.StatusCode
+		if rateLimits := usageevent.RateLimitHeaders(resp.Header); len(rateLimits) > 0 {
+			metadata["ratelimit"] = rateLimits
+		}
+		if id := resp.Header.Get("X-Request-Id"); id != "" {
+			fields["request_id"] = id
+		} else if id := resp.Header.Get("Request-Id"); id != "" {
+			fields["request_id"] = id
+		}
+	}
+	p.insert(c, fields)
+}
+
+func (p *Proxy) insert(c *capture, fields map[string]interface{}) {
+	event, err := usageevent.FromFields(fields, SourcePath)
+	if err != nil {
+		p.logger.Warn("Proxy skipped invalid usage event", "route", c.route.Name, "error", err)
+		return
+	}
+	if _, err := p.recorder.InsertAPIIntegrationUsageEvent(event); err != nil && !errors.Is(err, store.ErrDuplicateAPIIntegrationUsageEvent) {
+		p.logger.Error("Proxy failed to record usage event", "route", c.route.Name, "error", err)
+	}
+}
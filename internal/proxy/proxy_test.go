@@ -0,0 +1,225 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
+)
+
+type fakeRecorder struct {
+	mu     sync.Mutex
+	events []*usageevent.Event
+}
+
+func (r *fakeRecorder) InsertAPIIntegrationUsageEvent(event *usageevent.Event) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return int64(len(r.events)), nil
+}
+
+// waitEvents waits for n events; the proxy records once it has read the
+// whole upstream body, which can be just after the client has.
+func (r *fakeRecorder) waitEvents(t *testing.T, n int) []*usageevent.Event {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		r.mu.Lock()
+		events := append([]*usageevent.Event(nil), r.events...)
+		r.mu.Unlock()
+		if len(events) >= n || time.Now().After(deadline) {
+			if len(events) != n {
+				t.Fatalf("recorded %d events, want %d", len(events), n)
+			}
+			return events
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func startProxy(t *testing.T, provider string, upstream http.HandlerFunc, aliases map[string]string) (*httptest.Server, *fakeRecorder) {
+	t.Helper()
+	up := httptest.NewServer(upstream)
+	t.Cleanup(up.Close)
+	target, _ := url.Parse(up.URL + "/base")
+	rec := &fakeRecorder{}
+	p := New([]Route{{Name: "tool", Provider: provider, Upstream: target}}, aliases, rec, nil)
+	srv := httptest.NewServer(p)
+	t.Cleanup(srv.Close)
+	return srv, rec
+}
+
+func TestProxy_RecordsJSONUsage(t *testing.T) {
+	var gotPath, gotTag, gotAuth string
+	srv, rec := startProxy(t, "openrouter", func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotTag, gotAuth = r.URL.Path, r.Header.Get(IntegrationHeader), r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-RateLimit-Remaining-Requests", "99")
+		fmt.Fprint(w, `{"id":"gen-1","model":"openai/gpt-4.1","choices":[],"usage":{"prompt_tokens":21,"completion_tokens":9,"total_tokens":30,"cost":0.0042}}`)
+	}, map[string]string{"wxyz": "ci-bot"})
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/tool/v1/chat/completions", strings.NewReader(`{"model":"openai/gpt-4.1"}`))
+	req.Header.Set("Authorization", "Bearer sk-or-abcdwxyz")
+	req.Header.Set(IntegrationHeader, "notes")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `"gen-1"`) {
+		t.Fatalf("status=%d body=%s", resp.StatusCode, body)
+	}
+	if gotPath != "/base/v1/chat/completions" || gotTag != "" || gotAuth != "Bearer sk-or-abcdwxyz" {
+		t.Fatalf("upstream saw path=%q tag=%q auth=%q", gotPath, gotTag, gotAuth)
+	}
+
+	event := rec.waitEvents(t, 1)[0]
+	if event.Integration != "notes" || event.Provider != "openrouter" || event.Account != "ci-bot" || event.Model != "openai/gpt-4.1" || event.RequestID != "gen-1" {
+		t.Fatalf("event=%+v", event)
+	}
+	if event.PromptTokens != 21 || event.CompletionTokens != 9 || event.CostUSD == nil || *event.CostUSD != 0.0042 {
+		t.Fatalf("tokens=%d/%d cost=%v", event.PromptTokens, event.CompletionTokens, event.CostUSD)
+	}
+	var metadata map[string]interface{}
+	if err := json.Unmarshal([]byte(event.MetadataJSON), &metadata); err != nil {
+		t.Fatalf("metadata %q: %v", event.MetadataJSON, err)
+	}
+	limits, _ := metadata["ratelimit"].(map[string]interface{})
+	if metadata["route"] != "tool" || metadata["path"] != "/v1/chat/completions" || limits["x-ratelimit-remaining-requests"] != "99" {
+		t.Fatalf("metadata=%v", metadata)
+	}
+	if event.SourcePath != SourcePath || event.LatencyMS == nil {
+		t.Fatalf("source=%q latency=%v", event.SourcePath, event.LatencyMS)
+	}
+}
+
+func TestProxy_RecordsStreamedAnthropicUsage(t *testing.T) {
+	stream := strings.Join([]string{
+		"event: message_start",
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-3-7-sonnet","usage":{"input_tokens":12,"cache_read_input_tokens":100,"output_tokens":1}}}`,
+		"",
+		"event: content_block_delta",
+		`data: {"type":"content_block_delta","delta":{"type":"text_delta","text":"hi"}}`,
+		"",
+		"event: message_delta",
+		`data: {"type":"message_delta","usage":{"output_tokens":42}}`,
+		"",
+		"event: message_stop",
+		`data: {"type":"message_stop"}`,
+		"",
+	}, "\n")
+	srv, rec := startProxy(t, "anthropic", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		// Split mid-line to exercise partial line handling.
+		for _, chunk := range []string{stream[:57], stream[57:200], stream[200:]} {
+			io.WriteString(w, chunk)
+			flusher.Flush()
+		}
+	}, nil)
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/tool/v1/messages", strings.NewReader(`{}`))
+	req.Header.Set("X-Api-Key", "sk-ant-1234abcd")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != stream {
+		t.Fatalf("stream was altered:\n%s", body)
+	}
+
+	event := rec.waitEvents(t, 1)[0]
+	if event.Integration != "tool" || event.Account != "key-abcd" || event.Model != "claude-3-7-sonnet" || event.RequestID != "msg_1" {
+		t.Fatalf("event=%+v", event)
+	}
+	if event.PromptTokens != 112 || event.CompletionTokens != 42 {
+		t.Fatalf("tokens=%d/%d want 112/42", event.PromptTokens, event.CompletionTokens)
+	}
+	if !strings.Contains(event.MetadataJSON, `"stream":true`) {
+		t.Fatalf("metadata=%s", event.MetadataJSON)
+	}
+}
+
+func TestProxy_RecordsFailuresAndSkipsUnknownRoutes(t *testing.T) {
+	srv, rec := startProxy(t, "openai", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "req_429")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"message":"slow down"},"usage":{"prompt_tokens":5}}`)
+	}, nil)
+
+	resp, err := http.Post(srv.URL+"/tool/v1/chat/completions", "application/json", strings.NewReader(`{"model":"gpt-4.1"}`))
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("status=%d want upstream 429 passed through", resp.StatusCode)
+	}
+
+	resp, err = http.Get(srv.URL + "/nope/v1/models")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown route status=%d want 404", resp.StatusCode)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	event := rec.waitEvents(t, 1)[0]
+	if event.Status != usageevent.StatusError || event.ErrorType != "rate_limit" || event.Model != "gpt-4.1" || event.RequestID != "req_429" {
+		t.Fatalf("event=%+v", event)
+	}
+	if event.PromptTokens != 0 || event.LatencyMS == nil || !strings.Contains(event.MetadataJSON, `"status":429`) {
+		t.Fatalf("event=%+v metadata=%s", event, event.MetadataJSON)
+	}
+}
+
+func TestProxy_RecordsUnreachableUpstream(t *testing.T) {
+	target, _ := url.Parse("http://127.0.0.1:1")
+	rec := &fakeRecorder{}
+	srv := httptest.NewServer(New([]Route{{Name: "tool", Provider: "anthropic", Upstream: target}}, nil, rec, nil))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/tool/v1/messages", "application/json", strings.NewReader(`{"model":"claude-sonnet-4"}`))
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("status=%d want 502", resp.StatusCode)
+	}
+	event := rec.waitEvents(t, 1)[0]
+	if event.ErrorType != "network" || event.Provider != "anthropic" || event.Model != "claude-sonnet-4" {
+		t.Fatalf("event=%+v", event)
+	}
+}
+
+func TestParseRoute(t *testing.T) {
+	route, err := ParseRoute("team-a=OpenAI:https://gateway.internal/openai")
+	if err != nil {
+		t.Fatalf("ParseRoute: %v", err)
+	}
+	if route.Name != "team-a" || route.Provider != "openai" || route.Upstream.String() != "https://gateway.internal/openai" {
+		t.Fatalf("route=%+v", route)
+	}
+	for _, bad := range []string{"team-a", "team-a=openai", "team-a=bad provider:https://x", "team-a=openai:ftp://x", "a/b=openai:https://x"} {
+		if _, err := ParseRoute(bad); err == nil {
+			t.Errorf("ParseRoute(%q) succeeded, want error", bad)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package report
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+	"time"
+)
+
+//go:embed templates/report.html
+var reportTemplateSource string
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"date":     func(t time.Time) string { return t.Format("Jan 2, 2006 15:04") },
+	"duration": formatDuration,
+	"barWidth": func(pct float64) int { return int(min(100, max(0, pct))) },
+	"barColor": func(pct float64) string {
+		switch {
+		case pct >= fullWindowPercent:
+			return "#DC2626"
+		case pct >= 80:
+			return "#F59E0B"
+		default:
+			return "#10B981"
+		}
+	},
+}).Parse(reportTemplateSource))
+
+// RenderHTML writes the report as a self-contained HTML page. Styles are
+// inline so the same page works as an email body and as a download.
+func (r *Report) RenderHTML(w io.Writer) error {
+	return reportTemplate.Execute(w, r)
+}
@@ -0,0 +1,680 @@
+// Package report builds periodic usage reports from reset-cycle history,
+// session history and API-integration usage, and renders them as HTML or
+// plain text for email delivery and download.
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/api"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+// Report periods.
+const (
+	PeriodWeekly  = "weekly"
+	PeriodMonthly = "monthly"
+)
+
+// cycleOverviewLimit bounds the cycles read per quota. A month of 5-hour
+// windows is ~150 cycles, so this comfortably covers a monthly report.
+const cycleOverviewLimit = 500
+
+// topSessionsLimit is the number of biggest sessions listed per provider.
+const topSessionsLimit = 5
+
+// deepSeekCurrencies are the balance currencies DeepSeek reports.
+var deepSeekCurrencies = []string{"CNY", "USD"}
+
+// fullWindowPercent is the peak utilization counted as a window hitting its limit.
+const fullWindowPercent = 100.0
+
+// Report summarizes usage between Start and End.
+type Report struct {
+	Period       string
+	Start        time.Time
+	End          time.Time
+	GeneratedAt  time.Time
+	Providers    []ProviderSummary
+	Balances     []BalanceSummary
+	Sessions     []SessionSummary
+	Integrations []IntegrationCost
+	TotalCostUSD float64
+	// EstimatedCostUSD is the estimated cost of integration events that
+	// reported none; it is not part of TotalCostUSD.
+	EstimatedCostUSD float64
+}
+
+// ProviderSummary holds per-quota cycle statistics for one provider account
+// over its billing period: the whole reset cycles of its longest quota that
+// completed in the report period, from Start to End.
+type ProviderSummary struct {
+	Provider string
+	Label    string
+	Start    time.Time
+	End      time.Time
+	Quotas   []QuotaSummary
+}
+
+// QuotaSummary aggregates the cycles of one quota that completed in the
+// provider's billing period.
+// Peaks are percentages of the quota's limit; cycles whose limit is unknown
+// count towards CyclesCompleted but not towards the peak statistics.
+type QuotaSummary struct {
+	Name            string
+	Label           string
+	CyclesCompleted int
+	AvgPeakPercent  float64
+	MaxPeakPercent  float64
+	FullWindows     int // cycles whose peak reached 100%
+}
+
+// BalanceSummary aggregates the top-up cycles of a prepaid balance that
+// completed in the period. A balance cycle runs from one top-up to the next,
+// so it has no limit to express a peak against; the report shows the spend.
+type BalanceSummary struct {
+	Provider        string
+	Label           string
+	Unit            string
+	CyclesCompleted int
+	Spent           float64
+}
+
+// SessionSummary is one of the biggest sessions of its provider in the period.
+type SessionSummary struct {
+	Provider  string
+	StartedAt time.Time
+	Duration  time.Duration
+	Usage     float64 // primary-quota consumption in provider units
+	Snapshots int
+}
+
+// IntegrationCost is the API-integration usage total for one integration and provider.
+type IntegrationCost struct {
+	Integration string
+	Provider    string
+	Requests    int
+	TotalTokens int
+	CostUSD     float64
+	// EstimatedCostUSD prices the events without a reported cost from the
+	// model pricing catalog.
+	EstimatedCostUSD float64
+}
+
+// PeriodStart returns the start of the period of the given kind that ends at
+// end: the 7 days or the month before end. Quota cycles are summarized over
+// each provider's own billing period within it (see ProviderSummary);
+// balances, sessions and API integrations use the period as is.
+func PeriodStart(period string, end time.Time) (time.Time, error) {
+	switch period {
+	case PeriodWeekly:
+		return end.AddDate(0, 0, -7), nil
+	case PeriodMonthly:
+		return end.AddDate(0, -1, 0), nil
+	default:
+		return time.Time{}, fmt.Errorf("unknown report period %q", period)
+	}
+}
+
+// Build assembles a report for [start, end) from the store.
+func Build(s *store.Store, period string, start, end time.Time) (*Report, error) {
+	r := &Report{
+		Period:      period,
+		Start:       start,
+		End:         end,
+		GeneratedAt: time.Now(),
+	}
+
+	for _, src := range cycleSources(s) {
+		summary, err := buildProviderSummary(src, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("report.Build: %s: %w", src.provider, err)
+		}
+		if len(summary.Quotas) > 0 {
+			r.Providers = append(r.Providers, summary)
+		}
+	}
+
+	for _, src := range balanceSources(s) {
+		summary, err := buildBalanceSummary(src, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("report.Build: %s: %w", src.provider, err)
+		}
+		if summary.CyclesCompleted > 0 {
+			r.Balances = append(r.Balances, summary)
+		}
+	}
+
+	sessions, err := s.QueryTopSessions(start, end, topSessionsLimit)
+	if err != nil {
+		return nil, fmt.Errorf("report.Build: %w", err)
+	}
+	for _, sess := range sessions {
+		if sess.Usage <= 0 {
+			continue
+		}
+		ss := SessionSummary{
+			Provider:  sess.Provider,
+			StartedAt: sess.StartedAt,
+			Usage:     sess.Usage,
+			Snapshots: sess.SnapshotCount,
+		}
+		if sess.EndedAt != nil {
+			ss.Duration = sess.EndedAt.Sub(sess.StartedAt)
+		} else {
+			ss.Duration = end.Sub(sess.StartedAt)
+		}
+		r.Sessions = append(r.Sessions, ss)
+	}
+
+	totals, err := s.QueryAPIIntegrationUsageTotals(start, end)
+	if err != nil {
+		return nil, fmt.Errorf("report.Build: %w", err)
+	}
+	for _, t := range totals {
+		r.Integrations = append(r.Integrations, IntegrationCost{
+			Integration: t.IntegrationName,
+			Provider:    t.Provider,
+			Requests:    t.RequestCount,
+			TotalTokens: t.TotalTokens,
+			CostUSD:     t.TotalCostUSD,
+
+			EstimatedCostUSD: t.EstimatedCostUSD,
+		})
+		r.TotalCostUSD += t.TotalCostUSD
+		r.EstimatedCostUSD += t.EstimatedCostUSD
+	}
+
+	return r, nil
+}
+
+// cycleSource describes where one provider account's cycle overview comes from.
+type cycleSource struct {
+	provider   string
+	label      string
+	quotaNames func() ([]string, error)
+	overview   func(quota string) ([]store.CycleOverviewRow, error)
+	quotaLabel func(quota string) string
+	// peakIsPercent is true when CycleOverviewRow.PeakValue is already a
+	// utilization percentage rather than a raw count. Raw peaks are divided
+	// by the quota limit recorded in the row's CrossQuotas.
+	peakIsPercent bool
+}
+
+func staticNames(names ...string) func() ([]string, error) {
+	return func() ([]string, error) { return names, nil }
+}
+
+func identityLabel(quota string) string { return quota }
+
+// cycleSources lists the providers whose reset cycles are summarized.
+func cycleSources(s *store.Store) []cycleSource {
+	sources := []cycleSource{
+		{
+			provider:   "synthetic",
+			label:      "Synthetic",
+			quotaNames: staticNames("subscription", "search", "toolcall"),
+			overview: func(q string) ([]store.CycleOverviewRow, error) {
+				return s.QuerySyntheticCycleOverview(q, cycleOverviewLimit)
+			},
+			quotaLabel: identityLabel,
+		},
+		{
+			provider:   "zai",
+			label:      "Z.ai",
+			quotaNames: staticNames("tokens", "time"),
+			overview: func(q string) ([]store.CycleOverviewRow, error) {
+				return s.QueryZaiCycleOverview(q, cycleOverviewLimit)
+			},
+			quotaLabel: identityLabel,
+		},
+		{
+			provider:   "anthropic",
+			label:      "Anthropic",
+			quotaNames: s.QueryAllAnthropicQuotaNames,
+			overview: func(q string) ([]store.CycleOverviewRow, error) {
+				return s.QueryAnthropicCycleOverview(q, cycleOverviewLimit)
+			},
+			quotaLabel:    api.AnthropicDisplayName,
+			peakIsPercent: true,
+		},
+		{
+			provider:   "copilot",
+			label:      "Copilot",
+			quotaNames: s.QueryAllCopilotQuotaNames,
+			overview: func(q string) ([]store.CycleOverviewRow, error) {
+				return s.QueryCopilotCycleOverview(q, cycleOverviewLimit)
+			},
+			quotaLabel: api.CopilotDisplayName,
+		},
+	}
+
+	codexAccounts := activeAccounts(s, "codex", 1)
+	for _, accountID := range codexAccounts {
+		accountID := accountID
+		sources = append(sources, cycleSource{
+			provider:   "codex",
+			label:      accountLabel("Codex", accountID, len(codexAccounts)),
+			quotaNames: s.QueryAllCodexQuotaNames,
+			overview: func(q string) ([]store.CycleOverviewRow, error) {
+				return s.QueryCodexCycleOverview(accountID, q, cycleOverviewLimit)
+			},
+			quotaLabel:    api.CodexDisplayName,
+			peakIsPercent: true,
+		})
+	}
+
+	sources = append(sources,
+		cycleSource{
+			provider:   "cursor",
+			label:      "Cursor",
+			quotaNames: s.QueryAllCursorQuotaNames,
+			overview: func(q string) ([]store.CycleOverviewRow, error) {
+				return s.QueryCursorCycleOverview(q, cycleOverviewLimit)
+			},
+			quotaLabel:    identityLabel,
+			peakIsPercent: true,
+		},
+		cycleSource{
+			provider:   "gemini",
+			label:      "Gemini",
+			quotaNames: s.QueryAllGeminiModelIDs,
+			overview: func(q string) ([]store.CycleOverviewRow, error) {
+				return s.QueryGeminiCycleOverview(q, cycleOverviewLimit)
+			},
+			quotaLabel:    api.GeminiDisplayName,
+			peakIsPercent: true,
+		},
+		cycleSource{
+			provider:   "antigravity",
+			label:      "Antigravity",
+			quotaNames: staticNames(api.AntigravityQuotaGroupOrder()...),
+			overview: func(q string) ([]store.CycleOverviewRow, error) {
+				return s.QueryAntigravityCycleOverview(q, cycleOverviewLimit)
+			},
+			quotaLabel:    api.AntigravityQuotaGroupDisplayName,
+			peakIsPercent: true,
+		},
+	)
+
+	minimaxAccounts := activeAccounts(s, "minimax", 0)
+	for _, accountID := range minimaxAccounts {
+		accountID := accountID
+		sources = append(sources, cycleSource{
+			provider: "minimax",
+			label:    accountLabel("MiniMax", accountID, len(minimaxAccounts)),
+			quotaNames: func() ([]string, error) {
+				return s.QueryAllMiniMaxModelNames(accountID)
+			},
+			overview: func(q string) ([]store.CycleOverviewRow, error) {
+				return s.QueryMiniMaxCycleOverview(q, cycleOverviewLimit, accountID)
+			},
+			quotaLabel: identityLabel,
+		})
+	}
+
+	sources = append(sources,
+		cycleSource{
+			provider: "grok",
+			label:    "Grok",
+			quotaNames: func() ([]string, error) {
+				return s.QueryGrokCycleQuotaNames(store.DefaultGrokAccountID)
+			},
+			overview: func(q string) ([]store.CycleOverviewRow, error) {
+				cycles, err := s.QueryGrokCyclesForQuota(store.DefaultGrokAccountID, q, cycleOverviewLimit)
+				if err != nil {
+					return nil, err
+				}
+				rows := make([]store.CycleOverviewRow, 0, len(cycles))
+				for _, c := range cycles {
+					rows = append(rows, utilizationCycleRow(q, c.CycleStart, c.CycleEnd, c.PeakUtilization, c.TotalDelta))
+				}
+				return rows, nil
+			},
+			quotaLabel:    api.GrokDisplayName,
+			peakIsPercent: true,
+		},
+		cycleSource{
+			provider: "kimi",
+			label:    "Kimi",
+			quotaNames: func() ([]string, error) {
+				return s.QueryKimiCycleQuotaNames(store.DefaultKimiAccountID)
+			},
+			overview: func(q string) ([]store.CycleOverviewRow, error) {
+				cycles, err := s.QueryKimiCyclesForQuota(store.DefaultKimiAccountID, q, cycleOverviewLimit)
+				if err != nil {
+					return nil, err
+				}
+				rows := make([]store.CycleOverviewRow, 0, len(cycles))
+				for _, c := range cycles {
+					rows = append(rows, utilizationCycleRow(q, c.CycleStart, c.CycleEnd, c.PeakUtilization, c.TotalDelta))
+				}
+				return rows, nil
+			},
+			quotaLabel:    api.KimiDisplayName,
+			peakIsPercent: true,
+		},
+	)
+	return sources
+}
+
+// activeAccounts returns the IDs of a provider's active accounts, or
+// fallback when none are registered.
+func activeAccounts(s *store.Store, provider string, fallback int64) []int64 {
+	accounts, err := s.QueryActiveProviderAccounts(provider)
+	if err != nil || len(accounts) == 0 {
+		return []int64{fallback}
+	}
+	ids := make([]int64, 0, len(accounts))
+	for _, a := range accounts {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
+
+// accountLabel suffixes the provider label with the account ID when the
+// provider has more than one account.
+func accountLabel(label string, accountID int64, accounts int) string {
+	if accounts <= 1 {
+		return label
+	}
+	return label + " #" + strconv.FormatInt(accountID, 10)
+}
+
+// utilizationCycleRow adapts a cycle that stores its peak as a utilization
+// percentage to an overview row.
+func utilizationCycleRow(quota string, start time.Time, end *time.Time, peak, delta float64) store.CycleOverviewRow {
+	return store.CycleOverviewRow{
+		QuotaType:  quota,
+		CycleStart: start,
+		CycleEnd:   end,
+		PeakValue:  peak,
+		TotalDelta: delta,
+	}
+}
+
+func buildProviderSummary(src cycleSource, start, end time.Time) (ProviderSummary, error) {
+	summary := ProviderSummary{Provider: src.provider, Label: src.label}
+	names, err := src.quotaNames()
+	if err != nil {
+		return summary, err
+	}
+	sort.Strings(names)
+	cycles := make(map[string][]store.CycleOverviewRow, len(names))
+	for _, name := range names {
+		rows, err := src.overview(name)
+		if err != nil {
+			return summary, err
+		}
+		cycles[name] = rows
+	}
+	var ok bool
+	summary.Start, summary.End, ok = billingPeriod(cycles, start, end)
+	if !ok {
+		return summary, nil
+	}
+	for _, name := range names {
+		q := QuotaSummary{Name: name, Label: src.quotaLabel(name)}
+		var total float64
+		var measured int
+		for _, row := range cycles[name] {
+			if row.CycleEnd == nil || !row.CycleEnd.After(summary.Start) || row.CycleEnd.After(summary.End) {
+				continue
+			}
+			q.CyclesCompleted++
+			peak, ok := cyclePeakPercent(row, name, src.peakIsPercent)
+			if !ok {
+				continue
+			}
+			measured++
+			total += peak
+			if peak > q.MaxPeakPercent {
+				q.MaxPeakPercent = peak
+			}
+			if peak >= fullWindowPercent {
+				q.FullWindows++
+			}
+		}
+		if q.CyclesCompleted == 0 {
+			continue
+		}
+		if measured > 0 {
+			q.AvgPeakPercent = total / float64(measured)
+		}
+		summary.Quotas = append(summary.Quotas, q)
+	}
+	return summary, nil
+}
+
+// billingPeriod returns a provider's billing period for a report covering
+// [start, end). The billing cycle is the quota whose latest completed cycle
+// is the longest (a weekly or monthly limit rather than a 5-hour window), and
+// the period runs from the start of its first cycle that completed in
+// [start, end) to the end of its last one, so consecutive reports cover
+// consecutive resets. ok is false when no billing cycle completed in
+// [start, end).
+func billingPeriod(cycles map[string][]store.CycleOverviewRow, start, end time.Time) (from, to time.Time, ok bool) {
+	names := make([]string, 0, len(cycles))
+	for name := range cycles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var billing []store.CycleOverviewRow
+	var longest time.Duration
+	for _, name := range names {
+		rows := cycles[name]
+		var latest *store.CycleOverviewRow
+		for i, row := range rows {
+			if row.CycleEnd == nil || !row.CycleEnd.Before(end) {
+				continue
+			}
+			if latest == nil || row.CycleEnd.After(*latest.CycleEnd) {
+				latest = &rows[i]
+			}
+		}
+		if latest == nil {
+			continue
+		}
+		if d := latest.CycleEnd.Sub(latest.CycleStart); billing == nil || d > longest {
+			billing, longest = rows, d
+		}
+	}
+	for _, row := range billing {
+		if row.CycleEnd == nil || row.CycleEnd.Before(start) || !row.CycleEnd.Before(end) {
+			continue
+		}
+		if !ok || row.CycleStart.Before(from) {
+			from = row.CycleStart
+		}
+		if !ok || row.CycleEnd.After(to) {
+			to = *row.CycleEnd
+		}
+		ok = true
+	}
+	return from, to, ok
+}
+
+// cyclePeakPercent returns the quota's peak utilization in the cycle as a
+// percentage of its limit. ok is false when a raw peak has no known limit.
+func cyclePeakPercent(row store.CycleOverviewRow, quota string, peakIsPercent bool) (float64, bool) {
+	if peakIsPercent {
+		return row.PeakValue, true
+	}
+	for _, cq := range row.CrossQuotas {
+		if cq.Name != quota {
+			continue
+		}
+		if cq.Limit > 0 {
+			return row.PeakValue / cq.Limit * 100, true
+		}
+		return 0, false
+	}
+	return 0, false
+}
+
+// balanceCycle is a completed top-up cycle of a prepaid balance.
+type balanceCycle struct {
+	end   *time.Time
+	spent float64
+}
+
+// balanceSource describes where one prepaid balance's cycles come from.
+type balanceSource struct {
+	provider string
+	label    string
+	unit     string
+	cycles   func() ([]balanceCycle, error)
+}
+
+// balanceSources lists the prepaid balances whose top-up cycles are summarized.
+func balanceSources(s *store.Store) []balanceSource {
+	var sources []balanceSource
+	for _, currency := range deepSeekCurrencies {
+		currency := currency
+		sources = append(sources, balanceSource{
+			provider: "deepseek",
+			label:    "DeepSeek",
+			unit:     currency,
+			cycles: func() ([]balanceCycle, error) {
+				history, err := s.QueryDeepSeekCycleHistory("balance", currency, cycleOverviewLimit)
+				if err != nil {
+					return nil, err
+				}
+				out := make([]balanceCycle, 0, len(history))
+				for _, c := range history {
+					out = append(out, balanceCycle{end: c.CycleEnd, spent: c.TotalDelta})
+				}
+				return out, nil
+			},
+		})
+	}
+	sources = append(sources,
+		balanceSource{
+			provider: "moonshot",
+			label:    "Moonshot",
+			unit:     "USD",
+			cycles: func() ([]balanceCycle, error) {
+				history, err := s.QueryMoonshotCycleHistory("balance", cycleOverviewLimit)
+				if err != nil {
+					return nil, err
+				}
+				out := make([]balanceCycle, 0, len(history))
+				for _, c := range history {
+					out = append(out, balanceCycle{end: c.CycleEnd, spent: c.TotalDelta})
+				}
+				return out, nil
+			},
+		},
+		balanceSource{
+			provider: "openrouter",
+			label:    "OpenRouter",
+			unit:     "USD",
+			cycles: func() ([]balanceCycle, error) {
+				history, err := s.QueryOpenRouterCycleHistory("credits", cycleOverviewLimit)
+				if err != nil {
+					return nil, err
+				}
+				out := make([]balanceCycle, 0, len(history))
+				for _, c := range history {
+					out = append(out, balanceCycle{end: c.CycleEnd, spent: c.TotalDelta})
+				}
+				return out, nil
+			},
+		},
+	)
+	return sources
+}
+
+func buildBalanceSummary(src balanceSource, start, end time.Time) (BalanceSummary, error) {
+	summary := BalanceSummary{Provider: src.provider, Label: src.label, Unit: src.unit}
+	cycles, err := src.cycles()
+	if err != nil {
+		return summary, err
+	}
+	for _, c := range cycles {
+		if c.end == nil || c.end.Before(start) || !c.end.Before(end) {
+			continue
+		}
+		summary.CyclesCompleted++
+		summary.Spent += c.spent
+	}
+	return summary, nil
+}
+
+// Title returns a human-readable report title, e.g. "Weekly usage report".
+func (r *Report) Title() string {
+	if r.Period == "" {
+		return "Usage report"
+	}
+	return strings.ToUpper(r.Period[:1]) + r.Period[1:] + " usage report"
+}
+
+// Subject returns the email subject line for the report.
+func (r *Report) Subject() string {
+	return fmt.Sprintf("[onWatch] %s: %s - %s", r.Title(), r.Start.Format("Jan 2"), r.End.Format("Jan 2, 2006"))
+}
+
+// Text renders the report as plain text.
+func (r *Report) Text() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s\n%s - %s\n", r.Title(), r.Start.Format(time.RFC1123), r.End.Format(time.RFC1123)))
+
+	sb.WriteString("\nQuota cycles\n")
+	if len(r.Providers) == 0 && len(r.Balances) == 0 {
+		sb.WriteString("  No cycles completed in this period.\n")
+	}
+	for _, p := range r.Providers {
+		sb.WriteString(fmt.Sprintf("  %s (%s - %s)\n", p.Label, p.Start.Format("Jan 2 15:04"), p.End.Format("Jan 2 15:04")))
+		for _, q := range p.Quotas {
+			sb.WriteString(fmt.Sprintf("    %s: %d cycles, avg peak %.1f%%, max peak %.1f%%, %d hit 100%%\n",
+				q.Label, q.CyclesCompleted, q.AvgPeakPercent, q.MaxPeakPercent, q.FullWindows))
+		}
+	}
+	for _, b := range r.Balances {
+		sb.WriteString(fmt.Sprintf("  %s balance (%s): %d top-up cycles, %.2f spent\n",
+			b.Label, b.Unit, b.CyclesCompleted, b.Spent))
+	}
+
+	if len(r.Sessions) > 0 {
+		sb.WriteString("\nBiggest sessions by provider\n")
+		for _, s := range r.Sessions {
+			sb.WriteString(fmt.Sprintf("  %s %s: %.1f used over %s\n",
+				s.StartedAt.Format("Jan 2 15:04"), s.Provider, s.Usage, formatDuration(s.Duration)))
+		}
+	}
+
+	if len(r.Integrations) > 0 {
+		sb.WriteString(fmt.Sprintf("\nAPI integrations: $%.2f total%s\n", r.TotalCostUSD, estimatedSuffix(r.EstimatedCostUSD)))
+		for _, c := range r.Integrations {
+			sb.WriteString(fmt.Sprintf("  %s (%s): %d requests, %d tokens, $%.2f%s\n",
+				c.Integration, c.Provider, c.Requests, c.TotalTokens, c.CostUSD, estimatedSuffix(c.EstimatedCostUSD)))
+		}
+	}
+
+	sb.WriteString("\n-- Sent by onWatch")
+	return sb.String()
+}
+
+// estimatedSuffix renders an estimated cost as " + ~$1.23 est.", or nothing
+// when there is none.
+func estimatedSuffix(usd float64) string {
+	if usd <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" + ~$%.2f est.", usd)
+}
+
+// formatDuration renders a duration as "2h 15m" or "45m".
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	if h > 0 {
+		return fmt.Sprintf("%dh %dm", h, m)
+	}
+	return fmt.Sprintf("%dm", m)
+}
@@ -0,0 +1,356 @@
+package report
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/api"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
+)
+
+var (
+	testStart = time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	testEnd   = time.Date(2026, 4, 8, 0, 0, 0, 0, time.UTC)
+)
+
+func newTestStore(t *testing.T) *store.Store {
+	t.Helper()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// seedAnthropicCycles records five-hour cycles ending at the given offsets
+// from testStart with the given peak utilization.
+func seedAnthropicCycles(t *testing.T, s *store.Store, cycles map[time.Duration]float64) {
+	t.Helper()
+	for offset, peak := range cycles {
+		end := testStart.Add(offset)
+		if _, err := s.CreateAnthropicCycle("five_hour", end.Add(-5*time.Hour), &end); err != nil {
+			t.Fatalf("CreateAnthropicCycle: %v", err)
+		}
+		if err := s.CloseAnthropicCycle("five_hour", end, peak, peak); err != nil {
+			t.Fatalf("CloseAnthropicCycle: %v", err)
+		}
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	t.Parallel()
+	end := time.Date(2026, 3, 31, 9, 0, 0, 0, time.UTC)
+
+	weekly, err := PeriodStart(PeriodWeekly, end)
+	if err != nil || !weekly.Equal(end.AddDate(0, 0, -7)) {
+		t.Errorf("weekly start = %v, %v; want %v", weekly, err, end.AddDate(0, 0, -7))
+	}
+	monthly, err := PeriodStart(PeriodMonthly, end)
+	if err != nil || !monthly.Equal(end.AddDate(0, -1, 0)) {
+		t.Errorf("monthly start = %v, %v; want %v", monthly, err, end.AddDate(0, -1, 0))
+	}
+	if _, err := PeriodStart("daily", end); err == nil {
+		t.Error("expected error for unknown period")
+	}
+}
+
+func TestBuild_CycleStatistics(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	seedAnthropicCycles(t, s, map[time.Duration]float64{
+		-2 * time.Hour:  100, // ended before the period
+		10 * time.Hour:  40,
+		30 * time.Hour:  100,
+		50 * time.Hour:  70,
+		200 * time.Hour: 100, // ended after the period
+	})
+
+	r, err := Build(s, PeriodWeekly, testStart, testEnd)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(r.Providers) != 1 || r.Providers[0].Provider != "anthropic" {
+		t.Fatalf("providers = %+v, want only anthropic", r.Providers)
+	}
+	quotas := r.Providers[0].Quotas
+	if len(quotas) != 1 {
+		t.Fatalf("quotas = %+v, want one", quotas)
+	}
+	q := quotas[0]
+	if q.Label != "5-Hour Limit" {
+		t.Errorf("label = %q, want display name", q.Label)
+	}
+	if q.CyclesCompleted != 3 {
+		t.Errorf("cycles = %d, want 3", q.CyclesCompleted)
+	}
+	if math.Abs(q.AvgPeakPercent-70) > 0.01 {
+		t.Errorf("avg peak = %.2f, want 70", q.AvgPeakPercent)
+	}
+	if q.MaxPeakPercent != 100 || q.FullWindows != 1 {
+		t.Errorf("max peak = %.1f, full windows = %d; want 100 and 1", q.MaxPeakPercent, q.FullWindows)
+	}
+}
+
+func TestBuild_UsesProviderBillingPeriod(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	// The weekly cycle resets two days into the report period, so the
+	// provider's billing period is the week before that reset.
+	weekEnd := testStart.Add(48 * time.Hour)
+	weekStart := weekEnd.Add(-7 * 24 * time.Hour)
+	if _, err := s.CreateAnthropicCycle("seven_day", weekStart, &weekEnd); err != nil {
+		t.Fatalf("CreateAnthropicCycle: %v", err)
+	}
+	if err := s.CloseAnthropicCycle("seven_day", weekEnd, 60, 60); err != nil {
+		t.Fatalf("CloseAnthropicCycle: %v", err)
+	}
+	seedAnthropicCycles(t, s, map[time.Duration]float64{
+		-30 * time.Hour: 100, // before the period, inside the billing cycle
+		10 * time.Hour:  40,
+		48 * time.Hour:  80,  // ends with the billing cycle
+		60 * time.Hour:  100, // after the last reset in the period
+	})
+
+	r, err := Build(s, PeriodWeekly, testStart, testEnd)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(r.Providers) != 1 {
+		t.Fatalf("providers = %+v, want only anthropic", r.Providers)
+	}
+	p := r.Providers[0]
+	if !p.Start.Equal(weekStart) || !p.End.Equal(weekEnd) {
+		t.Errorf("billing period = %v - %v, want %v - %v", p.Start, p.End, weekStart, weekEnd)
+	}
+	cycles := map[string]int{}
+	for _, q := range p.Quotas {
+		cycles[q.Name] = q.CyclesCompleted
+	}
+	if cycles["seven_day"] != 1 || cycles["five_hour"] != 3 {
+		t.Errorf("cycles = %v, want seven_day 1 and five_hour 3", cycles)
+	}
+}
+
+func TestBuild_NormalizesRawPeaksToLimit(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+
+	// Synthetic peaks are request counts; the limit comes from the peak snapshot.
+	closeCycle := func(start, end time.Time, peak float64) {
+		t.Helper()
+		if _, err := s.CreateCycle("subscription", start, end); err != nil {
+			t.Fatalf("CreateCycle: %v", err)
+		}
+		if err := s.CloseCycle("subscription", end, peak, peak); err != nil {
+			t.Fatalf("CloseCycle: %v", err)
+		}
+	}
+	snapshot := func(at time.Time, requests float64) {
+		t.Helper()
+		snap := &api.Snapshot{CapturedAt: at, Sub: api.QuotaInfo{Limit: 1350, Requests: requests, RenewsAt: at.Add(time.Hour)}}
+		if _, err := s.InsertSnapshot(snap); err != nil {
+			t.Fatalf("InsertSnapshot: %v", err)
+		}
+	}
+	// The tracker saw the full 1350 although the last stored snapshot read 1000.
+	snapshot(testStart.Add(time.Hour), 1000)
+	closeCycle(testStart, testStart.Add(5*time.Hour), 1350)
+	snapshot(testStart.Add(6*time.Hour), 675)
+	closeCycle(testStart.Add(5*time.Hour), testStart.Add(10*time.Hour), 675)
+	// No snapshot: the limit is unknown, so the cycle has no peak percentage.
+	closeCycle(testStart.Add(10*time.Hour), testStart.Add(15*time.Hour), 900)
+
+	r, err := Build(s, PeriodWeekly, testStart, testEnd)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(r.Providers) != 1 || r.Providers[0].Provider != "synthetic" {
+		t.Fatalf("providers = %+v, want only synthetic", r.Providers)
+	}
+	q := r.Providers[0].Quotas[0]
+	if q.CyclesCompleted != 3 {
+		t.Errorf("cycles = %d, want 3", q.CyclesCompleted)
+	}
+	if q.MaxPeakPercent != 100 || q.FullWindows != 1 {
+		t.Errorf("max peak = %.1f, full windows = %d; want 100 and 1", q.MaxPeakPercent, q.FullWindows)
+	}
+	if math.Abs(q.AvgPeakPercent-75) > 0.01 {
+		t.Errorf("avg peak = %.2f, want 75 over the two cycles with a known limit", q.AvgPeakPercent)
+	}
+}
+
+func TestBuild_CoversUtilizationAndBalanceCycles(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+
+	end := testStart.Add(24 * time.Hour)
+	if _, err := s.InsertGrokResetCycle(&store.GrokResetCycle{
+		QuotaName: "queries", CycleStart: testStart, CycleEnd: &end, PeakUtilization: 100,
+	}); err != nil {
+		t.Fatalf("InsertGrokResetCycle: %v", err)
+	}
+	if _, err := s.InsertKimiResetCycle(&store.KimiResetCycle{
+		QuotaName: "weekly", CycleStart: testStart, CycleEnd: &end, PeakUtilization: 42,
+	}); err != nil {
+		t.Fatalf("InsertKimiResetCycle: %v", err)
+	}
+	for i, spent := range []float64{3.5, 1.5} {
+		at := testStart.Add(time.Duration(i+1) * 24 * time.Hour)
+		if _, err := s.CreateDeepSeekCycle("balance", "CNY", at.Add(-24*time.Hour)); err != nil {
+			t.Fatalf("CreateDeepSeekCycle: %v", err)
+		}
+		if err := s.CloseDeepSeekCycle("balance", "CNY", at, 20, spent); err != nil {
+			t.Fatalf("CloseDeepSeekCycle: %v", err)
+		}
+	}
+
+	r, err := Build(s, PeriodWeekly, testStart, testEnd)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	peaks := map[string]float64{}
+	for _, p := range r.Providers {
+		for _, q := range p.Quotas {
+			peaks[p.Provider+"/"+q.Name] = q.MaxPeakPercent
+		}
+	}
+	if peaks["grok/queries"] != 100 || peaks["kimi/weekly"] != 42 {
+		t.Errorf("peaks = %v, want grok/queries 100 and kimi/weekly 42", peaks)
+	}
+	if len(r.Balances) != 1 {
+		t.Fatalf("balances = %+v, want DeepSeek CNY only", r.Balances)
+	}
+	if b := r.Balances[0]; b.Provider != "deepseek" || b.Unit != "CNY" || b.CyclesCompleted != 2 || b.Spent != 5 {
+		t.Errorf("balance = %+v, want deepseek CNY with 2 cycles and 5 spent", b)
+	}
+	if text := r.Text(); !strings.Contains(text, "DeepSeek balance (CNY): 2 top-up cycles, 5.00 spent") {
+		t.Errorf("text missing balance line:\n%s", text)
+	}
+}
+
+func TestBuild_SessionsAndIntegrations(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+
+	if err := s.CreateSession("sess-1", testStart.Add(time.Hour), 60, "synthetic", 10); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if err := s.UpdateSessionMaxRequests("sess-1", 60, 0, 0); err != nil {
+		t.Fatalf("UpdateSessionMaxRequests: %v", err)
+	}
+	if err := s.CloseSession("sess-1", testStart.Add(3*time.Hour)); err != nil {
+		t.Fatalf("CloseSession: %v", err)
+	}
+	// Sessions without consumption are not worth listing.
+	if err := s.CreateSession("idle", testStart.Add(2*time.Hour), 60, "zai"); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	cost := 1.25
+	for i, at := range []time.Time{testStart.Add(time.Hour), testStart.Add(2 * time.Hour), testStart.Add(-time.Hour)} {
+		event := &usageevent.Event{
+			Timestamp:   at,
+			Integration: "notes",
+			Provider:    "anthropic",
+			Model:       "claude-sonnet",
+			TotalTokens: 100,
+			CostUSD:     &cost,
+			SourcePath:  "/tmp/notes.jsonl",
+			Fingerprint: string(rune('a' + i)),
+		}
+		if _, err := s.InsertAPIIntegrationUsageEvent(event); err != nil {
+			t.Fatalf("InsertAPIIntegrationUsageEvent: %v", err)
+		}
+	}
+
+	r, err := Build(s, PeriodWeekly, testStart, testEnd)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(r.Sessions) != 1 {
+		t.Fatalf("sessions = %+v, want one", r.Sessions)
+	}
+	if sess := r.Sessions[0]; sess.Usage != 50 || sess.Duration != 2*time.Hour || sess.Provider != "synthetic" {
+		t.Errorf("session = %+v, want synthetic usage 50 over 2h", sess)
+	}
+	if len(r.Integrations) != 1 || r.Integrations[0].Requests != 2 {
+		t.Fatalf("integrations = %+v, want notes with 2 requests", r.Integrations)
+	}
+	if r.TotalCostUSD != 2.5 {
+		t.Errorf("total cost = %.2f, want 2.50", r.TotalCostUSD)
+	}
+}
+
+func TestReport_TextAndHTML(t *testing.T) {
+	t.Parallel()
+	r := &Report{
+		Period:      PeriodMonthly,
+		Start:       testStart,
+		End:         testEnd,
+		GeneratedAt: testEnd,
+		Providers: []ProviderSummary{{
+			Provider: "anthropic",
+			Label:    "Anthropic",
+			Quotas: []QuotaSummary{{
+				Name: "five_hour", Label: "5-Hour Limit",
+				CyclesCompleted: 3, AvgPeakPercent: 70, MaxPeakPercent: 100, FullWindows: 1,
+			}},
+		}},
+		Sessions: []SessionSummary{{Provider: "synthetic", StartedAt: testStart, Duration: 135 * time.Minute, Usage: 50}},
+		Integrations: []IntegrationCost{
+			{Integration: "notes <beta>", Provider: "anthropic", Requests: 2, TotalTokens: 200, CostUSD: 2.5},
+			{Integration: "bot", Provider: "openai", Requests: 1, TotalTokens: 100, EstimatedCostUSD: 1.25},
+		},
+		TotalCostUSD:     2.5,
+		EstimatedCostUSD: 1.25,
+	}
+
+	if got := r.Subject(); got != "[onWatch] Monthly usage report: Apr 1 - Apr 8, 2026" {
+		t.Errorf("subject = %q", got)
+	}
+
+	text := r.Text()
+	for _, want := range []string{
+		"Monthly usage report",
+		"5-Hour Limit: 3 cycles, avg peak 70.0%, max peak 100.0%, 1 hit 100%",
+		"synthetic: 50.0 used over 2h 15m",
+		"API integrations: $2.50 total + ~$1.25 est.",
+		"bot (openai): 1 requests, 100 tokens, $0.00 + ~$1.25 est.",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text missing %q:\n%s", want, text)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := r.RenderHTML(&buf); err != nil {
+		t.Fatalf("RenderHTML: %v", err)
+	}
+	html := buf.String()
+	for _, want := range []string{"Monthly usage report", "5-Hour Limit", "70.0%", "2h 15m", "$2.50", "notes &lt;beta&gt;", "+ ~$1.25 est."} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML missing %q", want)
+		}
+	}
+}
+
+func TestReport_HTMLWithoutData(t *testing.T) {
+	t.Parallel()
+	r := &Report{Period: PeriodWeekly, Start: testStart, End: testEnd, GeneratedAt: testEnd}
+	var buf bytes.Buffer
+	if err := r.RenderHTML(&buf); err != nil {
+		t.Fatalf("RenderHTML: %v", err)
+	}
+	html := buf.String()
+	if !strings.Contains(html, "No cycles completed in this period.") {
+		t.Error("expected empty-state message")
+	}
+	if strings.Contains(html, "Biggest sessions") || strings.Contains(html, "API integrations") {
+		t.Error("empty sections should be omitted")
+	}
+}
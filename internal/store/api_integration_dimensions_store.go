@@ -0,0 +1,188 @@
+package store
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	apiintegrations "github.com/onllm-dev/onwatch/v2/internal/api_integrations"
+)
+
+// apiIntegrationDimensionPrefix prefixes the generated columns that hold
+// promoted metadata dimensions of API integration events.
+const apiIntegrationDimensionPrefix = "dim_"
+
+// APIIntegrationUsageGroupRow is the usage of one combination of dimension
+// values. Values holds one value per requested dimension, in order.
+type APIIntegrationUsageGroupRow struct {
+	Values           []string
+	RequestCount     int
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	TotalCostUSD     float64
+	EstimatedCostUSD float64
+}
+
+// apiIntegrationDimensionColumn returns the generated column of a promoted
+// metadata key. Keys that are not lowercase identifiers get a hash suffix so
+// that distinct keys never share a column.
+func apiIntegrationDimensionColumn(key string) string {
+	var b strings.Builder
+	b.WriteString(apiIntegrationDimensionPrefix)
+	for _, r := range strings.ToLower(key) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	if b.String() != apiIntegrationDimensionPrefix+key {
+		h := fnv.New32a()
+		h.Write([]byte(key))
+		fmt.Fprintf(&b, "_%08x", h.Sum32())
+	}
+	return b.String()
+}
+
+// SyncAPIIntegrationDimensions makes keys the promoted metadata dimensions of
+// API integration events. Each key gets an indexed virtual column extracted
+// from metadata_json, so stored events are covered without a rewrite.
+// Columns of keys that are no longer listed are dropped.
+func (s *Store) SyncAPIIntegrationDimensions(keys []string) error {
+	keys, err := apiintegrations.ParseDimensions(keys)
+	if err != nil {
+		return err
+	}
+	wanted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		wanted[apiIntegrationDimensionColumn(key)] = true
+	}
+	existing, err := s.apiIntegrationDimensionColumns()
+	if err != nil {
+		return err
+	}
+
+	for column := range existing {
+		if wanted[column] {
+			continue
+		}
+		if _, err := s.db.Exec(`DROP INDEX IF EXISTS idx_api_integration_usage_events_` + column); err != nil {
+			return fmt.Errorf("failed to drop index of dimension column %s: %w", column, err)
+		}
+		if _, err := s.db.Exec(`ALTER TABLE api_integration_usage_events DROP COLUMN ` + column); err != nil {
+			return fmt.Errorf("failed to drop dimension column %s: %w", column, err)
+		}
+	}
+	for _, key := range keys {
+		column := apiIntegrationDimensionColumn(key)
+		if !existing[column] {
+			// Keys are limited to [A-Za-z0-9_.-], so they are safe to quote inline.
+			if _, err := s.db.Exec(`ALTER TABLE api_integration_usage_events ADD COLUMN ` + column + ` TEXT
+				GENERATED ALWAYS AS (CASE WHEN json_valid(metadata_json) THEN json_extract(metadata_json, '$."` + key + `"') END) VIRTUAL`); err != nil {
+				return fmt.Errorf("failed to add dimension column for %q: %w", key, err)
+			}
+		}
+		if _, err := s.db.Exec(`CREATE INDEX IF NOT EXISTS idx_api_integration_usage_events_` + column +
+			` ON api_integration_usage_events(` + column + `, captured_at)`); err != nil {
+			return fmt.Errorf("failed to index dimension %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// apiIntegrationDimensionColumns returns the dimension columns of the events table.
+func (s *Store) apiIntegrationDimensionColumns() (map[string]bool, error) {
+	rows, err := s.db.Query(`SELECT name FROM pragma_table_xinfo('api_integration_usage_events') WHERE name LIKE 'dim\_%' ESCAPE '\'`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect dimension columns: %w", err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan dimension column: %w", err)
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+// QueryAPIIntegrationUsageGroups groups usage within [start, end) by the given
+// dimensions, ordered by cost (highest first). A dimension is one of
+// apiintegrations.BuiltinDimensions or a promoted metadata key; events without
+// the key group under an empty value.
+func (s *Store) QueryAPIIntegrationUsageGroups(start, end time.Time, dimensions []string) ([]APIIntegrationUsageGroupRow, error) {
+	if len(dimensions) == 0 {
+		return nil, fmt.Errorf("at least one dimension is required")
+	}
+	var promoted map[string]bool
+	columns := make([]string, len(dimensions))
+	for i, dimension := range dimensions {
+		switch dimension {
+		case "integration":
+			columns[i] = "integration_name"
+		case "account":
+			columns[i] = "account_name"
+		case "provider", "model":
+			columns[i] = dimension
+		default:
+			if promoted == nil {
+				var err error
+				if promoted, err = s.apiIntegrationDimensionColumns(); err != nil {
+					return nil, err
+				}
+			}
+			column := apiIntegrationDimensionColumn(dimension)
+			if !promoted[column] {
+				return nil, fmt.Errorf("unknown dimension %q", dimension)
+			}
+			columns[i] = "COALESCE(CAST(" + column + " AS TEXT), '')"
+		}
+	}
+	groupBy := strings.Join(columns, ", ")
+
+	rows, err := s.db.Query(`
+		SELECT `+groupBy+`,
+		       COUNT(*),
+		       COALESCE(SUM(prompt_tokens), 0),
+		       COALESCE(SUM(completion_tokens), 0),
+		       COALESCE(SUM(total_tokens), 0),
+		       COALESCE(SUM(cost_usd), 0),
+		       COALESCE(SUM(estimated_cost_usd), 0)
+		FROM api_integration_usage_events
+		WHERE captured_at >= ? AND captured_at < ?
+		GROUP BY `+groupBy+`
+		ORDER BY COALESCE(SUM(cost_usd), 0) + COALESCE(SUM(estimated_cost_usd), 0) DESC, COALESCE(SUM(total_tokens), 0) DESC, `+groupBy+`
+		LIMIT ?
+	`, start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano), apiIntegrationUsageSummaryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API integration usage groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []APIIntegrationUsageGroupRow
+	for rows.Next() {
+		row := APIIntegrationUsageGroupRow{Values: make([]string, len(dimensions))}
+		dest := make([]interface{}, 0, len(dimensions)+6)
+		for i := range row.Values {
+			dest = append(dest, &row.Values[i])
+		}
+		dest = append(dest,
+			&row.RequestCount,
+			&row.PromptTokens,
+			&row.CompletionTokens,
+			&row.TotalTokens,
+			&row.TotalCostUSD,
+			&row.EstimatedCostUSD,
+		)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan API integration usage group: %w", err)
+		}
+		groups = append(groups, row)
+	}
+	return groups, rows.Err()
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
+)
+
+func TestStore_QueryAPIIntegrationUsageGroups_PromotedDimensions(t *testing.T) {
+	t.Parallel()
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer s.Close()
+
+	insert := func(lines ...string) {
+		t.Helper()
+		for i, line := range lines {
+			event, err := usageevent.ParseLine([]byte(line), "/tmp/api-integrations/test.jsonl")
+			if err != nil {
+				t.Fatalf("ParseLine(%d): %v", i, err)
+			}
+			if _, err := s.InsertAPIIntegrationUsageEvent(event); err != nil {
+				t.Fatalf("InsertAPIIntegrationUsageEvent(%d): %v", i, err)
+			}
+		}
+	}
+	// Events stored before the dimension is promoted are covered too.
+	insert(
+		`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"openai","model":"gpt-4.1","prompt_tokens":100,"completion_tokens":10,"cost_usd":0.5,"metadata":{"feature":"search","Team":"core"}}`,
+		`{"ts":"2026-04-03T12:01:00Z","integration":"notes","provider":"openai","model":"gpt-4.1-mini","prompt_tokens":10,"completion_tokens":1,"cost_usd":0.25,"metadata":{"feature":"search"}}`,
+	)
+	if err := s.SyncAPIIntegrationDimensions([]string{"feature", "Team", "feature"}); err != nil {
+		t.Fatalf("SyncAPIIntegrationDimensions: %v", err)
+	}
+	insert(
+		`{"ts":"2026-04-03T12:02:00Z","integration":"notes","provider":"openai","model":"gpt-4.1","prompt_tokens":40,"completion_tokens":4,"cost_usd":1,"metadata":{"feature":"summaries"}}`,
+		`{"ts":"2026-04-03T12:03:00Z","integration":"notes","provider":"openai","model":"gpt-4.1","prompt_tokens":5,"completion_tokens":1}`,
+	)
+
+	start := time.Date(2026, 4, 3, 12, 0, 0, 0, time.UTC)
+	groups, err := s.QueryAPIIntegrationUsageGroups(start, start.Add(time.Hour), []string{"feature"})
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationUsageGroups: %v", err)
+	}
+	if len(groups) != 3 {
+		t.Fatalf("len(groups)=%d want 3: %+v", len(groups), groups)
+	}
+	if !reflect.DeepEqual(groups[0].Values, []string{"summaries"}) || groups[0].TotalCostUSD != 1 {
+		t.Fatalf("groups[0]=%+v want summaries first", groups[0])
+	}
+	if search := groups[1]; search.Values[0] != "search" || search.RequestCount != 2 || search.TotalTokens != 121 || search.TotalCostUSD != 0.75 {
+		t.Fatalf("groups[1]=%+v", search)
+	}
+	if untagged := groups[2]; untagged.Values[0] != "" || untagged.RequestCount != 1 {
+		t.Fatalf("groups[2]=%+v want the untagged event", untagged)
+	}
+
+	groups, err = s.QueryAPIIntegrationUsageGroups(start, start.Add(time.Hour), []string{"Team", "model"})
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationUsageGroups(Team, model): %v", err)
+	}
+	if len(groups) != 3 || !reflect.DeepEqual(groups[0].Values, []string{"", "gpt-4.1"}) || groups[0].RequestCount != 2 {
+		t.Fatalf("groups=%+v", groups)
+	}
+
+	// Dropping a dimension removes its column; unknown dimensions are rejected.
+	if err := s.SyncAPIIntegrationDimensions([]string{"Team"}); err != nil {
+		t.Fatalf("SyncAPIIntegrationDimensions(Team): %v", err)
+	}
+	if _, err := s.QueryAPIIntegrationUsageGroups(start, start.Add(time.Hour), []string{"feature"}); err == nil {
+		t.Fatal("expected an error for a dimension that is no longer promoted")
+	}
+	columns, err := s.apiIntegrationDimensionColumns()
+	if err != nil {
+		t.Fatalf("apiIntegrationDimensionColumns: %v", err)
+	}
+	if len(columns) != 1 || !columns[apiIntegrationDimensionColumn("Team")] {
+		t.Fatalf("columns=%v want only Team", columns)
+	}
+	if err := s.SyncAPIIntegrationDimensions([]string{"model"}); err == nil {
+		t.Fatal("expected an error for a built-in dimension")
+	}
+	if err := s.SyncAPIIntegrationDimensions([]string{"a'b"}); err == nil {
+		t.Fatal("expected an error for an invalid key")
+	}
+}
+
+func TestAPIIntegrationDimensionColumn(t *testing.T) {
+	t.Parallel()
+	if got := apiIntegrationDimensionColumn("feature"); got != "dim_feature" {
+		t.Fatalf("column(feature)=%q", got)
+	}
+	seen := make(map[string]string)
+	for _, key := range []string{"team", "Team", "team.name", "team-name", "team_name"} {
+		column := apiIntegrationDimensionColumn(key)
+		if other, ok := seen[column]; ok {
+			t.Fatalf("%q and %q share column %q", key, other, column)
+		}
+		seen[column] = key
+	}
+}
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// APIIntegrationReliabilityRow is the error rate and latency of the calls to
+// one integration, provider and model in a time bucket. Latency percentiles
+// cover the successful calls that reported a latency, LatencyCount of them;
+// they are 0 when there are none.
+type APIIntegrationReliabilityRow struct {
+	IntegrationName string
+	Provider        string
+	Model           string
+	BucketStart     time.Time // zero when the range is not bucketed
+	RequestCount    int
+	ErrorCount      int
+	ErrorTypes      map[string]int // error count by error type; "" when unclassified
+	LatencyCount    int
+	LatencyP50MS    int
+	LatencyP95MS    int
+	LatencyP99MS    int
+}
+
+// ErrorRate returns the share of requests that failed, from 0 to 1.
+func (r APIIntegrationReliabilityRow) ErrorRate() float64 {
+	if r.RequestCount <= 0 {
+		return 0
+	}
+	return float64(r.ErrorCount) / float64(r.RequestCount)
+}
+
+// TopErrorTypes returns the error types of r, most frequent first.
+func (r APIIntegrationReliabilityRow) TopErrorTypes() []string {
+	types := make([]string, 0, len(r.ErrorTypes))
+	for errorType := range r.ErrorTypes {
+		types = append(types, errorType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if r.ErrorTypes[types[i]] != r.ErrorTypes[types[j]] {
+			return r.ErrorTypes[types[i]] > r.ErrorTypes[types[j]]
+		}
+		return types[i] < types[j]
+	})
+	return types
+}
+
+type apiIntegrationReliabilityKey struct {
+	integration, provider, model string
+	bucket                       int64
+}
+
+// QueryAPIIntegrationReliability returns the error rate and nearest-rank
+// p50/p95/p99 latency per integration, provider and model over a range,
+// split into buckets of bucketSize, or over the whole range when bucketSize
+// is 0. Rows are ordered by integration, provider, model and bucket.
+func (s *Store) QueryAPIIntegrationReliability(start, end time.Time, bucketSize time.Duration) ([]APIIntegrationReliabilityRow, error) {
+	if bucketSize < 0 || (bucketSize > 0 && bucketSize < time.Second) {
+		return nil, fmt.Errorf("bucket size must be 0 or at least a second")
+	}
+	bucket := "0"
+	if bucketSize > 0 {
+		seconds := int64(bucketSize / time.Second)
+		bucket = fmt.Sprintf("(CAST(strftime('%%s', captured_at) AS INTEGER) / %d) * %d", seconds, seconds)
+	}
+	startArg, endArg := start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano)
+
+	rows, err := s.db.Query(`
+		SELECT integration_name, provider, model, `+bucket+`,
+		       COUNT(*),
+		       COALESCE(SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END), 0)
+		FROM api_integration_usage_events
+		WHERE captured_at BETWEEN ? AND ?
+		GROUP BY integration_name, provider, model, 4
+		ORDER BY integration_name, provider, model, 4
+		LIMIT ?
+	`, startArg, endArg, apiIntegrationUsageBucketsLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API integration reliability: %w", err)
+	}
+	defer rows.Close()
+
+	var result []APIIntegrationReliabilityRow
+	index := make(map[apiIntegrationReliabilityKey]int)
+	for rows.Next() {
+		var row APIIntegrationReliabilityRow
+		var key apiIntegrationReliabilityKey
+		if err := rows.Scan(&key.integration, &key.provider, &key.model, &key.bucket, &row.RequestCount, &row.ErrorCount); err != nil {
+			return nil, fmt.Errorf("failed to scan API integration reliability: %w", err)
+		}
+		row.IntegrationName, row.Provider, row.Model = key.integration, key.provider, key.model
+		if bucketSize > 0 {
+			row.BucketStart = time.Unix(key.bucket, 0).UTC()
+		}
+		index[key] = len(result)
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// The p-th percentile is the latency at rank ceil(p*n/100) in ascending
+	// order, the smallest latency whose row number reaches that rank.
+	rows, err = s.db.Query(`
+		WITH ranked AS (
+			SELECT integration_name, provider, model, `+bucket+` AS bucket, latency_ms,
+			       ROW_NUMBER() OVER (PARTITION BY integration_name, provider, model, `+bucket+` ORDER BY latency_ms) AS rn,
+			       COUNT(*) OVER (PARTITION BY integration_name, provider, model, `+bucket+`) AS n
+			FROM api_integration_usage_events
+			WHERE captured_at BETWEEN ? AND ? AND status != 'error' AND latency_ms IS NOT NULL
+		)
+		SELECT integration_name, provider, model, bucket, MAX(n),
+		       MIN(CASE WHEN rn >= (n * 50 + 99) / 100 THEN latency_ms END),
+		       MIN(CASE WHEN rn >= (n * 95 + 99) / 100 THEN latency_ms END),
+		       MIN(CASE WHEN rn >= (n * 99 + 99) / 100 THEN latency_ms END)
+		FROM ranked
+		GROUP BY integration_name, provider, model, bucket
+	`, startArg, endArg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API integration latency percentiles: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key apiIntegrationReliabilityKey
+		var count, p50, p95, p99 int
+		if err := rows.Scan(&key.integration, &key.provider, &key.model, &key.bucket, &count, &p50, &p95, &p99); err != nil {
+			return nil, fmt.Errorf("failed to scan API integration latency percentiles: %w", err)
+		}
+		if i, ok := index[key]; ok {
+			row := &result[i]
+			row.LatencyCount, row.LatencyP50MS, row.LatencyP95MS, row.LatencyP99MS = count, p50, p95, p99
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	rows, err = s.db.Query(`
+		SELECT integration_name, provider, model, `+bucket+`, error_type, COUNT(*)
+		FROM api_integration_usage_events
+		WHERE captured_at BETWEEN ? AND ? AND status = 'error'
+		GROUP BY integration_name, provider, model, 4, error_type
+	`, startArg, endArg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API integration error types: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key apiIntegrationReliabilityKey
+		var errorType string
+		var count int
+		if err := rows.Scan(&key.integration, &key.provider, &key.model, &key.bucket, &errorType, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan API integration error types: %w", err)
+		}
+		if i, ok := index[key]; ok {
+			row := &result[i]
+			if row.ErrorTypes == nil {
+				row.ErrorTypes = make(map[string]int)
+			}
+			row.ErrorTypes[errorType] = count
+		}
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
+)
+
+func TestStore_QueryAPIIntegrationReliability(t *testing.T) {
+	t.Parallel()
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer s.Close()
+
+	insert := func(line string) {
+		t.Helper()
+		event, err := usageevent.ParseLine([]byte(line), "/tmp/api-integrations/test.jsonl")
+		if err != nil {
+			t.Fatalf("ParseLine: %v", err)
+		}
+		if _, err := s.InsertAPIIntegrationUsageEvent(event); err != nil {
+			t.Fatalf("InsertAPIIntegrationUsageEvent: %v", err)
+		}
+	}
+	// 20 successful gpt-4.1 calls at 100, 200, ... 2000 ms in the first hour.
+	for i := 1; i <= 20; i++ {
+		insert(fmt.Sprintf(`{"ts":"2026-04-03T12:%02d:00Z","integration":"notes","provider":"openai","model":"gpt-4.1","prompt_tokens":1,"completion_tokens":1,"latency_ms":%d}`, i, i*100))
+	}
+	// Failures count towards the error rate but not the latency.
+	insert(`{"ts":"2026-04-03T12:30:00Z","integration":"notes","provider":"openai","model":"gpt-4.1","prompt_tokens":0,"completion_tokens":0,"latency_ms":30000,"status":"error","error_type":"timeout"}`)
+	insert(`{"ts":"2026-04-03T12:31:00Z","integration":"notes","provider":"openai","model":"gpt-4.1","prompt_tokens":0,"completion_tokens":0,"latency_ms":5,"error_type":"rate_limit"}`)
+	insert(`{"ts":"2026-04-03T12:32:00Z","integration":"notes","provider":"openai","model":"gpt-4.1","prompt_tokens":0,"completion_tokens":0,"error_type":"rate_limit"}`)
+	insert(`{"ts":"2026-04-03T12:33:00Z","integration":"notes","provider":"openai","model":"gpt-4.1","prompt_tokens":0,"completion_tokens":0,"status":"error"}`)
+	// One call in the next hour, and one without a latency.
+	insert(`{"ts":"2026-04-03T13:10:00Z","integration":"notes","provider":"openai","model":"gpt-4.1","prompt_tokens":1,"completion_tokens":1,"latency_ms":900}`)
+	insert(`{"ts":"2026-04-03T13:20:00Z","integration":"notes","provider":"anthropic","model":"claude-sonnet-4","prompt_tokens":1,"completion_tokens":1}`)
+
+	start := time.Date(2026, 4, 3, 12, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	rows, err := s.QueryAPIIntegrationReliability(start, end, 0)
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationReliability: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("rows=%+v want 2", rows)
+	}
+	claude, gpt := rows[0], rows[1]
+	if claude.Provider != "anthropic" || claude.RequestCount != 1 || claude.LatencyCount != 0 || claude.LatencyP95MS != 0 || claude.ErrorRate() != 0 {
+		t.Fatalf("claude=%+v", claude)
+	}
+	if gpt.RequestCount != 25 || gpt.ErrorCount != 4 || gpt.ErrorRate() != 0.16 || !gpt.BucketStart.IsZero() {
+		t.Fatalf("gpt=%+v", gpt)
+	}
+	// 21 latencies: 100..2000 and 900. Nearest ranks 11, 20 and 21.
+	if gpt.LatencyCount != 21 || gpt.LatencyP50MS != 1000 || gpt.LatencyP95MS != 1900 || gpt.LatencyP99MS != 2000 {
+		t.Fatalf("latency n=%d p50=%d p95=%d p99=%d", gpt.LatencyCount, gpt.LatencyP50MS, gpt.LatencyP95MS, gpt.LatencyP99MS)
+	}
+	if want := map[string]int{"timeout": 1, "rate_limit": 2, "": 1}; !reflect.DeepEqual(gpt.ErrorTypes, want) {
+		t.Fatalf("error types=%v want %v", gpt.ErrorTypes, want)
+	}
+	if got := gpt.TopErrorTypes(); !reflect.DeepEqual(got, []string{"rate_limit", "", "timeout"}) {
+		t.Fatalf("TopErrorTypes=%q", got)
+	}
+
+	rows, err = s.QueryAPIIntegrationReliability(start, end, time.Hour)
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationReliability(hourly): %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("hourly rows=%+v want 3", rows)
+	}
+	first, second := rows[1], rows[2]
+	if !first.BucketStart.Equal(start) || first.RequestCount != 24 || first.LatencyCount != 20 || first.LatencyP50MS != 1000 || first.LatencyP95MS != 1900 {
+		t.Fatalf("first hour=%+v", first)
+	}
+	if !second.BucketStart.Equal(start.Add(time.Hour)) || second.RequestCount != 1 || second.ErrorCount != 0 || second.LatencyP99MS != 900 {
+		t.Fatalf("second hour=%+v", second)
+	}
+
+	// The same range given in a local time zone covers the same events.
+	local := time.FixedZone("UTC-5", -5*3600)
+	rows, err = s.QueryAPIIntegrationReliability(start.In(local), end.In(local), 0)
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationReliability(local): %v", err)
+	}
+	if len(rows) != 2 || rows[1].RequestCount != 25 || rows[1].LatencyCount != 21 {
+		t.Fatalf("local rows=%+v want the same 2 groups", rows)
+	}
+
+	if _, err := s.QueryAPIIntegrationReliability(start, end, time.Millisecond); err == nil {
+		t.Fatal("expected an error for a sub-second bucket")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	apiintegrations "github.com/onllm-dev/onwatch/v2/internal/api_integrations"
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
 	sqlite "modernc.org/sqlite"
 	sqlite3 "modernc.org/sqlite/lib"
 )
@@ -16,6 +17,48 @@ const (
 	apiIntegrationUsageBucketsLimit = 5000
 )
 
+// APIIntegrationTokenDetails sums the optional token details of a group of
+// events. CachePromptTokens counts the prompt tokens of the events that
+// reported cache usage, the base for a cache hit ratio.
+type APIIntegrationTokenDetails struct {
+	CacheReadTokens   int
+	CacheWriteTokens  int
+	ReasoningTokens   int
+	AudioTokens       int
+	CachePromptTokens int
+}
+
+// Add adds other's token details to d.
+func (d *APIIntegrationTokenDetails) Add(other APIIntegrationTokenDetails) {
+	d.CacheReadTokens += other.CacheReadTokens
+	d.CacheWriteTokens += other.CacheWriteTokens
+	d.ReasoningTokens += other.ReasoningTokens
+	d.AudioTokens += other.AudioTokens
+	d.CachePromptTokens += other.CachePromptTokens
+}
+
+// CacheHitRatio returns the share of prompt tokens read from the cache, for
+// events that reported cache usage. ok is false when none did.
+func (d APIIntegrationTokenDetails) CacheHitRatio() (ratio float64, ok bool) {
+	if d.CachePromptTokens <= 0 {
+		return 0, false
+	}
+	return float64(d.CacheReadTokens) / float64(d.CachePromptTokens), true
+}
+
+// apiIntegrationTokenDetailColumns selects the APIIntegrationTokenDetails
+// sums, in field order.
+const apiIntegrationTokenDetailColumns = `
+		       COALESCE(SUM(cache_read_tokens), 0),
+		       COALESCE(SUM(cache_write_tokens), 0),
+		       COALESCE(SUM(reasoning_tokens), 0),
+		       COALESCE(SUM(audio_tokens), 0),
+		       COALESCE(SUM(CASE WHEN cache_read_tokens IS NOT NULL OR cache_write_tokens IS NOT NULL THEN prompt_tokens END), 0)`
+
+func (d *APIIntegrationTokenDetails) scanDest() []interface{} {
+	return []interface{}{&d.CacheReadTokens, &d.CacheWriteTokens, &d.ReasoningTokens, &d.AudioTokens, &d.CachePromptTokens}
+}
+
 // APIIntegrationUsageSummaryRow contains grouped usage totals for backend reporting.
 type APIIntegrationUsageSummaryRow struct {
 	IntegrationName  string
@@ -27,7 +70,9 @@ type APIIntegrationUsageSummaryRow struct {
 	CompletionTokens int
 	TotalTokens      int
 	TotalCostUSD     float64
+	EstimatedCostUSD float64 // estimated cost of the events without a reported cost
 	LastCapturedAt   time.Time
+	APIIntegrationTokenDetails
 }
 
 // APIIntegrationUsageBucketRow contains aggregated usage for one integration and time bucket.
@@ -39,30 +84,44 @@ type APIIntegrationUsageBucketRow struct {
 	CompletionTokens int
 	TotalTokens      int
 	TotalCostUSD     float64
+	EstimatedCostUSD float64
+	APIIntegrationTokenDetails
 }
 
 // APIIntegrationIngestHealthRow contains persisted ingest state with last seen event time.
 type APIIntegrationIngestHealthRow struct {
 	SourcePath     string
+	LogicalPath    string
 	OffsetBytes    int64
 	FileSize       int64
 	FileModTime    *time.Time
 	PartialLine    string
+	Rotations      int
+	LastRotatedAt  *time.Time
 	UpdatedAt      time.Time
 	LastCapturedAt *time.Time
 }
 
 // InsertAPIIntegrationUsageEvent stores a normalized API integrations telemetry event.
-func (s *Store) InsertAPIIntegrationUsageEvent(event *apiintegrations.UsageEvent) (int64, error) {
+func (s *Store) InsertAPIIntegrationUsageEvent(event *usageevent.Event) (int64, error) {
 	if event == nil {
 		return 0, fmt.Errorf("API integration usage event is nil")
 	}
+	if event.CostUSD == nil && event.EstimatedCostUSD == nil {
+		event.EstimatedCostUSD = s.estimateAPIIntegrationCost(event)
+	}
+	status := event.Status
+	if status == "" {
+		status = usageevent.StatusOK
+	}
 	res, err := s.db.Exec(`
 		INSERT INTO api_integration_usage_events (
 			captured_at, integration_name, provider, account_name, model, request_id,
-			prompt_tokens, completion_tokens, total_tokens, cost_usd, latency_ms,
+			prompt_tokens, completion_tokens, total_tokens,
+			cache_read_tokens, cache_write_tokens, reasoning_tokens, audio_tokens,
+			cost_usd, estimated_cost_usd, latency_ms, status, error_type,
 			metadata_json, source_path, fingerprint, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		event.Timestamp.Format(time.RFC3339Nano),
 		event.Integration,
@@ -73,8 +132,15 @@ func (s *Store) InsertAPIIntegrationUsageEvent(event *apiintegrations.UsageEvent
 		event.PromptTokens,
 		event.CompletionTokens,
 		event.TotalTokens,
+		event.CacheReadTokens,
+		event.CacheWriteTokens,
+		event.ReasoningTokens,
+		event.AudioTokens,
 		event.CostUSD,
+		event.EstimatedCostUSD,
 		event.LatencyMS,
+		status,
+		event.ErrorType,
 		event.MetadataJSON,
 		event.SourcePath,
 		event.Fingerprint,
@@ -93,11 +159,110 @@ func (s *Store) InsertAPIIntegrationUsageEvent(event *apiintegrations.UsageEvent
 	return id, nil
 }
 
+// APIIntegrationCostEstimator prices usage events that report no cost.
+type APIIntegrationCostEstimator interface {
+	EstimateCost(event *usageevent.Event) (float64, bool)
+}
+
+// apiIntegrationReestimateBatch is how many events ReestimateAPIIntegrationCosts
+// reads at a time.
+const apiIntegrationReestimateBatch = 5000
+
+// SetAPIIntegrationCostEstimator sets the estimator used for events inserted
+// from now on. Call ReestimateAPIIntegrationCosts to apply it to stored ones.
+func (s *Store) SetAPIIntegrationCostEstimator(estimator APIIntegrationCostEstimator) {
+	s.estimatorMu.Lock()
+	defer s.estimatorMu.Unlock()
+	s.costEstimator = estimator
+}
+
+func (s *Store) estimateAPIIntegrationCost(event *usageevent.Event) *float64 {
+	s.estimatorMu.RLock()
+	estimator := s.costEstimator
+	s.estimatorMu.RUnlock()
+	if estimator == nil {
+		return nil
+	}
+	cost, ok := estimator.EstimateCost(event)
+	if !ok {
+		return nil
+	}
+	return &cost
+}
+
+// ReestimateAPIIntegrationCosts recomputes the estimated cost of every stored
+// event without a reported cost, for example after prices change. It returns
+// the number of events that have an estimate.
+func (s *Store) ReestimateAPIIntegrationCosts() (int, error) {
+	type pending struct {
+		id       int64
+		estimate *float64
+	}
+	var lastID int64
+	estimated := 0
+	for {
+		rows, err := s.db.Query(`
+			SELECT id, captured_at, provider, model, prompt_tokens, completion_tokens, total_tokens,
+			       cache_read_tokens, cache_write_tokens
+			FROM api_integration_usage_events
+			WHERE cost_usd IS NULL AND id > ?
+			ORDER BY id
+			LIMIT ?
+		`, lastID, apiIntegrationReestimateBatch)
+		if err != nil {
+			return estimated, fmt.Errorf("failed to query API integration events to estimate: %w", err)
+		}
+		var batch []pending
+		for rows.Next() {
+			var event usageevent.Event
+			var id int64
+			var capturedAt string
+			var cacheRead, cacheWrite sql.NullInt64
+			if err := rows.Scan(&id, &capturedAt, &event.Provider, &event.Model, &event.PromptTokens, &event.CompletionTokens, &event.TotalTokens, &cacheRead, &cacheWrite); err != nil {
+				rows.Close()
+				return estimated, fmt.Errorf("failed to scan API integration event to estimate: %w", err)
+			}
+			event.Timestamp, _ = time.Parse(time.RFC3339Nano, capturedAt)
+			event.CacheReadTokens = nullIntPtr(cacheRead)
+			event.CacheWriteTokens = nullIntPtr(cacheWrite)
+			batch = append(batch, pending{id: id, estimate: s.estimateAPIIntegrationCost(&event)})
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return estimated, fmt.Errorf("failed to read API integration events to estimate: %w", err)
+		}
+		if len(batch) == 0 {
+			return estimated, nil
+		}
+
+		tx, err := s.db.Begin()
+		if err != nil {
+			return estimated, fmt.Errorf("failed to begin API integration cost estimate: %w", err)
+		}
+		for _, p := range batch {
+			if _, err := tx.Exec(`UPDATE api_integration_usage_events SET estimated_cost_usd = ? WHERE id = ?`, p.estimate, p.id); err != nil {
+				tx.Rollback()
+				return estimated, fmt.Errorf("failed to update API integration cost estimate: %w", err)
+			}
+			if p.estimate != nil {
+				estimated++
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return estimated, fmt.Errorf("failed to commit API integration cost estimates: %w", err)
+		}
+		lastID = batch[len(batch)-1].id
+	}
+}
+
 // QueryAPIIntegrationUsageRange returns API integration usage events ordered by capture time ascending.
-func (s *Store) QueryAPIIntegrationUsageRange(start, end time.Time, limit ...int) ([]apiintegrations.UsageEvent, error) {
+func (s *Store) QueryAPIIntegrationUsageRange(start, end time.Time, limit ...int) ([]usageevent.Event, error) {
 	query := `
 		SELECT captured_at, integration_name, provider, account_name, model, request_id,
-		       prompt_tokens, completion_tokens, total_tokens, cost_usd, latency_ms,
+		       prompt_tokens, completion_tokens, total_tokens,
+		       cache_read_tokens, cache_write_tokens, reasoning_tokens, audio_tokens,
+		       cost_usd, estimated_cost_usd, latency_ms, status, error_type,
 		       metadata_json, source_path, fingerprint
 		FROM api_integration_usage_events
 		WHERE captured_at BETWEEN ? AND ?
@@ -115,12 +280,13 @@ func (s *Store) QueryAPIIntegrationUsageRange(start, end time.Time, limit ...int
 	}
 	defer rows.Close()
 
-	var events []apiintegrations.UsageEvent
+	var events []usageevent.Event
 	for rows.Next() {
-		var event apiintegrations.UsageEvent
+		var event usageevent.Event
 		var capturedAt string
-		var costUSD sql.NullFloat64
+		var costUSD, estimatedCostUSD sql.NullFloat64
 		var latencyMS sql.NullInt64
+		var cacheRead, cacheWrite, reasoning, audio sql.NullInt64
 		if err := rows.Scan(
 			&capturedAt,
 			&event.Integration,
@@ -131,8 +297,15 @@ func (s *Store) QueryAPIIntegrationUsageRange(start, end time.Time, limit ...int
 			&event.PromptTokens,
 			&event.CompletionTokens,
 			&event.TotalTokens,
+			&cacheRead,
+			&cacheWrite,
+			&reasoning,
+			&audio,
 			&costUSD,
+			&estimatedCostUSD,
 			&latencyMS,
+			&event.Status,
+			&event.ErrorType,
 			&event.MetadataJSON,
 			&event.SourcePath,
 			&event.Fingerprint,
@@ -144,10 +317,18 @@ func (s *Store) QueryAPIIntegrationUsageRange(start, end time.Time, limit ...int
 			v := costUSD.Float64
 			event.CostUSD = &v
 		}
+		if estimatedCostUSD.Valid {
+			v := estimatedCostUSD.Float64
+			event.EstimatedCostUSD = &v
+		}
 		if latencyMS.Valid {
 			v := int(latencyMS.Int64)
 			event.LatencyMS = &v
 		}
+		event.CacheReadTokens = nullIntPtr(cacheRead)
+		event.CacheWriteTokens = nullIntPtr(cacheWrite)
+		event.ReasoningTokens = nullIntPtr(reasoning)
+		event.AudioTokens = nullIntPtr(audio)
 		events = append(events, event)
 	}
 	return events, rows.Err()
@@ -178,7 +359,8 @@ func (s *Store) QueryAPIIntegrationUsageSummary() ([]APIIntegrationUsageSummaryR
 		       COALESCE(SUM(completion_tokens), 0),
 		       COALESCE(SUM(total_tokens), 0),
 		       COALESCE(SUM(cost_usd), 0),
-		       MAX(captured_at)
+		       COALESCE(SUM(estimated_cost_usd), 0),
+		       MAX(captured_at),`+apiIntegrationTokenDetailColumns+`
 		FROM api_integration_usage_events
 		GROUP BY integration_name, provider, account_name, model
 		ORDER BY integration_name, provider, account_name, model
@@ -193,7 +375,7 @@ func (s *Store) QueryAPIIntegrationUsageSummary() ([]APIIntegrationUsageSummaryR
 	for rows.Next() {
 		var row APIIntegrationUsageSummaryRow
 		var lastCapturedAt string
-		if err := rows.Scan(
+		dest := []interface{}{
 			&row.IntegrationName,
 			&row.Provider,
 			&row.AccountName,
@@ -203,8 +385,10 @@ func (s *Store) QueryAPIIntegrationUsageSummary() ([]APIIntegrationUsageSummaryR
 			&row.CompletionTokens,
 			&row.TotalTokens,
 			&row.TotalCostUSD,
+			&row.EstimatedCostUSD,
 			&lastCapturedAt,
-		); err != nil {
+		}
+		if err := rows.Scan(append(dest, row.APIIntegrationTokenDetails.scanDest()...)...); err != nil {
 			return nil, fmt.Errorf("failed to scan API integration usage summary: %w", err)
 		}
 		row.LastCapturedAt, _ = time.Parse(time.RFC3339Nano, lastCapturedAt)
@@ -213,6 +397,94 @@ func (s *Store) QueryAPIIntegrationUsageSummary() ([]APIIntegrationUsageSummaryR
 	return summary, rows.Err()
 }
 
+// QueryAPIIntegrationUsageTotals groups usage within [start, end] by
+// integration and provider, ordered by cost (highest first).
+func (s *Store) QueryAPIIntegrationUsageTotals(start, end time.Time) ([]APIIntegrationUsageSummaryRow, error) {
+	rows, err := s.db.Query(`
+		SELECT integration_name, provider,
+		       COUNT(*),
+		       COALESCE(SUM(prompt_tokens), 0),
+		       COALESCE(SUM(completion_tokens), 0),
+		       COALESCE(SUM(total_tokens), 0),
+		       COALESCE(SUM(cost_usd), 0),
+		       COALESCE(SUM(estimated_cost_usd), 0),
+		       MAX(captured_at)
+		FROM api_integration_usage_events
+		WHERE captured_at BETWEEN ? AND ?
+		GROUP BY integration_name, provider
+		ORDER BY COALESCE(SUM(cost_usd), 0) + COALESCE(SUM(estimated_cost_usd), 0) DESC, integration_name, provider
+		LIMIT ?
+	`, start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano), apiIntegrationUsageSummaryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API integration usage totals: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []APIIntegrationUsageSummaryRow
+	for rows.Next() {
+		var row APIIntegrationUsageSummaryRow
+		var lastCapturedAt string
+		if err := rows.Scan(
+			&row.IntegrationName,
+			&row.Provider,
+			&row.RequestCount,
+			&row.PromptTokens,
+			&row.CompletionTokens,
+			&row.TotalTokens,
+			&row.TotalCostUSD,
+			&row.EstimatedCostUSD,
+			&lastCapturedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan API integration usage totals: %w", err)
+		}
+		row.LastCapturedAt, _ = time.Parse(time.RFC3339Nano, lastCapturedAt)
+		totals = append(totals, row)
+	}
+	return totals, rows.Err()
+}
+
+// QueryAPIIntegrationCostByScope sums reported and estimated cost within
+// [start, end) per value of scope, one of "provider", "integration",
+// "account" or "model". Values are lower-cased, so spellings that differ only
+// in case are summed together. Unlike the usage breakdown it has no row
+// limit, so totals built from it count every event.
+func (s *Store) QueryAPIIntegrationCostByScope(start, end time.Time, scope string) (map[string]float64, error) {
+	var column string
+	switch scope {
+	case "integration":
+		column = "integration_name"
+	case "account":
+		column = "account_name"
+	case "provider", "model":
+		column = scope
+	default:
+		return nil, fmt.Errorf("unknown cost scope %q", scope)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT LOWER(`+column+`),
+		       COALESCE(SUM(cost_usd), 0) + COALESCE(SUM(estimated_cost_usd), 0)
+		FROM api_integration_usage_events
+		WHERE captured_at >= ? AND captured_at < ?
+		GROUP BY LOWER(`+column+`)
+	`, start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API integration cost by %s: %w", scope, err)
+	}
+	defer rows.Close()
+
+	costs := make(map[string]float64)
+	for rows.Next() {
+		var value string
+		var cost float64
+		if err := rows.Scan(&value, &cost); err != nil {
+			return nil, fmt.Errorf("failed to scan API integration cost by %s: %w", scope, err)
+		}
+		costs[value] = cost
+	}
+	return costs, rows.Err()
+}
+
 // QueryAPIIntegrationUsageBuckets groups usage into time buckets over a range.
 func (s *Store) QueryAPIIntegrationUsageBuckets(start, end time.Time, bucketSize time.Duration) ([]APIIntegrationUsageBucketRow, error) {
 	if bucketSize <= 0 {
@@ -227,7 +499,8 @@ func (s *Store) QueryAPIIntegrationUsageBuckets(start, end time.Time, bucketSize
 		       COALESCE(SUM(prompt_tokens), 0),
 		       COALESCE(SUM(completion_tokens), 0),
 		       COALESCE(SUM(total_tokens), 0),
-		       COALESCE(SUM(cost_usd), 0)
+		       COALESCE(SUM(cost_usd), 0),
+		       COALESCE(SUM(estimated_cost_usd), 0),`+apiIntegrationTokenDetailColumns+`
 		FROM api_integration_usage_events
 		WHERE captured_at BETWEEN ? AND ?
 		GROUP BY integration_name, 2
@@ -243,7 +516,7 @@ func (s *Store) QueryAPIIntegrationUsageBuckets(start, end time.Time, bucketSize
 	for rows.Next() {
 		var row APIIntegrationUsageBucketRow
 		var bucketStart string
-		if err := rows.Scan(
+		dest := []interface{}{
 			&row.IntegrationName,
 			&bucketStart,
 			&row.RequestCount,
@@ -251,7 +524,9 @@ func (s *Store) QueryAPIIntegrationUsageBuckets(start, end time.Time, bucketSize
 			&row.CompletionTokens,
 			&row.TotalTokens,
 			&row.TotalCostUSD,
-		); err != nil {
+			&row.EstimatedCostUSD,
+		}
+		if err := rows.Scan(append(dest, row.APIIntegrationTokenDetails.scanDest()...)...); err != nil {
 			return nil, fmt.Errorf("failed to scan API integration usage bucket: %w", err)
 		}
 		row.BucketStart, _ = time.Parse(time.RFC3339Nano, bucketStart)
@@ -263,26 +538,31 @@ func (s *Store) QueryAPIIntegrationUsageBuckets(start, end time.Time, bucketSize
 // GetAPIIntegrationIngestState returns the persisted tail cursor for a source file.
 func (s *Store) GetAPIIntegrationIngestState(sourcePath string) (*apiintegrations.IngestState, error) {
 	var state apiintegrations.IngestState
-	var modTime sql.NullString
-	var partialLineBytes int64
+	var modTime, lastRotatedAt sql.NullString
+	var partialLineBytes, inode int64
 	var updatedAt string
 	err := s.db.QueryRow(`
-		SELECT source_path, offset_bytes, file_size, file_mod_time,
+		SELECT source_path, logical_path, file_inode, offset_bytes, file_size, file_mod_time,
 		       CASE
 		           WHEN length(CAST(partial_line AS BLOB)) > ? THEN ''
 		           ELSE partial_line
 		       END,
 		       length(CAST(partial_line AS BLOB)),
+		       rotations, last_rotated_at,
 		       updated_at
 		FROM api_integration_ingest_state
 		WHERE source_path = ?
 	`, apiintegrations.MaxIngestPartialLineBytes, sourcePath).Scan(
 		&state.SourcePath,
+		&state.LogicalPath,
+		&inode,
 		&state.Offset,
 		&state.FileSize,
 		&modTime,
 		&state.PartialLine,
 		&partialLineBytes,
+		&state.Rotations,
+		&lastRotatedAt,
 		&updatedAt,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
@@ -296,29 +576,87 @@ func (s *Store) GetAPIIntegrationIngestState(sourcePath string) (*apiintegration
 	if modTime.Valid {
 		state.FileModTime, _ = time.Parse(time.RFC3339Nano, modTime.String)
 	}
+	if lastRotatedAt.Valid {
+		state.LastRotatedAt, _ = time.Parse(time.RFC3339Nano, lastRotatedAt.String)
+	}
+	// Inodes are stored as their int64 bit pattern.
+	state.Inode = uint64(inode)
 	state.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
 	return &state, nil
 }
 
+// QueryAPIIntegrationIngestStatesByInode returns the tail cursors last seen
+// on the file with the given inode, ordered by source path.
+func (s *Store) QueryAPIIntegrationIngestStatesByInode(inode uint64) ([]*apiintegrations.IngestState, error) {
+	rows, err := s.db.Query(`
+		SELECT source_path FROM api_integration_ingest_state
+		WHERE file_inode = ?
+		ORDER BY source_path
+	`, int64(inode))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API integration ingest states by inode: %w", err)
+	}
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan API integration ingest state: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	states := make([]*apiintegrations.IngestState, 0, len(paths))
+	for _, path := range paths {
+		state, err := s.GetAPIIntegrationIngestState(path)
+		if err != nil {
+			return nil, err
+		}
+		if state != nil {
+			states = append(states, state)
+		}
+	}
+	return states, nil
+}
+
 // UpsertAPIIntegrationIngestState persists the current tail cursor for a source file.
 func (s *Store) UpsertAPIIntegrationIngestState(state *apiintegrations.IngestState) error {
 	if state == nil {
 		return fmt.Errorf("API integration ingest state is nil")
 	}
-	var modTime interface{}
+	var modTime, lastRotatedAt interface{}
 	if !state.FileModTime.IsZero() {
 		modTime = state.FileModTime.Format(time.RFC3339Nano)
 	}
+	if !state.LastRotatedAt.IsZero() {
+		lastRotatedAt = state.LastRotatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	logicalPath := state.LogicalPath
+	if logicalPath == "" {
+		logicalPath = state.SourcePath
+	}
 	_, err := s.db.Exec(`
-		INSERT INTO api_integration_ingest_state (source_path, offset_bytes, file_size, file_mod_time, partial_line, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO api_integration_ingest_state (
+			source_path, logical_path, file_inode, offset_bytes, file_size, file_mod_time, partial_line,
+			rotations, last_rotated_at, updated_at
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(source_path) DO UPDATE SET
+			logical_path = excluded.logical_path,
+			file_inode = excluded.file_inode,
 			offset_bytes = excluded.offset_bytes,
 			file_size = excluded.file_size,
 			file_mod_time = excluded.file_mod_time,
 			partial_line = excluded.partial_line,
+			rotations = excluded.rotations,
+			last_rotated_at = excluded.last_rotated_at,
 			updated_at = excluded.updated_at
-	`, state.SourcePath, state.Offset, state.FileSize, modTime, state.PartialLine, time.Now().UTC().Format(time.RFC3339Nano))
+	`, state.SourcePath, logicalPath, int64(state.Inode), state.Offset, state.FileSize, modTime, state.PartialLine,
+		state.Rotations, lastRotatedAt, time.Now().UTC().Format(time.RFC3339Nano))
 	if err != nil {
 		return fmt.Errorf("failed to upsert API integration ingest state: %w", err)
 	}
@@ -328,11 +666,13 @@ func (s *Store) UpsertAPIIntegrationIngestState(state *apiintegrations.IngestSta
 // QueryAPIIntegrationIngestHealth returns ingest cursor state plus last event timestamp per file.
 func (s *Store) QueryAPIIntegrationIngestHealth() ([]APIIntegrationIngestHealthRow, error) {
 	rows, err := s.db.Query(`
-		SELECT s.source_path, s.offset_bytes, s.file_size, s.file_mod_time, s.partial_line, s.updated_at,
+		SELECT s.source_path, s.logical_path, s.offset_bytes, s.file_size, s.file_mod_time, s.partial_line,
+		       s.rotations, s.last_rotated_at, s.updated_at,
 		       MAX(e.captured_at) as last_captured_at
 		FROM api_integration_ingest_state s
-		LEFT JOIN api_integration_usage_events e ON e.source_path = s.source_path
-		GROUP BY s.source_path, s.offset_bytes, s.file_size, s.file_mod_time, s.partial_line, s.updated_at
+		LEFT JOIN api_integration_usage_events e
+		       ON e.source_path = CASE WHEN s.logical_path = '' THEN s.source_path ELSE s.logical_path END
+		GROUP BY s.source_path
 		ORDER BY s.source_path
 	`)
 	if err != nil {
@@ -343,15 +683,18 @@ func (s *Store) QueryAPIIntegrationIngestHealth() ([]APIIntegrationIngestHealthR
 	var result []APIIntegrationIngestHealthRow
 	for rows.Next() {
 		var row APIIntegrationIngestHealthRow
-		var fileModTime sql.NullString
+		var fileModTime, lastRotatedAt sql.NullString
 		var updatedAt string
 		var lastCapturedAt sql.NullString
 		if err := rows.Scan(
 			&row.SourcePath,
+			&row.LogicalPath,
 			&row.OffsetBytes,
 			&row.FileSize,
 			&fileModTime,
 			&row.PartialLine,
+			&row.Rotations,
+			&lastRotatedAt,
 			&updatedAt,
 			&lastCapturedAt,
 		); err != nil {
@@ -361,6 +704,13 @@ func (s *Store) QueryAPIIntegrationIngestHealth() ([]APIIntegrationIngestHealthR
 			t, _ := time.Parse(time.RFC3339Nano, fileModTime.String)
 			row.FileModTime = &t
 		}
+		if row.LogicalPath == "" {
+			row.LogicalPath = row.SourcePath
+		}
+		if lastRotatedAt.Valid {
+			t, _ := time.Parse(time.RFC3339Nano, lastRotatedAt.String)
+			row.LastRotatedAt = &t
+		}
 		row.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updatedAt)
 		if lastCapturedAt.Valid {
 			t, _ := time.Parse(time.RFC3339Nano, lastCapturedAt.String)
@@ -404,6 +754,14 @@ func (s *Store) GetActiveSystemAlertsByProvider(provider string, limit int) ([]S
 	return alerts, rows.Err()
 }
 
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
+
 func isSQLiteUniqueConstraintError(err error) bool {
 	var sqliteErr *sqlite.Error
 	if !errors.As(err, &sqliteErr) {
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	apiintegrations "github.com/onllm-dev/onwatch/v2/internal/api_integrations"
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
 )
 
 func TestStore_InsertAPIIntegrationUsageEvent_Dedup(t *testing.T) {
@@ -18,9 +19,9 @@ func TestStore_InsertAPIIntegrationUsageEvent_Dedup(t *testing.T) {
 	}
 	defer s.Close()
 
-	event, err := apiintegrations.ParseUsageEventLine([]byte(`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"anthropic","model":"claude-3-7-sonnet","prompt_tokens":10,"completion_tokens":5}`), "/tmp/api-integrations/notes.jsonl")
+	event, err := usageevent.ParseLine([]byte(`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"anthropic","model":"claude-3-7-sonnet","prompt_tokens":10,"completion_tokens":5}`), "/tmp/api-integrations/notes.jsonl")
 	if err != nil {
-		t.Fatalf("ParseUsageEventLine: %v", err)
+		t.Fatalf("ParseLine: %v", err)
 	}
 
 	if _, err := s.InsertAPIIntegrationUsageEvent(event); err != nil {
@@ -45,9 +46,9 @@ func TestStore_QueryAPIIntegrationUsageSummary(t *testing.T) {
 		`{"ts":"2026-04-03T12:02:00Z","integration":"notes","provider":"mistral","model":"mistral-small-latest","prompt_tokens":4,"completion_tokens":1}`,
 	}
 	for i, line := range lines {
-		event, err := apiintegrations.ParseUsageEventLine([]byte(line), "/tmp/api-integrations/test.jsonl")
+		event, err := usageevent.ParseLine([]byte(line), "/tmp/api-integrations/test.jsonl")
 		if err != nil {
-			t.Fatalf("ParseUsageEventLine(%d): %v", i, err)
+			t.Fatalf("ParseLine(%d): %v", i, err)
 		}
 		if _, err := s.InsertAPIIntegrationUsageEvent(event); err != nil {
 			t.Fatalf("InsertAPIIntegrationUsageEvent(%d): %v", i, err)
@@ -69,6 +70,63 @@ func TestStore_QueryAPIIntegrationUsageSummary(t *testing.T) {
 	}
 }
 
+func TestStore_QueryAPIIntegrationUsageSummary_TokenDetails(t *testing.T) {
+	t.Parallel()
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer s.Close()
+
+	lines := []string{
+		`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"anthropic","model":"claude-sonnet-4","prompt_tokens":100,"completion_tokens":5,"cache_read_tokens":60,"cache_write_tokens":30}`,
+		`{"ts":"2026-04-03T12:01:00Z","integration":"notes","provider":"anthropic","model":"claude-sonnet-4","prompt_tokens":100,"completion_tokens":5,"cache_read_tokens":90,"reasoning_tokens":4}`,
+		`{"ts":"2026-04-03T12:02:00Z","integration":"notes","provider":"anthropic","model":"claude-sonnet-4","prompt_tokens":50,"completion_tokens":5}`,
+	}
+	for i, line := range lines {
+		event, err := usageevent.ParseLine([]byte(line), "/tmp/api-integrations/test.jsonl")
+		if err != nil {
+			t.Fatalf("ParseLine(%d): %v", i, err)
+		}
+		if _, err := s.InsertAPIIntegrationUsageEvent(event); err != nil {
+			t.Fatalf("InsertAPIIntegrationUsageEvent(%d): %v", i, err)
+		}
+	}
+
+	summary, err := s.QueryAPIIntegrationUsageSummary()
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationUsageSummary: %v", err)
+	}
+	if len(summary) != 1 {
+		t.Fatalf("len(summary)=%d want 1", len(summary))
+	}
+	details := summary[0].APIIntegrationTokenDetails
+	want := APIIntegrationTokenDetails{CacheReadTokens: 150, CacheWriteTokens: 30, ReasoningTokens: 4, CachePromptTokens: 200}
+	if details != want {
+		t.Fatalf("details=%+v want %+v", details, want)
+	}
+	if ratio, ok := details.CacheHitRatio(); !ok || ratio != 0.75 {
+		t.Fatalf("CacheHitRatio()=%v, %v want 0.75", ratio, ok)
+	}
+
+	start := time.Date(2026, 4, 3, 12, 0, 0, 0, time.UTC)
+	rows, err := s.QueryAPIIntegrationUsageBuckets(start, start.Add(time.Hour), time.Hour)
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationUsageBuckets: %v", err)
+	}
+	if len(rows) != 1 || rows[0].APIIntegrationTokenDetails != want {
+		t.Fatalf("buckets=%+v", rows)
+	}
+
+	events, err := s.QueryAPIIntegrationUsageRange(start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationUsageRange: %v", err)
+	}
+	if len(events) != 3 || events[0].CacheWriteTokens == nil || *events[0].CacheWriteTokens != 30 || events[2].CacheReadTokens != nil {
+		t.Fatalf("events=%+v", events)
+	}
+}
+
 func TestStore_QueryAPIIntegrationUsageSummary_BoundedAndOrdered(t *testing.T) {
 	t.Parallel()
 	s, err := New(":memory:")
@@ -84,9 +142,9 @@ func TestStore_QueryAPIIntegrationUsageSummary_BoundedAndOrdered(t *testing.T) {
 			base.Add(time.Duration(i)*time.Minute).Format(time.RFC3339),
 			i,
 		)
-		event, err := apiintegrations.ParseUsageEventLine([]byte(line), "/tmp/api-integrations/bounded.jsonl")
+		event, err := usageevent.ParseLine([]byte(line), "/tmp/api-integrations/bounded.jsonl")
 		if err != nil {
-			t.Fatalf("ParseUsageEventLine(%d): %v", i, err)
+			t.Fatalf("ParseLine(%d): %v", i, err)
 		}
 		if _, err := s.InsertAPIIntegrationUsageEvent(event); err != nil {
 			t.Fatalf("InsertAPIIntegrationUsageEvent(%d): %v", i, err)
@@ -117,9 +175,9 @@ func TestStore_QueryAPIIntegrationUsageRange_AndIngestState(t *testing.T) {
 	}
 	defer s.Close()
 
-	event, err := apiintegrations.ParseUsageEventLine([]byte(`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"openai","model":"gpt-4.1-mini","prompt_tokens":7,"completion_tokens":2}`), "/tmp/api-integrations/notes.jsonl")
+	event, err := usageevent.ParseLine([]byte(`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"openai","model":"gpt-4.1-mini","prompt_tokens":7,"completion_tokens":2}`), "/tmp/api-integrations/notes.jsonl")
 	if err != nil {
-		t.Fatalf("ParseUsageEventLine: %v", err)
+		t.Fatalf("ParseLine: %v", err)
 	}
 	if _, err := s.InsertAPIIntegrationUsageEvent(event); err != nil {
 		t.Fatalf("InsertAPIIntegrationUsageEvent: %v", err)
@@ -207,9 +265,9 @@ func TestStore_DeleteAPIIntegrationUsageEventsOlderThan(t *testing.T) {
 		`{"ts":"2026-03-15T12:00:00Z","integration":"notes","provider":"openai","model":"gpt-4.1-mini","prompt_tokens":2,"completion_tokens":2}`,
 	}
 	for i, line := range lines {
-		event, err := apiintegrations.ParseUsageEventLine([]byte(line), "/tmp/api-integrations/retention.jsonl")
+		event, err := usageevent.ParseLine([]byte(line), "/tmp/api-integrations/retention.jsonl")
 		if err != nil {
-			t.Fatalf("ParseUsageEventLine(%d): %v", i, err)
+			t.Fatalf("ParseLine(%d): %v", i, err)
 		}
 		if _, err := s.InsertAPIIntegrationUsageEvent(event); err != nil {
 			t.Fatalf("InsertAPIIntegrationUsageEvent(%d): %v", i, err)
@@ -248,9 +306,9 @@ func TestStore_QueryAPIIntegrationUsageBuckets(t *testing.T) {
 		`{"ts":"2026-04-03T12:08:00Z","integration":"daily-report","provider":"openai","model":"gpt-4.1-mini","prompt_tokens":6,"completion_tokens":2}`,
 	}
 	for i, line := range lines {
-		event, err := apiintegrations.ParseUsageEventLine([]byte(line), "/tmp/api-integrations/test.jsonl")
+		event, err := usageevent.ParseLine([]byte(line), "/tmp/api-integrations/test.jsonl")
 		if err != nil {
-			t.Fatalf("ParseUsageEventLine(%d): %v", i, err)
+			t.Fatalf("ParseLine(%d): %v", i, err)
 		}
 		if _, err := s.InsertAPIIntegrationUsageEvent(event); err != nil {
 			t.Fatalf("InsertAPIIntegrationUsageEvent(%d): %v", i, err)
@@ -296,9 +354,9 @@ func TestStore_QueryAPIIntegrationUsageBuckets_HourlyRange(t *testing.T) {
 		`{"ts":"2026-04-03T13:25:00Z","integration":"report","provider":"openai","model":"gpt-4.1-mini","prompt_tokens":7,"completion_tokens":3}`,
 	}
 	for i, line := range lines {
-		event, err := apiintegrations.ParseUsageEventLine([]byte(line), "/tmp/api-integrations/hourly.jsonl")
+		event, err := usageevent.ParseLine([]byte(line), "/tmp/api-integrations/hourly.jsonl")
 		if err != nil {
-			t.Fatalf("ParseUsageEventLine(%d): %v", i, err)
+			t.Fatalf("ParseLine(%d): %v", i, err)
 		}
 		if _, err := s.InsertAPIIntegrationUsageEvent(event); err != nil {
 			t.Fatalf("InsertAPIIntegrationUsageEvent(%d): %v", i, err)
@@ -340,9 +398,9 @@ func TestStore_QueryAPIIntegrationUsageBuckets_Bounded(t *testing.T) {
 	for i := 0; i < total; i++ {
 		line := fmt.Sprintf(`{"ts":"%s","integration":"integ-%04d","provider":"openai","model":"gpt-4.1-mini","prompt_tokens":1,"completion_tokens":1}`,
 			base.Add(time.Duration(i)*time.Minute).Format(time.RFC3339), i)
-		event, err := apiintegrations.ParseUsageEventLine([]byte(line), "/tmp/bounded.jsonl")
+		event, err := usageevent.ParseLine([]byte(line), "/tmp/bounded.jsonl")
 		if err != nil {
-			t.Fatalf("ParseUsageEventLine(%d): %v", i, err)
+			t.Fatalf("ParseLine(%d): %v", i, err)
 		}
 		if _, err := s.InsertAPIIntegrationUsageEvent(event); err != nil {
 			t.Fatalf("InsertAPIIntegrationUsageEvent(%d): %v", i, err)
@@ -388,9 +446,9 @@ func TestStore_QueryAPIIntegrationIngestHealth_AndAlertsByProvider(t *testing.T)
 		t.Fatalf("UpsertAPIIntegrationIngestState(stateB): %v", err)
 	}
 
-	event, err := apiintegrations.ParseUsageEventLine([]byte(`{"ts":"2026-04-03T12:07:00Z","integration":"notes","provider":"anthropic","model":"claude-3-7-sonnet","prompt_tokens":10,"completion_tokens":5}`), stateA.SourcePath)
+	event, err := usageevent.ParseLine([]byte(`{"ts":"2026-04-03T12:07:00Z","integration":"notes","provider":"anthropic","model":"claude-3-7-sonnet","prompt_tokens":10,"completion_tokens":5}`), stateA.SourcePath)
 	if err != nil {
-		t.Fatalf("ParseUsageEventLine: %v", err)
+		t.Fatalf("ParseLine: %v", err)
 	}
 	if _, err := s.InsertAPIIntegrationUsageEvent(event); err != nil {
 		t.Fatalf("InsertAPIIntegrationUsageEvent: %v", err)
@@ -434,3 +492,173 @@ func TestStore_QueryAPIIntegrationIngestHealth_AndAlertsByProvider(t *testing.T)
 		t.Fatalf("expected parsed alert createdAt, got %+v", alerts[0])
 	}
 }
+
+func TestStore_QueryAPIIntegrationUsageTotals(t *testing.T) {
+	t.Parallel()
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer s.Close()
+
+	lines := []string{
+		`{"ts":"2026-03-30T12:00:00Z","integration":"notes","provider":"anthropic","model":"claude-3-7-sonnet","prompt_tokens":100,"completion_tokens":50,"cost_usd":5}`,
+		`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"anthropic","model":"claude-3-7-sonnet","prompt_tokens":10,"completion_tokens":5,"cost_usd":0.1}`,
+		`{"ts":"2026-04-04T12:00:00Z","integration":"notes","provider":"anthropic","model":"claude-3-7-sonnet","prompt_tokens":2,"completion_tokens":3,"cost_usd":0.2}`,
+		`{"ts":"2026-04-05T12:00:00Z","integration":"crawler","provider":"openai","model":"gpt-4.1","prompt_tokens":4,"completion_tokens":1,"cost_usd":1.5}`,
+	}
+	for i, line := range lines {
+		event, err := usageevent.ParseLine([]byte(line), "/tmp/api-integrations/test.jsonl")
+		if err != nil {
+			t.Fatalf("ParseLine(%d): %v", i, err)
+		}
+		if _, err := s.InsertAPIIntegrationUsageEvent(event); err != nil {
+			t.Fatalf("InsertAPIIntegrationUsageEvent(%d): %v", i, err)
+		}
+	}
+
+	start := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 4, 8, 0, 0, 0, 0, time.UTC)
+	totals, err := s.QueryAPIIntegrationUsageTotals(start, end)
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationUsageTotals: %v", err)
+	}
+	if len(totals) != 2 {
+		t.Fatalf("len(totals)=%d want 2", len(totals))
+	}
+	if totals[0].IntegrationName != "crawler" || totals[0].TotalCostUSD != 1.5 {
+		t.Fatalf("expected most expensive integration first, got %+v", totals[0])
+	}
+	notes := totals[1]
+	if notes.IntegrationName != "notes" || notes.RequestCount != 2 || notes.TotalTokens != 20 {
+		t.Fatalf("notes totals=%+v, want 2 requests and 20 tokens within range", notes)
+	}
+}
+
+type fixedRateEstimator float64
+
+func (r fixedRateEstimator) EstimateCost(event *usageevent.Event) (float64, bool) {
+	if event.Provider != "anthropic" {
+		return 0, false
+	}
+	return float64(r) * float64(event.TotalTokens), true
+}
+
+func TestStore_APIIntegrationEstimatedCosts(t *testing.T) {
+	t.Parallel()
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer s.Close()
+
+	insert := func(line string) {
+		t.Helper()
+		event, err := usageevent.ParseLine([]byte(line), "/tmp/api-integrations/test.jsonl")
+		if err != nil {
+			t.Fatalf("ParseLine: %v", err)
+		}
+		if _, err := s.InsertAPIIntegrationUsageEvent(event); err != nil {
+			t.Fatalf("InsertAPIIntegrationUsageEvent: %v", err)
+		}
+	}
+	insert(`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"anthropic","model":"claude-sonnet-4","prompt_tokens":60,"completion_tokens":40}`)
+	s.SetAPIIntegrationCostEstimator(fixedRateEstimator(0.01))
+	insert(`{"ts":"2026-04-03T12:01:00Z","integration":"notes","provider":"anthropic","model":"claude-sonnet-4","prompt_tokens":100,"completion_tokens":100}`)
+	insert(`{"ts":"2026-04-03T12:02:00Z","integration":"notes","provider":"anthropic","model":"claude-sonnet-4","prompt_tokens":100,"completion_tokens":100,"cost_usd":0.5}`)
+	insert(`{"ts":"2026-04-03T12:03:00Z","integration":"notes","provider":"vllm","model":"qwen3","prompt_tokens":100,"completion_tokens":100}`)
+
+	summary, err := s.QueryAPIIntegrationUsageSummary()
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationUsageSummary: %v", err)
+	}
+	if len(summary) != 2 || summary[0].TotalCostUSD != 0.5 || summary[0].EstimatedCostUSD != 2 || summary[1].EstimatedCostUSD != 0 {
+		t.Fatalf("summary=%+v", summary)
+	}
+
+	n, err := s.ReestimateAPIIntegrationCosts()
+	if err != nil {
+		t.Fatalf("ReestimateAPIIntegrationCosts: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("estimated %d events, want 2", n)
+	}
+	start := time.Date(2026, 4, 3, 12, 0, 0, 0, time.UTC)
+	totals, err := s.QueryAPIIntegrationUsageTotals(start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationUsageTotals: %v", err)
+	}
+	if len(totals) != 2 || totals[0].Provider != "anthropic" || totals[0].EstimatedCostUSD != 3 {
+		t.Fatalf("totals=%+v", totals)
+	}
+	events, err := s.QueryAPIIntegrationUsageRange(start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationUsageRange: %v", err)
+	}
+	if events[0].EstimatedCostUSD == nil || *events[0].EstimatedCostUSD != 1 || events[2].EstimatedCostUSD != nil || events[3].EstimatedCostUSD != nil {
+		t.Fatalf("events=%+v", events)
+	}
+
+	s.SetAPIIntegrationCostEstimator(nil)
+	if n, err := s.ReestimateAPIIntegrationCosts(); err != nil || n != 0 {
+		t.Fatalf("ReestimateAPIIntegrationCosts without estimator = %d, %v", n, err)
+	}
+	buckets, err := s.QueryAPIIntegrationUsageBuckets(start, start.Add(time.Hour), time.Hour)
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationUsageBuckets: %v", err)
+	}
+	if len(buckets) != 1 || buckets[0].EstimatedCostUSD != 0 || buckets[0].TotalCostUSD != 0.5 {
+		t.Fatalf("buckets=%+v", buckets)
+	}
+}
+
+func TestStore_QueryAPIIntegrationCostByScope(t *testing.T) {
+	t.Parallel()
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer s.Close()
+
+	insert := func(line string) {
+		t.Helper()
+		event, err := usageevent.ParseLine([]byte(line), "/tmp/api-integrations/test.jsonl")
+		if err != nil {
+			t.Fatalf("ParseLine: %v", err)
+		}
+		if _, err := s.InsertAPIIntegrationUsageEvent(event); err != nil {
+			t.Fatalf("InsertAPIIntegrationUsageEvent: %v", err)
+		}
+	}
+	// More models than the usage breakdown returns, so a limited query
+	// would miss some of the spend.
+	for i := 0; i < apiIntegrationUsageSummaryLimit+10; i++ {
+		insert(fmt.Sprintf(`{"ts":"2026-04-03T12:00:00Z","integration":"crawler","provider":"openai","model":"model-%d","prompt_tokens":1,"cost_usd":0.5}`, i))
+	}
+	insert(`{"ts":"2026-04-04T12:00:00Z","integration":"Notes","provider":"Anthropic","model":"claude-sonnet-4","prompt_tokens":1,"cost_usd":2}`)
+	insert(`{"ts":"2026-04-04T13:00:00Z","integration":"notes","provider":"anthropic","model":"claude-sonnet-4","prompt_tokens":1,"cost_usd":3}`)
+	insert(`{"ts":"2026-05-01T00:00:00Z","integration":"notes","provider":"anthropic","model":"claude-sonnet-4","prompt_tokens":1,"cost_usd":100}`)
+
+	start := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	byProvider, err := s.QueryAPIIntegrationCostByScope(start, end, "provider")
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationCostByScope(provider): %v", err)
+	}
+	if want := float64(apiIntegrationUsageSummaryLimit+10) * 0.5; byProvider["openai"] != want {
+		t.Fatalf("openai cost=%v want %v from every model", byProvider["openai"], want)
+	}
+	if byProvider["anthropic"] != 5 {
+		t.Fatalf("anthropic cost=%v want 5 across spellings and within range", byProvider["anthropic"])
+	}
+	byIntegration, err := s.QueryAPIIntegrationCostByScope(start, end, "integration")
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationCostByScope(integration): %v", err)
+	}
+	if byIntegration["notes"] != 5 || len(byIntegration) != 2 {
+		t.Fatalf("by integration=%v want notes=5 and crawler", byIntegration)
+	}
+	if _, err := s.QueryAPIIntegrationCostByScope(start, end, "metadata"); err == nil {
+		t.Fatal("expected an error for an unknown scope")
+	}
+}
@@ -355,3 +355,28 @@ func (s *Store) QueryGrokCyclesForQuota(accountID int64, quotaName string, limit
 	}
 	return res, rows.Err()
 }
+
+// QueryGrokCycleQuotaNames returns the distinct quota names with reset cycles for an account.
+func (s *Store) QueryGrokCycleQuotaNames(accountID int64) ([]string, error) {
+	if accountID == 0 {
+		accountID = DefaultGrokAccountID
+	}
+	rows, err := s.db.Query(
+		`SELECT DISTINCT quota_name FROM grok_reset_cycles WHERE account_id = ? ORDER BY quota_name`,
+		accountID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query grok cycle quota names: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/api"
+)
+
+// QuotaHeatmapBucketSize is the width of the UTC buckets QueryQuotaHeatmap
+// sums into. Every time zone offset is a multiple of 15 minutes, so a bucket
+// always falls within one local hour.
+const QuotaHeatmapBucketSize = 15 * time.Minute
+
+// QuotaHeatmapBucket is the utilization a quota gained within one bucket.
+type QuotaHeatmapBucket struct {
+	Name      string
+	Label     string
+	Start     time.Time
+	Samples   int
+	Delta     float64 // sum of the increases since each previous sample
+	Crossings int     // samples that reached the threshold from below
+}
+
+// QueryQuotaHeatmap aggregates a provider's utilization samples between start
+// and end into QuotaHeatmapBucketSize buckets per quota, in SQL. Each sample
+// is compared with the previous one of its quota: increases add to Delta,
+// drops (window resets) are ignored, and so are pairs further apart than
+// maxGap. Buckets are ordered by quota name, then time. accountID applies to
+// multi-account providers. The second return value is false for providers
+// without percentage quotas.
+func (s *Store) QueryQuotaHeatmap(provider string, accountID int64, start, end time.Time, maxGap time.Duration, threshold float64) ([]QuotaHeatmapBucket, bool, error) {
+	samples, args, ok := quotaSamplesQuery(provider, accountID, start, end)
+	if !ok {
+		return nil, false, nil
+	}
+	bucketSeconds := int64(QuotaHeatmapBucketSize / time.Second)
+	args = append(args, bucketSeconds, bucketSeconds, maxGap.Seconds(), maxGap.Seconds(), threshold, threshold)
+
+	rows, err := s.db.Query(`
+		WITH samples(name, at, pct) AS (`+samples+`),
+		deltas AS (
+			SELECT name, at, pct,
+			       LAG(pct) OVER w AS prev_pct,
+			       (julianday(at) - julianday(LAG(at) OVER w)) * 86400 AS gap
+			FROM samples
+			WINDOW w AS (PARTITION BY name ORDER BY at)
+		)
+		SELECT name,
+		       CAST(strftime('%s', at) AS INTEGER) / ? * ? AS bucket,
+		       COUNT(*),
+		       COALESCE(SUM(CASE WHEN gap <= ? AND pct > prev_pct THEN pct - prev_pct END), 0),
+		       COALESCE(SUM(CASE WHEN gap <= ? AND prev_pct < ? AND pct >= ? THEN 1 END), 0)
+		FROM deltas
+		GROUP BY name, bucket
+		ORDER BY name, bucket
+	`, args...)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to query %s heatmap: %w", provider, err)
+	}
+	defer rows.Close()
+
+	var buckets []QuotaHeatmapBucket
+	for rows.Next() {
+		var b QuotaHeatmapBucket
+		var unix int64
+		if err := rows.Scan(&b.Name, &unix, &b.Samples, &b.Delta, &b.Crossings); err != nil {
+			return nil, true, fmt.Errorf("failed to scan %s heatmap: %w", provider, err)
+		}
+		// Hide historical rows for experimental/unknown quota keys.
+		if provider == "anthropic" && !api.IsKnownAnthropicQuota(b.Name) {
+			continue
+		}
+		b.Label = quotaLabel(provider, b.Name)
+		b.Start = time.Unix(unix, 0).UTC()
+		buckets = append(buckets, b)
+	}
+	return buckets, true, rows.Err()
+}
+
+// quotaSamplesQuery returns a query selecting (name, captured_at, percent)
+// for every quota sample of a provider between start and end, with its
+// arguments. It reads the same values as QueryQuotaSeries.
+func quotaSamplesQuery(provider string, accountID int64, start, end time.Time) (string, []interface{}, bool) {
+	from, to := start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano)
+	switch provider {
+	case "synthetic":
+		return `
+			SELECT 'subscription', captured_at, sub_requests / sub_limit * 100 FROM quota_snapshots
+			WHERE sub_limit > 0 AND captured_at BETWEEN ? AND ?
+			UNION ALL
+			SELECT 'search', captured_at, search_requests / search_limit * 100 FROM quota_snapshots
+			WHERE search_limit > 0 AND captured_at BETWEEN ? AND ?
+			UNION ALL
+			SELECT 'toolcall', captured_at, tool_requests / tool_limit * 100 FROM quota_snapshots
+			WHERE tool_limit > 0 AND captured_at BETWEEN ? AND ?`,
+			[]interface{}{from, to, from, to, from, to}, true
+	case "zai":
+		return `
+			SELECT 'tokens', captured_at, CAST(tokens_percentage AS REAL) FROM zai_snapshots
+			WHERE captured_at BETWEEN ? AND ?
+			UNION ALL
+			SELECT 'time', captured_at, time_current_value / time_usage * 100 FROM zai_snapshots
+			WHERE time_usage > 0 AND captured_at BETWEEN ? AND ?`,
+			[]interface{}{from, to, from, to}, true
+	case "anthropic":
+		return `
+			SELECT v.quota_name, s.captured_at, v.utilization
+			FROM anthropic_snapshots s JOIN anthropic_quota_values v ON v.snapshot_id = s.id
+			WHERE s.captured_at BETWEEN ? AND ?`,
+			[]interface{}{from, to}, true
+	case "codex":
+		if accountID == 0 {
+			accountID = DefaultCodexAccountID
+		}
+		return `
+			SELECT v.quota_name, s.captured_at, v.utilization
+			FROM codex_snapshots s JOIN codex_quota_values v ON v.snapshot_id = s.id
+			WHERE s.account_id = ? AND s.captured_at BETWEEN ? AND ?`,
+			[]interface{}{accountID, from, to}, true
+	case "copilot":
+		return `
+			SELECT v.quota_name, s.captured_at, 100 - v.percent_remaining
+			FROM copilot_snapshots s JOIN copilot_quota_values v ON v.snapshot_id = s.id
+			WHERE v.unlimited = 0 AND s.captured_at BETWEEN ? AND ?`,
+			[]interface{}{from, to}, true
+	case "gemini":
+		return `
+			SELECT v.model_id, s.captured_at, v.usage_percent
+			FROM gemini_snapshots s JOIN gemini_quota_values v ON v.snapshot_id = s.id
+			WHERE s.captured_at BETWEEN ? AND ?`,
+			[]interface{}{from, to}, true
+	case "openrouter":
+		return `
+			SELECT 'credits', captured_at, usage / credit_limit * 100 FROM openrouter_snapshots
+			WHERE credit_limit > 0 AND captured_at BETWEEN ? AND ?`,
+			[]interface{}{from, to}, true
+	case "cursor":
+		return `
+			SELECT v.quota_name, s.captured_at, v.utilization
+			FROM cursor_snapshots s JOIN cursor_quota_values v ON v.snapshot_id = s.id
+			WHERE s.captured_at BETWEEN ? AND ?`,
+			[]interface{}{from, to}, true
+	case "grok":
+		if accountID == 0 {
+			accountID = DefaultGrokAccountID
+		}
+		return `
+			SELECT v.quota_name, s.captured_at, v.utilization
+			FROM grok_snapshots s JOIN grok_quota_values v ON v.snapshot_id = s.id
+			WHERE s.account_id = ? AND s.captured_at BETWEEN ? AND ?`,
+			[]interface{}{accountID, from, to}, true
+	case "kimi":
+		if accountID == 0 {
+			accountID = DefaultKimiAccountID
+		}
+		return `
+			SELECT v.quota_name, s.captured_at, v.utilization
+			FROM kimi_snapshots s JOIN kimi_quota_values v ON v.snapshot_id = s.id
+			WHERE s.account_id = ? AND s.captured_at BETWEEN ? AND ?`,
+			[]interface{}{accountID, from, to}, true
+	}
+	return "", nil, false
+}
@@ -0,0 +1,203 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/api"
+)
+
+// QuotaSample is one utilization reading for a quota, in percent of its limit.
+type QuotaSample struct {
+	At      time.Time
+	Percent float64
+}
+
+// QuotaSeries holds the samples of one quota, oldest first.
+type QuotaSeries struct {
+	Name    string
+	Label   string
+	Samples []QuotaSample
+}
+
+// QueryQuotaSeries returns per-quota utilization samples for a provider
+// between start and end, reading at most limit snapshots (the most recent
+// ones in the range). Series are ordered by quota name. accountID applies to
+// multi-account providers. The second return value is false for providers
+// without percentage quotas.
+func (s *Store) QueryQuotaSeries(provider string, accountID int64, start, end time.Time, limit int) ([]QuotaSeries, bool, error) {
+	var order []string
+	byName := map[string]*QuotaSeries{}
+	add := func(name string, at time.Time, percent float64) {
+		series, ok := byName[name]
+		if !ok {
+			series = &QuotaSeries{Name: name, Label: quotaLabel(provider, name)}
+			byName[name] = series
+			order = append(order, name)
+		}
+		series.Samples = append(series.Samples, QuotaSample{At: at, Percent: percent})
+	}
+
+	switch provider {
+	case "synthetic":
+		snaps, err := s.QueryRange(start, end, limit)
+		if err != nil {
+			return nil, true, err
+		}
+		for _, snap := range snaps {
+			for _, q := range []struct {
+				name string
+				info api.QuotaInfo
+			}{
+				{"subscription", snap.Sub},
+				{"search", snap.Search},
+				{"toolcall", snap.ToolCall},
+			} {
+				if q.info.Limit > 0 {
+					add(q.name, snap.CapturedAt, q.info.Requests/q.info.Limit*100)
+				}
+			}
+		}
+	case "zai":
+		snaps, err := s.QueryZaiRange(start, end, limit)
+		if err != nil {
+			return nil, true, err
+		}
+		for _, snap := range snaps {
+			add("tokens", snap.CapturedAt, float64(snap.TokensPercentage))
+			if snap.TimeUsage > 0 {
+				add("time", snap.CapturedAt, snap.TimeCurrentValue/snap.TimeUsage*100)
+			}
+		}
+	case "anthropic":
+		snaps, err := s.QueryAnthropicRange(start, end, limit)
+		if err != nil {
+			return nil, true, err
+		}
+		for _, snap := range snaps {
+			for _, q := range snap.Quotas {
+				add(q.Name, snap.CapturedAt, q.Utilization)
+			}
+		}
+	case "codex":
+		snaps, err := s.QueryCodexRange(accountID, start, end, limit)
+		if err != nil {
+			return nil, true, err
+		}
+		for _, snap := range snaps {
+			for _, q := range snap.Quotas {
+				add(q.Name, snap.CapturedAt, q.Utilization)
+			}
+		}
+	case "copilot":
+		snaps, err := s.QueryCopilotRange(start, end, limit)
+		if err != nil {
+			return nil, true, err
+		}
+		for _, snap := range snaps {
+			for _, q := range snap.Quotas {
+				if !q.Unlimited {
+					add(q.Name, snap.CapturedAt, 100-q.PercentRemaining)
+				}
+			}
+		}
+	case "gemini":
+		snaps, err := s.QueryGeminiRange(start, end, limit)
+		if err != nil {
+			return nil, true, err
+		}
+		for _, snap := range snaps {
+			for _, q := range snap.Quotas {
+				add(q.ModelID, snap.CapturedAt, q.UsagePercent)
+			}
+		}
+	case "openrouter":
+		snaps, err := s.QueryOpenRouterRange(start, end, limit)
+		if err != nil {
+			return nil, true, err
+		}
+		for _, snap := range snaps {
+			if snap.Limit != nil && *snap.Limit > 0 {
+				add("credits", snap.CapturedAt, snap.Usage / *snap.Limit * 100)
+			}
+		}
+	case "cursor":
+		snaps, err := s.QueryCursorRange(start, end, limit)
+		if err != nil {
+			return nil, true, err
+		}
+		for _, snap := range snaps {
+			for _, q := range snap.Quotas {
+				add(q.Name, snap.CapturedAt, q.Utilization)
+			}
+		}
+	case "grok":
+		snaps, err := s.QueryGrokRange(accountID, start, end, limit)
+		if err != nil {
+			return nil, true, err
+		}
+		for _, snap := range snaps {
+			for _, q := range snap.Quotas {
+				add(q.Name, snap.CapturedAt, q.Utilization)
+			}
+		}
+	case "kimi":
+		snaps, err := s.QueryKimiRange(accountID, start, end, limit)
+		if err != nil {
+			return nil, true, err
+		}
+		for _, snap := range snaps {
+			for _, q := range snap.Quotas {
+				add(q.Name, snap.CapturedAt, q.Utilization)
+			}
+		}
+	default:
+		return nil, false, nil
+	}
+
+	sort.Strings(order)
+	out := make([]QuotaSeries, 0, len(order))
+	for _, name := range order {
+		out = append(out, *byName[name])
+	}
+	return out, true, nil
+}
+
+// quotaLabel returns the display name of a provider's quota.
+func quotaLabel(provider, name string) string {
+	switch provider {
+	case "synthetic":
+		switch name {
+		case "subscription":
+			return "Subscription"
+		case "search":
+			return "Search (Hourly)"
+		case "toolcall":
+			return "Tool Call Discounts"
+		}
+	case "zai":
+		switch name {
+		case "tokens":
+			return "Tokens Limit"
+		case "time":
+			return "Time Limit"
+		}
+	case "anthropic":
+		return api.AnthropicDisplayName(name)
+	case "codex":
+		return api.CodexDisplayName(name)
+	case "copilot":
+		return api.CopilotDisplayName(name)
+	case "gemini":
+		return api.GeminiDisplayName(name)
+	case "openrouter":
+		return "Credits"
+	case "cursor":
+		return api.CursorDisplayName(name)
+	case "grok":
+		return api.GrokDisplayName(name)
+	case "kimi":
+		return api.KimiDisplayName(name)
+	}
+	return name
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/api"
+)
+
+func TestStore_QueryQuotaSeries(t *testing.T) {
+	t.Parallel()
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer s.Close()
+
+	now := time.Now().UTC()
+	for i, used := range []float64{20, 50} {
+		snap := &api.Snapshot{
+			CapturedAt: now.Add(-time.Duration(2-i) * time.Hour),
+			Sub:        api.QuotaInfo{Limit: 200, Requests: used * 2},
+			Search:     api.QuotaInfo{Limit: 100, Requests: used},
+		}
+		if _, err := s.InsertSnapshot(snap); err != nil {
+			t.Fatalf("InsertSnapshot: %v", err)
+		}
+	}
+
+	series, supported, err := s.QueryQuotaSeries("synthetic", 1, now.Add(-24*time.Hour), now, 100)
+	if err != nil || !supported {
+		t.Fatalf("QueryQuotaSeries: supported=%v err=%v", supported, err)
+	}
+	// Ordered by name; the tool call quota has no limit and is left out.
+	if len(series) != 2 || series[0].Name != "search" || series[1].Name != "subscription" || series[1].Label != "Subscription" {
+		t.Fatalf("series=%+v", series)
+	}
+	if got := series[1].Samples; len(got) != 2 || got[0].Percent != 20 || got[1].Percent != 50 || !got[0].At.Before(got[1].At) {
+		t.Fatalf("subscription samples=%+v", got)
+	}
+
+	if series, supported, err := s.QueryQuotaSeries("antigravity", 1, now.Add(-24*time.Hour), now, 100); supported || err != nil || series != nil {
+		t.Fatalf("unsupported provider: series=%+v supported=%v err=%v", series, supported, err)
+	}
+}
+
+func TestStore_QueryQuotaHeatmap(t *testing.T) {
+	t.Parallel()
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer s.Close()
+
+	// Monday 2026-04-06 09:00 UTC.
+	base := time.Date(2026, 4, 6, 9, 0, 0, 0, time.UTC)
+	for _, sample := range []struct {
+		after time.Duration
+		util  float64
+	}{
+		{0, 10},
+		{30 * time.Minute, 30},             // +20 in the 09:30 bucket
+		{90 * time.Minute, 96},             // +66 in the 10:30 bucket, crosses 95
+		{120 * time.Minute, 5},             // reset: not counted
+		{6 * time.Hour, 40},                // after a gap: not counted
+		{6*time.Hour + 10*time.Minute, 41}, // +1 in the 15:00 bucket
+		{6*time.Hour + 12*time.Minute, 43}, // +2 in the same bucket
+	} {
+		snap := &api.AnthropicSnapshot{
+			CapturedAt: base.Add(sample.after),
+			Quotas: []api.AnthropicQuota{
+				{Name: "five_hour", Utilization: sample.util},
+				{Name: "seven_day_omelette", Utilization: sample.util},
+			},
+		}
+		if _, err := s.InsertAnthropicSnapshot(snap); err != nil {
+			t.Fatalf("InsertAnthropicSnapshot: %v", err)
+		}
+	}
+
+	buckets, supported, err := s.QueryQuotaHeatmap("anthropic", 1, base.Add(-time.Hour), base.Add(24*time.Hour), 2*time.Hour, 95)
+	if err != nil || !supported {
+		t.Fatalf("QueryQuotaHeatmap: supported=%v err=%v", supported, err)
+	}
+	type cell struct {
+		start     time.Time
+		samples   int
+		delta     float64
+		crossings int
+	}
+	want := []cell{
+		{base, 1, 0, 0},
+		{base.Add(30 * time.Minute), 1, 20, 0},
+		{base.Add(90 * time.Minute), 1, 66, 1},
+		{base.Add(120 * time.Minute), 1, 0, 0},
+		{base.Add(6 * time.Hour), 3, 3, 0},
+	}
+	if len(buckets) != len(want) {
+		t.Fatalf("buckets=%+v want %d known-quota buckets", buckets, len(want))
+	}
+	for i, w := range want {
+		b := buckets[i]
+		if b.Name != "five_hour" || b.Label != api.AnthropicDisplayName("five_hour") || !b.Start.Equal(w.start) ||
+			b.Samples != w.samples || b.Delta != w.delta || b.Crossings != w.crossings {
+			t.Errorf("bucket %d = %+v, want %+v", i, b, w)
+		}
+	}
+
+	if buckets, supported, err := s.QueryQuotaHeatmap("antigravity", 1, base, base.Add(time.Hour), time.Hour, 95); supported || err != nil || buckets != nil {
+		t.Fatalf("unsupported provider: buckets=%+v supported=%v err=%v", buckets, supported, err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/onllm-dev/onwatch/v2/internal/api"
@@ -20,6 +21,9 @@ import (
 // Store provides SQLite storage for onWatch
 type Store struct {
 	db *sql.DB
+
+	estimatorMu   sync.RWMutex
+	costEstimator APIIntegrationCostEstimator
 }
 
 // Session represents an agent session
@@ -790,8 +794,15 @@ func (s *Store) createTables() error {
 			prompt_tokens INTEGER NOT NULL,
 			completion_tokens INTEGER NOT NULL,
 			total_tokens INTEGER NOT NULL,
+			cache_read_tokens INTEGER,
+			cache_write_tokens INTEGER,
+			reasoning_tokens INTEGER,
+			audio_tokens INTEGER,
 			cost_usd REAL,
+			estimated_cost_usd REAL,
 			latency_ms INTEGER,
+			status TEXT NOT NULL DEFAULT 'ok',
+			error_type TEXT NOT NULL DEFAULT '',
 			metadata_json TEXT NOT NULL DEFAULT '',
 			source_path TEXT NOT NULL,
 			fingerprint TEXT NOT NULL,
@@ -810,6 +821,10 @@ func (s *Store) createTables() error {
 			file_size INTEGER NOT NULL DEFAULT 0,
 			file_mod_time TEXT,
 			partial_line TEXT NOT NULL DEFAULT '',
+			logical_path TEXT NOT NULL DEFAULT '',
+			file_inode INTEGER NOT NULL DEFAULT 0,
+			rotations INTEGER NOT NULL DEFAULT 0,
+			last_rotated_at TEXT,
 			updated_at TEXT NOT NULL
 		);
 	`
@@ -1085,6 +1100,59 @@ func (s *Store) migrateSchema() error {
 		}
 	}
 
+	// Add optional token detail columns to api_integration_usage_events.
+	// NULL means the event did not report the detail.
+	for _, col := range []string{
+		"cache_read_tokens INTEGER",
+		"cache_write_tokens INTEGER",
+		"reasoning_tokens INTEGER",
+		"audio_tokens INTEGER",
+	} {
+		if _, err := s.db.Exec(`ALTER TABLE api_integration_usage_events ADD COLUMN ` + col); err != nil {
+			if !strings.Contains(err.Error(), "duplicate column name") &&
+				!strings.Contains(err.Error(), "no such table") {
+				return fmt.Errorf("failed to add token detail column to api_integration_usage_events: %w", err)
+			}
+		}
+	}
+
+	// Add estimated_cost_usd to api_integration_usage_events.
+	if _, err := s.db.Exec(`ALTER TABLE api_integration_usage_events ADD COLUMN estimated_cost_usd REAL`); err != nil {
+		if !strings.Contains(err.Error(), "duplicate column name") &&
+			!strings.Contains(err.Error(), "no such table") {
+			return fmt.Errorf("failed to add estimated_cost_usd to api_integration_usage_events: %w", err)
+		}
+	}
+
+	// Add status and error_type to api_integration_usage_events. Events
+	// stored before them were all recorded as successful calls.
+	for _, col := range []string{
+		"status TEXT NOT NULL DEFAULT 'ok'",
+		"error_type TEXT NOT NULL DEFAULT ''",
+	} {
+		if _, err := s.db.Exec(`ALTER TABLE api_integration_usage_events ADD COLUMN ` + col); err != nil {
+			if !strings.Contains(err.Error(), "duplicate column name") &&
+				!strings.Contains(err.Error(), "no such table") {
+				return fmt.Errorf("failed to add status column to api_integration_usage_events: %w", err)
+			}
+		}
+	}
+
+	// Add rotation tracking columns to api_integration_ingest_state.
+	for _, col := range []string{
+		"logical_path TEXT NOT NULL DEFAULT ''",
+		"file_inode INTEGER NOT NULL DEFAULT 0",
+		"rotations INTEGER NOT NULL DEFAULT 0",
+		"last_rotated_at TEXT",
+	} {
+		if _, err := s.db.Exec(`ALTER TABLE api_integration_ingest_state ADD COLUMN ` + col); err != nil {
+			if !strings.Contains(err.Error(), "duplicate column name") &&
+				!strings.Contains(err.Error(), "no such table") {
+				return fmt.Errorf("failed to add rotation column to api_integration_ingest_state: %w", err)
+			}
+		}
+	}
+
 	// Drop raw_line column from api_integration_usage_events - no longer stored.
 	// Ignore "no such column" (new DB or already migrated) and "no such table"
 	// (migrateSchema called directly on a partial DB in tests, or pre-api-integrations DB).
@@ -1459,6 +1527,65 @@ func (s *Store) QuerySessionHistory(provider ...string) ([]*Session, error) {
 	return sessions, rows.Err()
 }
 
+// SessionUsage is a session with its provider and primary-quota consumption.
+type SessionUsage struct {
+	Session
+	Provider string
+	Usage    float64 // max_sub_requests - start_sub_requests
+}
+
+// QueryTopSessions returns up to limit sessions per provider started within
+// [start, end), ranked by primary-quota consumption within each provider.
+// Usage is in provider units, so sessions are never ranked across providers;
+// results are ordered by provider, then largest consumption first.
+func (s *Store) QueryTopSessions(start, end time.Time, limit int) ([]*SessionUsage, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	rows, err := s.db.Query(
+		`SELECT id, provider, started_at, ended_at, poll_interval,
+		 max_sub_requests, max_search_requests, max_tool_requests,
+		 start_sub_requests, start_search_requests, start_tool_requests, snapshot_count
+		FROM (
+			SELECT *, ROW_NUMBER() OVER (
+				PARTITION BY provider
+				ORDER BY (max_sub_requests - start_sub_requests) DESC, started_at DESC
+			) AS provider_rank
+			FROM sessions
+			WHERE started_at >= ? AND started_at < ?
+		)
+		WHERE provider_rank <= ?
+		ORDER BY provider, provider_rank`,
+		start.UTC().Format(time.RFC3339Nano), end.UTC().Format(time.RFC3339Nano), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("store.QueryTopSessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*SessionUsage
+	for rows.Next() {
+		var su SessionUsage
+		var startedAt string
+		var endedAt sql.NullString
+		if err := rows.Scan(
+			&su.ID, &su.Provider, &startedAt, &endedAt, &su.PollInterval,
+			&su.MaxSubRequests, &su.MaxSearchRequests, &su.MaxToolRequests,
+			&su.StartSubRequests, &su.StartSearchRequests, &su.StartToolRequests, &su.SnapshotCount,
+		); err != nil {
+			return nil, fmt.Errorf("store.QueryTopSessions: scan: %w", err)
+		}
+		su.StartedAt, _ = time.Parse(time.RFC3339Nano, startedAt)
+		if endedAt.Valid {
+			endTime, _ := time.Parse(time.RFC3339Nano, endedAt.String)
+			su.EndedAt = &endTime
+		}
+		su.Usage = su.MaxSubRequests - su.StartSubRequests
+		sessions = append(sessions, &su)
+	}
+	return sessions, rows.Err()
+}
+
 // CreateCycle creates a new reset cycle
 func (s *Store) CreateCycle(quotaType string, cycleStart, renewsAt time.Time) (int64, error) {
 	result, err := s.db.Exec(
@@ -1748,6 +1875,27 @@ func (s *Store) AutoRefreshTokensEnabled() bool {
 	}
 }
 
+// Setting key for the per-provider balance floors used by runway forecasts.
+const SettingRunwayFloors = "runway_floors"
+
+// RunwayFloor returns the configured balance floor for a prepaid provider, in
+// the provider's own currency. Runway counts down to this floor instead of
+// zero. Defaults to 0 when unset.
+func (s *Store) RunwayFloor(provider string) float64 {
+	if s == nil {
+		return 0
+	}
+	val, err := s.GetSetting(SettingRunwayFloors)
+	if err != nil || val == "" {
+		return 0
+	}
+	var floors map[string]float64
+	if err := json.Unmarshal([]byte(val), &floors); err != nil {
+		return 0
+	}
+	return floors[provider]
+}
+
 // GetMenubarSettings returns persisted menubar settings, falling back to defaults.
 func (s *Store) GetMenubarSettings() (*menubar.Settings, error) {
 	defaults := menubar.DefaultSettings()
@@ -2304,7 +2452,7 @@ type SystemAlert struct {
 }
 
 // CreateSystemAlert creates a new system alert for in-dashboard notifications.
-// Alert types: "auth_error", "token_refresh_failed", "polling_paused"
+// Alert types: "auth_error", "token_refresh_failed", "polling_paused", "consumption_anomaly"
 // Severity: "info", "warning", "error"
 func (s *Store) CreateSystemAlert(provider, alertType, title, message, severity string, metadata string) (int64, error) {
 	now := time.Now().UTC().Format(time.RFC3339)
@@ -435,6 +435,67 @@ func TestStore_CreateAndCloseSession(t *testing.T) {
 	}
 }
 
+func TestStore_QueryTopSessions(t *testing.T) {
+	t.Parallel()
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer s.Close()
+
+	base := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	sessions := []struct {
+		id       string
+		provider string
+		offset   time.Duration
+		start    float64
+		max      float64
+	}{
+		{"before", "synthetic", -time.Hour, 0, 900},
+		{"small", "synthetic", time.Hour, 10, 30},
+		{"big", "anthropic", 2 * time.Hour, 5, 85},
+		{"medium", "zai", 3 * time.Hour, 0, 40},
+		{"largest", "synthetic", 4 * time.Hour, 0, 500},
+		{"smallest", "synthetic", 5 * time.Hour, 0, 5},
+		{"after", "zai", 8 * 24 * time.Hour, 0, 900},
+	}
+	for _, sess := range sessions {
+		if err := s.CreateSession(sess.id, base.Add(sess.offset), 60, sess.provider, sess.start); err != nil {
+			t.Fatalf("CreateSession(%s) failed: %v", sess.id, err)
+		}
+		if err := s.UpdateSessionMaxRequests(sess.id, sess.max, 0, 0); err != nil {
+			t.Fatalf("UpdateSessionMaxRequests(%s) failed: %v", sess.id, err)
+		}
+	}
+	if err := s.CloseSession("big", base.Add(4*time.Hour)); err != nil {
+		t.Fatalf("CloseSession failed: %v", err)
+	}
+
+	// Usage is ranked within each provider: synthetic's large request counts
+	// must not crowd out the other providers.
+	top, err := s.QueryTopSessions(base, base.Add(7*24*time.Hour), 2)
+	if err != nil {
+		t.Fatalf("QueryTopSessions failed: %v", err)
+	}
+	var got []string
+	for _, su := range top {
+		got = append(got, su.Provider+"/"+su.ID)
+	}
+	want := []string{"anthropic/big", "synthetic/largest", "synthetic/small", "zai/medium"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("top = %v, want %v", got, want)
+	}
+	if top[0].Usage != 80 {
+		t.Errorf("top[0].Usage = %.0f, want 80", top[0].Usage)
+	}
+	if top[0].EndedAt == nil || !top[0].EndedAt.Equal(base.Add(4*time.Hour)) {
+		t.Errorf("top[0].EndedAt = %v, want %v", top[0].EndedAt, base.Add(4*time.Hour))
+	}
+	if top[2].Usage != 20 {
+		t.Errorf("top[2].Usage = %.0f, want 20", top[2].Usage)
+	}
+}
+
 func TestStore_UpdateSessionMaxRequests(t *testing.T) {
 	t.Parallel()
 	s, err := New(":memory:")
@@ -0,0 +1,148 @@
+package tracker
+
+import (
+	"sort"
+	"time"
+)
+
+// Baseline sources reported on ConsumptionBaseline.
+const (
+	BaselineFromSnapshots = "snapshots"
+	BaselineFromCycles    = "cycles"
+)
+
+const (
+	// DefaultAnomalyFactor is how many times the baseline rate the current
+	// hourly rate must reach to count as an anomaly.
+	DefaultAnomalyFactor = 3.0
+	// AnomalyMinRate ignores bursts below this many percentage points per
+	// hour, so quiet quotas with tiny baselines do not alert on normal use.
+	AnomalyMinRate = 5.0
+	// minBaselineHours is the number of active hours of snapshot history
+	// needed before the snapshot baseline is trusted.
+	minBaselineHours = 6
+	// minBaselineCycles is the number of completed cycles needed for the
+	// reset-cycle fallback baseline.
+	minBaselineCycles = 2
+	// anomalyMaxGap skips deltas across polling gaps.
+	anomalyMaxGap = 2 * time.Hour
+)
+
+// UsageSample is a quota's utilization (percent of its limit) at a point in time.
+type UsageSample struct {
+	At      time.Time
+	Percent float64
+}
+
+// ConsumptionBaseline is a quota's typical consumption rate.
+type ConsumptionBaseline struct {
+	RatePerHour float64 // percentage points per active hour
+	Source      string  // BaselineFromSnapshots or BaselineFromCycles
+	Samples     int     // active hours or completed cycles learned from
+}
+
+// ConsumptionAnomaly describes a quota consuming faster than its baseline.
+type ConsumptionAnomaly struct {
+	CurrentRate  float64 // percentage points consumed in the last hour
+	BaselineRate float64
+	Ratio        float64 // CurrentRate / BaselineRate
+	Source       string
+}
+
+// LearnConsumptionBaseline learns a quota's typical hourly consumption.
+// Positive snapshot deltas are summed per clock hour and the median of the
+// hours with any consumption is used once enough history exists. Until then
+// it falls back to the average rate of completed reset cycles (cycleRates,
+// in points per hour). It returns nil while there is too little history.
+func LearnConsumptionBaseline(history []UsageSample, cycleRates []float64) *ConsumptionBaseline {
+	hourly := make(map[time.Time]float64)
+	for i := 1; i < len(history); i++ {
+		prev, cur := history[i-1], history[i]
+		if cur.At.Sub(prev.At) > anomalyMaxGap {
+			continue
+		}
+		if delta := cur.Percent - prev.Percent; delta > 0 {
+			hourly[cur.At.UTC().Truncate(time.Hour)] += delta
+		}
+	}
+	if len(hourly) >= minBaselineHours {
+		rates := make([]float64, 0, len(hourly))
+		for _, v := range hourly {
+			rates = append(rates, v)
+		}
+		return &ConsumptionBaseline{RatePerHour: median(rates), Source: BaselineFromSnapshots, Samples: len(rates)}
+	}
+
+	var sum float64
+	var n int
+	for _, r := range cycleRates {
+		if r > 0 {
+			sum += r
+			n++
+		}
+	}
+	if n >= minBaselineCycles {
+		return &ConsumptionBaseline{RatePerHour: sum / float64(n), Source: BaselineFromCycles, Samples: n}
+	}
+	return nil
+}
+
+// CycleRate converts a completed cycle's total consumption into points per hour.
+func CycleRate(totalDelta float64, start, end time.Time) float64 {
+	hours := end.Sub(start).Hours()
+	if hours <= 0 {
+		return 0
+	}
+	return totalDelta / hours
+}
+
+// RecentConsumption sums the positive deltas of samples captured in the hour
+// before now, i.e. the current consumption rate in points per hour.
+func RecentConsumption(samples []UsageSample, now time.Time) float64 {
+	since := now.Add(-time.Hour)
+	var total float64
+	for i := 1; i < len(samples); i++ {
+		prev, cur := samples[i-1], samples[i]
+		if !cur.At.After(since) || cur.At.After(now) || cur.At.Sub(prev.At) > anomalyMaxGap {
+			continue
+		}
+		if delta := cur.Percent - prev.Percent; delta > 0 {
+			total += delta
+		}
+	}
+	return total
+}
+
+// DetectConsumptionAnomaly reports whether the current hourly rate exceeds
+// factor times the baseline. It returns nil when there is no baseline, the
+// rate is below AnomalyMinRate, or consumption is within the expected range.
+func DetectConsumptionAnomaly(current float64, baseline *ConsumptionBaseline, factor float64) *ConsumptionAnomaly {
+	if baseline == nil || baseline.RatePerHour <= 0 || current < AnomalyMinRate {
+		return nil
+	}
+	if factor <= 1 {
+		factor = DefaultAnomalyFactor
+	}
+	if current < baseline.RatePerHour*factor {
+		return nil
+	}
+	return &ConsumptionAnomaly{
+		CurrentRate:  current,
+		BaselineRate: baseline.RatePerHour,
+		Ratio:        current / baseline.RatePerHour,
+		Source:       baseline.Source,
+	}
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,103 @@
+package tracker
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// steadySamples returns one sample every 15 minutes for the given number of
+// hours, consuming perHour points each hour.
+func steadySamples(start time.Time, hours int, perHour float64) []UsageSample {
+	var samples []UsageSample
+	pct := 0.0
+	for i := 0; i <= hours*4; i++ {
+		samples = append(samples, UsageSample{At: start.Add(time.Duration(i) * 15 * time.Minute), Percent: pct})
+		pct += perHour / 4
+	}
+	return samples
+}
+
+func TestLearnConsumptionBaseline_Snapshots(t *testing.T) {
+	start := time.Date(2026, 4, 6, 0, 0, 0, 0, time.UTC)
+	history := steadySamples(start, 10, 2)
+
+	b := LearnConsumptionBaseline(history, []float64{50, 50})
+	if b == nil || b.Source != BaselineFromSnapshots {
+		t.Fatalf("baseline = %+v, want snapshot baseline", b)
+	}
+	if math.Abs(b.RatePerHour-2) > 0.001 {
+		t.Errorf("RatePerHour = %.3f, want 2", b.RatePerHour)
+	}
+}
+
+func TestLearnConsumptionBaseline_CycleFallback(t *testing.T) {
+	start := time.Date(2026, 4, 6, 0, 0, 0, 0, time.UTC)
+	short := steadySamples(start, 2, 2) // too few active hours
+
+	b := LearnConsumptionBaseline(short, []float64{4, 0, 6})
+	if b == nil || b.Source != BaselineFromCycles || b.Samples != 2 {
+		t.Fatalf("baseline = %+v, want cycle baseline from 2 cycles", b)
+	}
+	if math.Abs(b.RatePerHour-5) > 0.001 {
+		t.Errorf("RatePerHour = %.3f, want 5", b.RatePerHour)
+	}
+
+	if b := LearnConsumptionBaseline(short, []float64{4}); b != nil {
+		t.Errorf("expected no baseline while learning, got %+v", b)
+	}
+}
+
+func TestLearnConsumptionBaseline_IgnoresResetsAndGaps(t *testing.T) {
+	start := time.Date(2026, 4, 6, 0, 0, 0, 0, time.UTC)
+	history := steadySamples(start, 8, 2)
+	last := history[len(history)-1]
+	history = append(history,
+		UsageSample{At: last.At.Add(15 * time.Minute), Percent: 0}, // reset
+		UsageSample{At: last.At.Add(5 * time.Hour), Percent: 80},   // after a gap
+		UsageSample{At: last.At.Add(5*time.Hour + time.Minute), Percent: 80},
+	)
+	b := LearnConsumptionBaseline(history, nil)
+	if b == nil || math.Abs(b.RatePerHour-2) > 0.001 {
+		t.Errorf("baseline = %+v, want 2 points/hour", b)
+	}
+}
+
+func TestRecentConsumptionAndDetect(t *testing.T) {
+	now := time.Date(2026, 4, 6, 12, 0, 0, 0, time.UTC)
+	samples := []UsageSample{
+		{At: now.Add(-90 * time.Minute), Percent: 10},
+		{At: now.Add(-50 * time.Minute), Percent: 12}, // +2 inside the hour
+		{At: now.Add(-20 * time.Minute), Percent: 40}, // +28
+		{At: now.Add(-5 * time.Minute), Percent: 45},  // +5
+	}
+	current := RecentConsumption(samples, now)
+	if math.Abs(current-35) > 0.001 {
+		t.Fatalf("RecentConsumption = %.3f, want 35", current)
+	}
+
+	baseline := &ConsumptionBaseline{RatePerHour: 5, Source: BaselineFromSnapshots}
+	a := DetectConsumptionAnomaly(current, baseline, 3)
+	if a == nil || math.Abs(a.Ratio-7) > 0.001 {
+		t.Fatalf("anomaly = %+v, want ratio 7", a)
+	}
+	if a := DetectConsumptionAnomaly(current, baseline, 10); a != nil {
+		t.Errorf("7x should not trip a 10x factor, got %+v", a)
+	}
+	if a := DetectConsumptionAnomaly(4, &ConsumptionBaseline{RatePerHour: 0.5}, 3); a != nil {
+		t.Errorf("rates under AnomalyMinRate should be ignored, got %+v", a)
+	}
+	if a := DetectConsumptionAnomaly(current, nil, 3); a != nil {
+		t.Errorf("no baseline should not alert, got %+v", a)
+	}
+}
+
+func TestCycleRate(t *testing.T) {
+	start := time.Date(2026, 4, 6, 0, 0, 0, 0, time.UTC)
+	if r := CycleRate(50, start, start.Add(5*time.Hour)); r != 10 {
+		t.Errorf("CycleRate = %v, want 10", r)
+	}
+	if r := CycleRate(50, start, start); r != 0 {
+		t.Errorf("zero-length cycle rate = %v, want 0", r)
+	}
+}
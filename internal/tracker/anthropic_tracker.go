@@ -40,6 +40,7 @@ type AnthropicSummary struct {
 	PeakCycle       float64
 	TotalTracked    float64
 	TrackingSince   time.Time
+	Pacing          *Pacing // nil for windows shorter than a day
 }
 
 // NewAnthropicTracker creates a new AnthropicTracker.
@@ -306,5 +307,6 @@ func (t *AnthropicTracker) UsageSummary(quotaName string) (*AnthropicSummary, er
 		}
 	}
 
+	summary.Pacing = QuotaPacing(quotaName, summary.CurrentUtil, summary.ResetsAt, time.Now())
 	return summary, nil
 }
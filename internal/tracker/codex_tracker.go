@@ -38,6 +38,7 @@ type CodexSummary struct {
 	PeakCycle       float64
 	TotalTracked    float64
 	TrackingSince   time.Time
+	Pacing          *Pacing // nil for windows shorter than a day
 }
 
 // NewCodexTracker creates a new CodexTracker.
@@ -284,5 +285,6 @@ func (t *CodexTracker) UsageSummary(accountID int64, quotaName string) (*CodexSu
 		}
 	}
 
+	summary.Pacing = QuotaPacing(quotaName, summary.CurrentUtil, summary.ResetsAt, time.Now())
 	return summary, nil
 }
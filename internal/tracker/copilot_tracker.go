@@ -43,6 +43,7 @@ type CopilotSummary struct {
 	PeakCycle        int
 	TotalTracked     int
 	TrackingSince    time.Time
+	Pacing           *Pacing // monthly pacing; nil when unlimited
 }
 
 // NewCopilotTracker creates a new CopilotTracker.
@@ -273,5 +274,8 @@ func (t *CopilotTracker) UsageSummary(quotaName string) (*CopilotSummary, error)
 		}
 	}
 
+	if !summary.Unlimited && summary.Entitlement > 0 {
+		summary.Pacing = MonthlyPacing(summary.UsagePercent, summary.ResetDate, time.Now())
+	}
 	return summary, nil
 }
@@ -37,6 +37,7 @@ type DeepSeekSummary struct {
 	PeakCycle       float64
 	TotalTracked    float64
 	TrackingSince   time.Time
+	Runway          *Runway // nil until there is enough balance history
 }
 
 // NewDeepSeekTracker creates a new DeepSeekTracker.
@@ -205,8 +206,29 @@ func (t *DeepSeekTracker) UsageSummary(currency string) (*DeepSeekSummary, error
 			if elapsed.Hours() > 0 && activeCycle.TotalDelta > 0 {
 				summary.CurrentRate = activeCycle.TotalDelta / elapsed.Hours()
 			}
+
+			summary.Runway, err = t.Runway(currency, time.Now())
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
 	return summary, nil
 }
+
+// Runway forecasts when the balance in currency reaches its configured floor
+// at the spend rate of the trailing RunwayWindow.
+func (t *DeepSeekTracker) Runway(currency string, now time.Time) (*Runway, error) {
+	snapshots, err := t.store.QueryDeepSeekRange(now.UTC().Add(-RunwayWindow), now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query runway history: %w", err)
+	}
+	samples := make([]BalanceSample, 0, len(snapshots))
+	for _, snap := range snapshots {
+		if snap.Currency == currency {
+			samples = append(samples, BalanceSample{At: snap.CapturedAt, Balance: snap.TotalBalance})
+		}
+	}
+	return ComputeRunway(samples, t.store.RunwayFloor("deepseek")), nil
+}
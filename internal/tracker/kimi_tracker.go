@@ -35,6 +35,7 @@ type KimiSummary struct {
 	PeakCycle       float64
 	TotalTracked    float64
 	TrackingSince   time.Time
+	Pacing          *Pacing // nil for windows shorter than a day
 }
 
 // NewKimiTracker creates a KimiTracker.
@@ -218,5 +219,6 @@ func (t *KimiTracker) GetKimiSummary(accountID int64, quotaName string, latest *
 			sum.AvgPerCycle = sum.TotalTracked / float64(len(cycles))
 		}
 	}
+	sum.Pacing = QuotaPacing(quotaName, sum.CurrentUtil, sum.ResetsAt, time.Now())
 	return sum
 }
@@ -36,6 +36,7 @@ type MoonshotSummary struct {
 	PeakCycle       float64
 	TotalTracked    float64
 	TrackingSince   time.Time
+	Runway          *Runway // nil until there is enough balance history
 }
 
 // NewMoonshotTracker creates a new MoonshotTracker.
@@ -200,8 +201,27 @@ func (t *MoonshotTracker) UsageSummary() (*MoonshotSummary, error) {
 			if elapsed.Hours() > 0 && activeCycle.TotalDelta > 0 {
 				summary.CurrentRate = activeCycle.TotalDelta / elapsed.Hours()
 			}
+
+			summary.Runway, err = t.Runway(time.Now())
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
 	return summary, nil
 }
+
+// Runway forecasts when the available balance reaches its configured floor
+// at the spend rate of the trailing RunwayWindow.
+func (t *MoonshotTracker) Runway(now time.Time) (*Runway, error) {
+	snapshots, err := t.store.QueryMoonshotRange(now.UTC().Add(-RunwayWindow), now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query runway history: %w", err)
+	}
+	samples := make([]BalanceSample, 0, len(snapshots))
+	for _, snap := range snapshots {
+		samples = append(samples, BalanceSample{At: snap.CapturedAt, Balance: snap.AvailableBalance})
+	}
+	return ComputeRunway(samples, t.store.RunwayFloor("moonshot")), nil
+}
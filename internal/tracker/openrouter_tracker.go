@@ -41,6 +41,7 @@ type OpenRouterSummary struct {
 	PeakCycle       float64
 	TotalTracked    float64
 	TrackingSince   time.Time
+	Runway          *Runway // nil for keys without a credit limit
 }
 
 // NewOpenRouterTracker creates a new OpenRouterTracker.
@@ -208,8 +209,30 @@ func (t *OpenRouterTracker) UsageSummary() (*OpenRouterSummary, error) {
 			if elapsed.Hours() > 0 && summary.CurrentUsage > 0 {
 				summary.CurrentRate = summary.CurrentUsage / elapsed.Hours()
 			}
+
+			summary.Runway, err = t.Runway(time.Now())
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
 	return summary, nil
 }
+
+// Runway forecasts when the key's remaining credit limit reaches its
+// configured floor at the spend rate of the trailing RunwayWindow. Keys
+// without a limit have no balance to run out of and return nil.
+func (t *OpenRouterTracker) Runway(now time.Time) (*Runway, error) {
+	snapshots, err := t.store.QueryOpenRouterRange(now.UTC().Add(-RunwayWindow), now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query runway history: %w", err)
+	}
+	samples := make([]BalanceSample, 0, len(snapshots))
+	for _, snap := range snapshots {
+		if snap.LimitRemaining != nil {
+			samples = append(samples, BalanceSample{At: snap.CapturedAt, Balance: *snap.LimitRemaining})
+		}
+	}
+	return ComputeRunway(samples, t.store.RunwayFloor("openrouter")), nil
+}
@@ -0,0 +1,89 @@
+package tracker
+
+import (
+	"strings"
+	"time"
+)
+
+// Pacing status values.
+const (
+	PaceAhead  = "ahead"   // using faster than an even spread to reset
+	PaceOnPace = "on_pace" // within PaceTolerance of the budget line
+	PaceBehind = "behind"  // using slower than an even spread to reset
+)
+
+// PaceTolerance is how many utilization points either side of the budget
+// line still count as on pace.
+const PaceTolerance = 5.0
+
+// minPacingWindow is the shortest window worth pacing. Five-hour windows
+// reset too quickly for a daily allowance to mean anything.
+const minPacingWindow = 24 * time.Hour
+
+// Pacing compares usage in a long quota window against the ideal linear
+// budget line running from 0% at window start to 100% at reset.
+type Pacing struct {
+	WindowStart    time.Time
+	ResetsAt       time.Time
+	ExpectedUtil   float64 // budget line value now
+	DeltaPercent   float64 // CurrentUtil - ExpectedUtil; positive is ahead of pace
+	Status         string  // PaceAhead, PaceOnPace or PaceBehind
+	DaysLeft       float64
+	DailyAllowance float64 // utilization % usable per day to last until reset
+}
+
+// ComputePacing returns the pacing for a window that started at windowStart
+// and resets at resetsAt. It returns nil when the window is shorter than a
+// day or now lies outside it.
+func ComputePacing(currentUtil float64, windowStart, resetsAt, now time.Time) *Pacing {
+	window := resetsAt.Sub(windowStart)
+	if window < minPacingWindow || now.Before(windowStart) || !now.Before(resetsAt) {
+		return nil
+	}
+
+	p := &Pacing{
+		WindowStart:  windowStart,
+		ResetsAt:     resetsAt,
+		ExpectedUtil: float64(now.Sub(windowStart)) / float64(window) * 100,
+		DaysLeft:     resetsAt.Sub(now).Hours() / 24,
+	}
+	p.DeltaPercent = currentUtil - p.ExpectedUtil
+	switch {
+	case p.DeltaPercent > PaceTolerance:
+		p.Status = PaceAhead
+	case p.DeltaPercent < -PaceTolerance:
+		p.Status = PaceBehind
+	default:
+		p.Status = PaceOnPace
+	}
+
+	// With less than a day left, everything remaining is today's allowance.
+	remaining := max(0, 100-currentUtil)
+	p.DailyAllowance = remaining / max(1, p.DaysLeft)
+	return p
+}
+
+// QuotaPacing returns the pacing for a quota whose window length is implied
+// by its name: "seven_day*" windows last a week and "monthly_*" windows a
+// month. Other quotas, and quotas without a reset time, are not paced.
+func QuotaPacing(quotaName string, currentUtil float64, resetsAt *time.Time, now time.Time) *Pacing {
+	if resetsAt == nil {
+		return nil
+	}
+	switch {
+	case strings.HasPrefix(quotaName, "seven_day"):
+		return ComputePacing(currentUtil, resetsAt.AddDate(0, 0, -7), *resetsAt, now)
+	case strings.HasPrefix(quotaName, "monthly"):
+		return ComputePacing(currentUtil, resetsAt.AddDate(0, -1, 0), *resetsAt, now)
+	default:
+		return nil
+	}
+}
+
+// MonthlyPacing returns the pacing for a quota that resets monthly on resetsAt.
+func MonthlyPacing(currentUtil float64, resetsAt *time.Time, now time.Time) *Pacing {
+	if resetsAt == nil {
+		return nil
+	}
+	return ComputePacing(currentUtil, resetsAt.AddDate(0, -1, 0), *resetsAt, now)
+}
@@ -0,0 +1,104 @@
+package tracker
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestComputePacing(t *testing.T) {
+	start := time.Date(2026, 4, 6, 0, 0, 0, 0, time.UTC)
+	reset := start.AddDate(0, 0, 7)
+	// Halfway through the week the budget line sits at 50%.
+	now := start.Add(84 * time.Hour)
+
+	tests := []struct {
+		name       string
+		util       float64
+		wantStatus string
+		wantDelta  float64
+	}{
+		{"ahead", 70, PaceAhead, 20},
+		{"behind", 30, PaceBehind, -20},
+		{"on pace", 53, PaceOnPace, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := ComputePacing(tt.util, start, reset, now)
+			if p == nil {
+				t.Fatal("expected pacing")
+			}
+			if math.Abs(p.ExpectedUtil-50) > 0.001 {
+				t.Errorf("ExpectedUtil = %.3f, want 50", p.ExpectedUtil)
+			}
+			if math.Abs(p.DeltaPercent-tt.wantDelta) > 0.001 {
+				t.Errorf("DeltaPercent = %.3f, want %.1f", p.DeltaPercent, tt.wantDelta)
+			}
+			if p.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", p.Status, tt.wantStatus)
+			}
+			if math.Abs(p.DaysLeft-3.5) > 0.001 {
+				t.Errorf("DaysLeft = %.3f, want 3.5", p.DaysLeft)
+			}
+			if want := (100 - tt.util) / 3.5; math.Abs(p.DailyAllowance-want) > 0.001 {
+				t.Errorf("DailyAllowance = %.3f, want %.3f", p.DailyAllowance, want)
+			}
+		})
+	}
+}
+
+func TestComputePacing_LastDayAndExhausted(t *testing.T) {
+	start := time.Date(2026, 4, 6, 0, 0, 0, 0, time.UTC)
+	reset := start.AddDate(0, 0, 7)
+	now := reset.Add(-6 * time.Hour)
+
+	p := ComputePacing(80, start, reset, now)
+	if p == nil {
+		t.Fatal("expected pacing")
+	}
+	if math.Abs(p.DailyAllowance-20) > 0.001 {
+		t.Errorf("DailyAllowance = %.3f, want all 20 remaining points", p.DailyAllowance)
+	}
+
+	if p := ComputePacing(120, start, reset, now); p == nil || p.DailyAllowance != 0 {
+		t.Errorf("over-limit allowance = %+v, want 0", p)
+	}
+}
+
+func TestComputePacing_NotPaced(t *testing.T) {
+	start := time.Date(2026, 4, 6, 0, 0, 0, 0, time.UTC)
+
+	if p := ComputePacing(50, start, start.Add(5*time.Hour), start.Add(time.Hour)); p != nil {
+		t.Errorf("five-hour window should not be paced, got %+v", p)
+	}
+	reset := start.AddDate(0, 0, 7)
+	if p := ComputePacing(50, start, reset, reset); p != nil {
+		t.Errorf("window that has reset should not be paced, got %+v", p)
+	}
+	if p := ComputePacing(50, start, reset, start.Add(-time.Hour)); p != nil {
+		t.Errorf("time before window start should not be paced, got %+v", p)
+	}
+}
+
+func TestQuotaPacing(t *testing.T) {
+	reset := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	now := reset.Add(-48 * time.Hour)
+
+	weekly := QuotaPacing("seven_day_sonnet", 40, &reset, now)
+	if weekly == nil || !weekly.WindowStart.Equal(reset.AddDate(0, 0, -7)) {
+		t.Errorf("seven_day window start = %+v, want a week before reset", weekly)
+	}
+	monthly := QuotaPacing("monthly_limit", 40, &reset, now)
+	if monthly == nil || !monthly.WindowStart.Equal(reset.AddDate(0, -1, 0)) {
+		t.Errorf("monthly window start = %+v, want a month before reset", monthly)
+	}
+	if p := QuotaPacing("five_hour", 40, &reset, now); p != nil {
+		t.Errorf("five_hour should not be paced, got %+v", p)
+	}
+	if p := QuotaPacing("seven_day", 40, nil, now); p != nil {
+		t.Errorf("quota without reset should not be paced, got %+v", p)
+	}
+	if p := MonthlyPacing(40, &reset, now); p == nil || p.Status != PaceBehind {
+		t.Errorf("MonthlyPacing = %+v, want behind pace", p)
+	}
+}
@@ -0,0 +1,83 @@
+package tracker
+
+import "time"
+
+// RunwayWindow is the trailing period whose spend sets the burn rate used to
+// forecast when a prepaid balance runs out.
+const RunwayWindow = 7 * 24 * time.Hour
+
+// DefaultRunwayDays is how many days of runway left count as low.
+const DefaultRunwayDays = 7.0
+
+// minRunwaySpan is the shortest stretch of samples worth extrapolating.
+const minRunwaySpan = time.Hour
+
+// BalanceSample is one observation of a prepaid balance.
+type BalanceSample struct {
+	At      time.Time
+	Balance float64
+}
+
+// Runway forecasts when a prepaid balance reaches its floor at the trailing
+// spend rate. Top-ups are excluded from the rate.
+type Runway struct {
+	Balance    float64       // latest balance
+	Floor      float64       // balance considered depleted; 0 unless configured
+	Spent      float64       // balance decreases over the window
+	ToppedUp   float64       // balance increases over the window
+	LastChange float64       // balance change between the last two samples; positive after a top-up
+	Span       time.Duration // time between the first and last sample
+	DailySpend float64       // Spent per day over Span
+	DaysLeft   float64       // days until Balance reaches Floor; 0 when already there
+	DepletesAt *time.Time    // nil while nothing is being spent
+}
+
+// ComputeRunway measures the spend rate over samples (oldest first) and
+// projects when the latest balance reaches floor. Every decrease between
+// consecutive samples counts as spend and every increase as a top-up. It
+// returns nil with fewer than two samples or less than an hour between the
+// first and the last.
+func ComputeRunway(samples []BalanceSample, floor float64) *Runway {
+	if len(samples) < 2 {
+		return nil
+	}
+	first, last := samples[0], samples[len(samples)-1]
+	span := last.At.Sub(first.At)
+	if span < minRunwaySpan {
+		return nil
+	}
+
+	r := &Runway{Balance: last.Balance, Floor: floor, Span: span, LastChange: last.Balance - samples[len(samples)-2].Balance}
+	for i := 1; i < len(samples); i++ {
+		delta := samples[i].Balance - samples[i-1].Balance
+		if delta < 0 {
+			r.Spent -= delta
+		} else {
+			r.ToppedUp += delta
+		}
+	}
+	r.DailySpend = r.Spent / span.Hours() * 24
+
+	if r.Balance <= floor {
+		depleted := last.At
+		r.DepletesAt = &depleted
+		return r
+	}
+	if r.DailySpend > 0 {
+		r.DaysLeft = (r.Balance - floor) / r.DailySpend
+		depletes := last.At.Add(time.Duration(r.DaysLeft * 24 * float64(time.Hour)))
+		r.DepletesAt = &depletes
+	}
+	return r
+}
+
+// ToppedUpLast reports whether the latest sample shows a top-up.
+func (r *Runway) ToppedUpLast() bool {
+	return r != nil && r.LastChange > 0
+}
+
+// Low reports whether the balance is projected to reach its floor within
+// days.
+func (r *Runway) Low(days float64) bool {
+	return r != nil && r.DepletesAt != nil && r.DaysLeft < days
+}
@@ -0,0 +1,57 @@
+package tracker
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestComputeRunway(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	day := func(d float64) time.Time { return start.Add(time.Duration(d * 24 * float64(time.Hour))) }
+
+	// 10/day of spend with a 50 top-up on day 2 that must not slow the rate.
+	samples := []BalanceSample{
+		{At: day(0), Balance: 100},
+		{At: day(1), Balance: 90},
+		{At: day(2), Balance: 80},
+		{At: day(2.5), Balance: 130},
+		{At: day(4), Balance: 110},
+	}
+	r := ComputeRunway(samples, 0)
+	if r == nil {
+		t.Fatal("expected a runway")
+	}
+	if r.Spent != 40 || r.ToppedUp != 50 || math.Abs(r.DailySpend-10) > 1e-9 {
+		t.Errorf("spent %.2f, topped up %.2f, daily %.2f; want 40, 50, 10", r.Spent, r.ToppedUp, r.DailySpend)
+	}
+	if math.Abs(r.DaysLeft-11) > 1e-9 || !r.DepletesAt.Equal(day(15)) {
+		t.Errorf("days left %.2f (%v), want 11 days on day 15", r.DaysLeft, r.DepletesAt)
+	}
+	if r.LastChange != -20 || r.ToppedUpLast() {
+		t.Errorf("last change %.2f, want -20 without a top-up", r.LastChange)
+	}
+	if r.Low(DefaultRunwayDays) || !r.Low(12) {
+		t.Error("11 days left should be low against 12 days but not against 7")
+	}
+
+	// A floor shortens the runway.
+	if r := ComputeRunway(samples, 50); math.Abs(r.DaysLeft-6) > 1e-9 || !r.Low(DefaultRunwayDays) {
+		t.Errorf("with a 50 floor: %+v, want 6 low days", r)
+	}
+	if r := ComputeRunway(samples, 200); r.DaysLeft != 0 || r.DepletesAt == nil || !r.DepletesAt.Equal(day(4)) {
+		t.Errorf("below the floor: %+v, want depleted at the last sample", r)
+	}
+
+	// No spend means no depletion date.
+	if r := ComputeRunway([]BalanceSample{{At: day(0), Balance: 50}, {At: day(1), Balance: 60}}, 0); r == nil || r.DepletesAt != nil || r.Low(DefaultRunwayDays) || !r.ToppedUpLast() {
+		t.Errorf("top-up only: %+v, want no depletion", r)
+	}
+	if r := ComputeRunway(samples[:1], 0); r != nil {
+		t.Errorf("single sample: %+v, want nil", r)
+	}
+	if r := ComputeRunway([]BalanceSample{{At: day(0), Balance: 50}, {At: day(0).Add(time.Minute), Balance: 40}}, 0); r != nil {
+		t.Errorf("one minute of history: %+v, want nil", r)
+	}
+}
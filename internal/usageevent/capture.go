@@ -0,0 +1,382 @@
+package usageevent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxCaptureBytes caps how much of a JSON response, or of one SSE line, is
+// kept for usage parsing. Larger responses are still passed through in full.
+const maxCaptureBytes = 4 * 1024 * 1024
+
+// maxRequestPeek caps how much of a request body is read ahead to find the
+// requested model. Larger bodies are still sent in full.
+const maxRequestPeek = 64 * 1024
+
+// maxRateLimitHeaders caps the rate-limit headers returned by
+// RateLimitHeaders, keeping them well inside the metadata limit.
+const maxRateLimitHeaders = 12
+
+// providerHosts maps LLM API hosts to providers. Besides the providers with
+// their own response shapes, it lists OpenAI-compatible APIs.
+var providerHosts = map[string]string{
+	"api.openai.com":                    "openai",
+	"api.anthropic.com":                 "anthropic",
+	"openrouter.ai":                     "openrouter",
+	"api.mistral.ai":                    "mistral",
+	"generativelanguage.googleapis.com": "gemini",
+	"api.deepseek.com":                  "deepseek",
+	"api.groq.com":                      "groq",
+	"api.x.ai":                          "xai",
+	"api.together.xyz":                  "together",
+	"api.fireworks.ai":                  "fireworks",
+	"api.moonshot.ai":                   "moonshot",
+}
+
+// ProviderForHost returns the provider whose public API is served from host,
+// or "" for other hosts.
+func ProviderForHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return providerHosts[strings.ToLower(host)]
+}
+
+// ResponseUsage is the token usage reported by an LLM API response. Streamed
+// responses report usage across several events, and some counts are running
+// totals, so each count keeps the largest value seen.
+type ResponseUsage struct {
+	Model        string
+	ResponseID   string
+	InputTokens  int // includes Anthropic cache reads and writes
+	OutputTokens int
+	// Token details, when the response reports them. As in Event, cache
+	// tokens are part of InputTokens and reasoning tokens of OutputTokens.
+	CacheReadTokens  *int
+	CacheWriteTokens *int
+	ReasoningTokens  *int
+	AudioTokens      *int
+	CostUSD          *float64 // OpenRouter's usage.cost
+	Found            bool     // a usage block was seen
+}
+
+// Absorb reads usage from one response object or stream event. It covers
+// OpenAI chat completions and responses (also used by OpenRouter, Mistral and
+// Gemini's OpenAI-compatible API), Anthropic messages and native Gemini.
+func (u *ResponseUsage) Absorb(obj map[string]interface{}) {
+	if s, ok := obj["model"].(string); ok && s != "" {
+		u.Model = s
+	}
+	if s, ok := obj["modelVersion"].(string); ok && s != "" {
+		u.Model = s
+	}
+	if u.ResponseID == "" {
+		if s, ok := obj["id"].(string); ok {
+			u.ResponseID = s
+		} else if s, ok := obj["responseId"].(string); ok {
+			u.ResponseID = s
+		}
+	}
+
+	if m, ok := obj["usage"].(map[string]interface{}); ok {
+		input := jsonInt(m, "prompt_tokens") + jsonInt(m, "input_tokens") +
+			jsonInt(m, "cache_creation_input_tokens") + jsonInt(m, "cache_read_input_tokens")
+		output := jsonInt(m, "completion_tokens") + jsonInt(m, "output_tokens")
+		u.record(input, output)
+		if cost, ok := m["cost"].(float64); ok {
+			u.CostUSD = &cost
+		}
+
+		// OpenAI chat completions and responses, Anthropic and DeepSeek
+		// each name the details differently.
+		inputDetails, _ := m["prompt_tokens_details"].(map[string]interface{})
+		if d, ok := m["input_tokens_details"].(map[string]interface{}); ok {
+			inputDetails = d
+		}
+		outputDetails, _ := m["completion_tokens_details"].(map[string]interface{})
+		if d, ok := m["output_tokens_details"].(map[string]interface{}); ok {
+			outputDetails = d
+		}
+		recordDetail(&u.CacheReadTokens, inputDetails, "cached_tokens")
+		recordDetail(&u.CacheReadTokens, m, "cache_read_input_tokens")
+		recordDetail(&u.CacheReadTokens, m, "prompt_cache_hit_tokens")
+		recordDetail(&u.CacheWriteTokens, m, "cache_creation_input_tokens")
+		recordDetail(&u.ReasoningTokens, outputDetails, "reasoning_tokens")
+		_, inAudio := inputDetails["audio_tokens"]
+		_, outAudio := outputDetails["audio_tokens"]
+		if inAudio || outAudio {
+			recordMax(&u.AudioTokens, jsonInt(inputDetails, "audio_tokens")+jsonInt(outputDetails, "audio_tokens"))
+		}
+	}
+	if m, ok := obj["usageMetadata"].(map[string]interface{}); ok {
+		u.record(jsonInt(m, "promptTokenCount"), jsonInt(m, "candidatesTokenCount")+jsonInt(m, "thoughtsTokenCount"))
+		recordDetail(&u.CacheReadTokens, m, "cachedContentTokenCount")
+		recordDetail(&u.ReasoningTokens, m, "thoughtsTokenCount")
+		if audio, ok := geminiAudioTokens(m); ok {
+			recordMax(&u.AudioTokens, audio)
+		}
+	}
+
+	// Responses API stream events and Anthropic message_start wrap the
+	// object that carries the model and usage.
+	for _, key := range []string{"response", "message"} {
+		if nested, ok := obj[key].(map[string]interface{}); ok {
+			u.Absorb(nested)
+		}
+	}
+}
+
+func (u *ResponseUsage) record(input, output int) {
+	u.Found = true
+	u.InputTokens = max(u.InputTokens, input)
+	u.OutputTokens = max(u.OutputTokens, output)
+}
+
+// recordDetail keeps the largest value of a token detail reported as key in m.
+func recordDetail(dst **int, m map[string]interface{}, key string) {
+	if _, ok := m[key].(float64); ok {
+		recordMax(dst, jsonInt(m, key))
+	}
+}
+
+func recordMax(dst **int, v int) {
+	if *dst == nil || v > **dst {
+		*dst = &v
+	}
+}
+
+// geminiAudioTokens sums the AUDIO entries of Gemini's per-modality token
+// counts.
+func geminiAudioTokens(m map[string]interface{}) (int, bool) {
+	total, found := 0, false
+	for _, key := range []string{"promptTokensDetails", "candidatesTokensDetails"} {
+		details, _ := m[key].([]interface{})
+		for _, d := range details {
+			if entry, ok := d.(map[string]interface{}); ok && entry["modality"] == "AUDIO" {
+				total += jsonInt(entry, "tokenCount")
+				found = true
+			}
+		}
+	}
+	return total, found
+}
+
+func jsonInt(m map[string]interface{}, key string) int {
+	if v, ok := m[key].(float64); ok && v > 0 {
+		return int(v)
+	}
+	return 0
+}
+
+// AbsorbJSON reads usage from a complete JSON response body.
+func (u *ResponseUsage) AbsorbJSON(body []byte) {
+	var obj map[string]interface{}
+	if json.Unmarshal(body, &obj) == nil {
+		u.Absorb(obj)
+	}
+}
+
+// AbsorbSSELine reads usage from one line of a server-sent event stream.
+// Only data lines carry JSON; "[DONE]" ends OpenAI streams.
+func (u *ResponseUsage) AbsorbSSELine(line []byte) {
+	data, ok := bytes.CutPrefix(bytes.TrimRight(line, "\r"), []byte("data:"))
+	if !ok {
+		return
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("[DONE]")) {
+		return
+	}
+	u.AbsorbJSON(data)
+}
+
+// IsEventStream reports whether a response is a server-sent event stream.
+func IsEventStream(h http.Header) bool {
+	mediaType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	return mediaType == "text/event-stream"
+}
+
+// PeekRequestModel returns the model a request asks for, from its JSON body
+// or, for native Gemini calls, its path. It reads ahead in body, so callers
+// must send the returned reader in its place.
+func PeekRequestModel(body io.ReadCloser, path string) (string, io.ReadCloser, error) {
+	model := ModelFromPath(path)
+	if body == nil || body == http.NoBody {
+		return model, body, nil
+	}
+	peek, err := io.ReadAll(io.LimitReader(body, maxRequestPeek+1))
+	if err != nil {
+		body.Close()
+		return "", nil, err
+	}
+	if len(peek) <= maxRequestPeek {
+		var req struct {
+			Model string `json:"model"`
+		}
+		if json.Unmarshal(peek, &req) == nil && req.Model != "" {
+			model = req.Model
+		}
+	}
+	return model, struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(peek), body), body}, nil
+}
+
+// ModelFromPath returns the model of a native Gemini call such as
+// /v1beta/models/gemini-2.5-flash:streamGenerateContent.
+func ModelFromPath(path string) string {
+	_, rest, ok := strings.Cut(path, "/models/")
+	if !ok {
+		return ""
+	}
+	model, _, ok := strings.Cut(rest, ":")
+	if !ok || strings.Contains(model, "/") {
+		return ""
+	}
+	return model
+}
+
+// ErrorTypeForHTTPStatus classifies a failed API response by status code.
+func ErrorTypeForHTTPStatus(code int) string {
+	switch {
+	case code == http.StatusTooManyRequests:
+		return "rate_limit"
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return "auth"
+	case code == http.StatusRequestTimeout || code == http.StatusGatewayTimeout:
+		return "timeout"
+	case code == 529: // Anthropic's overloaded_error
+		return "overloaded"
+	case code >= 500:
+		return "server_error"
+	case code >= 400:
+		return "invalid_request"
+	default:
+		return "http_" + strconv.Itoa(code)
+	}
+}
+
+// ErrorTypeForError classifies a request that got no response.
+func ErrorTypeForError(err error) string {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return "timeout"
+	}
+	return "network"
+}
+
+// RateLimitHeaders collects the provider rate-limit headers of a response,
+// e.g. x-ratelimit-remaining-tokens or anthropic-ratelimit-requests-remaining,
+// keyed by lower-case name. It returns nil when there are none.
+func RateLimitHeaders(h http.Header) map[string]string {
+	var names []string
+	for name := range h {
+		if strings.Contains(strings.ToLower(name), "ratelimit") {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	if len(names) > maxRateLimitHeaders {
+		names = names[:maxRateLimitHeaders]
+	}
+	limits := make(map[string]string, len(names))
+	for _, name := range names {
+		value := h.Get(name)
+		if len(value) > 64 {
+			value = value[:64]
+		}
+		limits[strings.ToLower(name)] = value
+	}
+	return limits
+}
+
+// CaptureResponseUsage replaces resp.Body with one that passes the body
+// through unchanged while parsing its usage. done is called once, with the
+// usage seen so far, when the body is read to EOF or closed.
+func CaptureResponseUsage(resp *http.Response, done func(*ResponseUsage)) {
+	resp.Body = &usageBody{ReadCloser: resp.Body, sse: IsEventStream(resp.Header), done: done}
+}
+
+// usageBody parses usage out of a response body as it is read.
+type usageBody struct {
+	io.ReadCloser
+	sse  bool
+	done func(*ResponseUsage)
+
+	buf      bytes.Buffer // JSON body, or the current partial SSE line
+	overflow bool
+	usage    ResponseUsage
+	once     sync.Once
+}
+
+func (b *usageBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.consume(p[:n])
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *usageBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.finish()
+	return err
+}
+
+func (b *usageBody) consume(data []byte) {
+	if !b.sse {
+		if !b.overflow && b.buf.Len()+len(data) > maxCaptureBytes {
+			b.overflow = true
+			b.buf.Reset()
+		}
+		if !b.overflow {
+			b.buf.Write(data)
+		}
+		return
+	}
+	for len(data) > 0 {
+		line, rest, complete := bytes.Cut(data, []byte("\n"))
+		if !b.overflow {
+			b.buf.Write(line)
+			b.overflow = b.buf.Len() > maxCaptureBytes
+		}
+		if !complete {
+			return
+		}
+		if !b.overflow {
+			b.usage.AbsorbSSELine(b.buf.Bytes())
+		}
+		b.buf.Reset()
+		b.overflow = false
+		data = rest
+	}
+}
+
+func (b *usageBody) finish() {
+	b.once.Do(func() {
+		if !b.overflow && b.buf.Len() > 0 {
+			if b.sse {
+				b.usage.AbsorbSSELine(b.buf.Bytes())
+			} else {
+				b.usage.AbsorbJSON(b.buf.Bytes())
+			}
+		}
+		b.buf = bytes.Buffer{}
+		b.done(&b.usage)
+	})
+}
@@ -0,0 +1,86 @@
+package usageevent
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestProviderForHost(t *testing.T) {
+	tests := map[string]string{
+		"api.openai.com":                        "openai",
+		"API.Anthropic.com:443":                 "anthropic",
+		"generativelanguage.googleapis.com:443": "gemini",
+		"gateway.internal":                      "",
+		"[::1]:8080":                            "",
+	}
+	for host, want := range tests {
+		if got := ProviderForHost(host); got != want {
+			t.Errorf("ProviderForHost(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestModelFromPath(t *testing.T) {
+	tests := map[string]string{
+		"/v1beta/models/gemini-2.5-pro:generateContent": "gemini-2.5-pro",
+		"/v1/models/gpt-4.1":                            "",
+		"/v1/chat/completions":                          "",
+	}
+	for path, want := range tests {
+		if got := ModelFromPath(path); got != want {
+			t.Errorf("ModelFromPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestCaptureResponseUsage_JSONOnClose(t *testing.T) {
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": {"application/json"}},
+		Body:   io.NopCloser(strings.NewReader(`{"id":"resp_1","model":"gpt-4.1","usage":{"input_tokens":7,"output_tokens":3}}`)),
+	}
+	calls := 0
+	var got ResponseUsage
+	CaptureResponseUsage(resp, func(u *ResponseUsage) {
+		calls++
+		got = *u
+	})
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if calls != 1 {
+		t.Fatalf("done called %d times, want 1", calls)
+	}
+	if !got.Found || got.Model != "gpt-4.1" || got.ResponseID != "resp_1" || got.InputTokens != 7 || got.OutputTokens != 3 {
+		t.Fatalf("usage=%+v", got)
+	}
+}
+
+func TestResponseUsage_TokenDetails(t *testing.T) {
+	var openai ResponseUsage
+	openai.AbsorbJSON([]byte(`{"model":"o3","usage":{"prompt_tokens":100,"completion_tokens":50,"prompt_tokens_details":{"cached_tokens":64,"audio_tokens":0},"completion_tokens_details":{"reasoning_tokens":32}}}`))
+	if openai.CacheReadTokens == nil || *openai.CacheReadTokens != 64 || openai.ReasoningTokens == nil || *openai.ReasoningTokens != 32 || openai.CacheWriteTokens != nil {
+		t.Fatalf("openai=%+v", openai)
+	}
+
+	var anthropic ResponseUsage
+	anthropic.AbsorbSSELine([]byte(`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4","usage":{"input_tokens":10,"cache_read_input_tokens":500,"cache_creation_input_tokens":200,"output_tokens":1}}}`))
+	anthropic.AbsorbSSELine([]byte(`data: {"type":"message_delta","usage":{"output_tokens":25}}`))
+	if anthropic.CacheReadTokens == nil || *anthropic.CacheReadTokens != 500 || *anthropic.CacheWriteTokens != 200 || anthropic.InputTokens != 710 || anthropic.OutputTokens != 25 {
+		t.Fatalf("anthropic=%+v", anthropic)
+	}
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Anthropic-Ratelimit-Tokens-Remaining", "9000")
+	h.Set("Content-Type", "application/json")
+	limits := RateLimitHeaders(h)
+	if len(limits) != 1 || limits["anthropic-ratelimit-tokens-remaining"] != "9000" {
+		t.Fatalf("limits=%v", limits)
+	}
+	if RateLimitHeaders(http.Header{}) != nil {
+		t.Fatal("expected nil without rate-limit headers")
+	}
+}
@@ -0,0 +1,356 @@
+// Package usageevent defines the usage event schema onWatch ingests and the
+// helpers that capture it from LLM API traffic. It has no dependencies
+// outside the standard library so that client packages such as llmusage can
+// import it without pulling in the rest of onWatch.
+package usageevent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	maxIntegrationFieldLen = 256
+	maxMetadataJSONLen     = 4096
+	maxProviderIDLen       = 64
+
+	// MaxErrorTypeLen is the longest error_type an event may carry.
+	MaxErrorTypeLen = 64
+)
+
+// Event statuses. Events without a status are successful calls unless they
+// carry an error_type.
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// Event is the normalized API integration telemetry event stored by onWatch.
+type Event struct {
+	Timestamp        time.Time
+	Integration      string
+	Provider         string
+	Account          string
+	Model            string
+	RequestID        string
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+	// Optional token details. Cache reads and writes are part of
+	// PromptTokens, reasoning tokens part of CompletionTokens and audio
+	// tokens part of TotalTokens. Events using Anthropic's usage fields are
+	// normalized to this convention when parsed.
+	CacheReadTokens  *int
+	CacheWriteTokens *int
+	ReasoningTokens  *int
+	AudioTokens      *int
+	CostUSD          *float64
+	// EstimatedCostUSD is set by onWatch from its pricing catalog when the
+	// event reports no cost; it is never read from the event itself.
+	EstimatedCostUSD *float64
+	LatencyMS        *int
+	// Status is StatusOK or StatusError. ErrorType classifies failed calls,
+	// e.g. "rate_limit" or "server_error"; it is empty for successful ones.
+	Status       string
+	ErrorType    string
+	MetadataJSON string
+	SourcePath   string
+	Fingerprint  string
+}
+
+type usageEventWire struct {
+	TS               string          `json:"ts"`
+	Integration      string          `json:"integration"`
+	Provider         string          `json:"provider"`
+	Account          string          `json:"account"`
+	Model            string          `json:"model"`
+	RequestID        string          `json:"request_id"`
+	PromptTokens     int             `json:"prompt_tokens"`
+	CompletionTokens int             `json:"completion_tokens"`
+	TotalTokens      *int            `json:"total_tokens"`
+	CacheReadTokens  *int            `json:"cache_read_tokens"`
+	CacheWriteTokens *int            `json:"cache_write_tokens"`
+	ReasoningTokens  *int            `json:"reasoning_tokens"`
+	AudioTokens      *int            `json:"audio_tokens"`
+	CostUSD          *float64        `json:"cost_usd"`
+	LatencyMS        *int            `json:"latency_ms"`
+	Status           string          `json:"status"`
+	ErrorType        string          `json:"error_type"`
+	Metadata         json.RawMessage `json:"metadata"`
+
+	// Anthropic-native usage fields, an alternative to the token fields
+	// above. input_tokens excludes cached tokens.
+	InputTokens              *int `json:"input_tokens"`
+	OutputTokens             *int `json:"output_tokens"`
+	CacheReadInputTokens     *int `json:"cache_read_input_tokens"`
+	CacheCreationInputTokens *int `json:"cache_creation_input_tokens"`
+}
+
+// normalizeAnthropicUsage maps Anthropic-native usage fields onto the
+// normalized ones, where prompt_tokens includes cache reads and writes.
+func (w *usageEventWire) normalizeAnthropicUsage() error {
+	if w.InputTokens == nil && w.OutputTokens == nil && w.CacheReadInputTokens == nil && w.CacheCreationInputTokens == nil {
+		return nil
+	}
+	if w.PromptTokens != 0 || w.CompletionTokens != 0 || w.CacheReadTokens != nil || w.CacheWriteTokens != nil {
+		return fmt.Errorf("Anthropic usage fields cannot be combined with prompt_tokens, completion_tokens, cache_read_tokens or cache_write_tokens")
+	}
+	for _, field := range []struct {
+		name  string
+		value *int
+	}{
+		{"input_tokens", w.InputTokens},
+		{"output_tokens", w.OutputTokens},
+		{"cache_read_input_tokens", w.CacheReadInputTokens},
+		{"cache_creation_input_tokens", w.CacheCreationInputTokens},
+	} {
+		if field.value != nil && *field.value < 0 {
+			return fmt.Errorf("%s must be >= 0", field.name)
+		}
+	}
+	w.PromptTokens = derefInt(w.InputTokens) + derefInt(w.CacheReadInputTokens) + derefInt(w.CacheCreationInputTokens)
+	w.CompletionTokens = derefInt(w.OutputTokens)
+	w.CacheReadTokens = w.CacheReadInputTokens
+	w.CacheWriteTokens = w.CacheCreationInputTokens
+	return nil
+}
+
+// ParseLine validates and normalizes a single JSONL event line.
+func ParseLine(line []byte, sourcePath string) (*Event, error) {
+	trimmed := strings.TrimSpace(string(line))
+	if trimmed == "" {
+		return nil, fmt.Errorf("empty event line")
+	}
+
+	var wire usageEventWire
+	if err := json.Unmarshal([]byte(trimmed), &wire); err != nil {
+		return nil, fmt.Errorf("parse API integration usage event: %w", err)
+	}
+	if err := wire.normalizeAnthropicUsage(); err != nil {
+		return nil, err
+	}
+
+	ts, err := time.Parse(time.RFC3339, strings.TrimSpace(wire.TS))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ts: %w", err)
+	}
+
+	integrationName := strings.TrimSpace(wire.Integration)
+	if integrationName == "" {
+		return nil, fmt.Errorf("integration is required")
+	}
+	if len(integrationName) > maxIntegrationFieldLen {
+		return nil, fmt.Errorf("integration exceeds %d characters", maxIntegrationFieldLen)
+	}
+
+	provider, err := NormalizeProvider(wire.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	model := strings.TrimSpace(wire.Model)
+	if model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+	if len(model) > maxIntegrationFieldLen {
+		return nil, fmt.Errorf("model exceeds %d characters", maxIntegrationFieldLen)
+	}
+
+	if wire.PromptTokens < 0 {
+		return nil, fmt.Errorf("prompt_tokens must be >= 0")
+	}
+	if wire.CompletionTokens < 0 {
+		return nil, fmt.Errorf("completion_tokens must be >= 0")
+	}
+
+	totalTokens := wire.PromptTokens + wire.CompletionTokens
+	if wire.TotalTokens != nil {
+		if *wire.TotalTokens < 0 {
+			return nil, fmt.Errorf("total_tokens must be >= 0")
+		}
+		totalTokens = *wire.TotalTokens
+	}
+
+	for _, detail := range []struct {
+		name  string
+		value *int
+	}{
+		{"cache_read_tokens", wire.CacheReadTokens},
+		{"cache_write_tokens", wire.CacheWriteTokens},
+		{"reasoning_tokens", wire.ReasoningTokens},
+		{"audio_tokens", wire.AudioTokens},
+	} {
+		if detail.value != nil && *detail.value < 0 {
+			return nil, fmt.Errorf("%s must be >= 0", detail.name)
+		}
+	}
+	if derefInt(wire.CacheReadTokens)+derefInt(wire.CacheWriteTokens) > wire.PromptTokens {
+		return nil, fmt.Errorf("cache_read_tokens and cache_write_tokens must be included in prompt_tokens (or send Anthropic's input_tokens and cache_*_input_tokens fields)")
+	}
+	if derefInt(wire.ReasoningTokens) > wire.CompletionTokens {
+		return nil, fmt.Errorf("reasoning_tokens must be included in completion_tokens")
+	}
+	if derefInt(wire.AudioTokens) > totalTokens {
+		return nil, fmt.Errorf("audio_tokens must be included in total_tokens")
+	}
+
+	if wire.CostUSD != nil && *wire.CostUSD < 0 {
+		return nil, fmt.Errorf("cost_usd must be >= 0")
+	}
+	if wire.LatencyMS != nil && *wire.LatencyMS < 0 {
+		return nil, fmt.Errorf("latency_ms must be >= 0")
+	}
+
+	status := strings.ToLower(strings.TrimSpace(wire.Status))
+	errorType := strings.TrimSpace(wire.ErrorType)
+	switch status {
+	case "":
+		status = StatusOK
+		if errorType != "" {
+			status = StatusError
+		}
+	case StatusOK:
+		if errorType != "" {
+			return nil, fmt.Errorf("error_type requires status %q", StatusError)
+		}
+	case StatusError:
+	default:
+		return nil, fmt.Errorf("status must be %q or %q", StatusOK, StatusError)
+	}
+	if len(errorType) > MaxErrorTypeLen {
+		return nil, fmt.Errorf("error_type exceeds %d characters", MaxErrorTypeLen)
+	}
+
+	account := strings.TrimSpace(wire.Account)
+	if account == "" {
+		account = "default"
+	}
+	if len(account) > maxIntegrationFieldLen {
+		return nil, fmt.Errorf("account exceeds %d characters", maxIntegrationFieldLen)
+	}
+
+	metadataJSON := ""
+	if len(wire.Metadata) > 0 && string(wire.Metadata) != "null" {
+		var metadata map[string]interface{}
+		if err := json.Unmarshal(wire.Metadata, &metadata); err != nil {
+			return nil, fmt.Errorf("metadata must be a JSON object: %w", err)
+		}
+		compact, err := json.Marshal(metadata)
+		if err != nil {
+			return nil, fmt.Errorf("compact metadata: %w", err)
+		}
+		metadataJSON = string(compact)
+	}
+	if len(metadataJSON) > maxMetadataJSONLen {
+		return nil, fmt.Errorf("metadata_json exceeds %d bytes after compaction", maxMetadataJSONLen)
+	}
+
+	event := &Event{
+		Timestamp:        ts.UTC(),
+		Integration:      integrationName,
+		Provider:         provider,
+		Account:          account,
+		Model:            model,
+		RequestID:        strings.TrimSpace(wire.RequestID),
+		PromptTokens:     wire.PromptTokens,
+		CompletionTokens: wire.CompletionTokens,
+		TotalTokens:      totalTokens,
+		CacheReadTokens:  wire.CacheReadTokens,
+		CacheWriteTokens: wire.CacheWriteTokens,
+		ReasoningTokens:  wire.ReasoningTokens,
+		AudioTokens:      wire.AudioTokens,
+		CostUSD:          wire.CostUSD,
+		LatencyMS:        wire.LatencyMS,
+		Status:           status,
+		ErrorType:        errorType,
+		MetadataJSON:     metadataJSON,
+		SourcePath:       sourcePath,
+	}
+	event.Fingerprint = eventFingerprint(event)
+	return event, nil
+}
+
+// FromFields builds a usage event from fields keyed like a JSONL line,
+// validating them through ParseLine.
+func FromFields(fields map[string]interface{}, sourcePath string) (*Event, error) {
+	line, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLine(line, sourcePath)
+}
+
+func derefInt(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// eventFingerprint identifies an event for deduplication. Lines that agree
+// on every field hashed here are taken to be the same event read twice.
+func eventFingerprint(event *Event) string {
+	h := sha256.New()
+	writeHashPart(h, event.SourcePath)
+	writeHashPart(h, event.Timestamp.Format(time.RFC3339Nano))
+	writeHashPart(h, event.Integration)
+	writeHashPart(h, event.Provider)
+	writeHashPart(h, event.Account)
+	writeHashPart(h, event.Model)
+	writeHashPart(h, fmt.Sprintf("%d", event.PromptTokens))
+	writeHashPart(h, fmt.Sprintf("%d", event.CompletionTokens))
+	writeHashPart(h, fmt.Sprintf("%d", event.TotalTokens))
+	writeHashPart(h, event.RequestID)
+
+	// Status, error type and token details were added later and are hashed
+	// only when set, so events without them keep the fingerprints they were
+	// stored with. Latency predates them but was never hashed; hashing it now
+	// would change the fingerprint of lines already ingested.
+	if event.Status != StatusOK {
+		writeHashPart(h, "status="+event.Status)
+	}
+	if event.ErrorType != "" {
+		writeHashPart(h, "error_type="+event.ErrorType)
+	}
+	for _, detail := range []struct {
+		name  string
+		value *int
+	}{
+		{"cache_read_tokens", event.CacheReadTokens},
+		{"cache_write_tokens", event.CacheWriteTokens},
+		{"reasoning_tokens", event.ReasoningTokens},
+		{"audio_tokens", event.AudioTokens},
+	} {
+		if detail.value != nil {
+			writeHashPart(h, fmt.Sprintf("%s=%d", detail.name, *detail.value))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeHashPart(h interface{ Write([]byte) (int, error) }, part string) {
+	_, _ = h.Write([]byte(part))
+	_, _ = h.Write([]byte{0})
+}
+
+// NormalizeProvider lower-cases a provider id and checks that it is a short
+// slug of letters, digits, '.', '_' and '-', e.g. "openai" or "my-vllm".
+func NormalizeProvider(raw string) (string, error) {
+	id := strings.ToLower(strings.TrimSpace(raw))
+	if id == "" {
+		return "", fmt.Errorf("provider is required")
+	}
+	if len(id) > maxProviderIDLen {
+		return "", fmt.Errorf("provider exceeds %d characters", maxProviderIDLen)
+	}
+	for _, r := range id {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '.' && r != '_' && r != '-' {
+			return "", fmt.Errorf("invalid provider %q: use letters, digits, '.', '_' or '-'", raw)
+		}
+	}
+	return id, nil
+}
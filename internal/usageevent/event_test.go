@@ -0,0 +1,244 @@
+package usageevent
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseLine_Success(t *testing.T) {
+	line := []byte(`{"ts":"2026-04-03T12:00:00Z","integration":"notes-organiser","provider":"anthropic","model":"claude-3-7-sonnet","prompt_tokens":12,"completion_tokens":5,"metadata":{"task":"weekly"}}`)
+
+	event, err := ParseLine(line, "/tmp/api-integrations/notes.jsonl")
+	if err != nil {
+		t.Fatalf("ParseLine: %v", err)
+	}
+	if event.Integration != "notes-organiser" {
+		t.Fatalf("integration=%q", event.Integration)
+	}
+	if event.Provider != "anthropic" {
+		t.Fatalf("provider=%q", event.Provider)
+	}
+	if event.Account != "default" {
+		t.Fatalf("account=%q", event.Account)
+	}
+	if event.TotalTokens != 17 {
+		t.Fatalf("total_tokens=%d", event.TotalTokens)
+	}
+	if event.MetadataJSON != `{"task":"weekly"}` {
+		t.Fatalf("metadata=%q", event.MetadataJSON)
+	}
+	if event.Fingerprint == "" {
+		t.Fatal("expected fingerprint")
+	}
+}
+
+func TestParseLine_RejectsInvalidProvider(t *testing.T) {
+	for _, provider := range []string{"", "my provider", "vllm/local"} {
+		line := []byte(`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"` + provider + `","model":"x","prompt_tokens":1,"completion_tokens":1}`)
+		if _, err := ParseLine(line, "/tmp/test.jsonl"); err == nil {
+			t.Fatalf("provider %q: expected error", provider)
+		}
+	}
+}
+
+func TestParseLine_AcceptsCustomProvider(t *testing.T) {
+	line := []byte(`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":" Local-vLLM ","model":"x","prompt_tokens":1,"completion_tokens":1}`)
+	event, err := ParseLine(line, "/tmp/test.jsonl")
+	if err != nil {
+		t.Fatalf("ParseLine: %v", err)
+	}
+	if event.Provider != "local-vllm" {
+		t.Fatalf("provider=%q want local-vllm", event.Provider)
+	}
+}
+
+func TestParseLine_RejectsInvalidMetadata(t *testing.T) {
+	line := []byte(`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"openai","model":"gpt-4.1-mini","prompt_tokens":1,"completion_tokens":1,"metadata":["bad"]}`)
+	if _, err := ParseLine(line, "/tmp/test.jsonl"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestParseLine_RejectsOverlongFields(t *testing.T) {
+	long := func(n int) string {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = 'a'
+		}
+		return string(b)
+	}
+
+	// integration too long
+	line := []byte(`{"ts":"2026-04-03T12:00:00Z","integration":"` + long(maxIntegrationFieldLen+1) + `","provider":"anthropic","model":"claude-3-7-sonnet","prompt_tokens":1,"completion_tokens":1}`)
+	if _, err := ParseLine(line, "/tmp/test.jsonl"); err == nil {
+		t.Fatal("expected error for overlong integration")
+	}
+
+	// model too long
+	line = []byte(`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"anthropic","model":"` + long(maxIntegrationFieldLen+1) + `","prompt_tokens":1,"completion_tokens":1}`)
+	if _, err := ParseLine(line, "/tmp/test.jsonl"); err == nil {
+		t.Fatal("expected error for overlong model")
+	}
+
+	// account too long
+	line = []byte(`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"anthropic","model":"claude-3-7-sonnet","account":"` + long(maxIntegrationFieldLen+1) + `","prompt_tokens":1,"completion_tokens":1}`)
+	if _, err := ParseLine(line, "/tmp/test.jsonl"); err == nil {
+		t.Fatal("expected error for overlong account")
+	}
+}
+
+func TestParseLine_RejectsOverlongMetadata(t *testing.T) {
+	// Build a metadata object whose compacted JSON exceeds maxMetadataJSONLen
+	// by repeating a key-value pair enough times.
+	pairs := make([]string, 0, 200)
+	for i := 0; i < 200; i++ {
+		pairs = append(pairs, fmt.Sprintf(`"key%d":"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"`, i))
+	}
+	metadata := "{" + strings.Join(pairs, ",") + "}"
+	line := []byte(`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"anthropic","model":"claude-3-7-sonnet","prompt_tokens":1,"completion_tokens":1,"metadata":` + metadata + `}`)
+	if _, err := ParseLine(line, "/tmp/test.jsonl"); err == nil {
+		t.Fatal("expected error for overlong metadata")
+	}
+}
+
+func TestParseLine_FingerprintDependsOnSourcePath(t *testing.T) {
+	line := []byte(`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"mistral","model":"mistral-small-latest","prompt_tokens":1,"completion_tokens":1}`)
+
+	a, err := ParseLine(line, "/tmp/a.jsonl")
+	if err != nil {
+		t.Fatalf("ParseLine(a): %v", err)
+	}
+	b, err := ParseLine(line, "/tmp/b.jsonl")
+	if err != nil {
+		t.Fatalf("ParseLine(b): %v", err)
+	}
+	if a.Fingerprint == b.Fingerprint {
+		t.Fatal("expected different fingerprints for different source files")
+	}
+}
+
+func TestParseLine_FingerprintCoversErrorAndDetailFields(t *testing.T) {
+	// Unchanged for lines in the original format, latency included, so
+	// rereading lines stored before the new fields existed adds no duplicates.
+	for _, tt := range []struct{ line, want string }{
+		{`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"mistral","model":"mistral-small-latest","prompt_tokens":1,"completion_tokens":1}`, "c890a7a89257cbb413079ca6b3e98e98d12e1176d79a103fac691e7098683ad8"},
+		{`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"openai","model":"gpt-4.1","request_id":"req_1","prompt_tokens":12,"completion_tokens":5,"latency_ms":420}`, "c0ded3cf9a7f98e9e2b210e0c4bab292076a528af26bc72c019bd51d6da0c673"},
+	} {
+		event, err := ParseLine([]byte(tt.line), "/tmp/a.jsonl")
+		if err != nil {
+			t.Fatalf("ParseLine: %v", err)
+		}
+		if event.Fingerprint != tt.want {
+			t.Errorf("fingerprint of %s = %s want %s", tt.line, event.Fingerprint, tt.want)
+		}
+	}
+
+	// Two failed calls in the same second without a request id.
+	base := `{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"openai","model":"gpt-4.1","prompt_tokens":0,"completion_tokens":0,"status":"error"`
+	seen := map[string]string{}
+	for _, extra := range []string{
+		``,
+		`,"error_type":"rate_limit"`,
+		`,"error_type":"timeout"`,
+		`,"cache_read_tokens":0`,
+		`,"reasoning_tokens":0`,
+	} {
+		event, err := ParseLine([]byte(base+extra+"}"), "/tmp/a.jsonl")
+		if err != nil {
+			t.Fatalf("ParseLine(%s): %v", extra, err)
+		}
+		if prev, ok := seen[event.Fingerprint]; ok {
+			t.Errorf("%q and %q share a fingerprint", prev, extra)
+		}
+		seen[event.Fingerprint] = extra
+	}
+	ok, err := ParseLine([]byte(strings.Replace(base, `"error"`, `"ok"`, 1)+"}"), "/tmp/a.jsonl")
+	if err != nil {
+		t.Fatalf("ParseLine(ok): %v", err)
+	}
+	if _, dup := seen[ok.Fingerprint]; dup {
+		t.Error("a successful call shares a fingerprint with a failed one")
+	}
+}
+
+func TestParseLine_TokenDetails(t *testing.T) {
+	line := []byte(`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"anthropic","model":"claude-sonnet-4","prompt_tokens":100,"completion_tokens":40,"cache_read_tokens":60,"cache_write_tokens":20,"reasoning_tokens":30}`)
+	event, err := ParseLine(line, "/tmp/test.jsonl")
+	if err != nil {
+		t.Fatalf("ParseLine: %v", err)
+	}
+	if event.CacheReadTokens == nil || *event.CacheReadTokens != 60 || *event.CacheWriteTokens != 20 || *event.ReasoningTokens != 30 || event.AudioTokens != nil {
+		t.Fatalf("details=%v %v %v %v", event.CacheReadTokens, event.CacheWriteTokens, event.ReasoningTokens, event.AudioTokens)
+	}
+
+	for _, fields := range []string{
+		`"cache_read_tokens":-1`,
+		`"cache_read_tokens":80,"cache_write_tokens":30`,
+		`"reasoning_tokens":41`,
+		`"audio_tokens":141`,
+	} {
+		line := []byte(`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"openai","model":"o3","prompt_tokens":100,"completion_tokens":40,` + fields + `}`)
+		if _, err := ParseLine(line, "/tmp/test.jsonl"); err == nil {
+			t.Errorf("%s: expected error", fields)
+		}
+	}
+}
+
+func TestParseLine_AnthropicUsageFields(t *testing.T) {
+	// Anthropic's input_tokens excludes cached tokens, so the cache reads
+	// here exceed it.
+	line := []byte(`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"anthropic","model":"claude-sonnet-4","input_tokens":20,"cache_read_input_tokens":900,"cache_creation_input_tokens":80,"output_tokens":40}`)
+	event, err := ParseLine(line, "/tmp/test.jsonl")
+	if err != nil {
+		t.Fatalf("ParseLine: %v", err)
+	}
+	if event.PromptTokens != 1000 || event.CompletionTokens != 40 || event.TotalTokens != 1040 {
+		t.Fatalf("tokens prompt=%d completion=%d total=%d want 1000/40/1040", event.PromptTokens, event.CompletionTokens, event.TotalTokens)
+	}
+	if event.CacheReadTokens == nil || *event.CacheReadTokens != 900 || event.CacheWriteTokens == nil || *event.CacheWriteTokens != 80 {
+		t.Fatalf("cache read=%v write=%v want 900/80", event.CacheReadTokens, event.CacheWriteTokens)
+	}
+
+	for _, fields := range []string{
+		`"input_tokens":-1`,
+		`"input_tokens":20,"prompt_tokens":1000`,
+		`"input_tokens":20,"cache_read_tokens":900`,
+	} {
+		line := []byte(`{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"anthropic","model":"claude-sonnet-4",` + fields + `}`)
+		if _, err := ParseLine(line, "/tmp/test.jsonl"); err == nil {
+			t.Errorf("%s: expected error", fields)
+		}
+	}
+}
+
+func TestParseLine_Status(t *testing.T) {
+	base := `{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"openai","model":"gpt-4.1","prompt_tokens":0,"completion_tokens":0`
+	tests := []struct {
+		extra, wantStatus, wantType string
+	}{
+		{``, StatusOK, ""},
+		{`,"status":"OK"`, StatusOK, ""},
+		{`,"status":"error"`, StatusError, ""},
+		{`,"status":"error","error_type":"rate_limit"`, StatusError, "rate_limit"},
+		{`,"error_type":"server_error"`, StatusError, "server_error"},
+	}
+	for _, tt := range tests {
+		event, err := ParseLine([]byte(base+tt.extra+"}"), "/tmp/a.jsonl")
+		if err != nil {
+			t.Fatalf("ParseLine(%s): %v", tt.extra, err)
+		}
+		if event.Status != tt.wantStatus || event.ErrorType != tt.wantType {
+			t.Errorf("ParseLine(%s) status=%q error_type=%q", tt.extra, event.Status, event.ErrorType)
+		}
+	}
+	for _, extra := range []string{
+		`,"status":"failed"`,
+		`,"status":"ok","error_type":"timeout"`,
+		`,"error_type":"` + strings.Repeat("x", 65) + `"`,
+	} {
+		if _, err := ParseLine([]byte(base+extra+"}"), "/tmp/a.jsonl"); err == nil {
+			t.Errorf("ParseLine(%s) succeeded, want error", extra)
+		}
+	}
+}
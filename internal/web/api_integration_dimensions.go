@@ -0,0 +1,147 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	apiintegrations "github.com/onllm-dev/onwatch/v2/internal/api_integrations"
+)
+
+// settingAPIIntegrationDimensions stores the metadata keys promoted to
+// indexed dimensions of API integration events.
+const settingAPIIntegrationDimensions = "api_integration_dimensions"
+
+// maxAPIIntegrationGroupBy caps the dimensions of one breakdown query.
+const maxAPIIntegrationGroupBy = 4
+
+// parseAPIIntegrationDimensions validates the API integration dimensions
+// settings value, a list of metadata keys.
+func parseAPIIntegrationDimensions(raw json.RawMessage) ([]string, error) {
+	var keys []string
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return nil, fmt.Errorf("invalid API integration dimensions value")
+	}
+	return apiintegrations.ParseDimensions(keys)
+}
+
+// loadAPIIntegrationDimensions returns the promoted metadata keys, or none
+// when unset.
+func (h *Handler) loadAPIIntegrationDimensions() []string {
+	keys := []string{}
+	if h.store == nil {
+		return keys
+	}
+	raw, err := h.store.GetSetting(settingAPIIntegrationDimensions)
+	if err != nil || raw == "" {
+		return keys
+	}
+	if parsed, err := parseAPIIntegrationDimensions(json.RawMessage(raw)); err == nil {
+		keys = parsed
+	}
+	return keys
+}
+
+// apiIntegrationBreakdownRow is one group of a breakdown response.
+type apiIntegrationBreakdownRow struct {
+	Values           map[string]string `json:"values"`
+	RequestCount     int               `json:"requestCount"`
+	PromptTokens     int               `json:"promptTokens"`
+	CompletionTokens int               `json:"completionTokens"`
+	TotalTokens      int               `json:"totalTokens"`
+	TotalCostUSD     float64           `json:"totalCostUsd"`
+	EstimatedCostUSD *float64          `json:"estimatedCostUsd,omitempty"`
+}
+
+// APIIntegrationsBreakdown groups API integration usage over a range by up
+// to four dimensions: integration, provider, account, model or a promoted
+// metadata key, e.g. ?group_by=feature,model&range=7d.
+func (h *Handler) APIIntegrationsBreakdown(w http.ResponseWriter, r *http.Request) {
+	rangeStr := r.URL.Query().Get("range")
+	duration, err := parseTimeRange(rangeStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	dimensions := h.loadAPIIntegrationDimensions()
+	groupBy, err := parseAPIIntegrationGroupBy(r.URL.Query().Get("group_by"), dimensions)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if rangeStr == "" {
+		rangeStr = "6h"
+	}
+	response := map[string]interface{}{
+		"range":      rangeStr,
+		"groupBy":    groupBy,
+		"dimensions": dimensions,
+		"rows":       []apiIntegrationBreakdownRow{},
+	}
+	if h.store == nil {
+		respondJSON(w, http.StatusOK, response)
+		return
+	}
+
+	now := time.Now().UTC()
+	groups, err := h.store.QueryAPIIntegrationUsageGroups(now.Add(-duration), now, groupBy)
+	if err != nil {
+		h.logger.Error("failed to query API integrations breakdown", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to query API integrations breakdown")
+		return
+	}
+	rows := make([]apiIntegrationBreakdownRow, 0, len(groups))
+	for _, group := range groups {
+		row := apiIntegrationBreakdownRow{
+			Values:           make(map[string]string, len(groupBy)),
+			RequestCount:     group.RequestCount,
+			PromptTokens:     group.PromptTokens,
+			CompletionTokens: group.CompletionTokens,
+			TotalTokens:      group.TotalTokens,
+			TotalCostUSD:     group.TotalCostUSD,
+		}
+		for i, dimension := range groupBy {
+			row.Values[dimension] = group.Values[i]
+		}
+		if group.EstimatedCostUSD > 0 {
+			estimated := group.EstimatedCostUSD
+			row.EstimatedCostUSD = &estimated
+		}
+		rows = append(rows, row)
+	}
+	response["rows"] = rows
+	respondJSON(w, http.StatusOK, response)
+}
+
+// parseAPIIntegrationGroupBy parses a comma-separated group_by parameter
+// against the built-in dimensions and the promoted metadata keys. It
+// defaults to integration.
+func parseAPIIntegrationGroupBy(raw string, promoted []string) ([]string, error) {
+	known := make(map[string]bool, len(promoted))
+	for _, key := range promoted {
+		known[key] = true
+	}
+	var groupBy []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if !apiintegrations.IsBuiltinDimension(name) && !known[name] {
+			return nil, fmt.Errorf("unknown dimension: %s", name)
+		}
+		seen[name] = true
+		groupBy = append(groupBy, name)
+	}
+	if len(groupBy) == 0 {
+		groupBy = []string{"integration"}
+	}
+	if len(groupBy) > maxAPIIntegrationGroupBy {
+		return nil, fmt.Errorf("at most %d group_by dimensions are supported", maxAPIIntegrationGroupBy)
+	}
+	return groupBy, nil
+}
@@ -0,0 +1,90 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/config"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+func TestHandler_APIIntegrationsBreakdown(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	ts := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	for i, event := range []string{
+		`"model":"gpt-4.1","prompt_tokens":100,"completion_tokens":10,"cost_usd":0.5,"metadata":{"feature":"search"}`,
+		`"model":"gpt-4.1-mini","prompt_tokens":10,"completion_tokens":1,"cost_usd":0.25,"metadata":{"feature":"search"}`,
+		`"model":"gpt-4.1","prompt_tokens":40,"completion_tokens":4,"cost_usd":2,"metadata":{"feature":"summaries"}`,
+	} {
+		line := fmt.Sprintf(`{"ts":%q,"integration":"notes","provider":"openai","request_id":"r%d",%s}`, ts, i, event)
+		insertAPIIntegrationEventForTest(t, s, line, "/tmp/api-integrations/notes.jsonl")
+	}
+	h := NewHandler(s, nil, nil, nil, &config.Config{APIIntegrationsEnabled: true})
+
+	breakdown := func(query string) (int, map[string]json.RawMessage) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		h.APIIntegrationsBreakdown(rr, httptest.NewRequest(http.MethodGet, "/api/api-integrations/breakdown?"+query, nil))
+		var response map[string]json.RawMessage
+		if rr.Code == http.StatusOK {
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("json.Unmarshal: %v", err)
+			}
+		}
+		return rr.Code, response
+	}
+
+	if code, _ := breakdown("group_by=feature"); code != http.StatusBadRequest {
+		t.Fatalf("undeclared dimension status=%d want 400", code)
+	}
+	rr := httptest.NewRecorder()
+	h.UpdateSettings(rr, httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(`{"api_integration_dimensions":["feature"]}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("UpdateSettings: %d %s", rr.Code, rr.Body.String())
+	}
+
+	code, response := breakdown("group_by=feature,model&range=24h")
+	if code != http.StatusOK {
+		t.Fatalf("status=%d want 200", code)
+	}
+	var rows []apiIntegrationBreakdownRow
+	if err := json.Unmarshal(response["rows"], &rows); err != nil {
+		t.Fatalf("json.Unmarshal rows: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("len(rows)=%d want 3", len(rows))
+	}
+	if rows[0].Values["feature"] != "summaries" || rows[0].Values["model"] != "gpt-4.1" || rows[0].TotalCostUSD != 2 {
+		t.Fatalf("rows[0]=%+v", rows[0])
+	}
+	if string(response["dimensions"]) != `["feature"]` || string(response["groupBy"]) != `["feature","model"]` || string(response["range"]) != `"24h"` {
+		t.Fatalf("response=%s %s %s", response["dimensions"], response["groupBy"], response["range"])
+	}
+
+	code, response = breakdown("")
+	if code != http.StatusOK || string(response["groupBy"]) != `["integration"]` {
+		t.Fatalf("default breakdown=%d %s", code, response["groupBy"])
+	}
+	for _, query := range []string{"group_by=team", "range=2y", "group_by=integration,provider,account,model,feature"} {
+		if code, _ := breakdown(query); code != http.StatusBadRequest {
+			t.Errorf("breakdown(%s) status=%d want 400", query, code)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	h.UpdateSettings(rr, httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(`{"api_integration_dimensions":["provider"]}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("built-in dimension status=%d want 400", rr.Code)
+	}
+}
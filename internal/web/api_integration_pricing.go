@@ -0,0 +1,89 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	apiintegrations "github.com/onllm-dev/onwatch/v2/internal/api_integrations"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+// settingAPIIntegrationPricingFingerprint stores the fingerprint of the
+// catalog and provider rates the stored estimates were last computed with.
+const settingAPIIntegrationPricingFingerprint = "api_integration_pricing_fingerprint"
+
+// NewAPIIntegrationCostEstimator returns the estimator for events without a
+// reported cost: catalog prices first, then the rates of the providers
+// configured in s. A nil catalog means the built-in one.
+func NewAPIIntegrationCostEstimator(s *store.Store, catalog *apiintegrations.PricingCatalog) *apiintegrations.CostEstimator {
+	if catalog == nil {
+		catalog = apiintegrations.BuiltinPricingCatalog()
+	}
+	return apiintegrations.NewCostEstimator(catalog, apiintegrations.NewProviderRegistry(storedAPIIntegrationProviders(s)))
+}
+
+// SetAPIIntegrationPricing sets the pricing catalog and, if it differs from
+// the one stored events were estimated with, re-estimates them in the
+// background.
+func (h *Handler) SetAPIIntegrationPricing(catalog *apiintegrations.PricingCatalog) {
+	h.pricingCatalog = catalog
+	h.applyAPIIntegrationPricing()
+}
+
+// applyAPIIntegrationPricing installs the current estimator in the store,
+// after the catalog or provider rates change. Stored events are re-estimated
+// in the background, and only when the prices differ from those of the last
+// completed re-estimate, so a restart with unchanged prices costs nothing.
+func (h *Handler) applyAPIIntegrationPricing() {
+	if h.store == nil {
+		return
+	}
+	h.store.SetAPIIntegrationCostEstimator(NewAPIIntegrationCostEstimator(h.store, h.pricingCatalog))
+	h.reestimateWG.Add(1)
+	go func() {
+		defer h.reestimateWG.Done()
+		h.reestimateMu.Lock()
+		defer h.reestimateMu.Unlock()
+
+		fingerprint := h.apiIntegrationPricingFingerprint()
+		if stored, err := h.store.GetSetting(settingAPIIntegrationPricingFingerprint); err == nil && stored == fingerprint {
+			return
+		}
+		n, err := h.store.ReestimateAPIIntegrationCosts()
+		if err != nil {
+			h.logger.Error("failed to estimate API integration costs", "error", err)
+			return
+		}
+		if err := h.store.SetSetting(settingAPIIntegrationPricingFingerprint, fingerprint); err != nil {
+			h.logger.Error("failed to save API integration pricing fingerprint", "error", err)
+		}
+		h.logger.Debug("estimated API integration costs", "events", n)
+	}()
+}
+
+// apiIntegrationPricingFingerprint hashes the pricing catalog, including its
+// version, and the configured provider rates.
+func (h *Handler) apiIntegrationPricingFingerprint() string {
+	catalog := h.pricingCatalog
+	if catalog == nil {
+		catalog = apiintegrations.BuiltinPricingCatalog()
+	}
+	catalogJSON, _ := json.Marshal(catalog)
+	providersJSON, _ := json.Marshal(storedAPIIntegrationProviders(h.store))
+	sum := sha256.New()
+	sum.Write(catalogJSON)
+	sum.Write([]byte{0})
+	sum.Write(providersJSON)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// APIIntegrationsPricing returns the pricing catalog used for cost estimates.
+func (h *Handler) APIIntegrationsPricing(w http.ResponseWriter, r *http.Request) {
+	catalog := h.pricingCatalog
+	if catalog == nil {
+		catalog = apiintegrations.BuiltinPricingCatalog()
+	}
+	respondJSON(w, http.StatusOK, catalog)
+}
@@ -0,0 +1,124 @@
+package web
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apiintegrations "github.com/onllm-dev/onwatch/v2/internal/api_integrations"
+	"github.com/onllm-dev/onwatch/v2/internal/config"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+func TestHandler_APIIntegrationsEstimatedCost(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	// gpt-4.1 lists at $2 input and $8 output per million tokens.
+	insertAPIIntegrationEventForTest(t, s, `{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"openai","model":"gpt-4.1-2025-04-14","prompt_tokens":1000000,"completion_tokens":250000}`, "/tmp/api-integrations/notes.jsonl")
+	insertAPIIntegrationEventForTest(t, s, `{"ts":"2026-04-03T12:01:00Z","integration":"notes","provider":"openai","model":"gpt-4.1","prompt_tokens":10,"completion_tokens":5,"cost_usd":0.25}`, "/tmp/api-integrations/notes.jsonl")
+	insertAPIIntegrationEventForTest(t, s, `{"ts":"2026-04-03T12:02:00Z","integration":"notes","provider":"lab-vllm","model":"qwen3","prompt_tokens":1000000,"completion_tokens":0}`, "/tmp/api-integrations/notes.jsonl")
+
+	h := NewHandler(s, nil, nil, nil, &config.Config{APIIntegrationsEnabled: true})
+	h.SetAPIIntegrationPricing(nil)
+	h.reestimateWG.Wait()
+
+	current := func() map[string]interface{} {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		h.APIIntegrationsCurrent(rr, httptest.NewRequest(http.MethodGet, "/api/api-integrations/current", nil))
+		var response map[string]map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("json.Unmarshal: %v", err)
+		}
+		return response["notes"]
+	}
+	notes := current()
+	if notes["totalCostUsd"] != 0.25 || math.Abs(notes["estimatedCostUsd"].(float64)-4) > 1e-9 {
+		t.Fatalf("notes costs=%v/%v want 0.25 reported and 4 estimated", notes["totalCostUsd"], notes["estimatedCostUsd"])
+	}
+	providers := notes["providers"].([]interface{})
+	if vllm := providers[0].(map[string]interface{}); vllm["provider"] != "lab-vllm" || vllm["estimatedCostUsd"] != nil {
+		t.Fatalf("lab-vllm=%v want no estimate", vllm)
+	}
+
+	// Configuring provider rates estimates the events the catalog cannot price.
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(`{"api_integration_providers":[{"id":"lab-vllm","display_name":"Lab vLLM","input_usd_per_mtok":0.5}]}`))
+	h.UpdateSettings(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("UpdateSettings: %d %s", rr.Code, rr.Body.String())
+	}
+	h.reestimateWG.Wait()
+	if notes := current(); math.Abs(notes["estimatedCostUsd"].(float64)-4.5) > 1e-9 {
+		t.Fatalf("estimatedCostUsd=%v want 4.5", notes["estimatedCostUsd"])
+	}
+
+	rr = httptest.NewRecorder()
+	h.APIIntegrationsPricing(rr, httptest.NewRequest(http.MethodGet, "/api/api-integrations/pricing", nil))
+	var catalog struct {
+		Version string `json:"version"`
+		Prices  []struct {
+			Provider string `json:"provider"`
+			Model    string `json:"model"`
+		} `json:"prices"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &catalog); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if catalog.Version == "" || len(catalog.Prices) == 0 {
+		t.Fatalf("catalog=%+v", catalog)
+	}
+}
+
+func TestHandler_SetAPIIntegrationPricing_SkipsUnchangedPrices(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	h := NewHandler(s, nil, nil, nil, &config.Config{APIIntegrationsEnabled: true})
+	h.SetAPIIntegrationPricing(nil)
+	h.reestimateWG.Wait()
+
+	// An event stored without an estimate is only picked up by a re-estimate,
+	// which a restart with the same prices must not run.
+	s.SetAPIIntegrationCostEstimator(nil)
+	insertAPIIntegrationEventForTest(t, s, `{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"openai","model":"gpt-4.1","prompt_tokens":1000000,"completion_tokens":0}`, "/tmp/api-integrations/notes.jsonl")
+	estimated := func() interface{} {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		h.APIIntegrationsCurrent(rr, httptest.NewRequest(http.MethodGet, "/api/api-integrations/current", nil))
+		var response map[string]map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("json.Unmarshal: %v", err)
+		}
+		return response["notes"]["estimatedCostUsd"]
+	}
+
+	restarted := NewHandler(s, nil, nil, nil, &config.Config{APIIntegrationsEnabled: true})
+	restarted.SetAPIIntegrationPricing(nil)
+	restarted.reestimateWG.Wait()
+	if got := estimated(); got != nil {
+		t.Fatalf("estimatedCostUsd=%v want no re-estimate with unchanged prices", got)
+	}
+
+	catalog, err := apiintegrations.ParsePricingCatalog([]byte(`{"version":"test","prices":[{"provider":"openai","model":"gpt-4.1","effective_from":"2025-01-01","input_usd_per_mtok":3,"output_usd_per_mtok":12}]}`))
+	if err != nil {
+		t.Fatalf("ParsePricingCatalog: %v", err)
+	}
+	restarted.SetAPIIntegrationPricing(catalog)
+	restarted.reestimateWG.Wait()
+	if got, ok := estimated().(float64); !ok || math.Abs(got-3) > 1e-9 {
+		t.Fatalf("estimatedCostUsd=%v want 3 after the catalog changed", estimated())
+	}
+}
@@ -0,0 +1,144 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	apiintegrations "github.com/onllm-dev/onwatch/v2/internal/api_integrations"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
+)
+
+// settingAPIIntegrationProviders stores the configured API integration
+// providers, which add to or override the built-in ones.
+const settingAPIIntegrationProviders = "api_integration_providers"
+
+const (
+	maxAPIIntegrationProviders      = 100
+	maxAPIIntegrationProviderName   = 64
+	maxAPIIntegrationProviderUSDPer = 10000
+)
+
+// parseAPIIntegrationProviders validates the API integration providers
+// settings value. An entry for a built-in provider may leave the display name
+// blank to keep the built-in one.
+func parseAPIIntegrationProviders(raw json.RawMessage) ([]apiintegrations.Provider, error) {
+	var in []apiintegrations.Provider
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return nil, fmt.Errorf("invalid API integration providers value")
+	}
+	if len(in) > maxAPIIntegrationProviders {
+		return nil, fmt.Errorf("at most %d API integration providers are supported", maxAPIIntegrationProviders)
+	}
+	builtin := apiintegrations.NewProviderRegistry(nil)
+	out := make([]apiintegrations.Provider, 0, len(in))
+	seen := make(map[string]bool, len(in))
+	for _, p := range in {
+		id, err := usageevent.NormalizeProvider(p.ID)
+		if err != nil {
+			return nil, err
+		}
+		p.ID = id
+		p.DisplayName = strings.TrimSpace(p.DisplayName)
+		if _, known := builtin.Lookup(id); p.DisplayName == "" && !known {
+			return nil, fmt.Errorf("provider %s needs a display name", id)
+		}
+		if len(p.DisplayName) > maxAPIIntegrationProviderName {
+			return nil, fmt.Errorf("display name for %s exceeds %d characters", id, maxAPIIntegrationProviderName)
+		}
+		for _, rate := range []*float64{p.InputUSDPerMTok, p.OutputUSDPerMTok} {
+			if rate != nil && (*rate < 0 || *rate > maxAPIIntegrationProviderUSDPer || math.IsNaN(*rate)) {
+				return nil, fmt.Errorf("rates for %s must be between 0 and %d USD per million tokens", id, maxAPIIntegrationProviderUSDPer)
+			}
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("duplicate provider %s", id)
+		}
+		seen[id] = true
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// loadAPIIntegrationProviders returns the configured providers, or none when
+// unset.
+func (h *Handler) loadAPIIntegrationProviders() []apiintegrations.Provider {
+	return storedAPIIntegrationProviders(h.store)
+}
+
+func storedAPIIntegrationProviders(s *store.Store) []apiintegrations.Provider {
+	providers := []apiintegrations.Provider{}
+	if s == nil {
+		return providers
+	}
+	raw, err := s.GetSetting(settingAPIIntegrationProviders)
+	if err != nil || raw == "" {
+		return providers
+	}
+	if err := json.Unmarshal([]byte(raw), &providers); err != nil || providers == nil {
+		return []apiintegrations.Provider{}
+	}
+	return providers
+}
+
+func (h *Handler) apiIntegrationProviderRegistry() *apiintegrations.ProviderRegistry {
+	return apiintegrations.NewProviderRegistry(h.loadAPIIntegrationProviders())
+}
+
+// apiIntegrationProviderInfo is a provider as listed by
+// /api/api-integrations/providers.
+type apiIntegrationProviderInfo struct {
+	ID               string   `json:"id"`
+	DisplayName      string   `json:"displayName"`
+	Custom           bool     `json:"custom"` // seen in events but not registered
+	Configured       bool     `json:"configured"`
+	InputUSDPerMTok  *float64 `json:"inputUsdPerMTok,omitempty"`
+	OutputUSDPerMTok *float64 `json:"outputUsdPerMTok,omitempty"`
+}
+
+// APIIntegrationsProviders lists the registered API integration providers
+// and the custom ones events have been recorded for.
+func (h *Handler) APIIntegrationsProviders(w http.ResponseWriter, r *http.Request) {
+	configured := h.loadAPIIntegrationProviders()
+	registry := apiintegrations.NewProviderRegistry(configured)
+	isConfigured := make(map[string]bool, len(configured))
+	for _, p := range configured {
+		isConfigured[p.ID] = true
+	}
+
+	providers := []apiIntegrationProviderInfo{}
+	for _, p := range registry.Providers() {
+		providers = append(providers, apiIntegrationProviderInfo{
+			ID:               p.ID,
+			DisplayName:      p.DisplayName,
+			Configured:       isConfigured[p.ID],
+			InputUSDPerMTok:  p.InputUSDPerMTok,
+			OutputUSDPerMTok: p.OutputUSDPerMTok,
+		})
+	}
+	if h.store != nil {
+		rows, err := h.store.QueryAPIIntegrationUsageSummary()
+		if err != nil {
+			h.logger.Error("failed to query API integration providers", "error", err)
+		}
+		seen := make(map[string]bool)
+		for _, row := range rows {
+			if _, known := registry.Lookup(row.Provider); known || seen[row.Provider] {
+				continue
+			}
+			seen[row.Provider] = true
+			providers = append(providers, apiIntegrationProviderInfo{ID: row.Provider, DisplayName: row.Provider, Custom: true})
+		}
+	}
+	sort.SliceStable(providers, func(i, j int) bool {
+		if providers[i].Custom != providers[j].Custom {
+			return !providers[i].Custom
+		}
+		return providers[i].ID < providers[j].ID
+	})
+	respondJSON(w, http.StatusOK, map[string]interface{}{"providers": providers})
+}
@@ -0,0 +1,81 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/onllm-dev/onwatch/v2/internal/config"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+func TestParseAPIIntegrationProviders(t *testing.T) {
+	providers, err := parseAPIIntegrationProviders(json.RawMessage(`[
+		{"id":" DeepSeek ","input_usd_per_mtok":0.27,"output_usd_per_mtok":1.1},
+		{"id":"lab-vllm","display_name":"Lab vLLM"}
+	]`))
+	if err != nil {
+		t.Fatalf("parseAPIIntegrationProviders: %v", err)
+	}
+	if len(providers) != 2 || providers[0].ID != "deepseek" || providers[0].DisplayName != "" || *providers[0].OutputUSDPerMTok != 1.1 {
+		t.Fatalf("providers=%+v", providers)
+	}
+
+	for _, raw := range []string{
+		`{"id":"x"}`,
+		`[{"id":"lab-vllm"}]`,
+		`[{"id":"bad id","display_name":"Bad"}]`,
+		`[{"id":"groq","input_usd_per_mtok":-1}]`,
+		`[{"id":"groq"},{"id":"GROQ"}]`,
+	} {
+		if _, err := parseAPIIntegrationProviders(json.RawMessage(raw)); err == nil {
+			t.Errorf("parseAPIIntegrationProviders(%s) succeeded, want error", raw)
+		}
+	}
+}
+
+func TestHandler_APIIntegrationsProviders(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+	if err := s.SetSetting(settingAPIIntegrationProviders, `[{"id":"lab-vllm","display_name":"Lab vLLM","input_usd_per_mtok":0.1}]`); err != nil {
+		t.Fatalf("SetSetting: %v", err)
+	}
+	insertAPIIntegrationEventForTest(t, s, `{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"lab-vllm","model":"qwen3","prompt_tokens":10,"completion_tokens":5}`, "/tmp/api-integrations/notes.jsonl")
+	insertAPIIntegrationEventForTest(t, s, `{"ts":"2026-04-03T12:01:00Z","integration":"notes","provider":"acme-gw","model":"m","prompt_tokens":1,"completion_tokens":1}`, "/tmp/api-integrations/notes.jsonl")
+	h := NewHandler(s, nil, nil, nil, &config.Config{APIIntegrationsEnabled: true})
+
+	rr := httptest.NewRecorder()
+	h.APIIntegrationsProviders(rr, httptest.NewRequest(http.MethodGet, "/api/api-integrations/providers", nil))
+	var listing struct {
+		Providers []apiIntegrationProviderInfo `json:"providers"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &listing); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	byID := make(map[string]apiIntegrationProviderInfo)
+	for _, p := range listing.Providers {
+		byID[p.ID] = p
+	}
+	if p := byID["lab-vllm"]; !p.Configured || p.Custom || p.DisplayName != "Lab vLLM" || p.InputUSDPerMTok == nil {
+		t.Fatalf("lab-vllm=%+v", p)
+	}
+	if p := byID["groq"]; p.Configured || p.Custom || p.DisplayName != "Groq" {
+		t.Fatalf("groq=%+v", p)
+	}
+	last := listing.Providers[len(listing.Providers)-1]
+	if last.ID != "acme-gw" || !last.Custom {
+		t.Fatalf("last=%+v want custom acme-gw listed after registered providers", last)
+	}
+
+	current := h.buildAPIIntegrationsCurrent()
+	notes := current["notes"].(map[string]interface{})
+	providers := notes["providers"].([]apiIntegrationCurrentProviderBreakdown)
+	if len(providers) != 2 || providers[0].Provider != "acme-gw" || !providers[0].Custom || providers[1].DisplayName != "Lab vLLM" || providers[1].Custom {
+		t.Fatalf("providers=%+v", providers)
+	}
+}
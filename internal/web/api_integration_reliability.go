@@ -0,0 +1,240 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/notify"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+// settingAPIIntegrationReliability stores the error rate and latency
+// thresholds of API integration degradation alerts.
+const settingAPIIntegrationReliability = "api_integration_reliability"
+
+const (
+	defaultReliabilityWindowMinutes = 15
+	defaultReliabilityMinRequests   = 20
+	maxReliabilityWindowMinutes     = 24 * 60
+	maxReliabilityP95MS             = 10 * 60 * 1000
+)
+
+// apiIntegrationReliabilityConfig holds the thresholds an integration,
+// provider and model are held to over a trailing window. A zero threshold
+// is disabled, so no alerts fire until one is set.
+type apiIntegrationReliabilityConfig struct {
+	ErrorRatePercent float64 `json:"error_rate_percent"`
+	P95LatencyMS     int     `json:"p95_latency_ms"`
+	WindowMinutes    int     `json:"window_minutes"`
+	MinRequests      int     `json:"min_requests"`
+}
+
+func defaultAPIIntegrationReliabilityConfig() apiIntegrationReliabilityConfig {
+	return apiIntegrationReliabilityConfig{
+		WindowMinutes: defaultReliabilityWindowMinutes,
+		MinRequests:   defaultReliabilityMinRequests,
+	}
+}
+
+// parseAPIIntegrationReliability validates the reliability settings value and
+// fills in the default window and minimum request count.
+func parseAPIIntegrationReliability(raw json.RawMessage) (apiIntegrationReliabilityConfig, error) {
+	cfg := defaultAPIIntegrationReliabilityConfig()
+	var in apiIntegrationReliabilityConfig
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return cfg, fmt.Errorf("invalid API integration reliability value")
+	}
+	if in.ErrorRatePercent < 0 || in.ErrorRatePercent > 100 || math.IsNaN(in.ErrorRatePercent) {
+		return cfg, fmt.Errorf("error rate threshold must be between 0 and 100 percent")
+	}
+	if in.P95LatencyMS < 0 || in.P95LatencyMS > maxReliabilityP95MS {
+		return cfg, fmt.Errorf("p95 latency threshold must be between 0 and %d ms", maxReliabilityP95MS)
+	}
+	if in.WindowMinutes == 0 {
+		in.WindowMinutes = cfg.WindowMinutes
+	}
+	if in.WindowMinutes < 1 || in.WindowMinutes > maxReliabilityWindowMinutes {
+		return cfg, fmt.Errorf("reliability window must be between 1 and %d minutes", maxReliabilityWindowMinutes)
+	}
+	if in.MinRequests == 0 {
+		in.MinRequests = cfg.MinRequests
+	}
+	if in.MinRequests < 1 {
+		return cfg, fmt.Errorf("minimum requests must be at least 1")
+	}
+	return in, nil
+}
+
+// loadAPIIntegrationReliability returns the saved reliability thresholds, or
+// the defaults when unset.
+func (h *Handler) loadAPIIntegrationReliability() apiIntegrationReliabilityConfig {
+	if h.store == nil {
+		return defaultAPIIntegrationReliabilityConfig()
+	}
+	raw, err := h.store.GetSetting(settingAPIIntegrationReliability)
+	if err != nil || raw == "" {
+		return defaultAPIIntegrationReliabilityConfig()
+	}
+	cfg, err := parseAPIIntegrationReliability(json.RawMessage(raw))
+	if err != nil {
+		return defaultAPIIntegrationReliabilityConfig()
+	}
+	return cfg
+}
+
+// apiIntegrationReliabilityStats is the error rate and latency of a group of
+// calls, overall or in one bucket.
+type apiIntegrationReliabilityStats struct {
+	RequestCount int     `json:"requestCount"`
+	ErrorCount   int     `json:"errorCount"`
+	ErrorRate    float64 `json:"errorRate"` // percent
+	LatencyCount int     `json:"latencyCount"`
+	P50MS        *int    `json:"p50Ms,omitempty"`
+	P95MS        *int    `json:"p95Ms,omitempty"`
+	P99MS        *int    `json:"p99Ms,omitempty"`
+}
+
+func newAPIIntegrationReliabilityStats(row store.APIIntegrationReliabilityRow) apiIntegrationReliabilityStats {
+	stats := apiIntegrationReliabilityStats{
+		RequestCount: row.RequestCount,
+		ErrorCount:   row.ErrorCount,
+		ErrorRate:    row.ErrorRate() * 100,
+		LatencyCount: row.LatencyCount,
+	}
+	if row.LatencyCount > 0 {
+		p50, p95, p99 := row.LatencyP50MS, row.LatencyP95MS, row.LatencyP99MS
+		stats.P50MS, stats.P95MS, stats.P99MS = &p50, &p95, &p99
+	}
+	return stats
+}
+
+type apiIntegrationReliabilityBucket struct {
+	Start string `json:"start"`
+	apiIntegrationReliabilityStats
+}
+
+type apiIntegrationReliabilityGroup struct {
+	Integration string         `json:"integration"`
+	Provider    string         `json:"provider"`
+	Model       string         `json:"model"`
+	ErrorTypes  map[string]int `json:"errorTypes"`
+	apiIntegrationReliabilityStats
+	Buckets []apiIntegrationReliabilityBucket `json:"buckets"`
+}
+
+// APIIntegrationsReliability returns the error rate and p50/p95/p99 latency
+// per integration, provider and model over a range, overall and in time
+// buckets, e.g. ?range=24h.
+func (h *Handler) APIIntegrationsReliability(w http.ResponseWriter, r *http.Request) {
+	rangeStr := r.URL.Query().Get("range")
+	duration, err := parseTimeRange(rangeStr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if rangeStr == "" {
+		rangeStr = "6h"
+	}
+	bucketSize := apiIntegrationHistoryBucketSize(duration)
+	response := map[string]interface{}{
+		"range":         rangeStr,
+		"bucketMinutes": int(bucketSize / time.Minute),
+		"thresholds":    h.loadAPIIntegrationReliability(),
+		"groups":        []apiIntegrationReliabilityGroup{},
+	}
+	if h.store == nil {
+		respondJSON(w, http.StatusOK, response)
+		return
+	}
+
+	now := time.Now().UTC()
+	totals, err := h.store.QueryAPIIntegrationReliability(now.Add(-duration), now, 0)
+	if err == nil {
+		var buckets []store.APIIntegrationReliabilityRow
+		buckets, err = h.store.QueryAPIIntegrationReliability(now.Add(-duration), now, bucketSize)
+		if err == nil {
+			response["groups"] = buildAPIIntegrationReliabilityGroups(totals, buckets)
+		}
+	}
+	if err != nil {
+		h.logger.Error("failed to query API integrations reliability", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to query API integrations reliability")
+		return
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+// buildAPIIntegrationReliabilityGroups attaches each group's buckets to its
+// totals. Both row sets are ordered by integration, provider and model.
+func buildAPIIntegrationReliabilityGroups(totals, buckets []store.APIIntegrationReliabilityRow) []apiIntegrationReliabilityGroup {
+	groups := make([]apiIntegrationReliabilityGroup, 0, len(totals))
+	index := make(map[[3]string]int, len(totals))
+	for _, row := range totals {
+		errorTypes := row.ErrorTypes
+		if errorTypes == nil {
+			errorTypes = map[string]int{}
+		}
+		index[[3]string{row.IntegrationName, row.Provider, row.Model}] = len(groups)
+		groups = append(groups, apiIntegrationReliabilityGroup{
+			Integration:                    row.IntegrationName,
+			Provider:                       row.Provider,
+			Model:                          row.Model,
+			ErrorTypes:                     errorTypes,
+			apiIntegrationReliabilityStats: newAPIIntegrationReliabilityStats(row),
+			Buckets:                        []apiIntegrationReliabilityBucket{},
+		})
+	}
+	for _, row := range buckets {
+		i, ok := index[[3]string{row.IntegrationName, row.Provider, row.Model}]
+		if !ok {
+			continue
+		}
+		groups[i].Buckets = append(groups[i].Buckets, apiIntegrationReliabilityBucket{
+			Start:                          row.BucketStart.Format(time.RFC3339),
+			apiIntegrationReliabilityStats: newAPIIntegrationReliabilityStats(row),
+		})
+	}
+	return groups
+}
+
+// APIIntegrationReliabilityStatuses reports the reliability of every
+// integration, provider and model over the configured window ending at now.
+// It is the notify.ReliabilityProvider behind degradation alerts.
+func (h *Handler) APIIntegrationReliabilityStatuses(now time.Time) ([]notify.ReliabilityStatus, error) {
+	if h.store == nil {
+		return nil, nil
+	}
+	cfg := h.loadAPIIntegrationReliability()
+	if cfg.ErrorRatePercent <= 0 && cfg.P95LatencyMS <= 0 {
+		return nil, nil
+	}
+	window := time.Duration(cfg.WindowMinutes) * time.Minute
+	rows, err := h.store.QueryAPIIntegrationReliability(now.Add(-window), now, 0)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]notify.ReliabilityStatus, 0, len(rows))
+	for _, row := range rows {
+		status := notify.ReliabilityStatus{
+			Key:          row.IntegrationName + "/" + row.Provider + "/" + row.Model,
+			Integration:  row.IntegrationName,
+			Provider:     row.Provider,
+			Model:        row.Model,
+			Window:       window,
+			Requests:     row.RequestCount,
+			Errors:       row.ErrorCount,
+			P95LatencyMS: row.LatencyP95MS,
+			MaxErrorRate: cfg.ErrorRatePercent,
+			MaxP95MS:     cfg.P95LatencyMS,
+			MinRequests:  cfg.MinRequests,
+		}
+		if types := row.TopErrorTypes(); len(types) > 0 {
+			status.ErrorType = types[0]
+		}
+		out = append(out, status)
+	}
+	return out, nil
+}
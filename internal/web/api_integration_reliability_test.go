@@ -0,0 +1,103 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/config"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+func TestHandler_APIIntegrationsReliability(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	ts := time.Now().UTC().Add(-5 * time.Minute).Format(time.RFC3339)
+	for i := 1; i <= 8; i++ {
+		line := fmt.Sprintf(`{"ts":%q,"integration":"notes","provider":"openai","model":"gpt-4.1","request_id":"ok%d","prompt_tokens":1,"completion_tokens":1,"latency_ms":%d}`, ts, i, i*250)
+		insertAPIIntegrationEventForTest(t, s, line, "/tmp/api-integrations/notes.jsonl")
+	}
+	for i, errorType := range []string{"rate_limit", "rate_limit"} {
+		line := fmt.Sprintf(`{"ts":%q,"integration":"notes","provider":"openai","model":"gpt-4.1","request_id":"err%d","prompt_tokens":0,"completion_tokens":0,"status":"error","error_type":%q}`, ts, i, errorType)
+		insertAPIIntegrationEventForTest(t, s, line, "/tmp/api-integrations/notes.jsonl")
+	}
+	h := NewHandler(s, nil, nil, nil, &config.Config{APIIntegrationsEnabled: true})
+
+	rr := httptest.NewRecorder()
+	h.APIIntegrationsReliability(rr, httptest.NewRequest(http.MethodGet, "/api/api-integrations/reliability?range=1h", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var response struct {
+		Range         string                           `json:"range"`
+		BucketMinutes int                              `json:"bucketMinutes"`
+		Groups        []apiIntegrationReliabilityGroup `json:"groups"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if response.Range != "1h" || response.BucketMinutes != 1 || len(response.Groups) != 1 {
+		t.Fatalf("response=%+v", response)
+	}
+	group := response.Groups[0]
+	if group.RequestCount != 10 || group.ErrorCount != 2 || group.ErrorRate != 20 || group.ErrorTypes["rate_limit"] != 2 {
+		t.Fatalf("group=%+v", group)
+	}
+	if group.P50MS == nil || *group.P50MS != 1000 || *group.P95MS != 2000 || *group.P99MS != 2000 {
+		t.Fatalf("latency p50=%v p95=%v p99=%v", group.P50MS, group.P95MS, group.P99MS)
+	}
+	if len(group.Buckets) != 1 || group.Buckets[0].RequestCount != 10 {
+		t.Fatalf("buckets=%+v", group.Buckets)
+	}
+
+	// No thresholds, no statuses to alert on.
+	if statuses, err := h.APIIntegrationReliabilityStatuses(time.Now()); err != nil || len(statuses) != 0 {
+		t.Fatalf("statuses=%+v err=%v want none before thresholds are set", statuses, err)
+	}
+	rr = httptest.NewRecorder()
+	h.UpdateSettings(rr, httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(`{"api_integration_reliability":{"error_rate_percent":10,"min_requests":5}}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("UpdateSettings: %d %s", rr.Code, rr.Body.String())
+	}
+	if cfg := h.loadAPIIntegrationReliability(); cfg.WindowMinutes != 15 || cfg.MinRequests != 5 || cfg.ErrorRatePercent != 10 {
+		t.Fatalf("config=%+v", cfg)
+	}
+	statuses, err := h.APIIntegrationReliabilityStatuses(time.Now())
+	if err != nil || len(statuses) != 1 {
+		t.Fatalf("statuses=%+v err=%v", statuses, err)
+	}
+	status := statuses[0]
+	if status.Key != "notes/openai/gpt-4.1" || status.ErrorType != "rate_limit" || status.P95LatencyMS != 2000 || status.Window != 15*time.Minute {
+		t.Fatalf("status=%+v", status)
+	}
+	if errorRate, latency := status.Degraded(); !errorRate || latency {
+		t.Fatalf("Degraded()=%v,%v want error rate only", errorRate, latency)
+	}
+	// A local "now" on a non-UTC host sees the same window.
+	if local, err := h.APIIntegrationReliabilityStatuses(time.Now().In(time.FixedZone("UTC+2", 2*3600))); err != nil || len(local) != 1 || local[0].Requests != status.Requests {
+		t.Fatalf("local statuses=%+v err=%v want %d requests", local, err, status.Requests)
+	}
+
+	for _, raw := range []string{
+		`{"error_rate_percent":101}`,
+		`{"p95_latency_ms":-1}`,
+		`{"window_minutes":2000}`,
+		`{"min_requests":-3}`,
+		`[]`,
+	} {
+		rr = httptest.NewRecorder()
+		h.UpdateSettings(rr, httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(`{"api_integration_reliability":`+raw+`}`)))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("UpdateSettings(%s)=%d want 400", raw, rr.Code)
+		}
+	}
+}
@@ -4,8 +4,51 @@ import (
 	"net/http"
 	"sort"
 	"time"
+
+	apiintegrations "github.com/onllm-dev/onwatch/v2/internal/api_integrations"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
 )
 
+// apiIntegrationTokenDetails reports the optional token details of a usage
+// group. Fields are omitted when no event reported them.
+type apiIntegrationTokenDetails struct {
+	CacheReadTokens  int      `json:"cacheReadTokens,omitempty"`
+	CacheWriteTokens int      `json:"cacheWriteTokens,omitempty"`
+	ReasoningTokens  int      `json:"reasoningTokens,omitempty"`
+	AudioTokens      int      `json:"audioTokens,omitempty"`
+	CacheHitRatio    *float64 `json:"cacheHitRatio,omitempty"`
+}
+
+func newAPIIntegrationTokenDetails(d store.APIIntegrationTokenDetails) apiIntegrationTokenDetails {
+	out := apiIntegrationTokenDetails{
+		CacheReadTokens:  d.CacheReadTokens,
+		CacheWriteTokens: d.CacheWriteTokens,
+		ReasoningTokens:  d.ReasoningTokens,
+		AudioTokens:      d.AudioTokens,
+	}
+	if ratio, ok := d.CacheHitRatio(); ok {
+		out.CacheHitRatio = &ratio
+	}
+	return out
+}
+
+// addTo sets the reported details on a map-shaped response item.
+func (d apiIntegrationTokenDetails) addTo(item map[string]interface{}) {
+	for key, value := range map[string]int{
+		"cacheReadTokens":  d.CacheReadTokens,
+		"cacheWriteTokens": d.CacheWriteTokens,
+		"reasoningTokens":  d.ReasoningTokens,
+		"audioTokens":      d.AudioTokens,
+	} {
+		if value > 0 {
+			item[key] = value
+		}
+	}
+	if d.CacheHitRatio != nil {
+		item["cacheHitRatio"] = *d.CacheHitRatio
+	}
+}
+
 type apiIntegrationCurrentModelBreakdown struct {
 	Model            string   `json:"model"`
 	RequestCount     int      `json:"requestCount"`
@@ -13,7 +56,9 @@ type apiIntegrationCurrentModelBreakdown struct {
 	CompletionTokens int      `json:"completionTokens"`
 	TotalTokens      int      `json:"totalTokens"`
 	TotalCostUSD     *float64 `json:"totalCostUsd,omitempty"`
+	EstimatedCostUSD *float64 `json:"estimatedCostUsd,omitempty"` // for events without a reported cost
 	LastCapturedAt   string   `json:"lastCapturedAt"`
+	apiIntegrationTokenDetails
 }
 
 type apiIntegrationCurrentAccountBreakdown struct {
@@ -23,19 +68,25 @@ type apiIntegrationCurrentAccountBreakdown struct {
 	CompletionTokens int                                   `json:"completionTokens"`
 	TotalTokens      int                                   `json:"totalTokens"`
 	TotalCostUSD     *float64                              `json:"totalCostUsd,omitempty"`
+	EstimatedCostUSD *float64                              `json:"estimatedCostUsd,omitempty"`
 	LastCapturedAt   string                                `json:"lastCapturedAt"`
 	Models           []apiIntegrationCurrentModelBreakdown `json:"models"`
+	apiIntegrationTokenDetails
 }
 
 type apiIntegrationCurrentProviderBreakdown struct {
 	Provider         string                                  `json:"provider"`
+	DisplayName      string                                  `json:"displayName"`
+	Custom           bool                                    `json:"custom"` // not a built-in or configured provider
 	RequestCount     int                                     `json:"requestCount"`
 	PromptTokens     int                                     `json:"promptTokens"`
 	CompletionTokens int                                     `json:"completionTokens"`
 	TotalTokens      int                                     `json:"totalTokens"`
 	TotalCostUSD     *float64                                `json:"totalCostUsd,omitempty"`
+	EstimatedCostUSD *float64                                `json:"estimatedCostUsd,omitempty"`
 	LastCapturedAt   string                                  `json:"lastCapturedAt"`
 	Accounts         []apiIntegrationCurrentAccountBreakdown `json:"accounts"`
+	apiIntegrationTokenDetails
 }
 
 // APIIntegrationsCurrent returns grouped current API integration usage totals.
@@ -59,11 +110,13 @@ func (h *Handler) buildAPIIntegrationsCurrent() map[string]interface{} {
 		row apiIntegrationCurrentModelBreakdown
 	}
 	type accountNode struct {
-		row    apiIntegrationCurrentAccountBreakdown
-		models map[string]*modelNode
+		row     apiIntegrationCurrentAccountBreakdown
+		details store.APIIntegrationTokenDetails
+		models  map[string]*modelNode
 	}
 	type providerNode struct {
 		row      apiIntegrationCurrentProviderBreakdown
+		details  store.APIIntegrationTokenDetails
 		accounts map[string]*accountNode
 	}
 	type integrationNode struct {
@@ -73,10 +126,13 @@ func (h *Handler) buildAPIIntegrationsCurrent() map[string]interface{} {
 		TotalTokens      int
 		TotalCostUSD     float64
 		HasCost          bool
+		EstimatedCostUSD float64
 		LastCapturedAt   time.Time
+		Details          store.APIIntegrationTokenDetails
 		Providers        map[string]*providerNode
 	}
 
+	registry := h.apiIntegrationProviderRegistry()
 	integrationsMap := make(map[string]*integrationNode)
 	for _, entry := range rows {
 		integrationState, ok := integrationsMap[entry.IntegrationName]
@@ -86,8 +142,9 @@ func (h *Handler) buildAPIIntegrationsCurrent() map[string]interface{} {
 		}
 		providerState, ok := integrationState.Providers[entry.Provider]
 		if !ok {
+			info, known := registry.Lookup(entry.Provider)
 			providerState = &providerNode{
-				row:      apiIntegrationCurrentProviderBreakdown{Provider: entry.Provider},
+				row:      apiIntegrationCurrentProviderBreakdown{Provider: entry.Provider, DisplayName: info.DisplayName, Custom: !known},
 				accounts: make(map[string]*accountNode),
 			}
 			integrationState.Providers[entry.Provider] = providerState
@@ -108,11 +165,14 @@ func (h *Handler) buildAPIIntegrationsCurrent() map[string]interface{} {
 			CompletionTokens: entry.CompletionTokens,
 			TotalTokens:      entry.TotalTokens,
 			LastCapturedAt:   entry.LastCapturedAt.UTC().Format(time.RFC3339),
+
+			apiIntegrationTokenDetails: newAPIIntegrationTokenDetails(entry.APIIntegrationTokenDetails),
 		}
 		if entry.TotalCostUSD > 0 {
 			cost := entry.TotalCostUSD
 			model.TotalCostUSD = &cost
 		}
+		model.EstimatedCostUSD = addOptionalCost(nil, entry.EstimatedCostUSD)
 		accountState.models[entry.Model] = &modelNode{row: model}
 
 		acc := &accountState.row
@@ -121,6 +181,7 @@ func (h *Handler) buildAPIIntegrationsCurrent() map[string]interface{} {
 		acc.CompletionTokens += entry.CompletionTokens
 		acc.TotalTokens += entry.TotalTokens
 		acc.LastCapturedAt = laterTimeString(acc.LastCapturedAt, entry.LastCapturedAt)
+		accountState.details.Add(entry.APIIntegrationTokenDetails)
 		if entry.TotalCostUSD > 0 {
 			var current float64
 			if acc.TotalCostUSD != nil {
@@ -129,6 +190,7 @@ func (h *Handler) buildAPIIntegrationsCurrent() map[string]interface{} {
 			current += entry.TotalCostUSD
 			acc.TotalCostUSD = &current
 		}
+		acc.EstimatedCostUSD = addOptionalCost(acc.EstimatedCostUSD, entry.EstimatedCostUSD)
 
 		prov := &providerState.row
 		prov.RequestCount += entry.RequestCount
@@ -136,6 +198,7 @@ func (h *Handler) buildAPIIntegrationsCurrent() map[string]interface{} {
 		prov.CompletionTokens += entry.CompletionTokens
 		prov.TotalTokens += entry.TotalTokens
 		prov.LastCapturedAt = laterTimeString(prov.LastCapturedAt, entry.LastCapturedAt)
+		providerState.details.Add(entry.APIIntegrationTokenDetails)
 		if entry.TotalCostUSD > 0 {
 			var current float64
 			if prov.TotalCostUSD != nil {
@@ -144,6 +207,7 @@ func (h *Handler) buildAPIIntegrationsCurrent() map[string]interface{} {
 			current += entry.TotalCostUSD
 			prov.TotalCostUSD = &current
 		}
+		prov.EstimatedCostUSD = addOptionalCost(prov.EstimatedCostUSD, entry.EstimatedCostUSD)
 
 		integrationState.RequestCount += entry.RequestCount
 		integrationState.PromptTokens += entry.PromptTokens
@@ -151,6 +215,8 @@ func (h *Handler) buildAPIIntegrationsCurrent() map[string]interface{} {
 		integrationState.TotalTokens += entry.TotalTokens
 		integrationState.TotalCostUSD += entry.TotalCostUSD
 		integrationState.HasCost = integrationState.HasCost || entry.TotalCostUSD > 0
+		integrationState.EstimatedCostUSD += entry.EstimatedCostUSD
+		integrationState.Details.Add(entry.APIIntegrationTokenDetails)
 		if entry.LastCapturedAt.After(integrationState.LastCapturedAt) {
 			integrationState.LastCapturedAt = entry.LastCapturedAt
 		}
@@ -167,10 +233,12 @@ func (h *Handler) buildAPIIntegrationsCurrent() map[string]interface{} {
 				}
 				sortAPIIntegrationModels(models)
 				accountState.row.Models = models
+				accountState.row.apiIntegrationTokenDetails = newAPIIntegrationTokenDetails(accountState.details)
 				accounts = append(accounts, accountState.row)
 			}
 			sortAPIIntegrationAccounts(accounts)
 			providerState.row.Accounts = accounts
+			providerState.row.apiIntegrationTokenDetails = newAPIIntegrationTokenDetails(providerState.details)
 			providers = append(providers, providerState.row)
 		}
 		sortAPIIntegrationProviders(providers)
@@ -187,6 +255,10 @@ func (h *Handler) buildAPIIntegrationsCurrent() map[string]interface{} {
 		if integrationState.HasCost {
 			item["totalCostUsd"] = integrationState.TotalCostUSD
 		}
+		if integrationState.EstimatedCostUSD > 0 {
+			item["estimatedCostUsd"] = integrationState.EstimatedCostUSD
+		}
+		newAPIIntegrationTokenDetails(integrationState.Details).addTo(item)
 		response[integrationName] = item
 	}
 
@@ -231,6 +303,10 @@ func (h *Handler) buildAPIIntegrationsHistory(duration time.Duration) map[string
 		if row.TotalCostUSD > 0 {
 			entry["totalCostUsd"] = row.TotalCostUSD
 		}
+		if row.EstimatedCostUSD > 0 {
+			entry["estimatedCostUsd"] = row.EstimatedCostUSD
+		}
+		newAPIIntegrationTokenDetails(row.APIIntegrationTokenDetails).addTo(entry)
 		byIntegration[row.IntegrationName] = append(byIntegration[row.IntegrationName], entry)
 	}
 
@@ -263,6 +339,7 @@ func (h *Handler) buildAPIIntegrationsHealth() map[string]interface{} {
 	response := map[string]interface{}{
 		"enabled": false,
 		"dir":     "",
+		"sources": []string{},
 		"running": false,
 		"files":   []map[string]interface{}{},
 		"alerts":  []map[string]interface{}{},
@@ -270,6 +347,11 @@ func (h *Handler) buildAPIIntegrationsHealth() map[string]interface{} {
 	if h.config != nil {
 		response["enabled"] = h.config.APIIntegrationsEnabled
 		response["dir"] = h.config.APIIntegrationsDir
+		if len(h.config.APIIntegrationsSources) > 0 {
+			response["sources"] = h.config.APIIntegrationsSources
+		} else if h.config.APIIntegrationsDir != "" {
+			response["sources"] = apiintegrations.DefaultSources(h.config.APIIntegrationsDir)
+		}
 	}
 	if enabled, _ := response["enabled"].(bool); !enabled {
 		return response
@@ -287,11 +369,22 @@ func (h *Handler) buildAPIIntegrationsHealth() map[string]interface{} {
 		for _, file := range files {
 			item := map[string]interface{}{
 				"sourcePath":  file.SourcePath,
+				"kind":        "tail",
 				"offsetBytes": file.OffsetBytes,
 				"fileSize":    file.FileSize,
 				"partialLine": file.PartialLine,
+				"rotations":   file.Rotations,
 				"updatedAt":   file.UpdatedAt.UTC().Format(time.RFC3339),
 			}
+			if apiintegrations.IsArchiveSource(file.SourcePath) {
+				item["kind"] = "archive"
+			}
+			if file.LogicalPath != file.SourcePath {
+				item["logicalPath"] = file.LogicalPath
+			}
+			if file.LastRotatedAt != nil {
+				item["lastRotatedAt"] = file.LastRotatedAt.UTC().Format(time.RFC3339)
+			}
 			if file.FileModTime != nil {
 				item["fileModTime"] = file.FileModTime.UTC().Format(time.RFC3339)
 			}
@@ -341,6 +434,17 @@ func apiIntegrationHistoryBucketSize(duration time.Duration) time.Duration {
 	}
 }
 
+// addOptionalCost adds a positive cost to an optional total.
+func addOptionalCost(total *float64, cost float64) *float64 {
+	if cost <= 0 {
+		return total
+	}
+	if total != nil {
+		cost += *total
+	}
+	return &cost
+}
+
 func laterTimeString(current string, candidate time.Time) string {
 	if current == "" {
 		return candidate.UTC().Format(time.RFC3339)
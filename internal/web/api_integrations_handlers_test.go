@@ -10,13 +10,14 @@ import (
 	apiintegrations "github.com/onllm-dev/onwatch/v2/internal/api_integrations"
 	"github.com/onllm-dev/onwatch/v2/internal/config"
 	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
 )
 
 func insertAPIIntegrationEventForTest(t *testing.T, s *store.Store, line, sourcePath string) {
 	t.Helper()
-	event, err := apiintegrations.ParseUsageEventLine([]byte(line), sourcePath)
+	event, err := usageevent.ParseLine([]byte(line), sourcePath)
 	if err != nil {
-		t.Fatalf("ParseUsageEventLine: %v", err)
+		t.Fatalf("ParseLine: %v", err)
 	}
 	if _, err := s.InsertAPIIntegrationUsageEvent(event); err != nil {
 		t.Fatalf("InsertAPIIntegrationUsageEvent: %v", err)
@@ -134,6 +135,61 @@ func TestHandler_APIIntegrationsCurrent_GroupedTotals(t *testing.T) {
 	}
 }
 
+func TestHandler_APIIntegrationsCurrent_CacheHitRatio(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	insertAPIIntegrationEventForTest(t, s, `{"ts":"2026-04-03T12:00:00Z","integration":"notes","provider":"anthropic","model":"claude-sonnet-4","prompt_tokens":100,"completion_tokens":5,"cache_read_tokens":80,"cache_write_tokens":10}`, "/tmp/api-integrations/notes.jsonl")
+	insertAPIIntegrationEventForTest(t, s, `{"ts":"2026-04-03T12:01:00Z","integration":"notes","provider":"openai","model":"o3","prompt_tokens":100,"completion_tokens":50,"cache_read_tokens":20,"reasoning_tokens":30}`, "/tmp/api-integrations/notes.jsonl")
+	insertAPIIntegrationEventForTest(t, s, `{"ts":"2026-04-03T12:02:00Z","integration":"notes","provider":"mistral","model":"mistral-small-latest","prompt_tokens":6,"completion_tokens":2}`, "/tmp/api-integrations/notes.jsonl")
+
+	h := NewHandler(s, nil, nil, nil, &config.Config{APIIntegrationsEnabled: true})
+	rr := httptest.NewRecorder()
+	h.APIIntegrationsCurrent(rr, httptest.NewRequest(http.MethodGet, "/api/api-integrations/current", nil))
+
+	type details struct {
+		CacheReadTokens  int      `json:"cacheReadTokens"`
+		CacheWriteTokens int      `json:"cacheWriteTokens"`
+		ReasoningTokens  int      `json:"reasoningTokens"`
+		CacheHitRatio    *float64 `json:"cacheHitRatio"`
+	}
+	var response map[string]struct {
+		details
+		Providers []struct {
+			Provider string `json:"provider"`
+			details
+			Accounts []struct {
+				Models []struct {
+					Model string `json:"model"`
+					details
+				} `json:"models"`
+			} `json:"accounts"`
+		} `json:"providers"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+
+	notes := response["notes"]
+	if notes.CacheReadTokens != 100 || notes.CacheWriteTokens != 10 || notes.ReasoningTokens != 30 || notes.CacheHitRatio == nil || *notes.CacheHitRatio != 0.5 {
+		t.Fatalf("notes=%+v", notes.details)
+	}
+	if len(notes.Providers) != 3 {
+		t.Fatalf("providers=%+v", notes.Providers)
+	}
+	anthropic := notes.Providers[0].Accounts[0].Models[0]
+	if anthropic.Model != "claude-sonnet-4" || anthropic.CacheHitRatio == nil || *anthropic.CacheHitRatio != 0.8 {
+		t.Fatalf("anthropic model=%+v", anthropic)
+	}
+	if mistral := notes.Providers[1]; mistral.Provider != "mistral" || mistral.CacheHitRatio != nil {
+		t.Fatalf("mistral=%+v", mistral)
+	}
+}
+
 func TestHandler_APIIntegrationsHistory_RangeAndDownsample(t *testing.T) {
 	t.Parallel()
 	s, err := store.New(":memory:")
@@ -272,6 +328,58 @@ func TestHandler_APIIntegrationsHealth_StatusFilesAndAlerts(t *testing.T) {
 	}
 }
 
+func TestHandler_APIIntegrationsHealth_RotationAndArchives(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	rotatedAt := time.Date(2026, 4, 3, 13, 0, 0, 0, time.UTC)
+	for _, state := range []*apiintegrations.IngestState{
+		{SourcePath: "/logs/notes.jsonl", Offset: 10, FileSize: 10, Rotations: 2, LastRotatedAt: rotatedAt},
+		{SourcePath: "/logs/notes.jsonl.1.gz", LogicalPath: "/logs/notes.jsonl", Offset: 64, FileSize: 64},
+	} {
+		if err := s.UpsertAPIIntegrationIngestState(state); err != nil {
+			t.Fatalf("UpsertAPIIntegrationIngestState: %v", err)
+		}
+	}
+	insertAPIIntegrationEventForTest(t, s, `{"ts":"2026-04-03T12:09:00Z","integration":"notes","provider":"openai","model":"gpt-4.1","prompt_tokens":10,"completion_tokens":5}`, "/logs/notes.jsonl")
+
+	h := NewHandler(s, nil, nil, nil, &config.Config{APIIntegrationsEnabled: true, APIIntegrationsDir: "/logs", APIIntegrationsSources: []string{"/logs/*.jsonl*"}})
+	rr := httptest.NewRecorder()
+	h.APIIntegrationsHealth(rr, httptest.NewRequest(http.MethodGet, "/api/api-integrations/health", nil))
+
+	var response struct {
+		Sources []string `json:"sources"`
+		Files   []struct {
+			SourcePath     string `json:"sourcePath"`
+			Kind           string `json:"kind"`
+			LogicalPath    string `json:"logicalPath"`
+			Rotations      int    `json:"rotations"`
+			LastRotatedAt  string `json:"lastRotatedAt"`
+			LastCapturedAt string `json:"lastCapturedAt"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if len(response.Sources) != 1 || response.Sources[0] != "/logs/*.jsonl*" {
+		t.Fatalf("sources=%v", response.Sources)
+	}
+	if len(response.Files) != 2 {
+		t.Fatalf("files=%+v", response.Files)
+	}
+	live, archive := response.Files[0], response.Files[1]
+	if live.Kind != "tail" || live.Rotations != 2 || live.LastRotatedAt != "2026-04-03T13:00:00Z" || live.LogicalPath != "" {
+		t.Fatalf("live=%+v", live)
+	}
+	if archive.Kind != "archive" || archive.LogicalPath != "/logs/notes.jsonl" || archive.LastCapturedAt != "2026-04-03T12:09:00Z" {
+		t.Fatalf("archive=%+v", archive)
+	}
+}
+
 func TestHandler_APIIntegrationsHealth_Disabled(t *testing.T) {
 	t.Parallel()
 	s, err := store.New(":memory:")
@@ -0,0 +1,208 @@
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	apiintegrations "github.com/onllm-dev/onwatch/v2/internal/api_integrations"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
+)
+
+// maxAPIIntegrationIngestBody caps one POST to the ingest endpoint.
+const maxAPIIntegrationIngestBody = 4 * 1024 * 1024
+
+// apiIntegrationIngestTokenSetting holds the SHA-256 hex hash of the ingest
+// token. The token itself is shown once, when it is generated.
+const apiIntegrationIngestTokenSetting = "api_integrations_ingest_token"
+
+// apiIntegrationIngestError reports why one line of an ingest request was
+// rejected. Line is 1-based.
+type apiIntegrationIngestError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// APIIntegrationsIngest accepts API integration usage events over HTTP, for
+// workers that cannot write into the tailed JSONL directory. The body is a
+// single event object or NDJSON with one event per line, in the same schema
+// as the JSONL files. Events are deduplicated like tailed lines, so retrying
+// a batch is safe.
+func (h *Handler) APIIntegrationsIngest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.config != nil && !h.config.APIIntegrationsEnabled {
+		respondError(w, http.StatusServiceUnavailable, "API integrations ingestion is disabled")
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusServiceUnavailable, "store not available")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAPIIntegrationIngestBody)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		if isMaxBytesError(err) {
+			respondError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		respondError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	lines := splitAPIIntegrationIngestBody(body)
+	if len(lines) == 0 {
+		respondError(w, http.StatusBadRequest, "no events in request body")
+		return
+	}
+
+	accepted, duplicates := 0, 0
+	lineErrors := []apiIntegrationIngestError{}
+	for _, line := range lines {
+		event, err := usageevent.ParseLine(line.data, apiintegrations.HTTPSourcePath)
+		if err != nil {
+			lineErrors = append(lineErrors, apiIntegrationIngestError{Line: line.number, Error: err.Error()})
+			continue
+		}
+		if _, err := h.store.InsertAPIIntegrationUsageEvent(event); err != nil {
+			if errors.Is(err, store.ErrDuplicateAPIIntegrationUsageEvent) {
+				duplicates++
+				continue
+			}
+			h.logger.Error("failed to store API integration usage event", "error", err)
+			lineErrors = append(lineErrors, apiIntegrationIngestError{Line: line.number, Error: "failed to store event"})
+			continue
+		}
+		accepted++
+	}
+
+	status := http.StatusOK
+	if accepted == 0 && duplicates == 0 {
+		status = http.StatusBadRequest
+	}
+	respondJSON(w, status, map[string]interface{}{
+		"accepted":   accepted,
+		"duplicates": duplicates,
+		"rejected":   len(lineErrors),
+		"errors":     lineErrors,
+	})
+}
+
+type apiIntegrationIngestLine struct {
+	number int
+	data   []byte
+}
+
+// splitAPIIntegrationIngestBody returns the non-blank event lines of an
+// ingest body. A body that is one JSON value, even spread over several lines,
+// is a single event.
+func splitAPIIntegrationIngestBody(body []byte) []apiIntegrationIngestLine {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil
+	}
+	if bytes.IndexByte(trimmed, '\n') >= 0 && json.Valid(trimmed) {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, trimmed); err == nil {
+			return []apiIntegrationIngestLine{{number: 1, data: compact.Bytes()}}
+		}
+	}
+	var lines []apiIntegrationIngestLine
+	for i, raw := range bytes.Split(body, []byte("\n")) {
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 {
+			continue
+		}
+		lines = append(lines, apiIntegrationIngestLine{number: i + 1, data: raw})
+	}
+	return lines
+}
+
+// APIIntegrationsIngestToken manages the ingest token: POST generates a new
+// one, replacing any previous token, and DELETE revokes it. The token is a
+// bearer credential that is accepted only by POST
+// /api/api-integrations/events, so workers never need the admin password.
+func (h *Handler) APIIntegrationsIngestToken(w http.ResponseWriter, r *http.Request) {
+	if h.store == nil {
+		respondError(w, http.StatusServiceUnavailable, "store not available")
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		token := generateToken()
+		if err := h.store.SetSetting(apiIntegrationIngestTokenSetting, hashAPIIntegrationIngestToken(token)); err != nil {
+			h.logger.Error("failed to save API integrations ingest token", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save ingest token")
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]interface{}{"token": token})
+	case http.MethodDelete:
+		if err := h.store.SetSetting(apiIntegrationIngestTokenSetting, ""); err != nil {
+			h.logger.Error("failed to revoke API integrations ingest token", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to revoke ingest token")
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]interface{}{"revoked": true})
+	default:
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// apiIntegrationIngestTokenSet reports whether an ingest token is configured.
+func (h *Handler) apiIntegrationIngestTokenSet() bool {
+	if h.store == nil {
+		return false
+	}
+	hash, err := h.store.GetSetting(apiIntegrationIngestTokenSetting)
+	return err == nil && hash != ""
+}
+
+func hashAPIIntegrationIngestToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiIntegrationIngestPaths are the routes that accept the ingest token, for
+// POST requests only.
+var apiIntegrationIngestPaths = []string{
+	"/api/api-integrations/events",
+	"/api/api-integrations/otlp/v1/traces",
+	"/api/api-integrations/otlp/v1/metrics",
+}
+
+func isAPIIntegrationIngestPath(basePath, path string) bool {
+	for _, p := range apiIntegrationIngestPaths {
+		if path == basePath+p {
+			return true
+		}
+	}
+	return false
+}
+
+// isAPIIntegrationIngestTokenRequest reports whether r carries the configured
+// ingest token as a bearer token.
+func isAPIIntegrationIngestTokenRequest(db *store.Store, r *http.Request) bool {
+	if db == nil {
+		return false
+	}
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	stored, err := db.GetSetting(apiIntegrationIngestTokenSetting)
+	if err != nil || stored == "" {
+		return false
+	}
+	provided := hashAPIIntegrationIngestToken(strings.TrimSpace(auth[len("Bearer "):]))
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(stored)) == 1
+}
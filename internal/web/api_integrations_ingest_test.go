@@ -0,0 +1,225 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/config"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+type apiIntegrationIngestResponse struct {
+	Accepted   int                         `json:"accepted"`
+	Duplicates int                         `json:"duplicates"`
+	Rejected   int                         `json:"rejected"`
+	Errors     []apiIntegrationIngestError `json:"errors"`
+}
+
+func postAPIIntegrationEvents(t *testing.T, h *Handler, body string) (int, apiIntegrationIngestResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/api-integrations/events", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	h.APIIntegrationsIngest(rr, req)
+	var resp apiIntegrationIngestResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("json.Unmarshal: %v (body=%s)", err, rr.Body.String())
+	}
+	return rr.Code, resp
+}
+
+func TestHandler_APIIntegrationsIngest_BatchDedupesAndReportsLines(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+	h := NewHandler(s, nil, nil, nil, &config.Config{APIIntegrationsEnabled: true})
+
+	batch := strings.Join([]string{
+		`{"ts":"2026-04-03T12:00:00Z","integration":"worker","provider":"anthropic","model":"claude-3-7-sonnet","request_id":"a","prompt_tokens":10,"completion_tokens":5}`,
+		``,
+		`{"ts":"2026-04-03T12:01:00Z","integration":"worker","provider":"no such/provider","model":"m","prompt_tokens":1}`,
+		`{"ts":"2026-04-03T12:02:00Z","integration":"worker","provider":"openai","model":"gpt-4.1","request_id":"b","prompt_tokens":4,"completion_tokens":1}`,
+	}, "\n")
+
+	code, resp := postAPIIntegrationEvents(t, h, batch)
+	if code != http.StatusOK {
+		t.Fatalf("status=%d want 200", code)
+	}
+	if resp.Accepted != 2 || resp.Duplicates != 0 || resp.Rejected != 1 {
+		t.Fatalf("resp=%+v want 2 accepted, 1 rejected", resp)
+	}
+	if resp.Errors[0].Line != 3 || !strings.Contains(resp.Errors[0].Error, "invalid provider") {
+		t.Fatalf("errors=%+v want line 3 invalid provider", resp.Errors)
+	}
+
+	code, resp = postAPIIntegrationEvents(t, h, batch)
+	if code != http.StatusOK || resp.Accepted != 0 || resp.Duplicates != 2 {
+		t.Fatalf("retry status=%d resp=%+v want 2 duplicates", code, resp)
+	}
+
+	events, err := s.QueryAPIIntegrationUsageRange(time.Date(2026, 4, 3, 0, 0, 0, 0, time.UTC), time.Date(2026, 4, 4, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationUsageRange: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("stored %d events want 2", len(events))
+	}
+	if events[0].SourcePath != "http" {
+		t.Fatalf("source path=%q want http", events[0].SourcePath)
+	}
+}
+
+func TestHandler_APIIntegrationsIngest_KeepsDistinctErrorsInSameSecond(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+	h := NewHandler(s, nil, nil, nil, &config.Config{APIIntegrationsEnabled: true})
+
+	// Failed calls carry no tokens or request id; only the error type
+	// tells them apart. Latency is not part of the fingerprint.
+	line := `{"ts":"2026-04-03T12:00:00Z","integration":"worker","provider":"openai","model":"gpt-4.1","prompt_tokens":0,"completion_tokens":0,"error_type":"%s","latency_ms":%d}`
+	batch := strings.Join([]string{
+		fmt.Sprintf(line, "rate_limit", 120),
+		fmt.Sprintf(line, "server_error", 95),
+		fmt.Sprintf(line, "timeout", 120),
+		fmt.Sprintf(line, "timeout", 30000),
+	}, "\n")
+
+	code, resp := postAPIIntegrationEvents(t, h, batch)
+	if code != http.StatusOK || resp.Accepted != 3 || resp.Duplicates != 1 {
+		t.Fatalf("status=%d resp=%+v want 3 accepted, 1 duplicate", code, resp)
+	}
+}
+
+func TestHandler_APIIntegrationsIngest_SinglePrettyEvent(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+	h := NewHandler(s, nil, nil, nil, &config.Config{APIIntegrationsEnabled: true})
+
+	body := "{\n  \"ts\": \"2026-04-03T12:00:00Z\",\n  \"integration\": \"lambda\",\n  \"provider\": \"mistral\",\n  \"model\": \"mistral-small-latest\",\n  \"prompt_tokens\": 3\n}\n"
+	code, resp := postAPIIntegrationEvents(t, h, body)
+	if code != http.StatusOK || resp.Accepted != 1 || resp.Rejected != 0 {
+		t.Fatalf("status=%d resp=%+v want 1 accepted", code, resp)
+	}
+}
+
+func TestHandler_APIIntegrationsIngest_Rejections(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	h := NewHandler(s, nil, nil, nil, &config.Config{APIIntegrationsEnabled: true})
+	code, resp := postAPIIntegrationEvents(t, h, `{"ts":"bad"}`)
+	if code != http.StatusBadRequest || resp.Rejected != 1 {
+		t.Fatalf("invalid event status=%d resp=%+v want 400 with 1 rejected", code, resp)
+	}
+
+	rr := httptest.NewRecorder()
+	h.APIIntegrationsIngest(rr, httptest.NewRequest(http.MethodPost, "/api/api-integrations/events", strings.NewReader("  \n")))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("empty body status=%d want 400", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	h.APIIntegrationsIngest(rr, httptest.NewRequest(http.MethodGet, "/api/api-integrations/events", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET status=%d want 405", rr.Code)
+	}
+
+	disabled := NewHandler(s, nil, nil, nil, &config.Config{APIIntegrationsEnabled: false})
+	rr = httptest.NewRecorder()
+	disabled.APIIntegrationsIngest(rr, httptest.NewRequest(http.MethodPost, "/api/api-integrations/events", strings.NewReader(`{}`)))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("disabled status=%d want 503", rr.Code)
+	}
+}
+
+func TestSessionAuthMiddleware_APIIntegrationsIngestTokenScope(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+	h := NewHandler(s, nil, nil, nil, &config.Config{APIIntegrationsEnabled: true})
+
+	rr := httptest.NewRecorder()
+	h.APIIntegrationsIngestToken(rr, httptest.NewRequest(http.MethodPost, "/api/api-integrations/ingest-token", nil))
+	var generated struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &generated); err != nil || rr.Code != http.StatusOK || generated.Token == "" {
+		t.Fatalf("generate status=%d body=%s", rr.Code, rr.Body.String())
+	}
+	if stored, _ := s.GetSetting(apiIntegrationIngestTokenSetting); stored == "" || strings.Contains(stored, generated.Token) {
+		t.Fatalf("stored setting %q should be the token's hash", stored)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/api-integrations/events", h.APIIntegrationsIngest)
+	mux.HandleFunc("/api/api-integrations/otlp/v1/traces", h.APIIntegrationsOTLPTraces)
+	mux.HandleFunc("/api/api-integrations/otlp/v1/metrics", h.APIIntegrationsOTLPMetrics)
+	mux.HandleFunc("/api/api-integrations/ingest-token", h.APIIntegrationsIngestToken)
+	mux.HandleFunc("/api/settings", h.GetSettings)
+	handler := SessionAuthMiddleware(NewSessionStore("admin", legacyHashPassword("secret"), s))(mux)
+	event := `{"ts":"2026-04-03T12:00:00Z","integration":"worker","provider":"openai","model":"gpt-4.1","prompt_tokens":4}`
+
+	serve := func(method, path, bearer string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(event))
+		if strings.Contains(path, "/otlp/") {
+			req = httptest.NewRequest(method, path, strings.NewReader(`{}`))
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	for _, tc := range []struct {
+		name, method, path, token string
+		want                      int
+	}{
+		{"post events", http.MethodPost, "/api/api-integrations/events", generated.Token, http.StatusOK},
+		{"wrong token", http.MethodPost, "/api/api-integrations/events", "nope", http.StatusUnauthorized},
+		{"no token", http.MethodPost, "/api/api-integrations/events", "", http.StatusUnauthorized},
+		{"post OTLP traces", http.MethodPost, "/api/api-integrations/otlp/v1/traces", generated.Token, http.StatusOK},
+		{"post OTLP metrics", http.MethodPost, "/api/api-integrations/otlp/v1/metrics", generated.Token, http.StatusOK},
+		{"OTLP wrong token", http.MethodPost, "/api/api-integrations/otlp/v1/traces", "nope", http.StatusUnauthorized},
+		{"read settings", http.MethodGet, "/api/settings", generated.Token, http.StatusUnauthorized},
+		{"read events", http.MethodGet, "/api/api-integrations/events", generated.Token, http.StatusUnauthorized},
+		{"rotate token", http.MethodPost, "/api/api-integrations/ingest-token", generated.Token, http.StatusUnauthorized},
+	} {
+		if got := serve(tc.method, tc.path, tc.token); got != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, got)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	h.APIIntegrationsIngestToken(rr, httptest.NewRequest(http.MethodDelete, "/api/api-integrations/ingest-token", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("revoke status=%d", rr.Code)
+	}
+	if got := serve(http.MethodPost, "/api/api-integrations/events", generated.Token); got != http.StatusUnauthorized {
+		t.Fatalf("revoked token: expected 401, got %d", got)
+	}
+}
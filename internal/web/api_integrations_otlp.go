@@ -0,0 +1,185 @@
+package web
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	apiintegrations "github.com/onllm-dev/onwatch/v2/internal/api_integrations"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// maxOTLPDecodedBody caps an OTLP export after gzip decompression.
+const maxOTLPDecodedBody = 4 * maxAPIIntegrationIngestBody
+
+// errOTLPBodyTooLarge reports an export over the size limits.
+var errOTLPBodyTooLarge = errors.New("request body too large")
+
+// APIIntegrationsOTLPTraces is an OTLP/HTTP trace receiver. Spans following
+// the OpenTelemetry GenAI semantic conventions become API integration usage
+// events; other spans are accepted and dropped.
+func (h *Handler) APIIntegrationsOTLPTraces(w http.ResponseWriter, r *http.Request) {
+	body, isJSON, ok := h.readOTLPRequest(w, r)
+	if !ok {
+		return
+	}
+	data, err := apiintegrations.DecodeOTLPTraces(body, isJSON)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	result := apiintegrations.EventsFromOTLPTraces(data, h.otlpMapping())
+	h.finishOTLPRequest(w, result, isJSON, "rejectedSpans")
+}
+
+// APIIntegrationsOTLPMetrics is an OTLP/HTTP metrics receiver for the
+// gen_ai.client.token.usage metric. Other metrics are accepted and dropped.
+func (h *Handler) APIIntegrationsOTLPMetrics(w http.ResponseWriter, r *http.Request) {
+	body, isJSON, ok := h.readOTLPRequest(w, r)
+	if !ok {
+		return
+	}
+	data, err := apiintegrations.DecodeOTLPMetrics(body, isJSON)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	result := h.otlpMetrics.Convert(data, h.otlpMapping())
+	h.finishOTLPRequest(w, result, isJSON, "rejectedDataPoints")
+}
+
+// otlpMapping returns the configured attribute mapping for OTLP events.
+func (h *Handler) otlpMapping() apiintegrations.OTLPMapping {
+	if h.config == nil {
+		return apiintegrations.OTLPMapping{}
+	}
+	return apiintegrations.OTLPMapping{
+		IntegrationAttr: h.config.APIIntegrationsOTLPIntegrationAttr,
+		AccountAttr:     h.config.APIIntegrationsOTLPAccountAttr,
+		CostAttr:        h.config.APIIntegrationsOTLPCostAttr,
+	}
+}
+
+// readOTLPRequest checks and reads an OTLP/HTTP export body, decompressing
+// gzip. It writes the error response and returns false when the request
+// cannot be processed. Disabled ingestion answers 403 rather than 503, which
+// exporters would keep retrying.
+func (h *Handler) readOTLPRequest(w http.ResponseWriter, r *http.Request) ([]byte, bool, bool) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil, false, false
+	}
+	if h.config != nil && !h.config.APIIntegrationsEnabled {
+		respondError(w, http.StatusForbidden, "API integrations ingestion is disabled")
+		return nil, false, false
+	}
+	if h.store == nil {
+		respondError(w, http.StatusServiceUnavailable, "store not available")
+		return nil, false, false
+	}
+
+	var isJSON bool
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		isJSON = true
+	case "application/x-protobuf", "application/protobuf":
+	default:
+		respondError(w, http.StatusUnsupportedMediaType, "content type must be application/x-protobuf or application/json")
+		return nil, false, false
+	}
+
+	body, err := readOTLPBody(w, r)
+	if err != nil {
+		if errors.Is(err, errOTLPBodyTooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return nil, false, false
+		}
+		respondError(w, http.StatusBadRequest, "failed to read request body")
+		return nil, false, false
+	}
+	return body, isJSON, true
+}
+
+func readOTLPBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	var reader io.Reader = http.MaxBytesReader(w, r.Body, maxAPIIntegrationIngestBody)
+	switch r.Header.Get("Content-Encoding") {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			if isMaxBytesError(err) {
+				return nil, errOTLPBodyTooLarge
+			}
+			return nil, err
+		}
+		defer gz.Close()
+		reader = io.LimitReader(gz, maxOTLPDecodedBody+1)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding")
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		if isMaxBytesError(err) {
+			return nil, errOTLPBodyTooLarge
+		}
+		return nil, err
+	}
+	if len(body) > maxOTLPDecodedBody {
+		return nil, errOTLPBodyTooLarge
+	}
+	return body, nil
+}
+
+// finishOTLPRequest stores the converted events and writes an OTLP export
+// response, reporting rejected items as a partial success. Duplicates from
+// retried exports are ignored; a storage failure answers 500 so the exporter
+// retries the whole batch.
+func (h *Handler) finishOTLPRequest(w http.ResponseWriter, result *apiintegrations.OTLPResult, isJSON bool, rejectedField string) {
+	for _, event := range result.Events {
+		if _, err := h.store.InsertAPIIntegrationUsageEvent(event); err != nil {
+			if errors.Is(err, store.ErrDuplicateAPIIntegrationUsageEvent) {
+				continue
+			}
+			h.logger.Error("failed to store OTLP API integration usage event", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to store events")
+			return
+		}
+	}
+	if result.Rejected > 0 {
+		h.logger.Warn("OTLP receiver rejected GenAI telemetry", "rejected", result.Rejected, "error", result.ErrorMessage())
+	}
+
+	if isJSON {
+		response := map[string]interface{}{}
+		if result.Rejected > 0 {
+			response["partialSuccess"] = map[string]string{
+				rejectedField:  strconv.Itoa(result.Rejected),
+				"errorMessage": result.ErrorMessage(),
+			}
+		}
+		respondJSON(w, http.StatusOK, response)
+		return
+	}
+
+	// Export*ServiceResponse: field 1 is the partial success message, whose
+	// field 1 is the rejected count and field 2 the error message.
+	var body []byte
+	if result.Rejected > 0 {
+		var partial []byte
+		partial = protowire.AppendTag(partial, 1, protowire.VarintType)
+		partial = protowire.AppendVarint(partial, uint64(result.Rejected))
+		partial = protowire.AppendTag(partial, 2, protowire.BytesType)
+		partial = protowire.AppendString(partial, result.ErrorMessage())
+		body = protowire.AppendTag(body, 1, protowire.BytesType)
+		body = protowire.AppendBytes(body, partial)
+	}
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
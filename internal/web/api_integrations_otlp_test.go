@@ -0,0 +1,161 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/config"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+func otlpStringAttr(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
+
+func otlpIntAttr(key string, value int64) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: value}}}
+}
+
+func TestHandler_APIIntegrationsOTLPTraces_ProtobufGzip(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+	h := NewHandler(s, nil, nil, nil, &config.Config{APIIntegrationsEnabled: true})
+
+	end := time.Date(2026, 4, 3, 12, 0, 1, 0, time.UTC)
+	span := func(id byte, system string) *tracepb.Span {
+		return &tracepb.Span{
+			SpanId:            []byte{id, 0, 0, 0, 0, 0, 0, 1},
+			Name:              "chat",
+			StartTimeUnixNano: uint64(end.Add(-time.Second).UnixNano()),
+			EndTimeUnixNano:   uint64(end.UnixNano()),
+			Attributes: []*commonpb.KeyValue{
+				otlpStringAttr("gen_ai.system", system),
+				otlpStringAttr("gen_ai.request.model", "mistral-small-latest"),
+				otlpIntAttr("gen_ai.usage.input_tokens", 12),
+				otlpIntAttr("gen_ai.usage.output_tokens", 3),
+			},
+		}
+	}
+	payload, err := proto.Marshal(&tracepb.TracesData{ResourceSpans: []*tracepb.ResourceSpans{{
+		Resource:   &resourcepb.Resource{Attributes: []*commonpb.KeyValue{otlpStringAttr("service.name", "worker")}},
+		ScopeSpans: []*tracepb.ScopeSpans{{Spans: []*tracepb.Span{span(1, "mistral_ai"), span(2, "co here")}}},
+	}}})
+	if err != nil {
+		t.Fatalf("proto.Marshal: %v", err)
+	}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(payload)
+	zw.Close()
+
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/api-integrations/otlp/v1/traces", bytes.NewReader(gz.Bytes()))
+		req.Header.Set("Content-Type", "application/x-protobuf")
+		req.Header.Set("Content-Encoding", "gzip")
+		rr := httptest.NewRecorder()
+		h.APIIntegrationsOTLPTraces(rr, req)
+		return rr
+	}
+
+	rr := post()
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rr.Code, rr.Body.String())
+	}
+	// ExportTraceServiceResponse.partial_success{rejected_spans: 1}
+	partial, n := protowire.ConsumeBytes(rr.Body.Bytes()[1:])
+	if n < 0 {
+		t.Fatalf("malformed partial success %x", rr.Body.Bytes())
+	}
+	rejected, _ := protowire.ConsumeVarint(partial[1:])
+	if rejected != 1 || !strings.Contains(string(partial), "co here") {
+		t.Fatalf("partial success rejected=%d message=%q", rejected, partial)
+	}
+
+	// A retried export is deduplicated.
+	if rr := post(); rr.Code != http.StatusOK {
+		t.Fatalf("retry status=%d", rr.Code)
+	}
+	events, err := s.QueryAPIIntegrationUsageRange(end.Add(-time.Hour), end.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationUsageRange: %v", err)
+	}
+	if len(events) != 1 || events[0].Provider != "mistral" || events[0].TotalTokens != 15 || events[0].SourcePath != "otlp" {
+		t.Fatalf("events=%+v want one mistral event", events)
+	}
+}
+
+func TestHandler_APIIntegrationsOTLPMetrics_JSONAndRejections(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+	h := NewHandler(s, nil, nil, nil, &config.Config{APIIntegrationsEnabled: true})
+
+	body := `{"resourceMetrics":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"batch"}}]},
+		"scopeMetrics":[{"metrics":[{"name":"gen_ai.client.token.usage","histogram":{"aggregationTemporality":1,"dataPoints":[
+			{"timeUnixNano":"1775217600000000000","count":"2","sum":64,"attributes":[
+				{"key":"gen_ai.token.type","value":{"stringValue":"input"}},
+				{"key":"gen_ai.system","value":{"stringValue":"openai"}},
+				{"key":"gen_ai.request.model","value":{"stringValue":"gpt-4.1"}}]}]}}]}]}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/api-integrations/otlp/v1/metrics", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	h.APIIntegrationsOTLPMetrics(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", rr.Code, rr.Body.String())
+	}
+	var response map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || len(response) != 0 {
+		t.Fatalf("response=%s want {}", rr.Body.String())
+	}
+	events, err := s.QueryAPIIntegrationUsageRange(time.Unix(0, 1775217600000000000).Add(-time.Minute), time.Unix(0, 1775217600000000000).Add(time.Minute))
+	if err != nil {
+		t.Fatalf("QueryAPIIntegrationUsageRange: %v", err)
+	}
+	if len(events) != 1 || events[0].Integration != "batch" || events[0].PromptTokens != 64 {
+		t.Fatalf("events=%+v want one 64-token event", events)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/api-integrations/otlp/v1/metrics", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/plain")
+	rr = httptest.NewRecorder()
+	h.APIIntegrationsOTLPMetrics(rr, req)
+	if rr.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("text/plain status=%d want 415", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/api-integrations/otlp/v1/traces", strings.NewReader("{not json"))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	h.APIIntegrationsOTLPTraces(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("malformed status=%d want 400", rr.Code)
+	}
+
+	disabled := NewHandler(s, nil, nil, nil, &config.Config{APIIntegrationsEnabled: false})
+	req = httptest.NewRequest(http.MethodPost, "/api/api-integrations/otlp/v1/traces", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	disabled.APIIntegrationsOTLPTraces(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("disabled status=%d want 403", rr.Code)
+	}
+}
@@ -0,0 +1,280 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/notify"
+)
+
+// settingBudgets stores the configured monthly spend budgets.
+const settingBudgets = "budgets"
+
+const (
+	// defaultBudgetWarning and defaultBudgetCritical are the percentages of a
+	// budget that raise alerts when a budget does not set its own.
+	defaultBudgetWarning  = 80
+	defaultBudgetCritical = 100
+	maxBudgets            = 50
+	maxBudgetTargetLength = 128
+)
+
+// budgetScopeLabels lists the dimensions a budget can apply to. "total"
+// covers all metered spend; the others need a target value.
+var budgetScopeLabels = map[string]string{
+	"total":       "All spend",
+	"provider":    "Provider",
+	"integration": "Integration",
+	"account":     "Account",
+	"model":       "Model",
+}
+
+// budget is a monthly USD limit on metered spend: prepaid balance decreases,
+// OpenRouter credits and API integration costs. Subscriptions are fixed
+// costs and never count against a budget.
+type budget struct {
+	Scope     string  `json:"scope"`
+	Target    string  `json:"target,omitempty"`
+	AmountUSD float64 `json:"amount_usd"`
+	Warning   float64 `json:"warning,omitempty"`  // percent of the budget; default 80
+	Critical  float64 `json:"critical,omitempty"` // percent of the budget; default 100
+}
+
+// key identifies the budget, e.g. "total" or "model:gpt-4o".
+func (b budget) key() string {
+	if b.Scope == "total" {
+		return b.Scope
+	}
+	return b.Scope + ":" + strings.ToLower(b.Target)
+}
+
+func (b budget) label() string {
+	if b.Scope == "total" {
+		return budgetScopeLabels[b.Scope]
+	}
+	target := b.Target
+	if b.Scope == "provider" {
+		target = defaultProviderTabLabel(strings.ToLower(target))
+	}
+	return budgetScopeLabels[b.Scope] + " " + target
+}
+
+// parseBudgets validates the budgets settings value and fills in default
+// alert percentages.
+func parseBudgets(raw json.RawMessage) ([]budget, error) {
+	var in []budget
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return nil, fmt.Errorf("invalid budgets value")
+	}
+	if len(in) > maxBudgets {
+		return nil, fmt.Errorf("at most %d budgets are supported", maxBudgets)
+	}
+	out := make([]budget, 0, len(in))
+	seen := make(map[string]bool, len(in))
+	for _, b := range in {
+		b.Scope = strings.ToLower(strings.TrimSpace(b.Scope))
+		b.Target = strings.TrimSpace(b.Target)
+		if _, ok := budgetScopeLabels[b.Scope]; !ok {
+			return nil, fmt.Errorf("invalid budget scope: %s", b.Scope)
+		}
+		if b.Scope == "total" {
+			b.Target = ""
+		} else if b.Target == "" || len(b.Target) > maxBudgetTargetLength {
+			return nil, fmt.Errorf("%s budget needs a target of at most %d characters", b.Scope, maxBudgetTargetLength)
+		}
+		if b.AmountUSD <= 0 || b.AmountUSD > 1e7 || math.IsNaN(b.AmountUSD) {
+			return nil, fmt.Errorf("budget for %s must be a positive amount", b.label())
+		}
+		if b.Warning == 0 {
+			b.Warning = defaultBudgetWarning
+		}
+		if b.Critical == 0 {
+			b.Critical = defaultBudgetCritical
+		}
+		if b.Warning < 1 || b.Critical > 1000 || b.Warning >= b.Critical {
+			return nil, fmt.Errorf("budget for %s needs a warning percentage below the critical one", b.label())
+		}
+		if seen[b.key()] {
+			return nil, fmt.Errorf("duplicate budget for %s", b.label())
+		}
+		seen[b.key()] = true
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+// loadBudgets returns the saved budgets, or none when unset.
+func (h *Handler) loadBudgets() []budget {
+	budgets := []budget{}
+	if h.store == nil {
+		return budgets
+	}
+	raw, err := h.store.GetSetting(settingBudgets)
+	if err != nil || raw == "" {
+		return budgets
+	}
+	if err := json.Unmarshal([]byte(raw), &budgets); err != nil || budgets == nil {
+		return []budget{}
+	}
+	return budgets
+}
+
+// budgetStatus is a budget with the spend counted against it this month.
+type budgetStatus struct {
+	Key              string  `json:"key"`
+	Scope            string  `json:"scope"`
+	Target           string  `json:"target,omitempty"`
+	Label            string  `json:"label"`
+	BudgetUSD        float64 `json:"budgetUsd"`
+	SpentUSD         float64 `json:"spentUsd"`
+	ProjectedUSD     float64 `json:"projectedUsd"`
+	Percent          float64 `json:"percent"`
+	ProjectedPercent float64 `json:"projectedPercent"`
+	Warning          float64 `json:"warning"`
+	Critical         float64 `json:"critical"`
+	Level            string  `json:"level"` // "ok", "warning" or "critical"
+}
+
+// buildBudgetStatuses measures every budget against the month's metered
+// spend. A provider with a tracked balance or credit account is measured by
+// that alone, since its API integration events draw from the same balance;
+// other providers are measured by the events that name them.
+func (h *Handler) buildBudgetStatuses(m spendMonth) []budgetStatus {
+	budgets := h.loadBudgets()
+	statuses := make([]budgetStatus, 0, len(budgets))
+	if len(budgets) == 0 {
+		return statuses
+	}
+
+	var total float64
+	providerSpend := map[string]float64{}
+	metered := map[string]bool{}
+	for _, p := range meteredSpendProviders {
+		metered[p] = h.config != nil && h.config.HasProvider(p)
+	}
+	for _, src := range h.spendSources(m) {
+		if src.Kind == "subscription" || src.Kind == "integration" || !src.Converted {
+			continue
+		}
+		total += src.USD
+		providerSpend[src.ID] += src.USD
+	}
+	// API integration costs per scope value, queried once per scope in use.
+	costs := map[string]map[string]float64{}
+	scopeCosts := func(scope string) map[string]float64 {
+		if c, ok := costs[scope]; ok {
+			return c
+		}
+		c, err := h.store.QueryAPIIntegrationCostByScope(m.Start, m.End, scope)
+		if err != nil {
+			h.logger.Error("Failed to query API integration costs for budgets", "scope", scope, "error", err)
+		}
+		costs[scope] = c
+		return c
+	}
+
+	for _, b := range budgets {
+		var spent float64
+		target := strings.ToLower(b.Target)
+		switch b.Scope {
+		case "total":
+			spent = total
+			for provider, cost := range scopeCosts("provider") {
+				if !metered[provider] {
+					spent += cost
+				}
+			}
+		case "provider":
+			spent = providerSpend[target]
+			if !metered[target] {
+				spent += scopeCosts("provider")[target]
+			}
+		default:
+			spent = scopeCosts(b.Scope)[target]
+		}
+
+		st := budgetStatus{
+			Key:          b.key(),
+			Scope:        b.Scope,
+			Target:       b.Target,
+			Label:        b.label(),
+			BudgetUSD:    b.AmountUSD,
+			SpentUSD:     spent,
+			ProjectedUSD: m.project(spent),
+			Warning:      b.Warning,
+			Critical:     b.Critical,
+			Level:        "ok",
+		}
+		st.Percent = spent / b.AmountUSD * 100
+		st.ProjectedPercent = st.ProjectedUSD / b.AmountUSD * 100
+		switch {
+		case st.Percent >= b.Critical:
+			st.Level = "critical"
+		case st.Percent >= b.Warning:
+			st.Level = "warning"
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
+
+// Budgets handles GET /api/budgets: every monthly budget with this month's
+// spend and the projected end-of-month spend.
+func (h *Handler) Budgets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	loc, err := h.heatmapLocation(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+	now := time.Now().In(loc)
+	m := newSpendMonth(now, loc, now)
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"month":    m.Start.Format("2006-01"),
+		"timezone": loc.String(),
+		"budgets":  h.buildBudgetStatuses(m),
+	})
+}
+
+// BudgetStatuses reports the budgets for the month containing now, in the
+// saved timezone. It is the notify.BudgetProvider behind budget alerts.
+func (h *Handler) BudgetStatuses(now time.Time) ([]notify.BudgetStatus, error) {
+	if h.store == nil {
+		return nil, nil
+	}
+	loc := time.Local
+	if tz, err := h.store.GetSetting("timezone"); err == nil && tz != "" {
+		if saved, err := time.LoadLocation(tz); err == nil {
+			loc = saved
+		}
+	}
+	now = now.In(loc)
+	m := newSpendMonth(now, loc, now)
+	month := m.Start.Format("2006-01")
+
+	var out []notify.BudgetStatus
+	for _, st := range h.buildBudgetStatuses(m) {
+		out = append(out, notify.BudgetStatus{
+			Key:       st.Key,
+			Label:     st.Label,
+			Month:     month,
+			Budget:    st.BudgetUSD,
+			Spent:     st.SpentUSD,
+			Projected: st.ProjectedUSD,
+			Warning:   st.Warning,
+			Critical:  st.Critical,
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,126 @@
+package web
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/api"
+	"github.com/onllm-dev/onwatch/v2/internal/config"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+func TestParseBudgets(t *testing.T) {
+	t.Parallel()
+	budgets, err := parseBudgets(json.RawMessage(`[
+		{"scope": "total", "target": "ignored", "amount_usd": 100},
+		{"scope": " Model ", "target": " gpt-4o ", "amount_usd": 20, "warning": 50, "critical": 90}
+	]`))
+	if err != nil {
+		t.Fatalf("parseBudgets: %v", err)
+	}
+	if len(budgets) != 2 {
+		t.Fatalf("budgets = %+v", budgets)
+	}
+	if b := budgets[0]; b.Target != "" || b.Warning != defaultBudgetWarning || b.Critical != defaultBudgetCritical || b.key() != "total" {
+		t.Errorf("total budget = %+v, want defaults and no target", b)
+	}
+	if b := budgets[1]; b.Scope != "model" || b.Target != "gpt-4o" || b.key() != "model:gpt-4o" || b.label() != "Model gpt-4o" {
+		t.Errorf("model budget = %+v", b)
+	}
+
+	for _, raw := range []string{
+		`[{"scope": "team", "target": "x", "amount_usd": 10}]`,
+		`[{"scope": "model", "amount_usd": 10}]`,
+		`[{"scope": "total", "amount_usd": 0}]`,
+		`[{"scope": "total", "amount_usd": 10, "warning": 100, "critical": 90}]`,
+		`[{"scope": "model", "target": "a", "amount_usd": 1}, {"scope": "model", "target": "A", "amount_usd": 2}]`,
+		`{}`,
+	} {
+		if _, err := parseBudgets(json.RawMessage(raw)); err == nil {
+			t.Errorf("parseBudgets(%s) should fail", raw)
+		}
+	}
+}
+
+func TestHandler_Budgets(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	now := time.Now().UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i, usage := range []float64{20, 28} {
+		snap := &api.OpenRouterSnapshot{CapturedAt: monthStart.Add(time.Duration(i) * time.Minute), Usage: usage}
+		if _, err := s.InsertOpenRouterSnapshot(snap); err != nil {
+			t.Fatalf("InsertOpenRouterSnapshot: %v", err)
+		}
+	}
+	ts := monthStart.Add(time.Hour).Format(time.RFC3339)
+	insertAPIIntegrationEventForTest(t, s, `{"ts":"`+ts+`","integration":"notes","provider":"openrouter","account":"work","model":"deepseek-chat","prompt_tokens":10,"completion_tokens":5,"cost_usd":3}`, "/tmp/api-integrations/notes.jsonl")
+	insertAPIIntegrationEventForTest(t, s, `{"ts":"`+ts+`","integration":"bot","provider":"openai","account":"work","model":"gpt-4o","prompt_tokens":10,"completion_tokens":5,"cost_usd":2}`, "/tmp/api-integrations/bot.jsonl")
+
+	h := NewHandler(s, nil, nil, nil, &config.Config{OpenRouterAPIKey: "sk-or-test", APIIntegrationsEnabled: true})
+	req := httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(`{"timezone": "UTC", "budgets": [
+		{"scope": "total", "amount_usd": 100},
+		{"scope": "provider", "target": "openrouter", "amount_usd": 10},
+		{"scope": "provider", "target": "openai", "amount_usd": 2},
+		{"scope": "account", "target": "work", "amount_usd": 6},
+		{"scope": "model", "target": "gpt-4o", "amount_usd": 50}
+	]}`))
+	rr := httptest.NewRecorder()
+	h.UpdateSettings(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("UpdateSettings: %d %s", rr.Code, rr.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/budgets?tz=UTC", nil)
+	rr = httptest.NewRecorder()
+	h.Budgets(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Budgets []budgetStatus `json:"budgets"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	want := map[string]struct {
+		spent float64
+		level string
+	}{
+		"total":               {10, "ok"},      // 8 credits + 2 OpenAI; OpenRouter events are in the credits
+		"provider:openrouter": {8, "warning"},  // credits only
+		"provider:openai":     {2, "critical"}, // events naming OpenAI
+		"account:work":        {5, "warning"},  // 83% of 6
+		"model:gpt-4o":        {2, "ok"},
+	}
+	if len(resp.Budgets) != len(want) {
+		t.Fatalf("budgets = %+v", resp.Budgets)
+	}
+	for _, b := range resp.Budgets {
+		w := want[b.Key]
+		if math.Abs(b.SpentUSD-w.spent) > 1e-9 || b.Level != w.level {
+			t.Errorf("%s: spent %.2f (%s), want %.2f (%s)", b.Key, b.SpentUSD, b.Level, w.spent, w.level)
+		}
+		if b.ProjectedUSD < b.SpentUSD {
+			t.Errorf("%s: projected %.2f below spent %.2f", b.Key, b.ProjectedUSD, b.SpentUSD)
+		}
+	}
+
+	statuses, err := h.BudgetStatuses(now)
+	if err != nil || len(statuses) != len(want) {
+		t.Fatalf("BudgetStatuses = %+v, %v", statuses, err)
+	}
+	if st := statuses[1]; st.Key != "provider:openrouter" || st.Month != monthStart.Format("2006-01") || st.Percent() != 80 {
+		t.Errorf("openrouter status = %+v, want 80%% for %s", st, monthStart.Format("2006-01"))
+	}
+}
@@ -102,6 +102,18 @@ func ReEncryptAllData(store interface {
 		errors["smtp"] = err.Error()
 	}
 
+	// Re-encrypt SMTP OAuth2 secrets
+	for _, field := range []string{"oauth_client_secret", "oauth_refresh_token"} {
+		if err := reEncryptSettingField(store, "smtp", field, oldKey, newKey); err != nil {
+			errors["smtp_"+field] = err.Error()
+		}
+	}
+
+	// Re-encrypt Telegram bot token
+	if err := reEncryptSettingField(store, "telegram", "bot_token", oldKey, newKey); err != nil {
+		errors["telegram"] = err.Error()
+	}
+
 	return errors
 }
 
@@ -172,3 +184,51 @@ func reEncryptSMTPPassword(store interface {
 
 	return nil
 }
+
+// reEncryptSettingField re-encrypts one "enc:"-prefixed field of a JSON setting
+// (see notify.EncryptForStorage). Plaintext values are encrypted with the new key.
+func reEncryptSettingField(store interface {
+	GetSetting(key string) (string, error)
+	SetSetting(key, value string) error
+}, settingKey, field, oldKey, newKey string) error {
+	settingJSON, err := store.GetSetting(settingKey)
+	if err != nil || settingJSON == "" {
+		return nil
+	}
+
+	var settings map[string]interface{}
+	if err := json.Unmarshal([]byte(settingJSON), &settings); err != nil {
+		return fmt.Errorf("failed to parse %s settings: %w", settingKey, err)
+	}
+
+	value, _ := settings[field].(string)
+	if value == "" {
+		return nil
+	}
+
+	plaintext := value
+	if IsEncryptedValue(value) {
+		plaintext, err = notify.DecryptFromStorage(value, oldKey)
+		if err != nil {
+			if _, tryNewErr := notify.DecryptFromStorage(value, newKey); tryNewErr == nil {
+				return nil // already encrypted with new key
+			}
+			return fmt.Errorf("failed to decrypt %s %s with old key: %w", settingKey, field, err)
+		}
+	}
+
+	newEncrypted, err := notify.EncryptForStorage(plaintext, newKey)
+	if err != nil {
+		return fmt.Errorf("failed to re-encrypt %s %s: %w", settingKey, field, err)
+	}
+	settings[field] = newEncrypted
+
+	newJSON, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s settings: %w", settingKey, err)
+	}
+	if err := store.SetSetting(settingKey, string(newJSON)); err != nil {
+		return fmt.Errorf("failed to save %s settings: %w", settingKey, err)
+	}
+	return nil
+}
@@ -373,3 +373,80 @@ func TestReEncryptSMTPPassword_Branches(t *testing.T) {
 		})
 	}
 }
+
+func TestReEncryptAllData_TelegramBotToken(t *testing.T) {
+	t.Parallel()
+	setTestEncryptionSalt(t, []byte("abcdefghijklmnop"))
+	oldKey := DeriveEncryptionKey("old-hash", nil)
+	newKey := DeriveEncryptionKey("new-hash", nil)
+
+	encrypted, err := notify.EncryptForStorage("123456:secret", oldKey)
+	if err != nil {
+		t.Fatalf("notify.EncryptForStorage() error = %v", err)
+	}
+	store := newMemorySettingStore()
+	store.settings["telegram"] = `{"bot_token":"` + encrypted + `","chat_ids":"111"}`
+
+	errs := ReEncryptAllData(store, "old-hash", "new-hash")
+	if len(errs) != 0 {
+		t.Fatalf("ReEncryptAllData() errors = %v, want none", errs)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(store.settings["telegram"]), &got); err != nil {
+		t.Fatalf("failed to parse updated telegram setting: %v", err)
+	}
+	if got["chat_ids"] != "111" {
+		t.Fatalf("chat_ids = %v, want other fields preserved", got["chat_ids"])
+	}
+	ciphertext, _ := got["bot_token"].(string)
+	plaintext, err := notify.DecryptFromStorage(ciphertext, newKey)
+	if err != nil {
+		t.Fatalf("notify.DecryptFromStorage() with new key error = %v", err)
+	}
+	if plaintext != "123456:secret" {
+		t.Fatalf("decrypted bot token = %q, want 123456:secret", plaintext)
+	}
+}
+
+func TestReEncryptSettingField_Branches(t *testing.T) {
+	t.Parallel()
+	setTestEncryptionSalt(t, []byte("abcdefghijklmnop"))
+	oldKey := DeriveEncryptionKey("old-hash", nil)
+	newKey := DeriveEncryptionKey("new-hash", nil)
+	alreadyNew, _ := notify.EncryptForStorage("secret", newKey)
+
+	tests := []struct {
+		name          string
+		setting       string
+		wantErrSubstr string
+		wantSetCalls  int
+	}{
+		{name: "missing setting returns nil", setting: "", wantSetCalls: 0},
+		{name: "invalid json returns parse error", setting: "invalid-json", wantErrSubstr: "failed to parse telegram settings", wantSetCalls: 0},
+		{name: "empty field returns nil", setting: `{"bot_token":""}`, wantSetCalls: 0},
+		{name: "already encrypted with new key is skipped", setting: `{"bot_token":"` + alreadyNew + `"}`, wantSetCalls: 0},
+		{name: "undecryptable value returns error", setting: `{"bot_token":"enc:AAAAAAAAAAAAAAAAAAAAAAAA"}`, wantErrSubstr: "failed to decrypt telegram bot_token with old key", wantSetCalls: 0},
+		{name: "plaintext value gets encrypted", setting: `{"bot_token":"secret"}`, wantSetCalls: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMemorySettingStore()
+			store.settings["telegram"] = tc.setting
+
+			err := reEncryptSettingField(store, "telegram", "bot_token", oldKey, newKey)
+			if tc.wantErrSubstr == "" {
+				if err != nil {
+					t.Fatalf("reEncryptSettingField() unexpected error = %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tc.wantErrSubstr) {
+				t.Fatalf("reEncryptSettingField() error = %v, want substring %q", err, tc.wantErrSubstr)
+			}
+
+			if store.setCalls != tc.wantSetCalls {
+				t.Fatalf("SetSetting call count = %d, want %d", store.setCalls, tc.wantSetCalls)
+			}
+		})
+	}
+}
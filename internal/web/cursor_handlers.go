@@ -35,21 +35,6 @@ var cursorQuotaDisplayOrder = map[string]int{
 	"on_demand":   5,
 }
 
-var cursorDisplayNames = map[string]string{
-	"total_usage": "Total Usage",
-	"auto_usage":  "Auto + Composer",
-	"api_usage":   "API Usage",
-	"credits":     "Credits",
-	"on_demand":   "On-Demand",
-}
-
-func cursorDisplayName(name string) string {
-	if dn, ok := cursorDisplayNames[name]; ok {
-		return dn
-	}
-	return name
-}
-
 func cursorQuotaOrder(name string) int {
 	if order, ok := cursorQuotaDisplayOrder[name]; ok {
 		return order
@@ -129,7 +114,7 @@ func (h *Handler) computeCursorRate(quotaName string, currentUtil float64, summa
 func buildCursorBurnRateInsight(quota api.CursorQuota, rate cursorQuotaRate) insightItem {
 	item := insightItem{
 		Key:   fmt.Sprintf("forecast_%s", quota.Name),
-		Title: fmt.Sprintf("%s Burn Rate", cursorDisplayName(quota.Name)),
+		Title: fmt.Sprintf("%s Burn Rate", api.CursorDisplayName(quota.Name)),
 	}
 
 	resetStr := ""
@@ -211,7 +196,7 @@ func (h *Handler) buildCursorCurrent() map[string]interface{} {
 		for _, q := range latest.Quotas {
 			quotaMap := map[string]interface{}{
 				"name":          q.Name,
-				"displayName":   cursorDisplayName(q.Name),
+				"displayName":   api.CursorDisplayName(q.Name),
 				"utilization":   q.Utilization,
 				"used":          q.Used,
 				"limit":         q.Limit,
@@ -252,7 +237,7 @@ func (h *Handler) buildCursorCurrent() map[string]interface{} {
 		age := now.Sub(q.CapturedAt)
 		qMap := map[string]interface{}{
 			"name":          q.Name,
-			"displayName":   cursorDisplayName(q.Name),
+			"displayName":   api.CursorDisplayName(q.Name),
 			"utilization":   q.Utilization,
 			"used":          q.Used,
 			"limit":         q.Limit,
@@ -557,7 +542,7 @@ func (h *Handler) buildCursorInsights(hidden map[string]bool, _ time.Duration) c
 		insight := buildCursorBurnRateInsight(quota, rate)
 		resp.Stats = append(resp.Stats, cursorInsightStat{
 			Key:      insightKey,
-			Label:    fmt.Sprintf("%s Burn Rate", cursorDisplayName(quota.Name)),
+			Label:    fmt.Sprintf("%s Burn Rate", api.CursorDisplayName(quota.Name)),
 			Value:    value,
 			Sublabel: insight.Sublabel,
 			Metric:   insight.Metric,
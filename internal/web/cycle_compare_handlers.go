@@ -0,0 +1,489 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+const (
+	// defaultCompareCycles is the number of previous cycles compared when the
+	// cycles query param is absent.
+	defaultCompareCycles = 4
+	// maxCompareCycles caps the cycles query param.
+	maxCompareCycles = 12
+	// compareGridPoints is the most points each curve is resampled onto.
+	compareGridPoints = 96
+	// compareProbeWindow is how far back the default quota is looked up when
+	// the quota query param is absent.
+	compareProbeWindow = 7 * 24 * time.Hour
+)
+
+// compareGridSteps are the candidate grid resolutions, in hours.
+var compareGridSteps = []float64{0.25, 0.5, 1, 2, 3, 4, 6, 12, 24}
+
+// cycleSpan is the time range of one reset cycle. End is nil while active.
+type cycleSpan struct {
+	Start time.Time
+	End   *time.Time
+}
+
+// cycleCurve is a cycle's cumulative usage against hours since cycle start.
+type cycleCurve struct {
+	Offsets []float64
+	Values  []float64
+	Limit   float64 // hours the curve covers (cycle duration or time elapsed)
+}
+
+// compareCycle is one cycle's curve resampled onto the shared grid. Values
+// are nil where the cycle has no data (before the first sample or past its end).
+type compareCycle struct {
+	Start         time.Time  `json:"start"`
+	End           *time.Time `json:"end"`
+	DurationHours float64    `json:"durationHours"`
+	Total         float64    `json:"total"`
+	Values        []*float64 `json:"values"`
+}
+
+// comparePercentiles holds the spread of previous cycles at each grid offset.
+type comparePercentiles struct {
+	P25 []*float64 `json:"p25"`
+	P50 []*float64 `json:"p50"`
+	P75 []*float64 `json:"p75"`
+}
+
+// compareSummary compares the current cycle with the previous cycles' median
+// at the same time since cycle start.
+type compareSummary struct {
+	OffsetHours float64 `json:"offsetHours"`
+	Current     float64 `json:"current"`
+	Median      float64 `json:"median"`
+	Delta       float64 `json:"delta"`
+	Cycles      int     `json:"cycles"`
+}
+
+// compareQuotaOption is a quota that can be selected for comparison.
+type compareQuotaOption struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+}
+
+// parseCompareCycles reads the cycles query param (default 4, max 12).
+func parseCompareCycles(raw string) int {
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		return defaultCompareCycles
+	}
+	if n > maxCompareCycles {
+		return maxCompareCycles
+	}
+	return n
+}
+
+// CyclesCompare handles GET /api/cycles/compare. It returns the current
+// cycle's cumulative usage curve alongside the previous N completed cycles,
+// aligned on time since cycle start, with p25/p50/p75 bands.
+func (h *Handler) CyclesCompare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	provider, err := h.getProviderFromRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+
+	accountID := parseCodexAccountID(r)
+	quota := r.URL.Query().Get("quota")
+	n := parseCompareCycles(r.URL.Query().Get("cycles"))
+	now := time.Now().UTC()
+
+	probe, supported, err := h.quotaSeries(provider, accountID, now.Add(-compareProbeWindow), now)
+	if !supported {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("cycle comparison not available for provider: %s", provider))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to query cycle comparison data", "provider", provider, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to query cycle comparison data")
+		return
+	}
+	if quota == "" && len(probe) > 0 {
+		quota = probe[0].Name
+	}
+	quotas := make([]compareQuotaOption, 0, len(probe))
+	label := quota
+	for _, s := range probe {
+		quotas = append(quotas, compareQuotaOption{Name: s.Name, Label: s.Label})
+		if s.Name == quota {
+			label = s.Label
+		}
+	}
+
+	current, previous, supported, err := h.compareCycleSpans(provider, accountID, quota, n)
+	if !supported {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("cycle comparison not available for provider: %s", provider))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to query cycles for comparison", "provider", provider, "quota", quota, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to query cycle comparison data")
+		return
+	}
+
+	// Each cycle's samples are read over its own range, so the snapshot
+	// limit of one query never cuts the older cycles short.
+	spans := previous
+	if current != nil {
+		spans = append([]cycleSpan{*current}, previous...)
+	}
+	var samples []store.QuotaSample
+	for _, span := range spans {
+		end := now
+		if span.End != nil {
+			end = *span.End
+		}
+		series, _, err := h.quotaSeries(provider, accountID, span.Start, end)
+		if err != nil {
+			h.logger.Error("Failed to query cycle comparison data", "provider", provider, "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to query cycle comparison data")
+			return
+		}
+		for _, s := range series {
+			if s.Name == quota {
+				samples = append(samples, s.Samples...)
+				if label == quota {
+					label = s.Label
+				}
+			}
+		}
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].At.Before(samples[j].At) })
+
+	result := buildCycleComparison(samples, current, previous, now)
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"provider":    provider,
+		"quota":       quota,
+		"label":       label,
+		"quotas":      quotas,
+		"stepHours":   result.StepHours,
+		"offsets":     result.Offsets,
+		"current":     result.Current,
+		"previous":    result.Previous,
+		"percentiles": result.Percentiles,
+		"comparison":  result.Summary,
+	})
+}
+
+// cycleComparison is the provider-independent result of comparing cycles.
+type cycleComparison struct {
+	StepHours   float64
+	Offsets     []float64
+	Current     *compareCycle
+	Previous    []compareCycle
+	Percentiles comparePercentiles
+	Summary     *compareSummary
+}
+
+// buildCycleComparison builds the current and previous cycle curves from a
+// quota's samples, resamples them onto a shared grid of hours since cycle
+// start and computes percentile bands across the previous cycles.
+func buildCycleComparison(samples []store.QuotaSample, current *cycleSpan, previous []cycleSpan, now time.Time) cycleComparison {
+	var curCurve *cycleCurve
+	if current != nil {
+		c := buildCycleCurve(samples, current.Start, now)
+		curCurve = &c
+	}
+	prevCurves := make([]cycleCurve, len(previous))
+	span := 0.0
+	for i, p := range previous {
+		prevCurves[i] = buildCycleCurve(samples, p.Start, *p.End)
+		span = math.Max(span, prevCurves[i].Limit)
+	}
+	if curCurve != nil {
+		span = math.Max(span, curCurve.Limit)
+	}
+
+	step := compareGridSteps[len(compareGridSteps)-1]
+	for _, s := range compareGridSteps {
+		if span/s <= compareGridPoints {
+			step = s
+			break
+		}
+	}
+	offsets := []float64{}
+	for off := 0.0; off <= span; off += step {
+		offsets = append(offsets, off)
+	}
+
+	res := cycleComparison{StepHours: step, Offsets: offsets, Previous: make([]compareCycle, 0, len(previous))}
+	if curCurve != nil {
+		res.Current = resampleCycle(*curCurve, *current, offsets)
+	}
+	for i, p := range previous {
+		res.Previous = append(res.Previous, *resampleCycle(prevCurves[i], p, offsets))
+	}
+
+	res.Percentiles = comparePercentiles{
+		P25: make([]*float64, len(offsets)),
+		P50: make([]*float64, len(offsets)),
+		P75: make([]*float64, len(offsets)),
+	}
+	for i, off := range offsets {
+		values := curveValuesAt(prevCurves, off)
+		if len(values) == 0 {
+			continue
+		}
+		p25, p50, p75 := percentile(values, 25), percentile(values, 50), percentile(values, 75)
+		res.Percentiles.P25[i], res.Percentiles.P50[i], res.Percentiles.P75[i] = &p25, &p50, &p75
+	}
+
+	if curCurve != nil {
+		if cur, ok := curCurve.valueAt(curCurve.Limit); ok {
+			if values := curveValuesAt(prevCurves, curCurve.Limit); len(values) > 0 {
+				med := percentile(values, 50)
+				res.Summary = &compareSummary{
+					OffsetHours: curCurve.Limit,
+					Current:     cur,
+					Median:      med,
+					Delta:       cur - med,
+					Cycles:      len(values),
+				}
+			}
+		}
+	}
+	return res
+}
+
+// buildCycleCurve accumulates a cycle's usage from the samples captured
+// between start and end. The first sample's utilization is usage since the
+// reset; after that only increases count, so in-window drops do not undo it.
+func buildCycleCurve(samples []store.QuotaSample, start, end time.Time) cycleCurve {
+	c := cycleCurve{Limit: math.Max(end.Sub(start).Hours(), 0)}
+	var prev *store.QuotaSample
+	total := 0.0
+	for i := range samples {
+		s := &samples[i]
+		if s.At.Before(start) || !s.At.Before(end) {
+			continue
+		}
+		if prev == nil {
+			total = math.Max(s.Percent, 0)
+		} else if delta := s.Percent - prev.Percent; delta > 0 {
+			total += delta
+		}
+		prev = s
+		c.Offsets = append(c.Offsets, s.At.Sub(start).Hours())
+		c.Values = append(c.Values, total)
+	}
+	return c
+}
+
+// valueAt returns the curve's value at offset, carrying the latest sample
+// forward. It reports false before the first sample or past the curve's end.
+func (c cycleCurve) valueAt(offset float64) (float64, bool) {
+	if offset > c.Limit || len(c.Offsets) == 0 || offset < c.Offsets[0] {
+		return 0, false
+	}
+	i := sort.Search(len(c.Offsets), func(i int) bool { return c.Offsets[i] > offset })
+	return c.Values[i-1], true
+}
+
+// curveValuesAt collects the value of every curve that covers offset.
+func curveValuesAt(curves []cycleCurve, offset float64) []float64 {
+	var values []float64
+	for _, c := range curves {
+		if v, ok := c.valueAt(offset); ok {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func resampleCycle(c cycleCurve, span cycleSpan, offsets []float64) *compareCycle {
+	out := &compareCycle{
+		Start:         span.Start,
+		End:           span.End,
+		DurationHours: c.Limit,
+		Values:        make([]*float64, len(offsets)),
+	}
+	if n := len(c.Values); n > 0 {
+		out.Total = c.Values[n-1]
+	}
+	for i, off := range offsets {
+		if v, ok := c.valueAt(off); ok {
+			out.Values[i] = &v
+		}
+	}
+	return out
+}
+
+// percentile returns the p-th percentile of values using linear interpolation
+// between closest ranks.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+// compareCycleSpans returns the active cycle (nil if none) and up to limit
+// completed cycles, newest first, for a provider quota. The third return
+// value is false for providers without percentage quotas.
+func (h *Handler) compareCycleSpans(provider string, accountID int64, quota string, limit int) (*cycleSpan, []cycleSpan, bool, error) {
+	var current *cycleSpan
+	var previous []cycleSpan
+	add := func(start time.Time, end *time.Time) {
+		if end == nil {
+			if current == nil {
+				current = &cycleSpan{Start: start}
+			}
+			return
+		}
+		if len(previous) < limit {
+			previous = append(previous, cycleSpan{Start: start, End: end})
+		}
+	}
+
+	switch provider {
+	case "synthetic":
+		if active, err := h.store.QueryActiveCycle(quota); err != nil {
+			return nil, nil, true, err
+		} else if active != nil {
+			add(active.CycleStart, nil)
+		}
+		cycles, err := h.store.QueryCycleHistory(quota, limit)
+		if err != nil {
+			return nil, nil, true, err
+		}
+		for _, c := range cycles {
+			add(c.CycleStart, c.CycleEnd)
+		}
+	case "zai":
+		if active, err := h.store.QueryActiveZaiCycle(quota); err != nil {
+			return nil, nil, true, err
+		} else if active != nil {
+			add(active.CycleStart, nil)
+		}
+		cycles, err := h.store.QueryZaiCycleHistory(quota, limit)
+		if err != nil {
+			return nil, nil, true, err
+		}
+		for _, c := range cycles {
+			add(c.CycleStart, c.CycleEnd)
+		}
+	case "anthropic":
+		if active, err := h.store.QueryActiveAnthropicCycle(quota); err != nil {
+			return nil, nil, true, err
+		} else if active != nil {
+			add(active.CycleStart, nil)
+		}
+		cycles, err := h.store.QueryAnthropicCycleHistory(quota, limit)
+		if err != nil {
+			return nil, nil, true, err
+		}
+		for _, c := range cycles {
+			add(c.CycleStart, c.CycleEnd)
+		}
+	case "codex":
+		if active, err := h.store.QueryActiveCodexCycle(accountID, quota); err != nil {
+			return nil, nil, true, err
+		} else if active != nil {
+			add(active.CycleStart, nil)
+		}
+		cycles, err := h.store.QueryCodexCycleHistory(accountID, quota, limit)
+		if err != nil {
+			return nil, nil, true, err
+		}
+		for _, c := range cycles {
+			add(c.CycleStart, c.CycleEnd)
+		}
+	case "copilot":
+		if active, err := h.store.QueryActiveCopilotCycle(quota); err != nil {
+			return nil, nil, true, err
+		} else if active != nil {
+			add(active.CycleStart, nil)
+		}
+		cycles, err := h.store.QueryCopilotCycleHistory(quota, limit)
+		if err != nil {
+			return nil, nil, true, err
+		}
+		for _, c := range cycles {
+			add(c.CycleStart, c.CycleEnd)
+		}
+	case "gemini":
+		if active, err := h.store.QueryActiveGeminiCycle(quota); err != nil {
+			return nil, nil, true, err
+		} else if active != nil {
+			add(active.CycleStart, nil)
+		}
+		cycles, err := h.store.QueryGeminiCycleHistory(quota, limit)
+		if err != nil {
+			return nil, nil, true, err
+		}
+		for _, c := range cycles {
+			add(c.CycleStart, c.CycleEnd)
+		}
+	case "openrouter":
+		if active, err := h.store.QueryActiveOpenRouterCycle(quota); err != nil {
+			return nil, nil, true, err
+		} else if active != nil {
+			add(active.CycleStart, nil)
+		}
+		cycles, err := h.store.QueryOpenRouterCycleHistory(quota, limit)
+		if err != nil {
+			return nil, nil, true, err
+		}
+		for _, c := range cycles {
+			add(c.CycleStart, c.CycleEnd)
+		}
+	case "cursor":
+		if active, err := h.store.QueryActiveCursorCycle(quota); err != nil {
+			return nil, nil, true, err
+		} else if active != nil {
+			add(active.CycleStart, nil)
+		}
+		cycles, err := h.store.QueryCursorCycleHistory(quota, limit)
+		if err != nil {
+			return nil, nil, true, err
+		}
+		for _, c := range cycles {
+			add(c.CycleStart, c.CycleEnd)
+		}
+	case "grok":
+		// Grok and Kimi list the active cycle together with closed ones.
+		cycles, err := h.store.QueryGrokCyclesForQuota(accountID, quota, limit+1)
+		if err != nil {
+			return nil, nil, true, err
+		}
+		for _, c := range cycles {
+			add(c.CycleStart, c.CycleEnd)
+		}
+	case "kimi":
+		cycles, err := h.store.QueryKimiCyclesForQuota(accountID, quota, limit+1)
+		if err != nil {
+			return nil, nil, true, err
+		}
+		for _, c := range cycles {
+			add(c.CycleStart, c.CycleEnd)
+		}
+	default:
+		return nil, nil, false, nil
+	}
+	return current, previous, true, nil
+}
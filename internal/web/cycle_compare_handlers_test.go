@@ -0,0 +1,171 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/api"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+func TestBuildCycleComparison_AlignsCurves(t *testing.T) {
+	t.Parallel()
+	base := time.Date(2026, 4, 6, 0, 0, 0, 0, time.UTC)
+	endA, endB := base.Add(10*time.Hour), base.Add(20*time.Hour)
+	samples := []store.QuotaSample{
+		// Previous cycle A: 10 points per hour for 10 hours.
+		{At: base, Percent: 0},
+		{At: base.Add(5 * time.Hour), Percent: 50},
+		{At: base.Add(9 * time.Hour), Percent: 90},
+		// Previous cycle B: 30 points in the first hour, then a drop that
+		// must not undo the accumulated usage.
+		{At: endA, Percent: 10},
+		{At: endA.Add(time.Hour), Percent: 40},
+		{At: endA.Add(2 * time.Hour), Percent: 35},
+		{At: endA.Add(5 * time.Hour), Percent: 45},
+		// Current cycle, 5 hours in.
+		{At: endB, Percent: 20},
+		{At: endB.Add(4 * time.Hour), Percent: 70},
+	}
+	current := &cycleSpan{Start: endB}
+	previous := []cycleSpan{{Start: endA, End: &endB}, {Start: base, End: &endA}}
+
+	res := buildCycleComparison(samples, current, previous, endB.Add(5*time.Hour))
+	if res.StepHours != 0.25 || res.Offsets[len(res.Offsets)-1] != 10 {
+		t.Fatalf("grid = step %v up to %v, want 0.25 up to 10", res.StepHours, res.Offsets[len(res.Offsets)-1])
+	}
+	if len(res.Previous) != 2 || res.Previous[1].Total != 90 || res.Previous[0].Total != 50 {
+		t.Errorf("previous totals = %+v", res.Previous)
+	}
+
+	at := func(values []*float64, hours float64) *float64 {
+		return values[int(hours/res.StepHours)]
+	}
+	// At 5 hours the previous cycles stood at 50 (A) and 50 (B).
+	if v := at(res.Percentiles.P50, 5); v == nil || *v != 50 {
+		t.Errorf("p50 at 5h = %v, want 50", v)
+	}
+	// At 1 hour: A carried 0 forward, B had reached 40.
+	if p25, p75 := at(res.Percentiles.P25, 1), at(res.Percentiles.P75, 1); p25 == nil || *p25 != 10 || *p75 != 30 {
+		t.Errorf("p25/p75 at 1h = %v/%v, want 10/30", p25, p75)
+	}
+	if v := at(res.Current.Values, 6); v != nil {
+		t.Errorf("current value past now = %v, want nil", *v)
+	}
+
+	s := res.Summary
+	if s == nil || s.OffsetHours != 5 || s.Current != 70 || s.Median != 50 || s.Delta != 20 || s.Cycles != 2 {
+		t.Errorf("summary = %+v, want 70 vs median 50 at 5h", s)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	t.Parallel()
+	values := []float64{40, 10, 30, 20}
+	for _, tc := range []struct{ p, want float64 }{{0, 10}, {25, 17.5}, {50, 25}, {100, 40}} {
+		if got := percentile(values, tc.p); got != tc.want {
+			t.Errorf("percentile(%v) = %v, want %v", tc.p, got, tc.want)
+		}
+	}
+}
+
+func TestHandler_CyclesCompare_Anthropic(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	now := time.Now().UTC()
+	insert := func(at time.Time, util float64) {
+		snap := &api.AnthropicSnapshot{
+			CapturedAt: at,
+			Quotas:     []api.AnthropicQuota{{Name: "five_hour", Utilization: util}},
+		}
+		if _, err := s.InsertAnthropicSnapshot(snap); err != nil {
+			t.Fatalf("InsertAnthropicSnapshot: %v", err)
+		}
+	}
+	for i := 3; i >= 1; i-- {
+		start := now.Add(-time.Duration(i*5+2) * time.Hour)
+		if _, err := s.CreateAnthropicCycle("five_hour", start, nil); err != nil {
+			t.Fatalf("CreateAnthropicCycle: %v", err)
+		}
+		insert(start, 0)
+		insert(start.Add(2*time.Hour), float64(10*i))
+		if err := s.CloseAnthropicCycle("five_hour", start.Add(5*time.Hour), float64(10*i), float64(10*i)); err != nil {
+			t.Fatalf("CloseAnthropicCycle: %v", err)
+		}
+	}
+	start := now.Add(-2 * time.Hour)
+	if _, err := s.CreateAnthropicCycle("five_hour", start, nil); err != nil {
+		t.Fatalf("CreateAnthropicCycle: %v", err)
+	}
+	insert(start, 5)
+	insert(start.Add(90*time.Minute), 45)
+
+	h := NewHandler(s, nil, nil, nil, createTestConfigWithAnthropic())
+	req := httptest.NewRequest(http.MethodGet, "/api/cycles/compare?provider=anthropic&cycles=2", nil)
+	rr := httptest.NewRecorder()
+	h.CyclesCompare(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var resp struct {
+		Quota      string               `json:"quota"`
+		Label      string               `json:"label"`
+		Quotas     []compareQuotaOption `json:"quotas"`
+		Current    *compareCycle        `json:"current"`
+		Previous   []compareCycle       `json:"previous"`
+		Comparison *compareSummary      `json:"comparison"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	if resp.Quota != "five_hour" || resp.Label != api.AnthropicDisplayName("five_hour") || len(resp.Quotas) != 1 {
+		t.Errorf("quota = %s (%s), quotas = %+v", resp.Quota, resp.Label, resp.Quotas)
+	}
+	if resp.Current == nil || resp.Current.Total != 45 {
+		t.Fatalf("current = %+v, want total 45", resp.Current)
+	}
+	// cycles=2 keeps the two most recent completed cycles (10 and 20 points).
+	if len(resp.Previous) != 2 || resp.Previous[0].Total != 10 || resp.Previous[1].Total != 20 {
+		t.Errorf("previous = %+v", resp.Previous)
+	}
+	if c := resp.Comparison; c == nil || c.Current != 45 || c.Median != 15 || c.Cycles != 2 {
+		t.Errorf("comparison = %+v, want 45 vs median 15", c)
+	}
+}
+
+func TestHandler_CyclesCompare_Errors(t *testing.T) {
+	t.Parallel()
+	s, _ := store.New(":memory:")
+	defer s.Close()
+
+	h := NewHandler(s, nil, nil, nil, createTestConfigWithAntigravity())
+	req := httptest.NewRequest(http.MethodGet, "/api/cycles/compare?provider=antigravity&quota=x", nil)
+	rr := httptest.NewRecorder()
+	h.CyclesCompare(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("unsupported provider: expected 400, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/cycles/compare", nil)
+	rr = httptest.NewRecorder()
+	h.CyclesCompare(rr, req)
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: expected 405, got %d", rr.Code)
+	}
+
+	if n := parseCompareCycles("50"); n != maxCompareCycles {
+		t.Errorf("parseCompareCycles(50) = %d, want %d", n, maxCompareCycles)
+	}
+	if n := parseCompareCycles(""); n != defaultCompareCycles {
+		t.Errorf("parseCompareCycles(\"\") = %d, want %d", n, defaultCompareCycles)
+	}
+}
@@ -46,6 +46,8 @@ func defaultProviderTabLabel(key string) string {
 		return "DeepSeek"
 	case "api-integrations":
 		return "API Integrations"
+	case "spend":
+		return "Spend"
 	case "both":
 		return "All"
 	default:
@@ -91,12 +93,12 @@ func normalizeDashboardProviderLabel(label string) (string, bool) {
 // isDashboardSpecialTab is true for composite/header-only tabs that should stay
 // after real providers when a saved order omits newly added providers.
 func isDashboardSpecialTab(key string) bool {
-	return key == "both" || key == "api-integrations"
+	return key == "both" || key == "api-integrations" || key == "spend"
 }
 
 // orderDashboardProviders reorders available keys using a saved preference list.
 // Unknown keys in order are dropped; available keys missing from order are inserted
-// before special tabs (api-integrations / both) so a new provider is never buried
+// before special tabs (api-integrations / spend / both) so a new provider is never buried
 // after the All tab.
 func orderDashboardProviders(available, preferred []string) []string {
 	if len(available) == 0 {
@@ -108,7 +110,7 @@ func orderDashboardProviders(available, preferred []string) []string {
 	}
 	seen := make(map[string]struct{}, len(available))
 	regular := make([]string, 0, len(available))
-	specials := make([]string, 0, 2)
+	specials := make([]string, 0, 3)
 
 	appendKey := func(k string) {
 		if _, ok := availSet[k]; !ok {
@@ -134,8 +136,8 @@ func orderDashboardProviders(available, preferred []string) []string {
 		appendKey(k)
 	}
 
-	// Stable special order: api-integrations then both, regardless of discovery order.
-	specialOrder := []string{"api-integrations", "both"}
+	// Stable special order: api-integrations, spend, then both, regardless of discovery order.
+	specialOrder := []string{"api-integrations", "spend", "both"}
 	orderedSpecials := make([]string, 0, len(specials))
 	specialSeen := map[string]struct{}{}
 	for _, k := range specialOrder {
@@ -311,6 +313,9 @@ func (h *Handler) buildDashboardProviderTabs() []string {
 		if toolsVisible {
 			providers = append(providers, "api-integrations")
 		}
+		if h.spendTabAvailable(toolsVisible) {
+			providers = append(providers, "spend")
+		}
 		if h.config.HasMultipleProviders() {
 			providers = append(providers, "both")
 		}
@@ -68,6 +68,9 @@ func (h *Handler) buildDeepSeekCurrent() map[string]interface{} {
 					if !summary.TrackingSince.IsZero() {
 						balance["trackingSince"] = summary.TrackingSince.Format(time.RFC3339)
 					}
+					if runway := runwayResponse(summary.Runway, h.runwayLowDays()); runway != nil {
+						balance["runway"] = runway
+					}
 				}
 			}
 
@@ -203,6 +206,7 @@ func (h *Handler) buildDeepSeekSummaryMap(currency string) map[string]interface{
 			"peakCycle":       0.0,
 			"totalTracked":    0.0,
 			"trackingSince":   nil,
+			"runway":          nil,
 		},
 	}
 
@@ -218,6 +222,7 @@ func (h *Handler) buildDeepSeekSummaryMap(currency string) map[string]interface{
 				"peakCycle":       summary.PeakCycle,
 				"totalTracked":    summary.TotalTracked,
 				"trackingSince":   nil,
+				"runway":          runwayResponse(summary.Runway, h.runwayLowDays()),
 			}
 			if !summary.TrackingSince.IsZero() {
 				response["balance"].(map[string]interface{})["trackingSince"] = summary.TrackingSince.Format(time.RFC3339)
@@ -304,6 +309,9 @@ func (h *Handler) buildDeepSeekInsights(currency string, hidden map[string]bool)
 					Label: "Spend Rate", Value: fmt.Sprintf("%s%.4f/hr", currencySymbol, summary.CurrentRate),
 				})
 			}
+			if !hidden["runway"] && summary.Runway != nil {
+				resp.Insights = append(resp.Insights, buildRunwayInsight("DeepSeek", summary.Runway, h.runwayLowDays(), currencySymbol))
+			}
 		}
 	}
 	
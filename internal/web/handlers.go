@@ -7,6 +7,7 @@ import (
 	"html/template"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -17,6 +18,7 @@ import (
 	"time"
 
 	"github.com/onllm-dev/onwatch/v2/internal/api"
+	apiintegrations "github.com/onllm-dev/onwatch/v2/internal/api_integrations"
 	"github.com/onllm-dev/onwatch/v2/internal/config"
 	"github.com/onllm-dev/onwatch/v2/internal/menubar"
 	"github.com/onllm-dev/onwatch/v2/internal/metrics"
@@ -50,6 +52,10 @@ type Notifier interface {
 	ConfigurePush() error
 	SendTestEmail() error
 	SendTestPush() error
+	SendTestDesktop() error
+	ConfigureTelegram() error
+	SendTestTelegram() error
+	SendReportNow(scheduleID string) error
 	TestSMTPDiag() (string, error)
 	SetEncryptionKey(key string)
 	GetVAPIDPublicKey() string
@@ -112,7 +118,17 @@ type Handler struct {
 	smtpTestLastSent   time.Time
 	pushTestMu         sync.Mutex
 	pushTestLastSent   time.Time
+	desktopTestMu      sync.Mutex
+	desktopTestLast    time.Time
+	telegramTestMu     sync.Mutex
+	telegramTestLast   time.Time
+	reportSendMu       sync.Mutex
+	reportSendLast     time.Time
 	rateLimiter        *LoginRateLimiter // Per-IP rate limiting for login attempts
+	otlpMetrics        *apiintegrations.OTLPMetricsConverter
+	pricingCatalog     *apiintegrations.PricingCatalog // nil means the built-in catalog
+	reestimateMu       sync.Mutex                      // serializes background cost re-estimates
+	reestimateWG       sync.WaitGroup
 }
 
 // DefaultCodexAccountID is the default account ID for single-account setups.
@@ -710,6 +726,7 @@ func NewHandler(store *store.Store, tracker *tracker.Tracker, logger *slog.Logge
 		sessions:      sessions,
 		config:        cfg,
 		metrics:       metrics.New(),
+		otlpMetrics:   apiintegrations.NewOTLPMetricsConverter(time.Now()),
 	}
 	if len(zaiTracker) > 0 && zaiTracker[0] != nil {
 		h.zaiTracker = zaiTracker[0]
@@ -888,6 +905,14 @@ func sanitizeSMTPError(err error) string {
 
 	// Classify errors by type
 	switch {
+	case strings.Contains(errStr, "invalid_grant"):
+		return "OAuth2 refresh token expired or revoked: generate a new refresh token"
+	case strings.Contains(errStr, "invalid_client") || strings.Contains(errStr, "unauthorized_client"):
+		return "OAuth2 client rejected: check client ID and client secret"
+	case strings.Contains(errStr, "xoauth2 rejected"):
+		return "XOAUTH2 login rejected: check the username and that the token allows SMTP sending"
+	case strings.Contains(errStr, "oauth2"):
+		return "OAuth2 token refresh failed: check the provider and credentials"
 	case strings.Contains(errStr, "select none to allow unencrypted smtp authentication") ||
 		strings.Contains(errStr, "server does not offer tls"):
 		return "Server requires plaintext SMTP auth. Choose None only if you trust the server and network."
@@ -3247,6 +3272,9 @@ func (h *Handler) buildOpenRouterCurrent() map[string]interface{} {
 					if !summary.TrackingSince.IsZero() {
 						credits["trackingSince"] = summary.TrackingSince.Format(time.RFC3339)
 					}
+					if runway := runwayResponse(summary.Runway, h.runwayLowDays()); runway != nil {
+						credits["runway"] = runway
+					}
 				}
 			}
 
@@ -3375,6 +3403,7 @@ func (h *Handler) buildOpenRouterSummaryMap() map[string]interface{} {
 			"peakCycle":       0.0,
 			"totalTracked":    0.0,
 			"trackingSince":   nil,
+			"runway":          nil,
 		},
 	}
 
@@ -3393,6 +3422,7 @@ func (h *Handler) buildOpenRouterSummaryMap() map[string]interface{} {
 				"totalTracked":    summary.TotalTracked,
 				"trackingSince":   nil,
 				"isFreeTier":      summary.IsFreeTier,
+				"runway":          runwayResponse(summary.Runway, h.runwayLowDays()),
 			}
 			if !summary.TrackingSince.IsZero() {
 				response["credits"].(map[string]interface{})["trackingSince"] = summary.TrackingSince.Format(time.RFC3339)
@@ -3485,6 +3515,9 @@ func (h *Handler) buildOpenRouterInsights(hidden map[string]bool) insightsRespon
 					Label: "Usage Rate", Value: fmt.Sprintf("$%.4f/hr", summary.CurrentRate), Sublabel: "current rate",
 				})
 			}
+			if !hidden["runway"] && summary.Runway != nil {
+				resp.Insights = append(resp.Insights, buildRunwayInsight("OpenRouter", summary.Runway, h.runwayLowDays(), "$"))
+			}
 		}
 	}
 
@@ -5628,6 +5661,9 @@ func (h *Handler) buildAnthropicCurrent() map[string]interface{} {
 			if summary, err := h.anthropicTracker.UsageSummary(q.Name); err == nil && summary != nil {
 				qMap["currentRate"] = summary.CurrentRate
 				qMap["projectedUtil"] = summary.ProjectedUtil
+				if pacing := pacingResponse(summary.Pacing); pacing != nil {
+					qMap["pacing"] = pacing
+				}
 			}
 		}
 		quotas = append(quotas, qMap)
@@ -5680,6 +5716,9 @@ func (h *Handler) buildAnthropicCurrentFallback(response map[string]interface{})
 			if summary, err := h.anthropicTracker.UsageSummary(q.Name); err == nil && summary != nil {
 				qMap["currentRate"] = summary.CurrentRate
 				qMap["projectedUtil"] = summary.ProjectedUtil
+				if pacing := pacingResponse(summary.Pacing); pacing != nil {
+					qMap["pacing"] = pacing
+				}
 			}
 		}
 		quotas = append(quotas, qMap)
@@ -5896,6 +5935,9 @@ func buildAnthropicSummaryResponse(summary *tracker.AnthropicSummary) map[string
 		result["resetsAt"] = summary.ResetsAt.Format(time.RFC3339)
 		result["timeUntilReset"] = formatDuration(summary.TimeUntilReset)
 	}
+	if summary.Pacing != nil {
+		result["pacing"] = pacingResponse(summary.Pacing)
+	}
 	if !summary.TrackingSince.IsZero() {
 		result["trackingSince"] = summary.TrackingSince.Format(time.RFC3339)
 	}
@@ -6055,6 +6097,15 @@ func (h *Handler) buildAnthropicInsights(hidden map[string]bool, rangeDur time.D
 		resp.Insights = append(resp.Insights, item)
 	}
 
+	// Pacing against the budget line for weekly and monthly windows
+	for _, q := range latest.Quotas {
+		s := summaries[q.Name]
+		if s == nil || s.Pacing == nil || hidden["pacing_"+q.Name] {
+			continue
+		}
+		resp.Insights = append(resp.Insights, buildPacingInsight(q.Name, api.AnthropicDisplayName(q.Name), s.Pacing, 0))
+	}
+
 	// 2. Variance (per quota, ≥3 real billing periods)
 	for _, name := range quotaNames {
 		count := quotaBillingCount[name]
@@ -6480,10 +6531,37 @@ func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
 					smtp["password"] = ""
 					smtp["password_set"] = pwd != ""
 				}
+				// Same for the OAuth2 secrets
+				for _, field := range []string{"oauth_client_secret", "oauth_refresh_token"} {
+					secret, _ := smtp[field].(string)
+					smtp[field] = ""
+					smtp[field+"_set"] = secret != ""
+				}
 				result["smtp"] = smtp
 			}
 		}
 
+		// Telegram settings (never return the actual bot token)
+		telegramJSON, _ := h.store.GetSetting("telegram")
+		if telegramJSON != "" {
+			var telegram map[string]interface{}
+			if json.Unmarshal([]byte(telegramJSON), &telegram) == nil {
+				token, _ := telegram["bot_token"].(string)
+				telegram["bot_token"] = ""
+				telegram["bot_token_set"] = token != ""
+				result["telegram"] = telegram
+			}
+		}
+
+		// Scheduled report settings
+		reportsJSON, _ := h.store.GetSetting("reports")
+		if reportsJSON != "" {
+			var reports map[string]interface{}
+			if json.Unmarshal([]byte(reportsJSON), &reports) == nil {
+				result["reports"] = reports
+			}
+		}
+
 		// Notification settings
 		notifJSON, _ := h.store.GetSetting("notifications")
 		if notifJSON != "" {
@@ -6509,6 +6587,14 @@ func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
 			result["dashboard_providers_order"] = []string{}
 		}
 		result["dashboard_provider_labels"] = h.loadDashboardProviderLabels()
+		result["subscription_costs"] = h.loadSubscriptionCosts()
+		result["fx_rates"] = h.loadFXRates()
+		result["budgets"] = h.loadBudgets()
+		result["api_integration_providers"] = h.loadAPIIntegrationProviders()
+		result["api_integration_dimensions"] = h.loadAPIIntegrationDimensions()
+		result["api_integration_reliability"] = h.loadAPIIntegrationReliability()
+		result["api_integrations_ingest_token_set"] = h.apiIntegrationIngestTokenSet()
+		result["runway_floors"] = h.loadRunwayFloors()
 
 		toolsVisJSON, _ := h.store.GetSetting("api_integrations_visibility")
 		if toolsVisJSON != "" {
@@ -6634,6 +6720,14 @@ func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 			FromAddress string `json:"from_address"`
 			FromName    string `json:"from_name"`
 			To          string `json:"to"`
+
+			AuthMethod        string `json:"auth_method,omitempty"`
+			OAuthProvider     string `json:"oauth_provider,omitempty"`
+			OAuthTokenURL     string `json:"oauth_token_url,omitempty"`
+			OAuthTenant       string `json:"oauth_tenant,omitempty"`
+			OAuthClientID     string `json:"oauth_client_id,omitempty"`
+			OAuthClientSecret string `json:"oauth_client_secret,omitempty"`
+			OAuthRefreshToken string `json:"oauth_refresh_token,omitempty"`
 		}
 		if err := json.Unmarshal(raw, &smtp); err != nil {
 			respondError(w, http.StatusBadRequest, "invalid smtp value")
@@ -6663,18 +6757,58 @@ func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		// If password is empty, preserve the existing password
-		if smtp.Password == "" {
+		switch smtp.AuthMethod {
+		case "", notify.SMTPAuthPassword, notify.SMTPAuthOAuth2:
+		default:
+			respondError(w, http.StatusBadRequest, "SMTP auth method must be password or oauth2")
+			return
+		}
+		if smtp.AuthMethod == notify.SMTPAuthOAuth2 {
+			switch smtp.OAuthProvider {
+			case "google", "microsoft", "custom":
+			default:
+				respondError(w, http.StatusBadRequest, "OAuth2 provider must be google, microsoft, or custom")
+				return
+			}
+			if smtp.OAuthProvider == "custom" && smtp.OAuthTokenURL == "" {
+				respondError(w, http.StatusBadRequest, "OAuth2 token URL is required for a custom provider")
+				return
+			}
+			if smtp.OAuthTokenURL != "" {
+				u, err := url.Parse(smtp.OAuthTokenURL)
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					respondError(w, http.StatusBadRequest, "OAuth2 token URL must be an http(s) URL")
+					return
+				}
+			}
+			if smtp.Username == "" || smtp.OAuthClientID == "" {
+				respondError(w, http.StatusBadRequest, "OAuth2 requires a username and client ID")
+				return
+			}
+		}
+
+		// If password or OAuth2 secrets are empty, preserve the existing ones
+		if smtp.Password == "" || smtp.OAuthClientSecret == "" || smtp.OAuthRefreshToken == "" {
 			existingJSON, _ := h.store.GetSetting("smtp")
 			if existingJSON != "" {
 				var existing map[string]interface{}
 				if json.Unmarshal([]byte(existingJSON), &existing) == nil {
-					if pwd, ok := existing["password"].(string); ok {
+					if pwd, ok := existing["password"].(string); ok && smtp.Password == "" {
 						smtp.Password = pwd
 					}
+					if secret, ok := existing["oauth_client_secret"].(string); ok && smtp.OAuthClientSecret == "" {
+						smtp.OAuthClientSecret = secret
+					}
+					if token, ok := existing["oauth_refresh_token"].(string); ok && smtp.OAuthRefreshToken == "" {
+						smtp.OAuthRefreshToken = token
+					}
 				}
 			}
 		}
+		if smtp.AuthMethod == notify.SMTPAuthOAuth2 && smtp.OAuthRefreshToken == "" {
+			respondError(w, http.StatusBadRequest, "OAuth2 requires a refresh token")
+			return
+		}
 
 		// Encrypt SMTP password using admin password hash as key
 		if smtp.Password != "" && !IsEncryptedValue(smtp.Password) {
@@ -6688,6 +6822,20 @@ func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 			smtp.Password = encryptedPass
 		}
 
+		// Encrypt OAuth2 secrets the same way
+		for _, secret := range []*string{&smtp.OAuthClientSecret, &smtp.OAuthRefreshToken} {
+			if *secret == "" || IsEncryptedValue(*secret) {
+				continue
+			}
+			encrypted, err := notify.EncryptForStorage(*secret, DeriveEncryptionKey(h.sessions.passwordHash, nil))
+			if err != nil {
+				h.logger.Error("failed to encrypt SMTP OAuth2 secret", "error", err)
+				respondError(w, http.StatusInternalServerError, "failed to encrypt SMTP OAuth2 secret")
+				return
+			}
+			*secret = encrypted
+		}
+
 		smtpJSON, _ := json.Marshal(smtp)
 		if err := h.store.SetSetting("smtp", string(smtpJSON)); err != nil {
 			h.logger.Error("failed to save SMTP settings", "error", err)
@@ -6704,16 +6852,129 @@ func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Handle Telegram settings
+	if raw, ok := body["telegram"]; ok {
+		var telegram struct {
+			BotToken string `json:"bot_token"`
+			ChatIDs  string `json:"chat_ids"`
+			APIURL   string `json:"api_url"`
+		}
+		if err := json.Unmarshal(raw, &telegram); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid telegram value")
+			return
+		}
+		telegram.BotToken = strings.TrimSpace(telegram.BotToken)
+		telegram.APIURL = strings.TrimRight(strings.TrimSpace(telegram.APIURL), "/")
+		if _, err := notify.ParseTelegramChatIDs(telegram.ChatIDs); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if telegram.APIURL != "" {
+			u, err := url.Parse(telegram.APIURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				respondError(w, http.StatusBadRequest, "telegram API URL must be an http(s) URL")
+				return
+			}
+		}
+		if strings.ContainsAny(telegram.BotToken, "/?# ") {
+			respondError(w, http.StatusBadRequest, "invalid telegram bot token")
+			return
+		}
+
+		// If bot token is empty, preserve the existing one
+		if telegram.BotToken == "" {
+			existingJSON, _ := h.store.GetSetting("telegram")
+			if existingJSON != "" {
+				var existing map[string]interface{}
+				if json.Unmarshal([]byte(existingJSON), &existing) == nil {
+					if token, ok := existing["bot_token"].(string); ok {
+						telegram.BotToken = token
+					}
+				}
+			}
+		}
+
+		// Encrypt bot token using admin password hash as key
+		if telegram.BotToken != "" && !IsEncryptedValue(telegram.BotToken) {
+			encryptionKey := DeriveEncryptionKey(h.sessions.passwordHash, nil)
+			encryptedToken, err := notify.EncryptForStorage(telegram.BotToken, encryptionKey)
+			if err != nil {
+				h.logger.Error("failed to encrypt Telegram bot token", "error", err)
+				respondError(w, http.StatusInternalServerError, "failed to encrypt Telegram bot token")
+				return
+			}
+			telegram.BotToken = encryptedToken
+		}
+
+		telegramJSON, _ := json.Marshal(telegram)
+		if err := h.store.SetSetting("telegram", string(telegramJSON)); err != nil {
+			h.logger.Error("failed to save Telegram settings", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save Telegram settings")
+			return
+		}
+		result["telegram"] = "saved"
+
+		// Restart the bot with new settings
+		if h.notifier != nil {
+			if err := h.notifier.ConfigureTelegram(); err != nil {
+				h.logger.Error("failed to reconfigure Telegram after settings update", "error", err)
+			}
+		}
+	}
+
+	// Handle scheduled report settings
+	if raw, ok := body["reports"]; ok {
+		var reports notify.ReportSettings
+		if err := json.Unmarshal(raw, &reports); err != nil {
+			respondError(w, http.StatusBadRequest, "invalid reports value")
+			return
+		}
+		seen := make(map[string]bool, len(reports.Schedules))
+		for i := range reports.Schedules {
+			sched := &reports.Schedules[i]
+			sched.ID = strings.TrimSpace(sched.ID)
+			sched.Name = strings.TrimSpace(sched.Name)
+			sched.Recipients = strings.TrimSpace(sched.Recipients)
+			if err := sched.Validate(); err != nil {
+				respondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			if seen[sched.ID] {
+				respondError(w, http.StatusBadRequest, "duplicate report schedule id")
+				return
+			}
+			seen[sched.ID] = true
+		}
+		if reports.Schedules == nil {
+			reports.Schedules = []notify.ReportSchedule{}
+		}
+
+		reportsJSON, _ := json.Marshal(reports)
+		if err := h.store.SetSetting("reports", string(reportsJSON)); err != nil {
+			h.logger.Error("failed to save report settings", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save report settings")
+			return
+		}
+		result["reports"] = "saved"
+	}
+
 	// Handle notification settings
 	if raw, ok := body["notifications"]; ok {
 		var notif struct {
-			WarningThreshold  float64 `json:"warning_threshold"`
-			CriticalThreshold float64 `json:"critical_threshold"`
-			NotifyWarning     bool    `json:"notify_warning"`
-			NotifyCritical    bool    `json:"notify_critical"`
-			NotifyReset       bool    `json:"notify_reset"`
-			NotifyAuthError   bool    `json:"notify_auth_error"`
-			CooldownMinutes   int     `json:"cooldown_minutes"`
+			WarningThreshold  float64         `json:"warning_threshold"`
+			CriticalThreshold float64         `json:"critical_threshold"`
+			NotifyWarning     bool            `json:"notify_warning"`
+			NotifyCritical    bool            `json:"notify_critical"`
+			NotifyReset       bool            `json:"notify_reset"`
+			NotifyAuthError   bool            `json:"notify_auth_error"`
+			NotifyPacing      bool            `json:"notify_pacing"`
+			PaceThreshold     float64         `json:"pace_threshold,omitempty"`
+			NotifyAnomaly     bool            `json:"notify_anomaly"`
+			AnomalyFactor     float64         `json:"anomaly_factor,omitempty"`
+			NotifyRunway      bool            `json:"notify_runway"`
+			RunwayDays        float64         `json:"runway_days,omitempty"`
+			CooldownMinutes   int             `json:"cooldown_minutes"`
+			Channels          json.RawMessage `json:"channels,omitempty"`
 			Overrides         []struct {
 				QuotaKey       string  `json:"quota_key"`
 				Provider       string  `json:"provider"`
@@ -6742,9 +7003,22 @@ func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 			respondError(w, http.StatusBadRequest, "warning threshold must be less than critical threshold")
 			return
 		}
+		if notif.PaceThreshold < 0 || notif.PaceThreshold > 100 {
+			respondError(w, http.StatusBadRequest, "pace threshold must be between 0 and 100")
+			return
+		}
+		if notif.AnomalyFactor != 0 && (notif.AnomalyFactor < 1.5 || notif.AnomalyFactor > 100) {
+			respondError(w, http.StatusBadRequest, "anomaly factor must be between 1.5 and 100")
+			return
+		}
+		if notif.RunwayDays != 0 && (notif.RunwayDays < 1 || notif.RunwayDays > 90) {
+			respondError(w, http.StatusBadRequest, "runway days must be between 1 and 90")
+			return
+		}
 		if notif.CooldownMinutes < 1 {
 			notif.CooldownMinutes = 1
 		}
+		notif.Channels = normalizeNotificationChannels(notif.Channels)
 		// Validate per-quota overrides
 		for _, o := range notif.Overrides {
 			if o.IsAbsolute {
@@ -6834,6 +7108,118 @@ func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
 		result["dashboard_provider_labels"] = h.loadDashboardProviderLabels()
 	}
 
+	// Subscription prices (Settings → Providers → Subscription Costs)
+	if raw, ok := body["subscription_costs"]; ok {
+		costs, err := parseSubscriptionCosts(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		costsJSON, _ := json.Marshal(costs)
+		if err := h.store.SetSetting(settingSubscriptionCosts, string(costsJSON)); err != nil {
+			h.logger.Error("failed to save subscription costs", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save subscription costs")
+			return
+		}
+		result["subscription_costs"] = costs
+	}
+
+	if raw, ok := body["fx_rates"]; ok {
+		rates, err := parseFXRates(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		ratesJSON, _ := json.Marshal(rates)
+		if err := h.store.SetSetting(settingFXRates, string(ratesJSON)); err != nil {
+			h.logger.Error("failed to save fx rates", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save fx rates")
+			return
+		}
+		result["fx_rates"] = h.loadFXRates()
+	}
+
+	if raw, ok := body["budgets"]; ok {
+		budgets, err := parseBudgets(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		budgetsJSON, _ := json.Marshal(budgets)
+		if err := h.store.SetSetting(settingBudgets, string(budgetsJSON)); err != nil {
+			h.logger.Error("failed to save budgets", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save budgets")
+			return
+		}
+		result["budgets"] = budgets
+	}
+
+	if raw, ok := body["api_integration_providers"]; ok {
+		providers, err := parseAPIIntegrationProviders(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		providersJSON, _ := json.Marshal(providers)
+		if err := h.store.SetSetting(settingAPIIntegrationProviders, string(providersJSON)); err != nil {
+			h.logger.Error("failed to save API integration providers", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save API integration providers")
+			return
+		}
+		result["api_integration_providers"] = providers
+		h.applyAPIIntegrationPricing()
+	}
+
+	if raw, ok := body["api_integration_dimensions"]; ok {
+		dimensions, err := parseAPIIntegrationDimensions(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := h.store.SyncAPIIntegrationDimensions(dimensions); err != nil {
+			h.logger.Error("failed to update API integration dimensions", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to update API integration dimensions")
+			return
+		}
+		dimensionsJSON, _ := json.Marshal(dimensions)
+		if err := h.store.SetSetting(settingAPIIntegrationDimensions, string(dimensionsJSON)); err != nil {
+			h.logger.Error("failed to save API integration dimensions", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save API integration dimensions")
+			return
+		}
+		result["api_integration_dimensions"] = dimensions
+	}
+
+	if raw, ok := body["api_integration_reliability"]; ok {
+		reliability, err := parseAPIIntegrationReliability(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		reliabilityJSON, _ := json.Marshal(reliability)
+		if err := h.store.SetSetting(settingAPIIntegrationReliability, string(reliabilityJSON)); err != nil {
+			h.logger.Error("failed to save API integration reliability thresholds", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save API integration reliability thresholds")
+			return
+		}
+		result["api_integration_reliability"] = reliability
+	}
+
+	if raw, ok := body["runway_floors"]; ok {
+		floors, err := parseRunwayFloors(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		floorsJSON, _ := json.Marshal(floors)
+		if err := h.store.SetSetting(store.SettingRunwayFloors, string(floorsJSON)); err != nil {
+			h.logger.Error("failed to save runway floors", "error", err)
+			respondError(w, http.StatusInternalServerError, "failed to save runway floors")
+			return
+		}
+		result["runway_floors"] = floors
+	}
+
 	if raw, ok := body["api_integrations_visibility"]; ok {
 		var vis map[string]bool
 		if err := json.Unmarshal(raw, &vis); err != nil {
@@ -6961,6 +7347,45 @@ func (h *Handler) SMTPTest(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// TelegramTest sends a test message to the allowlisted Telegram chats.
+func (h *Handler) TelegramTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Rate limit: 30 second cooldown
+	h.telegramTestMu.Lock()
+	elapsed := time.Since(h.telegramTestLast)
+	if elapsed < 30*time.Second {
+		h.telegramTestMu.Unlock()
+		remaining := int((30*time.Second - elapsed).Seconds())
+		respondError(w, http.StatusTooManyRequests, fmt.Sprintf("please wait %d seconds before sending another test", remaining))
+		return
+	}
+	h.telegramTestLast = time.Now()
+	h.telegramTestMu.Unlock()
+
+	if h.notifier == nil {
+		respondError(w, http.StatusServiceUnavailable, "notification engine not configured")
+		return
+	}
+
+	if err := h.notifier.SendTestTelegram(); err != nil {
+		h.logger.Error("Telegram test failed", "error", err)
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": false,
+			"message": "Telegram test failed - check the bot token and chat IDs",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Test message sent to Telegram",
+	})
+}
+
 // PushVAPIDKey returns the VAPID public key for push subscription.
 func (h *Handler) PushVAPIDKey(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -7044,6 +7469,36 @@ func (h *Handler) PushSubscribe(w http.ResponseWriter, r *http.Request) {
 	respondError(w, http.StatusMethodNotAllowed, "method not allowed")
 }
 
+// normalizeNotificationChannels canonicalizes the delivery channel selection.
+// Accepts the object form ({"email":true,...}) and the list form (["email","push"]);
+// anything else is dropped so the notifier falls back to its default channels.
+func normalizeNotificationChannels(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return nil
+	}
+	var channels notify.NotificationChannels
+	if err := json.Unmarshal(raw, &channels); err != nil {
+		var names []string
+		if json.Unmarshal(raw, &names) != nil {
+			return nil
+		}
+		for _, name := range names {
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "email":
+				channels.Email = true
+			case "push":
+				channels.Push = true
+			case "desktop":
+				channels.Desktop = true
+			case "telegram":
+				channels.Telegram = true
+			}
+		}
+	}
+	out, _ := json.Marshal(channels)
+	return out
+}
+
 // PushTest sends a test push notification to all subscribed devices.
 func (h *Handler) PushTest(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -7084,6 +7539,45 @@ func (h *Handler) PushTest(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// DesktopTest shows a test notification on the local desktop via D-Bus.
+func (h *Handler) DesktopTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Rate limit: 30 second cooldown
+	h.desktopTestMu.Lock()
+	elapsed := time.Since(h.desktopTestLast)
+	if elapsed < 30*time.Second {
+		h.desktopTestMu.Unlock()
+		remaining := int((30*time.Second - elapsed).Seconds())
+		respondError(w, http.StatusTooManyRequests, fmt.Sprintf("please wait %d seconds before sending another test", remaining))
+		return
+	}
+	h.desktopTestLast = time.Now()
+	h.desktopTestMu.Unlock()
+
+	if h.notifier == nil {
+		respondError(w, http.StatusServiceUnavailable, "notification engine not configured")
+		return
+	}
+
+	if err := h.notifier.SendTestDesktop(); err != nil {
+		h.logger.Error("desktop notification test failed", "error", err)
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": false,
+			"message": "Desktop notification failed - is onWatch running inside a desktop session?",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Test desktop notification sent",
+	})
+}
+
 // Login handles GET (show form) and POST (authenticate).
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	// If already logged in, redirect to dashboard
@@ -7626,7 +8120,7 @@ func (h *Handler) cycleOverviewBoth(w http.ResponseWriter, r *http.Request) {
 			"cycles":     orCycles,
 		}
 	}
-	
+
 	if h.config.HasProvider("moonshot") {
 		quotaType := "balance"
 		var msCycles []map[string]interface{}
@@ -7774,6 +8268,9 @@ func (h *Handler) buildCopilotCurrent() map[string]interface{} {
 			if summary, err := h.copilotTracker.UsageSummary(q.Name); err == nil && summary != nil {
 				qMap["currentRate"] = summary.CurrentRate
 				qMap["projectedUsage"] = summary.ProjectedUsage
+				if pacing := pacingResponse(summary.Pacing); pacing != nil {
+					qMap["pacing"] = pacing
+				}
 			}
 		}
 		quotas = append(quotas, qMap)
@@ -7961,6 +8458,9 @@ func buildCopilotSummaryResponse(summary *tracker.CopilotSummary) map[string]int
 		result["resetDate"] = summary.ResetDate.Format(time.RFC3339)
 		result["timeUntilReset"] = formatDuration(summary.TimeUntilReset)
 	}
+	if summary.Pacing != nil {
+		result["pacing"] = pacingResponse(summary.Pacing)
+	}
 	if !summary.TrackingSince.IsZero() {
 		result["trackingSince"] = summary.TrackingSince.Format(time.RFC3339)
 	}
@@ -8051,6 +8551,15 @@ func (h *Handler) buildCopilotInsights(hidden map[string]bool, rangeDur time.Dur
 		}
 	}
 
+	// Pacing against the monthly budget line
+	for _, q := range latest.Quotas {
+		s := summaries[q.Name]
+		if s == nil || s.Pacing == nil || hidden["pacing_"+q.Name] {
+			continue
+		}
+		resp.Insights = append(resp.Insights, buildPacingInsight(q.Name, api.CopilotDisplayName(q.Name), s.Pacing, float64(q.Entitlement)/100))
+	}
+
 	// 2. Reset countdown
 	if !hidden["reset_countdown"] && latest.ResetDate != nil {
 		timeLeft := time.Until(*latest.ResetDate)
@@ -8342,6 +8851,9 @@ func (h *Handler) buildCodexCurrent(accountID int64) map[string]interface{} {
 			if summary, err := h.codexTracker.UsageSummary(accountID, q.Name); err == nil && summary != nil {
 				qMap["currentRate"] = summary.CurrentRate
 				qMap["projectedUtil"] = summary.ProjectedUtil
+				if pacing := pacingResponse(summary.Pacing); pacing != nil {
+					qMap["pacing"] = pacing
+				}
 			}
 		}
 		if idx, exists := quotaIndexByName[normalizedName]; exists {
@@ -10497,6 +11009,9 @@ func buildCodexSummaryResponse(summary *tracker.CodexSummary) map[string]interfa
 		result["resetsAt"] = summary.ResetsAt.Format(time.RFC3339)
 		result["timeUntilReset"] = formatDuration(summary.TimeUntilReset)
 	}
+	if summary.Pacing != nil {
+		result["pacing"] = pacingResponse(summary.Pacing)
+	}
 	if !summary.TrackingSince.IsZero() {
 		result["trackingSince"] = summary.TrackingSince.Format(time.RFC3339)
 	}
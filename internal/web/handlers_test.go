@@ -13,6 +13,7 @@ import (
 
 	"github.com/onllm-dev/onwatch/v2/internal/api"
 	"github.com/onllm-dev/onwatch/v2/internal/config"
+	"github.com/onllm-dev/onwatch/v2/internal/notify"
 	"github.com/onllm-dev/onwatch/v2/internal/store"
 	"github.com/onllm-dev/onwatch/v2/internal/tracker"
 )
@@ -3856,6 +3857,53 @@ func TestHandler_UpdateSettings_Notifications(t *testing.T) {
 	}
 }
 
+func TestHandler_UpdateSettings_Notifications_PersistsChannels(t *testing.T) {
+	t.Parallel()
+	s, _ := store.New(":memory:")
+	defer s.Close()
+
+	cfg := createTestConfigWithSynthetic()
+	h := NewHandler(s, nil, nil, nil, cfg)
+
+	body := strings.NewReader(`{"notifications":{"warning_threshold":60,"critical_threshold":85,"notify_warning":true,"cooldown_minutes":15,"channels":{"email":false,"push":true,"desktop":true,"telegram":true}}}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/settings", body)
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	h.UpdateSettings(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+
+	val, _ := s.GetSetting("notifications")
+	if !strings.Contains(val, `"channels":{"email":false,"push":true,"desktop":true,"telegram":true}`) {
+		t.Errorf("expected delivery channels to be saved, got %s", val)
+	}
+}
+
+func TestNormalizeNotificationChannels(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"object", `{"email":true,"push":false,"desktop":true}`, `{"email":true,"push":false,"desktop":true,"telegram":false}`},
+		{"list", `["push","desktop","telegram"]`, `{"email":false,"push":true,"desktop":true,"telegram":true}`},
+		{"empty", ``, ``},
+		{"invalid", `"email"`, ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(normalizeNotificationChannels(json.RawMessage(tt.raw)))
+			if got != tt.want {
+				t.Errorf("normalizeNotificationChannels(%s) = %s, want %s", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestHandler_UpdateSettings_Notifications_InvalidThresholds(t *testing.T) {
 	t.Parallel()
 	s, _ := store.New(":memory:")
@@ -3877,6 +3925,34 @@ func TestHandler_UpdateSettings_Notifications_InvalidThresholds(t *testing.T) {
 	}
 }
 
+func TestHandler_UpdateSettings_Notifications_AnomalyFactor(t *testing.T) {
+	t.Parallel()
+	s, _ := store.New(":memory:")
+	defer s.Close()
+
+	h := NewHandler(s, nil, nil, nil, createTestConfigWithSynthetic())
+
+	body := strings.NewReader(`{"notifications":{"warning_threshold":80,"critical_threshold":95,"notify_anomaly":true,"anomaly_factor":1.2}}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/settings", body)
+	rr := httptest.NewRecorder()
+	h.UpdateSettings(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for anomaly factor 1.2, got %d", rr.Code)
+	}
+
+	body = strings.NewReader(`{"notifications":{"warning_threshold":80,"critical_threshold":95,"notify_anomaly":true,"anomaly_factor":4}}`)
+	req = httptest.NewRequest(http.MethodPut, "/api/settings", body)
+	rr = httptest.NewRecorder()
+	h.UpdateSettings(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	stored, _ := s.GetSetting("notifications")
+	if !strings.Contains(stored, `"notify_anomaly":true`) || !strings.Contains(stored, `"anomaly_factor":4`) {
+		t.Errorf("stored notifications = %s", stored)
+	}
+}
+
 func TestHandler_UpdateSettings_MethodNotAllowed(t *testing.T) {
 	t.Parallel()
 	cfg := createTestConfigWithSynthetic()
@@ -3901,6 +3977,7 @@ func TestHandler_UpdateSettings_MethodNotAllowed(t *testing.T) {
 type mockNotifier struct {
 	sendTestErr  error
 	reloadCalled bool
+	reportID     string
 }
 
 func (m *mockNotifier) Reload() error                 { m.reloadCalled = true; return nil }
@@ -3908,6 +3985,10 @@ func (m *mockNotifier) ConfigureSMTP() error          { return nil }
 func (m *mockNotifier) ConfigurePush() error          { return nil }
 func (m *mockNotifier) SendTestEmail() error          { return m.sendTestErr }
 func (m *mockNotifier) SendTestPush() error           { return nil }
+func (m *mockNotifier) SendTestDesktop() error        { return nil }
+func (m *mockNotifier) ConfigureTelegram() error      { return nil }
+func (m *mockNotifier) SendTestTelegram() error       { return m.sendTestErr }
+func (m *mockNotifier) SendReportNow(id string) error { m.reportID = id; return m.sendTestErr }
 func (m *mockNotifier) TestSMTPDiag() (string, error) { return "", m.sendTestErr }
 func (m *mockNotifier) SetEncryptionKey(_ string)     {}
 func (m *mockNotifier) GetVAPIDPublicKey() string     { return "" }
@@ -5812,6 +5893,10 @@ func TestSanitizeSMTPError(t *testing.T) {
 		{"tls error", fmt.Errorf("TLS handshake failure"), "TLS error: try STARTTLS on port 587 or SSL/TLS on port 465"},
 		{"certificate error", fmt.Errorf("x509: certificate has expired"), "TLS error: try STARTTLS on port 587 or SSL/TLS on port 465"},
 		{"unknown error", fmt.Errorf("something unexpected happened"), "SMTP test failed"},
+		{"oauth revoked token", fmt.Errorf("notify.TestConnection: auth: oauth2: token refresh failed (HTTP 400): invalid_grant"), "OAuth2 refresh token expired or revoked: generate a new refresh token"},
+		{"oauth bad client", fmt.Errorf("oauth2: token refresh failed (HTTP 401): invalid_client"), "OAuth2 client rejected: check client ID and client secret"},
+		{"oauth endpoint unreachable", fmt.Errorf("oauth2: token endpoint unreachable: connection refused"), "OAuth2 token refresh failed: check the provider and credentials"},
+		{"xoauth2 rejected", fmt.Errorf("notify.Send: auth: XOAUTH2 rejected: status 401"), "XOAUTH2 login rejected: check the username and that the token allows SMTP sending"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -6271,10 +6356,11 @@ func TestBuildZaiTrackerSummaryResponse_WithoutRenewsAt(t *testing.T) {
 // ═══════════════════════════════════════════════════════════════════
 
 type mockNotifierWithVAPID struct {
-	sendTestErr  error
-	sendPushErr  error
-	reloadCalled bool
-	vapidKey     string
+	sendTestErr    error
+	sendPushErr    error
+	sendDesktopErr error
+	reloadCalled   bool
+	vapidKey       string
 }
 
 func (m *mockNotifierWithVAPID) Reload() error                 { m.reloadCalled = true; return nil }
@@ -6282,6 +6368,10 @@ func (m *mockNotifierWithVAPID) ConfigureSMTP() error          { return nil }
 func (m *mockNotifierWithVAPID) ConfigurePush() error          { return nil }
 func (m *mockNotifierWithVAPID) SendTestEmail() error          { return m.sendTestErr }
 func (m *mockNotifierWithVAPID) SendTestPush() error           { return m.sendPushErr }
+func (m *mockNotifierWithVAPID) SendTestDesktop() error        { return m.sendDesktopErr }
+func (m *mockNotifierWithVAPID) ConfigureTelegram() error      { return nil }
+func (m *mockNotifierWithVAPID) SendTestTelegram() error       { return m.sendTestErr }
+func (m *mockNotifierWithVAPID) SendReportNow(_ string) error  { return m.sendTestErr }
 func (m *mockNotifierWithVAPID) TestSMTPDiag() (string, error) { return "", m.sendTestErr }
 func (m *mockNotifierWithVAPID) SetEncryptionKey(_ string)     {}
 func (m *mockNotifierWithVAPID) GetVAPIDPublicKey() string     { return m.vapidKey }
@@ -6575,6 +6665,273 @@ func TestHandler_PushTest_SendFailure(t *testing.T) {
 	}
 }
 
+func TestHandler_DesktopTest_Success(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(nil, nil, nil, nil, createTestConfigWithSynthetic())
+	h.SetNotifier(&mockNotifierWithVAPID{vapidKey: "key"})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/desktop/test", nil)
+	rr := httptest.NewRecorder()
+	h.DesktopTest(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", rr.Code)
+	}
+
+	var response map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+
+	if response["success"] != true {
+		t.Errorf("expected success true, got %v", response["success"])
+	}
+}
+
+func TestHandler_DesktopTest_MethodNotAllowed(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(nil, nil, nil, nil, createTestConfigWithSynthetic())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/desktop/test", nil)
+	rr := httptest.NewRecorder()
+	h.DesktopTest(rr, req)
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", rr.Code)
+	}
+}
+
+func TestHandler_DesktopTest_NoNotifier(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(nil, nil, nil, nil, createTestConfigWithSynthetic())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/desktop/test", nil)
+	rr := httptest.NewRecorder()
+	h.DesktopTest(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", rr.Code)
+	}
+}
+
+func TestHandler_DesktopTest_RateLimit(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(nil, nil, nil, nil, createTestConfigWithSynthetic())
+	h.SetNotifier(&mockNotifierWithVAPID{vapidKey: "key"})
+
+	req1 := httptest.NewRequest(http.MethodPost, "/api/desktop/test", nil)
+	rr1 := httptest.NewRecorder()
+	h.DesktopTest(rr1, req1)
+
+	if rr1.Code != http.StatusOK {
+		t.Fatalf("first request: expected status 200, got %d", rr1.Code)
+	}
+
+	req2 := httptest.NewRequest(http.MethodPost, "/api/desktop/test", nil)
+	rr2 := httptest.NewRecorder()
+	h.DesktopTest(rr2, req2)
+
+	if rr2.Code != http.StatusTooManyRequests {
+		t.Errorf("second request: expected status 429, got %d", rr2.Code)
+	}
+}
+
+func TestHandler_DesktopTest_SendFailure(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(nil, nil, nil, nil, createTestConfigWithSynthetic())
+	h.SetNotifier(&mockNotifierWithVAPID{vapidKey: "key", sendDesktopErr: fmt.Errorf("no session bus")})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/desktop/test", nil)
+	rr := httptest.NewRecorder()
+	h.DesktopTest(rr, req)
+
+	var response map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+
+	if response["success"] != false {
+		t.Errorf("expected success false, got %v", response["success"])
+	}
+}
+
+func TestHandler_TelegramTest_Success(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(nil, nil, nil, nil, createTestConfigWithSynthetic())
+	h.SetNotifier(&mockNotifier{})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/settings/telegram/test", nil)
+	rr := httptest.NewRecorder()
+	h.TelegramTest(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", rr.Code)
+	}
+
+	var response map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+
+	if response["success"] != true {
+		t.Errorf("expected success true, got %v", response["success"])
+	}
+}
+
+func TestHandler_TelegramTest_MethodNotAllowed(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(nil, nil, nil, nil, createTestConfigWithSynthetic())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/settings/telegram/test", nil)
+	rr := httptest.NewRecorder()
+	h.TelegramTest(rr, req)
+
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", rr.Code)
+	}
+}
+
+func TestHandler_TelegramTest_NoNotifier(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(nil, nil, nil, nil, createTestConfigWithSynthetic())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/settings/telegram/test", nil)
+	rr := httptest.NewRecorder()
+	h.TelegramTest(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", rr.Code)
+	}
+}
+
+func TestHandler_TelegramTest_RateLimit(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(nil, nil, nil, nil, createTestConfigWithSynthetic())
+	h.SetNotifier(&mockNotifier{})
+
+	req1 := httptest.NewRequest(http.MethodPost, "/api/settings/telegram/test", nil)
+	rr1 := httptest.NewRecorder()
+	h.TelegramTest(rr1, req1)
+
+	if rr1.Code != http.StatusOK {
+		t.Fatalf("first request: expected status 200, got %d", rr1.Code)
+	}
+
+	req2 := httptest.NewRequest(http.MethodPost, "/api/settings/telegram/test", nil)
+	rr2 := httptest.NewRecorder()
+	h.TelegramTest(rr2, req2)
+
+	if rr2.Code != http.StatusTooManyRequests {
+		t.Errorf("second request: expected status 429, got %d", rr2.Code)
+	}
+}
+
+func TestHandler_TelegramTest_SendFailure(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(nil, nil, nil, nil, createTestConfigWithSynthetic())
+	h.SetNotifier(&mockNotifier{sendTestErr: fmt.Errorf("telegram not configured")})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/settings/telegram/test", nil)
+	rr := httptest.NewRecorder()
+	h.TelegramTest(rr, req)
+
+	var response map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+
+	if response["success"] != false {
+		t.Errorf("expected success false, got %v", response["success"])
+	}
+}
+
+func TestHandler_UpdateSettings_Telegram_EncryptsAndMasksToken(t *testing.T) {
+	// Not parallel: the encryption key depends on the package-level salt
+	// that crypto tests swap out.
+	s, _ := store.New(":memory:")
+	defer s.Close()
+
+	passHash := legacyHashPassword("admin")
+	sessions := NewSessionStore("admin", passHash, s)
+	h := NewHandler(s, nil, nil, sessions, createTestConfigWithSynthetic())
+	h.SetNotifier(&mockNotifier{})
+
+	body := strings.NewReader(`{"telegram":{"bot_token":"123456:secret","chat_ids":"111, -1002","api_url":"http://127.0.0.1:8081/"}}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/settings", body)
+	rr := httptest.NewRecorder()
+	h.UpdateSettings(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+
+	stored, _ := s.GetSetting("telegram")
+	var saved map[string]string
+	json.Unmarshal([]byte(stored), &saved)
+	if !IsEncryptedValue(saved["bot_token"]) {
+		t.Fatalf("bot token should be stored encrypted, got %q", saved["bot_token"])
+	}
+	token, err := notify.DecryptFromStorage(saved["bot_token"], DeriveEncryptionKey(passHash, nil))
+	if err != nil || token != "123456:secret" {
+		t.Fatalf("decrypted token = %q, err = %v", token, err)
+	}
+	if saved["api_url"] != "http://127.0.0.1:8081" {
+		t.Errorf("api_url = %q, want trailing slash trimmed", saved["api_url"])
+	}
+
+	// An empty token on a later save keeps the stored one
+	body = strings.NewReader(`{"telegram":{"bot_token":"","chat_ids":"111","api_url":""}}`)
+	req = httptest.NewRequest(http.MethodPut, "/api/settings", body)
+	rr = httptest.NewRecorder()
+	h.UpdateSettings(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	stored, _ = s.GetSetting("telegram")
+	var resaved map[string]string
+	json.Unmarshal([]byte(stored), &resaved)
+	if resaved["bot_token"] != saved["bot_token"] {
+		t.Error("expected existing bot token to be preserved")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/settings", nil)
+	rr = httptest.NewRecorder()
+	h.GetSettings(rr, req)
+
+	var response map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	telegram, ok := response["telegram"].(map[string]interface{})
+	if !ok {
+		t.Fatal("expected telegram field in response")
+	}
+	if telegram["bot_token"] != "" {
+		t.Error("Telegram bot token should be masked (empty) in GET response")
+	}
+	if telegram["bot_token_set"] != true {
+		t.Error("expected bot_token_set to be true")
+	}
+}
+
+func TestHandler_UpdateSettings_Telegram_Validation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid chat ID", `{"telegram":{"bot_token":"1:a","chat_ids":"@mychannel"}}`},
+		{"non-http API URL", `{"telegram":{"bot_token":"1:a","chat_ids":"1","api_url":"file:///etc/passwd"}}`},
+		{"token with path", `{"telegram":{"bot_token":"1:a/../x","chat_ids":"1"}}`},
+		{"invalid JSON", `{"telegram":"nope"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := store.New(":memory:")
+			defer s.Close()
+			h := NewHandler(s, nil, nil, nil, createTestConfigWithSynthetic())
+
+			req := httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			h.UpdateSettings(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d; body: %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
 // ═══════════════════════════════════════════════════════════════════
 // ── "Both" handler tests (cyclesBoth, summaryBoth, insightsBoth) ──
 // ═══════════════════════════════════════════════════════════════════
@@ -8054,6 +8411,103 @@ func TestHandler_UpdateSettings_SMTP_ValidConfig(t *testing.T) {
 	}
 }
 
+func TestHandler_UpdateSettings_SMTP_OAuth2EncryptsAndMasksSecrets(t *testing.T) {
+	// Not parallel: the encryption key depends on the package-level salt
+	// that crypto tests swap out.
+	s, _ := store.New(":memory:")
+	defer s.Close()
+
+	passHash := "abcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890"
+	sessions := NewSessionStore("admin", passHash, s)
+	h := NewHandler(s, nil, nil, sessions, createTestConfigWithSynthetic())
+	h.SetNotifier(&mockNotifier{})
+
+	body := `{"smtp":{"host":"smtp.gmail.com","port":587,"protocol":"starttls","username":"alerts@example.com","auth_method":"oauth2","oauth_provider":"google","oauth_client_id":"client-id","oauth_client_secret":"client-secret","oauth_refresh_token":"refresh-token","from_address":"alerts@example.com","to":"admin@example.com"}}`
+	req := httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	h.UpdateSettings(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+
+	stored, _ := s.GetSetting("smtp")
+	var saved map[string]string
+	json.Unmarshal([]byte(stored), &saved)
+	key := DeriveEncryptionKey(passHash, nil)
+	for field, want := range map[string]string{"oauth_client_secret": "client-secret", "oauth_refresh_token": "refresh-token"} {
+		if !IsEncryptedValue(saved[field]) {
+			t.Fatalf("%s should be stored encrypted, got %q", field, saved[field])
+		}
+		if got, err := notify.DecryptFromStorage(saved[field], key); err != nil || got != want {
+			t.Errorf("decrypted %s = %q, err = %v", field, got, err)
+		}
+	}
+
+	// Empty secrets on a later save keep the stored ones
+	body = `{"smtp":{"host":"smtp.gmail.com","port":587,"protocol":"starttls","username":"alerts@example.com","auth_method":"oauth2","oauth_provider":"google","oauth_client_id":"client-id","from_address":"alerts@example.com","to":"admin@example.com"}}`
+	req = httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(body))
+	rr = httptest.NewRecorder()
+	h.UpdateSettings(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	stored, _ = s.GetSetting("smtp")
+	var resaved map[string]string
+	json.Unmarshal([]byte(stored), &resaved)
+	if resaved["oauth_refresh_token"] != saved["oauth_refresh_token"] || resaved["oauth_client_secret"] != saved["oauth_client_secret"] {
+		t.Error("expected existing OAuth2 secrets to be preserved")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/settings", nil)
+	rr = httptest.NewRecorder()
+	h.GetSettings(rr, req)
+
+	var response map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	smtp, ok := response["smtp"].(map[string]interface{})
+	if !ok {
+		t.Fatal("expected smtp field in response")
+	}
+	if smtp["oauth_refresh_token"] != "" || smtp["oauth_client_secret"] != "" {
+		t.Error("OAuth2 secrets should be masked (empty) in GET response")
+	}
+	if smtp["oauth_refresh_token_set"] != true || smtp["oauth_client_secret_set"] != true {
+		t.Error("expected OAuth2 *_set flags to be true")
+	}
+}
+
+func TestHandler_UpdateSettings_SMTP_OAuth2Validation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		smtp string
+	}{
+		{"unknown auth method", `"auth_method":"cram-md5"`},
+		{"unknown provider", `"auth_method":"oauth2","oauth_provider":"yahoo","oauth_client_id":"id","oauth_refresh_token":"rt"`},
+		{"custom without token URL", `"auth_method":"oauth2","oauth_provider":"custom","oauth_client_id":"id","oauth_refresh_token":"rt"`},
+		{"non-http token URL", `"auth_method":"oauth2","oauth_provider":"custom","oauth_token_url":"ftp://idp","oauth_client_id":"id","oauth_refresh_token":"rt"`},
+		{"missing client ID", `"auth_method":"oauth2","oauth_provider":"google","oauth_refresh_token":"rt"`},
+		{"missing refresh token", `"auth_method":"oauth2","oauth_provider":"google","oauth_client_id":"id"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := store.New(":memory:")
+			defer s.Close()
+			h := NewHandler(s, nil, nil, nil, createTestConfigWithSynthetic())
+
+			body := `{"smtp":{"host":"smtp.example.com","port":587,"username":"user@example.com",` + tt.smtp + `}}`
+			req := httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(body))
+			rr := httptest.NewRecorder()
+			h.UpdateSettings(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d. Body: %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
 func TestHandler_UpdateSettings_SMTP_InvalidFromAddress(t *testing.T) {
 	t.Parallel()
 	s, _ := store.New(":memory:")
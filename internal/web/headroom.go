@@ -0,0 +1,402 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/tracker"
+)
+
+// headroomRateWindow is the snapshot history loaded to measure each quota's
+// current consumption rate.
+const headroomRateWindow = 2 * time.Hour
+
+// headroomStoredWindow is how far back BuildStoredHeadroom looks for each
+// quota's latest sample. Older quotas are treated as having no data.
+const headroomStoredWindow = 24 * time.Hour
+
+// headroomStoredProviders are the providers BuildStoredHeadroom can read from
+// snapshot history, in dashboard order.
+var headroomStoredProviders = []string{
+	"synthetic", "zai", "anthropic", "codex", "copilot", "gemini", "openrouter", "cursor", "grok", "kimi",
+}
+
+// headroomCategories groups providers for the category filter. "coding" is
+// agent and IDE subscriptions used directly; "api" is API-key plans consumed
+// through third-party tools.
+var headroomCategories = map[string]string{
+	"anthropic":   "coding",
+	"codex":       "coding",
+	"gemini":      "coding",
+	"kimi":        "coding",
+	"grok":        "coding",
+	"copilot":     "coding",
+	"cursor":      "coding",
+	"antigravity": "coding",
+	"synthetic":   "api",
+	"zai":         "api",
+	"minimax":     "api",
+	"openrouter":  "api",
+}
+
+// HeadroomReport ranks providers and accounts by how much they can still be used.
+type HeadroomReport struct {
+	GeneratedAt time.Time       `json:"generated_at"`
+	Best        string          `json:"best,omitempty"` // ID of the top available entry
+	Providers   []HeadroomEntry `json:"providers"`
+}
+
+// HeadroomEntry is one provider or provider account. Its headroom is that of
+// its binding quota, the quota with the least remaining capacity.
+type HeadroomEntry struct {
+	Rank                int             `json:"rank"`
+	ID                  string          `json:"id"`
+	Provider            string          `json:"provider"`
+	Label               string          `json:"label"`
+	Subtitle            string          `json:"subtitle,omitempty"`
+	Category            string          `json:"category"`
+	Available           bool            `json:"available"`
+	RemainingPercent    float64         `json:"remaining_percent"`
+	BindingQuota        string          `json:"binding_quota"`
+	ResetAt             string          `json:"reset_at,omitempty"`
+	ExhaustsBeforeReset bool            `json:"exhausts_before_reset"`
+	UpdatedAgo          string          `json:"updated_ago,omitempty"`
+	Quotas              []HeadroomQuota `json:"quotas"`
+}
+
+// HeadroomQuota is the remaining capacity and forecast of a single quota.
+type HeadroomQuota struct {
+	Key                 string   `json:"key"`
+	Label               string   `json:"label"`
+	UsedPercent         float64  `json:"used_percent"`
+	RemainingPercent    float64  `json:"remaining_percent"`
+	ResetAt             string   `json:"reset_at,omitempty"`
+	ResetInHours        *float64 `json:"reset_in_hours,omitempty"`
+	RatePerHour         float64  `json:"rate_per_hour"` // percentage points used in the last hour
+	ExhaustsAt          string   `json:"exhausts_at,omitempty"`
+	ExhaustsBeforeReset bool     `json:"exhausts_before_reset"`
+}
+
+// HeadroomFilter narrows the headroom ranking.
+type HeadroomFilter struct {
+	Providers    []string // provider names ("codex") or entry IDs ("codex:2")
+	Category     string   // "coding" or "api"
+	MinRemaining float64  // drop entries with less remaining capacity
+}
+
+// parseHeadroomFilter reads the provider, category and min_remaining query params.
+func parseHeadroomFilter(r *http.Request) HeadroomFilter {
+	q := r.URL.Query()
+	var f HeadroomFilter
+	for _, p := range strings.Split(q.Get("provider"), ",") {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			f.Providers = append(f.Providers, p)
+		}
+	}
+	f.Category = strings.ToLower(strings.TrimSpace(q.Get("category")))
+	if v, err := strconv.ParseFloat(q.Get("min_remaining"), 64); err == nil && v > 0 {
+		f.MinRemaining = v
+	}
+	return f
+}
+
+func (f HeadroomFilter) matches(e HeadroomEntry) bool {
+	if f.Category != "" && e.Category != f.Category {
+		return false
+	}
+	if e.RemainingPercent < f.MinRemaining {
+		return false
+	}
+	if len(f.Providers) == 0 {
+		return true
+	}
+	for _, p := range f.Providers {
+		if p == e.Provider || p == e.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// Headroom handles GET /api/headroom. It ranks every provider, account and
+// quota by remaining capacity, time to reset and forecast exhaustion so
+// scripts can route work to the tool with the most room.
+func (h *Handler) Headroom(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	respondJSON(w, http.StatusOK, h.BuildHeadroom(parseHeadroomFilter(r), time.Now().UTC()))
+}
+
+// BuildHeadroom ranks the same provider payloads the menubar renders.
+func (h *Handler) BuildHeadroom(filter HeadroomFilter, now time.Time) *HeadroomReport {
+	var entries []*HeadroomEntry
+	for _, source := range h.dashboardProviderPayloads() {
+		base := providerKeyBase(source.ID)
+		rates := h.headroomRates(base, source.AccountID, now)
+		entries = append(entries, buildHeadroomEntry(source, base, rates, now))
+	}
+	return newHeadroomReport(entries, filter, now)
+}
+
+// newHeadroomReport ranks the entries that match filter. Nil entries, for
+// providers without percentage quotas, are skipped.
+func newHeadroomReport(entries []*HeadroomEntry, filter HeadroomFilter, now time.Time) *HeadroomReport {
+	report := &HeadroomReport{GeneratedAt: now, Providers: []HeadroomEntry{}}
+	for _, entry := range entries {
+		if entry != nil && filter.matches(*entry) {
+			report.Providers = append(report.Providers, *entry)
+		}
+	}
+	rankHeadroom(report.Providers)
+	if len(report.Providers) > 0 && report.Providers[0].Available {
+		report.Best = report.Providers[0].ID
+	}
+	return report
+}
+
+// BuildStoredHeadroom builds a headroom report straight from the database,
+// for when no instance is running. Each quota reports its latest sample from
+// the last headroomStoredWindow. Reset times are not part of the stored
+// samples, so entries carry no reset time and are never forecast to run out
+// before one.
+func BuildStoredHeadroom(s *store.Store, filter HeadroomFilter, now time.Time) (*HeadroomReport, error) {
+	var entries []*HeadroomEntry
+	for _, provider := range headroomStoredProviders {
+		accounts := []int64{DefaultCodexAccountID}
+		if provider == "codex" {
+			ids, err := s.QueryCodexAccounts()
+			if err != nil {
+				return nil, err
+			}
+			accounts = ids
+		}
+		for _, accountID := range accounts {
+			id := provider
+			if provider == "codex" {
+				id = fmt.Sprintf("codex:%d", accountID)
+			}
+			series, _, err := s.QueryQuotaSeries(provider, accountID, now.Add(-headroomStoredWindow), now, quotaSnapshotLimit)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s history: %w", provider, err)
+			}
+			entries = append(entries, buildStoredHeadroomEntry(id, provider, series, now))
+		}
+	}
+	return newHeadroomReport(entries, filter, now), nil
+}
+
+// buildStoredHeadroomEntry converts a provider's percentage history into a
+// headroom entry. It returns nil when no quota has samples.
+func buildStoredHeadroomEntry(id, provider string, series []store.QuotaSeries, now time.Time) *HeadroomEntry {
+	entry := &HeadroomEntry{
+		ID:       id,
+		Provider: provider,
+		Label:    defaultProviderTabLabel(provider),
+		Category: headroomCategories[provider],
+	}
+	var latest time.Time
+	for _, s := range series {
+		if len(s.Samples) == 0 {
+			continue
+		}
+		last := s.Samples[len(s.Samples)-1]
+		if last.At.After(latest) {
+			latest = last.At
+		}
+		q := buildHeadroomQuota(s.Name, s.Label, last.Percent, "", headroomRate(s, now), now)
+		q.ExhaustsBeforeReset = false
+		entry.Quotas = append(entry.Quotas, q)
+	}
+	if len(entry.Quotas) == 0 {
+		return nil
+	}
+	entry.UpdatedAgo = timeAgo(latest)
+	entry.bind()
+	return entry
+}
+
+// headroomRates returns each quota's consumption in the last hour, keyed by
+// both quota name and display label, for providers with percentage history.
+func (h *Handler) headroomRates(provider string, accountID int64, now time.Time) map[string]float64 {
+	if h.store == nil {
+		return nil
+	}
+	rates, err := storedHeadroomRates(h.store, provider, accountID, now)
+	if err != nil {
+		h.logger.Debug("headroom rate lookup failed", "provider", provider, "error", err)
+	}
+	return rates
+}
+
+// storedHeadroomRates reads the last headroomRateWindow of a provider's
+// percentage history from s and returns each quota's consumption in the last
+// hour, keyed by both quota name and display label. It returns nil for
+// providers without percentage quotas.
+func storedHeadroomRates(s *store.Store, provider string, accountID int64, now time.Time) (map[string]float64, error) {
+	if accountID <= 0 {
+		accountID = DefaultCodexAccountID
+	}
+	series, supported, err := s.QueryQuotaSeries(provider, accountID, now.Add(-headroomRateWindow), now, quotaSnapshotLimit)
+	if !supported || err != nil {
+		return nil, err
+	}
+	rates := make(map[string]float64, len(series)*2)
+	for _, qs := range series {
+		rate := headroomRate(qs, now)
+		rates[qs.Name] = rate
+		rates[qs.Label] = rate
+	}
+	return rates, nil
+}
+
+// headroomRate is a quota's consumption in the hour before now, in
+// percentage points.
+func headroomRate(s store.QuotaSeries, now time.Time) float64 {
+	samples := make([]tracker.UsageSample, len(s.Samples))
+	for i, sample := range s.Samples {
+		samples[i] = tracker.UsageSample{At: sample.At, Percent: sample.Percent}
+	}
+	return tracker.RecentConsumption(samples, now)
+}
+
+// buildHeadroomEntry converts a provider payload into a headroom entry. It
+// returns nil when the payload has no percentage quotas.
+func buildHeadroomEntry(source providerPayload, base string, rates map[string]float64, now time.Time) *HeadroomEntry {
+	entry := &HeadroomEntry{
+		ID:         source.ID,
+		Provider:   base,
+		Label:      source.Label,
+		Subtitle:   source.Subtitle,
+		Category:   headroomCategories[base],
+		UpdatedAgo: timeAgo(parseCapturedAt(source.Payload)),
+	}
+	for _, item := range payloadQuotaItems(source.Payload) {
+		label := quotaItemLabel(item)
+		used, ok := readUsagePercent(item)
+		if !ok {
+			remaining, hasRemaining := item["remainingPercent"].(float64)
+			if !hasRemaining {
+				continue
+			}
+			used = 100 - remaining
+		}
+		if label == "" {
+			continue
+		}
+		key := firstString(item, "name", "quotaName", "modelId", "quotaGroup")
+		if key == "" {
+			key = strings.ToLower(strings.ReplaceAll(label, " ", "_"))
+		}
+		rate, ok := rates[key]
+		if !ok {
+			rate = rates[label]
+		}
+		resetAt := firstString(item, "renewsAt", "resetsAt", "resetDate", "resetTime", "resetAt")
+		entry.Quotas = append(entry.Quotas, buildHeadroomQuota(key, label, used, resetAt, rate, now))
+	}
+	if len(entry.Quotas) == 0 {
+		return nil
+	}
+	entry.bind()
+	return entry
+}
+
+// bind sets the entry's headline fields from its binding quota.
+func (e *HeadroomEntry) bind() {
+	binding := e.Quotas[0]
+	for _, q := range e.Quotas[1:] {
+		if q.RemainingPercent < binding.RemainingPercent {
+			binding = q
+		}
+	}
+	e.RemainingPercent = binding.RemainingPercent
+	e.BindingQuota = binding.Label
+	e.ResetAt = binding.ResetAt
+	e.Available = binding.RemainingPercent > 0
+	for _, q := range e.Quotas {
+		if q.ExhaustsBeforeReset {
+			e.ExhaustsBeforeReset = true
+		}
+	}
+}
+
+// buildHeadroomQuota forecasts when a quota runs out at its current rate and
+// whether that happens before it resets.
+func buildHeadroomQuota(key, label string, used float64, resetAt string, rate float64, now time.Time) HeadroomQuota {
+	if used < 0 {
+		used = 0
+	}
+	if used > 100 {
+		used = 100
+	}
+	q := HeadroomQuota{
+		Key:              key,
+		Label:            label,
+		UsedPercent:      used,
+		RemainingPercent: 100 - used,
+		ResetAt:          resetAt,
+		RatePerHour:      rate,
+	}
+	reset, hasReset := parseHeadroomTime(resetAt)
+	if hasReset && reset.After(now) {
+		hours := reset.Sub(now).Hours()
+		q.ResetInHours = &hours
+	}
+	if rate > 0 && q.RemainingPercent > 0 {
+		exhausts := now.Add(time.Duration(q.RemainingPercent / rate * float64(time.Hour)))
+		q.ExhaustsAt = exhausts.Format(time.RFC3339)
+		q.ExhaustsBeforeReset = !hasReset || exhausts.Before(reset)
+	}
+	return q
+}
+
+func parseHeadroomTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// rankHeadroom orders entries best first: available before exhausted, those
+// lasting until reset before those forecast to run out, then by remaining
+// capacity, and finally by sooner reset, since capacity that resets soon is
+// lost if unused.
+func rankHeadroom(entries []HeadroomEntry) {
+	resetOrder := func(e HeadroomEntry) float64 {
+		if t, ok := parseHeadroomTime(e.ResetAt); ok {
+			return float64(t.Unix()) / 3600
+		}
+		return 1e12
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Available != b.Available {
+			return a.Available
+		}
+		if a.ExhaustsBeforeReset != b.ExhaustsBeforeReset {
+			return !a.ExhaustsBeforeReset
+		}
+		if a.RemainingPercent != b.RemainingPercent {
+			return a.RemainingPercent > b.RemainingPercent
+		}
+		if ra, rb := resetOrder(a), resetOrder(b); ra != rb {
+			return ra < rb
+		}
+		return a.ID < b.ID
+	})
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+}
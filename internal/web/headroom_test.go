@@ -0,0 +1,213 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/api"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/tracker"
+)
+
+func TestBuildHeadroomQuota_Forecast(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 4, 6, 12, 0, 0, 0, time.UTC)
+	reset := now.Add(10 * time.Hour).Format(time.RFC3339)
+
+	// 40 points left at 10 points/hour runs out in 4h, before the 10h reset.
+	q := buildHeadroomQuota("five_hour", "5-Hour Limit", 60, reset, 10, now)
+	if q.RemainingPercent != 40 || !q.ExhaustsBeforeReset {
+		t.Errorf("quota = %+v, want 40%% left and exhausting before reset", q)
+	}
+	if q.ExhaustsAt != now.Add(4*time.Hour).Format(time.RFC3339) {
+		t.Errorf("ExhaustsAt = %s, want %s", q.ExhaustsAt, now.Add(4*time.Hour).Format(time.RFC3339))
+	}
+	if q.ResetInHours == nil || *q.ResetInHours != 10 {
+		t.Errorf("ResetInHours = %v, want 10", q.ResetInHours)
+	}
+
+	// At 2 points/hour the same quota lasts 20h, past the reset.
+	if q := buildHeadroomQuota("five_hour", "5-Hour Limit", 60, reset, 2, now); q.ExhaustsBeforeReset {
+		t.Errorf("slow burn should last until reset, got %+v", q)
+	}
+	// Without consumption there is no forecast.
+	if q := buildHeadroomQuota("five_hour", "5-Hour Limit", 60, reset, 0, now); q.ExhaustsAt != "" || q.ExhaustsBeforeReset {
+		t.Errorf("idle quota should not forecast exhaustion, got %+v", q)
+	}
+}
+
+func TestRankHeadroom(t *testing.T) {
+	t.Parallel()
+	entries := []HeadroomEntry{
+		{ID: "exhausted", Available: false},
+		{ID: "burning", Available: true, RemainingPercent: 90, ExhaustsBeforeReset: true},
+		{ID: "late-reset", Available: true, RemainingPercent: 50, ResetAt: "2026-04-08T00:00:00Z"},
+		{ID: "early-reset", Available: true, RemainingPercent: 50, ResetAt: "2026-04-07T00:00:00Z"},
+		{ID: "roomy", Available: true, RemainingPercent: 70},
+	}
+	rankHeadroom(entries)
+	want := []string{"roomy", "early-reset", "late-reset", "burning", "exhausted"}
+	for i, id := range want {
+		if entries[i].ID != id || entries[i].Rank != i+1 {
+			t.Errorf("rank %d = %s (rank %d), want %s", i+1, entries[i].ID, entries[i].Rank, id)
+		}
+	}
+}
+
+func TestHandler_Headroom_RanksAndFilters(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	now := time.Now().UTC()
+	s.InsertSnapshot(&api.Snapshot{
+		CapturedAt: now,
+		Sub:        api.QuotaInfo{Limit: 1000, Requests: 200, RenewsAt: now.Add(5 * time.Hour)},
+		Search:     api.QuotaInfo{Limit: 250, Requests: 0, RenewsAt: now.Add(time.Hour)},
+		ToolCall:   api.QuotaInfo{Limit: 1000, Requests: 100, RenewsAt: now.Add(3 * time.Hour)},
+	})
+	reset := now.Add(4 * time.Hour)
+	for i, util := range []float64{20, 50, 70} {
+		snap := &api.AnthropicSnapshot{
+			CapturedAt: now.Add(time.Duration(i-2) * 25 * time.Minute),
+			Quotas:     []api.AnthropicQuota{{Name: "five_hour", Utilization: util, ResetsAt: &reset}},
+		}
+		if _, err := s.InsertAnthropicSnapshot(snap); err != nil {
+			t.Fatalf("InsertAnthropicSnapshot: %v", err)
+		}
+	}
+
+	cfg := createTestConfigWithSynthetic()
+	cfg.AnthropicToken = "test_anthropic_token"
+	h := NewHandler(s, tracker.New(s, nil), nil, nil, cfg)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/headroom", nil)
+	rr := httptest.NewRecorder()
+	h.Headroom(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var report HeadroomReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	if len(report.Providers) != 2 || report.Best != "synthetic" {
+		t.Fatalf("report = %+v, want synthetic ranked first of 2", report)
+	}
+	syn, anth := report.Providers[0], report.Providers[1]
+	if syn.RemainingPercent != 80 || syn.BindingQuota == "" || syn.Category != "api" {
+		t.Errorf("synthetic = %+v, want 80%% left on its binding quota", syn)
+	}
+	if anth.ID != "anthropic" || anth.RemainingPercent != 30 || anth.Rank != 2 {
+		t.Errorf("anthropic = %+v, want rank 2 with 30%% left", anth)
+	}
+	// 50 points in the last hour with 30 left runs out well before the 4h reset.
+	if q := anth.Quotas[0]; q.RatePerHour != 50 || !q.ExhaustsBeforeReset || !anth.ExhaustsBeforeReset {
+		t.Errorf("anthropic quota = %+v, want 50 points/hour exhausting before reset", q)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/headroom?category=coding", nil)
+	rr = httptest.NewRecorder()
+	h.Headroom(rr, req)
+	report = HeadroomReport{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	if len(report.Providers) != 1 || report.Best != "anthropic" {
+		t.Errorf("coding filter = %+v, want only anthropic", report)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/headroom?provider=codex,gemini", nil)
+	rr = httptest.NewRecorder()
+	h.Headroom(rr, req)
+	report = HeadroomReport{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	if len(report.Providers) != 0 || report.Best != "" {
+		t.Errorf("provider filter = %+v, want no entries", report)
+	}
+}
+
+func TestBuildStoredHeadroom(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	now := time.Now().UTC()
+	for i, util := range []float64{20, 50, 70} {
+		snap := &api.AnthropicSnapshot{
+			CapturedAt: now.Add(time.Duration(i-2) * 25 * time.Minute),
+			Quotas:     []api.AnthropicQuota{{Name: "five_hour", Utilization: util}},
+		}
+		if _, err := s.InsertAnthropicSnapshot(snap); err != nil {
+			t.Fatalf("InsertAnthropicSnapshot: %v", err)
+		}
+	}
+
+	report, err := BuildStoredHeadroom(s, HeadroomFilter{}, now)
+	if err != nil {
+		t.Fatalf("BuildStoredHeadroom: %v", err)
+	}
+	if len(report.Providers) != 1 || report.Best != "anthropic" {
+		t.Fatalf("report = %+v, want only anthropic", report)
+	}
+	q := report.Providers[0].Quotas[0]
+	if q.Key != "five_hour" || q.UsedPercent != 70 || q.RatePerHour != 50 || q.ExhaustsBeforeReset {
+		t.Errorf("quota = %+v, want latest 70%% at 50 points/hour without a reset forecast", q)
+	}
+
+	report, err = BuildStoredHeadroom(s, HeadroomFilter{Providers: []string{"codex"}}, now)
+	if err != nil || len(report.Providers) != 0 {
+		t.Errorf("codex filter = %+v, %v, want no entries", report, err)
+	}
+}
+
+func TestSessionAuthMiddleware_HeadroomLoopbackOnly(t *testing.T) {
+	sessions := NewSessionStore("admin", legacyHashPassword("secret"), nil)
+	token, ok := sessions.Authenticate("admin", "secret")
+	if !ok {
+		t.Fatal("Authenticate failed")
+	}
+	ok200 := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, tc := range []struct {
+		name     string
+		basePath string
+		remote   string
+		session  bool
+		want     int
+	}{
+		{"loopback", "", "127.0.0.1:12345", false, http.StatusOK},
+		{"ipv6 loopback", "", "[::1]:12345", false, http.StatusOK},
+		{"remote", "", "192.168.1.50:12345", false, http.StatusUnauthorized},
+		{"remote with session", "", "192.168.1.50:12345", true, http.StatusOK},
+		// Behind a reverse proxy every request arrives from loopback.
+		{"base path loopback", "/onwatch", "127.0.0.1:12345", false, http.StatusUnauthorized},
+		{"base path remote", "/onwatch", "192.168.1.50:12345", false, http.StatusUnauthorized},
+		{"base path with session", "/onwatch", "127.0.0.1:12345", true, http.StatusOK},
+	} {
+		handler := sessionAuthMiddlewareWithBasePath(sessions, tc.basePath)(ok200)
+		req := httptest.NewRequest(http.MethodGet, tc.basePath+"/api/headroom", nil)
+		req.RemoteAddr = tc.remote
+		if tc.session {
+			req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: token})
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, rr.Code)
+		}
+	}
+}
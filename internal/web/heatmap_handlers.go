@@ -0,0 +1,207 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+const (
+	// quotaSnapshotLimit caps the snapshots read per request when samples
+	// are loaded into memory; 90 days of one-minute polling fits comfortably.
+	quotaSnapshotLimit = 150000
+	// heatmapMaxGap skips deltas across polling gaps (agent stopped, machine
+	// asleep) so they are not attributed to the hour polling resumed.
+	heatmapMaxGap = 2 * time.Hour
+	// heatmapCriticalTop is the number of critical hours returned.
+	heatmapCriticalTop = 5
+	// defaultHeatmapCritical mirrors the notifier's default critical threshold.
+	defaultHeatmapCritical = 95.0
+)
+
+// heatmapQuota is the per-quota grid returned by the heatmap endpoint.
+// Cells are indexed [day][hour] with Monday as day 0.
+type heatmapQuota struct {
+	Name     string         `json:"name"`
+	Label    string         `json:"label"`
+	Cells    [7][24]float64 `json:"cells"`
+	Total    float64        `json:"total"`
+	PeakDay  int            `json:"peakDay"`
+	PeakHour int            `json:"peakHour"`
+	Critical [24]int        `json:"critical"`
+	Samples  int            `json:"samples"`
+}
+
+// heatmapCriticalHour is an hour of day when windows most often hit critical.
+type heatmapCriticalHour struct {
+	Hour   int      `json:"hour"`
+	Count  int      `json:"count"`
+	Quotas []string `json:"quotas"`
+}
+
+// parseHeatmapRange maps the range query param to a duration (default 30d).
+func parseHeatmapRange(rangeStr string) (string, time.Duration) {
+	switch rangeStr {
+	case "7d":
+		return "7d", 7 * 24 * time.Hour
+	case "90d":
+		return "90d", 90 * 24 * time.Hour
+	default:
+		return "30d", 30 * 24 * time.Hour
+	}
+}
+
+// heatmapLocation resolves the tz query param, falling back to the saved
+// dashboard timezone and then the server's local zone.
+func (h *Handler) heatmapLocation(r *http.Request) (*time.Location, error) {
+	tz := r.URL.Query().Get("tz")
+	if tz == "" && h.store != nil {
+		if saved, err := h.store.GetSetting("timezone"); err == nil {
+			tz = saved
+		}
+	}
+	if tz == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %s", tz)
+	}
+	return loc, nil
+}
+
+// heatmapCriticalThreshold reads the critical threshold from notification settings.
+func (h *Handler) heatmapCriticalThreshold() float64 {
+	if h.store == nil {
+		return defaultHeatmapCritical
+	}
+	raw, err := h.store.GetSetting("notifications")
+	if err != nil || raw == "" {
+		return defaultHeatmapCritical
+	}
+	var notif struct {
+		CriticalThreshold float64 `json:"critical_threshold"`
+	}
+	if err := json.Unmarshal([]byte(raw), &notif); err != nil || notif.CriticalThreshold <= 0 {
+		return defaultHeatmapCritical
+	}
+	return notif.CriticalThreshold
+}
+
+// Heatmap handles GET /api/insights/heatmap. It aggregates snapshot deltas
+// into a 7x24 day-of-week by hour-of-day grid per quota in the requested
+// timezone, and lists the hours when windows most often cross critical.
+func (h *Handler) Heatmap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	provider, err := h.getProviderFromRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	loc, err := h.heatmapLocation(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+
+	rangeKey, rangeDur := parseHeatmapRange(r.URL.Query().Get("range"))
+	end := time.Now().UTC()
+	threshold := h.heatmapCriticalThreshold()
+	buckets, supported, err := h.store.QueryQuotaHeatmap(provider, parseCodexAccountID(r), end.Add(-rangeDur), end, heatmapMaxGap, threshold)
+	if !supported {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("heatmap not available for provider: %s", provider))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to query heatmap data", "provider", provider, "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to query heatmap data")
+		return
+	}
+
+	quotas := []heatmapQuota{}
+	for len(buckets) > 0 {
+		n := 1
+		for n < len(buckets) && buckets[n].Name == buckets[0].Name {
+			n++
+		}
+		quotas = append(quotas, buildHeatmapQuota(buckets[:n], loc))
+		buckets = buckets[n:]
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"provider":          provider,
+		"range":             rangeKey,
+		"timezone":          loc.String(),
+		"criticalThreshold": threshold,
+		"quotas":            quotas,
+		"criticalHours":     topCriticalHours(quotas, heatmapCriticalTop),
+	})
+}
+
+// buildHeatmapQuota folds one quota's buckets, as summed by
+// store.QueryQuotaHeatmap, into the hour and weekday they fall on in loc.
+func buildHeatmapQuota(buckets []store.QuotaHeatmapBucket, loc *time.Location) heatmapQuota {
+	var q heatmapQuota
+	if len(buckets) > 0 {
+		q.Name, q.Label = buckets[0].Name, buckets[0].Label
+	}
+	for _, b := range buckets {
+		local := b.Start.In(loc)
+		hour := local.Hour()
+		day := (int(local.Weekday()) + 6) % 7 // Monday first
+		q.Samples += b.Samples
+		q.Critical[hour] += b.Crossings
+		q.Cells[day][hour] += b.Delta
+		q.Total += b.Delta
+	}
+	for day := range q.Cells {
+		for hour := range q.Cells[day] {
+			if q.Cells[day][hour] > q.Cells[q.PeakDay][q.PeakHour] {
+				q.PeakDay, q.PeakHour = day, hour
+			}
+		}
+	}
+	return q
+}
+
+// topCriticalHours ranks hours of day by how many critical crossings they
+// saw across all quotas, most frequent first.
+func topCriticalHours(quotas []heatmapQuota, limit int) []heatmapCriticalHour {
+	hours := make([]heatmapCriticalHour, 0, 24)
+	for hour := 0; hour < 24; hour++ {
+		entry := heatmapCriticalHour{Hour: hour, Quotas: []string{}}
+		for _, q := range quotas {
+			if q.Critical[hour] > 0 {
+				entry.Count += q.Critical[hour]
+				entry.Quotas = append(entry.Quotas, q.Label)
+			}
+		}
+		if entry.Count > 0 {
+			hours = append(hours, entry)
+		}
+	}
+	sort.SliceStable(hours, func(i, j int) bool {
+		return hours[i].Count > hours[j].Count
+	})
+	if len(hours) > limit {
+		hours = hours[:limit]
+	}
+	return hours
+}
+
+// quotaSeries loads per-quota utilization samples for a provider. The
+// second return value is false for providers without percentage quotas.
+func (h *Handler) quotaSeries(provider string, accountID int64, start, end time.Time) ([]store.QuotaSeries, bool, error) {
+	return h.store.QueryQuotaSeries(provider, accountID, start, end, quotaSnapshotLimit)
+}
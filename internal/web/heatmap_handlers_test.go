@@ -0,0 +1,160 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/api"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+func TestBuildHeatmapQuota_FoldsBuckets(t *testing.T) {
+	t.Parallel()
+	// Monday 2026-04-06 09:00 UTC.
+	base := time.Date(2026, 4, 6, 9, 0, 0, 0, time.UTC)
+	buckets := []store.QuotaHeatmapBucket{
+		{Name: "five_hour", Label: "5-Hour Limit", Start: base, Samples: 1},
+		{Name: "five_hour", Label: "5-Hour Limit", Start: base.Add(15 * time.Minute), Samples: 1, Delta: 5},
+		{Name: "five_hour", Label: "5-Hour Limit", Start: base.Add(30 * time.Minute), Samples: 1, Delta: 15},
+		{Name: "five_hour", Label: "5-Hour Limit", Start: base.Add(90 * time.Minute), Samples: 2, Delta: 66, Crossings: 1},
+		{Name: "five_hour", Label: "5-Hour Limit", Start: base.Add(6 * time.Hour), Samples: 2, Delta: 1},
+	}
+
+	q := buildHeatmapQuota(buckets, time.UTC)
+	if q.Name != "five_hour" || q.Label != "5-Hour Limit" || q.Samples != 7 {
+		t.Errorf("quota = %s/%s with %d samples", q.Name, q.Label, q.Samples)
+	}
+	if q.Cells[0][9] != 20 || q.Cells[0][10] != 66 || q.Cells[0][15] != 1 {
+		t.Errorf("cells Mon 09/10/15 = %v/%v/%v, want 20/66/1", q.Cells[0][9], q.Cells[0][10], q.Cells[0][15])
+	}
+	if q.Total != 87 {
+		t.Errorf("Total = %v, want 87", q.Total)
+	}
+	if q.PeakDay != 0 || q.PeakHour != 10 {
+		t.Errorf("peak = day %d hour %d, want Mon 10", q.PeakDay, q.PeakHour)
+	}
+	if q.Critical[10] != 1 {
+		t.Errorf("Critical[10] = %d, want 1", q.Critical[10])
+	}
+
+	// In Tokyo (UTC+9) the same deltas land on Monday 18:00 and 19:00.
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+	q = buildHeatmapQuota(buckets, tokyo)
+	if q.Cells[0][18] != 20 || q.Cells[0][19] != 66 {
+		t.Errorf("Tokyo cells Mon 18/19 = %v/%v, want 20/66", q.Cells[0][18], q.Cells[0][19])
+	}
+
+	// In Kolkata (UTC+5:30) the local hour changes at half past the UTC hour.
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+	q = buildHeatmapQuota(buckets, kolkata)
+	if q.Cells[0][14] != 5 || q.Cells[0][15] != 15 {
+		t.Errorf("Kolkata cells Mon 14/15 = %v/%v, want 5/15", q.Cells[0][14], q.Cells[0][15])
+	}
+}
+
+func TestTopCriticalHours(t *testing.T) {
+	t.Parallel()
+	a := heatmapQuota{Label: "Weekly"}
+	a.Critical[14] = 3
+	a.Critical[9] = 1
+	b := heatmapQuota{Label: "5-Hour"}
+	b.Critical[14] = 1
+	b.Critical[22] = 2
+
+	hours := topCriticalHours([]heatmapQuota{a, b}, 2)
+	if len(hours) != 2 {
+		t.Fatalf("len = %d, want 2", len(hours))
+	}
+	if hours[0].Hour != 14 || hours[0].Count != 4 || len(hours[0].Quotas) != 2 {
+		t.Errorf("hours[0] = %+v, want 14:00 x4 across both quotas", hours[0])
+	}
+	if hours[1].Hour != 22 || hours[1].Count != 2 {
+		t.Errorf("hours[1] = %+v, want 22:00 x2", hours[1])
+	}
+}
+
+func TestHandler_Heatmap_Anthropic(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	start := time.Now().UTC().Add(-3 * time.Hour).Truncate(time.Hour)
+	for i, util := range []float64{10, 50, 97} {
+		snap := &api.AnthropicSnapshot{
+			CapturedAt: start.Add(time.Duration(i) * 30 * time.Minute),
+			Quotas:     []api.AnthropicQuota{{Name: "five_hour", Utilization: util}},
+		}
+		if _, err := s.InsertAnthropicSnapshot(snap); err != nil {
+			t.Fatalf("InsertAnthropicSnapshot: %v", err)
+		}
+	}
+	if err := s.SetSetting("notifications", `{"warning_threshold":70,"critical_threshold":90}`); err != nil {
+		t.Fatalf("SetSetting: %v", err)
+	}
+
+	h := NewHandler(s, nil, nil, nil, createTestConfigWithAnthropic())
+	req := httptest.NewRequest(http.MethodGet, "/api/insights/heatmap?provider=anthropic&range=7d&tz=UTC", nil)
+	rr := httptest.NewRecorder()
+	h.Heatmap(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var resp struct {
+		Range             string                `json:"range"`
+		Timezone          string                `json:"timezone"`
+		CriticalThreshold float64               `json:"criticalThreshold"`
+		Quotas            []heatmapQuota        `json:"quotas"`
+		CriticalHours     []heatmapCriticalHour `json:"criticalHours"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	if resp.Range != "7d" || resp.Timezone != "UTC" || resp.CriticalThreshold != 90 {
+		t.Errorf("range/timezone/threshold = %s/%s/%v", resp.Range, resp.Timezone, resp.CriticalThreshold)
+	}
+	if len(resp.Quotas) != 1 || resp.Quotas[0].Label != api.AnthropicDisplayName("five_hour") {
+		t.Fatalf("quotas = %+v", resp.Quotas)
+	}
+	if resp.Quotas[0].Total != 87 {
+		t.Errorf("Total = %v, want 87", resp.Quotas[0].Total)
+	}
+	wantHour := start.Add(time.Hour).Hour()
+	if len(resp.CriticalHours) != 1 || resp.CriticalHours[0].Hour != wantHour {
+		t.Errorf("criticalHours = %+v, want one entry at %02d:00", resp.CriticalHours, wantHour)
+	}
+}
+
+func TestHandler_Heatmap_Errors(t *testing.T) {
+	t.Parallel()
+	s, _ := store.New(":memory:")
+	defer s.Close()
+
+	h := NewHandler(s, nil, nil, nil, createTestConfigWithAnthropic())
+	req := httptest.NewRequest(http.MethodGet, "/api/insights/heatmap?provider=anthropic&tz=Not/AZone", nil)
+	rr := httptest.NewRecorder()
+	h.Heatmap(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("invalid tz: expected 400, got %d", rr.Code)
+	}
+
+	h = NewHandler(s, nil, nil, nil, createTestConfigWithAntigravity())
+	req = httptest.NewRequest(http.MethodGet, "/api/insights/heatmap?provider=antigravity", nil)
+	rr = httptest.NewRecorder()
+	h.Heatmap(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("unsupported provider: expected 400, got %d", rr.Code)
+	}
+}
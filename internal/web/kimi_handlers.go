@@ -84,6 +84,9 @@ func (h *Handler) buildKimiCurrent() map[string]interface{} {
 			qm["used"] = q.Used
 			qm["remaining"] = q.Remaining
 		}
+		if pacing := pacingResponse(tracker.QuotaPacing(q.Name, q.Utilization, q.ResetsAt, time.Now())); pacing != nil {
+			qm["pacing"] = pacing
+		}
 		quotas = append(quotas, qm)
 	}
 	response["quotas"] = quotas
@@ -113,6 +116,9 @@ func (h *Handler) buildKimiCurrent() map[string]interface{} {
 				sm["timeUntilReset"] = formatDuration(timeUntilReset)
 				sm["timeUntilResetSeconds"] = int64(timeUntilReset.Seconds())
 			}
+			if sum.Pacing != nil {
+				sm["pacing"] = pacingResponse(sum.Pacing)
+			}
 			response["summary"] = sm
 		}
 	}
@@ -337,6 +343,9 @@ func (h *Handler) buildKimiInsights(hidden map[string]bool) insightsResponse {
 				Desc:  fmt.Sprintf("%s utilization is at %.1f%%.", display, q.Utilization),
 			})
 		}
+		if p := tracker.QuotaPacing(q.Name, q.Utilization, q.ResetsAt, time.Now()); p != nil && !hidden["pacing_"+q.Name] {
+			resp.Insights = append(resp.Insights, buildPacingInsight(q.Name, display, p, 0))
+		}
 		if q.ResetsAt != nil && !hidden["resets_at"] {
 			resp.Insights = append(resp.Insights, insightItem{
 				Type: "info", Severity: "info",
@@ -235,7 +235,8 @@ func isLocalMenubarPublicPath(path string) bool {
 		path == "/api/menubar/summary" ||
 		path == "/api/menubar/preferences" ||
 		path == "/api/menubar/refresh" ||
-		path == "/api/menubar/tray-title"
+		path == "/api/menubar/tray-title" ||
+		path == "/api/headroom" // read by the local `onwatch headroom` CLI
 }
 
 // BuildMenubarSnapshot constructs the shared menubar UI contract.
@@ -257,35 +258,16 @@ func (h *Handler) BuildMenubarSnapshot() (*menubar.Snapshot, error) {
 
 func (h *Handler) buildMenubarProviders(settings *menubar.Settings, includeHidden bool) ([]menubar.ProviderCard, time.Time) {
 	normalized := settings.Normalize()
-	// Reuse dashboard tab renames (Settings → Tab name) for menubar cards.
-	labels := h.loadDashboardProviderLabels()
-
-	visibility := h.providerVisibilityMap()
 	providers := make([]menubar.ProviderCard, 0, 10)
 	latest := time.Time{}
 
-	if h.config != nil && h.config.HasProvider("synthetic") && h.providerDashboardVisible("synthetic", visibility) {
-		payload := h.buildSyntheticCurrent()
-		if card := normalizeProviderCard("synthetic", resolveProviderTabLabel("synthetic", labels), "", payload, normalized.WarningPercent, normalized.CriticalPercent); card != nil {
-			providers = append(providers, *card)
-			if captured := parseCapturedAt(payload); captured.After(latest) {
-				latest = captured
-			}
-		}
-	}
-	if h.config != nil && h.config.HasProvider("zai") && h.providerDashboardVisible("zai", visibility) {
-		payload := h.buildZaiCurrent()
-		if card := normalizeProviderCard("zai", resolveProviderTabLabel("zai", labels), "", payload, normalized.WarningPercent, normalized.CriticalPercent); card != nil {
-			providers = append(providers, *card)
-			if captured := parseCapturedAt(payload); captured.After(latest) {
-				latest = captured
-			}
+	for _, source := range h.dashboardProviderPayloads() {
+		card := normalizeProviderCard(source.ID, source.Label, source.Subtitle, source.Payload, normalized.WarningPercent, normalized.CriticalPercent)
+		if card == nil {
+			continue
 		}
-	}
-	if h.config != nil && h.config.HasProvider("anthropic") && h.providerDashboardVisible("anthropic", visibility) {
-		payload := h.buildAnthropicCurrent()
-		if card := normalizeProviderCard("anthropic", resolveProviderTabLabel("anthropic", labels), "", payload, normalized.WarningPercent, normalized.CriticalPercent); card != nil {
-			if promoData, ok := payload["promo"]; ok && promoData != nil {
+		if source.ID == "anthropic" {
+			if promoData, ok := source.Payload["promo"]; ok && promoData != nil {
 				if p, ok := promoData.(*anthropicPromo); ok {
 					compactText := "Off-peak hours"
 					if isAnthropicPeakHours(p, time.Now()) {
@@ -302,22 +284,58 @@ func (h *Handler) buildMenubarProviders(settings *menubar.Settings, includeHidde
 					}
 				}
 			}
-			providers = append(providers, *card)
-			if captured := parseCapturedAt(payload); captured.After(latest) {
-				latest = captured
-			}
+		}
+		providers = append(providers, *card)
+		if captured := parseCapturedAt(source.Payload); captured.After(latest) {
+			latest = captured
 		}
 	}
-	if h.config != nil && h.config.HasProvider("copilot") && h.providerDashboardVisible("copilot", visibility) {
-		payload := h.buildCopilotCurrent()
-		if card := normalizeProviderCard("copilot", resolveProviderTabLabel("copilot", labels), "", payload, normalized.WarningPercent, normalized.CriticalPercent); card != nil {
-			providers = append(providers, *card)
-			if captured := parseCapturedAt(payload); captured.After(latest) {
-				latest = captured
-			}
+	for _, source := range h.balanceProviderPayloads() {
+		providers = append(providers, *balanceProviderCard(source))
+		if captured := parseCapturedAt(source.Payload); captured.After(latest) {
+			latest = captured
+		}
+	}
+
+	sortProviderCards(providers, normalized.ProvidersOrder)
+	if !includeHidden {
+		providers = filterMenubarProviders(providers, normalized.VisibleProviders)
+	}
+	return providers, latest
+}
+
+// providerPayload is the current-usage payload of one dashboard-visible
+// provider, or one account of a multi-account provider.
+type providerPayload struct {
+	ID        string // card ID, e.g. "anthropic" or "codex:2"
+	Label     string
+	Subtitle  string
+	AccountID int64
+	Payload   map[string]interface{}
+}
+
+// dashboardProviderPayloads builds the current payload of every configured,
+// dashboard-visible provider. It backs both the menubar cards and the
+// headroom ranking.
+func (h *Handler) dashboardProviderPayloads() []providerPayload {
+	if h.config == nil {
+		return nil
+	}
+	// Reuse dashboard tab renames (Settings → Tab name) for menubar cards.
+	labels := h.loadDashboardProviderLabels()
+	visibility := h.providerVisibilityMap()
+	sources := make([]providerPayload, 0, 10)
+	single := func(key string, build func() map[string]interface{}) {
+		if h.config.HasProvider(key) && h.providerDashboardVisible(key, visibility) {
+			sources = append(sources, providerPayload{ID: key, Label: resolveProviderTabLabel(key, labels), Payload: build()})
 		}
 	}
-	if h.config != nil && h.config.HasProvider("codex") && h.providerDashboardVisible("codex", visibility) {
+
+	single("synthetic", h.buildSyntheticCurrent)
+	single("zai", h.buildZaiCurrent)
+	single("anthropic", h.buildAnthropicCurrent)
+	single("copilot", h.buildCopilotCurrent)
+	if h.config.HasProvider("codex") && h.providerDashboardVisible("codex", visibility) {
 		codexAccounts := h.codexUsageAccounts()
 		multiCodex := len(codexAccounts) > 1
 		for _, usage := range codexAccounts {
@@ -330,26 +348,17 @@ func (h *Handler) buildMenubarProviders(settings *menubar.Settings, includeHidde
 			if name == "" {
 				name = "default"
 			}
-			subtitle := "ChatGPT account"
-			label := menubarAccountLabel("codex", labels, name, multiCodex)
-			if card := normalizeProviderCard(providerKey, label, subtitle, usage, normalized.WarningPercent, normalized.CriticalPercent); card != nil {
-				providers = append(providers, *card)
-				if captured := parseCapturedAt(usage); captured.After(latest) {
-					latest = captured
-				}
-			}
-		}
-	}
-	if h.config != nil && h.config.HasProvider("antigravity") && h.providerDashboardVisible("antigravity", visibility) {
-		payload := h.buildAntigravityCurrent()
-		if card := normalizeProviderCard("antigravity", resolveProviderTabLabel("antigravity", labels), "", payload, normalized.WarningPercent, normalized.CriticalPercent); card != nil {
-			providers = append(providers, *card)
-			if captured := parseCapturedAt(payload); captured.After(latest) {
-				latest = captured
-			}
+			sources = append(sources, providerPayload{
+				ID:        providerKey,
+				Label:     menubarAccountLabel("codex", labels, name, multiCodex),
+				Subtitle:  "ChatGPT account",
+				AccountID: accountID,
+				Payload:   usage,
+			})
 		}
 	}
-	if h.config != nil && h.config.HasProvider("minimax") && h.providerDashboardVisible("minimax", visibility) {
+	single("antigravity", h.buildAntigravityCurrent)
+	if h.config.HasProvider("minimax") && h.providerDashboardVisible("minimax", visibility) {
 		minimaxAccounts := h.minimaxUsageAccounts()
 		multiMiniMax := len(minimaxAccounts) > 1
 		for _, usage := range minimaxAccounts {
@@ -362,67 +371,105 @@ func (h *Handler) buildMenubarProviders(settings *menubar.Settings, includeHidde
 			if name == "" {
 				name = "default"
 			}
-			subtitle := "MiniMax account"
-			label := menubarAccountLabel("minimax", labels, name, multiMiniMax)
-			if card := normalizeProviderCard(providerKey, label, subtitle, usage, normalized.WarningPercent, normalized.CriticalPercent); card != nil {
-				providers = append(providers, *card)
-				if captured := parseCapturedAt(usage); captured.After(latest) {
-					latest = captured
-				}
-			}
+			sources = append(sources, providerPayload{
+				ID:        providerKey,
+				Label:     menubarAccountLabel("minimax", labels, name, multiMiniMax),
+				Subtitle:  "MiniMax account",
+				AccountID: accountID,
+				Payload:   usage,
+			})
 		}
 	}
-	if h.config != nil && h.config.HasProvider("openrouter") && h.providerDashboardVisible("openrouter", visibility) {
-		payload := h.buildOpenRouterCurrent()
-		if card := normalizeProviderCard("openrouter", resolveProviderTabLabel("openrouter", labels), "", payload, normalized.WarningPercent, normalized.CriticalPercent); card != nil {
-			providers = append(providers, *card)
-			if captured := parseCapturedAt(payload); captured.After(latest) {
-				latest = captured
-			}
-		}
+	single("openrouter", h.buildOpenRouterCurrent)
+	single("gemini", h.buildGeminiCurrent)
+	single("grok", h.buildGrokCurrent)
+	if h.config.HasProvider("kimi") && h.providerDashboardVisible("kimi", visibility) {
+		sources = append(sources, providerPayload{ID: "kimi", Label: "Kimi Code", Payload: h.buildKimiCurrent()})
 	}
-	if h.config != nil && h.config.HasProvider("gemini") && h.providerDashboardVisible("gemini", visibility) {
-		payload := h.buildGeminiCurrent()
-		if card := normalizeProviderCard("gemini", resolveProviderTabLabel("gemini", labels), "", payload, normalized.WarningPercent, normalized.CriticalPercent); card != nil {
-			providers = append(providers, *card)
-			if captured := parseCapturedAt(payload); captured.After(latest) {
-				latest = captured
-			}
-		}
+	single("cursor", h.buildCursorCurrent)
+	return sources
+}
+
+// balanceProviderPayloads builds the current payload of the configured,
+// dashboard-visible prepaid balance providers. A balance has no usage
+// percentage, so these stay out of dashboardProviderPayloads and the
+// headroom ranking built on it.
+func (h *Handler) balanceProviderPayloads() []providerPayload {
+	if h.config == nil {
+		return nil
 	}
-	if h.config != nil && h.config.HasProvider("grok") && h.providerDashboardVisible("grok", visibility) {
-		payload := h.buildGrokCurrent()
-		if card := normalizeProviderCard("grok", resolveProviderTabLabel("grok", labels), "", payload, normalized.WarningPercent, normalized.CriticalPercent); card != nil {
-			providers = append(providers, *card)
-			if captured := parseCapturedAt(payload); captured.After(latest) {
-				latest = captured
-			}
+	labels := h.loadDashboardProviderLabels()
+	visibility := h.providerVisibilityMap()
+	sources := make([]providerPayload, 0, 2)
+	for _, p := range []struct {
+		key   string
+		build func() map[string]interface{}
+	}{
+		{"deepseek", h.buildDeepSeekCurrent},
+		{"moonshot", h.buildMoonshotCurrent},
+	} {
+		if h.config.HasProvider(p.key) && h.providerDashboardVisible(p.key, visibility) {
+			sources = append(sources, providerPayload{ID: p.key, Label: resolveProviderTabLabel(p.key, labels), Payload: p.build()})
 		}
 	}
-	if h.config != nil && h.config.HasProvider("kimi") && h.providerDashboardVisible("kimi", visibility) {
-		payload := h.buildKimiCurrent()
-		if card := normalizeProviderCard("kimi", "Kimi Code", "", payload, normalized.WarningPercent, normalized.CriticalPercent); card != nil {
-			providers = append(providers, *card)
-			if captured := parseCapturedAt(payload); captured.After(latest) {
-				latest = captured
-			}
+	return sources
+}
+
+// balanceProviderCard builds the menubar card of a prepaid balance. Its meter
+// shows the amount left and takes its status from the runway forecast rather
+// than from percent thresholds.
+func balanceProviderCard(source providerPayload) *menubar.ProviderCard {
+	balance, _ := source.Payload["balance"].(map[string]interface{})
+	amount := firstFloat(balance, "total", "available")
+	meter := menubar.QuotaMeter{
+		Key:          "balance",
+		Label:        "Balance",
+		DisplayValue: balanceDisplayValue(amount, stringValue(balance, "currency")),
+		Status:       "healthy",
+		CurrentRate:  firstFloat(balance, "rate"),
+	}
+	if runway, ok := balance["runway"].(map[string]interface{}); ok {
+		meter.Runway = menubarRunway(runway)
+		if meter.Runway.Low {
+			meter.Status = "warning"
 		}
 	}
-	if h.config != nil && h.config.HasProvider("cursor") && h.providerDashboardVisible("cursor", visibility) {
-		payload := h.buildCursorCurrent()
-		if card := normalizeProviderCard("cursor", resolveProviderTabLabel("cursor", labels), "", payload, normalized.WarningPercent, normalized.CriticalPercent); card != nil {
-			providers = append(providers, *card)
-			if captured := parseCapturedAt(payload); captured.After(latest) {
-				latest = captured
-			}
-		}
+	if stringValue(balance, "status") == "exhausted" || (meter.Runway != nil && meter.Runway.DepletesAt != "" && meter.Runway.DaysLeft <= 0) {
+		meter.Status = "critical"
+	}
+	return &menubar.ProviderCard{
+		ID:           source.ID,
+		BaseProvider: providerKeyBase(source.ID),
+		Label:        source.Label,
+		Subtitle:     "Prepaid balance",
+		Status:       meter.Status,
+		UpdatedAt:    timeAgo(parseCapturedAt(source.Payload)),
+		Quotas:       []menubar.QuotaMeter{meter},
 	}
+}
 
-	sortProviderCards(providers, normalized.ProvidersOrder)
-	if !includeHidden {
-		providers = filterMenubarProviders(providers, normalized.VisibleProviders)
+// balanceDisplayValue formats a balance for a meter, e.g. "¥42.10". Moonshot
+// payloads carry no currency and are billed in CNY.
+func balanceDisplayValue(amount float64, currency string) string {
+	switch currency {
+	case "", "CNY":
+		return fmt.Sprintf("¥%.2f", amount)
+	case "USD":
+		return fmt.Sprintf("$%.2f", amount)
+	default:
+		return fmt.Sprintf("%.2f %s", amount, currency)
+	}
+}
+
+// menubarRunway converts a payload "runway" object into its menubar form.
+func menubarRunway(runway map[string]interface{}) *menubar.QuotaRunway {
+	low, _ := runway["low"].(bool)
+	return &menubar.QuotaRunway{
+		DaysLeft:   firstFloat(runway, "daysLeft"),
+		DepletesAt: stringValue(runway, "depletesAt"),
+		DailySpend: firstFloat(runway, "dailySpend"),
+		Low:        low,
 	}
-	return providers, latest
 }
 
 // menubarAccountLabel builds multi-account menubar titles using the dashboard
@@ -669,41 +716,10 @@ func normalizeProviderCard(id, label, subtitle string, payload map[string]interf
 }
 
 func normalizeQuotas(payload map[string]interface{}, warningPercent, criticalPercent int) []menubar.QuotaMeter {
-	var rawQuotas []interface{}
-	switch typed := payload["quotas"].(type) {
-	case []interface{}:
-		rawQuotas = typed
-	case []map[string]interface{}:
-		rawQuotas = make([]interface{}, 0, len(typed))
-		for _, item := range typed {
-			rawQuotas = append(rawQuotas, item)
-		}
-	}
-
-	if len(rawQuotas) == 0 {
-		for _, key := range []string{"subscription", "search", "toolCalls", "tokensLimit", "timeLimit", "sharedQuota", "credits"} {
-			if quotaMap, ok := payload[key].(map[string]interface{}); ok {
-				rawQuotas = append(rawQuotas, quotaMap)
-			}
-		}
-	}
-
-	quotas := make([]menubar.QuotaMeter, 0, len(rawQuotas))
-	for _, raw := range rawQuotas {
-		item, ok := raw.(map[string]interface{})
-		if !ok {
-			continue
-		}
-		label := stringValue(item, "displayName")
-		if label == "" {
-			label = stringValue(item, "label")
-		}
-		if label == "" {
-			label = stringValue(item, "name")
-		}
-		if label == "" {
-			label = stringValue(item, "quotaName")
-		}
+	items := payloadQuotaItems(payload)
+	quotas := make([]menubar.QuotaMeter, 0, len(items))
+	for _, item := range items {
+		label := quotaItemLabel(item)
 		if label == "" {
 			continue
 		}
@@ -728,11 +744,65 @@ func normalizeQuotas(payload map[string]interface{}, warningPercent, criticalPer
 				meter.IsStale = b
 			}
 		}
+		if pacing, ok := item["pacing"].(map[string]interface{}); ok {
+			meter.Pacing = &menubar.QuotaPacing{
+				Status:         stringValue(pacing, "status"),
+				ExpectedPct:    firstFloat(pacing, "expectedUtil"),
+				DeltaPct:       firstFloat(pacing, "deltaPercent"),
+				DailyAllowance: firstFloat(pacing, "dailyAllowance"),
+				DaysLeft:       firstFloat(pacing, "daysLeft"),
+			}
+		}
+		if runway, ok := item["runway"].(map[string]interface{}); ok {
+			meter.Runway = menubarRunway(runway)
+		}
 		quotas = append(quotas, meter)
 	}
 	return quotas
 }
 
+// payloadQuotaItems returns the quota maps of a provider's current payload,
+// either from its "quotas" list or from the named top-level quota keys used
+// by Synthetic, Z.ai and OpenRouter.
+func payloadQuotaItems(payload map[string]interface{}) []map[string]interface{} {
+	var rawQuotas []interface{}
+	switch typed := payload["quotas"].(type) {
+	case []interface{}:
+		rawQuotas = typed
+	case []map[string]interface{}:
+		rawQuotas = make([]interface{}, 0, len(typed))
+		for _, item := range typed {
+			rawQuotas = append(rawQuotas, item)
+		}
+	}
+
+	if len(rawQuotas) == 0 {
+		for _, key := range []string{"subscription", "search", "toolCalls", "tokensLimit", "timeLimit", "sharedQuota", "credits"} {
+			if quotaMap, ok := payload[key].(map[string]interface{}); ok {
+				rawQuotas = append(rawQuotas, quotaMap)
+			}
+		}
+	}
+
+	items := make([]map[string]interface{}, 0, len(rawQuotas))
+	for _, raw := range rawQuotas {
+		if item, ok := raw.(map[string]interface{}); ok {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// quotaItemLabel returns the display label of a quota map, or "" if it has none.
+func quotaItemLabel(item map[string]interface{}) string {
+	for _, key := range []string{"displayName", "label", "name", "quotaName"} {
+		if label := stringValue(item, key); label != "" {
+			return label
+		}
+	}
+	return ""
+}
+
 func quotaStatus(item map[string]interface{}, percent float64, warningPercent, criticalPercent int) string {
 	rawStatus := stringValue(item, "status")
 	if _, ok := item["remainingPercent"]; ok || strings.EqualFold(stringValue(item, "cardLabel"), "Remaining") {
@@ -251,11 +251,22 @@ func sessionAuthMiddlewareWithBasePath(sessions *SessionStore, basePath string,
 			}
 
 			// Local tray surface is intentionally public for localhost requests.
+			// The base path is not stripped: it means onWatch sits behind a
+			// reverse proxy, whose forwarded requests all arrive from loopback.
 			if isLocalMenubarPublicPath(path) && isLoopbackRequest(r) {
 				next.ServeHTTP(w, r)
 				return
 			}
 
+			// Workers and OTLP exporters posting API integration events may
+			// use the ingest token instead of the admin credentials. It
+			// opens nothing else.
+			if r.Method == http.MethodPost && isAPIIntegrationIngestPath(basePath, path) &&
+				isAPIIntegrationIngestTokenRequest(sessions.store, r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			// Check session cookie first
 			if cookie, err := r.Cookie(sessionCookieName); err == nil {
 				if sessions.ValidateToken(cookie.Value) {
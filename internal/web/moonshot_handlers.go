@@ -64,6 +64,9 @@ func (h *Handler) buildMoonshotCurrent() map[string]interface{} {
 					if !summary.TrackingSince.IsZero() {
 						balance["trackingSince"] = summary.TrackingSince.Format(time.RFC3339)
 					}
+					if runway := runwayResponse(summary.Runway, h.runwayLowDays()); runway != nil {
+						balance["runway"] = runway
+					}
 				}
 			}
 
@@ -187,6 +190,7 @@ func (h *Handler) buildMoonshotSummaryMap() map[string]interface{} {
 			"peakCycle":       0.0,
 			"totalTracked":    0.0,
 			"trackingSince":   nil,
+			"runway":          nil,
 		},
 	}
 
@@ -201,6 +205,7 @@ func (h *Handler) buildMoonshotSummaryMap() map[string]interface{} {
 				"peakCycle":       summary.PeakCycle,
 				"totalTracked":    summary.TotalTracked,
 				"trackingSince":   nil,
+				"runway":          runwayResponse(summary.Runway, h.runwayLowDays()),
 			}
 			if !summary.TrackingSince.IsZero() {
 				response["balance"].(map[string]interface{})["trackingSince"] = summary.TrackingSince.Format(time.RFC3339)
@@ -271,6 +276,9 @@ func (h *Handler) buildMoonshotInsights(hidden map[string]bool) insightsResponse
 					Label: "Spend Rate", Value: fmt.Sprintf("¥%.2f/hr", summary.CurrentRate),
 				})
 			}
+			if !hidden["runway"] && summary.Runway != nil {
+				resp.Insights = append(resp.Insights, buildRunwayInsight("Moonshot", summary.Runway, h.runwayLowDays(), "¥"))
+			}
 		}
 	}
 
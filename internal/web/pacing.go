@@ -0,0 +1,65 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/tracker"
+)
+
+// pacingResponse converts tracker pacing into the "pacing" object attached to
+// current quota and summary payloads. It returns nil for unpaced quotas.
+func pacingResponse(p *tracker.Pacing) map[string]interface{} {
+	if p == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"windowStart":    p.WindowStart.Format(time.RFC3339),
+		"resetsAt":       p.ResetsAt.Format(time.RFC3339),
+		"expectedUtil":   p.ExpectedUtil,
+		"deltaPercent":   p.DeltaPercent,
+		"status":         p.Status,
+		"daysLeft":       p.DaysLeft,
+		"dailyAllowance": p.DailyAllowance,
+	}
+}
+
+// pacingLabel describes pace relative to the budget line, e.g.
+// "12% ahead of pace".
+func pacingLabel(p *tracker.Pacing) string {
+	switch p.Status {
+	case tracker.PaceAhead:
+		return fmt.Sprintf("%.0f%% ahead of pace", p.DeltaPercent)
+	case tracker.PaceBehind:
+		return fmt.Sprintf("%.0f%% behind pace", math.Abs(p.DeltaPercent))
+	default:
+		return "On pace"
+	}
+}
+
+// buildPacingInsight builds the "pacing_<quota>" insight for a long window.
+// unit converts utilization points into the quota's own unit for the daily
+// allowance (e.g. requests per percent); pass 0 to report percentages only.
+func buildPacingInsight(quotaName, displayName string, p *tracker.Pacing, unit float64) insightItem {
+	item := insightItem{
+		Key:    "pacing_" + quotaName,
+		Type:   "trend",
+		Title:  displayName + " Pace",
+		Metric: pacingLabel(p),
+	}
+	if p.Status == tracker.PaceAhead {
+		item.Severity = "warning"
+	} else {
+		item.Severity = "positive"
+	}
+
+	allowance := fmt.Sprintf("%.1f%%/day", p.DailyAllowance)
+	if unit > 0 {
+		allowance = fmt.Sprintf("%.0f/day", p.DailyAllowance*unit)
+	}
+	item.Sublabel = allowance + " to last until reset"
+	item.Desc = fmt.Sprintf("%.0f%% used vs %.0f%% expected by now on an even spread to reset in %s. You can use %s to last until reset.",
+		p.ExpectedUtil+p.DeltaPercent, p.ExpectedUtil, formatDuration(time.Until(p.ResetsAt)), allowance)
+	return item
+}
@@ -0,0 +1,187 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/api"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/tracker"
+)
+
+// newPacedAnthropicHandler records a snapshot where the weekly window is two
+// days from reset (budget line ~71%) and 90% used, so it is ahead of pace.
+func newPacedAnthropicHandler(t *testing.T) *Handler {
+	t.Helper()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	now := time.Now().UTC()
+	weeklyReset := now.Add(48 * time.Hour)
+	fiveHourReset := now.Add(2 * time.Hour)
+	snapshot := &api.AnthropicSnapshot{
+		CapturedAt: now,
+		Quotas: []api.AnthropicQuota{
+			{Name: "five_hour", Utilization: 40, ResetsAt: &fiveHourReset},
+			{Name: "seven_day", Utilization: 90, ResetsAt: &weeklyReset},
+		},
+	}
+	if _, err := s.InsertAnthropicSnapshot(snapshot); err != nil {
+		t.Fatalf("InsertAnthropicSnapshot: %v", err)
+	}
+	tr := tracker.NewAnthropicTracker(s, nil)
+	if err := tr.Process(snapshot); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	h := NewHandler(s, nil, nil, nil, createTestConfigWithAnthropic())
+	h.SetAnthropicTracker(tr)
+	return h
+}
+
+func TestHandler_Current_Anthropic_Pacing(t *testing.T) {
+	t.Parallel()
+	h := newPacedAnthropicHandler(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/current?provider=anthropic", nil)
+	rr := httptest.NewRecorder()
+	h.Current(rr, req)
+
+	var response struct {
+		Quotas []map[string]interface{} `json:"quotas"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	byName := map[string]map[string]interface{}{}
+	for _, q := range response.Quotas {
+		byName[q["name"].(string)] = q
+	}
+	if _, ok := byName["five_hour"]["pacing"]; ok {
+		t.Error("five_hour should not carry pacing")
+	}
+	pacing, ok := byName["seven_day"]["pacing"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("seven_day missing pacing: %v", byName["seven_day"])
+	}
+	if pacing["status"] != tracker.PaceAhead {
+		t.Errorf("status = %v, want ahead", pacing["status"])
+	}
+	if allowance := pacing["dailyAllowance"].(float64); allowance < 4.9 || allowance > 5.1 {
+		t.Errorf("dailyAllowance = %v, want ~5 (10%% over 2 days)", allowance)
+	}
+}
+
+func TestHandler_Insights_Anthropic_Pacing(t *testing.T) {
+	t.Parallel()
+	h := newPacedAnthropicHandler(t)
+
+	resp := h.buildAnthropicInsights(map[string]bool{}, 7*24*time.Hour)
+	var found *insightItem
+	for i := range resp.Insights {
+		if resp.Insights[i].Key == "pacing_five_hour" {
+			t.Error("five_hour should not get a pacing insight")
+		}
+		if resp.Insights[i].Key == "pacing_seven_day" {
+			found = &resp.Insights[i]
+		}
+	}
+	if found == nil {
+		t.Fatal("expected pacing_seven_day insight")
+	}
+	if found.Severity != "warning" || !strings.Contains(found.Metric, "ahead of pace") {
+		t.Errorf("insight = %+v, want warning ahead of pace", *found)
+	}
+	if !strings.Contains(found.Sublabel, "%/day") {
+		t.Errorf("sublabel = %q, want daily allowance", found.Sublabel)
+	}
+
+	hidden := h.buildAnthropicInsights(map[string]bool{"pacing_seven_day": true}, 7*24*time.Hour)
+	for _, item := range hidden.Insights {
+		if item.Key == "pacing_seven_day" {
+			t.Error("hidden pacing insight should be omitted")
+		}
+	}
+}
+
+func TestBuildMenubarSnapshot_IncludesPacing(t *testing.T) {
+	t.Parallel()
+	h := newPacedAnthropicHandler(t)
+
+	snapshot, err := h.BuildMenubarSnapshot()
+	if err != nil {
+		t.Fatalf("BuildMenubarSnapshot: %v", err)
+	}
+	provider := findMenubarProviderCard(t, snapshot, "anthropic")
+	for _, q := range provider.Quotas {
+		switch q.Label {
+		case api.AnthropicDisplayName("seven_day"):
+			if q.Pacing == nil || q.Pacing.Status != tracker.PaceAhead || q.Pacing.DailyAllowance <= 0 {
+				t.Errorf("seven_day pacing = %+v, want ahead with an allowance", q.Pacing)
+			}
+		case api.AnthropicDisplayName("five_hour"):
+			if q.Pacing != nil {
+				t.Errorf("five_hour pacing = %+v, want nil", q.Pacing)
+			}
+		}
+	}
+}
+
+func TestBuildPacingInsight_Units(t *testing.T) {
+	t.Parallel()
+	p := &tracker.Pacing{
+		ResetsAt:       time.Now().Add(72 * time.Hour),
+		ExpectedUtil:   60,
+		DeltaPercent:   -20,
+		Status:         tracker.PaceBehind,
+		DaysLeft:       3,
+		DailyAllowance: 20,
+	}
+	item := buildPacingInsight("premium_interactions", "Premium Requests", p, 3)
+	if item.Key != "pacing_premium_interactions" || item.Severity != "positive" {
+		t.Errorf("item = %+v", item)
+	}
+	if item.Metric != "20% behind pace" {
+		t.Errorf("metric = %q", item.Metric)
+	}
+	if item.Sublabel != "60/day to last until reset" {
+		t.Errorf("sublabel = %q, want allowance in requests", item.Sublabel)
+	}
+	if pacingResponse(nil) != nil {
+		t.Error("pacingResponse(nil) should be nil")
+	}
+}
+
+func TestUpdateSettings_RejectsInvalidPaceThreshold(t *testing.T) {
+	t.Parallel()
+	s, _ := store.New(":memory:")
+	defer s.Close()
+	h := NewHandler(s, nil, nil, nil, createTestConfigWithSynthetic())
+
+	body := strings.NewReader(`{"notifications":{"warning_threshold":80,"critical_threshold":95,"notify_pacing":true,"pace_threshold":150}}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/settings", body)
+	rr := httptest.NewRecorder()
+	h.UpdateSettings(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", rr.Code)
+	}
+
+	body = strings.NewReader(`{"notifications":{"warning_threshold":80,"critical_threshold":95,"notify_pacing":true,"pace_threshold":20}}`)
+	req = httptest.NewRequest(http.MethodPut, "/api/settings", body)
+	rr = httptest.NewRecorder()
+	h.UpdateSettings(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	stored, _ := s.GetSetting("notifications")
+	if !strings.Contains(stored, `"notify_pacing":true`) || !strings.Contains(stored, `"pace_threshold":20`) {
+		t.Errorf("stored notifications = %s", stored)
+	}
+}
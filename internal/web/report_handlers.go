@@ -0,0 +1,123 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/report"
+)
+
+// Report renders the usage report for a period as an HTML page.
+// Query params: period (weekly|monthly, default weekly), end (RFC3339 or
+// YYYY-MM-DD, default now) and download=1 to serve it as an attachment.
+func (h *Handler) Report(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusServiceUnavailable, "store not available")
+		return
+	}
+
+	q := r.URL.Query()
+	period := q.Get("period")
+	if period == "" {
+		period = report.PeriodWeekly
+	}
+	end := time.Now()
+	if raw := q.Get("end"); raw != "" {
+		parsed, err := parseReportEnd(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid end: use RFC3339 or YYYY-MM-DD")
+			return
+		}
+		end = parsed
+	}
+	start, err := report.PeriodStart(period, end)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rep, err := report.Build(h.store, period, start, end)
+	if err != nil {
+		h.logger.Error("failed to build usage report", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to build report")
+		return
+	}
+	var buf bytes.Buffer
+	if err := rep.RenderHTML(&buf); err != nil {
+		h.logger.Error("failed to render usage report", "error", err)
+		respondError(w, http.StatusInternalServerError, "failed to render report")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if q.Get("download") == "1" {
+		filename := fmt.Sprintf("onwatch-%s-report-%s.html", period, end.Format("2006-01-02"))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// parseReportEnd accepts an RFC3339 timestamp or a YYYY-MM-DD date, which
+// is taken as the start of that day in local time.
+func parseReportEnd(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", raw, time.Local)
+}
+
+// ReportSend emails the report for a configured schedule immediately.
+func (h *Handler) ReportSend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var body struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || strings.TrimSpace(body.ID) == "" {
+		respondError(w, http.StatusBadRequest, "report schedule id is required")
+		return
+	}
+
+	// Rate limit: 30 second cooldown
+	h.reportSendMu.Lock()
+	elapsed := time.Since(h.reportSendLast)
+	if elapsed < 30*time.Second {
+		h.reportSendMu.Unlock()
+		remaining := int((30*time.Second - elapsed).Seconds())
+		respondError(w, http.StatusTooManyRequests, fmt.Sprintf("please wait %d seconds before sending another report", remaining))
+		return
+	}
+	h.reportSendLast = time.Now()
+	h.reportSendMu.Unlock()
+
+	if h.notifier == nil {
+		respondError(w, http.StatusServiceUnavailable, "notification engine not configured")
+		return
+	}
+
+	if err := h.notifier.SendReportNow(strings.TrimSpace(body.ID)); err != nil {
+		h.logger.Error("report send failed", "error", err)
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": false,
+			"message": "Report send failed - save the schedule and check SMTP settings",
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Report sent",
+	})
+}
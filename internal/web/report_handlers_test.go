@@ -0,0 +1,196 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+func TestHandler_Report_RendersHTML(t *testing.T) {
+	t.Parallel()
+	s, _ := store.New(":memory:")
+	defer s.Close()
+
+	end := time.Date(2026, 4, 8, 0, 0, 0, 0, time.UTC)
+	cycleEnd := end.Add(-24 * time.Hour)
+	s.CreateAnthropicCycle("five_hour", cycleEnd.Add(-5*time.Hour), &cycleEnd)
+	s.CloseAnthropicCycle("five_hour", cycleEnd, 100, 100)
+
+	h := NewHandler(s, nil, nil, nil, createTestConfigWithSynthetic())
+	req := httptest.NewRequest(http.MethodGet, "/api/reports?period=weekly&end=2026-04-08T00:00:00Z", nil)
+	rr := httptest.NewRecorder()
+	h.Report(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Content-Type = %q, want text/html", ct)
+	}
+	if cd := rr.Header().Get("Content-Disposition"); cd != "" {
+		t.Errorf("inline view should not set Content-Disposition, got %q", cd)
+	}
+	body := rr.Body.String()
+	if !strings.Contains(body, "Weekly usage report") || !strings.Contains(body, "5-Hour Limit") {
+		t.Errorf("report body missing title or quota:\n%s", body)
+	}
+}
+
+func TestHandler_Report_Download(t *testing.T) {
+	t.Parallel()
+	s, _ := store.New(":memory:")
+	defer s.Close()
+
+	h := NewHandler(s, nil, nil, nil, createTestConfigWithSynthetic())
+	req := httptest.NewRequest(http.MethodGet, "/api/reports?period=monthly&end=2026-04-08&download=1", nil)
+	rr := httptest.NewRecorder()
+	h.Report(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	want := `attachment; filename="onwatch-monthly-report-2026-04-08.html"`
+	if cd := rr.Header().Get("Content-Disposition"); cd != want {
+		t.Errorf("Content-Disposition = %q, want %q", cd, want)
+	}
+}
+
+func TestHandler_Report_BadRequest(t *testing.T) {
+	t.Parallel()
+	s, _ := store.New(":memory:")
+	defer s.Close()
+	h := NewHandler(s, nil, nil, nil, createTestConfigWithSynthetic())
+
+	for _, query := range []string{"period=daily", "end=yesterday"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports?"+query, nil)
+		rr := httptest.NewRecorder()
+		h.Report(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, rr.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/reports", nil)
+	rr := httptest.NewRecorder()
+	h.Report(rr, req)
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: expected status 405, got %d", rr.Code)
+	}
+}
+
+func TestHandler_ReportSend(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(nil, nil, nil, nil, createTestConfigWithSynthetic())
+	notifier := &mockNotifier{}
+	h.SetNotifier(notifier)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/reports/send", strings.NewReader(`{"id":"weekly-ops"}`))
+	rr := httptest.NewRecorder()
+	h.ReportSend(rr, req)
+
+	var response map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if rr.Code != http.StatusOK || response["success"] != true {
+		t.Fatalf("expected success, got %d %v", rr.Code, response)
+	}
+	if notifier.reportID != "weekly-ops" {
+		t.Errorf("SendReportNow called with %q, want weekly-ops", notifier.reportID)
+	}
+
+	req2 := httptest.NewRequest(http.MethodPost, "/api/reports/send", strings.NewReader(`{"id":"weekly-ops"}`))
+	rr2 := httptest.NewRecorder()
+	h.ReportSend(rr2, req2)
+	if rr2.Code != http.StatusTooManyRequests {
+		t.Errorf("second request: expected status 429, got %d", rr2.Code)
+	}
+}
+
+func TestHandler_ReportSend_Failures(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(nil, nil, nil, nil, createTestConfigWithSynthetic())
+	h.SetNotifier(&mockNotifier{sendTestErr: fmt.Errorf("SMTP not configured")})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/reports/send", strings.NewReader(`{}`))
+	rr := httptest.NewRecorder()
+	h.ReportSend(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("missing id: expected status 400, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/reports/send", strings.NewReader(`{"id":"x"}`))
+	rr = httptest.NewRecorder()
+	h.ReportSend(rr, req)
+	var response map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if response["success"] != false {
+		t.Errorf("expected success false, got %v", response["success"])
+	}
+}
+
+func TestHandler_UpdateSettings_Reports(t *testing.T) {
+	t.Parallel()
+	s, _ := store.New(":memory:")
+	defer s.Close()
+	h := NewHandler(s, nil, nil, nil, createTestConfigWithSynthetic())
+
+	body := strings.NewReader(`{"reports":{"schedules":[{"id":" ops ","name":" Ops ","enabled":true,"period":"weekly","weekday":1,"hour":8,"recipients":" ops@example.com "}]}}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/settings", body)
+	rr := httptest.NewRecorder()
+	h.UpdateSettings(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d; body: %s", rr.Code, rr.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/settings", nil)
+	rr = httptest.NewRecorder()
+	h.GetSettings(rr, req)
+	var settings struct {
+		Reports struct {
+			Schedules []map[string]interface{} `json:"schedules"`
+		} `json:"reports"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &settings)
+	if len(settings.Reports.Schedules) != 1 {
+		t.Fatalf("schedules = %v, want one", settings.Reports.Schedules)
+	}
+	sched := settings.Reports.Schedules[0]
+	if sched["id"] != "ops" || sched["name"] != "Ops" || sched["recipients"] != "ops@example.com" {
+		t.Errorf("schedule fields not trimmed: %v", sched)
+	}
+}
+
+func TestHandler_UpdateSettings_Reports_Validation(t *testing.T) {
+	t.Parallel()
+	s, _ := store.New(":memory:")
+	defer s.Close()
+	h := NewHandler(s, nil, nil, nil, createTestConfigWithSynthetic())
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid JSON", `{"reports":"weekly"}`},
+		{"bad period", `{"reports":{"schedules":[{"id":"a","period":"daily"}]}}`},
+		{"bad recipient", `{"reports":{"schedules":[{"id":"a","period":"weekly","recipients":"nobody"}]}}`},
+		{"duplicate id", `{"reports":{"schedules":[{"id":"a","period":"weekly"},{"id":"a","period":"monthly","day_of_month":1}]}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			h.UpdateSettings(rr, req)
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", rr.Code)
+			}
+		})
+	}
+	if stored, _ := s.GetSetting("reports"); stored != "" {
+		t.Errorf("invalid settings should not be stored, got %s", stored)
+	}
+}
@@ -0,0 +1,149 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/tracker"
+)
+
+// runwayProviders are the prepaid providers whose balance can run out.
+var runwayProviders = []string{"deepseek", "moonshot", "openrouter"}
+
+// parseRunwayFloors validates the runway_floors settings value: a balance per
+// prepaid provider, in its own currency, that runway counts down to. Zero
+// floors are dropped.
+func parseRunwayFloors(raw json.RawMessage) (map[string]float64, error) {
+	var in map[string]float64
+	if err := json.Unmarshal(raw, &in); err != nil || in == nil {
+		return nil, fmt.Errorf("invalid runway_floors value")
+	}
+	floors := make(map[string]float64, len(in))
+	for provider, floor := range in {
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		known := false
+		for _, p := range runwayProviders {
+			known = known || p == provider
+		}
+		if !known {
+			return nil, fmt.Errorf("runway floors are not supported for %s", provider)
+		}
+		if floor < 0 || floor > 1e7 || math.IsNaN(floor) {
+			return nil, fmt.Errorf("runway floor for %s must be between 0 and 10000000", provider)
+		}
+		if floor > 0 {
+			floors[provider] = floor
+		}
+	}
+	return floors, nil
+}
+
+// loadRunwayFloors returns the saved runway floors, or none when unset.
+func (h *Handler) loadRunwayFloors() map[string]float64 {
+	floors := map[string]float64{}
+	if h.store == nil {
+		return floors
+	}
+	raw, err := h.store.GetSetting(store.SettingRunwayFloors)
+	if err != nil || raw == "" {
+		return floors
+	}
+	if err := json.Unmarshal([]byte(raw), &floors); err != nil || floors == nil {
+		return map[string]float64{}
+	}
+	return floors
+}
+
+// runwayLowDays returns the runway, in days, below which a balance counts as
+// low: the saved runway alert setting, or tracker.DefaultRunwayDays.
+func (h *Handler) runwayLowDays() float64 {
+	if h.store == nil {
+		return tracker.DefaultRunwayDays
+	}
+	raw, err := h.store.GetSetting("notifications")
+	if err != nil || raw == "" {
+		return tracker.DefaultRunwayDays
+	}
+	var notif struct {
+		RunwayDays float64 `json:"runway_days"`
+	}
+	if err := json.Unmarshal([]byte(raw), &notif); err != nil || notif.RunwayDays <= 0 {
+		return tracker.DefaultRunwayDays
+	}
+	return notif.RunwayDays
+}
+
+// runwayResponse converts a tracker runway into the "runway" object attached
+// to balance payloads. It returns nil without enough balance history.
+func runwayResponse(r *tracker.Runway, lowDays float64) map[string]interface{} {
+	if r == nil {
+		return nil
+	}
+	resp := map[string]interface{}{
+		"balance":    r.Balance,
+		"floor":      r.Floor,
+		"spent":      r.Spent,
+		"toppedUp":   r.ToppedUp,
+		"windowDays": r.Span.Hours() / 24,
+		"dailySpend": r.DailySpend,
+		"daysLeft":   nil,
+		"depletesAt": nil,
+		"low":        r.Low(lowDays),
+	}
+	if r.DepletesAt != nil {
+		resp["daysLeft"] = r.DaysLeft
+		resp["depletesAt"] = r.DepletesAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+// runwayLabel describes how long a balance lasts, e.g. "12.5 days left".
+func runwayLabel(r *tracker.Runway) string {
+	switch {
+	case r.DepletesAt == nil:
+		return "No recent spend"
+	case r.DaysLeft <= 0:
+		return "At floor"
+	case r.DaysLeft < 1:
+		return fmt.Sprintf("%.0f hours left", r.DaysLeft*24)
+	default:
+		return fmt.Sprintf("%.1f days left", r.DaysLeft)
+	}
+}
+
+// buildRunwayInsight describes when a prepaid balance runs out. symbol
+// prefixes amounts, e.g. "¥" or "$".
+func buildRunwayInsight(displayName string, r *tracker.Runway, lowDays float64, symbol string) insightItem {
+	item := insightItem{
+		Key:      "runway",
+		Type:     "trend",
+		Severity: "positive",
+		Title:    displayName + " Runway",
+		Metric:   runwayLabel(r),
+		Sublabel: fmt.Sprintf("%s%.2f/day", symbol, r.DailySpend),
+	}
+	floor := "zero"
+	if r.Floor > 0 {
+		floor = fmt.Sprintf("the %s%.2f floor", symbol, r.Floor)
+	}
+	switch {
+	case r.DepletesAt == nil:
+		item.Desc = fmt.Sprintf("No spend in the last %.1f days, so the %s%.2f balance is not running down.",
+			r.Span.Hours()/24, symbol, r.Balance)
+		return item
+	case r.DaysLeft <= 0:
+		item.Desc = fmt.Sprintf("The %s%.2f balance has reached %s. Top up before API calls start failing.",
+			symbol, r.Balance, floor)
+	default:
+		item.Desc = fmt.Sprintf("At %s%.2f/day over the last %.1f days (top-ups excluded), the %s%.2f balance reaches %s around %s.",
+			symbol, r.DailySpend, r.Span.Hours()/24, symbol, r.Balance, floor, r.DepletesAt.Format("Jan 2, 15:04 MST"))
+	}
+	if r.Low(lowDays) {
+		item.Severity = "warning"
+	}
+	return item
+}
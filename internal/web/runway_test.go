@@ -0,0 +1,97 @@
+package web
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/api"
+	"github.com/onllm-dev/onwatch/v2/internal/config"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/tracker"
+)
+
+func TestParseRunwayFloors(t *testing.T) {
+	t.Parallel()
+	floors, err := parseRunwayFloors(json.RawMessage(`{" DeepSeek ": 20, "openrouter": 0}`))
+	if err != nil {
+		t.Fatalf("parseRunwayFloors: %v", err)
+	}
+	if len(floors) != 1 || floors["deepseek"] != 20 {
+		t.Errorf("floors = %v, want deepseek only", floors)
+	}
+	for _, raw := range []string{`{"anthropic": 5}`, `{"moonshot": -1}`, `[]`} {
+		if _, err := parseRunwayFloors(json.RawMessage(raw)); err == nil {
+			t.Errorf("parseRunwayFloors(%s) should fail", raw)
+		}
+	}
+}
+
+func TestHandler_DeepSeekRunway(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	// 10 CNY/day over four days, with a small top-up that is not spend.
+	tr := tracker.NewDeepSeekTracker(s, nil)
+	now := time.Now().UTC()
+	for i, balance := range []float64{100, 90, 80, 95, 75} {
+		snap := &api.DeepSeekSnapshot{CapturedAt: now.Add(time.Duration(i-4) * 24 * time.Hour), IsAvailable: true, Currency: "CNY", TotalBalance: balance}
+		if _, err := s.InsertDeepSeekSnapshot(snap); err != nil {
+			t.Fatalf("InsertDeepSeekSnapshot: %v", err)
+		}
+		if err := tr.Process(snap); err != nil {
+			t.Fatalf("Process: %v", err)
+		}
+	}
+
+	h := NewHandler(s, nil, nil, nil, &config.Config{DeepSeekAPIKey: "sk-test"})
+	h.SetDeepSeekTracker(tr)
+	req := httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(`{"runway_floors": {"deepseek": 15}}`))
+	rr := httptest.NewRecorder()
+	h.UpdateSettings(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("UpdateSettings: %d %s", rr.Code, rr.Body.String())
+	}
+
+	balance := h.buildDeepSeekCurrent()["balance"].(map[string]interface{})
+	runway, ok := balance["runway"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("balance = %+v, want a runway", balance)
+	}
+	// (75 - 15) / 10 = 6 days, below the default 7 day threshold.
+	if math.Abs(runway["dailySpend"].(float64)-10) > 1e-9 || math.Abs(runway["daysLeft"].(float64)-6) > 1e-9 || runway["low"] != true {
+		t.Errorf("runway = %+v, want 10/day and 6 low days", runway)
+	}
+
+	summary := h.buildDeepSeekSummaryMap("CNY")["balance"].(map[string]interface{})
+	if summary["runway"] == nil {
+		t.Errorf("summary = %+v, want a runway", summary)
+	}
+
+	snapshot, err := h.BuildMenubarSnapshot()
+	if err != nil {
+		t.Fatalf("BuildMenubarSnapshot: %v", err)
+	}
+	found := false
+	for _, p := range snapshot.Providers {
+		if p.ID != "deepseek" {
+			continue
+		}
+		found = true
+		q := p.Quotas[0]
+		if q.DisplayValue != "¥75.00" || q.Status != "warning" || q.Runway == nil || !q.Runway.Low {
+			t.Errorf("deepseek meter = %+v, want a low runway warning", q)
+		}
+	}
+	if !found {
+		t.Errorf("menubar providers = %+v, want a DeepSeek balance card", snapshot.Providers)
+	}
+}
@@ -61,6 +61,7 @@ func NewServer(port int, handler *Handler, logger *slog.Logger, username, passwo
 	mux.HandleFunc(p("/api/current"), handler.Current)
 	mux.HandleFunc(p("/api/history"), handler.History)
 	mux.HandleFunc(p("/api/cycles"), handler.Cycles)
+	mux.HandleFunc(p("/api/cycles/compare"), handler.CyclesCompare)
 	mux.HandleFunc(p("/api/summary"), handler.Summary)
 	mux.HandleFunc(p("/api/capabilities"), handler.Capabilities)
 	mux.HandleFunc(p("/api/menubar/summary"), handler.MenubarSummary)
@@ -70,6 +71,11 @@ func NewServer(port int, handler *Handler, logger *slog.Logger, username, passwo
 	mux.HandleFunc(p("/api/menubar/test"), handler.MenubarTest)
 	mux.HandleFunc(p("/api/sessions"), handler.Sessions)
 	mux.HandleFunc(p("/api/insights"), handler.Insights)
+	mux.HandleFunc(p("/api/insights/heatmap"), handler.Heatmap)
+	mux.HandleFunc(p("/api/insights/value"), handler.SubscriptionValue)
+	mux.HandleFunc(p("/api/spend"), handler.SpendLedger)
+	mux.HandleFunc(p("/api/budgets"), handler.Budgets)
+	mux.HandleFunc(p("/api/headroom"), handler.Headroom)
 	mux.HandleFunc(p("/api/settings"), func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPut {
 			handler.UpdateSettings(w, r)
@@ -78,6 +84,9 @@ func NewServer(port int, handler *Handler, logger *slog.Logger, username, passwo
 		}
 	})
 	mux.HandleFunc(p("/api/settings/smtp/test"), handler.SMTPTest)
+	mux.HandleFunc(p("/api/settings/telegram/test"), handler.TelegramTest)
+	mux.HandleFunc(p("/api/reports"), handler.Report)
+	mux.HandleFunc(p("/api/reports/send"), handler.ReportSend)
 	mux.HandleFunc(p("/api/password"), handler.ChangePassword)
 	mux.HandleFunc(p("/api/cycle-overview"), handler.CycleOverview)
 	mux.HandleFunc(p("/api/logging-history"), handler.LoggingHistory)
@@ -86,6 +95,7 @@ func NewServer(port int, handler *Handler, logger *slog.Logger, username, passwo
 	mux.HandleFunc(p("/api/push/vapid"), handler.PushVAPIDKey)
 	mux.HandleFunc(p("/api/push/subscribe"), handler.PushSubscribe)
 	mux.HandleFunc(p("/api/push/test"), handler.PushTest)
+	mux.HandleFunc(p("/api/desktop/test"), handler.DesktopTest)
 	mux.HandleFunc(p("/api/codex/profiles"), handler.CodexProfiles)
 	mux.HandleFunc(p("/api/codex/usage"), handler.CodexUsage)
 	mux.HandleFunc(p("/api/codex/accounts/usage"), handler.CodexAccountsUsage)
@@ -100,6 +110,14 @@ func NewServer(port int, handler *Handler, logger *slog.Logger, username, passwo
 	mux.HandleFunc(p("/api/api-integrations/current"), handler.APIIntegrationsCurrent)
 	mux.HandleFunc(p("/api/api-integrations/history"), handler.APIIntegrationsHistory)
 	mux.HandleFunc(p("/api/api-integrations/health"), handler.APIIntegrationsHealth)
+	mux.HandleFunc(p("/api/api-integrations/providers"), handler.APIIntegrationsProviders)
+	mux.HandleFunc(p("/api/api-integrations/pricing"), handler.APIIntegrationsPricing)
+	mux.HandleFunc(p("/api/api-integrations/breakdown"), handler.APIIntegrationsBreakdown)
+	mux.HandleFunc(p("/api/api-integrations/reliability"), handler.APIIntegrationsReliability)
+	mux.HandleFunc(p("/api/api-integrations/events"), handler.APIIntegrationsIngest)
+	mux.HandleFunc(p("/api/api-integrations/ingest-token"), handler.APIIntegrationsIngestToken)
+	mux.HandleFunc(p("/api/api-integrations/otlp/v1/traces"), handler.APIIntegrationsOTLPTraces)
+	mux.HandleFunc(p("/api/api-integrations/otlp/v1/metrics"), handler.APIIntegrationsOTLPMetrics)
 
 	// System alerts (in-dashboard notifications)
 	mux.HandleFunc(p("/api/alerts"), handler.SystemAlerts)
@@ -0,0 +1,390 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// settingFXRates stores the USD value of one unit of each non-USD currency.
+const settingFXRates = "fx_rates"
+
+// spendBaselineWindow is how far before the month the previous balance is
+// looked up, so the first snapshot of the month has something to compare to.
+const spendBaselineWindow = 24 * time.Hour
+
+// meteredSpendProviders are the providers whose spend is measured from
+// balance or credit snapshots.
+var meteredSpendProviders = []string{"deepseek", "moonshot", "openrouter"}
+
+// moonshotCurrency is the currency Moonshot balances are reported in.
+const moonshotCurrency = "CNY"
+
+// defaultFXRates are the USD rates used until the user saves their own.
+// They are approximations; Settings → Providers → Currency Rates overrides them.
+var defaultFXRates = map[string]float64{
+	"CNY": 0.14,
+	"EUR": 1.08,
+	"GBP": 1.27,
+	"JPY": 0.0067,
+}
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// parseFXRates validates the fx_rates settings value. Entries without a
+// positive rate are dropped so clearing a field falls back to the default.
+func parseFXRates(raw json.RawMessage) (map[string]float64, error) {
+	var in map[string]float64
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return nil, fmt.Errorf("invalid fx_rates value")
+	}
+	out := make(map[string]float64, len(in))
+	for code, rate := range in {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if !currencyCodePattern.MatchString(code) {
+			return nil, fmt.Errorf("invalid currency code: %s", code)
+		}
+		if rate <= 0 || code == "USD" {
+			continue
+		}
+		if rate > 1e6 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+			return nil, fmt.Errorf("rate for %s is out of range", code)
+		}
+		out[code] = rate
+	}
+	return out, nil
+}
+
+// loadFXRates returns the saved rates on top of the defaults, with USD at 1.
+func (h *Handler) loadFXRates() map[string]float64 {
+	rates := map[string]float64{"USD": 1}
+	for code, rate := range defaultFXRates {
+		rates[code] = rate
+	}
+	if h.store == nil {
+		return rates
+	}
+	raw, err := h.store.GetSetting(settingFXRates)
+	if err != nil || raw == "" {
+		return rates
+	}
+	var saved map[string]float64
+	if err := json.Unmarshal([]byte(raw), &saved); err != nil {
+		return rates
+	}
+	for code, rate := range saved {
+		if rate > 0 {
+			rates[code] = rate
+		}
+	}
+	return rates
+}
+
+// spendTabAvailable reports whether anything feeds the spend ledger: a
+// prepaid or credit-based provider, visible API integrations or a priced
+// subscription.
+func (h *Handler) spendTabAvailable(integrationsVisible bool) bool {
+	if integrationsVisible {
+		return true
+	}
+	for _, p := range meteredSpendProviders {
+		if h.config.HasProvider(p) {
+			return true
+		}
+	}
+	return len(h.loadSubscriptionCosts()) > 0
+}
+
+// spendSource is one line of the ledger: a subscription, a prepaid balance,
+// a credit account or an API integration.
+type spendSource struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"` // "subscription", "balance", "credits" or "integration"
+	Label     string    `json:"label"`
+	Currency  string    `json:"currency"`
+	Native    float64   `json:"nativeTotal"`
+	USD       float64   `json:"usdTotal"`
+	Projected float64   `json:"projectedUsd"`           // month total at the current pace
+	Converted bool      `json:"converted"`              // false when no rate exists for Currency
+	Daily     []float64 `json:"daily"`                  // USD per day, or native amounts when not converted
+	Estimated float64   `json:"estimatedUsd,omitempty"` // part of USD estimated from model prices
+}
+
+// spendMonth is the calendar month the ledger covers, split into local days.
+type spendMonth struct {
+	Start, End time.Time
+	Days       []time.Time // local midnight of each day
+	Elapsed    int         // days up to and including today
+}
+
+func newSpendMonth(month time.Time, loc *time.Location, now time.Time) spendMonth {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, loc)
+	m := spendMonth{Start: start, End: start.AddDate(0, 1, 0)}
+	for day := start; day.Before(m.End); day = day.AddDate(0, 0, 1) {
+		m.Days = append(m.Days, day)
+		if !day.After(now) {
+			m.Elapsed++
+		}
+	}
+	return m
+}
+
+// dayIndex returns the day of the month t falls on, or -1 outside the month.
+func (m spendMonth) dayIndex(t time.Time) int {
+	if t.Before(m.Start) || !t.Before(m.End) {
+		return -1
+	}
+	return sort.Search(len(m.Days), func(i int) bool { return m.Days[i].After(t) }) - 1
+}
+
+// project extends an amount spent so far to the whole month at the same
+// daily pace. Past and future months are returned unchanged.
+func (m spendMonth) project(spent float64) float64 {
+	if m.Elapsed > 0 && m.Elapsed < len(m.Days) {
+		return spent / float64(m.Elapsed) * float64(len(m.Days))
+	}
+	return spent
+}
+
+// spendSample is a balance or cumulative usage reading.
+type spendSample struct {
+	At       time.Time
+	Value    float64
+	Currency string
+}
+
+// spendFromSamples turns consecutive readings into daily spend. For balances
+// spend is each decrease and increases are top-ups; for cumulative usage it
+// is each increase and decreases are counter resets. A reading in a different
+// currency than the previous one is not compared.
+func spendFromSamples(m spendMonth, samples []spendSample, decreasing bool) []float64 {
+	daily := make([]float64, len(m.Days))
+	for i := 1; i < len(samples); i++ {
+		prev, cur := samples[i-1], samples[i]
+		idx := m.dayIndex(cur.At)
+		if idx < 0 || prev.Currency != cur.Currency {
+			continue
+		}
+		delta := cur.Value - prev.Value
+		if decreasing {
+			delta = -delta
+		}
+		if delta > 0 {
+			daily[idx] += delta
+		}
+	}
+	return daily
+}
+
+// SpendLedger handles GET /api/spend. It normalizes subscriptions, prepaid
+// balances, OpenRouter credits and API integration costs into per-day USD
+// amounts for one month (?month=YYYY-MM, default the current month).
+func (h *Handler) SpendLedger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	loc, err := h.heatmapLocation(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+	now := time.Now().In(loc)
+	month := now
+	if v := r.URL.Query().Get("month"); v != "" {
+		if month, err = time.ParseInLocation("2006-01", v, loc); err != nil {
+			respondError(w, http.StatusBadRequest, "month must be YYYY-MM")
+			return
+		}
+	}
+	respondJSON(w, http.StatusOK, h.buildSpendLedger(newSpendMonth(month, loc, now)))
+}
+
+func (h *Handler) buildSpendLedger(m spendMonth) map[string]interface{} {
+	sources := h.spendSources(m)
+	days := make([]string, len(m.Days))
+	dailyTotals := make([]float64, len(m.Days))
+	var total, projected float64
+	missing := []string{}
+	for i, day := range m.Days {
+		days[i] = day.Format("2006-01-02")
+	}
+	for _, src := range sources {
+		if !src.Converted {
+			missing = append(missing, src.Currency)
+			continue
+		}
+		for i, v := range src.Daily {
+			dailyTotals[i] += v
+		}
+		total += src.USD
+		projected += src.Projected
+	}
+	if sources == nil {
+		sources = []spendSource{}
+	}
+
+	return map[string]interface{}{
+		"month":        m.Start.Format("2006-01"),
+		"timezone":     m.Start.Location().String(),
+		"days":         days,
+		"elapsedDays":  m.Elapsed,
+		"sources":      sources,
+		"dailyTotals":  dailyTotals,
+		"totalUsd":     total,
+		"projectedUsd": projected,
+		"fxRates":      h.loadFXRates(),
+		"missingRates": missing,
+	}
+}
+
+// spendSources collects every ledger source with spend in the month.
+func (h *Handler) spendSources(m spendMonth) []spendSource {
+	rates := h.loadFXRates()
+	var sources []spendSource
+	// add converts a source to USD. Subscriptions pass their full-month
+	// charges as planned; usage sources are projected at the month's pace.
+	add := func(id, kind, label, currency string, daily, planned []float64) {
+		src := spendSource{ID: id, Kind: kind, Label: label, Currency: currency, Daily: daily}
+		for _, v := range daily {
+			src.Native += v
+		}
+		if src.Native == 0 && planned == nil {
+			return
+		}
+		projected := m.project(src.Native)
+		if planned != nil {
+			projected = 0
+			for _, v := range planned {
+				projected += v
+			}
+		}
+		rate, ok := rates[currency]
+		src.Converted = ok
+		if ok {
+			for i := range src.Daily {
+				src.Daily[i] *= rate
+			}
+			src.USD = src.Native * rate
+			src.Projected = projected * rate
+		}
+		sources = append(sources, src)
+	}
+
+	costs := h.loadSubscriptionCosts()
+	for _, key := range sortedSubscriptionKeys(costs) {
+		planned := subscriptionDaily(m, costs[key])
+		daily := make([]float64, len(planned))
+		copy(daily[:m.Elapsed], planned[:m.Elapsed])
+		add("subscription:"+key, "subscription", subscriptionLedgerLabel(key), costs[key].Currency, daily, planned)
+	}
+
+	from, to := m.Start.Add(-spendBaselineWindow), m.End
+	if h.config != nil && h.config.HasProvider("deepseek") {
+		if snaps, err := h.store.QueryDeepSeekRange(from, to, quotaSnapshotLimit); err != nil {
+			h.logger.Error("Failed to query DeepSeek spend", "error", err)
+		} else if len(snaps) > 0 {
+			samples := make([]spendSample, len(snaps))
+			for i, s := range snaps {
+				samples[i] = spendSample{At: s.CapturedAt, Value: s.TotalBalance, Currency: strings.ToUpper(s.Currency)}
+			}
+			add("deepseek", "balance", "DeepSeek", samples[len(samples)-1].Currency, spendFromSamples(m, samples, true), nil)
+		}
+	}
+	if h.config != nil && h.config.HasProvider("moonshot") {
+		if snaps, err := h.store.QueryMoonshotRange(from, to, quotaSnapshotLimit); err != nil {
+			h.logger.Error("Failed to query Moonshot spend", "error", err)
+		} else {
+			samples := make([]spendSample, len(snaps))
+			for i, s := range snaps {
+				samples[i] = spendSample{At: s.CapturedAt, Value: s.AvailableBalance, Currency: moonshotCurrency}
+			}
+			add("moonshot", "balance", "Moonshot", moonshotCurrency, spendFromSamples(m, samples, true), nil)
+		}
+	}
+	if h.config != nil && h.config.HasProvider("openrouter") {
+		if snaps, err := h.store.QueryOpenRouterRange(from, to, quotaSnapshotLimit); err != nil {
+			h.logger.Error("Failed to query OpenRouter spend", "error", err)
+		} else {
+			samples := make([]spendSample, len(snaps))
+			for i, s := range snaps {
+				samples[i] = spendSample{At: s.CapturedAt, Value: s.Usage, Currency: "USD"}
+			}
+			add("openrouter", "credits", "OpenRouter", "USD", spendFromSamples(m, samples, false), nil)
+		}
+	}
+
+	// Hourly buckets keep day boundaries right in any whole-hour timezone.
+	buckets, err := h.store.QueryAPIIntegrationUsageBuckets(m.Start, m.End, time.Hour)
+	if err != nil {
+		h.logger.Error("Failed to query API integration spend", "error", err)
+	}
+	// Events without a reported cost count at their estimated cost.
+	integrations := map[string][]float64{}
+	estimated := map[string]float64{}
+	var names []string
+	for _, b := range buckets {
+		idx := m.dayIndex(b.BucketStart)
+		cost := b.TotalCostUSD + b.EstimatedCostUSD
+		if idx < 0 || cost == 0 {
+			continue
+		}
+		if _, ok := integrations[b.IntegrationName]; !ok {
+			integrations[b.IntegrationName] = make([]float64, len(m.Days))
+			names = append(names, b.IntegrationName)
+		}
+		integrations[b.IntegrationName][idx] += cost
+		estimated[b.IntegrationName] += b.EstimatedCostUSD
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add("integration:"+name, "integration", name, "USD", integrations[name], nil)
+		sources[len(sources)-1].Estimated = estimated[name]
+	}
+	return sources
+}
+
+// subscriptionDaily spreads each billing period's price evenly over the
+// days of that period, for every day of the month.
+func subscriptionDaily(m spendMonth, cost subscriptionCost) []float64 {
+	billingDay := cost.BillingDay
+	if billingDay <= 0 {
+		billingDay = 1
+	}
+	daily := make([]float64, len(m.Days))
+	for i, day := range m.Days {
+		period := billingPeriods(day.Add(12*time.Hour), day.Location(), billingDay, 1)[0]
+		days := math.Round(period[1].Sub(period[0]).Hours() / 24)
+		daily[i] = cost.MonthlyPrice / days
+	}
+	return daily
+}
+
+func sortedSubscriptionKeys(costs map[string]subscriptionCost) []string {
+	keys := make([]string, 0, len(costs))
+	for key := range costs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// subscriptionLedgerLabel names a subscription key, adding the account
+// number for multi-account providers ("Codex #2").
+func subscriptionLedgerLabel(key string) string {
+	base, account, found := strings.Cut(key, ":")
+	label := defaultProviderTabLabel(base)
+	if found {
+		label += " #" + account
+	}
+	return label + " subscription"
+}
@@ -0,0 +1,143 @@
+package web
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/api"
+	"github.com/onllm-dev/onwatch/v2/internal/config"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+func TestParseFXRates(t *testing.T) {
+	t.Parallel()
+	rates, err := parseFXRates(json.RawMessage(`{"cny": 0.15, "INR": 0.012, "EUR": 0, "USD": 2}`))
+	if err != nil {
+		t.Fatalf("parseFXRates: %v", err)
+	}
+	if len(rates) != 2 || rates["CNY"] != 0.15 || rates["INR"] != 0.012 {
+		t.Errorf("rates = %v, want CNY and INR only", rates)
+	}
+	for _, raw := range []string{`{"YUAN": 0.14}`, `{"CNY": 1e9}`, `[]`} {
+		if _, err := parseFXRates(json.RawMessage(raw)); err == nil {
+			t.Errorf("parseFXRates(%s) should fail", raw)
+		}
+	}
+}
+
+func TestSpendFromSamples(t *testing.T) {
+	t.Parallel()
+	m := newSpendMonth(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.UTC, time.Date(2026, 4, 2, 0, 0, 0, 0, time.UTC))
+	at := func(day, hour int) time.Time { return time.Date(2026, 3, day, hour, 0, 0, 0, time.UTC) }
+	balances := []spendSample{
+		{At: at(1, 0).Add(-time.Hour), Value: 100, Currency: "CNY"}, // baseline from February
+		{At: at(1, 6), Value: 90, Currency: "CNY"},
+		{At: at(1, 18), Value: 85, Currency: "CNY"},
+		{At: at(2, 6), Value: 185, Currency: "CNY"}, // top-up
+		{At: at(2, 12), Value: 180, Currency: "CNY"},
+		{At: at(3, 12), Value: 20, Currency: "USD"}, // currency switch is not spend
+	}
+	daily := spendFromSamples(m, balances, true)
+	if daily[0] != 15 || daily[1] != 5 || daily[2] != 0 {
+		t.Errorf("balance spend = %v, want 15, 5, 0", daily[:3])
+	}
+
+	usage := []spendSample{
+		{At: at(1, 6), Value: 1, Currency: "USD"},
+		{At: at(1, 18), Value: 3.5, Currency: "USD"},
+		{At: at(2, 6), Value: 0, Currency: "USD"}, // counter reset
+		{At: at(2, 12), Value: 1, Currency: "USD"},
+	}
+	daily = spendFromSamples(m, usage, false)
+	if daily[0] != 2.5 || daily[1] != 1 {
+		t.Errorf("credit spend = %v, want 2.5, 1", daily[:2])
+	}
+}
+
+func TestHandler_SpendLedger(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	day := func(d, hour int) time.Time { return time.Date(2026, 3, d, hour, 0, 0, 0, time.UTC) }
+	for _, snap := range []struct {
+		at      time.Time
+		balance float64
+	}{{day(1, 0).Add(-2 * time.Hour), 50}, {day(1, 12), 43}, {day(2, 12), 40}} {
+		if _, err := s.InsertDeepSeekSnapshot(&api.DeepSeekSnapshot{CapturedAt: snap.at, IsAvailable: true, Currency: "CNY", TotalBalance: snap.balance}); err != nil {
+			t.Fatalf("InsertDeepSeekSnapshot: %v", err)
+		}
+	}
+	insertAPIIntegrationEventForTest(t, s, `{"ts":"2026-03-02T09:00:00Z","integration":"notes","provider":"anthropic","model":"claude-3-7-sonnet","prompt_tokens":10,"completion_tokens":5,"cost_usd":0.5}`, "/tmp/api-integrations/notes.jsonl")
+
+	h := NewHandler(s, nil, nil, nil, &config.Config{DeepSeekAPIKey: "sk-test", APIIntegrationsEnabled: true})
+	req := httptest.NewRequest(http.MethodPut, "/api/settings",
+		strings.NewReader(`{"subscription_costs": {"anthropic": {"monthly_price": 31}}, "fx_rates": {"CNY": 0.1}}`))
+	rr := httptest.NewRecorder()
+	h.UpdateSettings(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("UpdateSettings: %d %s", rr.Code, rr.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/spend?month=2026-03&tz=UTC", nil)
+	rr = httptest.NewRecorder()
+	h.SpendLedger(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Days         []string      `json:"days"`
+		Sources      []spendSource `json:"sources"`
+		DailyTotals  []float64     `json:"dailyTotals"`
+		TotalUSD     float64       `json:"totalUsd"`
+		ProjectedUSD float64       `json:"projectedUsd"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	if len(resp.Days) != 31 || len(resp.Sources) != 3 {
+		t.Fatalf("days = %d, sources = %+v, want 31 days and 3 sources", len(resp.Days), resp.Sources)
+	}
+	byID := map[string]spendSource{}
+	for _, src := range resp.Sources {
+		byID[src.ID] = src
+	}
+	if src := byID["deepseek"]; src.Native != 10 || !src.Converted || math.Abs(src.USD-1) > 1e-9 {
+		t.Errorf("deepseek = %+v, want 10 CNY converted to 1 USD", src)
+	}
+	if src := byID["subscription:anthropic"]; math.Abs(src.USD-31) > 1e-9 || math.Abs(src.Daily[0]-1) > 1e-9 {
+		t.Errorf("subscription = %+v, want 31 USD spread at 1 USD per day", src)
+	}
+	if src := byID["integration:notes"]; src.USD != 0.5 || src.Daily[1] != 0.5 {
+		t.Errorf("integration = %+v, want 0.5 USD on March 2", src)
+	}
+	// Day 1: 0.7 DeepSeek + 1 subscription; day 2: 0.3 + 1 + 0.5.
+	if math.Abs(resp.DailyTotals[0]-1.7) > 1e-9 || math.Abs(resp.DailyTotals[1]-1.8) > 1e-9 {
+		t.Errorf("daily totals = %v, want 1.7, 1.8", resp.DailyTotals[:2])
+	}
+	if math.Abs(resp.TotalUSD-32.5) > 1e-9 || math.Abs(resp.ProjectedUSD-32.5) > 1e-9 {
+		t.Errorf("total = %.2f (projected %.2f), want 32.5 for a complete month", resp.TotalUSD, resp.ProjectedUSD)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/?provider=spend", nil)
+	rr = httptest.NewRecorder()
+	h.Dashboard(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `id="spend-dashboard"`) {
+		t.Errorf("spend tab: status %d, want the spend dashboard rendered", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/spend?month=March", nil)
+	rr = httptest.NewRecorder()
+	h.SpendLedger(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("invalid month: expected 400, got %d", rr.Code)
+	}
+}
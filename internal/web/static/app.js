@@ -46,6 +46,7 @@ async function authFetch(url, options) {
 
 // ── Provider State ──
 function getCurrentProvider() {
+  if (document.getElementById('spend-dashboard')) return 'spend';
   const bothView = document.getElementById('both-view') || document.getElementById('all-providers-container');
   if (bothView) return 'both';
   const apiIntegrationsDashboard = document.getElementById('api-integrations-dashboard');
@@ -160,6 +161,22 @@ const State = {
   hiddenInsights: new Set(),
   // Insights time range (1d / 7d / 30d)
   insightsRange: '7d',
+  // Usage heatmap range (7d / 30d / 90d) and selected quota
+  heatmapRange: '30d',
+  heatmapQuota: null,
+  heatmapData: null,
+  // Cycle comparison: previous cycles overlaid and selected quota
+  cycleCompareCycles: 4,
+  cycleCompareQuota: null,
+  cycleCompareChart: null,
+  cycleCompareData: null,
+  // Subscription value: billing periods shown
+  subscriptionValuePeriods: 3,
+  subscriptionValueData: null,
+  // Spend ledger: selected month (YYYY-MM, empty = current) and chart
+  spendMonth: '',
+  spendChart: null,
+  spendData: null,
   // Anthropic session column names (sorted, max 3 - mirrors backend positional mapping)
   anthropicSessionQuotas: [],
   // Cycle Overview state
@@ -199,6 +216,13 @@ const State = {
   apiIntegrationsVisibility: { dashboard: true },
   apiIntegrationsSelectedMetric: 'tokenPerCall',
   apiIntegrationsActiveWindow: '8d',
+  apiIntegrationsBreakdown: null,
+  apiIntegrationsBreakdownGroupBy: ['integration'],
+  apiIntegrationsBreakdownRange: '7d',
+  apiIntegrationsBreakdownRequestSeq: 0,
+  apiIntegrationsReliability: null,
+  apiIntegrationsReliabilityRange: '24h',
+  apiIntegrationsReliabilityRequestSeq: 0,
 };
 
 // ── Persistence ──
@@ -399,6 +423,9 @@ function refreshAll() {
   if (shouldShowCyclesTable()) tasks.push(fetchCycles());
   if (shouldShowSessionsTable()) tasks.push(fetchSessions());
   if (shouldShowOverviewTable()) tasks.push(fetchCycleOverview());
+  if (document.getElementById('heatmap-section')) tasks.push(fetchHeatmap());
+  if (document.getElementById('cycle-compare-section')) tasks.push(fetchCycleCompare());
+  if (document.getElementById('subscription-value-section')) tasks.push(fetchSubscriptionValue());
   Promise.all(tasks).finally(() => {
     if (refreshBtn) setTimeout(() => refreshBtn.classList.remove('spinning'), 600);
   });
@@ -1320,6 +1347,41 @@ function promoTagHTML() {
   return `<a class="promo-tag-inline ${cls}" href="https://www.reddit.com/r/ClaudeAI/comments/1s4idaq/update_on_session_limits/" target="_blank" rel="noopener noreferrer" onclick="event.stopPropagation()" title="${escapeHTML(_anthropicPromo.description)}">${text}</a>`;
 }
 
+// Draws the budget-line marker and "ahead/behind pace" line for weekly and
+// monthly windows. Quotas without pacing data have both removed.
+function applyQuotaPacing(progressEl, quota) {
+  const wrapper = progressEl ? progressEl.closest('.progress-wrapper') : null;
+  if (!wrapper) return;
+  let marker = wrapper.querySelector('.pace-marker');
+  let label = wrapper.querySelector('.pace-label');
+  const pacing = quota.pacing;
+  if (!pacing) {
+    if (marker) marker.remove();
+    if (label) label.remove();
+    return;
+  }
+  if (!marker) {
+    marker = document.createElement('span');
+    marker.className = 'pace-marker';
+    wrapper.appendChild(marker);
+  }
+  if (!label) {
+    label = document.createElement('div');
+    label.className = 'pace-label';
+    wrapper.appendChild(label);
+  }
+  const expected = Math.max(0, Math.min(100, pacing.expectedUtil || 0));
+  marker.style.left = `${quota.cardLabel === 'Remaining' ? 100 - expected : expected}%`;
+  marker.title = `Budget line: ${expected.toFixed(0)}% by now`;
+
+  const delta = Math.abs(pacing.deltaPercent || 0).toFixed(0);
+  let paceText = 'On pace';
+  if (pacing.status === 'ahead') paceText = `${delta}% ahead of pace`;
+  else if (pacing.status === 'behind') paceText = `${delta}% behind pace`;
+  label.textContent = `${paceText} · ${(pacing.dailyAllowance || 0).toFixed(1)}%/day to last until reset`;
+  label.dataset.pace = pacing.status;
+}
+
 function renderAnthropicQuotaCards(quotas, containerId) {
   const container = document.getElementById(containerId);
   if (!container) return;
@@ -1368,6 +1430,8 @@ function renderAnthropicQuotaCards(quotas, containerId) {
     </article>`;
   }).join('');
 
+  quotas.forEach(q => applyQuotaPacing(document.getElementById(`progress-anth-${q.name}`), q));
+
   // Re-attach modal click handlers for new cards
   container.querySelectorAll('.quota-card[role="button"]').forEach(card => {
     const handler = () => {
@@ -1397,7 +1461,8 @@ function updateAnthropicCard(quota) {
     displayName: quota.displayName,
     source: quota.source || '',
     ageSeconds: quota.ageSeconds || 0,
-    isStale: quota.isStale || false
+    isStale: quota.isStale || false,
+    pacing: quota.pacing || null
   };
 
   const progressEl = document.getElementById(`progress-anth-${quota.name}`);
@@ -1416,6 +1481,7 @@ function updateAnthropicCard(quota) {
     const bar = progressEl.parentElement;
     if (bar) bar.setAttribute('aria-valuenow', Math.round(displayPct));
   }
+  applyQuotaPacing(progressEl, quota);
   if (percentEl) {
     const oldVal = prev ? prev.percent : 0;
     if (Math.abs(oldVal - displayPct) > 0.2) {
@@ -1441,6 +1507,44 @@ function updateAnthropicCard(quota) {
   }
 }
 
+// Modal KPI tile showing pace against the budget line and the daily allowance.
+function pacingKpiHTML(pacing) {
+  if (!pacing) return '';
+  const delta = Math.abs(pacing.deltaPercent || 0).toFixed(0);
+  let value = 'On pace';
+  if (pacing.status === 'ahead') value = `${delta}% ahead`;
+  else if (pacing.status === 'behind') value = `${delta}% behind`;
+  return `
+      <div class="modal-kpi">
+        <div class="modal-kpi-value">${value}</div>
+        <div class="modal-kpi-label">Pace \u00B7 ${(pacing.dailyAllowance || 0).toFixed(1)}%/day left</div>
+      </div>`;
+}
+
+// Dashed budget line for modal history charts: the even spread from 0% at
+// window start to 100% at reset, drawn across the charted time span.
+function pacingBudgetDataset(pacing, points, colors) {
+  if (!pacing || points.length === 0) return null;
+  const start = new Date(pacing.windowStart).getTime();
+  const span = new Date(pacing.resetsAt).getTime() - start;
+  if (!(span > 0)) return null;
+  const times = points.map(p => p.x.getTime());
+  const from = Math.max(Math.min(...times), start);
+  const to = Math.max(...times);
+  if (to <= from) return null;
+  const budgetAt = t => Math.max(0, Math.min(100, (t - start) / span * 100));
+  return {
+    label: 'Budget line',
+    data: [{ x: new Date(from), y: budgetAt(from) }, { x: new Date(to), y: budgetAt(to) }],
+    borderColor: colors.text,
+    borderDash: [6, 4],
+    borderWidth: 1.5,
+    pointRadius: 0,
+    fill: false,
+    tension: 0
+  };
+}
+
 // Anthropic quota detail modal
 function openAnthropicModal(quotaName, providerOverride) {
   const key = `anth-${quotaName}`;
@@ -1477,6 +1581,7 @@ function openAnthropicModal(quotaName, providerOverride) {
         <div class="modal-kpi-value">${timeLeft}</div>
         <div class="modal-kpi-label">Until Reset</div>
       </div>
+      ${pacingKpiHTML(data.pacing)}
       ${sourceKpi}
     </div>
     <h3 class="modal-section-title">Usage History</h3>
@@ -1520,6 +1625,8 @@ async function loadAnthropicModalChart(quotaName) {
     const processed = processDataWithGaps(rawData, range);
     const maxVal = Math.max(...data.map(d => d[quotaName] || 0), 0);
     let yMax = maxVal <= 0 ? 10 : maxVal < 5 ? 10 : Math.min(Math.max(Math.ceil((maxVal * 1.2) / 5) * 5, 10), 100);
+    const budget = pacingBudgetDataset((State.currentQuotas[`anth-${quotaName}`] || {}).pacing, rawData, colors);
+    if (budget) yMax = Math.min(100, Math.max(yMax, Math.ceil(budget.data[1].y / 5) * 5));
 
     State.modalChart = new Chart(ctx, {
       type: 'line',
@@ -1536,7 +1643,7 @@ async function loadAnthropicModalChart(quotaName) {
           pointHoverRadius: 5,
           spanGaps: true,
           segment: getSegmentStyle(processed.gapSegments, c.border)
-        }; })()]
+        }; })()].concat(budget ? [budget] : [])
       },
       options: {
         responsive: true, maintainAspectRatio: false,
@@ -1639,6 +1746,8 @@ function renderCopilotQuotaCards(quotas, containerId) {
     </article>`;
   }).join('');
 
+  quotas.forEach(q => applyQuotaPacing(document.getElementById(`progress-copilot-${q.name}`), q));
+
   // Re-attach modal click handlers for new cards
   container.querySelectorAll('.quota-card[role="button"]').forEach(card => {
     const handler = () => {
@@ -1687,6 +1796,7 @@ function updateCopilotCard(quota) {
     const bar = progressEl.parentElement;
     if (bar) bar.setAttribute('aria-valuenow', quota.unlimited ? 0 : Math.round(rawPct));
   }
+  applyQuotaPacing(progressEl, quota);
   if (percentEl) {
     const oldVal = prev ? prev.percent : 0;
     if (!quota.unlimited && Math.abs(oldVal - rawPct) > 0.2) {
@@ -2355,7 +2465,7 @@ function renderOpenRouterCard(credits, containerId) {
         <svg class="status-icon" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="${statusCfg.icon}"/></svg>
         ${statusCfg.label}
       </span>
-      <span class="reset-time" id="reset-openrouter-credits">${hasLimit ? 'Remaining: ' + remainStr : ''}</span>
+      <span class="reset-time" id="reset-openrouter-credits">${hasLimit ? 'Remaining: ' + remainStr + runwaySuffix(credits.runway) : ''}</span>
     </footer>
   </article>`;
 }
@@ -2403,7 +2513,14 @@ function updateOpenRouterCard(credits) {
     statusEl.setAttribute('data-status', status);
     statusEl.innerHTML = `<svg class="status-icon" viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="${config.icon}"/></svg>${config.label}`;
   }
-  if (resetEl) resetEl.textContent = hasLimit ? 'Remaining: ' + remainStr : '';
+  if (resetEl) resetEl.textContent = hasLimit ? 'Remaining: ' + remainStr + runwaySuffix(credits.runway) : '';
+}
+
+// " · 12.5d left" when a prepaid balance has a runway forecast, else "".
+function runwaySuffix(runway) {
+  if (!runway || runway.daysLeft == null) return '';
+  if (runway.daysLeft <= 0) return ' \u00B7 at floor';
+  return ` \u00B7 ${runway.daysLeft < 1 ? Math.round(runway.daysLeft * 24) + 'h' : runway.daysLeft.toFixed(1) + 'd'} left`;
 }
 
 function getQuotaStatus(percent) {
@@ -2648,6 +2765,8 @@ function renderCodexQuotaCards(quotas, containerId, planType) {
     </article>`;
   }).join('');
 
+  visibleQuotas.forEach(q => applyQuotaPacing(document.getElementById(`progress-codex-${q.name}`), q));
+
   container.querySelectorAll('.quota-card[role="button"]').forEach(card => {
     const handler = () => {
       const providerCol = card.closest('.provider-column');
@@ -2729,6 +2848,7 @@ function renderCodexQuotaCardsForAccount(quotas, container, accountName, planTyp
   }).join('');
 
   container.appendChild(cardsDiv);
+  visibleQuotas.forEach(q => applyQuotaPacing(cardsDiv.querySelector(`#progress-codex-${safeAccountId}-${q.name}`), q));
 }
 
 function renderCodexAccountSections(accounts) {
@@ -2770,7 +2890,8 @@ function updateCodexCard(quota) {
     timeUntilResetSeconds: quota.timeUntilResetSeconds || 0,
     cardLabel,
     name: quota.name,
-    displayName: quota.displayName
+    displayName: quota.displayName,
+    pacing: quota.pacing || null
   };
 
   const progressEl = document.getElementById(`progress-codex-${quota.name}`);
@@ -2786,6 +2907,7 @@ function updateCodexCard(quota) {
     progressEl.style.width = `${utilPct}%`;
     progressEl.setAttribute('data-status', status);
   }
+  applyQuotaPacing(progressEl, quota);
   if (percentEl) {
     const oldVal = prev ? prev.percent : 0;
     if (Math.abs(oldVal - cardPercent) > 0.2) {
@@ -2842,6 +2964,7 @@ function openCodexModal(quotaName, providerOverride) {
         <div class="modal-kpi-value">${timeLeft}</div>
         <div class="modal-kpi-label">Until Reset</div>
       </div>
+      ${pacingKpiHTML(data.pacing)}
     </div>
     <h3 class="modal-section-title">Usage History</h3>
     <div class="modal-chart-container">
@@ -2882,7 +3005,9 @@ async function loadCodexModalChart(quotaName) {
     const rawData = data.map(d => ({ x: new Date(d.capturedAt), y: d[quotaName] || 0 }));
     const processed = processDataWithGaps(rawData, range);
     const maxVal = Math.max(...data.map(d => d[quotaName] || 0), 0);
-    const yMax = maxVal <= 0 ? 10 : maxVal < 5 ? 10 : Math.min(Math.max(Math.ceil((maxVal * 1.2) / 5) * 5, 10), 100);
+    let yMax = maxVal <= 0 ? 10 : maxVal < 5 ? 10 : Math.min(Math.max(Math.ceil((maxVal * 1.2) / 5) * 5, 10), 100);
+    const budget = pacingBudgetDataset((State.currentQuotas[`codex-${quotaName}`] || {}).pacing, rawData, colors);
+    if (budget) yMax = Math.min(100, Math.max(yMax, Math.ceil(budget.data[1].y / 5) * 5));
 
     State.modalChart = new Chart(ctx, {
       type: 'line',
@@ -2899,7 +3024,7 @@ async function loadCodexModalChart(quotaName) {
           pointHoverRadius: 5,
           spanGaps: true,
           segment: getSegmentStyle(processed.gapSegments, c.border)
-        }; })()]
+        }; })()].concat(budget ? [budget] : [])
       },
       options: {
         responsive: true, maintainAspectRatio: false,
@@ -3094,6 +3219,13 @@ function refreshTimezoneSensitiveText() {
   if (lastUpdated?.dataset.lastUpdatedAt) {
     setLastUpdated(lastUpdated.dataset.lastUpdatedAt);
   }
+  // Heatmap buckets are computed server-side in the selected timezone.
+  if (State.heatmapData) fetchHeatmap();
+  // So are spend days.
+  if (State.spendData) {
+    fetchSpend();
+    fetchBudgets();
+  }
 }
 
 function formatChartXAxisLabel(isoOrLabel, range) {
@@ -3721,6 +3853,7 @@ function renderKimiQuotaCards(quotas, containerId) {
       </footer>
     `;
     container.appendChild(card);
+    applyQuotaPacing(card.querySelector(`#progress-kimi-${name}`), q);
   });
 }
 
@@ -3750,6 +3883,7 @@ function updateKimiQuotaCards(quotas, containerId) {
     }
     const bar = fill ? fill.parentElement : null;
     if (bar) bar.setAttribute('aria-valuenow', Math.round(pct));
+    applyQuotaPacing(fill, q);
     const resetsAt = q.resets_at || q.resetsAt || '';
     const resetEl = document.getElementById('reset-kimi-' + name);
     if (resetEl) {
@@ -3802,6 +3936,8 @@ async function fetchCurrent() {
         renderAPIIntegrationsCards();
         renderAPIIntegrationsHealth();
         renderAPIIntegrationsInsights();
+        fetchAPIIntegrationsBreakdown();
+        fetchAPIIntegrationsReliability();
 
         setLastUpdated();
         const statusDot = document.getElementById('status-dot');
@@ -4585,9 +4721,9 @@ function renderAPIIntegrationsInsights() {
     }
   });
 
-  const totalProviders = new Set(entries.flatMap((entry) =>
-    (Array.isArray(entry.providers) ? entry.providers : []).map((provider) => provider.provider).filter(Boolean)
-  ));
+  const allProviders = entries.flatMap((entry) => (Array.isArray(entry.providers) ? entry.providers : []));
+  const totalProviders = new Set(allProviders.map((provider) => provider.provider).filter(Boolean));
+  const customProviders = new Set(allProviders.filter((provider) => provider.custom).map((provider) => provider.provider));
   const avgTokensPerCall = totals.requestCount > 0 ? totals.totalTokens / totals.requestCount : 0;
   const trendDelta = secondHalfTokens - firstHalfTokens;
   const trendPct = firstHalfTokens > 0 ? (trendDelta / firstHalfTokens) * 100 : 0;
@@ -4595,7 +4731,7 @@ function renderAPIIntegrationsInsights() {
 
   allTimeEl.innerHTML = [
     { label: 'Tracked Integrations', value: formatNumber(entries.length), sublabel: 'Integrations seen since records started' },
-    { label: 'Providers', value: formatNumber(totalProviders.size), sublabel: 'Distinct providers across all integrations' },
+    { label: 'Providers', value: formatNumber(totalProviders.size), sublabel: customProviders.size > 0 ? `Distinct providers across all integrations, ${formatNumber(customProviders.size)} custom` : 'Distinct providers across all integrations' },
     { label: 'Total Tokens', value: formatNumber(totals.totalTokens), sublabel: 'Accumulated token volume' },
     { label: 'Input Tokens', value: formatNumber(totals.inputTokens), sublabel: 'Prompt-side tokens across all time' },
     { label: 'Output Tokens', value: formatNumber(totals.outputTokens), sublabel: 'Completion-side tokens across all time' },
@@ -4658,6 +4794,570 @@ function renderInsightsRangePills() {
   header.appendChild(selector);
 }
 
+// ── Usage Heatmap (hour-of-day × day-of-week) ──
+
+const HEATMAP_PROVIDERS = new Set(['synthetic', 'zai', 'anthropic', 'codex', 'copilot', 'gemini', 'openrouter', 'cursor', 'grok', 'kimi']);
+const HEATMAP_DAYS = ['Mon', 'Tue', 'Wed', 'Thu', 'Fri', 'Sat', 'Sun'];
+
+function shouldShowHeatmap(provider = getCurrentProvider()) {
+  return HEATMAP_PROVIDERS.has(provider) && !isAccountsOverviewMode(provider);
+}
+
+function formatHeatmapHour(hour) {
+  return `${String(hour).padStart(2, '0')}:00`;
+}
+
+function initHeatmapControls() {
+  const selector = document.getElementById('heatmap-range-selector');
+  if (selector) {
+    selector.addEventListener('click', (e) => {
+      const btn = e.target.closest('[data-heatmap-range]');
+      if (!btn) return;
+      State.heatmapRange = btn.dataset.heatmapRange;
+      selector.querySelectorAll('.range-btn').forEach(b => b.classList.toggle('active', b === btn));
+      fetchHeatmap();
+    });
+  }
+  const select = document.getElementById('heatmap-quota-select');
+  if (select) {
+    select.addEventListener('change', () => {
+      State.heatmapQuota = select.value;
+      renderHeatmap();
+    });
+  }
+}
+
+async function fetchHeatmap() {
+  const section = document.getElementById('heatmap-section');
+  if (!section) return;
+  const provider = getCurrentProvider();
+  if (!shouldShowHeatmap(provider)) {
+    section.style.display = 'none';
+    return;
+  }
+  section.style.display = '';
+  const requestSeq = (State.heatmapRequestSeq || 0) + 1;
+  State.heatmapRequestSeq = requestSeq;
+  try {
+    const tz = encodeURIComponent(getEffectiveTimezone());
+    const res = await authFetch(`${API_BASE}/api/insights/heatmap?${providerParam()}&range=${State.heatmapRange}&tz=${tz}`);
+    if (!res.ok) throw new Error('Failed to fetch heatmap');
+    const data = await res.json();
+    if (State.heatmapRequestSeq !== requestSeq) return;
+    State.heatmapData = data;
+    renderHeatmap();
+  } catch (err) {
+    console.error('Heatmap fetch error:', err);
+    const grid = document.getElementById('heatmap-grid');
+    if (grid) grid.innerHTML = '<p class="insight-text">Unable to load heatmap.</p>';
+  }
+}
+
+function renderHeatmap() {
+  const data = State.heatmapData;
+  const grid = document.getElementById('heatmap-grid');
+  const criticalEl = document.getElementById('heatmap-critical');
+  const select = document.getElementById('heatmap-quota-select');
+  if (!data || !grid) return;
+
+  const quotas = data.quotas || [];
+  if (select) {
+    select.innerHTML = quotas.map(q => `<option value="${escapeHTML(q.name)}">${escapeHTML(q.label)}</option>`).join('');
+    select.style.display = quotas.length > 1 ? '' : 'none';
+  }
+  if (!quotas.some(q => q.name === State.heatmapQuota)) {
+    // Default to the quota that consumed the most over the range.
+    const busiest = quotas.reduce((best, q) => (!best || q.total > best.total ? q : best), null);
+    State.heatmapQuota = busiest ? busiest.name : null;
+  }
+  if (select && State.heatmapQuota) select.value = State.heatmapQuota;
+
+  const quota = quotas.find(q => q.name === State.heatmapQuota);
+  if (!quota || quota.total <= 0) {
+    grid.innerHTML = '<p class="insight-text">Not enough usage in this range to build a heatmap yet.</p>';
+  } else {
+    const max = Math.max(...quota.cells.flat());
+    let html = '<div class="heatmap-corner"></div>';
+    for (let hour = 0; hour < 24; hour++) {
+      html += `<div class="heatmap-hour">${hour % 3 === 0 ? String(hour).padStart(2, '0') : ''}</div>`;
+    }
+    quota.cells.forEach((row, day) => {
+      html += `<div class="heatmap-day">${HEATMAP_DAYS[day]}</div>`;
+      row.forEach((value, hour) => {
+        const pct = max > 0 ? Math.round((value / max) * 100) : 0;
+        const style = value > 0 ? ` style="--heat: ${Math.max(pct, 8)}%"` : '';
+        const title = `${HEATMAP_DAYS[day]} ${formatHeatmapHour(hour)} · ${value.toFixed(1)}% consumed`;
+        html += `<div class="heatmap-cell${value > 0 ? ' active' : ''}"${style} title="${title}"></div>`;
+      });
+    });
+    const hourTotals = Array.from({ length: 24 }, (_, h) => quota.cells.reduce((sum, row) => sum + row[h], 0));
+    const quietest = hourTotals
+      .map((total, hour) => ({ hour, total }))
+      .sort((a, b) => a.total - b.total || a.hour - b.hour)
+      .slice(0, 3)
+      .sort((a, b) => a.hour - b.hour)
+      .map(h => formatHeatmapHour(h.hour));
+    grid.innerHTML = `<div class="heatmap-matrix">${html}</div>
+      <p class="heatmap-legend">Peak: ${HEATMAP_DAYS[quota.peakDay]} ${formatHeatmapHour(quota.peakHour)} · Quietest hours: ${quietest.join(', ')} · ${escapeHTML(data.timezone)}</p>`;
+  }
+
+  if (criticalEl) {
+    const hours = data.criticalHours || [];
+    const threshold = Math.round(data.criticalThreshold || 95);
+    if (hours.length === 0) {
+      criticalEl.innerHTML = `<p class="insight-text">No quota crossed the critical threshold (${threshold}%) in this range.</p>`;
+    } else {
+      criticalEl.innerHTML = `<h4 class="heatmap-critical-title">Hours windows most often hit critical (${threshold}%)</h4>
+        <ul class="heatmap-critical-list">${hours.map(h =>
+          `<li><span class="heatmap-critical-hour">${formatHeatmapHour(h.hour)}</span> ${h.count}× · ${escapeHTML(h.quotas.join(', '))}</li>`
+        ).join('')}</ul>`;
+    }
+  }
+}
+
+// ── Cycle-over-cycle comparison ──
+
+function formatCycleOffset(hours) {
+  if (hours < 24) return `${Math.round(hours * 10) / 10}h`;
+  const days = Math.floor(hours / 24);
+  const rest = Math.round(hours - days * 24);
+  return rest > 0 ? `${days}d ${rest}h` : `${days}d`;
+}
+
+function initCycleCompareControls() {
+  const selector = document.getElementById('cycle-compare-selector');
+  if (selector) {
+    selector.addEventListener('click', (e) => {
+      const btn = e.target.closest('[data-compare-cycles]');
+      if (!btn) return;
+      State.cycleCompareCycles = parseInt(btn.dataset.compareCycles, 10) || 4;
+      selector.querySelectorAll('.range-btn').forEach(b => b.classList.toggle('active', b === btn));
+      fetchCycleCompare();
+    });
+  }
+  const select = document.getElementById('cycle-compare-quota-select');
+  if (select) {
+    select.addEventListener('change', () => {
+      State.cycleCompareQuota = select.value;
+      fetchCycleCompare();
+    });
+  }
+}
+
+async function fetchCycleCompare() {
+  const section = document.getElementById('cycle-compare-section');
+  if (!section) return;
+  const provider = getCurrentProvider();
+  if (!shouldShowHeatmap(provider)) {
+    section.style.display = 'none';
+    return;
+  }
+  section.style.display = '';
+  const requestSeq = (State.cycleCompareRequestSeq || 0) + 1;
+  State.cycleCompareRequestSeq = requestSeq;
+  try {
+    const quota = State.cycleCompareQuota ? `&quota=${encodeURIComponent(State.cycleCompareQuota)}` : '';
+    const res = await authFetch(`${API_BASE}/api/cycles/compare?${providerParam()}&cycles=${State.cycleCompareCycles}${quota}`);
+    if (!res.ok) throw new Error('Failed to fetch cycle comparison');
+    const data = await res.json();
+    if (State.cycleCompareRequestSeq !== requestSeq) return;
+    State.cycleCompareData = data;
+    renderCycleCompare(data);
+  } catch (err) {
+    console.error('Cycle comparison fetch error:', err);
+    const summary = document.getElementById('cycle-compare-summary');
+    if (summary) summary.textContent = 'Unable to load cycle comparison.';
+  }
+}
+
+function renderCycleCompare(data) {
+  const summary = document.getElementById('cycle-compare-summary');
+  const select = document.getElementById('cycle-compare-quota-select');
+  const canvas = document.getElementById('cycle-compare-chart');
+  if (!data || !summary || !canvas) return;
+
+  const quotas = data.quotas || [];
+  State.cycleCompareQuota = data.quota || null;
+  if (select) {
+    select.innerHTML = quotas.map(q => `<option value="${escapeHTML(q.name)}">${escapeHTML(q.label)}</option>`).join('');
+    select.style.display = quotas.length > 1 ? '' : 'none';
+    if (State.cycleCompareQuota) select.value = State.cycleCompareQuota;
+  }
+
+  const previous = data.previous || [];
+  const cmp = data.comparison;
+  if (cmp) {
+    const delta = cmp.delta;
+    const cls = delta > 1 ? 'heavier' : delta < -1 ? 'lighter' : '';
+    const verdict = delta > 1 ? 'heavier than usual' : delta < -1 ? 'lighter than usual' : 'in line with usual';
+    summary.innerHTML = `${formatCycleOffset(cmp.offsetHours)} into this cycle: ${cmp.current.toFixed(1)}% used vs a typical ${cmp.median.toFixed(1)}% across ${cmp.cycles} previous cycle${cmp.cycles === 1 ? '' : 's'} · <span class="cycle-compare-delta ${cls}">${delta >= 0 ? '+' : ''}${delta.toFixed(1)} pts, ${verdict}</span>`;
+  } else if (previous.length === 0) {
+    summary.textContent = 'No completed cycles yet. The comparison appears once this quota has reset at least once.';
+  } else {
+    summary.textContent = 'Waiting for data in the current cycle.';
+  }
+
+  if (State.cycleCompareChart) {
+    State.cycleCompareChart.destroy();
+    State.cycleCompareChart = null;
+  }
+  const offsets = data.offsets || [];
+  if (offsets.length === 0) return;
+
+  const colors = getThemeColors();
+  const style = getComputedStyle(document.documentElement);
+  const accent = style.getPropertyValue('--accent-teal').trim() || '#0D9488';
+  const band = style.getPropertyValue('--accent-teal-muted').trim() || 'rgba(13, 148, 136, 0.1)';
+  const points = values => (values || []).map((v, i) => ({ x: offsets[i], y: v }));
+  const pct = data.percentiles || {};
+
+  const datasets = [
+    { label: 'p25', data: points(pct.p25), borderWidth: 0, pointRadius: 0, fill: false, spanGaps: false },
+    { label: 'p25–p75', data: points(pct.p75), borderWidth: 0, pointRadius: 0, backgroundColor: band, fill: '-1', spanGaps: false },
+    { label: 'Median', data: points(pct.p50), borderColor: colors.text, borderDash: [6, 4], borderWidth: 1.5, pointRadius: 0, fill: false },
+  ];
+  previous.forEach(c => {
+    datasets.push({
+      label: `Cycle from ${formatDateTime(c.start)}`,
+      data: points(c.values),
+      borderColor: colors.grid,
+      borderWidth: 1,
+      pointRadius: 0,
+      fill: false,
+      isPreviousCycle: true,
+    });
+  });
+  if (data.current) {
+    datasets.push({ label: 'Current cycle', data: points(data.current.values), borderColor: accent, borderWidth: 2.5, pointRadius: 0, fill: false });
+  }
+
+  const yMax = Math.max(100, ...datasets.flatMap(d => d.data.map(p => p.y || 0)));
+  State.cycleCompareChart = new Chart(canvas, {
+    type: 'line',
+    data: { datasets },
+    options: {
+      responsive: true,
+      maintainAspectRatio: false,
+      interaction: { mode: 'index', intersect: false },
+      plugins: {
+        legend: {
+          labels: {
+            color: colors.text, usePointStyle: true, boxWidth: 8,
+            filter: item => item.text !== 'p25' && !datasets[item.datasetIndex].isPreviousCycle
+          }
+        },
+        tooltip: {
+          backgroundColor: colors.surfaceContainer || '#1E1E1E',
+          titleColor: colors.onSurface || '#E6E1E5',
+          bodyColor: colors.text || '#CAC4D0',
+          borderColor: colors.outline || '#938F99',
+          borderWidth: 1, padding: 12,
+          filter: item => !datasets[item.datasetIndex].isPreviousCycle,
+          callbacks: {
+            title: items => items.length ? `${formatCycleOffset(items[0].parsed.x)} into cycle` : '',
+            label: ctx => ctx.parsed.y != null ? `${ctx.dataset.label === 'p25–p75' ? 'p75' : ctx.dataset.label}: ${ctx.parsed.y.toFixed(1)}%` : null
+          }
+        }
+      },
+      scales: {
+        x: {
+          type: 'linear',
+          min: 0,
+          max: offsets[offsets.length - 1],
+          grid: { color: colors.grid, drawBorder: false },
+          ticks: { color: colors.text, maxTicksLimit: 8, callback: v => formatCycleOffset(v) }
+        },
+        y: { grid: { color: colors.grid, drawBorder: false }, ticks: { color: colors.text, callback: v => v + '%' }, min: 0, max: Math.ceil(yMax / 10) * 10 }
+      }
+    }
+  });
+}
+
+// ── Subscription value ──
+
+const VALUE_SUGGESTION_LABELS = {
+  upgrade: 'Upgrade',
+  downgrade: 'Downgrade',
+  keep: 'Keep',
+  insufficient_data: 'Not enough data',
+};
+
+function formatSubscriptionPrice(amount, currency) {
+  try {
+    return new Intl.NumberFormat(undefined, { style: 'currency', currency: currency || 'USD' }).format(amount || 0);
+  } catch (e) {
+    return `${(amount || 0).toFixed(2)} ${currency || ''}`.trim();
+  }
+}
+
+function initSubscriptionValueControls() {
+  const selector = document.getElementById('subscription-value-selector');
+  if (!selector) return;
+  selector.addEventListener('click', (e) => {
+    const btn = e.target.closest('[data-value-periods]');
+    if (!btn) return;
+    State.subscriptionValuePeriods = parseInt(btn.dataset.valuePeriods, 10) || 3;
+    selector.querySelectorAll('.range-btn').forEach(b => b.classList.toggle('active', b === btn));
+    fetchSubscriptionValue();
+  });
+}
+
+async function fetchSubscriptionValue() {
+  const section = document.getElementById('subscription-value-section');
+  if (!section) return;
+  const requestSeq = (State.subscriptionValueRequestSeq || 0) + 1;
+  State.subscriptionValueRequestSeq = requestSeq;
+  try {
+    const tz = encodeURIComponent(getEffectiveTimezone());
+    const res = await authFetch(`${API_BASE}/api/insights/value?periods=${State.subscriptionValuePeriods}&tz=${tz}`);
+    if (!res.ok) throw new Error('Failed to fetch subscription value');
+    const data = await res.json();
+    if (State.subscriptionValueRequestSeq !== requestSeq) return;
+    State.subscriptionValueData = data;
+    renderSubscriptionValue(data);
+  } catch (err) {
+    console.error('Subscription value fetch error:', err);
+    const summary = document.getElementById('subscription-value-summary');
+    if (summary) summary.textContent = 'Unable to load subscription value.';
+  }
+}
+
+function renderSubscriptionValue(data) {
+  const section = document.getElementById('subscription-value-section');
+  const summary = document.getElementById('subscription-value-summary');
+  const tbody = document.querySelector('#subscription-value-table tbody');
+  if (!data || !section || !summary || !tbody) return;
+
+  // The All tab lists every subscription; a provider tab only its own.
+  const provider = getCurrentProvider();
+  const subs = (data.subscriptions || []).filter(s => provider === 'both' || s.provider === provider);
+  if (subs.length === 0) {
+    section.style.display = 'none';
+    return;
+  }
+  section.style.display = '';
+
+  const configured = subs.filter(s => s.configured);
+  const totals = {};
+  configured.forEach(s => { totals[s.currency] = (totals[s.currency] || 0) + s.monthlyPrice; });
+  const totalText = Object.entries(totals).map(([cur, amount]) => formatSubscriptionPrice(amount, cur)).join(' + ');
+  summary.innerHTML = configured.length === 0
+    ? `Add monthly prices in <a href="${API_BASE}/settings">Settings → Providers</a> to see the cost per window and whether each plan pays off.`
+    : `${configured.length} priced subscription${configured.length === 1 ? '' : 's'} · ${escapeHTML(totalText)} per month · windows count as used when they reach 100%.`;
+
+  tbody.innerHTML = subs.map(sub => {
+    const current = (sub.periods || [])[0];
+    const history = (sub.periods || []).slice(1)
+      .map(p => `${Math.round(p.shareUsed)}%`)
+      .join(' · ');
+    const plan = sub.plan || sub.detectedPlan;
+    const planNote = sub.planMismatch
+      ? `<span class="subscription-value-warning" title="Price entered for ${escapeHTML(sub.plan)}, detected ${escapeHTML(sub.detectedPlan)}">plan changed?</span>`
+      : '';
+    const name = `<strong>${escapeHTML(sub.label)}</strong>${plan ? `<div class="subscription-value-sub">${escapeHTML(plan)} ${planNote}</div>` : ''}`;
+    const price = sub.configured ? formatSubscriptionPrice(sub.monthlyPrice, sub.currency) : '<span class="subscription-value-sub">Not set</span>';
+    if (!sub.supported || !current) {
+      return `<tr><td>${name}</td><td>${price}</td><td colspan="4" class="subscription-value-sub">Usage history is not tracked in percent for this provider.</td></tr>`;
+    }
+    const used = current.quotas.length === 0
+      ? '<span class="subscription-value-sub">No usage yet</span>'
+      : `${Math.round(current.shareUsed)}%<div class="subscription-value-sub">${escapeHTML(current.bindingQuota || '')}${history ? ` · before: ${history}` : ''}</div>`;
+    const perWindow = current.costPerWindow != null
+      ? `${formatSubscriptionPrice(current.costPerWindow, sub.currency)}<div class="subscription-value-sub">per full window used</div>`
+      : '<span class="subscription-value-sub">-</span>';
+    const atLimit = current.quotas.reduce((acc, q) => {
+      acc.hits += q.windowsAtLimit;
+      acc.windows += q.windows;
+      return acc;
+    }, { hits: 0, windows: 0 });
+    const suggestion = sub.suggestion || { action: 'insufficient_data', reason: '' };
+    return `<tr>
+      <td>${name}</td>
+      <td>${price}</td>
+      <td>${used}</td>
+      <td>${perWindow}</td>
+      <td>${atLimit.hits} / ${atLimit.windows}</td>
+      <td><span class="subscription-value-action ${escapeHTML(suggestion.action)}" title="${escapeHTML(suggestion.reason)}">${VALUE_SUGGESTION_LABELS[suggestion.action] || escapeHTML(suggestion.action)}</span></td>
+    </tr>`;
+  }).join('');
+}
+
+// ── Spend ledger ──
+
+const SPEND_KIND_LABELS = {
+  subscription: 'Subscription',
+  balance: 'Prepaid balance',
+  credits: 'Credits',
+  integration: 'API integration',
+};
+
+function initSpendDashboard() {
+  fetchBudgets();
+  const input = document.getElementById('spend-month');
+  if (input) {
+    input.addEventListener('change', () => {
+      State.spendMonth = input.value;
+      fetchSpend();
+    });
+  }
+  fetchSpend();
+}
+
+async function fetchBudgets() {
+  const list = document.getElementById('spend-budget-list');
+  if (!list) return;
+  try {
+    const tz = encodeURIComponent(getEffectiveTimezone());
+    const res = await authFetch(`${API_BASE}/api/budgets?tz=${tz}`);
+    if (!res.ok) throw new Error('Failed to fetch budgets');
+    renderBudgets(await res.json());
+  } catch (err) {
+    console.error('Budgets fetch error:', err);
+    list.innerHTML = '<p class="insight-text">Unable to load budgets.</p>';
+  }
+}
+
+// Each budget shows spend so far as a bar and the projected month-end
+// spend as a marker on the same scale.
+function renderBudgets(data) {
+  const list = document.getElementById('spend-budget-list');
+  if (!list || !data) return;
+  const budgets = data.budgets || [];
+  if (budgets.length === 0) {
+    list.innerHTML = `<p class="insight-text">No budgets yet. Add monthly budgets per provider, integration, account or model in <a href="${API_BASE}/settings">Settings → Notifications</a>.</p>`;
+    return;
+  }
+  const usd = amount => formatSubscriptionPrice(amount, 'USD');
+  list.innerHTML = budgets.map(b => {
+    const scale = Math.max(100, b.projectedPercent, b.percent);
+    const width = Math.min(100, b.percent / scale * 100);
+    const marker = Math.min(100, b.projectedPercent / scale * 100);
+    const limit = 100 / scale * 100;
+    const projectedCls = b.projectedPercent >= b.critical ? 'critical' : b.projectedPercent >= b.warning ? 'warning' : '';
+    return `<div class="spend-budget ${escapeHTML(b.level)}">
+      <div class="spend-budget-head">
+        <span class="spend-budget-label">${escapeHTML(b.label)}</span>
+        <span class="spend-budget-amount">${escapeHTML(usd(b.spentUsd))} of ${escapeHTML(usd(b.budgetUsd))} · ${Math.round(b.percent)}%</span>
+      </div>
+      <div class="spend-budget-track">
+        <div class="spend-budget-fill" style="width:${width}%"></div>
+        <div class="spend-budget-limit" style="left:${limit}%" title="Budget"></div>
+        <div class="spend-budget-marker" style="left:${marker}%" title="Projected"></div>
+      </div>
+      <div class="spend-budget-projection ${projectedCls}">Projected ${escapeHTML(usd(b.projectedUsd))} by month end (${Math.round(b.projectedPercent)}%)</div>
+    </div>`;
+  }).join('');
+}
+
+async function fetchSpend() {
+  const requestSeq = (State.spendRequestSeq || 0) + 1;
+  State.spendRequestSeq = requestSeq;
+  try {
+    const tz = encodeURIComponent(getEffectiveTimezone());
+    const month = State.spendMonth ? `&month=${encodeURIComponent(State.spendMonth)}` : '';
+    const res = await authFetch(`${API_BASE}/api/spend?tz=${tz}${month}`);
+    if (!res.ok) throw new Error('Failed to fetch spend');
+    const data = await res.json();
+    if (State.spendRequestSeq !== requestSeq) return;
+    State.spendData = data;
+    renderSpend(data);
+  } catch (err) {
+    console.error('Spend fetch error:', err);
+    const stats = document.getElementById('spend-stats');
+    if (stats) stats.innerHTML = '<p class="insight-text">Unable to load spend.</p>';
+  }
+}
+
+function renderSpend(data) {
+  const stats = document.getElementById('spend-stats');
+  const note = document.getElementById('spend-note');
+  const tbody = document.querySelector('#spend-sources-table tbody');
+  const input = document.getElementById('spend-month');
+  if (!data || !stats || !tbody) return;
+  if (input && !input.value) input.value = data.month;
+
+  const sources = data.sources || [];
+  const usd = amount => formatSubscriptionPrice(amount, 'USD');
+  const elapsed = data.elapsedDays || 0;
+  const days = (data.days || []).length;
+  const statItems = [
+    { value: usd(data.totalUsd), label: 'Spent this month', sublabel: data.month },
+    { value: usd(data.projectedUsd), label: 'Projected', sublabel: elapsed < days ? `at the pace of ${elapsed} day${elapsed === 1 ? '' : 's'}` : 'month complete' },
+    { value: usd(elapsed > 0 ? data.totalUsd / elapsed : 0), label: 'Per day', sublabel: 'average' },
+    { value: String(sources.length), label: 'Sources', sublabel: 'with spend' },
+  ];
+  stats.innerHTML = statItems.map(s => `<div class="insight-stat">
+      <div class="insight-stat-value">${escapeHTML(s.value)}</div>
+      <div class="insight-stat-label">${escapeHTML(s.label)}</div>
+      <div class="insight-stat-sublabel">${escapeHTML(s.sublabel)}</div>
+    </div>`).join('');
+
+  if (note) {
+    const missing = data.missingRates || [];
+    note.hidden = missing.length === 0;
+    note.innerHTML = missing.length === 0 ? '' : `No exchange rate for ${escapeHTML(missing.join(', '))}; those sources are left out of the USD totals. Add rates in <a href="${API_BASE}/settings">Settings → Providers</a>.`;
+  }
+
+  tbody.innerHTML = sources.length === 0
+    ? '<tr><td colspan="5" class="empty-state">No spend recorded for this month. Add subscription prices in Settings, or configure a prepaid provider or API integration.</td></tr>'
+    : sources.map(src => `<tr>
+        <td><strong>${escapeHTML(src.label)}</strong></td>
+        <td>${escapeHTML(SPEND_KIND_LABELS[src.kind] || src.kind)}</td>
+        <td>${escapeHTML(formatSubscriptionPrice(src.nativeTotal, src.currency))}</td>
+        <td>${src.converted ? escapeHTML(usd(src.usdTotal)) : '<span class="subscription-value-sub">no rate</span>'}${src.estimatedUsd ? ` <span class="subscription-value-sub" title="Estimated from model prices for events without a reported cost">incl. ~${escapeHTML(usd(src.estimatedUsd))} est.</span>` : ''}</td>
+        <td>${src.converted ? escapeHTML(usd(src.projectedUsd)) : '-'}</td>
+      </tr>`).join('');
+
+  const canvas = document.getElementById('spend-chart');
+  if (State.spendChart) {
+    State.spendChart.destroy();
+    State.spendChart = null;
+  }
+  if (!canvas) return;
+  const colors = getThemeColors();
+  const style = getComputedStyle(document.documentElement);
+  const palette = [
+    style.getPropertyValue('--chart-subscription').trim() || '#0D9488',
+    style.getPropertyValue('--chart-search').trim() || '#F59E0B',
+    style.getPropertyValue('--chart-toolcalls').trim() || '#3B82F6',
+    '#D97757', '#10B981', '#8B5CF6', '#EC4899', '#64748B',
+  ];
+  const datasets = sources.filter(src => src.converted).map((src, i) => ({
+    label: src.label,
+    data: src.daily,
+    backgroundColor: palette[i % palette.length],
+    borderWidth: 0,
+    stack: 'spend',
+  }));
+  State.spendChart = new Chart(canvas, {
+    type: 'bar',
+    data: { labels: (data.days || []).map(d => d.slice(8)), datasets },
+    options: {
+      responsive: true,
+      maintainAspectRatio: false,
+      interaction: { mode: 'index', intersect: false },
+      plugins: {
+        legend: { labels: { color: colors.text, usePointStyle: true, boxWidth: 8 } },
+        tooltip: {
+          backgroundColor: colors.surfaceContainer || '#1E1E1E',
+          titleColor: colors.onSurface || '#E6E1E5',
+          bodyColor: colors.text || '#CAC4D0',
+          borderColor: colors.outline || '#938F99',
+          borderWidth: 1, padding: 12,
+          callbacks: {
+            title: items => items.length ? data.days[items[0].dataIndex] : '',
+            label: ctx => ctx.parsed.y ? `${ctx.dataset.label}: ${usd(ctx.parsed.y)}` : null
+          }
+        }
+      },
+      scales: {
+        x: { stacked: true, grid: { display: false }, ticks: { color: colors.text } },
+        y: { stacked: true, grid: { color: colors.grid, drawBorder: false }, ticks: { color: colors.text, callback: v => usd(v) }, min: 0 }
+      }
+    }
+  });
+}
+
 function renderBothInsights(data, statsEl, cardsEl) {
   // Clear the single-mode containers
   if (statsEl) statsEl.innerHTML = '';
@@ -5070,6 +5770,8 @@ function updateChartTheme() {
     }
     return;
   }
+  if (State.cycleCompareData) renderCycleCompare(State.cycleCompareData);
+  if (State.spendData) renderSpend(State.spendData);
   if (!State.chart) return;
   const colors = getThemeColors();
   const style = getComputedStyle(document.documentElement);
@@ -6149,6 +6851,34 @@ function getAPIIntegrationsStatusMeta(health = State.apiIntegrationsHealth) {
   return { label: 'Idle', badgeStatus: 'danger' };
 }
 
+// Provider labels for an integration: registered providers by display name,
+// then the custom ones grouped into a single "Custom" label.
+function getAPIIntegrationProviderLabels(providers) {
+  const labels = providers.filter(p => p.provider && !p.custom).map(p => p.displayName || p.provider);
+  const custom = providers.filter(p => p.provider && p.custom).map(p => p.provider);
+  if (custom.length > 0) labels.push(`Custom: ${custom.join(', ')}`);
+  return labels;
+}
+
+function formatAPIIntegrationCacheHitRatio(ratio) {
+  return `${(Number(ratio) * 100).toFixed(1)}%`;
+}
+
+// getAPIIntegrationModelCacheHitLines lists the cache hit ratio of each model
+// that reported cache usage, for the cache stat tooltip.
+function getAPIIntegrationModelCacheHitLines(providers) {
+  const lines = [];
+  providers.forEach((provider) => {
+    (Array.isArray(provider.accounts) ? provider.accounts : []).forEach((account) => {
+      (Array.isArray(account.models) ? account.models : []).forEach((model) => {
+        if (model.cacheHitRatio == null) return;
+        lines.push(`${model.model}: ${formatAPIIntegrationCacheHitRatio(model.cacheHitRatio)}`);
+      });
+    });
+  });
+  return lines;
+}
+
 function renderAPIIntegrationsCards() {
   const container = document.getElementById('api-integrations-grid');
   if (!container) return;
@@ -6161,12 +6891,26 @@ function renderAPIIntegrationsCards() {
 
   container.innerHTML = entries.map((entry) => {
     const providers = Array.isArray(entry.providers) ? entry.providers : [];
-    const providerNames = providers.map(p => p.provider).filter(Boolean);
+    const providerNames = getAPIIntegrationProviderLabels(providers);
     const providerSummary = providerNames.length > 2
       ? `${providerNames.slice(0, 2).join(', ')} +${providerNames.length - 2}`
       : providerNames.join(', ');
     const promptTokens = Number(entry.promptTokens || 0);
     const completionTokens = Number(entry.completionTokens || 0);
+    const detailStats = [];
+    if (entry.cacheHitRatio != null) {
+      const modelLines = getAPIIntegrationModelCacheHitLines(providers);
+      detailStats.push(`<div class="api-integrations-stat" title="${escapeHTML(modelLines.join('\n'))}"><span class="api-integrations-stat-label">Cache Hit Rate: </span><span class="api-integrations-stat-value">${formatAPIIntegrationCacheHitRatio(entry.cacheHitRatio)}</span></div>`);
+    }
+    if (entry.cacheReadTokens || entry.cacheWriteTokens) {
+      detailStats.push(`<div class="api-integrations-stat"><span class="api-integrations-stat-label">Cache Read / Write: </span><span class="api-integrations-stat-value">${formatNumber(Number(entry.cacheReadTokens || 0))} / ${formatNumber(Number(entry.cacheWriteTokens || 0))}</span></div>`);
+    }
+    if (entry.reasoningTokens) {
+      detailStats.push(`<div class="api-integrations-stat"><span class="api-integrations-stat-label">Reasoning Tokens: </span><span class="api-integrations-stat-value">${formatNumber(Number(entry.reasoningTokens))}</span></div>`);
+    }
+    if (entry.audioTokens) {
+      detailStats.push(`<div class="api-integrations-stat"><span class="api-integrations-stat-label">Audio Tokens: </span><span class="api-integrations-stat-value">${formatNumber(Number(entry.audioTokens))}</span></div>`);
+    }
     return `<article class="quota-card api-integrations-card">
       <header class="card-header">
         <div class="quota-title-block">
@@ -6182,6 +6926,8 @@ function renderAPIIntegrationsCards() {
         <div class="api-integrations-stat"><span class="api-integrations-stat-label">Total Tokens: </span><span class="api-integrations-stat-value">${formatNumber(Number(entry.totalTokens || 0))}</span></div>
         <div class="api-integrations-stat"><span class="api-integrations-stat-label">Input / Output: </span><span class="api-integrations-stat-value">${formatNumber(promptTokens)} / ${formatNumber(completionTokens)}</span></div>
         <div class="api-integrations-stat"><span class="api-integrations-stat-label">Cost (where available): </span><span class="api-integrations-stat-value">${entry.totalCostUsd != null ? formatCurrencyUSD(Number(entry.totalCostUsd || 0)) : '--'}</span></div>
+        ${entry.estimatedCostUsd != null ? `<div class="api-integrations-stat" title="Estimated from model prices for requests without a reported cost"><span class="api-integrations-stat-label">Estimated Cost: </span><span class="api-integrations-stat-value">~${formatCurrencyUSD(Number(entry.estimatedCostUsd))}</span></div>` : ''}
+        ${detailStats.join('')}
       </div>
     </article>`;
   }).join('');
@@ -6197,7 +6943,7 @@ function renderAPIIntegrationsHealth() {
   if (!health) {
     summaryEl.innerHTML = '<p class="insight-text">Loading API integrations health...</p>';
     alertsEl.innerHTML = '';
-    tbody.innerHTML = '<tr><td colspan="3" class="empty-state">No API integration ingest state yet.</td></tr>';
+    tbody.innerHTML = '<tr><td colspan="4" class="empty-state">No API integration ingest state yet.</td></tr>';
     return;
   }
 
@@ -6209,7 +6955,8 @@ function renderAPIIntegrationsHealth() {
       <div class="api-integrations-health-item"><span class="api-integrations-health-label">Alerts: </span><span class="api-integrations-health-value">${formatNumber((Array.isArray(health.alerts) ? health.alerts : []).length)}</span></div>
     </div>
     <div class="api-integrations-health-copy">
-      <p><strong>Rotating files:</strong> Move or rename the active <code>.jsonl</code> file, then let your script create a new one. That starts a fresh source log for new events. Historical charts remain in the database until you clear or replace the stored onWatch data.</p>
+      <p><strong>Sources:</strong> ${(Array.isArray(health.sources) ? health.sources : []).map((source) => `<code>${escapeHTML(source)}</code>`).join(', ') || '--'}</p>
+      <p><strong>Rotating files:</strong> Rename the active <code>.jsonl</code> file (as logrotate does) and let your script create a new one. onWatch reads the rest of the renamed file before the new one, so no lines are lost. Gzip and zstd archives such as <code>notes.jsonl.1.gz</code> are read once, and events already ingested from the original file are skipped. Historical charts remain in the database until you clear or replace the stored onWatch data.</p>
     </div>
   `;
 
@@ -6227,18 +6974,214 @@ function renderAPIIntegrationsHealth() {
 
   const files = Array.isArray(health.files) ? health.files : [];
   if (files.length === 0) {
-    tbody.innerHTML = '<tr><td colspan="3" class="empty-state">No API integration ingest state yet.</td></tr>';
+    tbody.innerHTML = '<tr><td colspan="4" class="empty-state">No API integration ingest state yet.</td></tr>';
     return;
   }
   tbody.innerHTML = files.map((file) => `
     <tr>
       <td>${escapeHTML(file.sourcePath || '--')}</td>
+      <td>${escapeHTML(getAPIIntegrationsFileState(file))}</td>
       <td>${formatBytes(Number(file.fileSize || 0))}</td>
       <td>${file.lastCapturedAt ? escapeHTML(formatDateTime(file.lastCapturedAt)) : '--'}</td>
     </tr>
   `).join('');
 }
 
+function getAPIIntegrationsFileState(file) {
+  if (file.kind === 'archive') return 'Archive read';
+  const parts = ['Tailing'];
+  const pending = Number(file.fileSize || 0) - Number(file.offsetBytes || 0);
+  if (pending > 0) parts.push(`${formatBytes(pending)} pending`);
+  const rotations = Number(file.rotations || 0);
+  if (rotations > 0) {
+    parts.push(`rotated ${formatNumber(rotations)}x${file.lastRotatedAt ? `, last ${formatDateTime(file.lastRotatedAt)}` : ''}`);
+  }
+  return parts.join(' · ');
+}
+
+const apiIntegrationsBreakdownLabels = {
+  integration: 'Integration',
+  provider: 'Provider',
+  account: 'Account',
+  model: 'Model'
+};
+
+async function fetchAPIIntegrationsBreakdown() {
+  if (!document.getElementById('api-integrations-breakdown-tbody')) return;
+  const requestSeq = ++State.apiIntegrationsBreakdownRequestSeq;
+  const params = new URLSearchParams({
+    group_by: State.apiIntegrationsBreakdownGroupBy.join(','),
+    range: State.apiIntegrationsBreakdownRange
+  });
+  try {
+    const res = await authFetch(`${API_BASE}/api/api-integrations/breakdown?${params}`);
+    if (!res.ok) {
+      // A grouped dimension may have been removed in settings; fall back to the default.
+      if (res.status === 400 && State.apiIntegrationsBreakdownGroupBy.join(',') !== 'integration') {
+        State.apiIntegrationsBreakdownGroupBy = ['integration'];
+        return fetchAPIIntegrationsBreakdown();
+      }
+      throw new Error('Failed to fetch API integrations breakdown');
+    }
+    const data = await res.json();
+    if (requestSeq !== State.apiIntegrationsBreakdownRequestSeq) return;
+    State.apiIntegrationsBreakdown = data;
+    renderAPIIntegrationsBreakdown();
+  } catch (err) {
+    console.error('API integrations breakdown fetch error:', err);
+  }
+}
+
+function getAPIIntegrationsBreakdownLabel(dimension) {
+  return apiIntegrationsBreakdownLabels[dimension] || dimension;
+}
+
+function renderAPIIntegrationsBreakdownControls(dimensions) {
+  const groupSelect = document.getElementById('api-integrations-breakdown-group');
+  const thenSelect = document.getElementById('api-integrations-breakdown-then');
+  if (!groupSelect || !thenSelect) return;
+
+  const names = Object.keys(apiIntegrationsBreakdownLabels).concat(dimensions);
+  const options = names.map((name) => `<option value="${escapeHTML(name)}">${escapeHTML(getAPIIntegrationsBreakdownLabel(name))}</option>`).join('');
+  const [group, then] = State.apiIntegrationsBreakdownGroupBy;
+  groupSelect.innerHTML = options;
+  groupSelect.value = group;
+  thenSelect.innerHTML = '<option value="">Then by: none</option>' + options;
+  thenSelect.value = then || '';
+}
+
+function renderAPIIntegrationsBreakdown() {
+  const thead = document.getElementById('api-integrations-breakdown-thead');
+  const tbody = document.getElementById('api-integrations-breakdown-tbody');
+  if (!thead || !tbody) return;
+
+  const data = State.apiIntegrationsBreakdown;
+  if (!data) return;
+  const groupBy = Array.isArray(data.groupBy) ? data.groupBy : ['integration'];
+  const rows = Array.isArray(data.rows) ? data.rows : [];
+  renderAPIIntegrationsBreakdownControls(Array.isArray(data.dimensions) ? data.dimensions : []);
+
+  thead.innerHTML = `
+    <tr>
+      ${groupBy.map((dimension) => `<th>${escapeHTML(getAPIIntegrationsBreakdownLabel(dimension))}</th>`).join('')}
+      <th>Requests</th>
+      <th>Tokens</th>
+      <th>Cost</th>
+    </tr>
+  `;
+  if (rows.length === 0) {
+    tbody.innerHTML = `<tr><td colspan="${groupBy.length + 3}" class="empty-state">No API integration usage in this range.</td></tr>`;
+    return;
+  }
+  tbody.innerHTML = rows.map((row) => {
+    const values = row.values || {};
+    const cost = Number(row.totalCostUsd || 0);
+    const estimated = row.estimatedCostUsd != null ? Number(row.estimatedCostUsd) : null;
+    let costCell = cost > 0 || estimated == null ? formatCurrencyUSD(cost) : '';
+    if (estimated != null) {
+      costCell += `${costCell ? ' + ' : ''}<span title="Estimated from model prices for requests without a reported cost">~${formatCurrencyUSD(estimated)} est.</span>`;
+    }
+    return `
+      <tr>
+        ${groupBy.map((dimension) => `<td>${values[dimension] ? escapeHTML(values[dimension]) : '(none)'}</td>`).join('')}
+        <td>${formatNumber(Number(row.requestCount || 0))}</td>
+        <td>${formatNumber(Number(row.totalTokens || 0))}</td>
+        <td>${costCell}</td>
+      </tr>
+    `;
+  }).join('');
+}
+
+function setupAPIIntegrationsBreakdown() {
+  const groupSelect = document.getElementById('api-integrations-breakdown-group');
+  const thenSelect = document.getElementById('api-integrations-breakdown-then');
+  const rangeSelect = document.getElementById('api-integrations-breakdown-range');
+  if (!groupSelect || !thenSelect || !rangeSelect) return;
+
+  renderAPIIntegrationsBreakdownControls([]);
+  rangeSelect.value = State.apiIntegrationsBreakdownRange;
+  const update = () => {
+    const groupBy = [groupSelect.value || 'integration'];
+    if (thenSelect.value && thenSelect.value !== groupBy[0]) groupBy.push(thenSelect.value);
+    State.apiIntegrationsBreakdownGroupBy = groupBy;
+    State.apiIntegrationsBreakdownRange = rangeSelect.value;
+    fetchAPIIntegrationsBreakdown();
+  };
+  [groupSelect, thenSelect, rangeSelect].forEach((select) => select.addEventListener('change', update));
+}
+
+async function fetchAPIIntegrationsReliability() {
+  if (!document.getElementById('api-integrations-reliability-tbody')) return;
+  const requestSeq = ++State.apiIntegrationsReliabilityRequestSeq;
+  const params = new URLSearchParams({ range: State.apiIntegrationsReliabilityRange });
+  try {
+    const res = await authFetch(`${API_BASE}/api/api-integrations/reliability?${params}`);
+    if (!res.ok) throw new Error('Failed to fetch API integrations reliability');
+    const data = await res.json();
+    if (requestSeq !== State.apiIntegrationsReliabilityRequestSeq) return;
+    State.apiIntegrationsReliability = data;
+    renderAPIIntegrationsReliability();
+  } catch (err) {
+    console.error('API integrations reliability fetch error:', err);
+  }
+}
+
+function formatAPIIntegrationLatency(ms) {
+  if (ms == null) return '-';
+  const value = Number(ms);
+  return value < 1000 ? `${formatNumber(value)}ms` : `${(value / 1000).toFixed(1)}s`;
+}
+
+function renderAPIIntegrationsReliability() {
+  const tbody = document.getElementById('api-integrations-reliability-tbody');
+  const data = State.apiIntegrationsReliability;
+  if (!tbody || !data) return;
+
+  const groups = Array.isArray(data.groups) ? data.groups : [];
+  if (groups.length === 0) {
+    tbody.innerHTML = '<tr><td colspan="8" class="empty-state">No API integration calls in this range.</td></tr>';
+    return;
+  }
+  const thresholds = data.thresholds || {};
+  const maxErrorRate = Number(thresholds.error_rate_percent || 0);
+  const maxP95 = Number(thresholds.p95_latency_ms || 0);
+  // Highlight over-threshold values once the range has enough calls to judge.
+  const flag = (group, over) => over && Number(group.requestCount || 0) >= Number(thresholds.min_requests || 1);
+  tbody.innerHTML = groups.map((group) => {
+    const errorRate = Number(group.errorRate || 0);
+    const errorRateCell = `${errorRate.toFixed(1)}% <span class="text-muted">(${formatNumber(Number(group.errorCount || 0))})</span>`;
+    const errorTypes = Object.entries(group.errorTypes || {})
+      .sort((a, b) => b[1] - a[1] || a[0].localeCompare(b[0]))
+      .slice(0, 3)
+      .map(([type, count]) => `${escapeHTML(type || 'unknown')} ${formatNumber(count)}`)
+      .join(', ');
+    const p95Over = flag(group, maxP95 > 0 && group.p95Ms != null && Number(group.p95Ms) >= maxP95);
+    const errorOver = flag(group, maxErrorRate > 0 && errorRate >= maxErrorRate);
+    return `
+      <tr>
+        <td>${escapeHTML(group.integration || '')}</td>
+        <td>${escapeHTML(group.provider || '')} / ${escapeHTML(group.model || '')}</td>
+        <td>${formatNumber(Number(group.requestCount || 0))}</td>
+        <td>${errorOver ? `<span class="status-badge" data-status="danger">${errorRateCell}</span>` : errorRateCell}</td>
+        <td>${errorTypes || '-'}</td>
+        <td>${formatAPIIntegrationLatency(group.p50Ms)}</td>
+        <td>${p95Over ? `<span class="status-badge" data-status="warning">${formatAPIIntegrationLatency(group.p95Ms)}</span>` : formatAPIIntegrationLatency(group.p95Ms)}</td>
+        <td>${formatAPIIntegrationLatency(group.p99Ms)}</td>
+      </tr>
+    `;
+  }).join('');
+}
+
+function setupAPIIntegrationsReliability() {
+  const rangeSelect = document.getElementById('api-integrations-reliability-range');
+  if (!rangeSelect) return;
+  rangeSelect.value = State.apiIntegrationsReliabilityRange;
+  rangeSelect.addEventListener('change', () => {
+    State.apiIntegrationsReliabilityRange = rangeSelect.value;
+    fetchAPIIntegrationsReliability();
+  });
+}
+
 function buildAPIIntegrationsChartDatasets(historyRows, range, metric) {
   const integrationNames = Object.keys(historyRows || {}).sort((a, b) => {
     const aTotal = (historyRows[a] || []).reduce((sum, row) => sum + Number(row.totalTokens || 0), 0);
@@ -8831,6 +9774,12 @@ function setupHeaderActions() {
   if (refreshBtn) {
     refreshBtn.addEventListener('click', () => {
       refreshBtn.classList.add('spinning');
+      if (getCurrentProvider() === 'spend') {
+        Promise.all([fetchSpend(), fetchBudgets()]).finally(() => {
+          setTimeout(() => refreshBtn.classList.remove('spinning'), 600);
+        });
+        return;
+      }
       const tasks = [fetchCurrent(), fetchDeepInsights(), fetchHistory()];
       if (shouldShowCyclesTable()) tasks.push(fetchCycles());
       if (shouldShowSessionsTable()) tasks.push(fetchSessions());
@@ -9010,11 +9959,19 @@ async function initSettingsPage() {
   setupSettingsSave();
   setupProviderReload();
   setupProviderSettingsModal();
+  setupSMTPAuthMethod();
   setupSMTPTest();
+  setupTelegramTest();
+  setupAPIIntegrationIngestToken();
   setupPushNotifications();
+  setupDesktopTest();
   setupSettingsPassword();
   setupThresholdSliders();
   setupOverrides();
+  setupBudgets();
+  setupAPIIntegrationProviders();
+  setupAPIIntegrationDimensions();
+  setupReports();
 }
 
 function activateSettingsTab(tabName) {
@@ -9130,6 +10087,72 @@ async function loadSettings() {
         const pwdInput = document.getElementById('smtp-password');
         if (pwdInput) pwdInput.placeholder = '********** (saved)';
       }
+      setVal('smtp-auth-method', s.auth_method || 'password');
+      if (s.oauth_provider) {
+        setVal('smtp-oauth-provider', s.oauth_provider);
+      }
+      setVal('smtp-oauth-tenant', s.oauth_tenant);
+      setVal('smtp-oauth-token-url', s.oauth_token_url);
+      setVal('smtp-oauth-client-id', s.oauth_client_id);
+      if (s.oauth_client_secret_set) {
+        const secretInput = document.getElementById('smtp-oauth-client-secret');
+        if (secretInput) secretInput.placeholder = '********** (saved)';
+      }
+      if (s.oauth_refresh_token_set) {
+        const tokenInput = document.getElementById('smtp-oauth-refresh-token');
+        if (tokenInput) tokenInput.placeholder = '********** (saved)';
+      }
+      updateSMTPAuthFields();
+    }
+
+    // Telegram
+    if (data.telegram) {
+      const t = data.telegram;
+      setVal('telegram-chat-ids', t.chat_ids);
+      setVal('telegram-api-url', t.api_url);
+      if (t.bot_token_set) {
+        const tokenInput = document.getElementById('telegram-bot-token');
+        if (tokenInput) tokenInput.placeholder = '********** (saved)';
+      }
+    }
+
+    // Monthly budgets
+    if (Array.isArray(data.budgets)) {
+      data.budgets.forEach(b => addBudgetRow(b));
+    }
+
+    // API integration providers
+    if (Array.isArray(data.api_integration_providers)) {
+      data.api_integration_providers.forEach(p => addAPIIntegrationProviderRow(p));
+    }
+
+    // API integration dimensions
+    if (Array.isArray(data.api_integration_dimensions)) {
+      data.api_integration_dimensions.forEach(key => addAPIIntegrationDimensionRow(key));
+    }
+
+    // API integration reliability alerts
+    if (data.api_integration_reliability) {
+      const r = data.api_integration_reliability;
+      const setNum = (id, value) => {
+        const el = document.getElementById(id);
+        if (el) el.value = value > 0 ? value : '';
+      };
+      setNum('api-integration-reliability-error-rate', r.error_rate_percent);
+      setNum('api-integration-reliability-p95', r.p95_latency_ms);
+      setNum('api-integration-reliability-window', r.window_minutes);
+      setNum('api-integration-reliability-min-requests', r.min_requests);
+    }
+
+    // API integration ingest token (only whether one exists is returned)
+    const ingestTokenInput = document.getElementById('api-integration-ingest-token');
+    if (ingestTokenInput && data.api_integrations_ingest_token_set) {
+      ingestTokenInput.placeholder = '********** (set)';
+    }
+
+    // Scheduled reports
+    if (data.reports && Array.isArray(data.reports.schedules)) {
+      data.reports.schedules.forEach(r => addReportRow(r));
     }
 
     // Notifications
@@ -9147,13 +10170,26 @@ async function loadSettings() {
       if (resetCheck) resetCheck.checked = n.notify_reset !== false;
       const authErrorCheck = document.getElementById('notify-auth-error');
       if (authErrorCheck) authErrorCheck.checked = !!n.notify_auth_error;
+      const pacingCheck = document.getElementById('notify-pacing');
+      if (pacingCheck) pacingCheck.checked = !!n.notify_pacing;
+      const anomalyCheck = document.getElementById('notify-anomaly');
+      if (anomalyCheck) anomalyCheck.checked = !!n.notify_anomaly;
+      const runwayCheck = document.getElementById('notify-runway');
+      if (runwayCheck) runwayCheck.checked = !!n.notify_runway;
       setVal('notify-cooldown', n.cooldown_minutes || 30);
+      setVal('notify-pace-threshold', n.pace_threshold || 10);
+      setVal('notify-anomaly-factor', n.anomaly_factor || 3);
+      setVal('notify-runway-days', n.runway_days || 7);
       // Load channel preferences
       if (n.channels) {
         const emailToggle = document.getElementById('channel-email');
         const pushToggle = document.getElementById('channel-push');
         if (emailToggle) emailToggle.checked = n.channels.email !== false;
         if (pushToggle) pushToggle.checked = n.channels.push !== false;
+        const desktopToggle = document.getElementById('channel-desktop');
+        if (desktopToggle) desktopToggle.checked = !!n.channels.desktop;
+        const telegramToggle = document.getElementById('channel-telegram');
+        if (telegramToggle) telegramToggle.checked = !!n.channels.telegram;
       }
       // Load overrides
       if (n.overrides && n.overrides.length > 0) {
@@ -9183,12 +10219,103 @@ async function loadSettings() {
     // Provider visibility + dynamic provider status
     await populateProviderToggles(data.provider_visibility || {});
     await populateDashboardTabOrder();
+    await populateSubscriptionCosts(data.subscription_costs || {});
+    populateFXRates(data.fx_rates || {});
+    document.querySelectorAll('.runway-floor[data-provider]').forEach(input => {
+      const floor = (data.runway_floors || {})[input.dataset.provider];
+      input.value = floor > 0 ? floor : '';
+    });
     await populateMenubarSettings(data.menubar || {});
   } catch (e) {
     // Settings load failed silently
   }
 }
 
+// Subscription prices: one row per dashboard subscription (provider or account).
+async function populateSubscriptionCosts(costs) {
+  const list = document.getElementById('subscription-costs');
+  if (!list) return;
+  let subs = [];
+  try {
+    const res = await authFetch(`${API_BASE}/api/insights/value?periods=1`);
+    if (res.ok) {
+      const data = await res.json();
+      subs = Array.isArray(data.subscriptions) ? data.subscriptions : [];
+    }
+  } catch (e) {
+    subs = [];
+  }
+  if (subs.length === 0) {
+    list.innerHTML = '<p class="settings-field-hint">No providers available</p>';
+    return;
+  }
+  list.innerHTML = subs.map(sub => {
+    const cost = costs[sub.id] || {};
+    const detected = sub.detectedPlan ? `Detected plan: ${escapeHTML(sub.detectedPlan)}` : escapeHTML(sub.subtitle || '');
+    return `
+    <div class="subscription-cost-row" data-subscription="${escapeHTML(sub.id)}">
+      <div class="subscription-cost-name">
+        <span class="settings-toggle-label">${escapeHTML(sub.label)}</span>
+        <code class="dashboard-tab-order-id">${escapeHTML(sub.id)}</code>
+        <span class="settings-field-hint">${detected}</span>
+      </div>
+      <input type="number" class="settings-input subscription-cost-price" min="0" step="0.01" placeholder="Price / mo"
+        aria-label="Monthly price for ${escapeHTML(sub.label)}" value="${cost.monthly_price || ''}">
+      <input type="text" class="settings-input subscription-cost-currency" maxlength="3" placeholder="USD"
+        aria-label="Currency for ${escapeHTML(sub.label)}" value="${escapeHTML(cost.currency || '')}">
+      <input type="number" class="settings-input subscription-cost-day" min="1" max="28" placeholder="Bills on day 1"
+        aria-label="Billing day for ${escapeHTML(sub.label)}" value="${cost.billing_day || ''}">
+      <input type="text" class="settings-input subscription-cost-plan" maxlength="64" placeholder="${escapeHTML(sub.detectedPlan || 'Plan (optional)')}"
+        aria-label="Plan for ${escapeHTML(sub.label)}" value="${escapeHTML(cost.plan || '')}">
+    </div>`;
+  }).join('');
+}
+
+function gatherSubscriptionCosts() {
+  const rows = document.querySelectorAll('#subscription-costs .subscription-cost-row[data-subscription]');
+  if (rows.length === 0) return null;
+  const costs = {};
+  rows.forEach(row => {
+    const price = parseFloat(row.querySelector('.subscription-cost-price')?.value);
+    if (!(price > 0)) return;
+    costs[row.dataset.subscription] = {
+      monthly_price: price,
+      currency: (row.querySelector('.subscription-cost-currency')?.value || '').trim().toUpperCase(),
+      billing_day: parseInt(row.querySelector('.subscription-cost-day')?.value, 10) || 0,
+      plan: (row.querySelector('.subscription-cost-plan')?.value || '').trim(),
+    };
+  });
+  return costs;
+}
+
+// Exchange rates for the spend ledger: one row per known currency plus an empty
+// row for adding another.
+function populateFXRates(rates) {
+  const list = document.getElementById('fx-rates');
+  if (!list) return;
+  const codes = Object.keys(rates).filter(code => code !== 'USD').sort();
+  codes.push('');
+  list.innerHTML = codes.map(code => `
+    <div class="fx-rate-row">
+      <input type="text" class="settings-input fx-rate-code" maxlength="3" placeholder="Code (e.g. INR)"
+        aria-label="Currency code" value="${escapeHTML(code)}"${code ? ' readonly' : ''}>
+      <input type="number" class="settings-input fx-rate-value" min="0" step="any" placeholder="USD per unit"
+        aria-label="USD per unit${code ? ` of ${escapeHTML(code)}` : ''}" value="${code ? rates[code] : ''}">
+    </div>`).join('');
+}
+
+function gatherFXRates() {
+  const rows = document.querySelectorAll('#fx-rates .fx-rate-row');
+  if (rows.length === 0) return null;
+  const rates = {};
+  rows.forEach(row => {
+    const code = (row.querySelector('.fx-rate-code')?.value || '').trim().toUpperCase();
+    const rate = parseFloat(row.querySelector('.fx-rate-value')?.value);
+    if (code && rate > 0) rates[code] = rate;
+  });
+  return rates;
+}
+
 async function populateMenubarSettings(data) {
   const caps = State.menubarCapabilities || await loadCapabilities();
   State.menubarCapabilities = caps;
@@ -9643,6 +10770,7 @@ const DEFAULT_PROVIDER_TAB_LABELS = {
   grok: 'Grok',
   kimi: 'Kimi',
   'api-integrations': 'API Integrations',
+  spend: 'Spend',
   both: 'All',
 };
 
@@ -9653,7 +10781,7 @@ function defaultProviderTabLabel(key) {
 }
 
 function isDashboardSpecialTab(key) {
-  return key === 'both' || key === 'api-integrations';
+  return key === 'both' || key === 'api-integrations' || key === 'spend';
 }
 
 function mergeDashboardProviderOrder(preferred, available) {
@@ -9672,7 +10800,7 @@ function mergeDashboardProviderOrder(preferred, available) {
   (Array.isArray(preferred) ? preferred : []).forEach(pushKey);
   // Newly available providers (e.g. Grok) join before special tabs.
   avail.forEach(pushKey);
-  const specialOrder = ['api-integrations', 'both'];
+  const specialOrder = ['api-integrations', 'spend', 'both'];
   const orderedSpecials = [];
   specialOrder.forEach((k) => {
     if (specials.includes(k)) orderedSpecials.push(k);
@@ -10826,6 +11954,58 @@ function gatherSettings() {
       from_name: document.getElementById('smtp-from-name')?.value.trim() || '',
       to: document.getElementById('smtp-to')?.value.trim() || '',
     };
+    const authMethod = document.getElementById('smtp-auth-method')?.value || 'password';
+    settings.smtp.auth_method = authMethod;
+    if (authMethod === 'oauth2') {
+      settings.smtp.oauth_provider = document.getElementById('smtp-oauth-provider')?.value || 'google';
+      settings.smtp.oauth_tenant = document.getElementById('smtp-oauth-tenant')?.value.trim() || '';
+      settings.smtp.oauth_token_url = document.getElementById('smtp-oauth-token-url')?.value.trim() || '';
+      settings.smtp.oauth_client_id = document.getElementById('smtp-oauth-client-id')?.value.trim() || '';
+      settings.smtp.oauth_client_secret = document.getElementById('smtp-oauth-client-secret')?.value || '';
+      settings.smtp.oauth_refresh_token = document.getElementById('smtp-oauth-refresh-token')?.value.trim() || '';
+    }
+  }
+
+  // Telegram
+  const telegramChatIDs = document.getElementById('telegram-chat-ids');
+  if (telegramChatIDs) {
+    settings.telegram = {
+      bot_token: document.getElementById('telegram-bot-token')?.value.trim() || '',
+      chat_ids: telegramChatIDs.value.trim(),
+      api_url: document.getElementById('telegram-api-url')?.value.trim() || '',
+    };
+  }
+
+  // Monthly budgets
+  if (document.getElementById('budget-list')) {
+    settings.budgets = collectBudgets();
+  }
+
+  // API integration providers
+  if (document.getElementById('api-integration-provider-list')) {
+    settings.api_integration_providers = collectAPIIntegrationProviders();
+  }
+
+  // API integration dimensions
+  if (document.getElementById('api-integration-dimension-list')) {
+    settings.api_integration_dimensions = collectAPIIntegrationDimensions();
+  }
+
+  // API integration reliability alerts
+  if (document.getElementById('api-integration-reliability-error-rate')) {
+    const num = (id) => parseFloat(document.getElementById(id)?.value) || 0;
+    settings.api_integration_reliability = {
+      error_rate_percent: num('api-integration-reliability-error-rate'),
+      p95_latency_ms: Math.round(num('api-integration-reliability-p95')),
+      window_minutes: Math.round(num('api-integration-reliability-window')),
+      min_requests: Math.round(num('api-integration-reliability-min-requests')),
+    };
+  }
+
+  // Scheduled reports
+  const reportList = document.getElementById('report-list');
+  if (reportList) {
+    settings.reports = { schedules: collectReportSchedules() };
   }
 
   // Notifications
@@ -10853,10 +12033,18 @@ function gatherSettings() {
       notify_critical: document.getElementById('notify-critical')?.checked ?? true,
       notify_reset: document.getElementById('notify-reset')?.checked ?? true,
       notify_auth_error: document.getElementById('notify-auth-error')?.checked ?? false,
+      notify_pacing: document.getElementById('notify-pacing')?.checked ?? false,
+      pace_threshold: parseFloat(document.getElementById('notify-pace-threshold')?.value) || 10,
+      notify_anomaly: document.getElementById('notify-anomaly')?.checked ?? false,
+      anomaly_factor: parseFloat(document.getElementById('notify-anomaly-factor')?.value) || 3,
+      notify_runway: document.getElementById('notify-runway')?.checked ?? false,
+      runway_days: parseFloat(document.getElementById('notify-runway-days')?.value) || 7,
       cooldown_minutes: parseInt(document.getElementById('notify-cooldown')?.value) || 30,
       channels: {
         email: document.getElementById('channel-email')?.checked ?? true,
         push: document.getElementById('channel-push')?.checked ?? true,
+        desktop: document.getElementById('channel-desktop')?.checked ?? false,
+        telegram: document.getElementById('channel-telegram')?.checked ?? false,
       },
       overrides: overrides,
     };
@@ -10889,6 +12077,23 @@ function gatherSettings() {
     ? { ...State.dashboardProviderLabels }
     : {};
 
+  const subscriptionCosts = gatherSubscriptionCosts();
+  if (subscriptionCosts) {
+    settings.subscription_costs = subscriptionCosts;
+  }
+  const fxRates = gatherFXRates();
+  if (fxRates) {
+    settings.fx_rates = fxRates;
+  }
+  const floorInputs = document.querySelectorAll('.runway-floor[data-provider]');
+  if (floorInputs.length > 0) {
+    settings.runway_floors = {};
+    floorInputs.forEach(input => {
+      const floor = parseFloat(input.value);
+      if (floor > 0) settings.runway_floors[input.dataset.provider] = floor;
+    });
+  }
+
   // Timezone
   const tzSelect = document.getElementById('settings-timezone');
   if (tzSelect) {
@@ -10992,6 +12197,9 @@ function setupSettingsSave() {
         if (data.dashboard_provider_labels && typeof data.dashboard_provider_labels === 'object') {
           State.dashboardProviderLabels = { ...data.dashboard_provider_labels };
         }
+        if (data.reports === 'saved') {
+          document.querySelectorAll('.settings-report-row').forEach(row => { row.dataset.saved = 'true'; });
+        }
         showSettingsFeedback(feedback, 'Settings saved successfully. Reload the dashboard to apply tab order and names.', 'success');
       }
     } catch (e) {
@@ -11011,6 +12219,21 @@ function showSettingsFeedback(el, msg, type) {
   setTimeout(() => { el.hidden = true; }, 5000);
 }
 
+// updateSMTPAuthFields shows the password or OAuth2 inputs for the selected auth method.
+function updateSMTPAuthFields() {
+  const method = document.getElementById('smtp-auth-method')?.value || 'password';
+  const pwdField = document.getElementById('smtp-password-field');
+  if (pwdField) pwdField.hidden = method === 'oauth2';
+  document.querySelectorAll('.smtp-oauth-field').forEach(el => { el.hidden = method !== 'oauth2'; });
+}
+
+function setupSMTPAuthMethod() {
+  const select = document.getElementById('smtp-auth-method');
+  if (!select) return;
+  select.addEventListener('change', updateSMTPAuthFields);
+  updateSMTPAuthFields();
+}
+
 function setupSMTPTest() {
   const testBtn = document.getElementById('smtp-test-btn');
   const result = document.getElementById('smtp-test-result');
@@ -11046,6 +12269,103 @@ function setupSMTPTest() {
   });
 }
 
+function setupDesktopTest() {
+  const testBtn = document.getElementById('desktop-test-btn');
+  const result = document.getElementById('desktop-test-result');
+  if (!testBtn) return;
+
+  testBtn.addEventListener('click', async () => {
+    testBtn.disabled = true;
+    if (result) { result.textContent = ''; result.className = 'settings-test-result'; }
+
+    try {
+      const resp = await authFetch('/api/desktop/test', { method: 'POST' });
+      const data = await resp.json();
+      if (result) {
+        result.textContent = data.message || data.error || (data.success ? 'Test notification sent.' : 'Test failed.');
+        result.className = 'settings-test-result ' + (data.success ? 'success' : 'error');
+      }
+    } catch (e) {
+      if (result) {
+        result.textContent = 'Network error.';
+        result.className = 'settings-test-result error';
+      }
+    } finally {
+      testBtn.disabled = false;
+    }
+  });
+}
+
+function setupTelegramTest() {
+  const testBtn = document.getElementById('telegram-test-btn');
+  const result = document.getElementById('telegram-test-result');
+  if (!testBtn) return;
+
+  testBtn.addEventListener('click', async () => {
+    testBtn.disabled = true;
+    testBtn.textContent = 'Sending...';
+    if (result) { result.textContent = ''; result.className = 'settings-test-result'; }
+
+    try {
+      const resp = await authFetch('/api/settings/telegram/test', { method: 'POST' });
+      const data = await resp.json();
+      if (result) {
+        result.textContent = data.message || data.error || (data.success ? 'Test message sent.' : 'Test failed.');
+        result.className = 'settings-test-result ' + (data.success ? 'success' : 'error');
+      }
+    } catch (e) {
+      if (result) {
+        result.textContent = 'Network error.';
+        result.className = 'settings-test-result error';
+      }
+    } finally {
+      testBtn.disabled = false;
+      testBtn.innerHTML = '<svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M22 2L11 13M22 2l-7 20-4-9-9-4 20-7z"/></svg> Send Test Message';
+    }
+  });
+}
+
+function setupAPIIntegrationIngestToken() {
+  const input = document.getElementById('api-integration-ingest-token');
+  const generateBtn = document.getElementById('api-integration-ingest-token-generate');
+  const revokeBtn = document.getElementById('api-integration-ingest-token-revoke');
+  const result = document.getElementById('api-integration-ingest-token-result');
+  if (!input || !generateBtn || !revokeBtn) return;
+
+  const send = async (method, confirmText) => {
+    if (!confirm(confirmText)) return;
+    generateBtn.disabled = true;
+    revokeBtn.disabled = true;
+    if (result) { result.textContent = ''; result.className = 'settings-test-result'; }
+    try {
+      const resp = await authFetch('/api/api-integrations/ingest-token', { method });
+      const data = await resp.json();
+      if (!resp.ok) throw new Error(data.error || 'Request failed.');
+      if (data.token) {
+        input.value = data.token;
+        input.select();
+        if (result) result.textContent = 'Copy this token now; it will not be shown again.';
+      } else {
+        input.value = '';
+        input.placeholder = 'Not set';
+        if (result) result.textContent = 'Token revoked.';
+      }
+      if (result) result.className = 'settings-test-result success';
+    } catch (e) {
+      if (result) {
+        result.textContent = e.message || 'Network error.';
+        result.className = 'settings-test-result error';
+      }
+    } finally {
+      generateBtn.disabled = false;
+      revokeBtn.disabled = false;
+    }
+  };
+
+  generateBtn.addEventListener('click', () => send('POST', 'Generate a new ingest token? Workers using the current one will be rejected.'));
+  revokeBtn.addEventListener('click', () => send('DELETE', 'Revoke the ingest token?'));
+}
+
 function setupPushNotifications() {
   var statusLabel = document.getElementById('push-status-label');
   var subscribeBtn = document.getElementById('push-subscribe-btn');
@@ -11320,6 +12640,250 @@ function setupOverrides() {
   }
 }
 
+function setupBudgets() {
+  const addBtn = document.getElementById('add-budget-btn');
+  if (addBtn) {
+    addBtn.addEventListener('click', () => addBudgetRow({ scope: 'total', amount_usd: '', warning: 80, critical: 100 }));
+  }
+}
+
+const _budgetScopes = [
+  ['total', 'All spend'],
+  ['provider', 'Provider'],
+  ['integration', 'Integration'],
+  ['account', 'Account'],
+  ['model', 'Model'],
+];
+
+function addBudgetRow(b) {
+  const list = document.getElementById('budget-list');
+  if (!list) return;
+
+  const row = document.createElement('div');
+  row.className = 'settings-override-row settings-budget-row';
+  const scopeOptions = _budgetScopes.map(([value, label]) => `<option value="${value}" ${b.scope === value ? 'selected' : ''}>${label}</option>`).join('');
+  row.innerHTML = `
+    <select class="settings-input budget-scope" style="flex:1">${scopeOptions}</select>
+    <input type="text" class="settings-input budget-target" style="flex:2" maxlength="128" placeholder="deepseek, notes, gpt-4o..." value="">
+    <input type="number" class="settings-input budget-amount" style="flex:1" min="0" step="0.01" placeholder="USD / month" value="${b.amount_usd || ''}">
+    <input type="number" class="settings-input settings-input-sm budget-warning" min="1" placeholder="Warn%" value="${b.warning || 80}" title="Warning at % of budget">
+    <input type="number" class="settings-input settings-input-sm budget-critical" min="1" placeholder="Crit%" value="${b.critical || 100}" title="Critical at % of budget">
+    <button class="override-remove" title="Remove budget" type="button">
+      <svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M18 6L6 18M6 6l12 12"/></svg>
+    </button>
+  `;
+  row.querySelector('.budget-target').value = b.target || '';
+
+  const scopeSelect = row.querySelector('.budget-scope');
+  const updateTarget = () => {
+    row.querySelector('.budget-target').hidden = scopeSelect.value === 'total';
+  };
+  scopeSelect.addEventListener('change', updateTarget);
+  updateTarget();
+
+  row.querySelector('.override-remove').addEventListener('click', () => row.remove());
+  list.appendChild(row);
+}
+
+function collectBudgets() {
+  const budgets = [];
+  document.querySelectorAll('#budget-list .settings-budget-row').forEach(row => {
+    const amount = parseFloat(row.querySelector('.budget-amount')?.value);
+    if (!(amount > 0)) return;
+    const scope = row.querySelector('.budget-scope')?.value || 'total';
+    budgets.push({
+      scope,
+      target: scope === 'total' ? '' : (row.querySelector('.budget-target')?.value || '').trim(),
+      amount_usd: amount,
+      warning: parseFloat(row.querySelector('.budget-warning')?.value) || 80,
+      critical: parseFloat(row.querySelector('.budget-critical')?.value) || 100,
+    });
+  });
+  return budgets;
+}
+
+function setupAPIIntegrationProviders() {
+  const addBtn = document.getElementById('add-api-integration-provider-btn');
+  if (addBtn) {
+    addBtn.addEventListener('click', () => addAPIIntegrationProviderRow({}));
+  }
+}
+
+function addAPIIntegrationProviderRow(p) {
+  const list = document.getElementById('api-integration-provider-list');
+  if (!list) return;
+
+  const rate = (value) => (value == null ? '' : value);
+  const row = document.createElement('div');
+  row.className = 'settings-override-row settings-api-integration-provider-row';
+  row.innerHTML = `
+    <input type="text" class="settings-input api-integration-provider-id" style="flex:1" maxlength="64" placeholder="deepseek, my-vllm..." value="">
+    <input type="text" class="settings-input api-integration-provider-name" style="flex:2" maxlength="64" placeholder="Display name" value="">
+    <input type="number" class="settings-input api-integration-provider-input" style="flex:1" min="0" step="0.001" placeholder="Input $/M" value="${rate(p.input_usd_per_mtok)}" title="USD per million input tokens">
+    <input type="number" class="settings-input api-integration-provider-output" style="flex:1" min="0" step="0.001" placeholder="Output $/M" value="${rate(p.output_usd_per_mtok)}" title="USD per million output tokens">
+    <button class="override-remove" title="Remove provider" type="button">
+      <svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M18 6L6 18M6 6l12 12"/></svg>
+    </button>
+  `;
+  row.querySelector('.api-integration-provider-id').value = p.id || '';
+  row.querySelector('.api-integration-provider-name').value = p.display_name || '';
+  row.querySelector('.override-remove').addEventListener('click', () => row.remove());
+  list.appendChild(row);
+}
+
+function collectAPIIntegrationProviders() {
+  const providers = [];
+  document.querySelectorAll('#api-integration-provider-list .settings-api-integration-provider-row').forEach(row => {
+    const id = (row.querySelector('.api-integration-provider-id')?.value || '').trim();
+    if (!id) return;
+    const provider = { id, display_name: (row.querySelector('.api-integration-provider-name')?.value || '').trim() };
+    const input = parseFloat(row.querySelector('.api-integration-provider-input')?.value);
+    const output = parseFloat(row.querySelector('.api-integration-provider-output')?.value);
+    if (input >= 0) provider.input_usd_per_mtok = input;
+    if (output >= 0) provider.output_usd_per_mtok = output;
+    providers.push(provider);
+  });
+  return providers;
+}
+
+function setupAPIIntegrationDimensions() {
+  const addBtn = document.getElementById('add-api-integration-dimension-btn');
+  if (addBtn) {
+    addBtn.addEventListener('click', () => addAPIIntegrationDimensionRow(''));
+  }
+}
+
+function addAPIIntegrationDimensionRow(key) {
+  const list = document.getElementById('api-integration-dimension-list');
+  if (!list) return;
+
+  const row = document.createElement('div');
+  row.className = 'settings-override-row settings-api-integration-dimension-row';
+  row.innerHTML = `
+    <input type="text" class="settings-input api-integration-dimension-key" style="flex:1" maxlength="64" placeholder="Metadata key, e.g. feature" value="">
+    <button class="override-remove" title="Remove dimension" type="button">
+      <svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M18 6L6 18M6 6l12 12"/></svg>
+    </button>
+  `;
+  row.querySelector('.api-integration-dimension-key').value = key || '';
+  row.querySelector('.override-remove').addEventListener('click', () => row.remove());
+  list.appendChild(row);
+}
+
+function collectAPIIntegrationDimensions() {
+  const keys = [];
+  document.querySelectorAll('#api-integration-dimension-list .api-integration-dimension-key').forEach(input => {
+    const key = (input.value || '').trim();
+    if (key) keys.push(key);
+  });
+  return keys;
+}
+
+function setupReports() {
+  const addBtn = document.getElementById('add-report-btn');
+  if (addBtn) {
+    addBtn.addEventListener('click', () => addReportRow({ period: 'weekly', weekday: 1, day_of_month: 1, hour: 8, enabled: true }));
+  }
+}
+
+const _reportWeekdays = ['Sunday', 'Monday', 'Tuesday', 'Wednesday', 'Thursday', 'Friday', 'Saturday'];
+
+function addReportRow(sched) {
+  const list = document.getElementById('report-list');
+  if (!list) return;
+
+  const row = document.createElement('div');
+  row.className = 'settings-override-row settings-report-row';
+  row.dataset.id = sched.id || ('r' + Date.now().toString(36) + Math.random().toString(36).slice(2, 6));
+  row.dataset.saved = sched.id ? 'true' : 'false';
+  const weekdayOptions = _reportWeekdays.map((d, i) => `<option value="${i}" ${sched.weekday === i ? 'selected' : ''}>${d}</option>`).join('');
+  const hourOptions = Array.from({ length: 24 }, (_, h) => `<option value="${h}" ${sched.hour === h ? 'selected' : ''}>${String(h).padStart(2, '0')}:00</option>`).join('');
+  row.innerHTML = `
+    <input type="text" class="settings-input report-name" style="flex:2" placeholder="Name" value="">
+    <select class="settings-input report-period" style="flex:1">
+      <option value="weekly" ${sched.period !== 'monthly' ? 'selected' : ''}>Weekly</option>
+      <option value="monthly" ${sched.period === 'monthly' ? 'selected' : ''}>Monthly</option>
+    </select>
+    <select class="settings-input report-weekday" style="flex:1" title="Day of week">${weekdayOptions}</select>
+    <input type="number" class="settings-input settings-input-sm report-day" min="1" max="28" value="${sched.day_of_month || 1}" title="Day of month (1-28)">
+    <select class="settings-input report-hour" style="flex:1" title="Hour of day">${hourOptions}</select>
+    <input type="text" class="settings-input report-recipients" style="flex:3" placeholder="Recipients (default: SMTP To)" value="">
+    <label class="override-toggle" title="Send this report on schedule"><input type="checkbox" class="report-enabled" ${sched.enabled ? 'checked' : ''}> On</label>
+    <button class="override-toggle report-send" title="Send this report now (save first)" type="button">Send now</button>
+    <button class="override-remove" title="Remove schedule" type="button">
+      <svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M18 6L6 18M6 6l12 12"/></svg>
+    </button>
+  `;
+  row.querySelector('.report-name').value = sched.name || '';
+  row.querySelector('.report-recipients').value = sched.recipients || '';
+
+  const periodSelect = row.querySelector('.report-period');
+  const updatePeriodFields = () => {
+    const monthly = periodSelect.value === 'monthly';
+    row.querySelector('.report-weekday').hidden = monthly;
+    row.querySelector('.report-day').hidden = !monthly;
+  };
+  periodSelect.addEventListener('change', updatePeriodFields);
+  updatePeriodFields();
+
+  row.querySelector('.override-remove').addEventListener('click', () => row.remove());
+  row.querySelector('.report-send').addEventListener('click', () => sendReportNow(row));
+  list.appendChild(row);
+}
+
+function collectReportSchedules() {
+  const schedules = [];
+  document.querySelectorAll('.settings-report-row').forEach(row => {
+    schedules.push({
+      id: row.dataset.id,
+      name: row.querySelector('.report-name')?.value.trim() || '',
+      enabled: row.querySelector('.report-enabled')?.checked ?? false,
+      period: row.querySelector('.report-period')?.value || 'weekly',
+      weekday: parseInt(row.querySelector('.report-weekday')?.value) || 0,
+      day_of_month: parseInt(row.querySelector('.report-day')?.value) || 1,
+      hour: parseInt(row.querySelector('.report-hour')?.value) || 0,
+      recipients: row.querySelector('.report-recipients')?.value.trim() || '',
+    });
+  });
+  return schedules;
+}
+
+async function sendReportNow(row) {
+  const result = document.getElementById('report-send-result');
+  const btn = row.querySelector('.report-send');
+  if (result) { result.textContent = ''; result.className = 'settings-test-result'; }
+  if (row.dataset.saved !== 'true') {
+    if (result) {
+      result.textContent = 'Save settings before sending this report.';
+      result.className = 'settings-test-result error';
+    }
+    return;
+  }
+
+  btn.disabled = true;
+  btn.textContent = 'Sending...';
+  try {
+    const resp = await authFetch(`${API_BASE}/api/reports/send`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ id: row.dataset.id }),
+    });
+    const data = await resp.json();
+    if (result) {
+      result.textContent = data.message || data.error || (data.success ? 'Report sent.' : 'Report send failed.');
+      result.className = 'settings-test-result ' + (data.success ? 'success' : 'error');
+    }
+  } catch (e) {
+    if (result) {
+      result.textContent = 'Network error.';
+      result.className = 'settings-test-result error';
+    }
+  } finally {
+    btn.disabled = false;
+    btn.textContent = 'Send now';
+  }
+}
+
 const _overrideQuotasByProvider = {
   anthropic: [
     { key: 'five_hour', label: '5-Hour Limit' },
@@ -11715,6 +13279,8 @@ document.addEventListener('DOMContentLoaded', async () => {
   setupProviderSelector();
   setupRangeSelector();
   setupAPIIntegrationsMetricSelector();
+  setupAPIIntegrationsBreakdown();
+  setupAPIIntegrationsReliability();
   setupCycleFilters();
   setupPasswordToggle();
   setupTableControls();
@@ -11724,6 +13290,11 @@ document.addEventListener('DOMContentLoaded', async () => {
   setupCardModals();
   initNotificationCenter();
 
+  if (document.getElementById('spend-dashboard')) {
+    initSpendDashboard();
+    checkForUpdate();
+  }
+
   if (document.getElementById('usage-chart') || document.getElementById('both-view') || document.getElementById('all-providers-container')) {
     initChart();
 
@@ -11769,6 +13340,32 @@ document.addEventListener('DOMContentLoaded', async () => {
     if (shouldShowSessionsTable(activeProvider)) {
       lazyLoadOnVisible('.sessions-section', () => fetchSessions());
     }
+    if (HEATMAP_PROVIDERS.has(activeProvider)) {
+      initHeatmapControls();
+      if (shouldShowHeatmap(activeProvider)) {
+        lazyLoadOnVisible('.heatmap-section', () => fetchHeatmap());
+      } else {
+        fetchHeatmap(); // hides the section in the all-accounts overview
+      }
+    } else {
+      const heatmapSection = document.getElementById('heatmap-section');
+      if (heatmapSection) heatmapSection.style.display = 'none';
+    }
+    if (HEATMAP_PROVIDERS.has(activeProvider)) {
+      initCycleCompareControls();
+      if (shouldShowHeatmap(activeProvider)) {
+        lazyLoadOnVisible('.cycle-compare-section', () => fetchCycleCompare());
+      } else {
+        fetchCycleCompare(); // hides the section in the all-accounts overview
+      }
+    } else {
+      const compareSection = document.getElementById('cycle-compare-section');
+      if (compareSection) compareSection.style.display = 'none';
+    }
+    if (document.getElementById('subscription-value-section')) {
+      initSubscriptionValueControls();
+      lazyLoadOnVisible('.subscription-value-section', () => fetchSubscriptionValue());
+    }
 
     startCountdowns();
     startAutoRefresh();
@@ -0,0 +1,356 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+)
+
+// settingSubscriptionCosts stores what each subscription is billed, keyed by
+// dashboard provider ID ("anthropic", "codex:2").
+const settingSubscriptionCosts = "subscription_costs"
+
+const (
+	defaultValuePeriods = 3
+	maxValuePeriods     = 12
+	// valueResetDrop is the fall in utilization that marks a new window.
+	valueResetDrop = 5.0
+	// valueLimitPercent is the peak at which a window counts as hitting its limit.
+	valueLimitPercent = 99.5
+	// valueMinWindows is the window count below which no suggestion is made.
+	valueMinWindows = 3
+	// valueUpgradeHitRate is the share of windows hitting the limit that
+	// suggests an upgrade.
+	valueUpgradeHitRate = 0.2
+	// valueDowngradeShare is the average window peak, in percent, below which
+	// a subscription that never hits its limit is suggested for a downgrade.
+	valueDowngradeShare = 40.0
+)
+
+var subscriptionKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9-]*(:[0-9]+)?$`)
+
+// subscriptionCost is the price entered for one subscription.
+type subscriptionCost struct {
+	MonthlyPrice float64 `json:"monthly_price"`
+	Currency     string  `json:"currency,omitempty"`
+	Plan         string  `json:"plan,omitempty"`        // plan the price applies to; blank for any
+	BillingDay   int     `json:"billing_day,omitempty"` // day of month the billing period starts (1-28)
+}
+
+// parseSubscriptionCosts validates the subscription_costs settings value.
+// Entries without a positive price are dropped so clearing the field removes
+// the subscription.
+func parseSubscriptionCosts(raw json.RawMessage) (map[string]subscriptionCost, error) {
+	var in map[string]subscriptionCost
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return nil, fmt.Errorf("invalid subscription_costs value")
+	}
+	out := make(map[string]subscriptionCost, len(in))
+	for key, cost := range in {
+		if !subscriptionKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid subscription key: %s", key)
+		}
+		if cost.MonthlyPrice <= 0 {
+			continue
+		}
+		if cost.MonthlyPrice > 100000 || math.IsNaN(cost.MonthlyPrice) {
+			return nil, fmt.Errorf("monthly price for %s must be below 100000", key)
+		}
+		cost.Currency = strings.ToUpper(strings.TrimSpace(cost.Currency))
+		if cost.Currency == "" {
+			cost.Currency = "USD"
+		}
+		if len(cost.Currency) != 3 {
+			return nil, fmt.Errorf("currency for %s must be a 3-letter code", key)
+		}
+		if cost.BillingDay < 0 || cost.BillingDay > 28 {
+			return nil, fmt.Errorf("billing day for %s must be between 1 and 28", key)
+		}
+		cost.Plan = strings.TrimSpace(cost.Plan)
+		if len(cost.Plan) > 64 {
+			return nil, fmt.Errorf("plan name for %s is too long", key)
+		}
+		out[key] = cost
+	}
+	return out, nil
+}
+
+func (h *Handler) loadSubscriptionCosts() map[string]subscriptionCost {
+	costs := map[string]subscriptionCost{}
+	if h.store == nil {
+		return costs
+	}
+	raw, err := h.store.GetSetting(settingSubscriptionCosts)
+	if err != nil || raw == "" {
+		return costs
+	}
+	if err := json.Unmarshal([]byte(raw), &costs); err != nil || costs == nil {
+		return map[string]subscriptionCost{}
+	}
+	return costs
+}
+
+// valueQuota is one quota's use of its windows in a billing period.
+type valueQuota struct {
+	Name            string   `json:"name"`
+	Label           string   `json:"label"`
+	Windows         int      `json:"windows"`
+	WindowsAtLimit  int      `json:"windowsAtLimit"`
+	ShareUsed       float64  `json:"shareUsed"`       // average window peak, percent of paid capacity
+	ConsumedWindows float64  `json:"consumedWindows"` // full windows' worth consumed
+	CostPerWindow   *float64 `json:"costPerWindow,omitempty"`
+}
+
+// valuePeriod is one billing period of a subscription.
+type valuePeriod struct {
+	Start         time.Time    `json:"start"`
+	End           time.Time    `json:"end"`
+	Current       bool         `json:"current"`
+	Price         *float64     `json:"price,omitempty"` // prorated for the current period
+	BindingQuota  string       `json:"bindingQuota,omitempty"`
+	ShareUsed     float64      `json:"shareUsed"`
+	CostPerWindow *float64     `json:"costPerWindow,omitempty"`
+	Quotas        []valueQuota `json:"quotas"`
+}
+
+// valueSuggestion recommends keeping, downgrading or upgrading a plan.
+type valueSuggestion struct {
+	Action       string  `json:"action"` // "upgrade", "downgrade", "keep" or "insufficient_data"
+	Reason       string  `json:"reason"`
+	LimitHitRate float64 `json:"limitHitRate"`
+	ShareUsed    float64 `json:"shareUsed"`
+}
+
+// subscriptionValue is the value-for-money analysis of one subscription.
+type subscriptionValue struct {
+	ID           string           `json:"id"`
+	Provider     string           `json:"provider"`
+	Label        string           `json:"label"`
+	Subtitle     string           `json:"subtitle,omitempty"`
+	DetectedPlan string           `json:"detectedPlan,omitempty"`
+	Configured   bool             `json:"configured"`
+	MonthlyPrice float64          `json:"monthlyPrice,omitempty"`
+	Currency     string           `json:"currency,omitempty"`
+	Plan         string           `json:"plan,omitempty"`
+	BillingDay   int              `json:"billingDay"`
+	PlanMismatch bool             `json:"planMismatch"`
+	Supported    bool             `json:"supported"`
+	Periods      []valuePeriod    `json:"periods"`
+	Suggestion   *valueSuggestion `json:"suggestion,omitempty"`
+}
+
+// SubscriptionValue handles GET /api/insights/value. For every subscription it
+// reports, per billing period, the share of paid capacity used, the cost per
+// full window consumed and how often windows hit their limit, and suggests a
+// downgrade or upgrade from those numbers.
+func (h *Handler) SubscriptionValue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	loc, err := h.heatmapLocation(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if h.store == nil {
+		respondError(w, http.StatusInternalServerError, "store not available")
+		return
+	}
+	periods := defaultValuePeriods
+	if v, err := strconv.Atoi(r.URL.Query().Get("periods")); err == nil && v > 0 {
+		periods = min(v, maxValuePeriods)
+	}
+
+	now := time.Now().UTC()
+	costs := h.loadSubscriptionCosts()
+	subscriptions := []subscriptionValue{}
+	totals := map[string]float64{}
+	for _, source := range h.dashboardProviderPayloads() {
+		sub := h.buildSubscriptionValue(source, costs, periods, loc, now)
+		if sub.Configured {
+			totals[sub.Currency] += sub.MonthlyPrice
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"timezone":      loc.String(),
+		"subscriptions": subscriptions,
+		"monthlyTotals": totals,
+	})
+}
+
+func (h *Handler) buildSubscriptionValue(source providerPayload, costs map[string]subscriptionCost, periods int, loc *time.Location, now time.Time) subscriptionValue {
+	base := providerKeyBase(source.ID)
+	sub := subscriptionValue{
+		ID:           source.ID,
+		Provider:     base,
+		Label:        source.Label,
+		Subtitle:     source.Subtitle,
+		DetectedPlan: firstString(source.Payload, "planType", "copilotPlan", "planName", "tier", "membership"),
+		BillingDay:   1,
+		Periods:      []valuePeriod{},
+	}
+	cost, configured := costs[source.ID]
+	if configured {
+		sub.Configured = true
+		sub.MonthlyPrice = cost.MonthlyPrice
+		sub.Currency = cost.Currency
+		sub.Plan = cost.Plan
+		if cost.BillingDay > 0 {
+			sub.BillingDay = cost.BillingDay
+		}
+		sub.PlanMismatch = cost.Plan != "" && sub.DetectedPlan != "" && !strings.EqualFold(cost.Plan, sub.DetectedPlan)
+	}
+
+	spans := billingPeriods(now, loc, sub.BillingDay, periods)
+	accountID := source.AccountID
+	if accountID <= 0 {
+		accountID = DefaultCodexAccountID
+	}
+	series, supported, err := h.quotaSeries(base, accountID, spans[len(spans)-1][0], now)
+	if !supported {
+		return sub
+	}
+	if err != nil {
+		h.logger.Error("Failed to query subscription value data", "provider", source.ID, "error", err)
+		return sub
+	}
+	sub.Supported = true
+
+	for i, span := range spans {
+		period := valuePeriod{Start: span[0], End: span[1], Current: i == 0, Quotas: []valueQuota{}}
+		if configured {
+			price := cost.MonthlyPrice
+			if period.Current {
+				price *= now.Sub(span[0]).Seconds() / span[1].Sub(span[0]).Seconds()
+			}
+			period.Price = &price
+		}
+		for _, s := range series {
+			q := buildValueQuota(s, span[0], span[1])
+			if q.Windows == 0 {
+				continue
+			}
+			if period.Price != nil && q.ConsumedWindows > 0 {
+				perWindow := *period.Price / q.ConsumedWindows
+				q.CostPerWindow = &perWindow
+			}
+			period.Quotas = append(period.Quotas, q)
+			if q.ShareUsed >= period.ShareUsed {
+				period.BindingQuota = q.Label
+				period.ShareUsed = q.ShareUsed
+				period.CostPerWindow = q.CostPerWindow
+			}
+		}
+		sub.Periods = append(sub.Periods, period)
+	}
+	sub.Suggestion = suggestSubscriptionChange(series, spans[len(spans)-1][0], now)
+	return sub
+}
+
+// billingPeriods returns the current billing period and the count-1 before
+// it, newest first, as [start, end) pairs. Periods start at midnight on
+// billingDay in loc.
+func billingPeriods(now time.Time, loc *time.Location, billingDay, count int) [][2]time.Time {
+	local := now.In(loc)
+	start := time.Date(local.Year(), local.Month(), billingDay, 0, 0, 0, 0, loc)
+	if start.After(local) {
+		start = start.AddDate(0, -1, 0)
+	}
+	spans := make([][2]time.Time, 0, count)
+	for i := 0; i < count; i++ {
+		spans = append(spans, [2]time.Time{start, start.AddDate(0, 1, 0)})
+		start = start.AddDate(0, -1, 0)
+	}
+	return spans
+}
+
+// quotaWindows splits the samples in [start, end) into windows at each
+// reset, a drop of more than valueResetDrop points, and returns each
+// window's peak and the points consumed in it. The first sample of a window
+// counts in full.
+func quotaWindows(samples []store.QuotaSample, start, end time.Time) (peaks, consumed []float64) {
+	prev := -1.0
+	for _, sample := range samples {
+		if sample.At.Before(start) || !sample.At.Before(end) {
+			continue
+		}
+		switch {
+		case prev < 0 || sample.Percent < prev-valueResetDrop:
+			peaks = append(peaks, sample.Percent)
+			consumed = append(consumed, sample.Percent)
+		case sample.Percent > prev:
+			last := len(peaks) - 1
+			consumed[last] += sample.Percent - prev
+			peaks[last] = math.Max(peaks[last], sample.Percent)
+		}
+		prev = sample.Percent
+	}
+	return peaks, consumed
+}
+
+func buildValueQuota(s store.QuotaSeries, start, end time.Time) valueQuota {
+	q := valueQuota{Name: s.Name, Label: s.Label}
+	peaks, consumed := quotaWindows(s.Samples, start, end)
+	q.Windows = len(peaks)
+	if q.Windows == 0 {
+		return q
+	}
+	var peakSum float64
+	for i, peak := range peaks {
+		peakSum += peak
+		q.ConsumedWindows += consumed[i] / 100
+		if peak >= valueLimitPercent {
+			q.WindowsAtLimit++
+		}
+	}
+	q.ShareUsed = peakSum / float64(q.Windows)
+	return q
+}
+
+// suggestSubscriptionChange looks at every window since start. Frequent
+// limit hits on any quota suggest an upgrade; low peaks with no limit hits
+// on every quota suggest a downgrade.
+func suggestSubscriptionChange(series []store.QuotaSeries, start, end time.Time) *valueSuggestion {
+	var quotas []valueQuota
+	for _, s := range series {
+		if q := buildValueQuota(s, start, end); q.Windows >= valueMinWindows {
+			quotas = append(quotas, q)
+		}
+	}
+	if len(quotas) == 0 {
+		return &valueSuggestion{Action: "insufficient_data", Reason: "Not enough completed windows yet to judge this plan."}
+	}
+	sort.SliceStable(quotas, func(i, j int) bool {
+		return float64(quotas[i].WindowsAtLimit)/float64(quotas[i].Windows) >
+			float64(quotas[j].WindowsAtLimit)/float64(quotas[j].Windows)
+	})
+	worst := quotas[0]
+	s := &valueSuggestion{LimitHitRate: float64(worst.WindowsAtLimit) / float64(worst.Windows)}
+	for _, q := range quotas {
+		s.ShareUsed = math.Max(s.ShareUsed, q.ShareUsed)
+	}
+	switch {
+	case s.LimitHitRate >= valueUpgradeHitRate:
+		s.Action = "upgrade"
+		s.Reason = fmt.Sprintf("%s hit 100%% in %d of %d windows; a higher plan would avoid the stalls.",
+			worst.Label, worst.WindowsAtLimit, worst.Windows)
+	case worst.WindowsAtLimit == 0 && s.ShareUsed < valueDowngradeShare:
+		s.Action = "downgrade"
+		s.Reason = fmt.Sprintf("Windows peak at %.0f%% on average and never hit the limit; a smaller plan would likely do.", s.ShareUsed)
+	default:
+		s.Action = "keep"
+		s.Reason = fmt.Sprintf("Windows peak at %.0f%% on average and rarely hit the limit.", s.ShareUsed)
+	}
+	return s
+}
@@ -0,0 +1,179 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/api"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/tracker"
+)
+
+func TestParseSubscriptionCosts(t *testing.T) {
+	t.Parallel()
+	costs, err := parseSubscriptionCosts(json.RawMessage(`{
+		"anthropic": {"monthly_price": 200, "currency": "usd", "plan": " Max 20x ", "billing_day": 15},
+		"codex:2": {"monthly_price": 20},
+		"cursor": {"monthly_price": 0}
+	}`))
+	if err != nil {
+		t.Fatalf("parseSubscriptionCosts: %v", err)
+	}
+	if len(costs) != 2 {
+		t.Fatalf("costs = %+v, want the unpriced cursor entry dropped", costs)
+	}
+	if c := costs["anthropic"]; c.Currency != "USD" || c.Plan != "Max 20x" || c.BillingDay != 15 {
+		t.Errorf("anthropic = %+v", c)
+	}
+	if c := costs["codex:2"]; c.Currency != "USD" {
+		t.Errorf("codex:2 currency = %q, want USD default", c.Currency)
+	}
+
+	for _, raw := range []string{
+		`{"Bad Key": {"monthly_price": 10}}`,
+		`{"anthropic": {"monthly_price": 10, "currency": "dollars"}}`,
+		`{"anthropic": {"monthly_price": 10, "billing_day": 31}}`,
+		`{"anthropic": {"monthly_price": 1000000}}`,
+		`[]`,
+	} {
+		if _, err := parseSubscriptionCosts(json.RawMessage(raw)); err == nil {
+			t.Errorf("parseSubscriptionCosts(%s) should fail", raw)
+		}
+	}
+}
+
+func TestBillingPeriods(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	spans := billingPeriods(now, time.UTC, 15, 3)
+	want := []string{"2026-02-15", "2026-01-15", "2025-12-15"}
+	for i, span := range spans {
+		if got := span[0].Format("2006-01-02"); got != want[i] {
+			t.Errorf("period %d starts %s, want %s", i, got, want[i])
+		}
+		if !span[1].Equal(span[0].AddDate(0, 1, 0)) {
+			t.Errorf("period %d ends %s, want one month after start", i, span[1])
+		}
+	}
+}
+
+func TestBuildValueQuota_SplitsWindowsAtResets(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	var samples []store.QuotaSample
+	for i, pct := range []float64{20, 100, 20, 100, 20, 50, 48, 20, 100} {
+		samples = append(samples, store.QuotaSample{At: start.Add(time.Duration(i) * time.Hour), Percent: pct})
+	}
+	q := buildValueQuota(store.QuotaSeries{Name: "five_hour", Label: "5-Hour Limit", Samples: samples}, start, start.Add(24*time.Hour))
+	// The small 50 -> 48 dip is noise, not a reset.
+	if q.Windows != 4 || q.WindowsAtLimit != 3 {
+		t.Errorf("windows = %d (%d at limit), want 4 (3 at limit)", q.Windows, q.WindowsAtLimit)
+	}
+	if q.ConsumedWindows != 3.5 || q.ShareUsed != 87.5 {
+		t.Errorf("consumed %.2f windows at %.1f%% share, want 3.5 at 87.5%%", q.ConsumedWindows, q.ShareUsed)
+	}
+
+	s := suggestSubscriptionChange([]store.QuotaSeries{{Name: "five_hour", Label: "5-Hour Limit", Samples: samples}}, start, start.Add(24*time.Hour))
+	if s.Action != "upgrade" || s.LimitHitRate != 0.75 {
+		t.Errorf("suggestion = %+v, want upgrade at 0.75 hit rate", s)
+	}
+
+	var light []store.QuotaSample
+	for i, pct := range []float64{10, 30, 5, 25, 5, 20} {
+		light = append(light, store.QuotaSample{At: start.Add(time.Duration(i) * time.Hour), Percent: pct})
+	}
+	if s := suggestSubscriptionChange([]store.QuotaSeries{{Name: "five_hour", Samples: light}}, start, start.Add(24*time.Hour)); s.Action != "downgrade" {
+		t.Errorf("light usage suggestion = %+v, want downgrade", s)
+	}
+	if s := suggestSubscriptionChange([]store.QuotaSeries{{Name: "five_hour", Samples: light[:2]}}, start, start.Add(24*time.Hour)); s.Action != "insufficient_data" {
+		t.Errorf("single window suggestion = %+v, want insufficient_data", s)
+	}
+}
+
+func TestHandler_SubscriptionValue(t *testing.T) {
+	t.Parallel()
+	s, err := store.New(":memory:")
+	if err != nil {
+		t.Fatalf("store.New: %v", err)
+	}
+	defer s.Close()
+
+	now := time.Now().UTC()
+	for i, util := range []float64{20, 100, 20, 100, 20, 50, 20, 100} {
+		snap := &api.AnthropicSnapshot{
+			CapturedAt: now.Add(time.Duration(i-8) * time.Minute),
+			Quotas:     []api.AnthropicQuota{{Name: "five_hour", Utilization: util}},
+		}
+		if _, err := s.InsertAnthropicSnapshot(snap); err != nil {
+			t.Fatalf("InsertAnthropicSnapshot: %v", err)
+		}
+	}
+
+	cfg := createTestConfigWithSynthetic()
+	cfg.AnthropicToken = "test_anthropic_token"
+	h := NewHandler(s, tracker.New(s, nil), nil, nil, cfg)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/settings",
+		strings.NewReader(`{"subscription_costs": {"anthropic": {"monthly_price": 200, "plan": "Max 20x"}}}`))
+	rr := httptest.NewRecorder()
+	h.UpdateSettings(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("UpdateSettings: %d %s", rr.Code, rr.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/insights/value?tz=UTC", nil)
+	rr = httptest.NewRecorder()
+	h.SubscriptionValue(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		Subscriptions []subscriptionValue `json:"subscriptions"`
+		MonthlyTotals map[string]float64  `json:"monthlyTotals"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	if resp.MonthlyTotals["USD"] != 200 {
+		t.Errorf("monthly totals = %v, want 200 USD", resp.MonthlyTotals)
+	}
+	var anth *subscriptionValue
+	for i := range resp.Subscriptions {
+		if resp.Subscriptions[i].ID == "anthropic" {
+			anth = &resp.Subscriptions[i]
+		}
+	}
+	if anth == nil || !anth.Configured || !anth.Supported || len(anth.Periods) != defaultValuePeriods {
+		t.Fatalf("anthropic = %+v, want a configured, supported subscription with %d periods", anth, defaultValuePeriods)
+	}
+	windows := 0
+	for _, p := range anth.Periods {
+		if p.Price == nil {
+			t.Errorf("period %s has no price", p.Start)
+		}
+		for _, q := range p.Quotas {
+			windows += q.Windows
+			if q.CostPerWindow == nil {
+				t.Errorf("quota %s in period %s has no cost per window", q.Name, p.Start)
+			}
+		}
+	}
+	if windows != 4 {
+		t.Errorf("windows = %d, want 4", windows)
+	}
+	if anth.Suggestion == nil || anth.Suggestion.Action != "upgrade" {
+		t.Errorf("suggestion = %+v, want upgrade", anth.Suggestion)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/settings",
+		strings.NewReader(`{"subscription_costs": {"anthropic": {"monthly_price": 20, "currency": "US"}}}`))
+	rr = httptest.NewRecorder()
+	h.UpdateSettings(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("invalid currency: expected 400, got %d", rr.Code)
+	}
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/onllm-dev/onwatch/v2/internal/agent"
 	"github.com/onllm-dev/onwatch/v2/internal/api"
+	apiintegrations "github.com/onllm-dev/onwatch/v2/internal/api_integrations"
 	"github.com/onllm-dev/onwatch/v2/internal/config"
 	"github.com/onllm-dev/onwatch/v2/internal/menubar"
 	"github.com/onllm-dev/onwatch/v2/internal/notify"
@@ -76,6 +77,13 @@ func (d dualHandler) WithGroup(name string) slog.Handler {
 
 func main() {
 	if err := runWithCrashCapture(); err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			if exitErr.err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", exitErr.err)
+			}
+			os.Exit(exitErr.code)
+		}
 		if !errors.Is(err, errCodexProfileRefreshAborted) {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		}
@@ -457,6 +465,16 @@ func run() error {
 	}
 
 	// Phase 2: Handle subcommands (both with and without -- prefix)
+	// "headroom", "check" and "proxy" go first: their flags may name "codex"
+	if hasCommand("headroom") {
+		return runHeadroomCommand()
+	}
+	if hasCommand("check") {
+		return runCheckCommand()
+	}
+	if hasCommand("proxy") {
+		return runProxyCommand()
+	}
 	// Note: "codex" must be checked before "status" because "codex profile status" contains "status"
 	if hasCommand("codex") {
 		return runCodexCommand()
@@ -1174,6 +1192,7 @@ func run() error {
 	var apiIntegrationsAg *agent.APIIntegrationsIngestAgent
 	if cfg.APIIntegrationsEnabled {
 		apiIntegrationsAg = agent.NewAPIIntegrationsIngestAgent(db, cfg.APIIntegrationsDir, cfg.APIIntegrationsRetention, logger)
+		apiIntegrationsAg.SetSources(cfg.APIIntegrationsSources)
 	}
 
 	// Create notification engine
@@ -1183,6 +1202,12 @@ func run() error {
 	notifier.Reload()
 	notifier.ConfigureSMTP()
 	notifier.ConfigurePush()
+	dashboardURL := fmt.Sprintf("http://localhost:%d%s/", cfg.Port, cfg.BasePath)
+	notifier.SetDashboardURL(dashboardURL)
+	notifier.SetDataDir(filepath.Dir(cfg.DBPath))
+	if err := notifier.ConfigureDesktop(dashboardURL); err != nil {
+		logger.Debug("Desktop notifications unavailable", "error", err)
+	}
 
 	// Wire notifier to agents
 	if ag != nil {
@@ -1461,6 +1486,7 @@ func run() error {
 	handler := web.NewHandler(db, tr, logger, nil, cfg, zaiTr)
 	handler.SetVersion(version)
 	handler.SetNotifier(notifier)
+	handler.SetAPIIntegrationPricing(loadAPIIntegrationPricing(cfg, logger))
 	if anthropicTr != nil {
 		handler.SetAnthropicTracker(anthropicTr)
 	}
@@ -1555,6 +1581,19 @@ func run() error {
 	loginRateLimiter := web.NewLoginRateLimiter(1000)
 	handler.SetRateLimiter(loginRateLimiter)
 
+	// Telegram commands answer from the same snapshot as the menubar, so start
+	// the bot only after every tracker is attached to the handler.
+	notifier.SetSnapshotProvider(handler.BuildMenubarSnapshot)
+	if err := notifier.ConfigureTelegram(); err != nil {
+		logger.Warn("Failed to configure Telegram bot", "error", err)
+	}
+	notifier.SetBudgetProvider(handler.BudgetStatuses)
+	notifier.SetReliabilityProvider(handler.APIIntegrationReliabilityStatuses)
+	notifier.StartReports()
+	notifier.StartAnomalyDetection()
+	notifier.StartBudgetChecks()
+	notifier.StartReliabilityChecks()
+
 	server := web.NewServer(cfg.Port, handler, logger, cfg.AdminUser, cfg.AdminPassHash, cfg.Host, cfg.BasePath, cfg.MetricsToken)
 
 	// Setup signal handling
@@ -1639,6 +1678,7 @@ func run() error {
 	// Cancel context to stop agent
 	cancel()
 	agentMgr.StopAll()
+	notifier.Close()
 	_ = stopMenubarProcess(cfg.TestMode)
 
 	// Give agent a moment to clean up
@@ -2130,6 +2170,13 @@ func printHelp() {
 	fmt.Println("  stop, --stop       Stop the running onwatch instance")
 	fmt.Println("  status, --status   Show status of the running instance")
 	fmt.Println("  update, --update   Check for updates and self-update")
+	fmt.Println("  headroom           Rank providers by remaining capacity (needs a running instance)")
+	fmt.Println("                     [--category coding|api] [--provider a,b] [--min-remaining N] [--best] [--json]")
+	fmt.Println("  check              Exit non-zero when a quota is over a threshold (see 'onwatch check --help')")
+	fmt.Println("                     [--provider NAME] [--quota NAME] [--max PERCENT] [--json] [--db PATH]")
+	fmt.Println("                     Exit codes: 0 within --max, 1 over --max, 2 bad flags or no match, 3 no data")
+	fmt.Println("  proxy              Forward LLM API calls and record their usage (see 'onwatch proxy --help')")
+	fmt.Println("                     [--listen ADDR] [--route NAME=PROVIDER:URL] [--key-alias NAME=KEY_SUFFIX]")
 	fmt.Println()
 	fmt.Println("Codex Profile Management:")
 	fmt.Println("  codex profile save <name>    Save current Codex credentials as a named profile")
@@ -2179,6 +2226,8 @@ func printHelp() {
 	fmt.Println("  onwatch status                    # Check if running")
 	fmt.Println("  onwatch --status                  # Same as 'status'")
 	fmt.Println("  onwatch update                    # Check for updates and self-update")
+	fmt.Println("  onwatch headroom --category coding --best # Coding agent with the most room")
+	fmt.Println("  onwatch check --provider anthropic --quota five_hour --max 85 # Gate a nightly job")
 	fmt.Println("  onwatch --test --debug            # Run test instance (isolated)")
 	fmt.Println("  onwatch --test stop               # Stop only test instance")
 	fmt.Println("  onwatch --test status             # Check test instance status")
@@ -2243,3 +2292,15 @@ func initEncryptionSalt(db *store.Store, logger *slog.Logger) error {
 	logger.Info("Generated and stored new encryption salt")
 	return nil
 }
+
+// loadAPIIntegrationPricing returns the model pricing catalog for API
+// integration cost estimates. An unreadable or invalid pricing file is logged
+// and the built-in prices are used instead.
+func loadAPIIntegrationPricing(cfg *config.Config, logger *slog.Logger) *apiintegrations.PricingCatalog {
+	catalog, err := apiintegrations.LoadPricingCatalog(cfg.APIIntegrationsPricingFile)
+	if err != nil {
+		logger.Warn("Failed to load API integration pricing file, using built-in prices", "error", err)
+		return apiintegrations.BuiltinPricingCatalog()
+	}
+	return catalog
+}
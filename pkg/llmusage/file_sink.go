@@ -0,0 +1,134 @@
+package llmusage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileSink appends events to JSONL files in a directory tailed by onWatch.
+//
+// Files are named <name>-<YYYYMMDD>.jsonl after the UTC day they were
+// started, and a new file is started each day or once the current one reaches
+// MaxBytes (<name>-<YYYYMMDD>-2.jsonl and so on). Files are never renamed,
+// since onWatch tracks its position in each file by path, and never deleted;
+// remove old ones once onWatch has ingested them.
+type FileSink struct {
+	dir  string
+	name string
+	// MaxBytes starts a new file once the current one is this large. Zero
+	// means one file per day.
+	MaxBytes int64
+
+	mu   sync.Mutex
+	now  func() time.Time
+	file *os.File
+	path string
+	day  string
+	seq  int
+	size int64
+}
+
+// NewFileSink creates a FileSink writing <name>-*.jsonl files into dir, or
+// into DefaultDir() when dir is empty. The directory is created if needed.
+func NewFileSink(dir, name string) (*FileSink, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid file name prefix %q", name)
+	}
+	if dir == "" {
+		dir = DefaultDir()
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create events directory: %w", err)
+	}
+	return &FileSink{dir: dir, name: name, now: time.Now}, nil
+}
+
+// Write appends event as one line.
+func (s *FileSink) Write(event Event) error {
+	line, err := event.MarshalLine()
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.rotate(int64(len(line))); err != nil {
+		return err
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// Path returns the file currently written to, or "" before the first Write.
+func (s *FileSink) Path() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.path
+}
+
+// Close closes the current file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// rotate makes sure the open file belongs to today and has room for n more
+// bytes, moving on to the next file when it does not.
+func (s *FileSink) rotate(n int64) error {
+	day := s.now().UTC().Format("20060102")
+	full := s.MaxBytes > 0 && s.size > 0 && s.size+n > s.MaxBytes
+	if s.file != nil && day == s.day && !full {
+		return nil
+	}
+	if day != s.day {
+		s.day, s.seq = day, 0
+	}
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+	// Continue after the files an earlier process left for today.
+	for {
+		s.seq++
+		path := s.fileName(s.seq)
+		size := int64(0)
+		if info, err := os.Stat(path); err == nil {
+			if _, err := os.Stat(s.fileName(s.seq + 1)); err == nil {
+				continue
+			}
+			if s.MaxBytes > 0 && info.Size() > 0 && info.Size()+n > s.MaxBytes {
+				continue
+			}
+			size = info.Size()
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("open %s: %w", path, err)
+		}
+		s.file, s.path, s.size = f, path, size
+		return nil
+	}
+}
+
+func (s *FileSink) fileName(seq int) string {
+	if seq <= 1 {
+		return filepath.Join(s.dir, fmt.Sprintf("%s-%s.jsonl", s.name, s.day))
+	}
+	return filepath.Join(s.dir, fmt.Sprintf("%s-%s-%d.jsonl", s.name, s.day, seq))
+}
@@ -0,0 +1,224 @@
+package llmusage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IngestPath is the onWatch endpoint HTTPSink posts events to, relative to
+// the dashboard URL.
+const IngestPath = "/api/api-integrations/events"
+
+// maxHTTPBatch caps the events sent in one request, well inside the
+// endpoint's 4 MB body limit.
+const maxHTTPBatch = 200
+
+// ErrQueueFull is returned by HTTPSink.Write when events arrive faster than
+// they can be posted.
+var ErrQueueFull = errors.New("llmusage: event queue is full")
+
+// ErrClosed is returned by HTTPSink.Write after Close.
+var ErrClosed = errors.New("llmusage: sink is closed")
+
+// HTTPSinkOptions configures an HTTPSink.
+type HTTPSinkOptions struct {
+	// Token is an onWatch ingest token, sent as a bearer token. It can only
+	// post events, so prefer it to the dashboard credentials.
+	Token string
+	// Username and Password are the onWatch dashboard credentials, used
+	// when Token is empty.
+	Username string
+	Password string
+	// Client sends the requests; http.DefaultClient when nil. It must not
+	// itself be instrumented with a Transport writing to this sink.
+	Client *http.Client
+	// QueueSize is the number of events buffered before Write fails with
+	// ErrQueueFull (default 1024).
+	QueueSize int
+	// FlushInterval is how long events wait to be batched (default 1s).
+	FlushInterval time.Duration
+	// MaxRetries is how many times a batch is resent after a network error
+	// or a 429 or 5xx response (default 3; negative disables retries).
+	// RetryBackoff is the wait before the first retry, doubled for each
+	// further one (default 500ms).
+	MaxRetries   int
+	RetryBackoff time.Duration
+	// OnError is called when a batch is finally dropped, because its retries
+	// ran out or onWatch refused it, and when onWatch rejects some of its
+	// events as invalid. Those events are lost.
+	OnError func(error)
+}
+
+// HTTPSink posts events to onWatch's ingest endpoint, for services that do
+// not share a filesystem with the daemon. Events are queued and sent in
+// batches from a background goroutine; Close sends what is left.
+type HTTPSink struct {
+	endpoint string
+	opts     HTTPSinkOptions
+
+	mu     sync.Mutex
+	closed bool
+	queue  chan []byte
+	done   chan struct{}
+}
+
+// NewHTTPSink creates an HTTPSink for the onWatch dashboard at baseURL, e.g.
+// "http://localhost:9211" or, behind a base path, "https://host/onwatch".
+func NewHTTPSink(baseURL string, opts HTTPSinkOptions) (*HTTPSink, error) {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+		return nil, fmt.Errorf("onWatch URL must start with http:// or https://, got %q", baseURL)
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1024
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = 3
+	} else if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = 500 * time.Millisecond
+	}
+	s := &HTTPSink{
+		endpoint: baseURL + IngestPath,
+		opts:     opts,
+		queue:    make(chan []byte, opts.QueueSize),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// Write validates event and queues it for sending. It does not block.
+func (s *HTTPSink) Write(event Event) error {
+	line, err := event.MarshalLine()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	select {
+	case s.queue <- line:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close sends the queued events and stops the sink. It waits for retries of
+// the last batch, if onWatch is unreachable.
+func (s *HTTPSink) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	<-s.done
+	return nil
+}
+
+func (s *HTTPSink) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+
+	var batch [][]byte
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.send(batch); err != nil && s.opts.OnError != nil {
+			s.opts.OnError(err)
+		}
+		batch = nil
+	}
+	for {
+		select {
+		case line, ok := <-s.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, line)
+			if len(batch) >= maxHTTPBatch {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// send posts a batch, retrying with exponential backoff while onWatch is
+// unreachable or overloaded. Events are deduplicated by onWatch, so a batch
+// whose response was lost is safely resent as a whole.
+func (s *HTTPSink) send(batch [][]byte) error {
+	backoff := s.opts.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(batch)
+		if err == nil || !retry || attempt >= s.opts.MaxRetries {
+			if err != nil && retry {
+				err = fmt.Errorf("%w (dropped after %d retries)", err, attempt)
+			}
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post sends one batch as NDJSON. retry reports whether the failure may be
+// temporary.
+func (s *HTTPSink) post(batch [][]byte) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(bytes.Join(batch, []byte("\n"))))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("X-Requested-With", "llmusage")
+	if s.opts.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.opts.Token)
+	} else if s.opts.Username != "" || s.opts.Password != "" {
+		req.SetBasicAuth(s.opts.Username, s.opts.Password)
+	}
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("post %d events to onWatch: %w", len(batch), err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Rejected int `json:"rejected"`
+		Errors   []struct {
+			Line  int    `json:"line"`
+			Error string `json:"error"`
+		} `json:"errors"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err := json.Unmarshal(body, &result); err != nil || (resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest) {
+		retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("post %d events to onWatch: %s: %s", len(batch), resp.Status, strings.TrimSpace(string(body)))
+	}
+	if result.Rejected > 0 && len(result.Errors) > 0 {
+		return false, fmt.Errorf("onWatch rejected %d of %d events; line %d: %s", result.Rejected, len(batch), result.Errors[0].Line, result.Errors[0].Error)
+	}
+	return false, nil
+}
@@ -0,0 +1,128 @@
+// Package llmusage records the token usage of LLM API calls made by Go
+// programs as onWatch API integration events.
+//
+// Wrap an http.Client's transport in a Transport and every call to OpenAI,
+// Anthropic, Gemini, Mistral, OpenRouter or an OpenAI-compatible API, streamed
+// or not, successful or failed, is turned into an Event and handed to a Sink. FileSink
+// appends events to JSONL files in the directory the onWatch daemon tails;
+// HTTPSink posts them to the daemon's ingest endpoint.
+//
+//	sink, err := llmusage.NewFileSink("", "billing-worker")
+//	if err != nil {
+//		return err
+//	}
+//	defer sink.Close()
+//	client := &http.Client{Transport: &llmusage.Transport{
+//		Integration: "billing-worker",
+//		Sink:        sink,
+//	}}
+package llmusage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
+)
+
+// Event is one LLM API call, in the schema of onWatch's JSONL files.
+type Event struct {
+	Timestamp        time.Time // zero means now
+	Integration      string    // required
+	Provider         string    // required: a provider id such as "openai" or "deepseek"
+	Account          string    // "default" when empty
+	Model            string    // required
+	RequestID        string
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      *int // prompt plus completion when nil
+	CacheReadTokens  *int // part of PromptTokens
+	CacheWriteTokens *int // part of PromptTokens
+	ReasoningTokens  *int // part of CompletionTokens
+	AudioTokens      *int // part of the total
+	CostUSD          *float64
+	LatencyMS        *int
+	Status           string // "ok" or "error"; "ok" when empty
+	ErrorType        string // classifies failed calls, e.g. "rate_limit"
+	Metadata         map[string]interface{}
+}
+
+// MarshalLine encodes e as one JSONL line, without the trailing newline, and
+// validates it the way onWatch will when ingesting it.
+func (e Event) MarshalLine() ([]byte, error) {
+	ts := e.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	fields := map[string]interface{}{
+		"ts":                ts.UTC().Format(time.RFC3339Nano),
+		"integration":       e.Integration,
+		"provider":          e.Provider,
+		"model":             e.Model,
+		"prompt_tokens":     e.PromptTokens,
+		"completion_tokens": e.CompletionTokens,
+	}
+	if e.Account != "" {
+		fields["account"] = e.Account
+	}
+	if e.RequestID != "" {
+		fields["request_id"] = e.RequestID
+	}
+	if e.TotalTokens != nil {
+		fields["total_tokens"] = *e.TotalTokens
+	}
+	for key, value := range map[string]*int{
+		"cache_read_tokens":  e.CacheReadTokens,
+		"cache_write_tokens": e.CacheWriteTokens,
+		"reasoning_tokens":   e.ReasoningTokens,
+		"audio_tokens":       e.AudioTokens,
+	} {
+		if value != nil {
+			fields[key] = *value
+		}
+	}
+	if e.CostUSD != nil {
+		fields["cost_usd"] = *e.CostUSD
+	}
+	if e.LatencyMS != nil {
+		fields["latency_ms"] = *e.LatencyMS
+	}
+	if e.Status != "" {
+		fields["status"] = e.Status
+	}
+	if e.ErrorType != "" {
+		fields["error_type"] = e.ErrorType
+	}
+	if len(e.Metadata) > 0 {
+		fields["metadata"] = e.Metadata
+	}
+	line, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := usageevent.ParseLine(line, ""); err != nil {
+		return nil, err
+	}
+	return line, nil
+}
+
+// Sink receives the events captured by a Transport. Write must be safe for
+// concurrent use.
+type Sink interface {
+	Write(event Event) error
+}
+
+// DefaultDir returns the directory the onWatch daemon tails by default:
+// ONWATCH_API_INTEGRATIONS_DIR when set, otherwise ~/.onwatch/api-integrations.
+func DefaultDir() string {
+	if dir := os.Getenv("ONWATCH_API_INTEGRATIONS_DIR"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return "./api-integrations"
+	}
+	return filepath.Join(home, ".onwatch", "api-integrations")
+}
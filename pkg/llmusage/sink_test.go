@@ -0,0 +1,181 @@
+package llmusage
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testEvent(requestID string) Event {
+	return Event{
+		Timestamp:        time.Date(2026, 4, 3, 12, 0, 0, 0, time.UTC),
+		Integration:      "worker",
+		Provider:         "anthropic",
+		Model:            "claude-3-7-sonnet",
+		RequestID:        requestID,
+		PromptTokens:     10,
+		CompletionTokens: 5,
+	}
+}
+
+func TestEvent_MarshalLineValidates(t *testing.T) {
+	line, err := testEvent("msg_1").MarshalLine()
+	if err != nil {
+		t.Fatalf("MarshalLine: %v", err)
+	}
+	if !strings.Contains(string(line), `"ts":"2026-04-03T12:00:00Z"`) || strings.Contains(string(line), "cost_usd") {
+		t.Fatalf("line=%s", line)
+	}
+
+	bad := testEvent("msg_2")
+	bad.Provider = "not a provider"
+	if _, err := bad.MarshalLine(); err == nil {
+		t.Fatal("MarshalLine accepted an invalid provider")
+	}
+}
+
+func TestFileSink_RotatesByDayAndSize(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir, "worker")
+	if err != nil {
+		t.Fatalf("NewFileSink: %v", err)
+	}
+	defer sink.Close()
+	now := time.Date(2026, 4, 3, 23, 59, 0, 0, time.UTC)
+	sink.now = func() time.Time { return now }
+	line, _ := testEvent("msg_1").MarshalLine()
+	sink.MaxBytes = int64(2 * (len(line) + 1))
+
+	for i := 0; i < 3; i++ {
+		if err := sink.Write(testEvent("msg_1")); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	now = now.Add(time.Minute)
+	if err := sink.Write(testEvent("msg_1")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	wantLines := map[string]int{"worker-20260403.jsonl": 2, "worker-20260403-2.jsonl": 1, "worker-20260404.jsonl": 1}
+	for name, want := range wantLines {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if got := strings.Count(string(data), "\n"); got != want {
+			t.Errorf("%s has %d lines, want %d", name, got, want)
+		}
+	}
+
+	// A new sink for the same day continues after the existing files.
+	sink.Close()
+	next, _ := NewFileSink(dir, "worker")
+	defer next.Close()
+	next.now = func() time.Time { return now.Add(-time.Minute) }
+	next.MaxBytes = sink.MaxBytes
+	if err := next.Write(testEvent("msg_2")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := filepath.Base(next.Path()); got != "worker-20260403-2.jsonl" {
+		t.Fatalf("resumed in %s, want worker-20260403-2.jsonl", got)
+	}
+}
+
+func TestHTTPSink_PostsBatches(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if r.URL.Path != "/onwatch"+IngestPath || r.Header.Get("X-Requested-With") == "" || user != "admin" || pass != "secret" {
+			http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"accepted": strings.Count(string(body), "\n") + 1, "rejected": 0})
+	}))
+	defer server.Close()
+
+	var errs []error
+	sink, err := NewHTTPSink(server.URL+"/onwatch/", HTTPSinkOptions{Username: "admin", Password: "secret", FlushInterval: time.Hour, OnError: func(err error) { errs = append(errs, err) }})
+	if err != nil {
+		t.Fatalf("NewHTTPSink: %v", err)
+	}
+	for _, id := range []string{"msg_1", "msg_2"} {
+		if err := sink.Write(testEvent(id)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	sink.Close()
+
+	if len(errs) != 0 {
+		t.Fatalf("post errors: %v", errs)
+	}
+	if len(bodies) != 1 || strings.Count(bodies[0], "\n") != 1 || !strings.Contains(bodies[0], "msg_2") {
+		t.Fatalf("bodies=%q", bodies)
+	}
+	if err := sink.Write(testEvent("msg_3")); err != ErrClosed {
+		t.Fatalf("Write after Close = %v, want ErrClosed", err)
+	}
+	if _, err := NewHTTPSink("localhost:9211", HTTPSinkOptions{}); err == nil {
+		t.Fatal("NewHTTPSink accepted a URL without scheme")
+	}
+}
+
+func TestHTTPSink_TokenAndRetries(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ingest-token" {
+			http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		attempts++
+		n := attempts
+		mu.Unlock()
+		if n < 3 {
+			http.Error(w, `{"error":"busy"}`, http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"accepted": 1, "rejected": 0})
+	}))
+	defer server.Close()
+
+	var errs []error
+	sink, err := NewHTTPSink(server.URL, HTTPSinkOptions{Token: "ingest-token", FlushInterval: time.Hour, RetryBackoff: time.Millisecond, OnError: func(err error) { errs = append(errs, err) }})
+	if err != nil {
+		t.Fatalf("NewHTTPSink: %v", err)
+	}
+	if err := sink.Write(testEvent("msg_1")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	sink.Close()
+	if len(errs) != 0 || attempts != 3 {
+		t.Fatalf("attempts=%d errs=%v, want success on the third attempt", attempts, errs)
+	}
+
+	// Refusals are not retried, and the dropped batch is reported.
+	attempts = 0
+	errs = nil
+	sink, err = NewHTTPSink(server.URL, HTTPSinkOptions{Token: "wrong", FlushInterval: time.Hour, RetryBackoff: time.Millisecond, OnError: func(err error) { errs = append(errs, err) }})
+	if err != nil {
+		t.Fatalf("NewHTTPSink: %v", err)
+	}
+	if err := sink.Write(testEvent("msg_2")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	sink.Close()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "401") {
+		t.Fatalf("errs=%v, want one 401 error", errs)
+	}
+}
@@ -0,0 +1,175 @@
+package llmusage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/usageevent"
+)
+
+// Transport is an http.RoundTripper that records the usage of LLM API calls.
+//
+// Calls to the public OpenAI, Anthropic, Gemini, Mistral and OpenRouter APIs,
+// and to OpenAI-compatible ones such as DeepSeek, Groq and xAI, are recognised
+// by host; set Provider to record calls to other hosts, such as a gateway, as
+// that provider. An event is written to Sink once a successful response body
+// has been read to the end or closed, so latency covers the whole response,
+// including streams. Responses without a usage block, such as OpenAI streams
+// requested without stream_options.include_usage, are not recorded. Error
+// responses, and requests that get no response, are recorded at once with
+// status "error", an error type and no tokens.
+type Transport struct {
+	// Base makes the requests; http.DefaultTransport when nil.
+	Base http.RoundTripper
+	// Sink receives the events. Required.
+	Sink Sink
+	// Integration names the calling application. Required.
+	Integration string
+	// Account is recorded with each event; "default" when empty.
+	Account string
+	// Provider, when set, is used for calls to every host.
+	Provider string
+	// Metadata is added to every event.
+	Metadata map[string]interface{}
+	// OnError is called with events the sink failed to write, which are
+	// otherwise dropped.
+	OnError func(error)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	provider := t.Provider
+	if provider == "" {
+		provider = usageevent.ProviderForHost(req.URL.Host)
+	}
+	if provider == "" || t.Sink == nil {
+		return base.RoundTrip(req)
+	}
+
+	requestModel, body, err := usageevent.PeekRequestModel(req.Body, req.URL.Path)
+	if err != nil {
+		return nil, err
+	}
+	if body != req.Body {
+		// RoundTrip must not modify the caller's request.
+		req = req.Clone(req.Context())
+		req.Body = body
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			t.writeFailure(provider, requestModel, req.URL.Path, start, usageevent.ErrorTypeForError(err), nil)
+		}
+		return resp, err
+	}
+	if resp.StatusCode >= 400 {
+		t.writeFailure(provider, requestModel, req.URL.Path, start, usageevent.ErrorTypeForHTTPStatus(resp.StatusCode), resp)
+		return resp, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, nil
+	}
+
+	status, stream := resp.StatusCode, usageevent.IsEventStream(resp.Header)
+	rateLimits := usageevent.RateLimitHeaders(resp.Header)
+	headerID := responseRequestID(resp)
+	usageevent.CaptureResponseUsage(resp, func(u *usageevent.ResponseUsage) {
+		if !u.Found {
+			return
+		}
+		latency := int(time.Since(start).Milliseconds())
+		event := Event{
+			Timestamp:        time.Now(),
+			Integration:      t.Integration,
+			Provider:         provider,
+			Account:          t.Account,
+			Model:            u.Model,
+			RequestID:        u.ResponseID,
+			PromptTokens:     u.InputTokens,
+			CompletionTokens: u.OutputTokens,
+			CacheReadTokens:  u.CacheReadTokens,
+			CacheWriteTokens: u.CacheWriteTokens,
+			ReasoningTokens:  u.ReasoningTokens,
+			AudioTokens:      u.AudioTokens,
+			CostUSD:          u.CostUSD,
+			LatencyMS:        &latency,
+			Metadata:         t.metadata(req.URL.Path),
+		}
+		if event.Model == "" {
+			event.Model = requestModel
+		}
+		if event.Model == "" {
+			event.Model = "unknown"
+		}
+		if event.RequestID == "" {
+			event.RequestID = headerID
+		}
+		event.Metadata["status"] = status
+		event.Metadata["stream"] = stream
+		if len(rateLimits) > 0 {
+			event.Metadata["ratelimit"] = rateLimits
+		}
+		t.write(event)
+	})
+	return resp, nil
+}
+
+// writeFailure records a call that failed with an error response, or with no
+// response when resp is nil. Its latency runs to the response headers.
+func (t *Transport) writeFailure(provider, model, path string, start time.Time, errorType string, resp *http.Response) {
+	latency := int(time.Since(start).Milliseconds())
+	if model == "" {
+		model = "unknown"
+	}
+	event := Event{
+		Timestamp:   time.Now(),
+		Integration: t.Integration,
+		Provider:    provider,
+		Account:     t.Account,
+		Model:       model,
+		LatencyMS:   &latency,
+		Status:      usageevent.StatusError,
+		ErrorType:   errorType,
+		Metadata:    t.metadata(path),
+	}
+	if resp != nil {
+		event.RequestID = responseRequestID(resp)
+		event.Metadata["status"] = resp.StatusCode
+		if rateLimits := usageevent.RateLimitHeaders(resp.Header); len(rateLimits) > 0 {
+			event.Metadata["ratelimit"] = rateLimits
+		}
+	}
+	t.write(event)
+}
+
+func (t *Transport) metadata(path string) map[string]interface{} {
+	metadata := make(map[string]interface{}, len(t.Metadata)+4)
+	for k, v := range t.Metadata {
+		metadata[k] = v
+	}
+	metadata["path"] = path
+	return metadata
+}
+
+func (t *Transport) write(event Event) {
+	if err := t.Sink.Write(event); err != nil && t.OnError != nil {
+		t.OnError(err)
+	}
+}
+
+// responseRequestID returns the request ID a provider sent in the response
+// headers, if any.
+func responseRequestID(resp *http.Response) string {
+	if id := resp.Header.Get("X-Request-Id"); id != "" {
+		return id
+	}
+	return resp.Header.Get("Request-Id")
+}
@@ -0,0 +1,153 @@
+package llmusage
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type memorySink struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (s *memorySink) Write(event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *memorySink) all() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Event(nil), s.events...)
+}
+
+func TestTransport_RecordsJSONUsage(t *testing.T) {
+	var gotBody string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "req_42")
+		fmt.Fprint(w, `{"object":"chat.completion","choices":[],"usage":{"prompt_tokens":11,"completion_tokens":4,"total_tokens":15}}`)
+	}))
+	defer upstream.Close()
+
+	sink := &memorySink{}
+	client := &http.Client{Transport: &Transport{Sink: sink, Integration: "worker", Provider: "openai", Metadata: map[string]interface{}{"env": "test"}}}
+	reqBody := `{"model":"gpt-4.1-mini","messages":[]}`
+	resp, err := client.Post(upstream.URL+"/v1/chat/completions", "application/json", strings.NewReader(reqBody))
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if gotBody != reqBody {
+		t.Fatalf("upstream got body %q, want %q", gotBody, reqBody)
+	}
+	events := sink.all()
+	if len(events) != 1 {
+		t.Fatalf("recorded %d events, want 1", len(events))
+	}
+	e := events[0]
+	if e.Integration != "worker" || e.Provider != "openai" || e.Model != "gpt-4.1-mini" || e.RequestID != "req_42" {
+		t.Fatalf("event=%+v", e)
+	}
+	if e.PromptTokens != 11 || e.CompletionTokens != 4 || e.LatencyMS == nil || e.Metadata["env"] != "test" {
+		t.Fatalf("event=%+v", e)
+	}
+	if _, err := e.MarshalLine(); err != nil {
+		t.Fatalf("MarshalLine: %v", err)
+	}
+}
+
+func TestTransport_RecordsStreamedGeminiUsage(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"candidates\":[],\"usageMetadata\":{\"promptTokenCount\":8}}\r\n\r\n")
+		w.(http.Flusher).Flush()
+		fmt.Fprint(w, "data: {\"candidates\":[],\"usageMetadata\":{\"promptTokenCount\":8,\"candidatesTokenCount\":30,\"thoughtsTokenCount\":12},\"responseId\":\"r-1\"}\r\n\r\n")
+	}))
+	defer upstream.Close()
+
+	sink := &memorySink{}
+	client := &http.Client{Transport: &Transport{Sink: sink, Integration: "worker", Provider: "gemini", Account: "team-a"}}
+	resp, err := client.Post(upstream.URL+"/v1beta/models/gemini-2.5-flash:streamGenerateContent?alt=sse", "application/json", strings.NewReader(`{"contents":[]}`))
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	events := sink.all()
+	if len(events) != 1 {
+		t.Fatalf("recorded %d events, want 1", len(events))
+	}
+	e := events[0]
+	if e.Model != "gemini-2.5-flash" || e.Account != "team-a" || e.RequestID != "r-1" || e.PromptTokens != 8 || e.CompletionTokens != 42 {
+		t.Fatalf("event=%+v", e)
+	}
+	if e.Metadata["stream"] != true {
+		t.Fatalf("metadata=%v", e.Metadata)
+	}
+}
+
+func TestTransport_SkipsUnknownHostsAndRecordsFailures(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.Header().Set("X-Request-Id", "req_fail")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+		fmt.Fprint(w, `{"usage":{"prompt_tokens":1,"completion_tokens":1}}`)
+	}))
+	defer upstream.Close()
+
+	sink := &memorySink{}
+	// No Provider: the test server's host is not a known LLM API.
+	client := &http.Client{Transport: &Transport{Sink: sink, Integration: "worker"}}
+	resp, err := client.Get(upstream.URL + "/ok")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if events := sink.all(); len(events) != 0 {
+		t.Fatalf("recorded %d events for an unknown host, want 0: %+v", len(events), events)
+	}
+
+	client.Transport.(*Transport).Provider = "openai"
+	resp, err = client.Post(upstream.URL+"/fail", "application/json", strings.NewReader(`{"model":"gpt-4.1"}`))
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	events := sink.all()
+	if len(events) != 1 {
+		t.Fatalf("recorded %d events, want 1: %+v", len(events), events)
+	}
+	e := events[0]
+	if e.Status != "error" || e.ErrorType != "rate_limit" || e.Model != "gpt-4.1" || e.RequestID != "req_fail" || e.PromptTokens != 0 || e.LatencyMS == nil {
+		t.Fatalf("event=%+v", e)
+	}
+	if _, err := e.MarshalLine(); err != nil {
+		t.Fatalf("MarshalLine: %v", err)
+	}
+
+	// A request that gets no response is recorded as a network error.
+	upstream.Close()
+	if _, err := client.Get(upstream.URL + "/ok"); err == nil {
+		t.Fatal("expected a connection error")
+	}
+	if events := sink.all(); len(events) != 2 || events[1].ErrorType != "network" || events[1].Model != "unknown" {
+		t.Fatalf("events=%+v", events)
+	}
+}
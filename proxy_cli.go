@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/onllm-dev/onwatch/v2/internal/config"
+	"github.com/onllm-dev/onwatch/v2/internal/proxy"
+	"github.com/onllm-dev/onwatch/v2/internal/store"
+	"github.com/onllm-dev/onwatch/v2/internal/web"
+)
+
+// proxyExitUsage is the exit status for bad arguments, as for `onwatch check`.
+const proxyExitUsage = 2
+
+// proxyCLIOptions holds the flags accepted by `onwatch proxy`.
+type proxyCLIOptions struct {
+	Listen     string
+	Routes     []proxy.Route
+	KeyAliases map[string]string
+	Help       bool
+}
+
+// parseProxyArgs reads the proxy flags from the arguments, including the
+// subcommand itself. --db is only validated; config.Load reads it. Unknown
+// flags and stray arguments are errors.
+func parseProxyArgs(args []string) (proxyCLIOptions, error) {
+	opts := proxyCLIOptions{Listen: proxy.DefaultListenAddr, KeyAliases: map[string]string{}}
+	sawCommand := false
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		switch name {
+		case "--help", "-h":
+			opts.Help = true
+		case "--listen", "--route", "--key-alias", "--db":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return opts, fmt.Errorf("%s needs a value", name)
+				}
+				i++
+				value = args[i]
+			}
+			switch name {
+			case "--listen":
+				opts.Listen = value
+			case "--route":
+				route, err := proxy.ParseRoute(value)
+				if err != nil {
+					return opts, err
+				}
+				opts.Routes = append(opts.Routes, route)
+			case "--key-alias":
+				alias, suffix, ok := strings.Cut(value, "=")
+				if !ok || strings.TrimSpace(alias) == "" || strings.TrimSpace(suffix) == "" {
+					return opts, fmt.Errorf("--key-alias must look like NAME=KEY_SUFFIX, got %q", value)
+				}
+				opts.KeyAliases[strings.TrimSpace(suffix)] = strings.TrimSpace(alias)
+			case "--db":
+				if value == "" {
+					return opts, fmt.Errorf("--db needs a value")
+				}
+			}
+		case "proxy":
+			if sawCommand {
+				return opts, fmt.Errorf("unexpected argument %q", args[i])
+			}
+			sawCommand = true
+		default:
+			if strings.HasPrefix(name, "-") {
+				return opts, fmt.Errorf("unknown flag %q (see 'onwatch proxy --help')", name)
+			}
+			return opts, fmt.Errorf("unexpected argument %q", args[i])
+		}
+	}
+	return opts, nil
+}
+
+// runProxyCommand handles `onwatch proxy`: a foreground reverse proxy that
+// records the usage of the LLM API calls passing through it.
+func runProxyCommand() error {
+	opts, err := parseProxyArgs(os.Args[1:])
+	if err != nil {
+		return &exitCodeError{code: proxyExitUsage, err: err}
+	}
+	if opts.Help {
+		printProxyHelp()
+		return nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	s, err := store.New(cfg.DBPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer s.Close()
+
+	logger := slog.Default()
+	s.SetAPIIntegrationCostEstimator(web.NewAPIIntegrationCostEstimator(s, loadAPIIntegrationPricing(cfg, logger)))
+	p := proxy.New(append(proxy.DefaultRoutes(), opts.Routes...), opts.KeyAliases, s, logger)
+	srv := &http.Server{
+		Addr:              opts.Listen,
+		Handler:           p,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("onWatch proxy listening on http://%s (routes: %s)\n", opts.Listen, strings.Join(p.RouteNames(), ", "))
+	fmt.Printf("Recording usage to %s\n", cfg.DBPath)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("proxy server: %w", err)
+	}
+	return nil
+}
+
+// printProxyHelp prints help for the proxy command.
+func printProxyHelp() {
+	fmt.Println("Usage Recording Proxy")
+	fmt.Println()
+	fmt.Println("Usage: onwatch proxy [--listen ADDR] [--route NAME=PROVIDER:URL] [--key-alias NAME=KEY_SUFFIX] [--db PATH]")
+	fmt.Println()
+	fmt.Println("Forwards LLM API calls to their upstream and records the token usage of")
+	fmt.Println("each response, streamed or not, as API Integrations events.")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Printf("  --listen ADDR                Address to listen on (default: %s)\n", proxy.DefaultListenAddr)
+	fmt.Println("  --route NAME=PROVIDER:URL    Add or replace a route; repeatable")
+	fmt.Println("  --key-alias NAME=KEY_SUFFIX  Record calls made with keys ending in KEY_SUFFIX")
+	fmt.Println("                               under account NAME; repeatable")
+	fmt.Println("  --db PATH                    Database to record into")
+	fmt.Println()
+	fmt.Println("Built-in routes (point a tool's base URL at http://ADDR/ROUTE):")
+	for _, r := range proxy.DefaultRoutes() {
+		fmt.Printf("  /%-12s %s\n", r.Name, r.Upstream)
+	}
+	fmt.Println()
+	fmt.Println("Calls are recorded with the route name as integration and an account")
+	fmt.Println("named after the API key (key-<last 4>). Tools can override both with the")
+	fmt.Printf("%s and %s request headers.\n", proxy.IntegrationHeader, proxy.AccountHeader)
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  onwatch proxy")
+	fmt.Println("  OPENAI_BASE_URL=http://127.0.0.1:9212/openai/v1 my-tool")
+	fmt.Println("  ANTHROPIC_BASE_URL=http://127.0.0.1:9212/anthropic claude")
+	fmt.Println("  onwatch proxy --route team-a=openai:https://gateway.internal/openai --key-alias ci=Xy9Q")
+}
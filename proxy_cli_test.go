@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/onllm-dev/onwatch/v2/internal/proxy"
+)
+
+func TestParseProxyArgs(t *testing.T) {
+	opts, err := parseProxyArgs([]string{"proxy", "--listen", "127.0.0.1:9300", "--route=team=anthropic:https://gw.internal/anthropic", "--key-alias", "ci=Xy9Q", "--db", "/tmp/x.db"})
+	if err != nil {
+		t.Fatalf("parseProxyArgs: %v", err)
+	}
+	if opts.Listen != "127.0.0.1:9300" {
+		t.Errorf("Listen = %q", opts.Listen)
+	}
+	if len(opts.Routes) != 1 || opts.Routes[0].Name != "team" || opts.Routes[0].Provider != "anthropic" {
+		t.Errorf("Routes = %+v", opts.Routes)
+	}
+	if opts.KeyAliases["Xy9Q"] != "ci" {
+		t.Errorf("KeyAliases = %v", opts.KeyAliases)
+	}
+
+	if opts, _ := parseProxyArgs([]string{"proxy"}); opts.Listen != proxy.DefaultListenAddr {
+		t.Errorf("default Listen = %q, want %q", opts.Listen, proxy.DefaultListenAddr)
+	}
+	for _, args := range [][]string{
+		{"proxy", "--route", "bad"},
+		{"proxy", "--key-alias", "ci"},
+		{"proxy", "--listen"},
+		{"proxy", "--lisen", "127.0.0.1:39556"},
+		{"proxy", "127.0.0.1:39556"},
+		{"proxy", "--db="},
+	} {
+		if _, err := parseProxyArgs(args); err == nil {
+			t.Errorf("parseProxyArgs(%v) should fail", args)
+		}
+	}
+}
+
+func TestRunProxyCommand_UnknownFlagIsUsageError(t *testing.T) {
+	origArgs := os.Args
+	t.Cleanup(func() { os.Args = origArgs })
+	os.Args = []string{"onwatch", "proxy", "--lisen", "127.0.0.1:39556"}
+
+	err := runProxyCommand()
+	var exitErr *exitCodeError
+	if !errors.As(err, &exitErr) || exitErr.code != proxyExitUsage {
+		t.Fatalf("runProxyCommand() = %v, want exit code %d", err, proxyExitUsage)
+	}
+	if !strings.Contains(err.Error(), `unknown flag "--lisen"`) {
+		t.Errorf("error = %q, want it to name the unknown flag", err)
+	}
+}